@@ -5,16 +5,20 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"os"
 	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	f "github.com/kubex-ecosystem/gobe/factory"
 	"github.com/kubex-ecosystem/gobe/internal/config"
 	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
 	"github.com/kubex-ecosystem/gobe/internal/services/llm"
+	"github.com/kubex-ecosystem/gobe/internal/services/mcp"
 	"github.com/spf13/cobra"
+	"golang.org/x/sys/unix"
 )
 
 var (
@@ -32,6 +36,8 @@ var (
 	}
 
 	mcpServerPort           string
+	mcpServerTransport      string
+	mcpServerToolsDir       string
 	mcpServerBind           string
 	mcpServerLogFile        string
 	mcpServerConfigFile     string
@@ -51,6 +57,8 @@ var (
 
 func init() {
 	mcpServerCmd.Flags().StringVarP(&mcpServerPort, "port", "p", "8080", "Port for the MCP server")
+	mcpServerCmd.Flags().StringVarP(&mcpServerTransport, "transport", "t", mcp.TransportHTTP, "MCP transport: stdio, or http (SSE and streamable HTTP on the gin router)")
+	mcpServerCmd.Flags().StringVar(&mcpServerToolsDir, "tools-dir", "", "Directory of MCP tool manifests (overrides tools_dir from the config)")
 	mcpServerCmd.Flags().StringVarP(&mcpServerBind, "bind", "b", "0.0.0.0", "Bind address for the MCP server")
 	mcpServerCmd.Flags().StringVarP(&mcpServerLogFile, "log-file", "l", "mcp_server.log", "Log file for the MCP server")
 	mcpServerCmd.Flags().StringVarP(&mcpServerConfigFile, "config-file", "c", "mcp_server.yaml", "Config file for the MCP server")
@@ -65,11 +73,23 @@ func MCPServerCmd() *cobra.Command {
 	mcpServerCmd.AddCommand(generateTextCmd())
 	mcpServerCmd.AddCommand(analyzeTextCmd())
 	mcpServerCmd.AddCommand(summarizeTextCmd())
+	mcpServerCmd.AddCommand(mcpToolsCmd())
 
 	return mcpServerCmd
 }
 
 func startMCPServer() {
+	// stdio must own the real stdout before anything gets a chance to log
+	var protocolOut *os.File
+	if mcpServerTransport == mcp.TransportStdio {
+		out, err := detachStdout()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error preparing stdio transport: %s\n", err)
+			os.Exit(1)
+		}
+		protocolOut = out
+	}
+
 	initArgs := gl.InitArgs{
 		ConfigFile:     mcpServerConfigFile,
 		ConfigType:     "yaml",
@@ -77,63 +97,126 @@ func startMCPServer() {
 		Debug:          mcpServerDebug,
 		ReleaseMode:    mcpServerReleaseMode,
 		IsConfidential: mcpServerIsConfidential,
+		Port:           mcpServerPort,
+		Bind:           mcpServerBind,
 	}
 
 	cfg, err := config.Load[*config.MCPServerConfig](initArgs)
 	if err != nil {
-		fmt.Printf("Error loading config: %s\n", err)
-		os.Exit(1)
+		// Editors spawn the stdio server without a config file; run with tool defaults
+		if mcpServerTransport != mcp.TransportStdio {
+			fmt.Fprintf(os.Stderr, "Error loading config: %s\n", err)
+			os.Exit(1)
+		}
+		gl.Log("warn", fmt.Sprintf("Error loading config, using defaults: %s", err))
+		cfg = &config.MCPServerConfig{}
 	}
 
 	gl.Log("notice", "Starting MCP Server...")
-	gl.Log("Configuration: %+v", cfg)
+	gl.Log("debug", fmt.Sprintf("Configuration: transport=%s address=%s port=%d tools_dir=%q job_ttl_minutes=%d policy_bindings=%d policy_rules=%d dev_mode=%t",
+		mcpServerTransport, cfg.Address, cfg.Port, cfg.ToolsDir, cfg.JobTTLMinutes, len(cfg.Policy.Bindings), len(cfg.Policy.Rules), cfg.DevMode))
+
+	switch mcpServerTransport {
+	case mcp.TransportStdio:
+		if err := serveMCPStdio(cfg, protocolOut); err != nil {
+			gl.Log("error", fmt.Sprintf("MCP stdio server stopped: %v", err))
+			os.Exit(1)
+		}
+	case mcp.TransportHTTP:
+		serveMCPHTTP(initArgs)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown transport %q (expected %s or %s)\n", mcpServerTransport, mcp.TransportStdio, mcp.TransportHTTP)
+		os.Exit(1)
+	}
+}
 
-	// Initialize GoBE backend
+// serveMCPStdio publishes the tool registry over stdin/stdout, for editors
+// and agents that spawn `gobe mcp-server --transport stdio` as a subprocess.
+func serveMCPStdio(cfg *config.MCPServerConfig, out *os.File) error {
+	registry := mcp.NewRegistry()
+	if err := mcp.RegisterBuiltinTools(registry); err != nil {
+		return fmt.Errorf("failed to register built-in tools: %w", err)
+	}
 
+	server, err := mcp.NewServer(nil)
+	if err != nil {
+		return fmt.Errorf("failed to create MCP server: %w", err)
+	}
+
+	policy := mcp.NewPolicy(cfg.Policy)
+	registry.SetPolicy(policy)
+	server.SetPolicy(policy)
+	server.RegisterRegistryTools(registry)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	toolsDir := mcpServerToolsDir
+	if toolsDir == "" {
+		toolsDir = cfg.ToolsDir
+	}
+	if toolsDir != "" {
+		loader := mcp.NewManifestLoader(os.ExpandEnv(toolsDir), registry, mcp.ManifestBackends{})
+		loader.OnChange(func(removed []string) {
+			server.SyncRegistryTools(registry, removed)
+		})
+		if _, err := loader.Load(); err != nil {
+			gl.Log("warn", "Some MCP tool manifests were rejected", err)
+		}
+		go func() {
+			if err := loader.Watch(ctx); err != nil {
+				gl.Log("error", "MCP tool manifest watcher stopped", err)
+			}
+		}()
+	}
+
+	gl.Log("info", "MCP Server listening on stdio")
+	err = server.ServeStdio(ctx, os.Stdin, out)
+	if err != nil && ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// serveMCPHTTP starts the full GoBE stack; the MCP SSE and streamable HTTP
+// transports are mounted on its gin router under /mcp.
+func serveMCPHTTP(initArgs gl.InitArgs) {
 	goBe, err := f.NewGoBE(initArgs)
 	if err != nil {
 		fmt.Printf("Error initializing GoBE: %s\n", err)
 		os.Exit(1)
 	}
 
-	// dbService, err := f.GetDatabaseService(goBe)
-	// if err != nil {
-	// 	fmt.Printf("Error getting database service: %s\n", err)
-	// 	os.Exit(1)
-	// }
-
-	// if dbService != nil {
-	// 	err = dbService.Connect()
-	// 	if err != nil {
-	// 		fmt.Printf("Error connecting to database: %s\n", err)
-	// 		os.Exit(1)
-	// 	}
-	// 	defer dbService.Disconnect()
-	// }
-
-	// err = goBe.Start()
-	// if err != nil {
-	// 	fmt.Printf("Error starting GoBE: %s\n", err)
-	// 	os.Exit(1)
-	// }
-
-	if err := goBe.InitializeResources(); err != nil {
-		fmt.Printf("Error initializing resources: %s\n", err)
-		os.Exit(1)
-	}
+	go goBe.StartGoBE()
 
 	// Start consuming messages from RabbitMQ in a separate goroutine
 	go f.ConsumeMessages("mcp_queue")
 
-	// Keep the main goroutine running
+	gl.Log("info", fmt.Sprintf("MCP Server available at http://%s%s (SSE at %s/sse)", net.JoinHostPort(mcpServerBind, mcpServerPort), mcp.DefaultHTTPBasePath, mcp.DefaultHTTPBasePath))
+
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 	<-quit
 
-	fmt.Println("MCP Server is running...")
-	select {}
+	goBe.StopGoBE()
+}
+
+// detachStdout reserves the process stdout for the MCP stdio protocol and
+// points fd 1 at stderr, so log lines written by any package cannot corrupt
+// the JSON-RPC stream.
+func detachStdout() (*os.File, error) {
+	fd, err := unix.Dup(int(os.Stdout.Fd()))
+	if err != nil {
+		return nil, err
+	}
+	if err := unix.Dup2(int(os.Stderr.Fd()), int(os.Stdout.Fd())); err != nil {
+		_ = unix.Close(fd)
+		return nil, err
+	}
+	return os.NewFile(uintptr(fd), "mcp-stdout"), nil
 }
+
 func llmCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "llm",
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kubex-ecosystem/gobe/internal/services/mcp"
+	"github.com/spf13/cobra"
+)
+
+func mcpToolsCmd() *cobra.Command {
+	shortDesc := "Manage declarative MCP tools"
+	longDesc := `Manage the MCP tools declared as YAML/JSON manifests (see "tools_dir" in the MCP config).`
+
+	cmd := &cobra.Command{
+		Use:         "tools",
+		Short:       shortDesc,
+		Long:        longDesc,
+		Annotations: GetDescriptions([]string{shortDesc, longDesc}, (os.Getenv("GOBE_HIDEBANNER") == "true")),
+	}
+	cmd.AddCommand(mcpToolsValidateCmd())
+	return cmd
+}
+
+func mcpToolsValidateCmd() *cobra.Command {
+	shortDesc := "Validate a directory of MCP tool manifests"
+	longDesc := `Parse every manifest in <dir>, compile its schemas and templates, and check
+for duplicate tool names. Nothing is registered or executed.`
+
+	return &cobra.Command{
+		Use:         "validate <dir>",
+		Short:       shortDesc,
+		Long:        longDesc,
+		Aliases:     []string{"check", "lint"},
+		Annotations: GetDescriptions([]string{shortDesc, longDesc}, (os.Getenv("GOBE_HIDEBANNER") == "true")),
+		Args:        cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			reports, err := mcp.ValidateManifests(args[0])
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			failed := 0
+			tools := 0
+			for _, report := range reports {
+				if report.Err != nil {
+					failed++
+					fmt.Fprintf(out, "✗ %v\n", report.Err)
+					continue
+				}
+				tools += len(report.Tools)
+				fmt.Fprintf(out, "✓ %s: %s\n", filepath.Base(report.File), strings.Join(report.Tools, ", "))
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d of %d manifest files are invalid", failed, len(reports))
+			}
+			fmt.Fprintf(out, "%d tools in %d files are valid\n", tools, len(reports))
+			return nil
+		},
+	}
+}
@@ -1,12 +1,15 @@
 package main
 
 import (
+	"github.com/kubex-ecosystem/gobe/internal/app/security/execsafe"
 	"github.com/kubex-ecosystem/gobe/internal/module"
 	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
 )
 
 // main initializes the logger and creates a new GoBE instance.
 func main() {
+	// isolated execsafe commands re-enter this binary as their sandbox trampoline
+	execsafe.RunSandbox()
 	if err := module.RegX().Command().Execute(); err != nil {
 		gl.Log("fatal", err.Error())
 	}
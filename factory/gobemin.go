@@ -2,15 +2,17 @@
 package factory
 
 import (
+	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	gb "github.com/kubex-ecosystem/gobe"
 	s "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
+	"github.com/kubex-ecosystem/gobe/internal/config"
 	ci "github.com/kubex-ecosystem/gobe/internal/contracts/interfaces"
 	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
 	msg "github.com/kubex-ecosystem/gobe/internal/sockets/messagery"
-	amqp "github.com/rabbitmq/amqp091-go"
 )
 
 type GoBE interface {
@@ -24,10 +26,6 @@ var (
 )
 
 func NewGoBE(args gl.InitArgs) (ci.IGoBE, error) {
-	err := initRabbitMQ()
-	if err != nil {
-		return nil, err
-	}
 	goBe, err := gb.NewGoBE(args, gl.GetLogger("GoBE"))
 	if err != nil {
 		return nil, err
@@ -43,70 +41,59 @@ func NewGoBE(args gl.InitArgs) (ci.IGoBE, error) {
 	return goBe, nil
 }
 
-var rabbitMQConn *amqp.Connection
+var (
+	brokerMu      sync.Mutex
+	factoryBroker msg.Broker
+)
 
-func initRabbitMQ() error {
-	var err error
-	url := msg.GetRabbitMQURL(dbConfig)
-	if url != "" {
-		rabbitMQConn, err = amqp.Dial(url)
-		if err != nil {
-			gl.Log("error", fmt.Sprintf("Erro ao conectar ao RabbitMQ: %s", err))
-			return err
-		}
-		if rabbitMQConn == nil {
-			return fmt.Errorf("RabbitMQ connection is not initialized")
-		}
-		gl.Log("info", "Conexão com RabbitMQ estabelecida com sucesso.")
+// broker returns the message broker of the factory helpers, started on
+// first use: RabbitMQ if the database config enables it and the in-process
+// broker otherwise.
+func broker() (msg.Broker, error) {
+	brokerMu.Lock()
+	defer brokerMu.Unlock()
+	if factoryBroker != nil {
+		return factoryBroker, nil
 	}
-	return nil
-}
-
-func ConsumeMessages(queueName string) {
-	url := msg.GetRabbitMQURL(dbConfig)
-	if url == "" {
-		gl.Log("error", "RabbitMQ URL is not configured")
-		return
+	var cfg config.BrokerConfig
+	if dbConfig != nil && dbConfig.Messagery != nil && dbConfig.Messagery.RabbitMQ != nil && dbConfig.Messagery.RabbitMQ.Enabled {
+		cfg.Kind = msg.BrokerAMQP
 	}
-	conn, err := amqp.Dial(url)
+	b, err := msg.NewBroker(cfg, dbConfig)
 	if err != nil {
-		gl.Log("error", fmt.Sprintf("Erro ao conectar ao RabbitMQ: %s", err))
-		return
+		return nil, err
 	}
-	defer conn.Close()
+	if err := b.Start(context.Background()); err != nil {
+		return nil, err
+	}
+	factoryBroker = b
+	return b, nil
+}
 
-	ch, err := conn.Channel()
+// ConsumeMessages logs the messages of queueName until the process exits.
+// They are acknowledged, retried and dead-lettered by a messagery.Consumer,
+// which also consumes again after the connection is lost.
+func ConsumeMessages(queueName string) {
+	b, err := broker()
 	if err != nil {
-		gl.Log("error", fmt.Sprintf("Erro ao abrir um canal: %s", err))
+		gl.Log("error", fmt.Sprintf("Erro ao iniciar o broker de mensagens: %s", err))
 		return
 	}
-	defer ch.Close()
-
-	msgs, err := ch.Consume(
-		queueName,
-		"",
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
+	consumer := msg.NewConsumer(b)
+	err = consumer.Handle(queueName, msg.DefaultConsumerOptions, func(ctx context.Context, d msg.Delivery) error {
+		gl.Log("debug", fmt.Sprintf("Mensagem recebida: %s", d.Body))
+		return nil
+	})
+	if err == nil {
+		err = consumer.Start()
+	}
 	if err != nil {
 		gl.Log("error", fmt.Sprintf("Erro ao registrar um consumidor: %s", err))
 		return
 	}
 
-	forever := make(chan bool)
-
-	go func() {
-		for d := range msgs {
-			gl.Log("debug", fmt.Sprintf("Mensagem recebida: %s", d.Body))
-			// Processar a mensagem aqui
-		}
-	}()
-
 	gl.Log("debug", fmt.Sprintf("Aguardando mensagens na fila %s. Para sair pressione CTRL+C", queueName))
-	<-forever
+	select {}
 }
 
 func retry(attempts int, sleep time.Duration, fn func() error) error {
@@ -127,37 +114,17 @@ func PublishMessageWithRetry(queueName string, message string) error {
 	})
 }
 
+// PublishMessage sends message to queueName through the message broker.
 func PublishMessage(queueName, message string) error {
-	url := msg.GetRabbitMQURL(dbConfig)
-	if url == "" {
-		gl.Log("error", "RabbitMQ URL is not configured")
-		return fmt.Errorf("RabbitMQ URL is not configured")
-	}
-	conn, err := amqp.Dial(url)
+	b, err := broker()
 	if err != nil {
-		gl.Log("error", fmt.Sprintf("Erro ao conectar ao RabbitMQ: %s", err))
+		gl.Log("error", fmt.Sprintf("Erro ao iniciar o broker de mensagens: %s", err))
 		return err
 	}
-	defer conn.Close()
-
-	ch, err := conn.Channel()
-	if err != nil {
-		gl.Log("error", fmt.Sprintf("Erro ao abrir um canal: %s", err))
-		return err
+	if !b.IsReady() {
+		return fmt.Errorf("%s broker is not ready", b.Kind())
 	}
-	defer ch.Close()
-
-	err = ch.Publish(
-		"",
-		queueName,
-		false,
-		false,
-		amqp.Publishing{
-			ContentType: "text/plain",
-			Body:        []byte(message),
-		},
-	)
-	if err != nil {
+	if err := b.Publish("", queueName, []byte(message)); err != nil {
 		gl.Log("error", fmt.Sprintf("Erro ao publicar mensagem: %s", err))
 		return err
 	}
@@ -4,14 +4,15 @@ package discord
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"gorm.io/gorm"
 
@@ -20,6 +21,8 @@ import (
 	"github.com/kubex-ecosystem/gobe/internal/observers/events"
 	"github.com/kubex-ecosystem/gobe/internal/proxy/hub"
 	"github.com/kubex-ecosystem/gobe/internal/services/chatbot/discord"
+	"github.com/kubex-ecosystem/gobe/internal/services/mcp"
+	"github.com/kubex-ecosystem/gobe/internal/services/webhooks/verify"
 
 	fscm "github.com/kubex-ecosystem/gdbase/factory/models"
 	t "github.com/kubex-ecosystem/gobe/internal/contracts/types"
@@ -37,6 +40,7 @@ var (
 type HubInterface interface {
 	GetEventStream() *events.Stream
 	GetApprovalManager() *approval.Manager
+	ApproverFor(principal *mcp.Principal) approval.Approver
 	ProcessMessageWithLLM(ctx context.Context, msg interface{}) error
 }
 
@@ -46,6 +50,9 @@ type DiscordController struct {
 	config         *config.Config
 	hub            HubInterface
 	upgrader       websocket.Upgrader
+	// interactions verifica as assinaturas Ed25519 das interações; nil
+	// quando nenhuma chave pública foi configurada.
+	interactions *verify.Registry
 }
 
 type (
@@ -80,13 +87,25 @@ type DiscordOAuthAuthorizeResponse struct {
 
 // DiscordApprovalList lista solicitações pendentes.
 type DiscordApprovalList struct {
-	Approvals []interface{} `json:"approvals"`
+	Approvals []*approval.Request `json:"approvals"`
+}
+
+// DiscordApprovalDetail traz a solicitação e sua trilha de auditoria.
+type DiscordApprovalDetail struct {
+	Request *approval.Request   `json:"request"`
+	History []approval.Decision `json:"history"`
+}
+
+// DiscordApprovalDecision acompanha a aprovação ou rejeição com um comentário.
+type DiscordApprovalDecision struct {
+	Comment string `json:"comment,omitempty"`
 }
 
 // DiscordActionResponse descreve respostas de aprovação/rejeição.
 type DiscordActionResponse struct {
-	Message   string `json:"message"`
-	RequestID string `json:"request_id,omitempty"`
+	Message   string             `json:"message"`
+	RequestID string             `json:"request_id,omitempty"`
+	Approval  *approval.Response `json:"approval,omitempty"`
 }
 
 // DiscordTestMessageRequest representa o payload de teste.
@@ -121,6 +140,7 @@ type DiscordInteractionResponse struct {
 
 func NewDiscordController(db *gorm.DB, hub *hub.DiscordMCPHub, config *config.Config) *DiscordController {
 	return &DiscordController{
+		interactions:   interactionVerifiers(config),
 		discordService: fscm.NewDiscordService(fscm.NewDiscordRepo(db)),
 		APIWrapper:     t.NewAPIWrapper[fscm.DiscordModel](),
 		hub:            hub,
@@ -356,6 +376,12 @@ func (dc *DiscordController) HandleWebSocket(c *gin.Context) {
 		return
 	}
 
+	client, err := events.ClientFromRequest(c.Request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+
 	conn, err := dc.upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		gl.Log("error", fmt.Sprintf("❌ WebSocket upgrade error: %v", err))
@@ -364,12 +390,6 @@ func (dc *DiscordController) HandleWebSocket(c *gin.Context) {
 	}
 	defer conn.Close()
 
-	client := &events.Client{
-		ID:   uuid.New().String(),
-		Conn: conn,
-		Send: make(chan events.Event, 256),
-	}
-
 	// Verificar se o hub está disponível
 	if dc.hub == nil {
 		gl.Log("error", "❌ Discord hub is not initialized")
@@ -384,125 +404,157 @@ func (dc *DiscordController) HandleWebSocket(c *gin.Context) {
 		return
 	}
 
-	eventStream.RegisterClient(client)
 	gl.Log("info", fmt.Sprintf("✅ WebSocket client connected: %s", client.ID))
+	eventStream.ServeWebSocket(conn, client)
+}
 
-	// Enviar mensagem de confirmação
-	welcomeMsg := map[string]interface{}{
-		"type":      "connection",
-		"status":    "connected",
-		"client_id": client.ID,
-		"message":   "WebSocket connected successfully",
-		"timestamp": time.Now().Unix(),
+// HandleEventStream transmite os eventos do hub via Server-Sent Events.
+//
+// @Summary     Stream de eventos (SSE)
+// @Description Variante SSE do WebSocket de eventos. `topics` filtra os tipos de evento com padrões glob separados por vírgula (`approval_*,webhook.*`); `last_event_id` (ou o header `Last-Event-ID`) retoma a partir do último evento recebido. [Em desenvolvimento]
+// @Tags        discord beta
+// @Produce     text/event-stream
+// @Param       topics        query string false "Padrões de tipo de evento"
+// @Param       last_event_id query int    false "Último evento recebido"
+// @Success     200 {string} string "event stream"
+// @Failure     400 {object} ErrorResponse
+// @Failure     503 {object} ErrorResponse
+// @Router      /api/v1/discord/events [get]
+func (dc *DiscordController) HandleEventStream(c *gin.Context) {
+	eventStream := dc.eventStream(c)
+	if eventStream == nil {
+		return
 	}
-
-	if msgBytes, err := json.Marshal(welcomeMsg); err == nil {
-		conn.WriteMessage(websocket.TextMessage, msgBytes)
+	client, err := events.ClientFromRequest(c.Request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Status: "error", Message: err.Error()})
+		return
 	}
+	gl.Log("info", fmt.Sprintf("✅ SSE client connected: %s", client.ID))
+	eventStream.ServeSSE(c.Writer, c.Request, client)
+}
 
-	// ✅ Goroutine para enviar mensagens do canal
-	go func() {
-		defer eventStream.UnregisterClient(client)
-		for {
-			for event := range client.Send {
-				if msgBytes, err := json.Marshal(event); err == nil {
-					conn.WriteMessage(websocket.TextMessage, msgBytes)
-				}
-			}
-		}
-	}()
-
-	// Loop para manter a conexão viva e processar mensagens
-	for {
-		messageType, message, err := conn.ReadMessage()
-		if err != nil {
-			gl.Log("info", fmt.Sprintf("WebSocket client %s disconnected: %v", client.ID, err))
-			break
-		}
+// EventStreamStats retorna o estado do stream de eventos.
+//
+// @Summary     Estatísticas do stream de eventos
+// @Description Retorna o último ID de evento, o tamanho do histórico e, por cliente conectado, tópicos, eventos entregues, descartados e enfileirados. [Em desenvolvimento]
+// @Tags        discord beta
+// @Produce     json
+// @Success     200 {object} events.StreamStats
+// @Failure     503 {object} ErrorResponse
+// @Router      /api/v1/discord/events/stats [get]
+func (dc *DiscordController) EventStreamStats(c *gin.Context) {
+	eventStream := dc.eventStream(c)
+	if eventStream == nil {
+		return
+	}
+	c.JSON(http.StatusOK, eventStream.Stats())
+}
 
-		if messageType == websocket.TextMessage {
-			gl.Log("info", fmt.Sprintf("📨 WebSocket message from %s: %s", client.ID, string(message)))
-
-			// ✅ Processar mensagem recebida
-			var msgData map[string]interface{}
-			if err := json.Unmarshal(message, &msgData); err == nil {
-				if msgType, ok := msgData["type"].(string); ok {
-					switch msgType {
-					case "ping":
-						response := map[string]interface{}{
-							"type":      "pong",
-							"timestamp": time.Now().Unix(),
-						}
-						if respBytes, err := json.Marshal(response); err == nil {
-							conn.WriteMessage(websocket.TextMessage, respBytes)
-						}
-					case "test":
-						response := map[string]interface{}{
-							"type":    "test_response",
-							"message": "WebSocket is working!",
-							"echo":    msgData,
-						}
-						if respBytes, err := json.Marshal(response); err == nil {
-							conn.WriteMessage(websocket.TextMessage, respBytes)
-						}
-					}
-				}
-			}
-		}
+func (dc *DiscordController) eventStream(c *gin.Context) *events.Stream {
+	var eventStream *events.Stream
+	if dc.hub != nil {
+		eventStream = dc.hub.GetEventStream()
+	}
+	if eventStream == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Status: "error", Message: "event stream not available"})
 	}
+	return eventStream
 }
 
 // GetPendingApprovals retorna solicitações aguardando aprovação manual.
 //
 // @Summary     Listar aprovações pendentes
-// @Description Retorna solicitações aguardando aprovação manual. [Em desenvolvimento]
+// @Description Retorna solicitações aguardando aprovação, com a política, os votos já registrados e o prazo de cada uma. [Em desenvolvimento]
 // @Tags        discord beta
 // @Produce     json
 // @Success     200 {object} DiscordApprovalList
+// @Failure     503 {object} ErrorResponse
 // @Router      /api/v1/discord/approvals [post]
 // @Router      /api/v1/discord/interactions/pending [post]
 func (dc *DiscordController) GetPendingApprovals(c *gin.Context) {
-	// This would need to be implemented based on your approval manager interface
-	c.JSON(http.StatusOK, DiscordApprovalList{Approvals: []interface{}{}})
+	manager := dc.approvalManager(c)
+	if manager == nil {
+		return
+	}
+	c.JSON(http.StatusOK, DiscordApprovalList{Approvals: manager.GetPendingApprovals()})
+}
+
+// GetApproval retorna uma solicitação e sua trilha de auditoria.
+//
+// @Summary     Detalhar aprovação
+// @Description Retorna a solicitação, pendente ou decidida, e cada decisão registrada: pedido, votos, escalonamento e resultado. [Em desenvolvimento]
+// @Tags        discord beta
+// @Produce     json
+// @Param       id path string true "ID da solicitação"
+// @Success     200 {object} DiscordApprovalDetail
+// @Failure     404 {object} ErrorResponse
+// @Failure     503 {object} ErrorResponse
+// @Router      /api/v1/discord/approvals/{id} [get]
+func (dc *DiscordController) GetApproval(c *gin.Context) {
+	manager := dc.approvalManager(c)
+	if manager == nil {
+		return
+	}
+	ctx := c.Request.Context()
+	req, err := manager.GetRequest(ctx, c.Param("id"))
+	if err != nil {
+		writeApprovalError(c, err)
+		return
+	}
+	history, err := manager.History(ctx, req.ID)
+	if err != nil {
+		writeApprovalError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, DiscordApprovalDetail{Request: req, History: history})
 }
 
 // ApproveRequest confirma manualmente uma solicitação pendente.
 //
 // @Summary     Aprovar solicitação Discord
-// @Description Confirma solicitações pendentes para liberar ações no hub. [Em desenvolvimento]
+// @Description Registra o voto do usuário autenticado. A solicitação é aprovada quando o quórum da política é atingido; até lá a resposta a mantém pendente. [Em desenvolvimento]
 // @Tags        discord beta
+// @Accept      json
 // @Produce     json
-// @Param       id query string false "ID da solicitação"
+// @Security    BearerAuth
+// @Param       id query string true "ID da solicitação"
+// @Param       payload body DiscordApprovalDecision false "Comentário da decisão"
 // @Success     200 {object} DiscordActionResponse
 // @Failure     400 {object} ErrorResponse
+// @Failure     401 {object} ErrorResponse
+// @Failure     403 {object} ErrorResponse
+// @Failure     404 {object} ErrorResponse
+// @Failure     409 {object} ErrorResponse
+// @Failure     410 {object} ErrorResponse
 // @Router      /api/v1/discord/approve [post]
 func (dc *DiscordController) ApproveRequest(c *gin.Context) {
-	requestID := c.Param("id")
-	if requestID == "" {
-		requestID = c.Query("id")
-	}
-	if requestID == "" {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Status: "error", Message: "missing request id"})
-		return
-	}
-
-	// Mock approval - implement with your approval manager
-	gl.Log("info", fmt.Sprintf("Approving request: %s", requestID))
-
-	c.JSON(http.StatusOK, DiscordActionResponse{Message: "Request approved", RequestID: requestID})
+	dc.decideApproval(c, true)
 }
 
 // RejectRequest registra a rejeição de uma solicitação pendente.
 //
 // @Summary     Rejeitar solicitação Discord
-// @Description Marca a solicitação como rejeitada no fluxo de aprovação. [Em desenvolvimento]
+// @Description Registra a rejeição do usuário autenticado; uma única rejeição encerra a solicitação. [Em desenvolvimento]
 // @Tags        discord beta
+// @Accept      json
 // @Produce     json
-// @Param       id query string false "ID da solicitação"
+// @Security    BearerAuth
+// @Param       id query string true "ID da solicitação"
+// @Param       payload body DiscordApprovalDecision false "Comentário da decisão"
 // @Success     200 {object} DiscordActionResponse
 // @Failure     400 {object} ErrorResponse
+// @Failure     401 {object} ErrorResponse
+// @Failure     403 {object} ErrorResponse
+// @Failure     404 {object} ErrorResponse
+// @Failure     409 {object} ErrorResponse
+// @Failure     410 {object} ErrorResponse
 // @Router      /api/v1/discord/reject [post]
 func (dc *DiscordController) RejectRequest(c *gin.Context) {
+	dc.decideApproval(c, false)
+}
+
+func (dc *DiscordController) decideApproval(c *gin.Context, approved bool) {
 	requestID := c.Param("id")
 	if requestID == "" {
 		requestID = c.Query("id")
@@ -511,11 +563,71 @@ func (dc *DiscordController) RejectRequest(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Status: "error", Message: "missing request id"})
 		return
 	}
+	var body DiscordApprovalDecision
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Status: "error", Message: "invalid JSON"})
+			return
+		}
+	}
 
-	// Mock rejection - implement with your approval manager
-	gl.Log("info", fmt.Sprintf("Rejecting request: %s", requestID))
+	manager := dc.approvalManager(c)
+	if manager == nil {
+		return
+	}
+	principal := mcp.PrincipalFromContext(c.Request.Context())
+	if principal == nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Status: "error", Message: "approver not authenticated"})
+		return
+	}
+	approver := dc.hub.ApproverFor(principal)
+	if approver.ID == "" {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Status: "error", Message: "approver not authenticated"})
+		return
+	}
 
-	c.JSON(http.StatusOK, DiscordActionResponse{Message: "Request rejected", RequestID: requestID})
+	resp, err := manager.Decide(c.Request.Context(), requestID, approved, approver, body.Comment)
+	if err != nil {
+		writeApprovalError(c, err)
+		return
+	}
+	gl.Log("info", fmt.Sprintf("Approval %s voted by %s: %s", requestID, approver.ID, resp.Status))
+
+	message := "Vote recorded"
+	switch resp.Status {
+	case approval.StatusApproved:
+		message = "Request approved"
+	case approval.StatusRejected:
+		message = "Request rejected"
+	}
+	c.JSON(http.StatusOK, DiscordActionResponse{Message: message, RequestID: requestID, Approval: resp})
+}
+
+// approvalManager writes 503 and returns nil when the hub has none.
+func (dc *DiscordController) approvalManager(c *gin.Context) *approval.Manager {
+	var manager *approval.Manager
+	if dc.hub != nil {
+		manager = dc.hub.GetApprovalManager()
+	}
+	if manager == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Status: "error", Message: "approval manager not available"})
+	}
+	return manager
+}
+
+func writeApprovalError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, approval.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, approval.ErrNotEligible):
+		status = http.StatusForbidden
+	case errors.Is(err, approval.ErrAlreadyVoted), errors.Is(err, approval.ErrNotPending):
+		status = http.StatusConflict
+	case errors.Is(err, approval.ErrExpired):
+		status = http.StatusGone
+	}
+	c.JSON(status, ErrorResponse{Status: "error", Message: err.Error()})
 }
 
 // HandleTestMessage injeta mensagens de teste no hub Discord.
@@ -665,25 +777,18 @@ func (dc *DiscordController) HandleDiscordWebhook(c *gin.Context) {
 // HandleDiscordInteractions processa interações recebidas do Discord.
 //
 // @Summary     Processar interação Discord
-// @Description Responde PINGs e interações de componentes enviados pelo Discord. [Em desenvolvimento]
+// @Description Verifica a assinatura Ed25519 (X-Signature-Ed25519) e responde PINGs e interações de componentes enviados pelo Discord. [Em desenvolvimento]
 // @Tags        discord beta
 // @Accept      json
 // @Produce     json
 // @Param       payload body DiscordInteractionEvent true "Interação recebida"
 // @Success     200 {object} DiscordInteractionResponse
 // @Failure     400 {object} ErrorResponse
+// @Failure     401 {object} ErrorResponse
 // @Router      /api/v1/discord/interactions [post]
 func (dc *DiscordController) HandleDiscordInteractions(c *gin.Context) {
 	gl.Log("info", "⚡ Discord interaction received")
 
-	// Verify Discord signature (important for security)
-	signature := c.GetHeader("X-Signature-Ed25519")
-	timestamp := c.GetHeader("X-Signature-Timestamp")
-
-	gl.Log("info", "📋 Headers:")
-	gl.Log("info", fmt.Sprintf("  X-Signature-Ed25519: %s", signature))
-	gl.Log("info", fmt.Sprintf("  X-Signature-Timestamp: %s", timestamp))
-
 	// Read body
 	body, err := io.ReadAll(c.Request.Body)
 	if err != nil {
@@ -692,6 +797,18 @@ func (dc *DiscordController) HandleDiscordInteractions(c *gin.Context) {
 		return
 	}
 
+	// Verify Discord signature (important for security): Discord expects a
+	// 401 for invalid signatures
+	if dc.interactions != nil {
+		if _, err := dc.interactions.Verify("discord", c.Request.Header, body); err != nil {
+			gl.Log("warn", fmt.Sprintf("🚫 Discord interaction rejected: %v", err))
+			c.JSON(http.StatusUnauthorized, ErrorResponse{Status: "error", Message: "invalid request signature"})
+			return
+		}
+	} else {
+		gl.Log("warn", "Discord public key not configured, interaction signature not verified")
+	}
+
 	// Parse interaction
 	var interaction map[string]interface{}
 	if err := json.Unmarshal(body, &interaction); err != nil {
@@ -713,6 +830,14 @@ func (dc *DiscordController) HandleDiscordInteractions(c *gin.Context) {
 		return
 	}
 
+	// Approve/reject buttons of approval requests
+	if interactionType, ok := interaction["type"].(float64); ok && interactionType == 3 {
+		if response, handled := dc.handleApprovalButton(c.Request.Context(), interaction); handled {
+			c.JSON(http.StatusOK, response)
+			return
+		}
+	}
+
 	// Handle other interactions
 	c.JSON(http.StatusOK, DiscordInteractionResponse{
 		Type: 4, // CHANNEL_MESSAGE_WITH_SOURCE
@@ -722,6 +847,95 @@ func (dc *DiscordController) HandleDiscordInteractions(c *gin.Context) {
 	})
 }
 
+// handleApprovalButton registra o voto de quem clicou em um botão de
+// aprovação; exige interações verificadas. O sucesso só é confirmado (tipo 6): a mensagem é editada pelo
+// apresentador do hub; recusas são respondidas apenas a quem clicou.
+func (dc *DiscordController) handleApprovalButton(ctx context.Context, interaction map[string]interface{}) (DiscordInteractionResponse, bool) {
+	data, _ := interaction["data"].(map[string]interface{})
+	customID, _ := data["custom_id"].(string)
+	requestID, approve, ok := approval.ParseCallbackData(customID)
+	if !ok {
+		return DiscordInteractionResponse{}, false
+	}
+
+	ephemeral := func(content string) (DiscordInteractionResponse, bool) {
+		return DiscordInteractionResponse{
+			Type: 4, // CHANNEL_MESSAGE_WITH_SOURCE
+			Data: map[string]interface{}{"content": content, "flags": 64},
+		}, true
+	}
+
+	if dc.hub == nil || dc.hub.GetApprovalManager() == nil {
+		return ephemeral("Aprovações indisponíveis no momento")
+	}
+	// Sem verificação de assinatura qualquer um poderia votar em nome de outro
+	if dc.interactions == nil {
+		gl.Log("warn", "Approval button refused: Discord interactions are not verified")
+		return ephemeral("Aprovações pelo Discord exigem a chave pública configurada")
+	}
+	approver := dc.hub.ApproverFor(interactionPrincipal(interaction))
+	if approver.ID == "" {
+		return ephemeral(approval.ChatError(approval.ErrNotEligible))
+	}
+	resp, err := dc.hub.GetApprovalManager().Decide(ctx, requestID, approve, approver, "")
+	if err != nil {
+		gl.Log("warn", fmt.Sprintf("Approval button refused for %s on %s: %v", approver.ID, requestID, err))
+		return ephemeral(approval.ChatError(err))
+	}
+	gl.Log("info", fmt.Sprintf("Approval %s voted by %s from Discord: %s", requestID, approver.ID, resp.Status))
+	return DiscordInteractionResponse{Type: 6}, true // DEFERRED_UPDATE_MESSAGE
+}
+
+// interactionPrincipal identifica quem disparou a interação: o membro em
+// servidores, o usuário em mensagens diretas.
+func interactionPrincipal(interaction map[string]interface{}) *mcp.Principal {
+	user, _ := interaction["user"].(map[string]interface{})
+	if member, ok := interaction["member"].(map[string]interface{}); ok {
+		if memberUser, ok := member["user"].(map[string]interface{}); ok {
+			user = memberUser
+		}
+	}
+	principal := &mcp.Principal{Source: "discord"}
+	principal.ID, _ = user["id"].(string)
+	principal.Username, _ = user["username"].(string)
+	principal.GuildID, _ = interaction["guild_id"].(string)
+	principal.ChannelID, _ = interaction["channel_id"].(string)
+	return principal
+}
+
+// interactionVerifiers monta o verificador das interações a partir da
+// fonte "discord" de webhooks.sources, da chave pública OAuth2 do Discord ou
+// de DISCORD_PUBLIC_KEY, nessa ordem.
+func interactionVerifiers(cfg *config.Config) *verify.Registry {
+	if cfg == nil {
+		return nil
+	}
+	var verifier verify.Verifier
+	var err error
+	if source, ok := cfg.Webhooks.Sources["discord"]; ok && source.Scheme == "discord" {
+		verifier, err = verify.NewVerifier(source)
+	} else {
+		publicKey := cfg.Discord.OAuth2.PublicKey
+		if publicKey == "" {
+			publicKey = os.Getenv("DISCORD_PUBLIC_KEY")
+		}
+		if publicKey == "" {
+			return nil
+		}
+		verifier, err = verify.NewDiscord(publicKey)
+	}
+
+	registry := verify.NewRegistry(time.Duration(cfg.Webhooks.ReplayWindowSeconds) * time.Second)
+	if err != nil {
+		// chave inválida: recusa tudo em vez de aceitar sem verificar
+		gl.Log("error", fmt.Sprintf("Invalid Discord interaction verifier: %v", err))
+		registry.Require(true)
+		return registry
+	}
+	registry.Register("discord", verifier)
+	return registry
+}
+
 func (dc *DiscordController) InitiateBotMCP() {
 	var err error
 	var h *hub.DiscordMCPHub
@@ -868,6 +1082,9 @@ func (dc *DiscordController) GetHubStatus(c *gin.Context) {
 			// Adicione mais detalhes se disponível na interface
 			status["connected_clients"] = "check event stream"
 		}
+		if mcpHub, ok := dc.hub.(*hub.DiscordMCPHub); ok && mcpHub.GetEventBus() != nil {
+			status["event_bus"] = mcpHub.GetEventBus().Stats()
+		}
 	}
 
 	if dc.config != nil {
@@ -2,11 +2,19 @@
 package telegram
 
 import (
+	"context"
+	"crypto/subtle"
+	"fmt"
 	"net/http"
+	"strconv"
+	"sync"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+	"github.com/kubex-ecosystem/gobe/internal/observers/approval"
+	"github.com/kubex-ecosystem/gobe/internal/services/mcp"
 	tg "github.com/kubex-ecosystem/gobe/internal/services/chatbot/telegram"
 	t "github.com/kubex-ecosystem/gobe/internal/contracts/types"
 )
@@ -28,6 +36,30 @@ type SendMessageRequest struct {
 	Text   string `json:"text"`
 }
 
+// ApprovalHub decides approvals on behalf of chat users.
+type ApprovalHub interface {
+	GetApprovalManager() *approval.Manager
+	ApproverFor(principal *mcp.Principal) approval.Approver
+}
+
+var (
+	approvalHubMu sync.RWMutex
+	approvalHub   ApprovalHub
+)
+
+// SetApprovalHub lets inline keyboard clicks decide the approvals of hub.
+func SetApprovalHub(hub ApprovalHub) {
+	approvalHubMu.Lock()
+	defer approvalHubMu.Unlock()
+	approvalHub = hub
+}
+
+func currentApprovalHub() ApprovalHub {
+	approvalHubMu.RLock()
+	defer approvalHubMu.RUnlock()
+	return approvalHub
+}
+
 // NewController creates a new Telegram controller.
 func NewController(db *gorm.DB, service *tg.Service) *Controller {
 	return &Controller{db: db, service: service}
@@ -36,20 +68,33 @@ func NewController(db *gorm.DB, service *tg.Service) *Controller {
 // HandleWebhook processes incoming Telegram updates.
 //
 // @Summary     Webhook Telegram
-// @Description Recebe eventos do Telegram e armazena a mensagem básica. [Em desenvolvimento]
+// @Description Recebe eventos do Telegram e armazena a mensagem básica. Cliques nos botões de aprovação (callback queries) registram o voto de quem clicou. Com `webhook_secret` configurado, exige o header `X-Telegram-Bot-Api-Secret-Token`. [Em desenvolvimento]
 // @Tags        telegram beta
 // @Accept      json
 // @Produce     json
 // @Param       payload body map[string]any true "Atualização do Telegram"
 // @Success     200 {object} map[string]string "acknowledged"
 // @Failure     400 {object} ErrorResponse
+// @Failure     401 {object} ErrorResponse
 // @Router      /api/v1/telegram/webhook [post]
 func (c *Controller) HandleWebhook(ctx *gin.Context) {
+	secret := c.service.Config().WebhookSecret
+	verified := secret != "" && subtle.ConstantTimeCompare([]byte(ctx.GetHeader("X-Telegram-Bot-Api-Secret-Token")), []byte(secret)) == 1
+	if secret != "" && !verified {
+		ctx.JSON(http.StatusUnauthorized, ErrorResponse{Status: "error", Message: "invalid secret token"})
+		return
+	}
+
 	var update map[string]any
 	if err := ctx.ShouldBindJSON(&update); err != nil {
 		ctx.JSON(http.StatusBadRequest, ErrorResponse{Status: "error", Message: err.Error()})
 		return
 	}
+	if query, ok := update["callback_query"].(map[string]any); ok {
+		c.handleCallbackQuery(ctx.Request.Context(), query, verified)
+		ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
+		return
+	}
 	msg := tg.Message{}
 	if m, ok := update["message"].(map[string]any); ok {
 		if from, ok := m["from"].(map[string]any); ok {
@@ -62,6 +107,75 @@ func (c *Controller) HandleWebhook(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
 
+// handleCallbackQuery records the vote of whoever clicked an approval
+// button. The clicker comes from the update itself, so only verified
+// updates may decide.
+func (c *Controller) handleCallbackQuery(ctx context.Context, query map[string]any, verified bool) {
+	data, _ := query["data"].(string)
+	requestID, approve, ok := approval.ParseCallbackData(data)
+	if !ok {
+		return
+	}
+	queryID, _ := query["id"].(string)
+	answer := func(text string) {
+		if err := c.service.AnswerCallbackQuery(queryID, text); err != nil {
+			gl.Log("warn", "Failed to answer Telegram callback query", err)
+		}
+	}
+
+	hub := currentApprovalHub()
+	if hub == nil || hub.GetApprovalManager() == nil {
+		answer("Aprovações indisponíveis no momento")
+		return
+	}
+	if !verified {
+		gl.Log("warn", "Approval button refused: Telegram webhook secret not configured")
+		answer("Aprovações pelo Telegram exigem o webhook_secret configurado")
+		return
+	}
+
+	approver := hub.ApproverFor(callbackPrincipal(query))
+	if approver.ID == "" {
+		answer(approval.ChatError(approval.ErrNotEligible))
+		return
+	}
+	resp, err := hub.GetApprovalManager().Decide(ctx, requestID, approve, approver, "")
+	if err != nil {
+		gl.Log("warn", fmt.Sprintf("Approval button refused for %s on %s: %v", approver.ID, requestID, err))
+		answer(approval.ChatError(err))
+		return
+	}
+	gl.Log("info", fmt.Sprintf("Approval %s voted by %s from Telegram: %s", requestID, approver.ID, resp.Status))
+	switch resp.Status {
+	case approval.StatusApproved:
+		answer("✅ Aprovado")
+	case approval.StatusRejected:
+		answer("🚫 Rejeitado")
+	default:
+		answer(fmt.Sprintf("Voto registrado (%d de %d)", resp.Approvals, resp.Quorum))
+	}
+}
+
+// callbackPrincipal identifies who clicked a button; the chat is the
+// channel MCP bindings match on.
+func callbackPrincipal(query map[string]any) *mcp.Principal {
+	principal := &mcp.Principal{Source: "telegram"}
+	if from, ok := query["from"].(map[string]any); ok {
+		if id, ok := getInt64(from["id"]); ok {
+			principal.ID = strconv.FormatInt(id, 10)
+		}
+		principal.Username, _ = from["username"].(string)
+	}
+	if message, ok := query["message"].(map[string]any); ok {
+		if chat, ok := message["chat"].(map[string]any); ok {
+			if id, ok := getInt64(chat["id"]); ok {
+				principal.ChannelID = strconv.FormatInt(id, 10)
+			}
+		}
+	}
+	return principal
+}
+
 func getInt64(v any) (int64, bool) {
 	switch val := v.(type) {
 	case float64:
@@ -208,6 +208,7 @@ func (ac *AdviseController) buildChatRequest(c *gin.Context, req *AdviceRequest)
 	for k, v := range req.Metadata {
 		meta[k] = v
 	}
+	meta["kind"] = "advise"
 
 	externalKey := strings.TrimSpace(c.GetHeader("x-external-api-key"))
 
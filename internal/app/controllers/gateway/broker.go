@@ -0,0 +1,47 @@
+package gateway
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubex-ecosystem/gobe/internal/sockets/messagery"
+)
+
+// BrokerConsumersResponse describes the message broker and its consumers.
+type BrokerConsumersResponse struct {
+	Broker     string                 `json:"broker"`
+	Connection map[string]interface{} `json:"connection"`
+	Queues     []messagery.QueueStats `json:"queues"`
+}
+
+// BrokerController exposes the counters of the message broker consumers.
+type BrokerController struct {
+	broker   messagery.Broker
+	consumer *messagery.Consumer
+}
+
+func NewBrokerController(broker messagery.Broker, consumer *messagery.Consumer) *BrokerController {
+	return &BrokerController{broker: broker, consumer: consumer}
+}
+
+// Consumers returns the per-queue counters of the broker consumers.
+//
+// @Summary     Consumidores do broker
+// @Description Retorna o tipo do broker de mensagens (`memory`, `amqp` ou `nats`), o estado da sua conexão e, por fila, as mensagens recebidas, concluídas, reenfileiradas para nova tentativa e enviadas à fila de mensagens mortas. [Em desenvolvimento]
+// @Tags        gateway beta
+// @Security    BearerAuth
+// @Produce     json
+// @Success     200 {object} BrokerConsumersResponse
+// @Failure     503 {object} ErrorResponse
+// @Router      /v1/broker/consumers [get]
+func (bc *BrokerController) Consumers(c *gin.Context) {
+	if bc.broker == nil || bc.consumer == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "broker consumers disabled"})
+		return
+	}
+	c.JSON(http.StatusOK, BrokerConsumersResponse{
+		Broker:     bc.broker.Kind(),
+		Connection: bc.broker.ConnectionStats(),
+		Queues:     bc.consumer.Stats(),
+	})
+}
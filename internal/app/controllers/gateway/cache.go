@@ -0,0 +1,40 @@
+package gateway
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	gatewaysvc "github.com/kubex-ecosystem/gobe/internal/services/gateway/registry"
+)
+
+// CacheController exposes the counters of the gateway response cache.
+type CacheController struct {
+	service *gatewaysvc.Service
+}
+
+func NewCacheController(service *gatewaysvc.Service) *CacheController {
+	return &CacheController{service: service}
+}
+
+// Stats returns the hit/miss counters of the response cache.
+//
+// @Summary     Estatísticas do cache
+// @Description Retorna os contadores do cache de respostas do gateway (acertos, faltas, desvios, gravações e erros) e o backend em uso. [Em desenvolvimento]
+// @Tags        gateway beta
+// @Security    BearerAuth
+// @Produce     json
+// @Success     200 {object} CacheStatsResponse
+// @Failure     503 {object} ErrorResponse
+// @Router      /v1/cache/stats [get]
+func (cc *CacheController) Stats(c *gin.Context) {
+	if cc.service == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "gateway service unavailable"})
+		return
+	}
+	stats, ok := cc.service.CacheStats()
+	if !ok {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "response cache disabled"})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
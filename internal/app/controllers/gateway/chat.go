@@ -2,6 +2,7 @@ package gateway
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -11,10 +12,12 @@ import (
 	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
 	gatewayService "github.com/kubex-ecosystem/gobe/internal/services/gateway"
 	gatewaysvc "github.com/kubex-ecosystem/gobe/internal/services/gateway/registry"
+	"github.com/kubex-ecosystem/gobe/internal/services/mcp"
 )
 
 type ChatController struct {
 	service *gatewaysvc.Service
+	tools   mcp.Registry
 }
 
 func NewChatController(service *gatewaysvc.Service) *ChatController {
@@ -24,10 +27,16 @@ func NewChatController(service *gatewaysvc.Service) *ChatController {
 	return &ChatController{service: service}
 }
 
+// SetToolRegistry enables tool calling: requests listing "tools" may let the
+// model call these MCP tools on behalf of the caller.
+func (cc *ChatController) SetToolRegistry(reg mcp.Registry) {
+	cc.tools = reg
+}
+
 // ChatSSE streams provider responses as Server-Sent Events for conversational use cases.
 //
 // @Summary     Chat streaming
-// @Description Dispara uma conversação streaming (`data: {"delta"}`) com o provedor configurado. [Em desenvolvimento]
+// @Description Dispara uma conversação streaming (`data: {"delta"}`) com o provedor configurado. Com `tools`, o modelo pode chamar ferramentas MCP; cada passo emite eventos `tool_call` e `tool_result`. [Em desenvolvimento]
 // @Tags        gateway beta
 // @Security    BearerAuth
 // @Accept      json
@@ -39,6 +48,7 @@ func NewChatController(service *gatewaysvc.Service) *ChatController {
 // @Success     200 {string} string "Fluxo SSE com eventos {\"delta\":string}"
 // @Failure     400 {object} ErrorResponse
 // @Failure     401 {object} ErrorResponse
+// @Failure     502 {object} ErrorResponse
 // @Failure     503 {object} ErrorResponse
 // @Router      /chat [post]
 func (cc *ChatController) ChatSSE(c *gin.Context) {
@@ -63,8 +73,9 @@ func (cc *ChatController) ChatSSE(c *gin.Context) {
 		return
 	}
 
-	if req.Temperature == 0 {
-		req.Temperature = 0.7
+	temperature := float32(0.7)
+	if req.Temperature != nil {
+		temperature = *req.Temperature
 	}
 	if req.Meta == nil {
 		req.Meta = make(map[string]interface{})
@@ -88,7 +99,7 @@ func (cc *ChatController) ChatSSE(c *gin.Context) {
 		Provider:    req.Provider,
 		Model:       req.Model,
 		Messages:    req.Messages,
-		Temperature: req.Temperature,
+		Temperature: temperature,
 		Stream:      true,
 		Meta:        req.Meta,
 		Headers: map[string]string{
@@ -99,9 +110,26 @@ func (cc *ChatController) ChatSSE(c *gin.Context) {
 	}
 
 	ctx := c.Request.Context()
-	stream, config, err := cc.service.Chat(ctx, svcReq)
+	var stream <-chan gatewayService.ChatChunk
+	var config gatewayService.ProviderConfig
+	var err error
+	if len(req.Tools) > 0 {
+		if cc.tools == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "mcp tools unavailable"})
+			return
+		}
+		executor := mcp.GatewayTools(ctx, cc.tools, req.Tools)
+		stream, config, err = cc.service.ChatWithTools(ctx, svcReq, executor, req.MaxSteps)
+	} else {
+		stream, config, err = cc.service.Chat(ctx, svcReq)
+	}
 	if err != nil {
 		gl.Log("error", fmt.Sprintf("chat service failed: %v", err))
+		var routeErr *gatewayService.RouteError
+		if errors.As(err, &routeErr) {
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "attempts": routeErr.Attempts})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -133,6 +161,7 @@ func (cc *ChatController) ChatSSE(c *gin.Context) {
 	})
 
 	var lastUsage *gatewayService.Usage
+	lastStep := 0
 
 streamLoop:
 	for {
@@ -152,6 +181,11 @@ streamLoop:
 				return
 			}
 
+			// Keep text and tool events in the order the model produced them
+			if chunk.ToolCall != nil || chunk.ToolResult != nil {
+				coalescer.Flush()
+			}
+
 			if chunk.Content != "" {
 				if err := coalescer.Add(chunk.Content); err != nil {
 					gl.Log("warn", fmt.Sprintf("chat coalescer failed: %v", err))
@@ -159,12 +193,19 @@ streamLoop:
 			}
 
 			if chunk.ToolCall != nil {
-				sendEvent(gin.H{"tool_call": chunk.ToolCall})
+				sendEvent(gin.H{"tool_call": chunk.ToolCall, "step": chunk.Step})
+			}
+
+			if chunk.ToolResult != nil {
+				sendEvent(gin.H{"tool_result": chunk.ToolResult, "step": chunk.Step})
 			}
 
 			if chunk.Usage != nil {
 				lastUsage = chunk.Usage
 			}
+			if chunk.Step > lastStep {
+				lastStep = chunk.Step
+			}
 
 			if chunk.Done {
 				break streamLoop
@@ -175,6 +216,9 @@ streamLoop:
 	coalescer.Close()
 
 	response := gin.H{"done": true, "provider": config.Name}
+	if lastStep > 0 {
+		response["steps"] = lastStep
+	}
 	if lastUsage != nil {
 		response["usage"] = lastUsage
 		if lastUsage.Model != "" {
@@ -0,0 +1,493 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+	gatewayService "github.com/kubex-ecosystem/gobe/internal/services/gateway"
+	gatewaysvc "github.com/kubex-ecosystem/gobe/internal/services/gateway/registry"
+)
+
+// ModelCatalog lists the LLM model records advertised next to the providers'
+// default models. svc.LLMService satisfies it.
+type ModelCatalog interface {
+	GetEnabledLLMModels() ([]svc.LLMModel, error)
+}
+
+// OpenAIController serves an OpenAI-compatible facade over the gateway registry.
+type OpenAIController struct {
+	service *gatewaysvc.Service
+	models  ModelCatalog
+}
+
+var errModelNotFound = errors.New("model not found")
+
+func NewOpenAIController(service *gatewaysvc.Service, models ModelCatalog) *OpenAIController {
+	if service == nil {
+		gl.Log("warn", "openai controller created without gateway service")
+	}
+	return &OpenAIController{service: service, models: models}
+}
+
+// ChatCompletions answers OpenAI chat completion requests through the gateway.
+//
+// @Summary     Chat completions (OpenAI)
+// @Description Fachada compatível com a API OpenAI. `model` aceita uma rota configurada ou `provedor/modelo` para escolher a entrada do registry; com `stream` responde em chunks `chat.completion.chunk` terminados por `[DONE]`.
+// @Tags        gateway beta
+// @Security    BearerAuth
+// @Accept      json
+// @Produce     json
+// @Produce     text/event-stream
+// @Param       X-External-API-Key header string false "Chave externa do cliente"
+// @Param       payload body OpenAIChatRequest true "Requisição no formato OpenAI"
+// @Success     200 {object} OpenAIChatResponse
+// @Failure     400 {object} OpenAIErrorResponse
+// @Failure     404 {object} OpenAIErrorResponse
+// @Failure     502 {object} OpenAIErrorResponse
+// @Failure     503 {object} OpenAIErrorResponse
+// @Router      /v1/chat/completions [post]
+func (oc *OpenAIController) ChatCompletions(c *gin.Context) {
+	if oc.service == nil {
+		openAIError(c, http.StatusServiceUnavailable, "server_error", "gateway service unavailable")
+		return
+	}
+
+	var req OpenAIChatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		openAIError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	if strings.TrimSpace(req.Model) == "" {
+		openAIError(c, http.StatusBadRequest, "invalid_request_error", "model is required")
+		return
+	}
+	if len(req.Messages) == 0 {
+		openAIError(c, http.StatusBadRequest, "invalid_request_error", "messages cannot be empty")
+		return
+	}
+
+	provider, model, err := oc.resolveModel(c.Request.Context(), req.Model)
+	if err != nil {
+		openAIError(c, http.StatusNotFound, "invalid_request_error", fmt.Sprintf("model %q does not exist", req.Model))
+		return
+	}
+
+	externalKey := strings.TrimSpace(c.GetHeader("x-external-api-key"))
+	tenantID := strings.TrimSpace(c.GetHeader("x-tenant-id"))
+	userID := strings.TrimSpace(c.GetHeader("x-user-id"))
+	if userID == "" {
+		userID = req.User
+	}
+
+	svcReq := gatewayService.ChatRequest{
+		Provider:    provider,
+		Model:       model,
+		Messages:    fromOpenAIMessages(req.Messages),
+		Temperature: 0.7,
+		Stream:      req.Stream,
+		Meta:        map[string]interface{}{"kind": "openai"},
+		Tools:       fromOpenAITools(req.Tools),
+		Headers: map[string]string{
+			"x-external-api-key": externalKey,
+			"x-tenant-id":        tenantID,
+			"x-user-id":          userID,
+		},
+	}
+	if req.Temperature != nil {
+		svcReq.Temperature = *req.Temperature
+	}
+	if externalKey != "" {
+		svcReq.Meta["external_api_key"] = externalKey
+	}
+	if tenantID != "" {
+		svcReq.Meta["tenant_id"] = tenantID
+	}
+	if userID != "" {
+		svcReq.Meta["user_id"] = userID
+	}
+
+	ctx := c.Request.Context()
+	stream, config, err := oc.service.Chat(ctx, svcReq)
+	if err != nil {
+		gl.Log("error", fmt.Sprintf("openai facade chat failed: %v", err))
+		openAIError(c, http.StatusBadGateway, "api_error", err.Error())
+		return
+	}
+
+	// Responses name the entry that answered, which differs from the
+	// requested one when a route fell back
+	if model == "" {
+		model = config.DefaultModel
+	}
+	completion := completionState{
+		id:      "chatcmpl-" + uuid.NewString(),
+		created: time.Now().Unix(),
+		model:   config.Name + "/" + model,
+	}
+	c.Header("X-Gateway-Provider", config.Name)
+
+	if req.Stream {
+		includeUsage := req.StreamOptions != nil && req.StreamOptions.IncludeUsage
+		oc.streamCompletion(c, stream, completion, includeUsage)
+		return
+	}
+
+	var content strings.Builder
+	var calls []OpenAIToolCall
+	var usage *gatewayService.Usage
+	for chunk := range stream {
+		if chunk.Error != "" {
+			openAIError(c, http.StatusBadGateway, "api_error", chunk.Error)
+			return
+		}
+		content.WriteString(chunk.Content)
+		if chunk.ToolCall != nil {
+			calls = append(calls, toOpenAIToolCall(*chunk.ToolCall, nil))
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+		if chunk.Done {
+			break
+		}
+	}
+
+	if usage != nil && usage.Model != "" {
+		completion.model = config.Name + "/" + usage.Model
+	}
+
+	finish := finishReason(len(calls) > 0)
+	c.JSON(http.StatusOK, OpenAIChatResponse{
+		ID:      completion.id,
+		Object:  "chat.completion",
+		Created: completion.created,
+		Model:   completion.model,
+		Choices: []OpenAIChoice{{
+			Index:        0,
+			Message:      &OpenAIMessage{Role: "assistant", Content: OpenAIContent(content.String()), ToolCalls: calls},
+			FinishReason: &finish,
+		}},
+		Usage: toOpenAIUsage(usage),
+	})
+}
+
+// ListModels lists the models reachable through /v1/chat/completions.
+//
+// @Summary     Listar modelos (OpenAI)
+// @Description Lista as rotas configuradas, os modelos padrão dos provedores e os registros LLMModel habilitados e os modelos descobertos em servidores locais (Ollama, OpenAI-compatible), no formato `provedor/modelo`.
+// @Tags        gateway beta
+// @Security    BearerAuth
+// @Produce     json
+// @Success     200 {object} OpenAIModelList
+// @Failure     503 {object} OpenAIErrorResponse
+// @Router      /v1/models [get]
+func (oc *OpenAIController) ListModels(c *gin.Context) {
+	if oc.service == nil {
+		openAIError(c, http.StatusServiceUnavailable, "server_error", "gateway service unavailable")
+		return
+	}
+
+	seen := make(map[string]bool)
+	data := make([]OpenAIModel, 0)
+	add := func(provider, model string) {
+		id := provider + "/" + model
+		if model == "" || seen[id] {
+			return
+		}
+		seen[id] = true
+		data = append(data, OpenAIModel{ID: id, Object: "model", OwnedBy: provider})
+	}
+
+	for _, route := range oc.service.Routes() {
+		data = append(data, OpenAIModel{ID: route.Name, Object: "model", OwnedBy: "gobe"})
+	}
+	summaries := oc.providerSummaries()
+	for _, summary := range summaries {
+		add(summary.Name, summary.DefaultModel)
+	}
+	for _, record := range oc.catalog() {
+		for _, summary := range summaries {
+			if record.GetProvider() == summary.Name || record.GetProvider() == summary.Type {
+				add(summary.Name, record.GetModel())
+			}
+		}
+	}
+	for name, models := range oc.service.DiscoverModels(c.Request.Context()) {
+		for _, model := range models {
+			add(name, model)
+		}
+	}
+
+	sort.Slice(data, func(i, j int) bool { return data[i].ID < data[j].ID })
+	c.JSON(http.StatusOK, OpenAIModelList{Object: "list", Data: data})
+}
+
+type completionState struct {
+	id      string
+	created int64
+	model   string
+}
+
+func (oc *OpenAIController) streamCompletion(c *gin.Context, stream <-chan gatewayService.ChatChunk, completion completionState, includeUsage bool) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	flusher, _ := c.Writer.(http.Flusher)
+	send := func(payload interface{}) {
+		bytes, err := json.Marshal(payload)
+		if err != nil {
+			gl.Log("error", fmt.Sprintf("failed to marshal completion chunk: %v", err))
+			return
+		}
+		if _, err := fmt.Fprintf(c.Writer, "data: %s\n\n", bytes); err != nil {
+			gl.Log("error", fmt.Sprintf("failed to write completion chunk: %v", err))
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	chunk := func(delta OpenAIDelta, finish *string) OpenAIChatResponse {
+		return OpenAIChatResponse{
+			ID:      completion.id,
+			Object:  "chat.completion.chunk",
+			Created: completion.created,
+			Model:   completion.model,
+			Choices: []OpenAIChoice{{Index: 0, Delta: &delta, FinishReason: finish}},
+		}
+	}
+	done := func() {
+		_, _ = fmt.Fprint(c.Writer, "data: [DONE]\n\n")
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	send(chunk(OpenAIDelta{Role: "assistant"}, nil))
+
+	ctx := c.Request.Context()
+	var usage *gatewayService.Usage
+	toolCalls := 0
+
+streamLoop:
+	for {
+		select {
+		case <-ctx.Done():
+			gl.Log("warn", "openai completion stream cancelled by client")
+			return
+		case msg, ok := <-stream:
+			if !ok {
+				break streamLoop
+			}
+			if msg.Error != "" {
+				send(OpenAIErrorResponse{Error: OpenAIError{Message: msg.Error, Type: "api_error"}})
+				done()
+				return
+			}
+			if msg.Content != "" {
+				send(chunk(OpenAIDelta{Content: msg.Content}, nil))
+			}
+			if msg.ToolCall != nil {
+				index := toolCalls
+				toolCalls++
+				send(chunk(OpenAIDelta{ToolCalls: []OpenAIToolCall{toOpenAIToolCall(*msg.ToolCall, &index)}}, nil))
+			}
+			if msg.Usage != nil {
+				usage = msg.Usage
+			}
+			if msg.Done {
+				break streamLoop
+			}
+		}
+	}
+
+	finish := finishReason(toolCalls > 0)
+	send(chunk(OpenAIDelta{}, &finish))
+	if includeUsage {
+		send(OpenAIChatResponse{
+			ID:      completion.id,
+			Object:  "chat.completion.chunk",
+			Created: completion.created,
+			Model:   completion.model,
+			Choices: []OpenAIChoice{},
+			Usage:   toOpenAIUsage(usage),
+		})
+	}
+	done()
+}
+
+// resolveModel maps an OpenAI model string to a registry entry or route and
+// the model passed to it. A route name selects the route; "name/model" selects the entry called name (or the first one
+// of type name); a bare model is matched against default models, then against
+// LLMModel records and the models discovered on local servers. An empty model
+// means the entry's default.
+func (oc *OpenAIController) resolveModel(ctx context.Context, requested string) (string, string, error) {
+	requested = strings.TrimSpace(requested)
+	summaries := oc.providerSummaries()
+
+	// Routes are resolved by the gateway service itself
+	for _, route := range oc.service.Routes() {
+		if route.Name == requested {
+			return route.Name, "", nil
+		}
+	}
+
+	if prefix, model, ok := strings.Cut(requested, "/"); ok {
+		for _, summary := range summaries {
+			if summary.Name == prefix {
+				return summary.Name, model, nil
+			}
+		}
+		for _, summary := range summaries {
+			if summary.Type == prefix {
+				return summary.Name, model, nil
+			}
+		}
+		// Model IDs such as "meta-llama/Llama-3" contain a slash themselves
+	}
+
+	for _, summary := range summaries {
+		if summary.Name == requested {
+			return summary.Name, "", nil
+		}
+	}
+	for _, summary := range summaries {
+		if summary.DefaultModel == requested {
+			return summary.Name, requested, nil
+		}
+	}
+	for _, record := range oc.catalog() {
+		if record.GetModel() != requested {
+			continue
+		}
+		for _, summary := range summaries {
+			if record.GetProvider() == summary.Name || record.GetProvider() == summary.Type {
+				return summary.Name, requested, nil
+			}
+		}
+	}
+	discovered := oc.service.DiscoverModels(ctx)
+	for _, summary := range summaries {
+		if slices.Contains(discovered[summary.Name], requested) {
+			return summary.Name, requested, nil
+		}
+	}
+	if len(summaries) == 1 {
+		return summaries[0].Name, requested, nil
+	}
+	return "", "", errModelNotFound
+}
+
+// providerSummaries returns the registry entries sorted by name so routing by
+// type is deterministic.
+func (oc *OpenAIController) providerSummaries() []gatewayService.ProviderSummary {
+	summaries := oc.service.ProviderSummaries()
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Name < summaries[j].Name })
+	return summaries
+}
+
+func (oc *OpenAIController) catalog() []svc.LLMModel {
+	if oc.models == nil {
+		return nil
+	}
+	records, err := oc.models.GetEnabledLLMModels()
+	if err != nil {
+		gl.Log("warn", "failed to list LLM models", err)
+		return nil
+	}
+	return records
+}
+
+func fromOpenAIMessages(messages []OpenAIMessage) []gatewayService.Message {
+	result := make([]gatewayService.Message, 0, len(messages))
+	for _, msg := range messages {
+		out := gatewayService.Message{
+			Role:       msg.Role,
+			Content:    string(msg.Content),
+			ToolCallID: msg.ToolCallID,
+			Name:       msg.Name,
+		}
+		for _, call := range msg.ToolCalls {
+			var args interface{} = map[string]interface{}{}
+			if call.Function.Arguments != "" {
+				var parsed map[string]interface{}
+				if err := json.Unmarshal([]byte(call.Function.Arguments), &parsed); err == nil {
+					args = parsed
+				}
+			}
+			out.ToolCalls = append(out.ToolCalls, gatewayService.ToolCall{ID: call.ID, Name: call.Function.Name, Args: args})
+		}
+		result = append(result, out)
+	}
+	return result
+}
+
+func fromOpenAITools(tools []OpenAITool) []gatewayService.Tool {
+	result := make([]gatewayService.Tool, 0, len(tools))
+	for _, tool := range tools {
+		if tool.Type != "" && tool.Type != "function" {
+			continue
+		}
+		result = append(result, gatewayService.Tool{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			Parameters:  tool.Function.Parameters,
+		})
+	}
+	return result
+}
+
+func toOpenAIToolCall(call gatewayService.ToolCall, index *int) OpenAIToolCall {
+	out := OpenAIToolCall{Index: index, ID: call.ID, Type: "function"}
+	out.Function.Name = call.Name
+	switch args := call.Args.(type) {
+	case string:
+		out.Function.Arguments = args
+	case nil:
+		out.Function.Arguments = "{}"
+	default:
+		data, err := json.Marshal(args)
+		if err != nil {
+			data = []byte("{}")
+		}
+		out.Function.Arguments = string(data)
+	}
+	return out
+}
+
+func toOpenAIUsage(usage *gatewayService.Usage) *OpenAIUsage {
+	if usage == nil {
+		return &OpenAIUsage{}
+	}
+	return &OpenAIUsage{
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+		Attempts:         usage.Attempts,
+	}
+}
+
+func finishReason(toolCalls bool) string {
+	if toolCalls {
+		return "tool_calls"
+	}
+	return "stop"
+}
+
+func openAIError(c *gin.Context, status int, errType, message string) {
+	c.JSON(status, OpenAIErrorResponse{Error: OpenAIError{Message: message, Type: errType}})
+}
@@ -39,13 +39,14 @@ func (pc *ProvidersController) ListProviders(c *gin.Context) {
 
 	for _, summary := range summaries {
 		items = append(items, ProviderItem{
-			Name:         summary.Name,
-			Type:         summary.Type,
-			Org:          summary.Org,
-			DefaultModel: summary.DefaultModel,
-			Available:    summary.Available,
-			LastError:    summary.LastError,
-			Metadata:     summary.Metadata,
+			Name:          summary.Name,
+			Type:          summary.Type,
+			Org:           summary.Org,
+			DefaultModel:  summary.DefaultModel,
+			Available:     summary.Available,
+			LastError:     summary.LastError,
+			Metadata:      summary.Metadata,
+			CooldownUntil: summary.CooldownUntil,
 		})
 	}
 
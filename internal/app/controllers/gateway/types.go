@@ -1,10 +1,17 @@
 package gateway
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
+	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
 	t "github.com/kubex-ecosystem/gobe/internal/contracts/types"
 	gatewaytypes "github.com/kubex-ecosystem/gobe/internal/services/gateway"
+	"github.com/kubex-ecosystem/gobe/internal/services/gateway/cache"
+	webhooks "github.com/kubex-ecosystem/gobe/internal/services/webhooks"
+	"github.com/kubex-ecosystem/gobe/internal/services/webhooks/outbound"
 )
 
 type (
@@ -16,24 +23,29 @@ type (
 type ChatMessage = gatewaytypes.Message
 
 // ChatRequest captures the payload expected by the chat SSE endpoint.
+// Temperature defaults to 0.7; an explicit 0 makes the answer cacheable.
 type ChatRequest struct {
 	Provider    string                 `json:"provider"`
 	Model       string                 `json:"model"`
 	Messages    []gatewaytypes.Message `json:"messages"`
 	Stream      bool                   `json:"stream"`
-	Temperature float32                `json:"temperature"`
+	Temperature *float32               `json:"temperature,omitempty"`
 	Meta        map[string]interface{} `json:"meta,omitempty"`
+	// Tools lists the MCP tools (names or globs) the model may call.
+	Tools    []string `json:"tools,omitempty"`
+	MaxSteps int      `json:"max_steps,omitempty"`
 }
 
 // ProviderItem holds provider metadata for the gateway /providers response.
 type ProviderItem struct {
-	Name         string                 `json:"name"`
-	Type         string                 `json:"type"`
-	Org          string                 `json:"org,omitempty"`
-	DefaultModel string                 `json:"default_model,omitempty"`
-	Available    bool                   `json:"available"`
-	LastError    string                 `json:"last_error,omitempty"`
-	Metadata     map[string]interface{} `json:"metadata,omitempty"`
+	Name          string                 `json:"name"`
+	Type          string                 `json:"type"`
+	Org           string                 `json:"org,omitempty"`
+	DefaultModel  string                 `json:"default_model,omitempty"`
+	Available     bool                   `json:"available"`
+	LastError     string                 `json:"last_error,omitempty"`
+	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+	CooldownUntil *time.Time             `json:"cooldown_until,omitempty"`
 }
 
 // AdviceRequest is a lightweight payload for /advise endpoints.
@@ -60,6 +72,23 @@ type ProvidersResponse struct {
 	Timestamp time.Time      `json:"timestamp"`
 }
 
+// UsageResponse reports the gateway spend over a time range.
+type UsageResponse struct {
+	From    *time.Time        `json:"from,omitempty"`
+	To      *time.Time        `json:"to,omitempty"`
+	Totals  svc.UsageTotals   `json:"totals"`
+	GroupBy string            `json:"group_by,omitempty"`
+	Groups  []svc.UsageTotals `json:"groups,omitempty"`
+}
+
+// UsageRecordsResponse lists ledger records, newest first.
+type UsageRecordsResponse struct {
+	Records []svc.UsageRecord `json:"records"`
+}
+
+// CacheStatsResponse holds the response cache counters.
+type CacheStatsResponse = cache.Stats
+
 // ScorecardEntry describes the scorecard placeholder output.
 type ScorecardEntry struct {
 	ID          string    `json:"id"`
@@ -165,14 +194,16 @@ type WebhookEvent struct {
 	Processed bool                   `json:"processed"`
 	Status    string                 `json:"status"`
 	Error     string                 `json:"error,omitempty"`
+	Attempts  int                    `json:"attempts"`
 }
 
 // WebhookEventsResponse lists webhook events with pagination
 type WebhookEventsResponse struct {
-	Events []interface{} `json:"events"`
-	Total  int           `json:"total"`
-	Limit  int           `json:"limit"`
-	Offset int           `json:"offset"`
+	Events     []interface{} `json:"events"`
+	Total      int           `json:"total"`
+	Limit      int           `json:"limit"`
+	Offset     int           `json:"offset"`
+	NextCursor string        `json:"next_cursor,omitempty"`
 }
 
 // WebhookRetryResponse confirms webhook retry operation
@@ -182,3 +213,214 @@ type WebhookRetryResponse struct {
 	Timestamp    time.Time `json:"timestamp"`
 	Message      string    `json:"message"`
 }
+
+// WebhookSubscription is an outbound webhook subscription.
+type WebhookSubscription = outbound.Subscription
+
+// WebhookSubscriptionRequest registers a URL for gobe events. Secret is
+// generated when omitted and returned only in the creation response.
+type WebhookSubscriptionRequest struct {
+	URL         string   `json:"url" binding:"required"`
+	EventTypes  []string `json:"event_types" binding:"required"`
+	Secret      string   `json:"secret,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
+// WebhookSubscriptionUpdateRequest changes the fields it sets.
+type WebhookSubscriptionUpdateRequest = outbound.SubscriptionUpdate
+
+// WebhookSubscriptionsResponse lists outbound webhook subscriptions.
+type WebhookSubscriptionsResponse struct {
+	Subscriptions []WebhookSubscription `json:"subscriptions"`
+}
+
+// WebhookDelivery is an entry of the outbound delivery log.
+type WebhookDelivery = outbound.Delivery
+
+// WebhookDeliveriesResponse lists outbound deliveries with pagination.
+type WebhookDeliveriesResponse struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+	Total      int64             `json:"total"`
+	Limit      int               `json:"limit"`
+	Offset     int               `json:"offset"`
+}
+
+// WebhookRule maps inbound webhook events to an action.
+type WebhookRule = webhooks.Rule
+
+// WebhookRuleRequest creates or replaces a webhook rule. Enabled defaults to
+// true.
+type WebhookRuleRequest struct {
+	Name        string                 `json:"name"`
+	Source      string                 `json:"source,omitempty"`
+	EventType   string                 `json:"event_type,omitempty"`
+	Action      string                 `json:"action" binding:"required"`
+	Target      string                 `json:"target,omitempty"`
+	Params      map[string]interface{} `json:"params,omitempty"`
+	Mapping     map[string]string      `json:"mapping,omitempty"`
+	Priority    int                    `json:"priority"`
+	StopOnMatch bool                   `json:"stop_on_match"`
+	Enabled     *bool                  `json:"enabled,omitempty"`
+}
+
+// Rule converts the request to the rule it describes.
+func (r WebhookRuleRequest) Rule() webhooks.Rule {
+	enabled := r.Enabled == nil || *r.Enabled
+	return webhooks.Rule{
+		Name:        r.Name,
+		Source:      r.Source,
+		EventType:   r.EventType,
+		Action:      r.Action,
+		Target:      r.Target,
+		Params:      r.Params,
+		Mapping:     r.Mapping,
+		Priority:    r.Priority,
+		StopOnMatch: r.StopOnMatch,
+		Enabled:     enabled,
+	}
+}
+
+// WebhookRulesResponse lists the webhook rules in evaluation order.
+type WebhookRulesResponse struct {
+	Rules []WebhookRule `json:"rules"`
+}
+
+// OpenAIChatRequest is the subset of the OpenAI chat completions payload the
+// /v1/chat/completions facade understands. Unknown fields are ignored.
+type OpenAIChatRequest struct {
+	Model         string               `json:"model"`
+	Messages      []OpenAIMessage      `json:"messages"`
+	Stream        bool                 `json:"stream"`
+	Temperature   *float32             `json:"temperature,omitempty"`
+	Tools         []OpenAITool         `json:"tools,omitempty"`
+	StreamOptions *OpenAIStreamOptions `json:"stream_options,omitempty"`
+	User          string               `json:"user,omitempty"`
+}
+
+// OpenAIStreamOptions mirrors the OpenAI stream_options object.
+type OpenAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// OpenAIMessage is a chat message in OpenAI wire format.
+type OpenAIMessage struct {
+	Role       string           `json:"role"`
+	Content    OpenAIContent    `json:"content"`
+	Name       string           `json:"name,omitempty"`
+	ToolCalls  []OpenAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+// OpenAIContent accepts both the plain string and the array-of-parts forms of
+// message content; only text parts are kept.
+type OpenAIContent string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (c *OpenAIContent) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*c = ""
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*c = OpenAIContent(text)
+		return nil
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(data, &parts); err != nil {
+		return fmt.Errorf("content must be a string or an array of parts: %w", err)
+	}
+	var sb strings.Builder
+	for _, part := range parts {
+		if part.Type == "text" {
+			sb.WriteString(part.Text)
+		}
+	}
+	*c = OpenAIContent(sb.String())
+	return nil
+}
+
+// OpenAITool is a client-defined function offered to the model.
+type OpenAITool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string          `json:"name"`
+		Description string          `json:"description,omitempty"`
+		Parameters  json.RawMessage `json:"parameters,omitempty"`
+	} `json:"function"`
+}
+
+// OpenAIToolCall is a function call made by the model.
+type OpenAIToolCall struct {
+	Index    *int   `json:"index,omitempty"`
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// OpenAIUsage is the usage block of chat completion responses. Attempts is a
+// gobe extension listing the provider calls made by routing.
+type OpenAIUsage struct {
+	PromptTokens     int                    `json:"prompt_tokens"`
+	CompletionTokens int                    `json:"completion_tokens"`
+	TotalTokens      int                    `json:"total_tokens"`
+	Attempts         []gatewaytypes.Attempt `json:"attempts,omitempty"`
+}
+
+// OpenAIChoice is one choice of a completion or of a streamed chunk.
+type OpenAIChoice struct {
+	Index        int            `json:"index"`
+	Message      *OpenAIMessage `json:"message,omitempty"`
+	Delta        *OpenAIDelta   `json:"delta,omitempty"`
+	FinishReason *string        `json:"finish_reason"`
+}
+
+// OpenAIDelta carries the incremental content of a streamed chunk.
+type OpenAIDelta struct {
+	Role      string           `json:"role,omitempty"`
+	Content   string           `json:"content,omitempty"`
+	ToolCalls []OpenAIToolCall `json:"tool_calls,omitempty"`
+}
+
+// OpenAIChatResponse is a chat.completion or chat.completion.chunk object.
+type OpenAIChatResponse struct {
+	ID      string         `json:"id"`
+	Object  string         `json:"object"`
+	Created int64          `json:"created"`
+	Model   string         `json:"model"`
+	Choices []OpenAIChoice `json:"choices"`
+	Usage   *OpenAIUsage   `json:"usage,omitempty"`
+}
+
+// OpenAIModel describes a routable model in the /v1/models list.
+type OpenAIModel struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+// OpenAIModelList is the /v1/models response.
+type OpenAIModelList struct {
+	Object string        `json:"object"`
+	Data   []OpenAIModel `json:"data"`
+}
+
+// OpenAIErrorResponse is the OpenAI error envelope.
+type OpenAIErrorResponse struct {
+	Error OpenAIError `json:"error"`
+}
+
+// OpenAIError describes a failed request in OpenAI format.
+type OpenAIError struct {
+	Message string  `json:"message"`
+	Type    string  `json:"type"`
+	Param   *string `json:"param"`
+	Code    *string `json:"code"`
+}
@@ -0,0 +1,158 @@
+package gateway
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
+	"github.com/kubex-ecosystem/gobe/internal/services/gateway/ledger"
+)
+
+const defaultUsageRecordsLimit = 100
+
+// UsageController exposes the token and cost ledger of the gateway.
+type UsageController struct {
+	ledger *ledger.Ledger
+}
+
+func NewUsageController(l *ledger.Ledger) *UsageController {
+	return &UsageController{ledger: l}
+}
+
+// Summary returns the spend over a time range, optionally grouped.
+//
+// @Summary     Consumo do gateway
+// @Description Soma tokens e custo (USD) das chamadas de chat/advise no intervalo `from`–`to` (RFC3339 ou AAAA-MM-DD), filtrando por tenant, usuário, provedor ou modelo e agrupando por `group_by` (tenant, user, provider, model, kind). [Em desenvolvimento]
+// @Tags        gateway beta
+// @Security    BearerAuth
+// @Produce     json
+// @Param       tenant   query string false "Tenant (x-tenant-id)"
+// @Param       user     query string false "Usuário (x-user-id)"
+// @Param       provider query string false "Provedor"
+// @Param       model    query string false "Modelo"
+// @Param       from     query string false "Início (inclusivo)"
+// @Param       to       query string false "Fim (exclusivo)"
+// @Param       group_by query string false "Agrupamento"
+// @Success     200 {object} UsageResponse
+// @Failure     400 {object} ErrorResponse
+// @Failure     503 {object} ErrorResponse
+// @Router      /v1/usage [get]
+func (uc *UsageController) Summary(c *gin.Context) {
+	if uc.ledger == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "usage ledger unavailable"})
+		return
+	}
+
+	filter, err := usageFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	groupBy := strings.TrimSpace(c.Query("group_by"))
+	if _, ok := svc.UsageGroups[groupBy]; groupBy != "" && !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid group_by %q", groupBy)})
+		return
+	}
+
+	ctx := c.Request.Context()
+	totals, err := uc.ledger.Totals(ctx, filter, "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	response := UsageResponse{GroupBy: groupBy}
+	if len(totals) > 0 {
+		response.Totals = totals[0]
+	}
+	if !filter.From.IsZero() {
+		response.From = &filter.From
+	}
+	if !filter.To.IsZero() {
+		response.To = &filter.To
+	}
+	if groupBy != "" {
+		if response.Groups, err = uc.ledger.Totals(ctx, filter, groupBy); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Records lists the ledger records of a time range.
+//
+// @Summary     Registros de consumo
+// @Description Lista as chamadas registradas no ledger, da mais recente para a mais antiga, com os mesmos filtros de /v1/usage. [Em desenvolvimento]
+// @Tags        gateway beta
+// @Security    BearerAuth
+// @Produce     json
+// @Param       tenant   query string false "Tenant (x-tenant-id)"
+// @Param       from     query string false "Início (inclusivo)"
+// @Param       to       query string false "Fim (exclusivo)"
+// @Param       limit    query int    false "Máximo de registros (padrão 100)"
+// @Success     200 {object} UsageRecordsResponse
+// @Failure     400 {object} ErrorResponse
+// @Failure     503 {object} ErrorResponse
+// @Router      /v1/usage/records [get]
+func (uc *UsageController) Records(c *gin.Context) {
+	if uc.ledger == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "usage ledger unavailable"})
+		return
+	}
+
+	filter, err := usageFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.Limit = defaultUsageRecordsLimit
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	records, err := uc.ledger.Records(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, UsageRecordsResponse{Records: records})
+}
+
+func usageFilter(c *gin.Context) (svc.UsageFilter, error) {
+	filter := svc.UsageFilter{
+		TenantID: strings.TrimSpace(c.Query("tenant")),
+		UserID:   strings.TrimSpace(c.Query("user")),
+		Provider: strings.TrimSpace(c.Query("provider")),
+		Model:    strings.TrimSpace(c.Query("model")),
+	}
+	var err error
+	if filter.From, err = usageTime(c.Query("from")); err != nil {
+		return filter, fmt.Errorf("invalid from: %w", err)
+	}
+	if filter.To, err = usageTime(c.Query("to")); err != nil {
+		return filter, fmt.Errorf("invalid to: %w", err)
+	}
+	return filter, nil
+}
+
+// usageTime parses RFC3339 timestamps and plain dates (midnight UTC).
+func usageTime(raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if ts, err := time.Parse(time.RFC3339, raw); err == nil {
+		return ts.UTC(), nil
+	}
+	return time.Parse(time.DateOnly, raw)
+}
@@ -0,0 +1,162 @@
+package gateway
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+	webhooks "github.com/kubex-ecosystem/gobe/internal/services/webhooks"
+)
+
+// CreateRule registers a webhook handler rule.
+//
+// @Summary     Criar regra de webhook
+// @Description Associa eventos recebidos (padrões glob de `source` e `event_type`) a uma ação: `mcp_tool`, `cron_run`, `chat`, `amqp` ou `transform`. `mapping` extrai valores do evento com JSONPath (`$.payload.repository.full_name`) e os sobrepõe a `params`. [Em desenvolvimento]
+// @Tags        gateway beta
+// @Security    BearerAuth
+// @Accept      json
+// @Produce     json
+// @Param       payload body WebhookRuleRequest true "Regra"
+// @Success     201 {object} WebhookRule
+// @Failure     400 {object} ErrorResponse
+// @Failure     503 {object} ErrorResponse
+// @Router      /v1/webhooks/rules [post]
+func (wc *WebhookController) CreateRule(c *gin.Context) {
+	if !wc.rulesAvailable(c) {
+		return
+	}
+	var req WebhookRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Status: "error", Message: "invalid request body"})
+		return
+	}
+	rule, err := wc.webhookService.CreateRule(c.Request.Context(), req.Rule())
+	if err != nil {
+		wc.ruleFailed(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, rule)
+}
+
+// ListRules returns the webhook rules in evaluation order.
+//
+// @Summary     Listar regras de webhook
+// @Description Retorna as regras de webhook por prioridade crescente, na ordem em que são avaliadas. [Em desenvolvimento]
+// @Tags        gateway beta
+// @Security    BearerAuth
+// @Produce     json
+// @Success     200 {object} WebhookRulesResponse
+// @Failure     503 {object} ErrorResponse
+// @Router      /v1/webhooks/rules [get]
+func (wc *WebhookController) ListRules(c *gin.Context) {
+	if !wc.rulesAvailable(c) {
+		return
+	}
+	rules, err := wc.webhookService.ListRules(c.Request.Context())
+	if err != nil {
+		wc.ruleFailed(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, WebhookRulesResponse{Rules: rules})
+}
+
+// GetRule returns a webhook rule.
+//
+// @Summary     Obter regra de webhook
+// @Description Retorna uma regra de webhook. [Em desenvolvimento]
+// @Tags        gateway beta
+// @Security    BearerAuth
+// @Produce     json
+// @Param       id path string true "ID da regra"
+// @Success     200 {object} WebhookRule
+// @Failure     404 {object} ErrorResponse
+// @Failure     503 {object} ErrorResponse
+// @Router      /v1/webhooks/rules/{id} [get]
+func (wc *WebhookController) GetRule(c *gin.Context) {
+	if !wc.rulesAvailable(c) {
+		return
+	}
+	rule, err := wc.webhookService.GetRule(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		wc.ruleFailed(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+// UpdateRule replaces a webhook rule.
+//
+// @Summary     Atualizar regra de webhook
+// @Description Substitui a regra informada; passa a valer para os próximos eventos processados. [Em desenvolvimento]
+// @Tags        gateway beta
+// @Security    BearerAuth
+// @Accept      json
+// @Produce     json
+// @Param       id      path string             true "ID da regra"
+// @Param       payload body WebhookRuleRequest true "Regra"
+// @Success     200 {object} WebhookRule
+// @Failure     400 {object} ErrorResponse
+// @Failure     404 {object} ErrorResponse
+// @Failure     503 {object} ErrorResponse
+// @Router      /v1/webhooks/rules/{id} [put]
+func (wc *WebhookController) UpdateRule(c *gin.Context) {
+	if !wc.rulesAvailable(c) {
+		return
+	}
+	var req WebhookRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Status: "error", Message: "invalid request body"})
+		return
+	}
+	rule, err := wc.webhookService.UpdateRule(c.Request.Context(), c.Param("id"), req.Rule())
+	if err != nil {
+		wc.ruleFailed(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteRule removes a webhook rule.
+//
+// @Summary     Remover regra de webhook
+// @Description Remove a regra informada. [Em desenvolvimento]
+// @Tags        gateway beta
+// @Security    BearerAuth
+// @Produce     json
+// @Param       id path string true "ID da regra"
+// @Success     200 {object} MessageResponse
+// @Failure     404 {object} ErrorResponse
+// @Failure     503 {object} ErrorResponse
+// @Router      /v1/webhooks/rules/{id} [delete]
+func (wc *WebhookController) DeleteRule(c *gin.Context) {
+	if !wc.rulesAvailable(c) {
+		return
+	}
+	if err := wc.webhookService.DeleteRule(c.Request.Context(), c.Param("id")); err != nil {
+		wc.ruleFailed(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, MessageResponse{Status: "success", Message: "rule deleted"})
+}
+
+func (wc *WebhookController) rulesAvailable(c *gin.Context) bool {
+	if wc.webhookService == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Status: "error", Message: "webhook service unavailable"})
+		return false
+	}
+	return true
+}
+
+func (wc *WebhookController) ruleFailed(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, webhooks.ErrInvalidRule):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Status: "error", Message: err.Error()})
+	case errors.Is(err, svc.ErrWebhookRuleNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Status: "error", Message: err.Error()})
+	default:
+		gl.Log("error", "Webhook rule operation failed", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Status: "error", Message: "webhook rule operation failed"})
+	}
+}
@@ -0,0 +1,266 @@
+package gateway
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+	"github.com/kubex-ecosystem/gobe/internal/services/webhooks/outbound"
+)
+
+// WebhookSubscriptionController manages the outbound webhook subscriptions
+// and their delivery log.
+type WebhookSubscriptionController struct {
+	dispatcher *outbound.Dispatcher
+}
+
+func NewWebhookSubscriptionController(dispatcher *outbound.Dispatcher) *WebhookSubscriptionController {
+	return &WebhookSubscriptionController{dispatcher: dispatcher}
+}
+
+// Create registers a subscription.
+//
+// @Summary     Criar assinatura de webhook
+// @Description Registra uma URL para receber eventos do gobe (`webhook.received`, `cron.job.executed`, `approval.decided`, `analyzer.notification`; aceita `prefixo.*` e `*`). As entregas são assinadas com HMAC-SHA256 no header `X-Gobe-Signature`; o segredo só é retornado nesta resposta. [Em desenvolvimento]
+// @Tags        gateway beta
+// @Security    BearerAuth
+// @Accept      json
+// @Produce     json
+// @Param       payload body WebhookSubscriptionRequest true "Assinatura"
+// @Success     201 {object} WebhookSubscription
+// @Failure     400 {object} ErrorResponse
+// @Failure     503 {object} ErrorResponse
+// @Router      /v1/webhooks/subscriptions [post]
+func (sc *WebhookSubscriptionController) Create(c *gin.Context) {
+	if !sc.available(c) {
+		return
+	}
+	var req WebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Status: "error", Message: "invalid request body"})
+		return
+	}
+	sub, err := sc.dispatcher.Subscribe(c.Request.Context(), outbound.Subscription{
+		URL:         req.URL,
+		EventTypes:  req.EventTypes,
+		Secret:      req.Secret,
+		Description: req.Description,
+	})
+	if err != nil {
+		sc.fail(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, sub)
+}
+
+// List returns every subscription.
+//
+// @Summary     Listar assinaturas de webhook
+// @Description Retorna as assinaturas de webhooks de saída, sem os segredos. [Em desenvolvimento]
+// @Tags        gateway beta
+// @Security    BearerAuth
+// @Produce     json
+// @Success     200 {object} WebhookSubscriptionsResponse
+// @Failure     503 {object} ErrorResponse
+// @Router      /v1/webhooks/subscriptions [get]
+func (sc *WebhookSubscriptionController) List(c *gin.Context) {
+	if !sc.available(c) {
+		return
+	}
+	subs, err := sc.dispatcher.ListSubscriptions(c.Request.Context())
+	if err != nil {
+		sc.fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, WebhookSubscriptionsResponse{Subscriptions: subs})
+}
+
+// Get returns a subscription.
+//
+// @Summary     Obter assinatura de webhook
+// @Description Retorna uma assinatura de webhook de saída, sem o segredo. [Em desenvolvimento]
+// @Tags        gateway beta
+// @Security    BearerAuth
+// @Produce     json
+// @Param       id path string true "ID da assinatura"
+// @Success     200 {object} WebhookSubscription
+// @Failure     404 {object} ErrorResponse
+// @Router      /v1/webhooks/subscriptions/{id} [get]
+func (sc *WebhookSubscriptionController) Get(c *gin.Context) {
+	if !sc.available(c) {
+		return
+	}
+	sub, err := sc.dispatcher.GetSubscription(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		sc.fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, sub)
+}
+
+// Update changes a subscription, for instance to pause it.
+//
+// @Summary     Atualizar assinatura de webhook
+// @Description Altera URL, tipos de evento, descrição ou `active` de uma assinatura. [Em desenvolvimento]
+// @Tags        gateway beta
+// @Security    BearerAuth
+// @Accept      json
+// @Produce     json
+// @Param       id      path string                           true "ID da assinatura"
+// @Param       payload body WebhookSubscriptionUpdateRequest true "Campos alterados"
+// @Success     200 {object} WebhookSubscription
+// @Failure     400 {object} ErrorResponse
+// @Failure     404 {object} ErrorResponse
+// @Router      /v1/webhooks/subscriptions/{id} [patch]
+func (sc *WebhookSubscriptionController) Update(c *gin.Context) {
+	if !sc.available(c) {
+		return
+	}
+	var req WebhookSubscriptionUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Status: "error", Message: "invalid request body"})
+		return
+	}
+	sub, err := sc.dispatcher.UpdateSubscription(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		sc.fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, sub)
+}
+
+// Delete removes a subscription.
+//
+// @Summary     Remover assinatura de webhook
+// @Description Remove a assinatura; entregas ainda pendentes vão para dead letter. [Em desenvolvimento]
+// @Tags        gateway beta
+// @Security    BearerAuth
+// @Produce     json
+// @Param       id path string true "ID da assinatura"
+// @Success     200 {object} MessageResponse
+// @Failure     404 {object} ErrorResponse
+// @Router      /v1/webhooks/subscriptions/{id} [delete]
+func (sc *WebhookSubscriptionController) Delete(c *gin.Context) {
+	if !sc.available(c) {
+		return
+	}
+	if err := sc.dispatcher.DeleteSubscription(c.Request.Context(), c.Param("id")); err != nil {
+		sc.fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, MessageResponse{Status: "success", Message: "subscription deleted"})
+}
+
+// ListDeliveries returns a page of the delivery log.
+//
+// @Summary     Listar entregas de webhook
+// @Description Retorna o log de entregas de saída, da mais recente para a mais antiga. Status: `pending`, `sending`, `succeeded` ou `dead`. [Em desenvolvimento]
+// @Tags        gateway beta
+// @Security    BearerAuth
+// @Produce     json
+// @Param       subscription_id query string false "Filtrar por assinatura"
+// @Param       status          query string false "Filtrar por status"
+// @Param       type            query string false "Filtrar por tipo do evento"
+// @Param       limit           query int    false "Número máximo de entregas (default: 50)"
+// @Param       offset          query int    false "Número de entregas a pular"
+// @Success     200 {object} WebhookDeliveriesResponse
+// @Failure     503 {object} ErrorResponse
+// @Router      /v1/webhooks/deliveries [get]
+func (sc *WebhookSubscriptionController) ListDeliveries(c *gin.Context) {
+	if !sc.available(c) {
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 {
+		limit = 50
+	}
+	if limit > 100 {
+		limit = 100 // Max limit
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	deliveries, total, err := sc.dispatcher.ListDeliveries(c.Request.Context(), outbound.DeliveryQuery{
+		SubscriptionID: c.Query("subscription_id"),
+		Status:         c.Query("status"),
+		EventType:      c.Query("type"),
+		Offset:         offset,
+		Limit:          limit,
+	})
+	if err != nil {
+		sc.fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, WebhookDeliveriesResponse{Deliveries: deliveries, Total: total, Limit: limit, Offset: offset})
+}
+
+// GetDelivery returns a delivery.
+//
+// @Summary     Obter entrega de webhook
+// @Description Retorna uma entrega de saída com payload, tentativas e último erro. [Em desenvolvimento]
+// @Tags        gateway beta
+// @Security    BearerAuth
+// @Produce     json
+// @Param       id path string true "ID da entrega"
+// @Success     200 {object} WebhookDelivery
+// @Failure     404 {object} ErrorResponse
+// @Router      /v1/webhooks/deliveries/{id} [get]
+func (sc *WebhookSubscriptionController) GetDelivery(c *gin.Context) {
+	if !sc.available(c) {
+		return
+	}
+	delivery, err := sc.dispatcher.GetDelivery(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		sc.fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, delivery)
+}
+
+// Redeliver queues the event of a delivery again.
+//
+// @Summary     Reenviar entrega de webhook
+// @Description Enfileira uma nova entrega do mesmo evento (mesmo `X-Gobe-Event-Id`) com novas tentativas, inclusive para entregas em dead letter. [Em desenvolvimento]
+// @Tags        gateway beta
+// @Security    BearerAuth
+// @Produce     json
+// @Param       id path string true "ID da entrega"
+// @Success     202 {object} WebhookDelivery
+// @Failure     404 {object} ErrorResponse
+// @Router      /v1/webhooks/deliveries/{id}/redeliver [post]
+func (sc *WebhookSubscriptionController) Redeliver(c *gin.Context) {
+	if !sc.available(c) {
+		return
+	}
+	delivery, err := sc.dispatcher.Redeliver(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		sc.fail(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+func (sc *WebhookSubscriptionController) available(c *gin.Context) bool {
+	if sc.dispatcher == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Status: "error", Message: "outbound webhooks unavailable"})
+		return false
+	}
+	return true
+}
+
+func (sc *WebhookSubscriptionController) fail(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, outbound.ErrInvalidSubscription):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Status: "error", Message: err.Error()})
+	case errors.Is(err, svc.ErrWebhookSubscriptionNotFound), errors.Is(err, svc.ErrWebhookDeliveryNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Status: "error", Message: err.Error()})
+	default:
+		gl.Log("error", "Outbound webhook operation failed", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Status: "error", Message: "outbound webhook operation failed"})
+	}
+}
@@ -1,6 +1,9 @@
 package gateway
 
 import (
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 	"time"
@@ -9,53 +12,54 @@ import (
 	"github.com/google/uuid"
 	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
 	webhooks "github.com/kubex-ecosystem/gobe/internal/services/webhooks"
+	"github.com/kubex-ecosystem/gobe/internal/services/webhooks/verify"
 )
 
 // WebhookController proxies webhook notifications into the GoBE event bus.
 type WebhookController struct {
 	webhookService *webhooks.WebhookService
+	verifiers      *verify.Registry
 }
 
-func NewWebhookController(webhookService *webhooks.WebhookService) *WebhookController {
+// NewWebhookController creates the controller. Deliveries are checked
+// against verifiers when it is not nil.
+func NewWebhookController(webhookService *webhooks.WebhookService, verifiers *verify.Registry) *WebhookController {
 	return &WebhookController{
 		webhookService: webhookService,
+		verifiers:      verifiers,
 	}
 }
 
 // Handle receives webhook events and acknowledges their processing.
 //
 // @Summary     Receber webhook
-// @Description Aceita eventos externos, valida o JSON e agenda processamento interno
+// @Description Aceita eventos externos, valida a assinatura da fonte (GitHub, Stripe, Slack, Discord ou HMAC, conforme a configuração `webhooks.sources`) e o JSON e agenda processamento interno. Entregas recusadas são registradas com o motivo; IDs de entrega repetidos são recusados.
 // @Tags        gateway
 // @Security    BearerAuth
 // @Accept      json
 // @Produce     json
+// @Param       source query string false "Fonte do webhook (alternativa ao header X-Webhook-Source)"
 // @Param       payload body map[string]interface{} true "Evento enviado pelo provedor"
 // @Success     202 {object} WebhookAckResponse
 // @Failure     400 {object} ErrorResponse
 // @Failure     401 {object} ErrorResponse
 // @Router      /v1/webhooks [post]
 func (wc *WebhookController) Handle(c *gin.Context) {
-	var payload map[string]interface{}
-	if err := c.ShouldBindJSON(&payload); err != nil {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Status: "error", Message: "invalid request body"})
 		return
 	}
 
-	// Extract source and event type from headers or payload
+	// Extract source and event type from headers or query
 	source := c.GetHeader("X-Webhook-Source")
+	if source == "" {
+		source = c.Query("source")
+	}
 	if source == "" {
 		source = "unknown"
 	}
-
 	eventType := c.GetHeader("X-Event-Type")
-	if eventType == "" {
-		if et, exists := payload["event_type"].(string); exists {
-			eventType = et
-		} else {
-			eventType = "generic"
-		}
-	}
 
 	// Extract headers
 	headers := make(map[string]string)
@@ -65,10 +69,41 @@ func (wc *WebhookController) Handle(c *gin.Context) {
 		}
 	}
 
+	var deliveryID string
+	if wc.verifiers != nil {
+		deliveryID, err = wc.verifiers.Verify(source, c.Request.Header, body)
+		if err != nil {
+			if wc.webhookService != nil {
+				if _, recErr := wc.webhookService.RecordRejected(source, eventType, headers, err.Error()); recErr != nil {
+					gl.Log("error", "Failed to record rejected webhook", recErr)
+				}
+			}
+			c.JSON(http.StatusUnauthorized, ErrorResponse{Status: "error", Message: err.Error()})
+			return
+		}
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		wc.verifiers.Release(source, deliveryID)
+		c.JSON(http.StatusBadRequest, ErrorResponse{Status: "error", Message: "invalid request body"})
+		return
+	}
+
+	if eventType == "" {
+		if et, exists := payload["event_type"].(string); exists {
+			eventType = et
+		} else {
+			eventType = "generic"
+		}
+	}
+
 	// Process webhook using service
 	if wc.webhookService != nil {
 		event, err := wc.webhookService.ReceiveWebhook(source, eventType, payload, headers)
 		if err != nil {
+			// let the sender retry the delivery
+			wc.verifiers.Release(source, deliveryID)
 			gl.Log("error", "Failed to process webhook", err)
 			c.JSON(http.StatusInternalServerError, ErrorResponse{Status: "error", Message: "failed to process webhook"})
 			return
@@ -123,14 +158,20 @@ func (wc *WebhookController) Health(c *gin.Context) {
 // ListEvents returns a paginated list of webhook events.
 //
 // @Summary     Listar webhook events
-// @Description Retorna lista paginada de eventos de webhook recebidos
+// @Description Retorna eventos de webhook recebidos, do mais recente para o mais antigo, filtrando por fonte, tipo, status e intervalo `from`–`to` (RFC3339 ou AAAA-MM-DD). Use `next_cursor` da resposta como `cursor` para a próxima página.
 // @Tags        gateway
 // @Security    BearerAuth
 // @Produce     json
 // @Param       limit query int false "Número máximo de eventos (default: 50)"
-// @Param       offset query int false "Número de eventos a pular (default: 0)"
+// @Param       cursor query string false "Cursor da próxima página"
+// @Param       offset query int false "Número de eventos a pular (ignorado com cursor)"
 // @Param       source query string false "Filtrar por fonte do webhook"
+// @Param       type query string false "Filtrar por tipo do evento"
+// @Param       status query string false "Filtrar por status (received, processing, completed, failed)"
+// @Param       from query string false "Início (inclusivo)"
+// @Param       to query string false "Fim (exclusivo)"
 // @Success     200 {object} WebhookEventsResponse
+// @Failure     400 {object} ErrorResponse
 // @Failure     401 {object} ErrorResponse
 // @Router      /v1/webhooks/events [get]
 func (wc *WebhookController) ListEvents(c *gin.Context) {
@@ -142,7 +183,6 @@ func (wc *WebhookController) ListEvents(c *gin.Context) {
 	// Parse query parameters
 	limitStr := c.DefaultQuery("limit", "50")
 	offsetStr := c.DefaultQuery("offset", "0")
-	source := c.Query("source")
 
 	limit, err := strconv.Atoi(limitStr)
 	if err != nil || limit < 1 {
@@ -157,7 +197,28 @@ func (wc *WebhookController) ListEvents(c *gin.Context) {
 		offset = 0
 	}
 
-	events, total, err := wc.webhookService.ListWebhookEvents(limit, offset, source)
+	query := webhooks.EventQuery{
+		Source:    c.Query("source"),
+		EventType: c.Query("type"),
+		Status:    c.Query("status"),
+		Cursor:    c.Query("cursor"),
+		Offset:    offset,
+		Limit:     limit,
+	}
+	if query.From, err = usageTime(c.Query("from")); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Status: "error", Message: "invalid from"})
+		return
+	}
+	if query.To, err = usageTime(c.Query("to")); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Status: "error", Message: "invalid to"})
+		return
+	}
+
+	page, err := wc.webhookService.ListEvents(c.Request.Context(), query)
+	if errors.Is(err, webhooks.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Status: "error", Message: "invalid cursor"})
+		return
+	}
 	if err != nil {
 		gl.Log("error", "Failed to list webhook events", err)
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Status: "error", Message: "failed to list events"})
@@ -165,17 +226,21 @@ func (wc *WebhookController) ListEvents(c *gin.Context) {
 	}
 
 	// Convert events to interface{} slice
-	eventInterfaces := make([]interface{}, len(events))
-	for i, event := range events {
+	eventInterfaces := make([]interface{}, len(page.Events))
+	for i, event := range page.Events {
 		eventInterfaces[i] = event
 	}
 
-	c.JSON(http.StatusOK, WebhookEventsResponse{
-		Events: eventInterfaces,
-		Total:  total,
-		Limit:  limit,
-		Offset: offset,
-	})
+	response := WebhookEventsResponse{
+		Events:     eventInterfaces,
+		Total:      int(page.Total),
+		Limit:      limit,
+		NextCursor: page.NextCursor,
+	}
+	if query.Cursor == "" {
+		response.Offset = offset
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // GetEvent returns details of a specific webhook event.
@@ -19,6 +19,7 @@ import (
 	"github.com/google/uuid"
 	gdbtypes "github.com/kubex-ecosystem/gdbase/types"
 	"github.com/kubex-ecosystem/gobe/internal/services/analyzer"
+	"github.com/kubex-ecosystem/gobe/internal/services/webhooks/outbound"
 	"gorm.io/gorm"
 
 	m "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
@@ -31,9 +32,12 @@ type AnalyzerController struct {
 	APIWrapper      *t.APIWrapper[any]
 	analyzerService *analyzer.Service
 	analysisService m.AnalysisJobService
+	dispatcher      *outbound.Dispatcher
 }
 
-func NewAnalyzerController(db *gorm.DB) *AnalyzerController {
+// NewAnalyzerController creates the analyzer controller; webhook
+// notifications are queued with dispatcher, dropped when it is nil.
+func NewAnalyzerController(db *gorm.DB, dispatcher *outbound.Dispatcher) *AnalyzerController {
 	if db == nil {
 		gl.Log("warn", "Database connection is nil for AnalyzerController")
 	}
@@ -64,6 +68,7 @@ func NewAnalyzerController(db *gorm.DB) *AnalyzerController {
 		APIWrapper:      t.NewAPIWrapper[any](),
 		analyzerService: analyzerService,
 		analysisService: analysisService,
+		dispatcher:      dispatcher,
 	}
 }
 
@@ -876,8 +881,17 @@ func (ac *AnalyzerController) sendEmailNotification(ctx context.Context, req Not
 	return nil
 }
 
-// sendWebhookNotification sends notification via HTTP webhook
+// sendWebhookNotification posts the notification to each recipient URL and
+// publishes it to the outbound webhook subscribers. Deliveries are queued and
+// retried by the outbound dispatcher; like the other channels it is best
+// effort, so failures are logged and not returned.
 func (ac *AnalyzerController) sendWebhookNotification(ctx context.Context, req NotificationRequest, messageID string) error {
+	dispatcher := ac.dispatcher
+	if dispatcher == nil {
+		gl.Log("warn", "Outbound webhooks are not available, webhook notification dropped", "message_id", messageID)
+		return nil
+	}
+
 	// Prepare webhook payload
 	payload := map[string]interface{}{
 		"message_id": messageID,
@@ -889,13 +903,18 @@ func (ac *AnalyzerController) sendWebhookNotification(ctx context.Context, req N
 		"timestamp":  time.Now().UTC(),
 	}
 
-	payloadJSON, _ := json.Marshal(payload)
-
-	// Send to each webhook URL
+	// Queue a delivery to each webhook URL
 	for _, recipient := range req.Recipients {
-		// Here you would actually send HTTP POST to webhook URL
-		// For now, just log it
-		gl.Log("info", "Webhook notification sent", "url", recipient, "payload_size", len(payloadJSON), "message_id", messageID)
+		delivery, err := dispatcher.Send(ctx, recipient, outbound.EventAnalyzerNotification, payload)
+		if err != nil {
+			gl.Log("error", "Failed to queue webhook notification", "url", recipient, "message_id", messageID, err)
+			continue
+		}
+		gl.Log("info", "Webhook notification queued", "url", recipient, "delivery_id", delivery.ID, "message_id", messageID)
+	}
+
+	if _, err := dispatcher.Publish(ctx, outbound.EventAnalyzerNotification, payload); err != nil {
+		gl.Log("error", "Failed to publish analyzer notification", err)
 	}
 
 	return nil
@@ -2,7 +2,9 @@
 package system
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -16,6 +18,7 @@ import (
 	services "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
 	"github.com/kubex-ecosystem/gobe/internal/contracts/types"
 	"github.com/kubex-ecosystem/gobe/internal/module/logger"
+	"github.com/kubex-ecosystem/gobe/internal/observers/events"
 	"github.com/kubex-ecosystem/gobe/internal/services/mcp"
 	"github.com/kubex-ecosystem/gobe/internal/services/mcp/hooks"
 	"github.com/kubex-ecosystem/gobe/internal/services/mcp/system"
@@ -28,6 +31,9 @@ var (
 	gl          = logger.GetLogger[l.Logger](nil)
 	sysServ     services.ISystemService
 	mcpRegistry mcp.Registry
+	mcpServer   *mcp.Server
+	mcpHTTP     *mcp.HTTPTransport
+	mcpJobs     *mcp.JobManager
 )
 
 type MetricsController struct {
@@ -35,6 +41,8 @@ type MetricsController struct {
 	mcpState      *hooks.Bitstate[uint64, system.SystemDomain]
 	systemService services.ISystemService
 	registry      mcp.Registry
+	transport     *mcp.HTTPTransport
+	jobs          *mcp.JobManager
 	apiWrapper    *types.APIWrapper[interface{}]
 }
 
@@ -44,21 +52,31 @@ func NewMetricsController(db *gorm.DB) *MetricsController {
 	}
 
 	// Initialize registry if not already done
-	if mcpRegistry == nil {
-		mcpRegistry = mcp.NewRegistry()
-		gl.Log("info", "Initialized new MCP registry")
+	GetMCPRegistry()
 
-		// Register built-in tools
-		err := mcp.RegisterBuiltinTools(mcpRegistry)
+	// Serve the registry over the MCP HTTP transports (SSE and streamable HTTP)
+	if mcpServer == nil {
+		srv, err := mcp.NewServer(nil)
 		if err != nil {
-			gl.Log("error", "Failed to register built-in tools", err)
+			gl.Log("error", "Failed to create MCP server", err)
+		} else {
+			srv.RegisterRegistryTools(mcpRegistry)
+			mcpServer = srv
+			mcpHTTP = srv.NewHTTPTransport(mcp.DefaultHTTPBasePath)
 		}
 	}
 
+	// Background execution of registry tools (POST /mcp/exec with async=true)
+	if mcpJobs == nil {
+		mcpJobs = mcp.NewJobManager(mcpRegistry, mcp.DefaultJobTTL)
+	}
+
 	return &MetricsController{
 		dbConn:        db,
 		systemService: sysServ,
 		registry:      mcpRegistry,
+		transport:     mcpHTTP,
+		jobs:          mcpJobs,
 		apiWrapper:    types.NewAPIWrapper[interface{}](),
 	}
 }
@@ -141,6 +159,87 @@ func SetSystemService(service services.ISystemService) {
 	sysServ = service
 }
 
+// SetMCPPolicy replaces the authorization policy used by the MCP tool registry.
+// A nil policy restores the default, which only enforces each tool's Auth field.
+func SetMCPPolicy(policy mcp.Policy) {
+	if mcpRegistry == nil {
+		gl.Log("warn", "MCP registry is not initialized, policy not applied")
+		return
+	}
+	mcpRegistry.SetPolicy(policy)
+	if mcpServer != nil {
+		mcpServer.SetPolicy(policy)
+	}
+}
+
+// SetMCPJobTTL sets how long results of async MCP tool calls are kept.
+func SetMCPJobTTL(ttl time.Duration) {
+	if mcpJobs == nil {
+		gl.Log("warn", "MCP job manager is not initialized, job TTL not applied")
+		return
+	}
+	mcpJobs.SetTTL(ttl)
+}
+
+// SetMCPEventStream publishes progress of async MCP tool calls on stream.
+func SetMCPEventStream(stream *events.Stream) {
+	if mcpJobs == nil {
+		gl.Log("warn", "MCP job manager is not initialized, job events not published")
+		return
+	}
+	mcpJobs.SetEventStream(stream)
+}
+
+// SubmitMCPJob runs a registry tool in the background as the principal in
+// ctx; its progress is published like the one of async MCP tool calls.
+func SubmitMCPJob(ctx context.Context, tool string, args map[string]interface{}) (mcp.Job, error) {
+	if mcpJobs == nil {
+		return mcp.Job{}, errors.New("MCP job manager is not initialized")
+	}
+	return mcpJobs.Submit(ctx, tool, args)
+}
+
+// LoadToolManifests registers the tools declared in dir and reloads them when
+// the files change, republishing them on the MCP HTTP transports.
+func LoadToolManifests(dir string, backends mcp.ManifestBackends) {
+	if mcpRegistry == nil {
+		gl.Log("warn", "MCP registry is not initialized, tool manifests not loaded")
+		return
+	}
+
+	loader := mcp.NewManifestLoader(dir, mcpRegistry, backends)
+	if mcpServer != nil {
+		loader.OnChange(func(removed []string) {
+			mcpServer.SyncRegistryTools(mcpRegistry, removed)
+		})
+	}
+	if _, err := loader.Load(); err != nil {
+		gl.Log("warn", "Some MCP tool manifests were rejected", err)
+	}
+
+	go func() {
+		if err := loader.Watch(context.Background()); err != nil {
+			gl.Log("error", "MCP tool manifest watcher stopped", err)
+		}
+	}()
+}
+
+// GetMCPRegistry returns the shared MCP tool registry, creating it with the
+// built-in tools on first use.
+func GetMCPRegistry() mcp.Registry {
+	if mcpRegistry == nil {
+		mcpRegistry = mcp.NewRegistry()
+		gl.Log("info", "Initialized new MCP registry")
+
+		// Register built-in tools
+		err := mcp.RegisterBuiltinTools(mcpRegistry)
+		if err != nil {
+			gl.Log("error", "Failed to register built-in tools", err)
+		}
+	}
+	return mcpRegistry
+}
+
 // GetSystemService returns the current system service instance.
 func GetSystemService() services.ISystemService {
 	if sysServ == nil {
@@ -565,7 +664,8 @@ func (c *MetricsController) ListTools(ctx *gin.Context) {
 	})
 }
 
-// ExecTool executes an MCP tool by name
+// ExecTool executes an MCP tool by name. With "async": true the call runs in
+// the background and the response carries a job to poll at /mcp/jobs/:id.
 func (c *MetricsController) ExecTool(ctx *gin.Context) {
 	if c.registry == nil {
 		gl.Log("error", "MCP registry is not initialized")
@@ -574,8 +674,9 @@ func (c *MetricsController) ExecTool(ctx *gin.Context) {
 	}
 
 	var request struct {
-		Tool string                 `json:"tool" binding:"required"`
-		Args map[string]interface{} `json:"args"`
+		Tool  string                 `json:"tool" binding:"required"`
+		Args  map[string]interface{} `json:"args"`
+		Async bool                   `json:"async"`
 	}
 
 	if err := ctx.ShouldBindJSON(&request); err != nil {
@@ -588,10 +689,23 @@ func (c *MetricsController) ExecTool(ctx *gin.Context) {
 		request.Args = make(map[string]interface{})
 	}
 
+	if request.Async {
+		if c.jobs == nil {
+			c.apiWrapper.JSONResponseWithError(ctx, fmt.Errorf("async execution not available"))
+			return
+		}
+		job, err := c.jobs.Submit(ctx.Request.Context(), request.Tool, request.Args)
+		if err != nil {
+			c.writeToolError(ctx, request.Tool, err)
+			return
+		}
+		c.apiWrapper.JSONResponse(ctx, "success", "tool execution accepted", "", job, nil, http.StatusAccepted)
+		return
+	}
+
 	result, err := c.registry.Exec(ctx.Request.Context(), request.Tool, request.Args)
 	if err != nil {
-		gl.Log("error", "Tool execution failed", request.Tool, err)
-		c.apiWrapper.JSONResponseWithError(ctx, fmt.Errorf("tool execution failed: %w", err))
+		c.writeToolError(ctx, request.Tool, err)
 		return
 	}
 
@@ -601,6 +715,90 @@ func (c *MetricsController) ExecTool(ctx *gin.Context) {
 	})
 }
 
+// GetJob returns the status, progress and (once finished) result of an async tool call.
+func (c *MetricsController) GetJob(ctx *gin.Context) {
+	if c.jobs == nil {
+		c.apiWrapper.JSONResponseWithError(ctx, fmt.Errorf("async execution not available"))
+		return
+	}
+
+	job, err := c.jobs.Get(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		c.apiWrapper.JSONResponse(ctx, "error", err.Error(), "", nil, nil, http.StatusNotFound)
+		return
+	}
+	c.apiWrapper.JSONResponseWithSuccess(ctx, "job retrieved successfully", "", job)
+}
+
+// CancelJob cancels a pending or running async tool call.
+func (c *MetricsController) CancelJob(ctx *gin.Context) {
+	if c.jobs == nil {
+		c.apiWrapper.JSONResponseWithError(ctx, fmt.Errorf("async execution not available"))
+		return
+	}
+
+	job, err := c.jobs.Cancel(ctx.Request.Context(), ctx.Param("id"))
+	switch {
+	case errors.Is(err, mcp.ErrJobNotFound):
+		c.apiWrapper.JSONResponse(ctx, "error", err.Error(), "", nil, nil, http.StatusNotFound)
+	case errors.Is(err, mcp.ErrJobFinished):
+		c.apiWrapper.JSONResponse(ctx, "error", err.Error(), "", job, nil, http.StatusConflict)
+	case err != nil:
+		c.apiWrapper.JSONResponseWithError(ctx, err)
+	default:
+		c.apiWrapper.JSONResponseWithSuccess(ctx, "job cancelled successfully", "", job)
+	}
+}
+
+// writeToolError maps registry errors to HTTP statuses: 403 for policy
+// denials, 422 for schema violations and 400 for everything else.
+func (c *MetricsController) writeToolError(ctx *gin.Context, tool string, err error) {
+	var authErr *mcp.AuthorizationError
+	if errors.As(err, &authErr) {
+		c.apiWrapper.JSONResponse(ctx, "error", authErr.Error(), "", authErr, nil, http.StatusForbidden)
+		return
+	}
+	var verr *mcp.ValidationError
+	if errors.As(err, &verr) {
+		gl.Log("warn", "Tool call rejected by schema", tool, err)
+		c.apiWrapper.JSONResponse(ctx, "error", verr.Error(), "", map[string]interface{}{
+			"tool":   tool,
+			"target": verr.Target,
+			"errors": verr.Errors,
+		}, nil, http.StatusUnprocessableEntity)
+		return
+	}
+	gl.Log("error", "Tool execution failed", tool, err)
+	c.apiWrapper.JSONResponseWithError(ctx, fmt.Errorf("tool execution failed: %w", err))
+}
+
+// ServeMCPSSE opens an MCP SSE session (GET /mcp/sse).
+func (c *MetricsController) ServeMCPSSE(ctx *gin.Context) {
+	if c.transport == nil {
+		c.apiWrapper.JSONResponseWithError(ctx, fmt.Errorf("mcp transport not available"))
+		return
+	}
+	c.transport.SSEHandler().ServeHTTP(ctx.Writer, ctx.Request)
+}
+
+// ServeMCPMessage receives the JSON-RPC messages of an SSE session (POST /mcp/message).
+func (c *MetricsController) ServeMCPMessage(ctx *gin.Context) {
+	if c.transport == nil {
+		c.apiWrapper.JSONResponseWithError(ctx, fmt.Errorf("mcp transport not available"))
+		return
+	}
+	c.transport.MessageHandler().ServeHTTP(ctx.Writer, ctx.Request)
+}
+
+// ServeMCPStreamable serves the MCP streamable HTTP transport (GET/POST/DELETE /mcp).
+func (c *MetricsController) ServeMCPStreamable(ctx *gin.Context) {
+	if c.transport == nil {
+		c.apiWrapper.JSONResponseWithError(ctx, fmt.Errorf("mcp transport not available"))
+		return
+	}
+	c.transport.StreamableHandler().ServeHTTP(ctx.Writer, ctx.Request)
+}
+
 // Helper functions
 func containsNumbers(s string) bool {
 	for _, r := range s {
@@ -4,8 +4,10 @@ package cron
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -14,17 +16,76 @@ import (
 	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
 	"github.com/kubex-ecosystem/gobe/internal/contracts/types"
 	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+	schedulermgr "github.com/kubex-ecosystem/gobe/internal/services/scheduler/manager"
+	"github.com/kubex-ecosystem/gobe/internal/services/scheduler/runner"
+	schedulersvc "github.com/kubex-ecosystem/gobe/internal/services/scheduler/services"
+)
+
+// Bounds of the "limit" query parameter of GetExecutionLogs.
+const (
+	defaultLogsLimit = 50
+	maxLogsLimit     = 500
 )
 
 type CronController struct {
 	ICronService cron.CronJobService
 	APIWrapper   *types.APIWrapper[cron.CronJobModel]
+
+	// jobRunner runs the jobs; the router's reaches the MCP registry, the
+	// gateway and the broker.
+	jobRunner *runner.Runner
+	// scheduler is kept up to date with the stored jobs, when not nil.
+	scheduler *schedulermgr.CronJobScheduler
 }
 
 func respondCronError(c *gin.Context, status int, message string) {
 	c.JSON(status, ErrorResponse{Status: "error", Message: message})
 }
 
+// applyJobRequest copies the work described by req to job, keeping the
+// fields req leaves empty.
+func applyJobRequest(job *cron.CronJobModel, req CronJobRequest) {
+	if req.CronType != "" {
+		job.CronType = req.CronType
+	}
+	if req.Command != "" {
+		job.Command = req.Command
+	}
+	if req.Method != "" {
+		job.Method = strings.ToUpper(req.Method)
+	}
+	if req.APIEndpoint != "" {
+		job.APIEndpoint = req.APIEndpoint
+	}
+	if req.Payload != nil {
+		job.Payload = req.Payload
+	}
+	if req.Headers != nil {
+		job.Headers = req.Headers
+	}
+	if req.Metadata != nil {
+		job.Metadata = req.Metadata
+	}
+	for key, value := range map[string]string{"kind": req.Kind, "timezone": req.Timezone, "overlap": req.Overlap} {
+		if value == "" {
+			continue
+		}
+		if job.Metadata == nil {
+			job.Metadata = map[string]any{}
+		}
+		job.Metadata[key] = value
+	}
+	if req.ExecTimeout > 0 {
+		job.ExecTimeout = req.ExecTimeout
+	}
+	if req.MaxRetries != nil {
+		job.MaxRetries = *req.MaxRetries
+	}
+	if req.RetryInterval > 0 {
+		job.RetryInterval = req.RetryInterval
+	}
+}
+
 func cronIDFromContext(ctx context.Context) (uuid.UUID, bool) {
 	if value := ctx.Value(types.CtxKey("cronID")); value != nil {
 		if id, ok := value.(uuid.UUID); ok {
@@ -68,12 +129,58 @@ func marshalToMapSlice(value any) ([]map[string]any, error) {
 	return response, nil
 }
 
-func NewCronJobController(bridge *svc.Bridge) *CronController {
+// NewCronJobController creates the cron controller running the jobs with
+// jobRunner, or with a runner of its own when it is nil, and rescheduling
+// them on scheduler.
+func NewCronJobController(bridge *svc.Bridge, jobRunner *runner.Runner, scheduler *schedulermgr.CronJobScheduler) *CronController {
+	if jobRunner == nil {
+		jobRunner = runner.New(bridge.CronRepo(), bridge.CronExecutionStore(), runner.DefaultOptions)
+	}
 	return &CronController{
 
 		ICronService: bridge.CronService(),
 		APIWrapper:   types.NewAPIWrapper[cron.CronJobModel](),
+		jobRunner:    jobRunner,
+		scheduler:    scheduler,
+	}
+}
+
+// validateJob reports why job could not be scheduled or run.
+func validateJob(job *cron.CronJobModel) error {
+	if strings.TrimSpace(job.CronExpression) != "" {
+		if err := schedulersvc.ValidateSchedule(job); err != nil {
+			return err
+		}
+	}
+	return runner.Validate(job)
+}
+
+// syncSchedule brings the schedule of the cron job cronID up to date with
+// its stored version.
+func (cc *CronController) syncSchedule(ctx context.Context, cronID uuid.UUID) {
+	if cc.scheduler != nil {
+		if err := cc.scheduler.Sync(ctx, cronID); err != nil {
+			gl.Log("warn", fmt.Sprintf("failed to reschedule cron job %s: %s", cronID, err))
+		}
+	}
+}
+
+// runJob runs the cron job cronID now and answers with its execution.
+func (cc *CronController) runJob(ctx context.Context, c *gin.Context, cronID uuid.UUID) {
+	record, err := cc.jobRunner.RunByID(ctx, cronID, runner.TriggerManual)
+	if errors.Is(err, runner.ErrJobNotFound) {
+		respondCronError(c, http.StatusNotFound, "cron job not found")
+		return
+	}
+	if record == nil {
+		respondCronError(c, http.StatusInternalServerError, "failed to execute cron job")
+		return
 	}
+	message := "Cron job executed successfully"
+	if err != nil {
+		message = "Cron job run failed"
+	}
+	c.JSON(http.StatusOK, CronExecutionResponse{Message: message, Execution: *record})
 }
 
 func (cc *CronController) RegisterRoutes(router *gin.Engine) {
@@ -203,11 +310,17 @@ func (cc *CronController) CreateCronJob(c *gin.Context) {
 		CreatedBy:      userID,
 		UpdatedBy:      userID,
 	}
+	applyJobRequest(job, req)
+	if err := validateJob(job); err != nil {
+		respondCronError(c, http.StatusBadRequest, err.Error())
+		return
+	}
 	createdJob, err := cc.ICronService.CreateCronJob(ctx, job)
 	if err != nil {
 		respondCronError(c, http.StatusInternalServerError, "failed to create cron job")
 		return
 	}
+	cc.syncSchedule(ctx, createdJob.ID)
 	c.JSON(http.StatusCreated, CronJobResponse{Job: *createdJob})
 }
 
@@ -254,6 +367,11 @@ func (cc *CronController) UpdateCronJob(c *gin.Context) {
 	}
 	existing.Description = req.Description
 	existing.IsActive = req.Enabled
+	applyJobRequest(existing, req)
+	if err := validateJob(existing); err != nil {
+		respondCronError(c, http.StatusBadRequest, err.Error())
+		return
+	}
 	if userID, ok := userIDFromContext(ctx); ok {
 		existing.UpdatedBy = userID
 	}
@@ -262,6 +380,7 @@ func (cc *CronController) UpdateCronJob(c *gin.Context) {
 		respondCronError(c, http.StatusInternalServerError, "failed to update cron job")
 		return
 	}
+	cc.syncSchedule(ctx, cronID)
 	c.JSON(http.StatusOK, CronJobResponse{Job: *updatedJob})
 }
 
@@ -314,6 +433,7 @@ func (cc *CronController) DeleteCronJob(c *gin.Context) {
 		respondCronError(c, http.StatusInternalServerError, "failed to delete cron job")
 		return
 	}
+	cc.syncSchedule(ctx, cronID)
 	c.JSON(http.StatusOK, CronActionResponse{Message: "Cron job deleted successfully"})
 }
 
@@ -346,6 +466,7 @@ func (cc *CronController) EnableCronJob(c *gin.Context) {
 		respondCronError(c, http.StatusInternalServerError, "failed to enable cron job")
 		return
 	}
+	cc.syncSchedule(ctx, cronID)
 	c.JSON(http.StatusOK, CronActionResponse{Message: "Cron job enabled successfully"})
 }
 
@@ -378,18 +499,19 @@ func (cc *CronController) DisableCronJob(c *gin.Context) {
 		respondCronError(c, http.StatusInternalServerError, "failed to disable cron job")
 		return
 	}
+	cc.syncSchedule(ctx, cronID)
 	c.JSON(http.StatusOK, CronActionResponse{Message: "Cron job disabled successfully"})
 }
 
 // ExecuteCronJobManually executa o cron job imediatamente.
 //
 // @Summary     Executar cron job manualmente
-// @Description Dispara manualmente a execução do cron job informado. [Em desenvolvimento]
+// @Description Executa o cron job informado e retorna o registro da execução. [Em desenvolvimento]
 // @Tags        cron beta
 // @Security    BearerAuth
 // @Produce     json
 // @Param       id path string true "ID do cron job"
-// @Success     200 {object} CronActionResponse
+// @Success     200 {object} CronExecutionResponse
 // @Failure     400 {object} ErrorResponse
 // @Failure     401 {object} ErrorResponse
 // @Failure     404 {object} ErrorResponse
@@ -406,11 +528,7 @@ func (cc *CronController) ExecuteCronJobManually(c *gin.Context) {
 		respondCronError(c, http.StatusBadRequest, "invalid cron job id")
 		return
 	}
-	if err := cc.ICronService.ExecuteCronJobManually(ctx, cronID); err != nil {
-		respondCronError(c, http.StatusInternalServerError, "failed to execute cron job")
-		return
-	}
-	c.JSON(http.StatusOK, CronActionResponse{Message: "Cron job executed successfully"})
+	cc.runJob(ctx, c, cronID)
 }
 
 // ExecuteCronJobManuallyByID mantém compatibilidade com rotas antigas.
@@ -422,7 +540,7 @@ func (cc *CronController) ExecuteCronJobManually(c *gin.Context) {
 // @Produce     json
 // @Param       id     path string true "ID do cron job"
 // @Param       job_id path string false "ID adicional do job"
-// @Success     200 {object} CronActionResponse
+// @Success     200 {object} CronExecutionResponse
 // @Failure     400 {object} ErrorResponse
 // @Failure     401 {object} ErrorResponse
 // @Failure     404 {object} ErrorResponse
@@ -445,15 +563,11 @@ func (cc *CronController) ExecuteCronJobManuallyByID(c *gin.Context) {
 		respondCronError(c, http.StatusNotFound, "cron job not found")
 		return
 	}
-	if job.LastRunStatus == "running" {
+	if job.LastRunStatus == "running" || cc.jobRunner.Running(cronID) {
 		respondCronError(c, http.StatusBadRequest, "cron job currently running")
 		return
 	}
-	if err := cc.ICronService.ExecuteCronJobManually(ctx, cronID); err != nil {
-		respondCronError(c, http.StatusInternalServerError, "failed to execute cron job")
-		return
-	}
-	c.JSON(http.StatusOK, CronActionResponse{Message: "Cron job executed successfully"})
+	cc.runJob(ctx, c, cronID)
 }
 
 // RescheduleCronJob atualiza a expressão de agendamento.
@@ -501,10 +615,17 @@ func (cc *CronController) RescheduleCronJob(c *gin.Context) {
 		respondCronError(c, http.StatusBadRequest, "cron job is associated with a user and cannot be rescheduled")
 		return
 	}
+	rescheduled := *job
+	rescheduled.CronExpression = payload.NewExpression
+	if err := schedulersvc.ValidateSchedule(&rescheduled); err != nil {
+		respondCronError(c, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err := cc.ICronService.RescheduleCronJob(ctx, cronID, payload.NewExpression); err != nil {
 		respondCronError(c, http.StatusInternalServerError, "failed to reschedule cron job")
 		return
 	}
+	cc.syncSchedule(ctx, cronID)
 	c.JSON(http.StatusOK, CronActionResponse{Message: "Cron job rescheduled successfully"})
 }
 
@@ -648,11 +769,12 @@ func (cc *CronController) ReprocessFailedJobs(c *gin.Context) {
 // GetExecutionLogs lista os logs de execução de um cron job.
 //
 // @Summary     Listar logs de execução
-// @Description Recupera os logs associados ao cron job informado. [Em desenvolvimento]
+// @Description Recupera as últimas execuções do cron job informado, da mais recente à mais antiga. [Em desenvolvimento]
 // @Tags        cron beta
 // @Security    BearerAuth
 // @Produce     json
-// @Param       id path string true "ID do cron job"
+// @Param       id    path  string true  "ID do cron job"
+// @Param       limit query int    false "Quantidade máxima de execuções (50, até 500)"
 // @Success     200 {object} CronExecutionLogsResponse
 // @Failure     400 {object} ErrorResponse
 // @Failure     401 {object} ErrorResponse
@@ -670,7 +792,16 @@ func (cc *CronController) GetExecutionLogs(c *gin.Context) {
 		respondCronError(c, http.StatusBadRequest, "invalid cron job id")
 		return
 	}
-	logs, err := cc.ICronService.GetExecutionLogs(ctx, cronID)
+	limit := defaultLogsLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			respondCronError(c, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		limit = min(parsed, maxLogsLimit)
+	}
+	logs, err := cc.jobRunner.Executions(ctx, cronID, limit)
 	if err != nil {
 		respondCronError(c, http.StatusInternalServerError, "failed to retrieve execution logs")
 		return
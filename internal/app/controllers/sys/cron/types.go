@@ -2,6 +2,7 @@ package cron
 
 import (
 	cron "github.com/kubex-ecosystem/gdbase/factory/models"
+	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
 	t "github.com/kubex-ecosystem/gobe/internal/contracts/types"
 )
 
@@ -11,11 +12,28 @@ type (
 )
 
 // CronJobRequest representa o payload básico de criação/atualização de cron job.
+// Kind escolhe o trabalho executado (command, http, mcp_tool, llm ou amqp) e
+// os demais campos o descrevem; campos omitidos na atualização são mantidos.
+// Timezone (ex.: "America/Sao_Paulo") e Overlap (skip, delay ou allow)
+// ajustam o agendamento e ficam em metadata.
 type CronJobRequest struct {
-	Name        string `json:"name"`
-	Expression  string `json:"expression"`
-	Description string `json:"description,omitempty"`
-	Enabled     bool   `json:"enabled"`
+	Name          string         `json:"name"`
+	Expression    string         `json:"expression"`
+	Description   string         `json:"description,omitempty"`
+	Enabled       bool           `json:"enabled"`
+	Kind          string         `json:"kind,omitempty"`
+	CronType      string         `json:"cron_type,omitempty"`
+	Command       string         `json:"command,omitempty"`
+	Method        string         `json:"method,omitempty"`
+	APIEndpoint   string         `json:"api_endpoint,omitempty"`
+	Payload       map[string]any `json:"payload,omitempty"`
+	Headers       map[string]any `json:"headers,omitempty"`
+	Metadata      map[string]any `json:"metadata,omitempty"`
+	ExecTimeout   int            `json:"exec_timeout,omitempty"`
+	MaxRetries    *int           `json:"max_retries,omitempty"`
+	RetryInterval int            `json:"retry_interval,omitempty"`
+	Timezone      string         `json:"timezone,omitempty"`
+	Overlap       string         `json:"overlap,omitempty"`
 }
 
 // CronJobResponse descreve o retorno dos endpoints principais.
@@ -54,6 +72,12 @@ type CronJobQueueResponse struct {
 	Queue []map[string]any `json:"queue"`
 }
 
+// CronExecutionResponse traz o registro de uma execução de cron job.
+type CronExecutionResponse struct {
+	Message   string                  `json:"message"`
+	Execution svc.CronExecutionRecord `json:"execution"`
+}
+
 // CronExecutionLogsResponse agrega os logs de execução associados a um cron job.
 type CronExecutionLogsResponse struct {
 	Logs []map[string]any `json:"logs"`
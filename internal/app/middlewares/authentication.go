@@ -22,6 +22,7 @@ import (
 	sci "github.com/kubex-ecosystem/gobe/internal/app/security/interfaces"
 	srv "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
 	cm "github.com/kubex-ecosystem/gobe/internal/commons"
+	"github.com/kubex-ecosystem/gobe/internal/contracts/types"
 	"github.com/kubex-ecosystem/gobe/internal/module/logger"
 )
 
@@ -113,24 +114,23 @@ func (a *AuthenticationMiddleware) ValidateJWT(next gin.HandlerFunc) gin.Handler
 			return
 		}
 
-		type CtxKey string
-
-		// Criando um contexto com o usuário autenticado
-		ctx := context.WithValue(c.Request.Context(), CtxKey("user"), claims)
+		// Criando um contexto com o usuário autenticado (claims completas,
+		// incluindo roles/scopes usados pelas políticas MCP)
+		ctx := context.WithValue(c.Request.Context(), types.CtxKey("user"), claims)
 		c.Request = c.Request.WithContext(ctx)
 
 		c.Next()
 	}
 }
 
-func (a *AuthenticationMiddleware) validateToken(tokenString string) (*jwt.RegisteredClaims, error) {
+func (a *AuthenticationMiddleware) validateToken(tokenString string) (jwt.MapClaims, error) {
 	publicK, err := a.CertService.GetPublicKey()
 	if err != nil {
 		gl.Log("error", fmt.Sprintf("Error getting public key: %v", err))
 		return nil, err
 	}
 
-	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
+	token, err := jwt.ParseWithClaims(tokenString, jwt.MapClaims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
 			gl.Log("error", fmt.Sprintf("Unexpected signing method: %v", token.Header["alg"]))
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -146,7 +146,7 @@ func (a *AuthenticationMiddleware) validateToken(tokenString string) (*jwt.Regis
 		return nil, fmt.Errorf("access denied")
 	}
 
-	if claims, ok := token.Claims.(*jwt.RegisteredClaims); ok && token.Valid {
+	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
 		return claims, nil
 	}
 
@@ -1,12 +1,49 @@
 package middlewares
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
 	"github.com/gin-gonic/gin"
+	"github.com/kubex-ecosystem/gobe/internal/services/gateway/ledger"
 )
 
-func BudgetMiddleware() gin.HandlerFunc {
+// BudgetChecker tells whether a tenant may still spend. *ledger.Ledger
+// implements it.
+type BudgetChecker interface {
+	CheckBudget(ctx context.Context, tenant string) error
+}
+
+// BudgetMiddleware rejects requests with 402 once the tenant named by the
+// x-tenant-id header has spent its daily or monthly budget. Requests pass
+// when checker is nil or the ledger cannot be read.
+func BudgetMiddleware(checker BudgetChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Budget middleware logic
-		c.Next()
+		if checker == nil {
+			return
+		}
+
+		tenant := strings.TrimSpace(c.GetHeader("x-tenant-id"))
+		err := checker.CheckBudget(c.Request.Context(), tenant)
+		if err == nil {
+			return
+		}
+
+		var budgetErr *ledger.BudgetError
+		if !errors.As(err, &budgetErr) {
+			gl.Log("warn", fmt.Sprintf("budget check failed, letting request through: %v", err))
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusPaymentRequired, gin.H{
+			"error":     budgetErr.Error(),
+			"tenant":    budgetErr.Tenant,
+			"period":    budgetErr.Period,
+			"limit_usd": budgetErr.LimitUSD,
+			"spent_usd": budgetErr.SpentUSD,
+			"resets_at": budgetErr.ResetsAt,
+		})
 	}
 }
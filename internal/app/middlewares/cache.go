@@ -1,12 +1,84 @@
 package middlewares
 
 import (
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"github.com/kubex-ecosystem/gobe/internal/services/gateway/cache"
 )
 
+// CacheMiddleware hands the Cache-Control directives of the request
+// (no-cache, no-store, max-age) to the gateway response cache and reports
+// its outcome in the X-Cache (HIT, MISS or BYPASS) and Age response headers.
+// Requests that never reach the cache get neither header.
 func CacheMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Cache middleware logic
+		directive := parseCacheControl(c.GetHeader("Cache-Control"))
+		c.Request = c.Request.WithContext(cache.WithDirective(c.Request.Context(), directive))
+		c.Writer = &cacheStatusWriter{ResponseWriter: c.Writer, directive: directive}
 		c.Next()
 	}
 }
+
+func parseCacheControl(header string) *cache.Directive {
+	directive := &cache.Directive{}
+	for _, part := range strings.Split(header, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch strings.ToLower(name) {
+		case "no-cache":
+			directive.NoCache = true
+		case "no-store":
+			directive.NoStore = true
+		case "max-age":
+			if seconds, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil && seconds > 0 {
+				directive.MaxAge = time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	return directive
+}
+
+// cacheStatusWriter adds the cache headers right before the response
+// headers are sent.
+type cacheStatusWriter struct {
+	gin.ResponseWriter
+	directive *cache.Directive
+	done      bool
+}
+
+func (w *cacheStatusWriter) setHeaders() {
+	if w.done || w.ResponseWriter.Written() {
+		return
+	}
+	w.done = true
+	status, age := w.directive.Outcome()
+	if status == "" {
+		return
+	}
+	w.Header().Set("X-Cache", status)
+	if status == cache.StatusHit {
+		w.Header().Set("Age", strconv.Itoa(int(age.Seconds())))
+	}
+}
+
+func (w *cacheStatusWriter) WriteHeaderNow() {
+	w.setHeaders()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *cacheStatusWriter) Write(data []byte) (int, error) {
+	w.setHeaders()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *cacheStatusWriter) WriteString(s string) (int, error) {
+	w.setHeaders()
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *cacheStatusWriter) Flush() {
+	w.setHeaders()
+	w.ResponseWriter.Flush()
+}
@@ -1,6 +1,7 @@
 package middlewares
 
 import (
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -8,6 +9,12 @@ import (
 
 func TimeoutMiddleware(duration time.Duration) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// Long-lived streams (SSE, WebSocket) must not be cut by the request timeout
+		if isStreamingRequest(c) {
+			c.Next()
+			return
+		}
+
 		// Create a channel to signal when the request is done
 		done := make(chan struct{})
 
@@ -27,3 +34,8 @@ func TimeoutMiddleware(duration time.Duration) gin.HandlerFunc {
 		}
 	}
 }
+
+func isStreamingRequest(c *gin.Context) bool {
+	return strings.Contains(c.GetHeader("Accept"), "text/event-stream") ||
+		strings.EqualFold(c.GetHeader("Upgrade"), "websocket")
+}
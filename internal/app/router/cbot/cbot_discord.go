@@ -2,17 +2,24 @@
 package cbot
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
 
 	discord_controller "github.com/kubex-ecosystem/gobe/internal/app/controllers/app/chatbots/discord"
+	telegram_controller "github.com/kubex-ecosystem/gobe/internal/app/controllers/app/chatbots/telegram"
+	mcp_system_controller "github.com/kubex-ecosystem/gobe/internal/app/controllers/mcp/system"
 	proto "github.com/kubex-ecosystem/gobe/internal/app/router/types"
+	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
 	common "github.com/kubex-ecosystem/gobe/internal/commons"
 	"github.com/kubex-ecosystem/gobe/internal/config"
 	ar "github.com/kubex-ecosystem/gobe/internal/contracts/interfaces"
 	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
 	"github.com/kubex-ecosystem/gobe/internal/proxy/hub"
+	"github.com/kubex-ecosystem/gobe/internal/services/chatbot/telegram"
+	"github.com/kubex-ecosystem/gobe/internal/services/webhooks"
+	"github.com/kubex-ecosystem/gobe/internal/services/webhooks/outbound"
 )
 
 type DiscordRoutes struct {
@@ -20,7 +27,9 @@ type DiscordRoutes struct {
 	h *hub.DiscordMCPHub
 }
 
-func NewDiscordRoutes(rtr *ar.IRouter) map[string]ar.IRoute {
+// NewDiscordRoutes builds the Discord routes; approval decisions are sent
+// to the outbound webhook subscribers through dispatcher, when not nil.
+func NewDiscordRoutes(rtr *ar.IRouter, dispatcher *outbound.Dispatcher) map[string]ar.IRoute {
 	if rtr == nil {
 		gl.Log("error", "Router is nil for DiscordRoute")
 		return nil
@@ -75,9 +84,37 @@ func NewDiscordRoutes(rtr *ar.IRouter) map[string]ar.IRoute {
 		return nil
 	}
 
+	// Approvals survive restarts; the ones left pending resume here
+	if err := h.GetApprovalManager().SetStore(svc.NewBridge(dbGorm).ApprovalStore()); err != nil {
+		gl.Log("error", "Failed to restore pending approvals", err)
+	}
+	h.GetApprovalManager().SetDispatcher(dispatcher)
+	// Approval requests also go to a Telegram chat, decided from its keyboard
+	telegram_controller.SetApprovalHub(h)
+	if tgCfg := cfg.Integrations.Telegram; tgCfg.Enabled && tgCfg.ApprovalChatID != 0 {
+		h.GetApprovalManager().AddPresenter(telegram.NewApprovalPresenter(telegram.NewService(tgCfg), tgCfg.ApprovalChatID))
+	}
+
 	discordController := discord_controller.NewDiscordController(dbGorm, h, cfg)
 
+	// Async MCP jobs report their progress on the hub's event stream
+	mcp_system_controller.SetMCPEventStream(h.GetEventStream())
+	// Webhook rules can post to Discord channels
+	webhooks.RegisterChatSender("discord", func(ctx context.Context, channel, text string) error {
+		return h.SendDiscordMessage(channel, text)
+	})
+	// The ZMQ event bus also carries received webhooks and runs the commands
+	// of the publishers it subscribes to as MCP jobs
+	if bus := h.GetEventBus(); bus != nil {
+		webhooks.RegisterPublisher("zmq", func(ctx context.Context, topic string, body []byte) error {
+			return bus.Publish(topic, body)
+		})
+		h.HandleBusCommands(mcp_system_controller.SubmitMCPJob)
+	}
+
 	routesMap["DiscordWebSocket"] = proto.NewRoute(http.MethodGet, "/api/v1/discord/websocket", "application/json", discordController.HandleWebSocket, middlewaresMap, dbService, secureProperties, nil)
+	routesMap["DiscordEventStream"] = proto.NewRoute(http.MethodGet, "/api/v1/discord/events", "text/event-stream", discordController.HandleEventStream, middlewaresMap, dbService, secureProperties, nil)
+	routesMap["DiscordEventStreamStats"] = proto.NewRoute(http.MethodGet, "/api/v1/discord/events/stats", "application/json", discordController.EventStreamStats, middlewaresMap, dbService, secureProperties, nil)
 	routesMap["DiscordOAuth2Authorize"] = proto.NewRoute(http.MethodGet, "/api/v1/discord/oauth2/authorize", "application/json", discordController.HandleDiscordOAuth2Authorize, middlewaresMap, dbService, secureProperties, nil)
 	routesMap["DiscordOAuth2Token"] = proto.NewRoute(http.MethodGet, "/api/v1/discord/oauth2/token", "application/json", discordController.HandleDiscordOAuth2Token, middlewaresMap, dbService, secureProperties, nil)
 
@@ -89,8 +126,10 @@ func NewDiscordRoutes(rtr *ar.IRouter) map[string]ar.IRoute {
 	routesMap["InteractionsDiscord"] = proto.NewRoute(http.MethodPost, "/api/v1/discord/interactions", "application/json", discordController.HandleDiscordInteractions, nil, dbService, nil, nil)
 	routesMap["GetPendingApprovals"] = proto.NewRoute(http.MethodPost, "/api/v1/discord/interactions/pending", "application/json", discordController.GetPendingApprovals, nil, dbService, nil, nil)
 	routesMap["GetApprovals"] = proto.NewRoute(http.MethodPost, "/api/v1/discord/approvals", "application/json", discordController.GetPendingApprovals, nil, dbService, nil, nil)
-	routesMap["ApproveRequest"] = proto.NewRoute(http.MethodPost, "/api/v1/discord/approve", "application/json", discordController.ApproveRequest, nil, dbService, nil, nil)
-	routesMap["RejectRequest"] = proto.NewRoute(http.MethodPost, "/api/v1/discord/reject", "application/json", discordController.RejectRequest, nil, dbService, nil, nil)
+	routesMap["GetApproval"] = proto.NewRoute(http.MethodGet, "/api/v1/discord/approvals/:id", "application/json", discordController.GetApproval, nil, dbService, nil, nil)
+	// Decisions are recorded under the authenticated approver
+	routesMap["ApproveRequest"] = proto.NewRoute(http.MethodPost, "/api/v1/discord/approve", "application/json", discordController.ApproveRequest, middlewaresMap, dbService, secureProperties, nil)
+	routesMap["RejectRequest"] = proto.NewRoute(http.MethodPost, "/api/v1/discord/reject", "application/json", discordController.RejectRequest, middlewaresMap, dbService, secureProperties, nil)
 	routesMap["HandleTestMessage"] = proto.NewRoute(http.MethodPost, "/api/v1/discord/test", "application/json", discordController.HandleTestMessage, nil, dbService, nil, nil)
 	routesMap["PingAdapter"] = proto.NewRoute(http.MethodGet, "/api/v1/discord/ping", "application/json", discordController.PingAdapter, nil, dbService, nil, nil)
 	routesMap["PingAdapter"] = proto.NewRoute(http.MethodPost, "/api/v1/discord/ping", "application/json", discordController.PingDiscordAdapter, nil, dbService, nil, nil)
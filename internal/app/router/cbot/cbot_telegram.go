@@ -1,8 +1,11 @@
 package cbot
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"os"
+	"strconv"
 
 	telegram_controller "github.com/kubex-ecosystem/gobe/internal/app/controllers/app/chatbots/telegram"
 	proto "github.com/kubex-ecosystem/gobe/internal/app/router/types"
@@ -10,6 +13,7 @@ import (
 	ar "github.com/kubex-ecosystem/gobe/internal/contracts/interfaces"
 	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
 	"github.com/kubex-ecosystem/gobe/internal/services/chatbot/telegram"
+	"github.com/kubex-ecosystem/gobe/internal/services/webhooks"
 )
 
 // NewTelegramRoutes registers Telegram related endpoints.
@@ -44,6 +48,13 @@ func NewTelegramRoutes(rtr *ar.IRouter) map[string]ar.IRoute {
 		return nil
 	}
 	svc := telegram.NewService(cfg.Integrations.Telegram)
+	webhooks.RegisterChatSender("telegram", func(ctx context.Context, channel, text string) error {
+		chatID, err := strconv.ParseInt(channel, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid telegram chat id %q", channel)
+		}
+		return svc.SendMessage(telegram.OutgoingMessage{ChatID: chatID, Text: text})
+	})
 	controller := telegram_controller.NewController(dbGorm, svc)
 	routes := make(map[string]ar.IRoute)
 	routes["TelegramWebhook"] = proto.NewRoute(http.MethodPost, "/api/v1/telegram/webhook", "application/json", controller.HandleWebhook, nil, dbService, nil, nil)
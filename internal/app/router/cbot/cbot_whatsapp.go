@@ -1,6 +1,7 @@
 package cbot
 
 import (
+	"context"
 	"net/http"
 	"os"
 
@@ -10,6 +11,7 @@ import (
 	ar "github.com/kubex-ecosystem/gobe/internal/contracts/interfaces"
 	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
 	"github.com/kubex-ecosystem/gobe/internal/services/chatbot/whatsapp"
+	"github.com/kubex-ecosystem/gobe/internal/services/webhooks"
 )
 
 // NewWhatsAppRoutes registers WhatsApp related endpoints.
@@ -44,6 +46,9 @@ func NewWhatsAppRoutes(rtr *ar.IRouter) map[string]ar.IRoute {
 		return nil
 	}
 	svc := whatsapp.NewService(cfg.Integrations.WhatsApp)
+	webhooks.RegisterChatSender("whatsapp", func(ctx context.Context, channel, text string) error {
+		return svc.SendMessage(whatsapp.OutgoingMessage{To: channel, Text: text})
+	})
 	controller := whatsapp_controller.NewController(dbGorm, svc)
 	routes := make(map[string]ar.IRoute)
 	routes["WhatsAppWebhookPost"] = proto.NewRoute(http.MethodPost, "/api/v1/whatsapp/webhook", "application/json", controller.HandleWebhook, nil, dbService, nil, nil)
@@ -2,20 +2,36 @@
 package gateway
 
 import (
+	"context"
+	"crypto/sha256"
+	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	analyzergateway "github.com/kubex-ecosystem/analyzer/factory/gateway"
 	models "github.com/kubex-ecosystem/gdbase/factory/models/mcp"
 	gatewayController "github.com/kubex-ecosystem/gobe/internal/app/controllers/gateway"
+	mcp_system_controller "github.com/kubex-ecosystem/gobe/internal/app/controllers/mcp/system"
+	"github.com/kubex-ecosystem/gobe/internal/app/middlewares"
 	proto "github.com/kubex-ecosystem/gobe/internal/app/router/types"
+	crt "github.com/kubex-ecosystem/gobe/internal/app/security/certificates"
 	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
+	"github.com/kubex-ecosystem/gobe/internal/config"
 	ar "github.com/kubex-ecosystem/gobe/internal/contracts/interfaces"
 	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+	"github.com/kubex-ecosystem/gobe/internal/services/gateway/cache"
+	"github.com/kubex-ecosystem/gobe/internal/services/gateway/ledger"
 	gatewaysvc "github.com/kubex-ecosystem/gobe/internal/services/gateway/registry"
+	mcpsvc "github.com/kubex-ecosystem/gobe/internal/services/mcp"
+	schedulermgr "github.com/kubex-ecosystem/gobe/internal/services/scheduler/manager"
+	"github.com/kubex-ecosystem/gobe/internal/services/scheduler/runner"
 	webhooksvc "github.com/kubex-ecosystem/gobe/internal/services/webhooks"
+	"github.com/kubex-ecosystem/gobe/internal/services/webhooks/outbound"
+	"github.com/kubex-ecosystem/gobe/internal/services/webhooks/verify"
 	messagery "github.com/kubex-ecosystem/gobe/internal/sockets/messagery"
 	"gorm.io/gorm"
 )
@@ -24,10 +40,29 @@ type GatewayRoutes struct {
 	ar.IRouter
 }
 
-func NewGatewayRoutes(rtr *ar.IRouter) map[string]ar.IRoute {
+// Services are the long-lived services of the gateway module, started once
+// by the router and shared with the other route groups. Each one is nil when
+// it is not available (no database, disabled in the config, ...).
+type Services struct {
+	Gateway    *gatewaysvc.Service
+	Models     gatewayController.ModelCatalog
+	Ledger     *ledger.Ledger
+	Webhooks   *webhooksvc.WebhookService
+	Dispatcher *outbound.Dispatcher
+	Broker     messagery.Broker
+	Consumer   *messagery.Consumer
+	CronRunner *runner.Runner
+	Scheduler  *schedulermgr.CronJobScheduler
+}
+
+// StartServices starts the gateway services; cfg is the gobe config loaded
+// by the router, nil when it could not be read (the services keep their
+// defaults). It never returns nil.
+func StartServices(rtr *ar.IRouter, cfg *config.Config) *Services {
+	services := &Services{}
 	if rtr == nil {
-		gl.Log("error", "Router is nil for GatewayRoutes")
-		return nil
+		gl.Log("error", "Router is nil for the gateway services")
+		return services
 	}
 	rtl := *rtr
 
@@ -40,34 +75,99 @@ func NewGatewayRoutes(rtr *ar.IRouter) map[string]ar.IRoute {
 			gl.Log("warn", "Failed to fetch DB for gateway module", err)
 		}
 	} else {
-		gl.Log("warn", "Database service is nil for GatewayRoutes")
+		gl.Log("warn", "Database service is nil for the gateway services")
+	}
+
+	var dbConfig *messagery.DBConfig
+	if dbService != nil {
+		dbConfig = dbService.GetConfig()
+	}
+	services.Broker = startBroker(cfg, dbConfig)
+	services.Consumer = startBrokerConsumers(cfg, services.Broker)
+	if db == nil {
+		return services
+	}
+
+	services.Ledger = ledger.New(svc.NewBridge(db).UsageLedgerService(), ledger.DefaultPrices)
+	providersSvc := svc.NewProvidersService(models.NewProvidersRepo(db))
+	gw, err := gatewaysvc.NewService(providersSvc)
+	if err != nil {
+		gl.Log("error", "failed to initialize gateway service", err)
+	} else {
+		services.Gateway = gw
+		gw.SetUsageRecorder(services.Ledger)
+		gw.SetCache(cache.New(cache.NewLRU(0), 0))
+		applyGatewayConfig(cfg, gw, services.Ledger)
 	}
+	services.Models = svc.NewBridge(db).LLMService()
 
-	var gatewayService *gatewaysvc.Service
-	var webhookService *webhooksvc.WebhookService
+	// Outbound webhooks: gobe events delivered to subscribers
+	services.Dispatcher = startDispatcher(cfg, db)
 
-	if db != nil {
-		providersSvc := svc.NewProvidersService(models.NewProvidersRepo(db))
-		gw, err := gatewaysvc.NewService(providersSvc)
+	// Initialize webhook service with the message broker
+	webhookOptions := webhooksvc.DefaultOptions
+	if cfg != nil {
+		webhookOptions = webhooksvc.OptionsFromConfig(cfg.Webhooks)
+	}
+	services.Webhooks = webhooksvc.NewWebhookService(services.Broker, svc.NewBridge(db).WebhookEventStore(), webhookOptions)
+	services.Webhooks.SetRuleStore(svc.NewBridge(db).WebhookRuleStore())
+	services.Webhooks.SetDispatcher(services.Dispatcher)
+	services.CronRunner, services.Scheduler = startCronRunner(cfg, db, services.Gateway, services.Broker, services.Dispatcher)
+	registerWebhookActions(services.Webhooks, services.CronRunner)
+	return services
+}
+
+// NewGatewayRoutes builds the gateway routes on the services started by
+// StartServices; cfg is the gobe config loaded by the router, nil when it
+// could not be read (the modules keep their defaults).
+func NewGatewayRoutes(rtr *ar.IRouter, cfg *config.Config, services *Services) map[string]ar.IRoute {
+	if rtr == nil {
+		gl.Log("error", "Router is nil for GatewayRoutes")
+		return nil
+	}
+	rtl := *rtr
+
+	dbService := rtl.GetDatabaseService()
+	var db *gorm.DB
+	if dbService != nil {
+		var err error
+		db, err = dbService.GetDB()
 		if err != nil {
-			gl.Log("error", "failed to initialize gateway service", err)
-		} else {
-			gatewayService = gw
+			gl.Log("warn", "Failed to fetch DB for gateway module", err)
 		}
+	} else {
+		gl.Log("warn", "Database service is nil for GatewayRoutes")
+	}
 
-		// Initialize webhook service with AMQP connection
-		amqp := messagery.NewAMQP()
-		webhookService = webhooksvc.NewWebhookService(amqp)
+	if services == nil {
+		services = &Services{}
+	}
+	gatewayService := services.Gateway
+	webhookService := services.Webhooks
+	dispatcher := services.Dispatcher
+	modelCatalog := services.Models
+	usageLedger := services.Ledger
+	broker := services.Broker
+	brokerConsumer := services.Consumer
+	var budgetChecker middlewares.BudgetChecker
+	if usageLedger != nil {
+		budgetChecker = usageLedger
 	}
 
 	chatController := gatewayController.NewChatController(gatewayService)
+	chatController.SetToolRegistry(mcp_system_controller.GetMCPRegistry())
+	openAIController := gatewayController.NewOpenAIController(gatewayService, modelCatalog)
 	providersController := gatewayController.NewProvidersController(gatewayService)
 	adviseController := gatewayController.NewAdviseController(gatewayService)
 	scorecardController := gatewayController.NewScorecardController(db)
 	healthController := gatewayController.NewHealthController(dbService, gatewayService)
 	lookAtniController := gatewayController.NewLookAtniController(db)
-	webhookController := gatewayController.NewWebhookController(webhookService)
+	webhookController := gatewayController.NewWebhookController(webhookService, webhookVerifiers(cfg))
+	subscriptionController := gatewayController.NewWebhookSubscriptionController(dispatcher)
 	schedulerController := gatewayController.NewSchedulerController()
+	usageController := gatewayController.NewUsageController(usageLedger)
+	cacheController := gatewayController.NewCacheController(gatewayService)
+	brokerController := gatewayController.NewBrokerController(broker, brokerConsumer)
 
 	webRoot := ""
 	if prop := rtl.GetProperty("gateway.web.root"); prop != nil {
@@ -79,6 +179,14 @@ func NewGatewayRoutes(rtr *ar.IRouter) map[string]ar.IRoute {
 
 	routes := make(map[string]ar.IRoute)
 	middlewaresMap := make(map[string]gin.HandlerFunc)
+	budget := middlewares.BudgetMiddleware(budgetChecker)
+	withBudget := func(handler gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			if budget(c); !c.IsAborted() {
+				handler(c)
+			}
+		}
+	}
 	secure := func(secure bool) map[string]bool {
 		return map[string]bool{
 			"secure":                  secure,
@@ -91,12 +199,22 @@ func NewGatewayRoutes(rtr *ar.IRouter) map[string]ar.IRoute {
 	routes["Status"] = proto.NewRoute(http.MethodGet, "/status", "application/json", healthController.Status, middlewaresMap, dbService, secure(true), nil)
 	routes["APIHealth"] = proto.NewRoute(http.MethodGet, "/api/v1/health", "application/json", healthController.APIHealth, middlewaresMap, dbService, secure(true), nil)
 
-	routes["ChatSSE"] = proto.NewRoute(http.MethodPost, "/chat", "text/event-stream", chatController.ChatSSE, middlewaresMap, dbService, secure(true), nil)
+	routes["ChatSSE"] = proto.NewRoute(http.MethodPost, "/chat", "text/event-stream", withBudget(chatController.ChatSSE), middlewaresMap, dbService, secure(true), nil)
+
+	routes["OpenAIChatCompletions"] = proto.NewRoute(http.MethodPost, "/v1/chat/completions", "application/json", withBudget(openAIController.ChatCompletions), middlewaresMap, dbService, secure(true), nil)
+	routes["OpenAIModels"] = proto.NewRoute(http.MethodGet, "/v1/models", "application/json", openAIController.ListModels, middlewaresMap, dbService, secure(true), nil)
 
 	routes["Providers"] = proto.NewRoute(http.MethodGet, "/providers", "application/json", providersController.ListProviders, middlewaresMap, dbService, secure(true), nil)
 
-	routes["AdviseV1"] = proto.NewRoute(http.MethodPost, "/v1/advise", "text/event-stream", adviseController.Advise, middlewaresMap, dbService, secure(true), nil)
-	routes["AdviseLegacy"] = proto.NewRoute(http.MethodPost, "/advise", "text/event-stream", adviseController.Advise, middlewaresMap, dbService, secure(true), nil)
+	routes["AdviseV1"] = proto.NewRoute(http.MethodPost, "/v1/advise", "text/event-stream", withBudget(adviseController.Advise), middlewaresMap, dbService, secure(true), nil)
+	routes["AdviseLegacy"] = proto.NewRoute(http.MethodPost, "/advise", "text/event-stream", withBudget(adviseController.Advise), middlewaresMap, dbService, secure(true), nil)
+
+	routes["Usage"] = proto.NewRoute(http.MethodGet, "/v1/usage", "application/json", usageController.Summary, middlewaresMap, dbService, secure(true), nil)
+	routes["UsageRecords"] = proto.NewRoute(http.MethodGet, "/v1/usage/records", "application/json", usageController.Records, middlewaresMap, dbService, secure(true), nil)
+
+	routes["CacheStats"] = proto.NewRoute(http.MethodGet, "/v1/cache/stats", "application/json", cacheController.Stats, middlewaresMap, dbService, secure(true), nil)
+
+	routes["BrokerConsumers"] = proto.NewRoute(http.MethodGet, "/v1/broker/consumers", "application/json", brokerController.Consumers, middlewaresMap, dbService, secure(true), nil)
 
 	routes["Scorecard"] = proto.NewRoute(http.MethodGet, "/api/v1/scorecard", "application/json", scorecardController.GetScorecard, middlewaresMap, dbService, secure(true), nil)
 	routes["ScorecardAdvice"] = proto.NewRoute(http.MethodGet, "/api/v1/scorecard/advice", "application/json", scorecardController.GetScorecardAdvice, middlewaresMap, dbService, secure(true), nil)
@@ -107,12 +225,28 @@ func NewGatewayRoutes(rtr *ar.IRouter) map[string]ar.IRoute {
 	routes["LookAtniDownload"] = proto.NewRoute(http.MethodGet, "/api/v1/lookatni/download/:id", "application/json", lookAtniController.Download, middlewaresMap, dbService, secure(true), nil)
 	routes["LookAtniProjects"] = proto.NewRoute(http.MethodGet, "/api/v1/lookatni/projects", "application/json", lookAtniController.Projects, middlewaresMap, dbService, secure(true), nil)
 
-	routes["Webhooks"] = proto.NewRoute(http.MethodPost, "/v1/webhooks", "application/json", webhookController.Handle, middlewaresMap, dbService, secure(true), nil)
+	// senders authenticate with their signature, checked by the verifier registry
+	routes["Webhooks"] = proto.NewRoute(http.MethodPost, "/v1/webhooks", "application/json", webhookController.Handle, middlewaresMap, dbService, secure(false), nil)
 	routes["WebhooksHealth"] = proto.NewRoute(http.MethodGet, "/v1/webhooks/health", "application/json", webhookController.Health, middlewaresMap, dbService, secure(true), nil)
 	routes["WebhooksEventsList"] = proto.NewRoute(http.MethodGet, "/v1/webhooks/events", "application/json", webhookController.ListEvents, middlewaresMap, dbService, secure(true), nil)
 	routes["WebhooksEventsGet"] = proto.NewRoute(http.MethodGet, "/v1/webhooks/events/:id", "application/json", webhookController.GetEvent, middlewaresMap, dbService, secure(true), nil)
 	routes["WebhooksRetry"] = proto.NewRoute(http.MethodPost, "/v1/webhooks/retry", "application/json", webhookController.RetryFailedEvents, middlewaresMap, dbService, secure(true), nil)
 
+	routes["WebhookRulesCreate"] = proto.NewRoute(http.MethodPost, "/v1/webhooks/rules", "application/json", webhookController.CreateRule, middlewaresMap, dbService, secure(true), nil)
+	routes["WebhookRulesList"] = proto.NewRoute(http.MethodGet, "/v1/webhooks/rules", "application/json", webhookController.ListRules, middlewaresMap, dbService, secure(true), nil)
+	routes["WebhookRulesGet"] = proto.NewRoute(http.MethodGet, "/v1/webhooks/rules/:id", "application/json", webhookController.GetRule, middlewaresMap, dbService, secure(true), nil)
+	routes["WebhookRulesUpdate"] = proto.NewRoute(http.MethodPut, "/v1/webhooks/rules/:id", "application/json", webhookController.UpdateRule, middlewaresMap, dbService, secure(true), nil)
+	routes["WebhookRulesDelete"] = proto.NewRoute(http.MethodDelete, "/v1/webhooks/rules/:id", "application/json", webhookController.DeleteRule, middlewaresMap, dbService, secure(true), nil)
+
+	routes["WebhookSubscriptionsCreate"] = proto.NewRoute(http.MethodPost, "/v1/webhooks/subscriptions", "application/json", subscriptionController.Create, middlewaresMap, dbService, secure(true), nil)
+	routes["WebhookSubscriptionsList"] = proto.NewRoute(http.MethodGet, "/v1/webhooks/subscriptions", "application/json", subscriptionController.List, middlewaresMap, dbService, secure(true), nil)
+	routes["WebhookSubscriptionsGet"] = proto.NewRoute(http.MethodGet, "/v1/webhooks/subscriptions/:id", "application/json", subscriptionController.Get, middlewaresMap, dbService, secure(true), nil)
+	routes["WebhookSubscriptionsUpdate"] = proto.NewRoute(http.MethodPatch, "/v1/webhooks/subscriptions/:id", "application/json", subscriptionController.Update, middlewaresMap, dbService, secure(true), nil)
+	routes["WebhookSubscriptionsDelete"] = proto.NewRoute(http.MethodDelete, "/v1/webhooks/subscriptions/:id", "application/json", subscriptionController.Delete, middlewaresMap, dbService, secure(true), nil)
+	routes["WebhookDeliveriesList"] = proto.NewRoute(http.MethodGet, "/v1/webhooks/deliveries", "application/json", subscriptionController.ListDeliveries, middlewaresMap, dbService, secure(true), nil)
+	routes["WebhookDeliveriesGet"] = proto.NewRoute(http.MethodGet, "/v1/webhooks/deliveries/:id", "application/json", subscriptionController.GetDelivery, middlewaresMap, dbService, secure(true), nil)
+	routes["WebhookDeliveriesRedeliver"] = proto.NewRoute(http.MethodPost, "/v1/webhooks/deliveries/:id/redeliver", "application/json", subscriptionController.Redeliver, middlewaresMap, dbService, secure(true), nil)
+
 	routes["SchedulerStats"] = proto.NewRoute(http.MethodGet, "/health/scheduler/stats", "application/json", schedulerController.Stats, middlewaresMap, dbService, secure(true), nil)
 	routes["SchedulerForce"] = proto.NewRoute(http.MethodPost, "/health/scheduler/force", "application/json", schedulerController.ForceRun, middlewaresMap, dbService, secure(true), nil)
 
@@ -134,11 +268,6 @@ func NewGatewayRoutes(rtr *ar.IRouter) map[string]ar.IRoute {
 		routes["LookAtniProjects"] = proto.NewRoute(http.MethodGet, "/api/v1/lookatni/projects", "application/json", wrap(), middlewaresMap, dbService, secure(true), nil)
 		routes["LookAtniProjectFragments"] = proto.NewRoute(http.MethodGet, "/api/v1/lookatni/projects/*path", "application/json", wrap(), middlewaresMap, dbService, secure(true), nil)
 
-		routes["Webhooks"] = proto.NewRoute(http.MethodPost, "/v1/webhooks", "application/json", wrap(), middlewaresMap, dbService, secure(true), nil)
-		routes["WebhooksHealth"] = proto.NewRoute(http.MethodGet, "/v1/webhooks/health", "application/json", wrap(), middlewaresMap, dbService, secure(true), nil)
-		routes["WebhooksEventsList"] = proto.NewRoute(http.MethodGet, "/v1/webhooks/events", "application/json", wrap(), middlewaresMap, dbService, secure(true), nil)
-		routes["WebhooksEventsGet"] = proto.NewRoute(http.MethodGet, "/v1/webhooks/events/:id", "application/json", wrap(), middlewaresMap, dbService, secure(true), nil)
-		routes["WebhooksRetry"] = proto.NewRoute(http.MethodPost, "/v1/webhooks/retry", "application/json", wrap(), middlewaresMap, dbService, secure(true), nil)
 		routes["SchedulerStats"] = proto.NewRoute(http.MethodGet, "/health/scheduler/stats", "application/json", wrap(), middlewaresMap, dbService, secure(true), nil)
 		routes["SchedulerForce"] = proto.NewRoute(http.MethodPost, "/health/scheduler/force", "application/json", wrap(), middlewaresMap, dbService, secure(true), nil)
 	}
@@ -146,6 +275,184 @@ func NewGatewayRoutes(rtr *ar.IRouter) map[string]ar.IRoute {
 	return routes
 }
 
+// applyGatewayConfig installs the routing policies, prices, budgets and
+// response cache of the "gateway" config section.
+func applyGatewayConfig(cfg *config.Config, gw *gatewaysvc.Service, usageLedger *ledger.Ledger) {
+	if cfg == nil {
+		return
+	}
+	if err := gw.ApplyConfig(cfg.Gateway); err != nil {
+		gl.Log("error", "Invalid gateway routing config", err)
+	}
+	usageLedger.ApplyConfig(cfg.Gateway)
+
+	responses, err := cache.FromConfig(cfg.Gateway.Cache)
+	if err != nil {
+		gl.Log("error", "Invalid gateway cache config, keeping the in-memory cache", err)
+		return
+	}
+	gw.SetCache(responses)
+}
+
+// webhookVerifiers builds the signature verifiers of the "webhooks" config
+// section. Without config no source has a verifier and every delivery is
+// refused.
+func webhookVerifiers(cfg *config.Config) *verify.Registry {
+	if cfg == nil {
+		return verify.NewRegistry(0)
+	}
+	verifiers, err := verify.FromConfig(cfg.Webhooks)
+	if err != nil {
+		gl.Log("error", "Invalid webhook verifier config, refusing the affected sources", err)
+	}
+	return verifiers
+}
+
+// outboundKey returns the key encrypting the outbound subscription secrets,
+// derived from a secret generated once and kept in the keyring.
+func outboundKey() ([]byte, error) {
+	secret, err := crt.GetOrGenPasswordKeyringPass("webhooks_outbound_key")
+	if err != nil {
+		return nil, err
+	}
+	key := sha256.Sum256([]byte(secret))
+	return key[:], nil
+}
+
+// startDispatcher starts the outbound webhooks of the "webhooks.outbound"
+// config section, or returns nil when they cannot be started.
+func startDispatcher(cfg *config.Config, db *gorm.DB) *outbound.Dispatcher {
+	outboundOptions := outbound.DefaultOptions
+	if cfg != nil {
+		outboundOptions = outbound.OptionsFromConfig(cfg.Webhooks.Outbound)
+		if outboundCfg := cfg.Webhooks.Outbound; outboundCfg.SecretEnv != "" || outboundCfg.SecretKeyring != "" {
+			secret, err := verify.Secret(outboundCfg.SecretEnv, outboundCfg.SecretKeyring)
+			if err != nil {
+				gl.Log("error", "Outbound webhook secret unavailable, one-off deliveries are disabled", err)
+			}
+			outboundOptions.Secret = secret
+		}
+	}
+	if key, err := outboundKey(); err != nil {
+		gl.Log("error", "Outbound webhook key unavailable, subscription secrets will not survive a restart", err)
+	} else {
+		outboundOptions.Key = key
+	}
+	dispatcher, err := outbound.NewDispatcher(svc.NewBridge(db).WebhookSubscriptionStore(), outboundOptions)
+	if err != nil {
+		gl.Log("error", "failed to initialize outbound webhooks", err)
+		return nil
+	}
+	return dispatcher
+}
+
+// registerWebhookActions lets webhook rules run MCP tools and cron jobs.
+// Tools run as the "webhooks" principal with the "webhook" role, which MCP
+// policies can grant or deny like any other caller.
+func registerWebhookActions(service *webhooksvc.WebhookService, cronRunner *runner.Runner) {
+	if registry := mcp_system_controller.GetMCPRegistry(); registry != nil {
+		principal := &mcpsvc.Principal{ID: "webhooks", Source: "webhook", Roles: []string{"webhook"}}
+		service.RegisterAction(webhooksvc.ActionMCPTool, webhooksvc.ToolAction(func(ctx context.Context, tool string, args map[string]interface{}) (interface{}, error) {
+			return registry.Exec(mcpsvc.WithPrincipal(ctx, principal), tool, args)
+		}))
+	}
+	service.RegisterAction(webhooksvc.ActionCronRun, webhooksvc.CronAction(func(ctx context.Context, jobID uuid.UUID) error {
+		_, err := cronRunner.RunByID(ctx, jobID, runner.TriggerWebhook)
+		return err
+	}))
+}
+
+// startCronRunner runs the cron jobs of the database, tuned by the "cron"
+// config section, and schedules them on the cron engine. MCP tool jobs
+// run as the cron principal with the "cron" role; llm jobs go through gw,
+// amqp jobs through broker and the runs are reported through dispatcher.
+func startCronRunner(cfg *config.Config, db *gorm.DB, gw *gatewaysvc.Service, broker messagery.Broker, dispatcher *outbound.Dispatcher) (*runner.Runner, *schedulermgr.CronJobScheduler) {
+	var cronConfig config.CronConfig
+	if cfg != nil {
+		cronConfig = cfg.Cron
+	}
+	bridge := svc.NewBridge(db)
+	cronRunner := runner.New(bridge.CronRepo(), bridge.CronExecutionStore(), runner.OptionsFromConfig(cronConfig))
+	if registry := mcp_system_controller.GetMCPRegistry(); registry != nil {
+		principalID := cronConfig.Principal
+		if principalID == "" {
+			principalID = "cron"
+		}
+		principal := &mcpsvc.Principal{ID: principalID, Source: "cron", Roles: []string{"cron"}}
+		cronRunner.SetToolRunner(func(ctx context.Context, tool string, args map[string]interface{}) (interface{}, error) {
+			return registry.Exec(mcpsvc.WithPrincipal(ctx, principal), tool, args)
+		})
+	}
+	if gw != nil {
+		cronRunner.SetChatter(gw)
+	}
+	if broker != nil {
+		cronRunner.SetBroker(broker)
+	}
+	cronRunner.SetDispatcher(dispatcher)
+
+	scheduler := schedulermgr.NewCronJobScheduler(bridge.CronRepo(), cronRunner)
+	if err := scheduler.Start(context.Background()); err != nil {
+		gl.Log("error", fmt.Sprintf("Failed to load cron jobs: %v", err))
+	}
+	return cronRunner, scheduler
+}
+
+// startBroker starts the message broker of the "broker" config section.
+// Without a kind, RabbitMQ is used when the AMQP
+// consumers are enabled and the in-process broker otherwise. It returns
+// nil when the broker cannot be created.
+func startBroker(cfg *config.Config, dbConfig *messagery.DBConfig) messagery.Broker {
+	var brokerConfig config.BrokerConfig
+	if cfg != nil {
+		brokerConfig = cfg.Broker
+		if brokerConfig.Kind == "" && cfg.AMQP.Enabled {
+			brokerConfig.Kind = messagery.BrokerAMQP
+		}
+	}
+	broker, err := messagery.NewBroker(brokerConfig, dbConfig)
+	if err != nil {
+		gl.Log("error", "Failed to create the message broker", err)
+		return nil
+	}
+	if err := broker.Start(context.Background()); err != nil {
+		gl.Log("error", "Failed to start the message broker", err)
+		return nil
+	}
+	gl.Log("info", fmt.Sprintf("Message broker: %s", broker.Kind()))
+	return broker
+}
+
+// startBrokerConsumers consumes the MCP tasks of the broker, tuned by the
+// "amqp" config section. Tasks run as the "amqp" principal with the "amqp"
+// role. It returns nil when the consumers are disabled.
+func startBrokerConsumers(cfg *config.Config, broker messagery.Broker) *messagery.Consumer {
+	if cfg == nil || !cfg.AMQP.Enabled || broker == nil {
+		return nil
+	}
+	registry := mcp_system_controller.GetMCPRegistry()
+	if registry == nil {
+		gl.Log("warn", "Broker consumers enabled without an MCP registry")
+		return nil
+	}
+
+	consumer := messagery.NewConsumer(broker)
+	if cfg.AMQP.DrainTimeoutSeconds > 0 {
+		consumer.DrainTimeout = time.Duration(cfg.AMQP.DrainTimeoutSeconds) * time.Second
+	}
+	principal := &mcpsvc.Principal{ID: "amqp", Source: "amqp", Roles: []string{"amqp"}}
+	opts := messagery.ConsumerOptionsFromConfig(cfg.AMQP, mcpsvc.TaskQueue)
+	if err := consumer.Handle(mcpsvc.TaskQueue, opts, mcpsvc.TaskHandler(registry, principal, broker.Reply)); err != nil {
+		gl.Log("error", "Failed to register the MCP task consumer", err)
+		return nil
+	}
+	if err := consumer.Start(); err != nil {
+		gl.Log("error", "Failed to start the broker consumers", err)
+		return nil
+	}
+	return consumer
+}
+
 func initializeAnalyzerHandler() http.Handler {
 	configPath := analyzerProvidersConfigPath()
 	if configPath == "" {
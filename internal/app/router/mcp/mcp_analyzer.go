@@ -8,13 +8,16 @@ import (
 	proto "github.com/kubex-ecosystem/gobe/internal/app/router/types"
 	ar "github.com/kubex-ecosystem/gobe/internal/contracts/interfaces"
 	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+	"github.com/kubex-ecosystem/gobe/internal/services/webhooks/outbound"
 )
 
 type MCPAnalyzerRoutes struct {
 	ar.IRouter
 }
 
-func NewMCPAnalyzerRoutes(rtr *ar.IRouter) map[string]ar.IRoute {
+// NewMCPAnalyzerRoutes builds the analyzer routes; its webhook
+// notifications are queued with dispatcher, nil without outbound webhooks.
+func NewMCPAnalyzerRoutes(rtr *ar.IRouter, dispatcher *outbound.Dispatcher) map[string]ar.IRoute {
 	if rtr == nil {
 		gl.Log("error", "Router is nil, cannot create MCP Analyzer routes")
 		return nil
@@ -31,7 +34,7 @@ func NewMCPAnalyzerRoutes(rtr *ar.IRouter) map[string]ar.IRoute {
 		gl.Log("error", "Failed to get DB from service", err)
 		return nil
 	}
-	mcpAnalyzerController := mcp_analyzer_controller.NewAnalyzerController(dbGorm)
+	mcpAnalyzerController := mcp_analyzer_controller.NewAnalyzerController(dbGorm, dispatcher)
 
 	routesMap := make(map[string]ar.IRoute)
 	middlewaresMap := make(map[string]gin.HandlerFunc)
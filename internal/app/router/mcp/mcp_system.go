@@ -2,18 +2,28 @@ package mcp
 
 import (
 	"net/http"
+	"os"
+	"time"
 
+	models "github.com/kubex-ecosystem/gdbase/factory/models/mcp"
 	mcp_system_controller "github.com/kubex-ecosystem/gobe/internal/app/controllers/mcp/system"
 	proto "github.com/kubex-ecosystem/gobe/internal/app/router/types"
+	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
+	"github.com/kubex-ecosystem/gobe/internal/config"
 	ar "github.com/kubex-ecosystem/gobe/internal/contracts/interfaces"
 	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+	gatewaysvc "github.com/kubex-ecosystem/gobe/internal/services/gateway/registry"
+	"github.com/kubex-ecosystem/gobe/internal/services/mcp"
 )
 
 type MCPSystemRoutes struct {
 	ar.IRouter
 }
 
-func NewMCPSystemRoutes(rtr *ar.IRouter) map[string]ar.IRoute {
+// NewMCPSystemRoutes builds the MCP system routes; cfg is the gobe config
+// loaded by the router, nil when it could not be read (the tools keep their
+// defaults).
+func NewMCPSystemRoutes(rtr *ar.IRouter, cfg *config.Config) map[string]ar.IRoute {
 	if rtr == nil {
 		gl.Log("error", "Router is nil, cannot create MCP System routes")
 		return nil
@@ -32,6 +42,23 @@ func NewMCPSystemRoutes(rtr *ar.IRouter) map[string]ar.IRoute {
 	}
 	mcpSystemController := mcp_system_controller.NewMetricsController(dbGorm)
 
+	// Apply the configured tool authorization policy to /mcp/exec
+	if cfg != nil {
+		mcp_system_controller.SetMCPPolicy(mcp.NewPolicy(cfg.MCP.Policy))
+		if cfg.MCP.JobTTLMinutes > 0 {
+			mcp_system_controller.SetMCPJobTTL(time.Duration(cfg.MCP.JobTTLMinutes) * time.Minute)
+		}
+		if cfg.MCP.ToolsDir != "" {
+			backends := mcp.ManifestBackends{}
+			if gw, err := gatewaysvc.NewService(svc.NewProvidersService(models.NewProvidersRepo(dbGorm))); err != nil {
+				gl.Log("warn", "Gateway unavailable, prompt tools will fail", err)
+			} else {
+				backends.Chat = gw
+			}
+			mcp_system_controller.LoadToolManifests(os.ExpandEnv(cfg.MCP.ToolsDir), backends)
+		}
+	}
+
 	routesMap := make(map[string]ar.IRoute)
 	// middlewaresMap := rtl.GetMiddlewares()
 
@@ -40,12 +67,28 @@ func NewMCPSystemRoutes(rtr *ar.IRouter) map[string]ar.IRoute {
 	secureProperties["validateAndSanitize"] = false
 	secureProperties["validateAndSanitizeBody"] = false
 
+	// Tool execution, its jobs and the MCP transports run tools as the JWT
+	// subject, so they require a token.
+	principalProperties := map[string]bool{
+		"secure":                  true,
+		"validateAndSanitize":     false,
+		"validateAndSanitizeBody": false,
+	}
+
 	routesMap["GetGeneralSystemMetrics"] = proto.NewRoute(http.MethodGet, "/api/v1/mcp/system/metrics", "application/json", mcpSystemController.GetGeneralSystemMetrics /* middlewaresMap */, nil, dbService, secureProperties, nil)
 	routesMap["RegisterRoutes"] = proto.NewRoute(http.MethodGet, "/api/v1/mcp/system/routes", "application/json", mcpSystemController.RegisterResources, nil, dbService, secureProperties, nil)
 	routesMap["RegisterTools"] = proto.NewRoute(http.MethodGet, "/api/v1/mcp/system/tools", "application/json", mcpSystemController.RegisterTools, nil, dbService, secureProperties, nil)
 	// New MCP Registry endpoints
 	routesMap["ListMCPTools"] = proto.NewRoute(http.MethodGet, "/mcp/tools", "application/json", mcpSystemController.ListTools, nil, dbService, secureProperties, nil)
-	routesMap["ExecMCPTool"] = proto.NewRoute(http.MethodPost, "/mcp/exec", "application/json", mcpSystemController.ExecTool, nil, dbService, secureProperties, nil)
+	routesMap["ExecMCPTool"] = proto.NewRoute(http.MethodPost, "/mcp/exec", "application/json", mcpSystemController.ExecTool, nil, dbService, principalProperties, nil)
+	routesMap["GetMCPJob"] = proto.NewRoute(http.MethodGet, "/mcp/jobs/:id", "application/json", mcpSystemController.GetJob, nil, dbService, principalProperties, nil)
+	routesMap["CancelMCPJob"] = proto.NewRoute(http.MethodPost, "/mcp/jobs/:id/cancel", "application/json", mcpSystemController.CancelJob, nil, dbService, principalProperties, nil)
+	// MCP transports: SSE (GET /mcp/sse + POST /mcp/message) and streamable HTTP (/mcp)
+	routesMap["MCPTransportSSE"] = proto.NewRoute(http.MethodGet, "/mcp/sse", "text/event-stream", mcpSystemController.ServeMCPSSE, nil, dbService, principalProperties, nil)
+	routesMap["MCPTransportMessage"] = proto.NewRoute(http.MethodPost, "/mcp/message", "application/json", mcpSystemController.ServeMCPMessage, nil, dbService, principalProperties, nil)
+	routesMap["MCPTransportStreamableGet"] = proto.NewRoute(http.MethodGet, "/mcp", "text/event-stream", mcpSystemController.ServeMCPStreamable, nil, dbService, principalProperties, nil)
+	routesMap["MCPTransportStreamablePost"] = proto.NewRoute(http.MethodPost, "/mcp", "application/json", mcpSystemController.ServeMCPStreamable, nil, dbService, principalProperties, nil)
+	routesMap["MCPTransportStreamableDelete"] = proto.NewRoute(http.MethodDelete, "/mcp", "application/json", mcpSystemController.ServeMCPStreamable, nil, dbService, principalProperties, nil)
 	routesMap["HandleAnalyzeMessage"] = proto.NewRoute(http.MethodPost, "/api/v1/mcp/system/analyze", "application/json", mcpSystemController.HandleAnalyzeMessage, nil, dbService, secureProperties, nil)
 	routesMap["HandleSendMessage"] = proto.NewRoute(http.MethodPost, "/api/v1/mcp/system/send-message", "application/json", mcpSystemController.SendMessage, nil, dbService, secureProperties, nil)
 	routesMap["HandleCreateTask"] = proto.NewRoute(http.MethodPost, "/api/v1/mcp/system/create-task", "application/json", mcpSystemController.HandleCreateTask, nil, dbService, secureProperties, nil)
@@ -11,6 +11,7 @@ import (
 	gdbf "github.com/kubex-ecosystem/gdbase/factory"
 	"github.com/kubex-ecosystem/gdbase/types"
 	mdw "github.com/kubex-ecosystem/gobe/internal/app/middlewares"
+	"github.com/kubex-ecosystem/gobe/internal/app/router/gateway"
 	ci "github.com/kubex-ecosystem/gobe/internal/contracts/interfaces"
 	t "github.com/kubex-ecosystem/gobe/internal/contracts/types"
 	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
@@ -39,6 +40,9 @@ type Router struct {
 	middlewares     map[string]gin.HandlerFunc
 	engine          *gin.Engine
 	debug           bool
+
+	// services are shared by the route groups and stopped on shutdown
+	services *gateway.Services
 }
 
 // newRouter initializes a new Router instance with the provided configuration.
@@ -119,7 +123,10 @@ func newRouter(serverConfig *t.GoBEConfig, databaseService gdbf.DBService, logge
 	}
 	gl.Log("debug", fmt.Sprintf("Server security policies initialized at %s", fullBindAddress))
 
-	for groupName, routeGroup := range GetDefaultRouteMap(rtr) {
+	var irtr ci.IRouter = rtr
+	cfg := loadConfig(irtr)
+	rtr.services = gateway.StartServices(&irtr, cfg)
+	for groupName, routeGroup := range defaultRouteMap(rtr, cfg, rtr.services) {
 		for routeName, route := range routeGroup {
 			if route != nil {
 				rtr.RegisterRoute(groupName, routeName, route, strMiddlewares)
@@ -464,6 +471,20 @@ func (rtr *Router) ShutdownServerGracefully() {
 
 	gl.Log("info", "Server shut down gracefully.")
 
+	// Let the broker consumers finish the messages they hold
+	if rtr.services != nil {
+		if consumer := rtr.services.Consumer; consumer != nil {
+			if err := consumer.Shutdown(context.Background()); err != nil {
+				gl.Log("warn", "Broker consumers shut down before draining", err)
+			}
+		}
+		if broker := rtr.services.Broker; broker != nil {
+			if err := broker.Close(); err != nil {
+				gl.Log("warn", "Failed to close the message broker", err)
+			}
+		}
+	}
+
 	os.Exit(0)
 }
 
@@ -1,8 +1,13 @@
 package router
 
 import (
+	"os"
+
 	gdbf "github.com/kubex-ecosystem/gdbase/factory"
+	common "github.com/kubex-ecosystem/gobe/internal/commons"
+	"github.com/kubex-ecosystem/gobe/internal/config"
 	ci "github.com/kubex-ecosystem/gobe/internal/contracts/interfaces"
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
 
 	"github.com/gin-gonic/gin"
 	"github.com/kubex-ecosystem/gobe/internal/app/router/app"
@@ -20,14 +25,23 @@ func NewRoute(method, path, contentType string, handler gin.HandlerFunc, middlew
 	return proto.NewRoute(method, path, contentType, handler, middlewares, dbConfig, secureProperties, metadata)
 }
 
+// GetDefaultRouteMap starts the gateway services and builds the route
+// groups on them.
 func GetDefaultRouteMap(rtr ci.IRouter) map[string]map[string]ci.IRoute {
+	cfg := loadConfig(rtr)
+	return defaultRouteMap(rtr, cfg, gateway.StartServices(&rtr, cfg))
+}
+
+// defaultRouteMap builds the route groups; cfg and services are shared by
+// the groups that need them.
+func defaultRouteMap(rtr ci.IRouter, cfg *config.Config, services *gateway.Services) map[string]map[string]ci.IRoute {
 	return map[string]map[string]ci.IRoute{
 		"serverManagementRoutes": sys.NewServerRoutes(&rtr),
-		"cronRoutes":             sys.NewCronRoutes(&rtr),
+		"cronRoutes":             sys.NewCronRoutes(&rtr, services.CronRunner, services.Scheduler),
 		"swaggerRoutes":          sys.NewSwaggerRoutes(&rtr),
 
 		"webhookRoutes": webhooks.NewWebhookRoutes(&rtr),
-		"gatewayRoutes": gateway.NewGatewayRoutes(&rtr),
+		"gatewayRoutes": gateway.NewGatewayRoutes(&rtr, cfg, services),
 
 		"contactRoutes":  app.NewContactRoutes(&rtr),
 		"productRoutes":  app.NewProductRoutes(&rtr),
@@ -37,7 +51,7 @@ func GetDefaultRouteMap(rtr ci.IRouter) map[string]map[string]ci.IRoute {
 		"userRoutes":  user.NewUserRoutes(&rtr),
 		"oauthRoutes": oauth.NewOAuthRoutes(&rtr),
 
-		"discordRoutes":  cbot.NewDiscordRoutes(&rtr),
+		"discordRoutes":  cbot.NewDiscordRoutes(&rtr, services.Dispatcher),
 		"whatsappRoutes": cbot.NewWhatsAppRoutes(&rtr),
 		"telegramRoutes": cbot.NewTelegramRoutes(&rtr),
 
@@ -45,8 +59,24 @@ func GetDefaultRouteMap(rtr ci.IRouter) map[string]map[string]ci.IRoute {
 		"mcpProvidersRoutes":   mcp.NewMCPProvidersRoutes(&rtr),
 		"mcpLLMRoutes":         mcp.NewMCPLLMRoutes(&rtr),
 		"mcpPreferencesRoutes": mcp.NewMCPPreferencesRoutes(&rtr),
-		"mcpSystemRoutes":      mcp.NewMCPSystemRoutes(&rtr),
-		"mcpAnalyzerRoutes":    mcp.NewMCPAnalyzerRoutes(&rtr),
+		"mcpSystemRoutes":      mcp.NewMCPSystemRoutes(&rtr, cfg),
+		"mcpAnalyzerRoutes":    mcp.NewMCPAnalyzerRoutes(&rtr, services.Dispatcher),
 		"mcpGDBaseRoutes":      mcp.NewMCPGDBaseRoutes(&rtr),
 	}
 }
+
+// loadConfig reads the gobe config once for the route groups that need it,
+// or returns nil (and those modules keep their defaults) when it cannot be
+// read.
+func loadConfig(rtr ci.IRouter) *config.Config {
+	initArgs := rtr.GetInitArgs()
+	if initArgs.ConfigFile == "" {
+		initArgs.ConfigFile = os.ExpandEnv(common.DefaultGoBEConfigPath)
+	}
+	cfg, err := config.Load[*config.Config](initArgs)
+	if err != nil {
+		gl.Log("error", "Failed to load the gobe config, route modules use their defaults", err)
+		return nil
+	}
+	return cfg
+}
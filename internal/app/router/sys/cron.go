@@ -7,6 +7,8 @@ import (
 	gdbasez "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
 	ar "github.com/kubex-ecosystem/gobe/internal/contracts/interfaces"
 	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+	schedulermgr "github.com/kubex-ecosystem/gobe/internal/services/scheduler/manager"
+	"github.com/kubex-ecosystem/gobe/internal/services/scheduler/runner"
 	l "github.com/kubex-ecosystem/logz"
 )
 
@@ -14,8 +16,10 @@ type CronRoutes struct {
 	ar.IRouter
 }
 
-// NewCronRoutes cria novas rotas para o serviço de cron jobs.
-func NewCronRoutes(rtr *ar.IRouter) map[string]ar.IRoute {
+// NewCronRoutes cria novas rotas para o serviço de cron jobs, executados
+// por cronRunner (ou por um runner próprio quando nil) e reagendados em
+// scheduler.
+func NewCronRoutes(rtr *ar.IRouter, cronRunner *runner.Runner, scheduler *schedulermgr.CronJobScheduler) map[string]ar.IRoute {
 	if rtr == nil {
 		l.ErrorCtx("Router is nil for CronRoute", nil)
 		return nil
@@ -34,7 +38,7 @@ func NewCronRoutes(rtr *ar.IRouter) map[string]ar.IRoute {
 		return nil
 	}
 
-	cronJobController := c.NewCronJobController(bridge)
+	cronJobController := c.NewCronJobController(bridge, cronRunner, scheduler)
 	routesMap := make(map[string]ar.IRoute)
 	middlewaresMap := make(map[string]gin.HandlerFunc)
 
@@ -53,6 +57,7 @@ func NewCronRoutes(rtr *ar.IRouter) map[string]ar.IRoute {
 	routesMap["EnableCronJobRoute"] = proto.NewRoute("POST", "/api/v1/cronjobs/:id/enable", "application/json", cronJobController.EnableCronJob, middlewaresMap, dbService, secureProperties, nil)
 	routesMap["DisableCronJobRoute"] = proto.NewRoute("POST", "/api/v1/cronjobs/:id/disable", "application/json", cronJobController.DisableCronJob, middlewaresMap, dbService, secureProperties, nil)
 	routesMap["ExecuteCronJobManuallyRoute"] = proto.NewRoute("POST", "/api/v1/cronjobs/:id/execute", "application/json", cronJobController.ExecuteCronJobManually, middlewaresMap, dbService, secureProperties, nil)
+	routesMap["GetExecutionLogsRoute"] = proto.NewRoute("GET", "/api/v1/cronjobs/:id/logs", "application/json", cronJobController.GetExecutionLogs, middlewaresMap, dbService, secureProperties, nil)
 	routesMap["ListActiveCronJobsRoute"] = proto.NewRoute("GET", "/api/v1/cronjobs/active", "application/json", cronJobController.ListActiveCronJobs, middlewaresMap, dbService, secureProperties, nil)
 	routesMap["RescheduleCronJobRoute"] = proto.NewRoute("PUT", "/api/v1/cronjobs/:id/reschedule", "application/json", cronJobController.RescheduleCronJob, middlewaresMap, dbService, secureProperties, nil)
 	routesMap["ValidateCronExpressionRoute"] = proto.NewRoute("POST", "/api/v1/cronjobs/validate", "application/json", cronJobController.ValidateCronExpression, middlewaresMap, dbService, secureProperties, nil)
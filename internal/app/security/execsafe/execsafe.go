@@ -91,6 +91,42 @@ type CommandSpec struct {
 	WorkDir      string        // opcional
 	MaxOutputKB  int           // truncar saída (por stream)
 	EnvAllowList []string      // nomes de env que podem vazar
+	Limits       Limits        // rlimits do processo filho
+	Sandbox      Sandbox       // isolamento (somente Linux)
+}
+
+// Limits are rlimits set on the child right before it runs the binary.
+// Zero keeps the limit inherited from gobe.
+type Limits struct {
+	CPUSeconds     uint64 `yaml:"cpuSeconds,omitempty" json:"cpuSeconds,omitempty"`         // RLIMIT_CPU
+	AddressSpaceMB uint64 `yaml:"addressSpaceMB,omitempty" json:"addressSpaceMB,omitempty"` // RLIMIT_AS
+	OpenFiles      uint64 `yaml:"openFiles,omitempty" json:"openFiles,omitempty"`           // RLIMIT_NOFILE
+	// Processes caps the processes of the real user (RLIMIT_NPROC); the
+	// kernel does not enforce it for root.
+	Processes uint64 `yaml:"processes,omitempty" json:"processes,omitempty"`
+}
+
+func (l Limits) set() bool {
+	return l.CPUSeconds > 0 || l.AddressSpaceMB > 0 || l.OpenFiles > 0 || l.Processes > 0
+}
+
+// Sandbox isolates the child from gobe. The namespace options run it in a
+// new user namespace mapping only gobe's uid and gid, so they need a kernel
+// allowing unprivileged user namespaces.
+type Sandbox struct {
+	NoNewPrivileges bool `yaml:"noNewPrivileges,omitempty" json:"noNewPrivileges,omitempty"` // setuid/file caps não elevam
+	ReadOnlyFS      bool `yaml:"readOnlyFS,omitempty" json:"readOnlyFS,omitempty"`           // todas as montagens somente leitura
+	NoNetwork       bool `yaml:"noNetwork,omitempty" json:"noNetwork,omitempty"`             // netns vazio (só lo, desligada)
+	PrivateTmp      bool `yaml:"privateTmp,omitempty" json:"privateTmp,omitempty"`           // tmpfs próprio em /tmp
+	PIDNamespace    bool `yaml:"pidNamespace,omitempty" json:"pidNamespace,omitempty"`       // não enxerga outros processos
+}
+
+func (s Sandbox) namespaces() bool {
+	return s.ReadOnlyFS || s.NoNetwork || s.PrivateTmp || s.PIDNamespace
+}
+
+func (s Sandbox) set() bool {
+	return s.NoNewPrivileges || s.namespaces()
 }
 
 type Registry struct {
@@ -322,44 +358,62 @@ func RunSafe(ctx context.Context, reg *Registry, name string, args []string) (*E
 	cctx, cancel := context.WithTimeout(ctx, tmo)
 	defer cancel()
 
-	cmd := exec.CommandContext(cctx, spec.Binary, args...) // SEM shell
-	if spec.WorkDir != "" {
-		cmd.Dir = spec.WorkDir
-	}
 	// env controlado
+	var env []string
 	if len(spec.EnvAllowList) > 0 {
-		base := []string{}
 		for _, k := range spec.EnvAllowList {
 			if v, ok := os.LookupEnv(k); ok {
-				base = append(base, fmt.Sprintf("%s=%s", k, v))
+				env = append(env, fmt.Sprintf("%s=%s", k, v))
 			}
 		}
-		cmd.Env = append(base, fmt.Sprintf("PATH=%s", os.Getenv("PATH")))
+		env = append(env, fmt.Sprintf("PATH=%s", os.Getenv("PATH")))
 	}
 
-	var outBuf, errBuf bytes.Buffer
-	cmd.Stdout = &outBuf
-	cmd.Stderr = &errBuf
-
-	start := time.Now()
-	runErr := cmd.Run()
-	dur := time.Since(start)
-
-	res := &ExecResult{
-		Cmd:      spec.Binary,
-		Args:     args,
-		Duration: dur,
-		ExitCode: exitCodeOf(runErr),
+	var cmd *exec.Cmd
+	var startCmd func() error
+	if spec.Limits.set() || spec.Sandbox.set() {
+		var err error
+		if cmd, startCmd, err = isolatedCommand(cctx, spec, args, env); err != nil {
+			return nil, err
+		}
+	} else {
+		cmd = exec.CommandContext(cctx, spec.Binary, args...) // SEM shell
+		cmd.Env = env
+		startCmd = cmd.Start
 	}
+	if spec.WorkDir != "" {
+		cmd.Dir = spec.WorkDir
+	}
+	// processos netos que herdam stdout/stderr não seguram o Wait além do timeout
+	cmd.WaitDelay = time.Second
 
 	maxKB := spec.MaxOutputKB
 	if maxKB <= 0 {
 		maxKB = 256
 	} // default 256KB por stream
-	res.Stdout, res.Truncated = TruncateKB(outBuf.String(), maxKB)
-	stderrStr, trunc2 := TruncateKB(errBuf.String(), maxKB)
-	res.Stderr = stderrStr
-	res.Truncated = res.Truncated || trunc2
+	// a saída é limitada enquanto chega, sem bufferizar o excedente
+	outBuf := newLimitedBuffer(maxKB * 1024)
+	errBuf := newLimitedBuffer(maxKB * 1024)
+	cmd.Stdout = outBuf
+	cmd.Stderr = errBuf
+
+	start := time.Now()
+	runErr := startCmd()
+	if runErr == nil {
+		runErr = cmd.Wait()
+	}
+	dur := time.Since(start)
+
+	stderrStr := errBuf.StringWithNotice()
+	res := &ExecResult{
+		Cmd:       spec.Binary,
+		Args:      args,
+		Duration:  dur,
+		ExitCode:  exitCodeOf(runErr),
+		Stdout:    outBuf.StringWithNotice(),
+		Stderr:    stderrStr,
+		Truncated: outBuf.Truncated() || errBuf.Truncated(),
+	}
 
 	// contexto cancelado vira timeout
 	if errors.Is(runErr, context.DeadlineExceeded) {
@@ -450,6 +504,16 @@ func ParseUserCommand(text string) (*Parsed, error) {
 	if raw == "" {
 		return nil, errors.New("nenhum comando encontrado")
 	}
+	return ParseCommand(raw)
+}
+
+// ParseCommand divide uma linha de comando (sem gatilho em linguagem natural)
+// em binário e argumentos, recusando metacaracteres de shell.
+func ParseCommand(line string) (*Parsed, error) {
+	raw := strings.TrimSpace(line)
+	if raw == "" {
+		return nil, errors.New("comando vazio")
+	}
 	if metaBad.MatchString(raw) {
 		return nil, errors.New("uso de metachar proibido")
 	}
@@ -0,0 +1,351 @@
+package execsafe
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Isolated commands start as a copy of the running binary ("trampoline")
+// that applies the sandbox to itself and then execs the real binary:
+// no_new_privs and mounts cannot be set on a child from outside. The
+// trampoline is recognised by sandboxEnv and dispatched by RunSandbox.
+//
+// The rlimits are not set by the trampoline: a low RLIMIT_AS would starve
+// the Go runtime before the exec. Once the sandbox is in place the
+// trampoline reports on sandboxFD and parks in a raw read; gobe then sets
+// the rlimits on its pid with prlimit and releases it, and the trampoline
+// execs from argv and envp prepared beforehand, without allocating.
+const (
+	sandboxEnv  = "GOBE_EXECSAFE_SANDBOX"
+	sandboxArg0 = "execsafe-sandbox"
+	// sandboxFD is the trampoline's end of the control socket (ExtraFiles[0]).
+	sandboxFD = 3
+	// sandboxExit is the exit code of a trampoline that could not set up
+	// the sandbox or exec the binary.
+	sandboxExit = 126
+)
+
+type sandboxPlan struct {
+	Sandbox Sandbox `json:"sandbox"`
+}
+
+// RunSandbox runs the sandbox trampoline and never returns when the process
+// was started as one by RunSafe; otherwise it returns at once. Binaries
+// running commands with Limits or a Sandbox call it first thing in main.
+func RunSandbox() {
+	raw, ok := os.LookupEnv(sandboxEnv)
+	if !ok {
+		return
+	}
+	err := runTrampoline(raw)
+	fmt.Fprintf(os.Stderr, "execsafe sandbox: %v\n", err)
+	os.Exit(sandboxExit)
+}
+
+// isolatedCommand returns a command running spec.Binary under the limits and
+// sandbox of spec, and the function starting it; the caller then waits for
+// the command as usual.
+func isolatedCommand(ctx context.Context, spec CommandSpec, args, env []string) (*exec.Cmd, func() error, error) {
+	resolved, err := lookPathIn(spec.Binary, env)
+	if err != nil {
+		return nil, nil, err
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return nil, nil, fmt.Errorf("sandbox indisponível: %w", err)
+	}
+	plan, err := json.Marshal(sandboxPlan{Sandbox: spec.Sandbox})
+	if err != nil {
+		return nil, nil, err
+	}
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_STREAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("sandbox indisponível: %w", err)
+	}
+	control := os.NewFile(uintptr(fds[0]), "execsafe-control")
+	trampoline := os.NewFile(uintptr(fds[1]), "execsafe-trampoline")
+
+	cmd := exec.CommandContext(ctx, self, append([]string{resolved}, args...)...)
+	cmd.Args[0] = sandboxArg0
+	if env == nil {
+		env = os.Environ()
+	}
+	cmd.Env = append(env[:len(env):len(env)], sandboxEnv+"="+string(plan))
+	cmd.ExtraFiles = []*os.File{trampoline}
+
+	attr := &syscall.SysProcAttr{Setpgid: true}
+	if spec.Sandbox.namespaces() {
+		attr.Cloneflags = syscall.CLONE_NEWUSER
+		attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}}
+		attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}}
+		attr.GidMappingsEnableSetgroups = false
+		if spec.Sandbox.NoNetwork {
+			attr.Cloneflags |= syscall.CLONE_NEWNET
+		}
+		if spec.Sandbox.ReadOnlyFS || spec.Sandbox.PrivateTmp || spec.Sandbox.PIDNamespace {
+			attr.Cloneflags |= syscall.CLONE_NEWNS
+		}
+		if spec.Sandbox.PIDNamespace {
+			attr.Cloneflags |= syscall.CLONE_NEWPID
+		}
+	}
+	cmd.SysProcAttr = attr
+	// mata o grupo inteiro (e o namespace de PIDs) no timeout
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	start := func() error {
+		defer control.Close()
+		err := cmd.Start()
+		trampoline.Close()
+		if err != nil {
+			return err
+		}
+		return release(cmd, control, spec.Limits)
+	}
+	return cmd, start, nil
+}
+
+// release waits for the trampoline of cmd to park, sets limits on it and
+// lets it exec. A trampoline that fails before parking closes the socket
+// and its exit status is left to Wait.
+func release(cmd *exec.Cmd, control *os.File, limits Limits) error {
+	var ready [1]byte
+	if _, err := control.Read(ready[:]); err != nil {
+		return nil
+	}
+	err := setLimits(cmd.Process.Pid, limits)
+	if err == nil {
+		_, err = control.Write(ready[:])
+	}
+	if err != nil {
+		syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		cmd.Wait()
+		return err
+	}
+	return nil
+}
+
+// lookPathIn resolves bin against the PATH the child will see.
+func lookPathIn(bin string, env []string) (string, error) {
+	if strings.Contains(bin, "/") {
+		return bin, nil
+	}
+	path := os.Getenv("PATH")
+	for _, kv := range env {
+		if value, ok := strings.CutPrefix(kv, "PATH="); ok {
+			path = value
+		}
+	}
+	for _, dir := range strings.Split(path, ":") {
+		if dir == "" {
+			dir = "."
+		}
+		candidate := dir + "/" + bin
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() && info.Mode()&0o111 != 0 {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("%s: %w", bin, exec.ErrNotFound)
+}
+
+// runTrampoline applies the plan to the current process, waits on sandboxFD
+// for gobe to set the rlimits and execs the binary in os.Args[1]. It only
+// returns on failure.
+func runTrampoline(raw string) error {
+	// no_new_privs vale por thread: prctl e execve na mesma thread
+	runtime.LockOSThread()
+
+	var plan sandboxPlan
+	if err := json.Unmarshal([]byte(raw), &plan); err != nil {
+		return fmt.Errorf("invalid plan: %w", err)
+	}
+	if len(os.Args) < 2 {
+		return errors.New("missing binary")
+	}
+	env := make([]string, 0, len(os.Environ()))
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, sandboxEnv+"=") {
+			env = append(env, kv)
+		}
+	}
+
+	sb := plan.Sandbox
+	if sb.ReadOnlyFS || sb.PrivateTmp || sb.PIDNamespace {
+		if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+			return fmt.Errorf("make mounts private: %w", err)
+		}
+	}
+	if sb.ReadOnlyFS {
+		if err := remountReadOnly(); err != nil {
+			return err
+		}
+	}
+	if sb.PrivateTmp {
+		if err := unix.Mount("tmpfs", "/tmp", "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "mode=1777"); err != nil {
+			return fmt.Errorf("mount private /tmp: %w", err)
+		}
+	}
+	if sb.PIDNamespace {
+		flags := uintptr(unix.MS_NOSUID | unix.MS_NODEV | unix.MS_NOEXEC)
+		if sb.ReadOnlyFS {
+			flags |= unix.MS_RDONLY
+		}
+		if err := unix.Mount("proc", "/proc", "proc", flags, ""); err != nil {
+			return fmt.Errorf("mount /proc: %w", err)
+		}
+	}
+	if sb.NoNewPrivileges {
+		if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+			return fmt.Errorf("set no_new_privs: %w", err)
+		}
+	}
+
+	// tudo que o execve usa é alocado antes dos rlimits
+	path, err := syscall.BytePtrFromString(os.Args[1])
+	if err != nil {
+		return err
+	}
+	argv, err := syscall.SlicePtrFromStrings(os.Args[1:])
+	if err != nil {
+		return err
+	}
+	envv, err := syscall.SlicePtrFromStrings(env)
+	if err != nil {
+		return err
+	}
+	unix.CloseOnExec(sandboxFD)
+	var signal [1]byte
+	if _, err := unix.Write(sandboxFD, signal[:]); err != nil {
+		return fmt.Errorf("signal gobe: %w", err)
+	}
+	// RawSyscall: o runtime não intervém enquanto espera nem depois
+	if n, _, _ := unix.RawSyscall(unix.SYS_READ, sandboxFD, uintptr(unsafe.Pointer(&signal[0])), 1); n != 1 {
+		return errors.New("gobe did not release the sandbox")
+	}
+	_, _, errno := unix.RawSyscall(unix.SYS_EXECVE,
+		uintptr(unsafe.Pointer(path)),
+		uintptr(unsafe.Pointer(&argv[0])),
+		uintptr(unsafe.Pointer(&envv[0])))
+	return errno
+}
+
+// setLimits sets the rlimits of l on the process pid.
+func setLimits(pid int, l Limits) error {
+	limits := []struct {
+		name     string
+		resource int
+		value    uint64
+	}{
+		{"cpu", unix.RLIMIT_CPU, l.CPUSeconds},
+		{"address space", unix.RLIMIT_AS, l.AddressSpaceMB << 20},
+		{"open files", unix.RLIMIT_NOFILE, l.OpenFiles},
+		{"processes", unix.RLIMIT_NPROC, l.Processes},
+	}
+	for _, limit := range limits {
+		if limit.value == 0 {
+			continue
+		}
+		if err := unix.Prlimit(pid, limit.resource, &unix.Rlimit{Cur: limit.value, Max: limit.value}, nil); err != nil {
+			return fmt.Errorf("set %s limit: %w", limit.name, err)
+		}
+	}
+	return nil
+}
+
+// remountReadOnly makes every mount of the (private) mount namespace read
+// only, with one mount_setattr call when the kernel has it (5.12+) and one
+// bind remount per mount point otherwise.
+func remountReadOnly() error {
+	err := unix.MountSetattr(-1, "/", unix.AT_RECURSIVE, &unix.MountAttr{Attr_set: unix.MOUNT_ATTR_RDONLY})
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, unix.ENOSYS) {
+		return fmt.Errorf("remount read-only: %w", err)
+	}
+
+	points, err := mountPoints()
+	if err != nil {
+		return err
+	}
+	for _, point := range points {
+		var st unix.Statfs_t
+		if err := unix.Statfs(point, &st); err != nil {
+			continue // escondido por outra montagem
+		}
+		// flags travadas pelo namespace de usuário precisam ser mantidas
+		flags := uintptr(unix.MS_BIND | unix.MS_REMOUNT | unix.MS_RDONLY)
+		for stFlag, msFlag := range lockedMountFlags {
+			if st.Flags&stFlag != 0 {
+				flags |= msFlag
+			}
+		}
+		if err := unix.Mount("", point, "", flags, ""); err != nil {
+			return fmt.Errorf("remount %s read-only: %w", point, err)
+		}
+	}
+	return nil
+}
+
+// lockedMountFlags maps statfs flags to the mount flags a bind remount must
+// repeat.
+var lockedMountFlags = map[int64]uintptr{
+	unix.ST_NOSUID:     unix.MS_NOSUID,
+	unix.ST_NODEV:      unix.MS_NODEV,
+	unix.ST_NOEXEC:     unix.MS_NOEXEC,
+	unix.ST_NOATIME:    unix.MS_NOATIME,
+	unix.ST_NODIRATIME: unix.MS_NODIRATIME,
+	unix.ST_RELATIME:   unix.MS_RELATIME,
+}
+
+// mountPoints lists the mount points of /proc/self/mountinfo, parents first.
+func mountPoints() ([]string, error) {
+	file, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var points []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		points = append(points, unescapeMountPath(fields[4]))
+	}
+	return points, scanner.Err()
+}
+
+// unescapeMountPath decodes the octal escapes (\040 for space) of mountinfo.
+func unescapeMountPath(path string) string {
+	if !strings.Contains(path, `\`) {
+		return path
+	}
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] == '\\' && i+3 < len(path) {
+			if c, err := strconv.ParseUint(path[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(path[i])
+	}
+	return b.String()
+}
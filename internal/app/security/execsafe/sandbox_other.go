@@ -0,0 +1,17 @@
+//go:build !linux
+
+package execsafe
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+)
+
+// RunSandbox returns at once: there is no sandbox trampoline outside Linux.
+func RunSandbox() {}
+
+// isolatedCommand refuses to run: rlimits and sandboxing need Linux.
+func isolatedCommand(ctx context.Context, spec CommandSpec, args, env []string) (*exec.Cmd, func() error, error) {
+	return nil, nil, errors.New("limites e sandbox exigem Linux")
+}
@@ -74,6 +74,17 @@ func (b *Bridge) CronService() CronService {
 	return models.NewCronJobService(repo)
 }
 
+// CronRepo reads and saves cron jobs without the events CronService
+// publishes on each change.
+func (b *Bridge) CronRepo() CronRepo {
+	return models.NewCronJobRepo(b.ctx, b.db)
+}
+
+// CronExecutionStore persists the execution log of the cron jobs.
+func (b *Bridge) CronExecutionStore() CronExecutionStore {
+	return NewCronExecutionStore(b.db)
+}
+
 // ========================================
 // Discord
 // ========================================
@@ -101,6 +112,33 @@ func (b *Bridge) WebhookService() WebhookService {
 	return models.NewWebhookService(repo)
 }
 
+// WebhookEventStore persists the inbound events of the gateway webhook
+// receiver.
+func (b *Bridge) WebhookEventStore() WebhookEventStore {
+	return NewWebhookEventStore(b.db)
+}
+
+// WebhookSubscriptionStore persists the outbound webhook subscriptions and
+// their delivery log.
+func (b *Bridge) WebhookSubscriptionStore() WebhookSubscriptionStore {
+	return NewWebhookSubscriptionStore(b.db)
+}
+
+// WebhookRuleStore persists the rules mapping inbound webhook events to
+// actions.
+func (b *Bridge) WebhookRuleStore() WebhookRuleStore {
+	return NewWebhookRuleStore(b.db)
+}
+
+// ========================================
+// Approvals
+// ========================================
+
+// ApprovalStore persists approval requests and their audit trail.
+func (b *Bridge) ApprovalStore() ApprovalStore {
+	return NewApprovalStore(b.db)
+}
+
 // ========================================
 // Analysis Jobs
 // ========================================
@@ -191,3 +229,11 @@ func (b *Bridge) ProvidersService() ProvidersService {
 	repo := mcpmodels.NewProvidersRepo(b.db)
 	return mcpmodels.NewProvidersService(repo)
 }
+
+// ========================================
+// Gateway: Usage Ledger
+// ========================================
+
+func (b *Bridge) UsageLedgerService() UsageLedgerService {
+	return NewUsageLedgerService(b.db)
+}
@@ -0,0 +1,125 @@
+package gdbasez
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+	"gorm.io/gorm"
+)
+
+// ErrApprovalRequestNotFound is returned for unknown approval request ids.
+var ErrApprovalRequestNotFound = errors.New("approval request not found")
+
+// ApprovalRequestRecord is an approval request and the policy it was filed
+// under. Details, Policy and Messages are JSON objects.
+type ApprovalRequestRecord struct {
+	ID          string     `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	Action      string     `json:"action" gorm:"index"`
+	Platform    string     `json:"platform"`
+	RequestedBy string     `json:"requested_by"`
+	Details     string     `json:"details" gorm:"type:text"`
+	Policy      string     `json:"policy" gorm:"type:text"`
+	Messages    string     `json:"messages" gorm:"type:text"`
+	Status      string     `json:"status" gorm:"index"`
+	Escalated   bool       `json:"escalated"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	DecidedAt   *time.Time `json:"decided_at,omitempty"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func (ApprovalRequestRecord) TableName() string { return "approval_requests" }
+
+// ApprovalDecisionRecord is an entry of the audit trail of a request: its
+// filing, each vote, the escalation and the final outcome. Roles is a comma
+// separated list.
+type ApprovalDecisionRecord struct {
+	ID           string    `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	RequestID    string    `json:"request_id" gorm:"index;type:varchar(36)"`
+	Kind         string    `json:"kind"`
+	ApproverID   string    `json:"approver_id"`
+	ApproverName string    `json:"approver_name"`
+	Roles        string    `json:"roles"`
+	Comment      string    `json:"comment" gorm:"type:text"`
+	CreatedAt    time.Time `json:"created_at" gorm:"index"`
+}
+
+func (ApprovalDecisionRecord) TableName() string { return "approval_decisions" }
+
+// ApprovalStore persists approval requests and their audit trail.
+type ApprovalStore interface {
+	SaveRequest(ctx context.Context, record *ApprovalRequestRecord) error
+	GetRequest(ctx context.Context, id string) (*ApprovalRequestRecord, error)
+	// ListRequests returns the requests with status, or all of them when
+	// status is empty, oldest first.
+	ListRequests(ctx context.Context, status string) ([]ApprovalRequestRecord, error)
+	AppendDecision(ctx context.Context, record *ApprovalDecisionRecord) error
+	// ListDecisions returns the audit trail of a request, oldest first.
+	ListDecisions(ctx context.Context, requestID string) ([]ApprovalDecisionRecord, error)
+}
+
+type approvalStore struct {
+	db *gorm.DB
+}
+
+// NewApprovalStore returns an approval store backed by db, creating its
+// tables when missing.
+func NewApprovalStore(db *gorm.DB) ApprovalStore {
+	if err := db.AutoMigrate(&ApprovalRequestRecord{}, &ApprovalDecisionRecord{}); err != nil {
+		gl.Log("error", "failed to migrate approvals", err)
+	}
+	return &approvalStore{db: db}
+}
+
+func (s *approvalStore) SaveRequest(ctx context.Context, record *ApprovalRequestRecord) error {
+	if record.ID == "" {
+		record.ID = uuid.NewString()
+	}
+	return s.db.WithContext(ctx).Save(record).Error
+}
+
+func (s *approvalStore) GetRequest(ctx context.Context, id string) (*ApprovalRequestRecord, error) {
+	var record ApprovalRequestRecord
+	err := s.db.WithContext(ctx).Where("id = ?", id).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrApprovalRequestNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (s *approvalStore) ListRequests(ctx context.Context, status string) ([]ApprovalRequestRecord, error) {
+	query := s.db.WithContext(ctx).Order("created_at ASC").Order("id ASC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var records []ApprovalRequestRecord
+	if err := query.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (s *approvalStore) AppendDecision(ctx context.Context, record *ApprovalDecisionRecord) error {
+	if record.ID == "" {
+		record.ID = uuid.NewString()
+	}
+	return s.db.WithContext(ctx).Create(record).Error
+}
+
+func (s *approvalStore) ListDecisions(ctx context.Context, requestID string) ([]ApprovalDecisionRecord, error) {
+	var records []ApprovalDecisionRecord
+	err := s.db.WithContext(ctx).
+		Where("request_id = ?", requestID).
+		Order("created_at ASC").Order("id ASC").
+		Find(&records).Error
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
@@ -0,0 +1,122 @@
+package gdbasez
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+	"gorm.io/gorm"
+)
+
+// Statuses of a cron job run. The finished ones match the values of
+// CronModel.LastRunStatus.
+const (
+	CronExecutionRunning = "running"
+	CronExecutionSuccess = "success"
+	CronExecutionFailure = "failure"
+)
+
+// ErrCronExecutionNotFound is returned by GetExecution for unknown ids.
+var ErrCronExecutionNotFound = errors.New("cron execution not found")
+
+// CronExecutionRecord is a run of a cron job. Output holds what the run
+// produced: the output of a command, the body of an HTTP response, the
+// result of a tool, the answer of a model or the publish confirmation.
+// ExitCode is the exit status of a command, 0 or 1 for the other kinds, and
+// -1 when the run could not start or timed out; StatusCode is the HTTP
+// status of HTTP runs.
+type CronExecutionRecord struct {
+	ID            string     `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	CronJobID     string     `json:"cronjob_id" gorm:"type:varchar(36);index:idx_cron_executions_job_time,priority:1"`
+	Kind          string     `json:"kind"`
+	Trigger       string     `json:"trigger"`
+	Status        string     `json:"status" gorm:"index"`
+	ExitCode      int        `json:"exit_code"`
+	StatusCode    int        `json:"status_code,omitempty"`
+	Output        string     `json:"output" gorm:"type:text"`
+	ErrorMessage  string     `json:"error_message,omitempty" gorm:"type:text"`
+	Truncated     bool       `json:"truncated,omitempty"`
+	RetryCount    int        `json:"retry_count"`
+	ExecutionTime time.Time  `json:"execution_time" gorm:"index:idx_cron_executions_job_time,priority:2"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+	DurationMs    int64      `json:"duration_ms"`
+	UserID        string     `json:"user_id,omitempty" gorm:"type:varchar(36)"`
+}
+
+func (CronExecutionRecord) TableName() string { return "cron_executions" }
+
+// CronExecutionStore persists the execution log of the cron jobs.
+type CronExecutionStore interface {
+	SaveExecution(ctx context.Context, record *CronExecutionRecord) error
+	UpdateExecution(ctx context.Context, record *CronExecutionRecord) error
+	GetExecution(ctx context.Context, id string) (*CronExecutionRecord, error)
+	// ListExecutions returns the last limit runs of a cron job, newest
+	// first. A limit of 0 returns them all.
+	ListExecutions(ctx context.Context, cronJobID string, limit int) ([]CronExecutionRecord, error)
+	// PurgeExecutions deletes the finished runs started before cutoff.
+	PurgeExecutions(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+type cronExecutionStore struct {
+	db *gorm.DB
+}
+
+// NewCronExecutionStore returns an execution log backed by db, creating its
+// table when missing.
+func NewCronExecutionStore(db *gorm.DB) CronExecutionStore {
+	if err := db.AutoMigrate(&CronExecutionRecord{}); err != nil {
+		gl.Log("error", "failed to migrate cron executions", err)
+	}
+	return &cronExecutionStore{db: db}
+}
+
+func (s *cronExecutionStore) SaveExecution(ctx context.Context, record *CronExecutionRecord) error {
+	if record.ID == "" {
+		record.ID = uuid.NewString()
+	}
+	if record.ExecutionTime.IsZero() {
+		record.ExecutionTime = time.Now().UTC()
+	}
+	if record.Status == "" {
+		record.Status = CronExecutionRunning
+	}
+	return s.db.WithContext(ctx).Create(record).Error
+}
+
+func (s *cronExecutionStore) UpdateExecution(ctx context.Context, record *CronExecutionRecord) error {
+	return s.db.WithContext(ctx).Save(record).Error
+}
+
+func (s *cronExecutionStore) GetExecution(ctx context.Context, id string) (*CronExecutionRecord, error) {
+	var record CronExecutionRecord
+	err := s.db.WithContext(ctx).Where("id = ?", id).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCronExecutionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (s *cronExecutionStore) ListExecutions(ctx context.Context, cronJobID string, limit int) ([]CronExecutionRecord, error) {
+	query := s.db.WithContext(ctx).Where("cron_job_id = ?", cronJobID).
+		Order("execution_time DESC").Order("id DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	var records []CronExecutionRecord
+	if err := query.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (s *cronExecutionStore) PurgeExecutions(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).
+		Where("status <> ? AND execution_time < ?", CronExecutionRunning, cutoff).
+		Delete(&CronExecutionRecord{})
+	return result.RowsAffected, result.Error
+}
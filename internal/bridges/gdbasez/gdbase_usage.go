@@ -0,0 +1,148 @@
+package gdbasez
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+	"gorm.io/gorm"
+)
+
+// UsageRecord is one priced gateway call (chat, advise, OpenAI facade) in
+// the usage ledger.
+type UsageRecord struct {
+	ID               string    `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	TenantID         string    `json:"tenant_id" gorm:"index:idx_gateway_usage_tenant_time,priority:1"`
+	UserID           string    `json:"user_id" gorm:"index"`
+	Kind             string    `json:"kind"`
+	Provider         string    `json:"provider" gorm:"index"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	TotalTokens      int       `json:"total_tokens"`
+	CostUSD          float64   `json:"cost_usd"`
+	LatencyMS        int64     `json:"latency_ms"`
+	CreatedAt        time.Time `json:"created_at" gorm:"index:idx_gateway_usage_tenant_time,priority:2"`
+}
+
+func (UsageRecord) TableName() string { return "gateway_usage_ledger" }
+
+// UsageFilter selects ledger records. Empty fields match everything; From is
+// inclusive and To exclusive.
+type UsageFilter struct {
+	TenantID string
+	UserID   string
+	Provider string
+	Model    string
+	From     time.Time
+	To       time.Time
+	Limit    int
+}
+
+// UsageTotals sums the records sharing Key (empty when not grouped).
+type UsageTotals struct {
+	Key              string  `json:"key,omitempty" gorm:"column:group_key"`
+	Requests         int64   `json:"requests"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+}
+
+// UsageGroups are the columns ledger totals can be grouped by.
+var UsageGroups = map[string]string{
+	"tenant":   "tenant_id",
+	"user":     "user_id",
+	"provider": "provider",
+	"model":    "model",
+	"kind":     "kind",
+}
+
+// UsageLedgerService stores and aggregates gateway usage records.
+type UsageLedgerService interface {
+	RecordUsage(ctx context.Context, record *UsageRecord) error
+	ListUsage(ctx context.Context, filter UsageFilter) ([]UsageRecord, error)
+	SumUsage(ctx context.Context, filter UsageFilter, groupBy string) ([]UsageTotals, error)
+}
+
+type usageLedgerService struct {
+	db *gorm.DB
+}
+
+// NewUsageLedgerService returns a ledger backed by db, creating its table
+// when missing.
+func NewUsageLedgerService(db *gorm.DB) UsageLedgerService {
+	if err := db.AutoMigrate(&UsageRecord{}); err != nil {
+		gl.Log("error", "failed to migrate gateway usage ledger", err)
+	}
+	return &usageLedgerService{db: db}
+}
+
+func (s *usageLedgerService) RecordUsage(ctx context.Context, record *UsageRecord) error {
+	if record.ID == "" {
+		record.ID = uuid.NewString()
+	}
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now().UTC()
+	}
+	return s.db.WithContext(ctx).Create(record).Error
+}
+
+func (s *usageLedgerService) ListUsage(ctx context.Context, filter UsageFilter) ([]UsageRecord, error) {
+	var records []UsageRecord
+	query := s.filtered(ctx, filter).Order("created_at DESC")
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if err := query.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (s *usageLedgerService) SumUsage(ctx context.Context, filter UsageFilter, groupBy string) ([]UsageTotals, error) {
+	sums := "COUNT(*) AS requests, COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens, " +
+		"COALESCE(SUM(completion_tokens), 0) AS completion_tokens, COALESCE(SUM(total_tokens), 0) AS total_tokens, " +
+		"COALESCE(SUM(cost_usd), 0) AS cost_usd"
+
+	var totals []UsageTotals
+	query := s.filtered(ctx, filter)
+	if groupBy == "" {
+		query = query.Select(sums)
+	} else {
+		column, ok := UsageGroups[groupBy]
+		if !ok {
+			return nil, fmt.Errorf("unknown usage group %q", groupBy)
+		}
+		query = query.Select(column + " AS group_key, " + sums).Group(column).Order("cost_usd DESC")
+	}
+	if err := query.Scan(&totals).Error; err != nil {
+		return nil, err
+	}
+	return totals, nil
+}
+
+func (s *usageLedgerService) filtered(ctx context.Context, filter UsageFilter) *gorm.DB {
+	query := s.db.WithContext(ctx).Model(&UsageRecord{})
+	if filter.TenantID != "" {
+		query = query.Where("tenant_id = ?", filter.TenantID)
+	}
+	if filter.UserID != "" {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Provider != "" {
+		query = query.Where("provider = ?", filter.Provider)
+	}
+	if filter.Model != "" {
+		query = query.Where("model = ?", filter.Model)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+	return query
+}
@@ -0,0 +1,223 @@
+package gdbasez
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+	"gorm.io/gorm"
+)
+
+// Statuses of an inbound webhook event.
+const (
+	WebhookEventReceived   = "received"
+	WebhookEventProcessing = "processing"
+	WebhookEventCompleted  = "completed"
+	WebhookEventFailed     = "failed"
+	// WebhookEventRejected marks deliveries refused before processing, such
+	// as a bad signature; Error holds the reason.
+	WebhookEventRejected = "rejected"
+)
+
+// ErrWebhookEventNotFound is returned by GetEvent for unknown ids.
+var ErrWebhookEventNotFound = errors.New("webhook event not found")
+
+// WebhookEventRecord is an inbound webhook event. Payload and Headers hold
+// JSON documents. LockedUntil is the lease of the worker processing the
+// event; a processing event whose lease expired is picked up again.
+type WebhookEventRecord struct {
+	ID          string     `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	Source      string     `json:"source" gorm:"index:idx_webhook_events_source_time,priority:1"`
+	EventType   string     `json:"event_type" gorm:"index:idx_webhook_events_type_time,priority:1"`
+	Payload     string     `json:"payload" gorm:"type:text"`
+	Headers     string     `json:"headers" gorm:"type:text"`
+	Status      string     `json:"status" gorm:"index:idx_webhook_events_status_time,priority:1"`
+	Error       string     `json:"error,omitempty"`
+	Attempts    int        `json:"attempts"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+	ReceivedAt  time.Time  `json:"received_at" gorm:"index:idx_webhook_events_source_time,priority:2;index:idx_webhook_events_type_time,priority:2;index:idx_webhook_events_status_time,priority:2;index"`
+	ProcessedAt *time.Time `json:"processed_at,omitempty"`
+}
+
+func (WebhookEventRecord) TableName() string { return "webhook_events" }
+
+// WebhookEventFilter selects events. Empty fields match everything; From is
+// inclusive and To exclusive. Events are listed newest first; a non-zero
+// Before (with BeforeID breaking ties) continues a previous page.
+type WebhookEventFilter struct {
+	Source    string
+	EventType string
+	Status    string
+	From      time.Time
+	To        time.Time
+	Before    time.Time
+	BeforeID  string
+	Offset    int
+	Limit     int
+}
+
+// WebhookEventStore persists inbound webhook events.
+type WebhookEventStore interface {
+	SaveEvent(ctx context.Context, record *WebhookEventRecord) error
+	UpdateEvent(ctx context.Context, record *WebhookEventRecord) error
+	GetEvent(ctx context.Context, id string) (*WebhookEventRecord, error)
+	ListEvents(ctx context.Context, filter WebhookEventFilter) ([]WebhookEventRecord, error)
+	CountEvents(ctx context.Context, filter WebhookEventFilter) (int64, error)
+	CountByStatus(ctx context.Context) (map[string]int64, error)
+	// ClaimEvents marks up to limit pending events (received, or processing
+	// with an expired lease) as processing until lockUntil and returns them.
+	ClaimEvents(ctx context.Context, limit int, now, lockUntil time.Time) ([]WebhookEventRecord, error)
+	// RetryFailed puts failed events back to received.
+	RetryFailed(ctx context.Context) (int64, error)
+	// PurgeEvents deletes the completed, failed and rejected events received
+	// before cutoff.
+	PurgeEvents(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+type webhookEventStore struct {
+	db *gorm.DB
+}
+
+// NewWebhookEventStore returns an event store backed by db, creating its
+// table when missing.
+func NewWebhookEventStore(db *gorm.DB) WebhookEventStore {
+	if err := db.AutoMigrate(&WebhookEventRecord{}); err != nil {
+		gl.Log("error", "failed to migrate webhook events", err)
+	}
+	return &webhookEventStore{db: db}
+}
+
+func (s *webhookEventStore) SaveEvent(ctx context.Context, record *WebhookEventRecord) error {
+	if record.ID == "" {
+		record.ID = uuid.NewString()
+	}
+	if record.ReceivedAt.IsZero() {
+		record.ReceivedAt = time.Now().UTC()
+	}
+	if record.Status == "" {
+		record.Status = WebhookEventReceived
+	}
+	return s.db.WithContext(ctx).Create(record).Error
+}
+
+func (s *webhookEventStore) UpdateEvent(ctx context.Context, record *WebhookEventRecord) error {
+	return s.db.WithContext(ctx).Save(record).Error
+}
+
+func (s *webhookEventStore) GetEvent(ctx context.Context, id string) (*WebhookEventRecord, error) {
+	var record WebhookEventRecord
+	err := s.db.WithContext(ctx).Where("id = ?", id).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrWebhookEventNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (s *webhookEventStore) ListEvents(ctx context.Context, filter WebhookEventFilter) ([]WebhookEventRecord, error) {
+	query := s.filtered(ctx, filter).Order("received_at DESC").Order("id DESC")
+	if !filter.Before.IsZero() {
+		query = query.Where("received_at < ? OR (received_at = ? AND id < ?)", filter.Before, filter.Before, filter.BeforeID)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	var records []WebhookEventRecord
+	if err := query.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (s *webhookEventStore) CountEvents(ctx context.Context, filter WebhookEventFilter) (int64, error) {
+	var count int64
+	err := s.filtered(ctx, filter).Count(&count).Error
+	return count, err
+}
+
+func (s *webhookEventStore) CountByStatus(ctx context.Context) (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	err := s.db.WithContext(ctx).Model(&WebhookEventRecord{}).
+		Select("status, COUNT(*) AS count").Group("status").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+func (s *webhookEventStore) ClaimEvents(ctx context.Context, limit int, now, lockUntil time.Time) ([]WebhookEventRecord, error) {
+	pending := func(db *gorm.DB) *gorm.DB {
+		return db.Where("status = ? OR (status = ? AND locked_until < ?)", WebhookEventReceived, WebhookEventProcessing, now)
+	}
+
+	var candidates []WebhookEventRecord
+	err := s.db.WithContext(ctx).Scopes(pending).Order("received_at ASC").Limit(limit).Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	claimed := make([]WebhookEventRecord, 0, len(candidates))
+	for _, record := range candidates {
+		// Only one worker wins the conditional update of an event
+		result := s.db.WithContext(ctx).Model(&WebhookEventRecord{}).
+			Where("id = ?", record.ID).Scopes(pending).
+			Updates(map[string]interface{}{"status": WebhookEventProcessing, "locked_until": lockUntil})
+		if result.Error != nil {
+			return claimed, result.Error
+		}
+		if result.RowsAffected == 1 {
+			record.Status = WebhookEventProcessing
+			record.LockedUntil = &lockUntil
+			claimed = append(claimed, record)
+		}
+	}
+	return claimed, nil
+}
+
+func (s *webhookEventStore) RetryFailed(ctx context.Context) (int64, error) {
+	result := s.db.WithContext(ctx).Model(&WebhookEventRecord{}).
+		Where("status = ?", WebhookEventFailed).
+		Updates(map[string]interface{}{"status": WebhookEventReceived, "error": ""})
+	return result.RowsAffected, result.Error
+}
+
+func (s *webhookEventStore) PurgeEvents(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).
+		Where("received_at < ? AND status IN ?", cutoff, []string{WebhookEventCompleted, WebhookEventFailed, WebhookEventRejected}).
+		Delete(&WebhookEventRecord{})
+	return result.RowsAffected, result.Error
+}
+
+func (s *webhookEventStore) filtered(ctx context.Context, filter WebhookEventFilter) *gorm.DB {
+	query := s.db.WithContext(ctx).Model(&WebhookEventRecord{})
+	if filter.Source != "" {
+		query = query.Where("source = ?", filter.Source)
+	}
+	if filter.EventType != "" {
+		query = query.Where("event_type = ?", filter.EventType)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("received_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("received_at < ?", filter.To)
+	}
+	return query
+}
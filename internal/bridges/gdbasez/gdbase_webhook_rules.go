@@ -0,0 +1,98 @@
+package gdbasez
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+	"gorm.io/gorm"
+)
+
+// ErrWebhookRuleNotFound is returned for unknown rule ids.
+var ErrWebhookRuleNotFound = errors.New("webhook rule not found")
+
+// WebhookRuleRecord maps the events matching Source and EventType to an
+// action. Params and Mapping are JSON objects.
+type WebhookRuleRecord struct {
+	ID          string    `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	Name        string    `json:"name"`
+	Source      string    `json:"source"`
+	EventType   string    `json:"event_type"`
+	Action      string    `json:"action"`
+	Target      string    `json:"target"`
+	Params      string    `json:"params" gorm:"type:text"`
+	Mapping     string    `json:"mapping" gorm:"type:text"`
+	Priority    int       `json:"priority"`
+	StopOnMatch bool      `json:"stop_on_match"`
+	Enabled     bool      `json:"enabled" gorm:"index"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (WebhookRuleRecord) TableName() string { return "webhook_rules" }
+
+// WebhookRuleStore persists the webhook handler rules.
+type WebhookRuleStore interface {
+	SaveRule(ctx context.Context, record *WebhookRuleRecord) error
+	GetRule(ctx context.Context, id string) (*WebhookRuleRecord, error)
+	// ListRules returns the rules by ascending priority, then creation.
+	ListRules(ctx context.Context, enabledOnly bool) ([]WebhookRuleRecord, error)
+	DeleteRule(ctx context.Context, id string) error
+}
+
+type webhookRuleStore struct {
+	db *gorm.DB
+}
+
+// NewWebhookRuleStore returns a rule store backed by db, creating its table
+// when missing.
+func NewWebhookRuleStore(db *gorm.DB) WebhookRuleStore {
+	if err := db.AutoMigrate(&WebhookRuleRecord{}); err != nil {
+		gl.Log("error", "failed to migrate webhook rules", err)
+	}
+	return &webhookRuleStore{db: db}
+}
+
+func (s *webhookRuleStore) SaveRule(ctx context.Context, record *WebhookRuleRecord) error {
+	if record.ID == "" {
+		record.ID = uuid.NewString()
+	}
+	return s.db.WithContext(ctx).Save(record).Error
+}
+
+func (s *webhookRuleStore) GetRule(ctx context.Context, id string) (*WebhookRuleRecord, error) {
+	var record WebhookRuleRecord
+	err := s.db.WithContext(ctx).Where("id = ?", id).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrWebhookRuleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (s *webhookRuleStore) ListRules(ctx context.Context, enabledOnly bool) ([]WebhookRuleRecord, error) {
+	query := s.db.WithContext(ctx).Order("priority ASC").Order("created_at ASC").Order("id ASC")
+	if enabledOnly {
+		query = query.Where("enabled = ?", true)
+	}
+	var records []WebhookRuleRecord
+	if err := query.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (s *webhookRuleStore) DeleteRule(ctx context.Context, id string) error {
+	result := s.db.WithContext(ctx).Where("id = ?", id).Delete(&WebhookRuleRecord{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrWebhookRuleNotFound
+	}
+	return nil
+}
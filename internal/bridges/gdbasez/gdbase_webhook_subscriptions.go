@@ -0,0 +1,227 @@
+package gdbasez
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+	"gorm.io/gorm"
+)
+
+// Statuses of an outbound webhook delivery. Pending deliveries are sent once
+// NextAttemptAt passes; a delivery that failed MaxAttempts times is dead.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySending   = "sending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryDead      = "dead"
+)
+
+var (
+	// ErrWebhookSubscriptionNotFound is returned for unknown subscription ids.
+	ErrWebhookSubscriptionNotFound = errors.New("webhook subscription not found")
+	// ErrWebhookDeliveryNotFound is returned for unknown delivery ids.
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// WebhookSubscriptionRecord is a consumer of gobe events. EventTypes is a
+// comma separated list of event types; "*" matches every event and
+// "prefix.*" every event under prefix. Secret is stored encrypted by the
+// outbound dispatcher.
+type WebhookSubscriptionRecord struct {
+	ID          string    `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	URL         string    `json:"url"`
+	EventTypes  string    `json:"event_types"`
+	Secret      string    `json:"-"`
+	Description string    `json:"description,omitempty"`
+	Active      bool      `json:"active" gorm:"index"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (WebhookSubscriptionRecord) TableName() string { return "webhook_subscriptions" }
+
+// WebhookDeliveryRecord is one event sent (or to be sent) to one URL.
+// SubscriptionID is empty for one-off deliveries, signed with the dispatcher
+// secret.
+type WebhookDeliveryRecord struct {
+	ID             string     `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	SubscriptionID string     `json:"subscription_id,omitempty" gorm:"index:idx_webhook_deliveries_subscription,priority:1"`
+	URL            string     `json:"url"`
+	EventID        string     `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Payload        string     `json:"payload" gorm:"type:text"`
+	Status         string     `json:"status" gorm:"index:idx_webhook_deliveries_due,priority:1"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" gorm:"index:idx_webhook_deliveries_due,priority:2"`
+	LockedUntil    *time.Time `json:"locked_until,omitempty"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"index:idx_webhook_deliveries_subscription,priority:2"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
+
+func (WebhookDeliveryRecord) TableName() string { return "webhook_deliveries" }
+
+// WebhookDeliveryFilter selects deliveries, newest first. Empty fields match
+// everything.
+type WebhookDeliveryFilter struct {
+	SubscriptionID string
+	Status         string
+	EventType      string
+	Offset         int
+	Limit          int
+}
+
+// WebhookSubscriptionStore persists outbound webhook subscriptions and their
+// delivery log.
+type WebhookSubscriptionStore interface {
+	SaveSubscription(ctx context.Context, record *WebhookSubscriptionRecord) error
+	GetSubscription(ctx context.Context, id string) (*WebhookSubscriptionRecord, error)
+	ListSubscriptions(ctx context.Context, activeOnly bool) ([]WebhookSubscriptionRecord, error)
+	DeleteSubscription(ctx context.Context, id string) error
+
+	SaveDelivery(ctx context.Context, record *WebhookDeliveryRecord) error
+	GetDelivery(ctx context.Context, id string) (*WebhookDeliveryRecord, error)
+	ListDeliveries(ctx context.Context, filter WebhookDeliveryFilter) ([]WebhookDeliveryRecord, int64, error)
+	// ClaimDeliveries marks up to limit due deliveries (pending with
+	// NextAttemptAt before now, or sending with an expired lease) as sending
+	// until lockUntil and returns them.
+	ClaimDeliveries(ctx context.Context, limit int, now, lockUntil time.Time) ([]WebhookDeliveryRecord, error)
+}
+
+type webhookSubscriptionStore struct {
+	db *gorm.DB
+}
+
+// NewWebhookSubscriptionStore returns a subscription store backed by db,
+// creating its tables when missing.
+func NewWebhookSubscriptionStore(db *gorm.DB) WebhookSubscriptionStore {
+	if err := db.AutoMigrate(&WebhookSubscriptionRecord{}, &WebhookDeliveryRecord{}); err != nil {
+		gl.Log("error", "failed to migrate webhook subscriptions", err)
+	}
+	return &webhookSubscriptionStore{db: db}
+}
+
+func (s *webhookSubscriptionStore) SaveSubscription(ctx context.Context, record *WebhookSubscriptionRecord) error {
+	if record.ID == "" {
+		record.ID = uuid.NewString()
+	}
+	return s.db.WithContext(ctx).Save(record).Error
+}
+
+func (s *webhookSubscriptionStore) GetSubscription(ctx context.Context, id string) (*WebhookSubscriptionRecord, error) {
+	var record WebhookSubscriptionRecord
+	err := s.db.WithContext(ctx).Where("id = ?", id).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrWebhookSubscriptionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (s *webhookSubscriptionStore) ListSubscriptions(ctx context.Context, activeOnly bool) ([]WebhookSubscriptionRecord, error) {
+	query := s.db.WithContext(ctx).Order("created_at ASC")
+	if activeOnly {
+		query = query.Where("active = ?", true)
+	}
+	var records []WebhookSubscriptionRecord
+	if err := query.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (s *webhookSubscriptionStore) DeleteSubscription(ctx context.Context, id string) error {
+	result := s.db.WithContext(ctx).Where("id = ?", id).Delete(&WebhookSubscriptionRecord{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrWebhookSubscriptionNotFound
+	}
+	return nil
+}
+
+func (s *webhookSubscriptionStore) SaveDelivery(ctx context.Context, record *WebhookDeliveryRecord) error {
+	if record.ID == "" {
+		record.ID = uuid.NewString()
+	}
+	return s.db.WithContext(ctx).Save(record).Error
+}
+
+func (s *webhookSubscriptionStore) GetDelivery(ctx context.Context, id string) (*WebhookDeliveryRecord, error) {
+	var record WebhookDeliveryRecord
+	err := s.db.WithContext(ctx).Where("id = ?", id).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (s *webhookSubscriptionStore) ListDeliveries(ctx context.Context, filter WebhookDeliveryFilter) ([]WebhookDeliveryRecord, int64, error) {
+	query := s.db.WithContext(ctx).Model(&WebhookDeliveryRecord{})
+	if filter.SubscriptionID != "" {
+		query = query.Where("subscription_id = ?", filter.SubscriptionID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.EventType != "" {
+		query = query.Where("event_type = ?", filter.EventType)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query = query.Order("created_at DESC").Order("id DESC")
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	var records []WebhookDeliveryRecord
+	if err := query.Find(&records).Error; err != nil {
+		return nil, 0, err
+	}
+	return records, total, nil
+}
+
+func (s *webhookSubscriptionStore) ClaimDeliveries(ctx context.Context, limit int, now, lockUntil time.Time) ([]WebhookDeliveryRecord, error) {
+	due := func(db *gorm.DB) *gorm.DB {
+		return db.Where("(status = ? AND next_attempt_at <= ?) OR (status = ? AND locked_until < ?)",
+			WebhookDeliveryPending, now, WebhookDeliverySending, now)
+	}
+
+	var candidates []WebhookDeliveryRecord
+	err := s.db.WithContext(ctx).Scopes(due).Order("next_attempt_at ASC").Limit(limit).Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	claimed := make([]WebhookDeliveryRecord, 0, len(candidates))
+	for _, record := range candidates {
+		// Only one worker wins the conditional update of a delivery
+		result := s.db.WithContext(ctx).Model(&WebhookDeliveryRecord{}).
+			Where("id = ?", record.ID).Scopes(due).
+			Updates(map[string]interface{}{"status": WebhookDeliverySending, "locked_until": lockUntil})
+		if result.Error != nil {
+			return claimed, result.Error
+		}
+		if result.RowsAffected == 1 {
+			record.Status = WebhookDeliverySending
+			record.LockedUntil = &lockUntil
+			claimed = append(claimed, record)
+		}
+	}
+	return claimed, nil
+}
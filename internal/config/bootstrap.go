@@ -181,6 +181,14 @@ func defaultConfig(args *gl.InitArgs) Config {
 			},
 			DevMode: true,
 		},
+		MCP: MCPServerConfig{
+			Address: args.Bind,
+			Policy: MCPPolicyConfig{
+				Bindings: []MCPRoleBinding{},
+				Rules:    []MCPPolicyRule{},
+			},
+			DevMode: true,
+		},
 		DevMode: true,
 	}
 }
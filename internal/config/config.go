@@ -61,6 +61,13 @@ type Config struct {
 	GoBE           GoBeConfig        `json:"gobe"`
 	GobeCtl        GobeCtlConfig     `json:"gobeCtl"`
 	Integrations   IntegrationConfig `json:"integrations"`
+	MCP            MCPServerConfig   `json:"mcp"`
+	Gateway        GatewayConfig     `json:"gateway"`
+	Webhooks       WebhooksConfig    `json:"webhooks"`
+	Pipeline       PipelineConfig    `json:"pipeline" mapstructure:"pipeline"`
+	AMQP           AMQPConfig        `json:"amqp" mapstructure:"amqp"`
+	Broker         BrokerConfig      `json:"broker" mapstructure:"broker"`
+	Cron           CronConfig        `json:"cron" mapstructure:"cron"`
 	DevMode        bool              `json:"dev_mode"`
 }
 
@@ -78,6 +85,13 @@ func (c *Config) GetSettings() map[string]interface{} {
 	settings["gobe"] = c.GoBE
 	settings["gobeCtl"] = c.GobeCtl
 	settings["integrations"] = c.Integrations
+	settings["mcp"] = c.MCP
+	settings["gateway"] = c.Gateway
+	settings["webhooks"] = c.Webhooks
+	settings["pipeline"] = c.Pipeline
+	settings["amqp"] = c.AMQP
+	settings["broker"] = c.Broker
+	settings["cron"] = c.Cron
 	settings["dev_mode"] = c.DevMode
 	return settings
 }
@@ -133,6 +147,7 @@ type LLMConfig struct {
 	MaxTokens        int      `json:"max_tokens" mapstructure:"max_tokens"`
 	Temperature      float64  `json:"temperature" mapstructure:"temperature"`
 	APIKey           string   `json:"api_key" mapstructure:"api_key"`
+	BaseURL          string   `json:"base_url" mapstructure:"base_url"`
 	DevMode          bool     `json:"dev_mode"`
 	TopP             float64  `json:"top_p" mapstructure:"top_p"`
 	FrequencyPenalty float64  `json:"frequency_penalty" mapstructure:"frequency_penalty"`
@@ -148,16 +163,35 @@ func (c *LLMConfig) GetSettings() map[string]interface{} {
 	settings := make(map[string]interface{})
 	settings["provider"] = c.Provider
 	settings["model"] = c.Model
+	settings["base_url"] = c.BaseURL
 	settings["max_tokens"] = c.MaxTokens
 	settings["temperature"] = c.Temperature
 	// Do not include API key for security reasons
 	return settings
 }
 
+// ApprovalConfig sets the default expiry of approval requests
+// (ApprovalTimeoutMinutes, 30 when unset) and the Policies applied per
+// action type ("shell_command", "deploy", "scale"; "*" for the others).
 type ApprovalConfig struct {
-	RequireApprovalForResponses bool `json:"require_approval_for_responses"`
-	ApprovalTimeoutMinutes      int  `json:"approval_timeout_minutes"`
-	DevMode                     bool `json:"dev_mode"`
+	RequireApprovalForResponses bool                            `json:"require_approval_for_responses"`
+	ApprovalTimeoutMinutes      int                             `json:"approval_timeout_minutes"`
+	Policies                    map[string]ApprovalPolicyConfig `json:"policies,omitempty" mapstructure:"policies"`
+	DevMode                     bool                            `json:"dev_mode"`
+}
+
+// ApprovalPolicyConfig decides who may approve an action and how many must.
+// Approvers need one of Roles (anyone when empty) and Quorum (1) approvals
+// are required; a single rejection rejects. Unanswered requests expire after
+// TimeoutMinutes, or are rejected with AutoReject. After EscalateAfterMinutes
+// approvers holding EscalationRoles may decide as well.
+type ApprovalPolicyConfig struct {
+	Roles                []string `json:"roles,omitempty" mapstructure:"roles"`
+	Quorum               int      `json:"quorum,omitempty" mapstructure:"quorum"`
+	TimeoutMinutes       int      `json:"timeout_minutes,omitempty" mapstructure:"timeout_minutes"`
+	AutoReject           bool     `json:"auto_reject,omitempty" mapstructure:"auto_reject"`
+	EscalateAfterMinutes int      `json:"escalate_after_minutes,omitempty" mapstructure:"escalate_after_minutes"`
+	EscalationRoles      []string `json:"escalation_roles,omitempty" mapstructure:"escalation_roles"`
 }
 
 func newApprovalConfig() *ApprovalConfig      { return &ApprovalConfig{} }
@@ -168,6 +202,7 @@ func (c *ApprovalConfig) GetSettings() map[string]interface{} {
 	settings := make(map[string]interface{})
 	settings["require_approval_for_responses"] = c.RequireApprovalForResponses
 	settings["approval_timeout_minutes"] = c.ApprovalTimeoutMinutes
+	settings["policies"] = c.Policies
 	return settings
 }
 
@@ -190,10 +225,20 @@ func (c *ServerConfig) GetSettings() map[string]interface{} {
 	return settings
 }
 
+// ZMQConfig enables the ZeroMQ event bus. The PUB socket binds Address
+// ("tcp://*" by default) on Port; Subscribe lists publishers whose commands
+// gobe runs, as Principal ("zmq") under the MCP policy. Topics start with
+// TopicPrefix ("gobe.").
 type ZMQConfig struct {
-	Address string `json:"address"`
-	Port    int    `json:"port"`
-	DevMode bool   `json:"dev_mode"`
+	Enabled                  bool     `json:"enabled" mapstructure:"enabled"`
+	Address                  string   `json:"address"`
+	Port                     int      `json:"port"`
+	DevMode                  bool     `json:"dev_mode"`
+	Subscribe                []string `json:"subscribe,omitempty" mapstructure:"subscribe"`
+	TopicPrefix              string   `json:"topic_prefix,omitempty" mapstructure:"topic_prefix"`
+	Principal                string   `json:"principal,omitempty" mapstructure:"principal"`
+	QueueSize                int      `json:"queue_size,omitempty" mapstructure:"queue_size"`
+	ReconnectIntervalSeconds int      `json:"reconnect_interval_seconds,omitempty" mapstructure:"reconnect_interval_seconds"`
 }
 
 func newZMQConfig() *ZMQConfig           { return &ZMQConfig{} }
@@ -202,8 +247,12 @@ func (c *ZMQConfig) GetType() string     { return "zmq_config" }
 func (c *ZMQConfig) SetDevMode(dev bool) { c.DevMode = dev }
 func (c *ZMQConfig) GetSettings() map[string]interface{} {
 	settings := make(map[string]interface{})
+	settings["enabled"] = c.Enabled
 	settings["address"] = c.Address
 	settings["port"] = c.Port
+	settings["subscribe"] = c.Subscribe
+	settings["topic_prefix"] = c.TopicPrefix
+	settings["principal"] = c.Principal
 	return settings
 }
 
@@ -294,6 +343,15 @@ type TelegramConfig struct {
 	WebhookURL     string   `json:"webhook_url" mapstructure:"webhook_url"`
 	AllowedUpdates []string `json:"allowed_updates" mapstructure:"allowed_updates"`
 	DevMode        bool     `json:"dev_mode" mapstructure:"dev_mode"`
+	// APIURL points at a self-hosted Bot API server; api.telegram.org when
+	// empty.
+	APIURL string `json:"api_url,omitempty" mapstructure:"api_url"`
+	// ApprovalChatID receives approval requests with approve and reject
+	// buttons; 0 keeps them off Telegram.
+	ApprovalChatID int64 `json:"approval_chat_id,omitempty" mapstructure:"approval_chat_id"`
+	// WebhookSecret is the secret_token given to setWebhook; updates
+	// without it in X-Telegram-Bot-Api-Secret-Token are refused.
+	WebhookSecret string `json:"webhook_secret,omitempty" mapstructure:"webhook_secret"`
 }
 
 func newTelegramConfig() *TelegramConfig      { return &TelegramConfig{} }
@@ -306,13 +364,259 @@ func (c *TelegramConfig) GetSettings() map[string]interface{} {
 	// Do not include BotToken for security reasons
 	settings["webhook_url"] = c.WebhookURL
 	settings["allowed_updates"] = c.AllowedUpdates
+	settings["api_url"] = c.APIURL
+	settings["approval_chat_id"] = c.ApprovalChatID
 	return settings
 }
 
 type MCPServerConfig struct {
-	Address string `json:"address"`
-	Port    int    `json:"port"`
-	DevMode bool   `json:"dev_mode"`
+	Address       string          `json:"address"`
+	Port          int             `json:"port"`
+	Policy        MCPPolicyConfig `json:"policy" mapstructure:"policy"`
+	ToolsDir      string          `json:"tools_dir,omitempty" mapstructure:"tools_dir"`
+	JobTTLMinutes int             `json:"job_ttl_minutes,omitempty" mapstructure:"job_ttl_minutes"`
+	DevMode       bool            `json:"dev_mode"`
+}
+
+// MCPPolicyConfig declares who may run which MCP tools.
+// Bindings grant roles to chat identities (which carry no JWT claims);
+// Rules add per-tool allow/deny decisions on top of each tool's Auth requirement.
+type MCPPolicyConfig struct {
+	Bindings []MCPRoleBinding `json:"bindings,omitempty" mapstructure:"bindings"`
+	Rules    []MCPPolicyRule  `json:"rules,omitempty" mapstructure:"rules"`
+}
+
+// MCPRoleBinding grants Role to callers matching any of the listed identities.
+type MCPRoleBinding struct {
+	Role     string   `json:"role" mapstructure:"role"`
+	Users    []string `json:"users,omitempty" mapstructure:"users"`
+	Guilds   []string `json:"guilds,omitempty" mapstructure:"guilds"`
+	Channels []string `json:"channels,omitempty" mapstructure:"channels"`
+}
+
+// MCPPolicyRule allows or denies Tools (names or glob patterns) for callers
+// matching every non-empty selector.
+type MCPPolicyRule struct {
+	Tools    []string `json:"tools" mapstructure:"tools"`
+	Effect   string   `json:"effect" mapstructure:"effect"` // "allow" or "deny"
+	Roles    []string `json:"roles,omitempty" mapstructure:"roles"`
+	Scopes   []string `json:"scopes,omitempty" mapstructure:"scopes"`
+	Users    []string `json:"users,omitempty" mapstructure:"users"`
+	Guilds   []string `json:"guilds,omitempty" mapstructure:"guilds"`
+	Channels []string `json:"channels,omitempty" mapstructure:"channels"`
+}
+
+// GatewayConfig tunes how the AI gateway routes chat requests.
+// Routes map logical model names to provider entries; Retry and
+// CooldownSeconds apply to every request. Prices override the built-in
+// per-model price table and Budgets cap the spend of each tenant. Cache
+// tunes the response cache of deterministic requests.
+type GatewayConfig struct {
+	Routes          []GatewayRouteConfig         `json:"routes,omitempty" mapstructure:"routes"`
+	Retry           GatewayRetryConfig           `json:"retry,omitempty" mapstructure:"retry"`
+	CooldownSeconds int                          `json:"cooldown_seconds,omitempty" mapstructure:"cooldown_seconds"`
+	Prices          map[string]GatewayModelPrice `json:"prices,omitempty" mapstructure:"prices"`
+	Budgets         GatewayBudgetConfig          `json:"budgets,omitempty" mapstructure:"budgets"`
+	Cache           GatewayCacheConfig           `json:"cache,omitempty" mapstructure:"cache"`
+}
+
+// GatewayRouteConfig declares a logical model served by Targets, tried in
+// order ("fallback", the default) or picked by weight ("weighted").
+type GatewayRouteConfig struct {
+	Name     string               `json:"name" mapstructure:"name"`
+	Strategy string               `json:"strategy,omitempty" mapstructure:"strategy"`
+	Targets  []GatewayRouteTarget `json:"targets" mapstructure:"targets"`
+	Retry    *GatewayRetryConfig  `json:"retry,omitempty" mapstructure:"retry"`
+}
+
+// GatewayRouteTarget is a provider entry (and optional model) of a route.
+type GatewayRouteTarget struct {
+	Provider string `json:"provider" mapstructure:"provider"`
+	Model    string `json:"model,omitempty" mapstructure:"model"`
+	Weight   int    `json:"weight,omitempty" mapstructure:"weight"`
+}
+
+// GatewayRetryConfig retries 429/5xx failures with exponential backoff.
+type GatewayRetryConfig struct {
+	MaxAttempts  int `json:"max_attempts,omitempty" mapstructure:"max_attempts"`
+	BackoffMS    int `json:"backoff_ms,omitempty" mapstructure:"backoff_ms"`
+	MaxBackoffMS int `json:"max_backoff_ms,omitempty" mapstructure:"max_backoff_ms"`
+}
+
+// GatewayModelPrice is the USD price per million tokens of a model. Keys of
+// GatewayConfig.Prices match model names exactly or by prefix.
+type GatewayModelPrice struct {
+	InputPerMillion  float64 `json:"input_per_million" mapstructure:"input_per_million"`
+	OutputPerMillion float64 `json:"output_per_million" mapstructure:"output_per_million"`
+}
+
+// GatewayBudgetConfig caps the USD spend per tenant (x-tenant-id). The
+// default limits apply to tenants without an entry in Tenants; zero means
+// unlimited.
+type GatewayBudgetConfig struct {
+	DailyUSD   float64                        `json:"daily_usd,omitempty" mapstructure:"daily_usd"`
+	MonthlyUSD float64                        `json:"monthly_usd,omitempty" mapstructure:"monthly_usd"`
+	Tenants    map[string]GatewayTenantBudget `json:"tenants,omitempty" mapstructure:"tenants"`
+}
+
+// GatewayTenantBudget overrides the default budget of one tenant.
+type GatewayTenantBudget struct {
+	DailyUSD   float64 `json:"daily_usd,omitempty" mapstructure:"daily_usd"`
+	MonthlyUSD float64 `json:"monthly_usd,omitempty" mapstructure:"monthly_usd"`
+}
+
+// GatewayCacheConfig selects the response cache backend: "memory" (the
+// default, an LRU of MaxEntries answers), "redis" (RedisURL, keys under
+// RedisPrefix) or "off". TTLSeconds defaults to 600.
+type GatewayCacheConfig struct {
+	Backend     string `json:"backend,omitempty" mapstructure:"backend"`
+	TTLSeconds  int    `json:"ttl_seconds,omitempty" mapstructure:"ttl_seconds"`
+	MaxEntries  int    `json:"max_entries,omitempty" mapstructure:"max_entries"`
+	RedisURL    string `json:"redis_url,omitempty" mapstructure:"redis_url"`
+	RedisPrefix string `json:"redis_prefix,omitempty" mapstructure:"redis_prefix"`
+}
+
+// PipelineConfig tunes the worker pool processing chat messages. Workers (4)
+// take up to QueueSize (1000) queued jobs, urgent first; a job gains one
+// priority level every AgingSeconds (30) it waits. Each attempt times out
+// after TimeoutSeconds (120) and failed attempts are retried after
+// BackoffSeconds (2), doubling up to MaxBackoffSeconds (30), until
+// MaxAttempts (3).
+type PipelineConfig struct {
+	Workers           int `json:"workers,omitempty" mapstructure:"workers"`
+	QueueSize         int `json:"queue_size,omitempty" mapstructure:"queue_size"`
+	AgingSeconds      int `json:"aging_seconds,omitempty" mapstructure:"aging_seconds"`
+	TimeoutSeconds    int `json:"timeout_seconds,omitempty" mapstructure:"timeout_seconds"`
+	MaxAttempts       int `json:"max_attempts,omitempty" mapstructure:"max_attempts"`
+	BackoffSeconds    int `json:"backoff_seconds,omitempty" mapstructure:"backoff_seconds"`
+	MaxBackoffSeconds int `json:"max_backoff_seconds,omitempty" mapstructure:"max_backoff_seconds"`
+}
+
+// AMQPConfig tunes the consumers of the message broker (see BrokerConfig),
+// which run when Enabled. Consumer applies to every queue and Queues
+// overrides it by queue name. On shutdown, consumers get
+// DrainTimeoutSeconds (30) to finish the messages they hold.
+type AMQPConfig struct {
+	Enabled             bool                          `json:"enabled" mapstructure:"enabled"`
+	Consumer            AMQPConsumerConfig            `json:"consumer,omitempty" mapstructure:"consumer"`
+	Queues              map[string]AMQPConsumerConfig `json:"queues,omitempty" mapstructure:"queues"`
+	DrainTimeoutSeconds int                           `json:"drain_timeout_seconds,omitempty" mapstructure:"drain_timeout_seconds"`
+}
+
+// AMQPConsumerConfig tunes the consumer of a queue. It holds Prefetch (10)
+// unacknowledged messages, handled by Concurrency (4) workers. A failed
+// message is retried after RetryDelaySeconds (5), doubling up to
+// MaxRetryDelaySeconds (300), and dead-lettered after MaxAttempts (5).
+type AMQPConsumerConfig struct {
+	Prefetch             int `json:"prefetch,omitempty" mapstructure:"prefetch"`
+	Concurrency          int `json:"concurrency,omitempty" mapstructure:"concurrency"`
+	MaxAttempts          int `json:"max_attempts,omitempty" mapstructure:"max_attempts"`
+	RetryDelaySeconds    int `json:"retry_delay_seconds,omitempty" mapstructure:"retry_delay_seconds"`
+	MaxRetryDelaySeconds int `json:"max_retry_delay_seconds,omitempty" mapstructure:"max_retry_delay_seconds"`
+}
+
+// BrokerConfig selects the message broker behind webhooks, task queues and
+// factory.PublishMessage: "memory" (default, in-process), "amqp" (RabbitMQ
+// from the database config) or "nats" (JetStream at NATS.URL). Without a
+// Kind, RabbitMQ is used when AMQP.Enabled is set. Each declares the same
+// exchanges, queues and bindings.
+type BrokerConfig struct {
+	Kind string     `json:"kind,omitempty" mapstructure:"kind"`
+	NATS NATSConfig `json:"nats,omitempty" mapstructure:"nats"`
+	// MemoryMaxLength caps each in-process queue (10000); the oldest
+	// messages are dropped beyond it.
+	MemoryMaxLength int `json:"memory_max_length,omitempty" mapstructure:"memory_max_length"`
+}
+
+// NATSConfig connects the "nats" broker to URL ("nats://localhost:4222")
+// with Token or User and Password. Streams are named after StreamPrefix
+// ("GOBE").
+type NATSConfig struct {
+	URL          string `json:"url,omitempty" mapstructure:"url"`
+	Token        string `json:"token,omitempty" mapstructure:"token"`
+	User         string `json:"user,omitempty" mapstructure:"user"`
+	Password     string `json:"password,omitempty" mapstructure:"password"`
+	StreamPrefix string `json:"stream_prefix,omitempty" mapstructure:"stream_prefix"`
+}
+
+// CronConfig tunes the runner of the cron jobs. Command jobs may only run
+// the binaries named in AllowedCommands and are refused without it. Each
+// run keeps MaxOutputKB (64) of output in the execution log, which is
+// purged of the runs older than RetentionDays (30, negative keeps them
+// forever). MCP tool jobs run as Principal ("cron") with the "cron" role.
+type CronConfig struct {
+	AllowedCommands []string `json:"allowed_commands,omitempty" mapstructure:"allowed_commands"`
+	MaxOutputKB     int      `json:"max_output_kb,omitempty" mapstructure:"max_output_kb"`
+	RetentionDays   int      `json:"retention_days,omitempty" mapstructure:"retention_days"`
+	Principal       string   `json:"principal,omitempty" mapstructure:"principal"`
+}
+
+// WebhooksConfig tunes the inbound webhook event store. Completed and failed
+// events older than RetentionDays (30 by default, negative keeps them
+// forever) are purged every PurgeIntervalMinutes (60). The worker claims up
+// to BatchSize (50) pending events every PollSeconds (5) and owns them for
+// LeaseSeconds (60); events of a worker that crashed are picked up again
+// once their lease expires.
+//
+// Sources maps the webhook source (X-Webhook-Source header or "source" query
+// parameter) to the scheme verifying its signatures. Sources without an entry
+// are refused unless AllowUnsigned is set. Delivery IDs, derived from the
+// signature and the body, are remembered in memory for ReplayWindowSeconds
+// (24h) to refuse replays.
+type WebhooksConfig struct {
+	RetentionDays        int                            `json:"retention_days,omitempty" mapstructure:"retention_days"`
+	PurgeIntervalMinutes int                            `json:"purge_interval_minutes,omitempty" mapstructure:"purge_interval_minutes"`
+	PollSeconds          int                            `json:"poll_seconds,omitempty" mapstructure:"poll_seconds"`
+	BatchSize            int                            `json:"batch_size,omitempty" mapstructure:"batch_size"`
+	LeaseSeconds         int                            `json:"lease_seconds,omitempty" mapstructure:"lease_seconds"`
+	AllowUnsigned        bool                           `json:"allow_unsigned,omitempty" mapstructure:"allow_unsigned"`
+	ReplayWindowSeconds  int                            `json:"replay_window_seconds,omitempty" mapstructure:"replay_window_seconds"`
+	Sources              map[string]WebhookSourceConfig `json:"sources,omitempty" mapstructure:"sources"`
+	Outbound             WebhookOutboundConfig          `json:"outbound,omitempty" mapstructure:"outbound"`
+}
+
+// WebhookOutboundConfig tunes the delivery of gobe events to subscribers. A
+// failed delivery is retried after BackoffSeconds (30), doubling up to
+// MaxBackoffSeconds (3600), and is dead after MaxAttempts (8). Each attempt
+// times out after TimeoutSeconds (10).
+//
+// One-off deliveries, such as analyzer webhook notifications, are signed
+// with the secret read from the SecretEnv environment variable or, when
+// unset, from the SecretKeyring entry of the keyring; without it they are
+// not sent.
+//
+// Deliveries to loopback, link-local and private addresses are refused
+// unless AllowPrivateTargets is set.
+type WebhookOutboundConfig struct {
+	MaxAttempts         int    `json:"max_attempts,omitempty" mapstructure:"max_attempts"`
+	BackoffSeconds      int    `json:"backoff_seconds,omitempty" mapstructure:"backoff_seconds"`
+	MaxBackoffSeconds   int    `json:"max_backoff_seconds,omitempty" mapstructure:"max_backoff_seconds"`
+	TimeoutSeconds      int    `json:"timeout_seconds,omitempty" mapstructure:"timeout_seconds"`
+	SecretEnv           string `json:"secret_env,omitempty" mapstructure:"secret_env"`
+	SecretKeyring       string `json:"secret_keyring,omitempty" mapstructure:"secret_keyring"`
+	AllowPrivateTargets bool   `json:"allow_private_targets,omitempty" mapstructure:"allow_private_targets"`
+}
+
+// WebhookSourceConfig selects the signature scheme of a webhook source:
+// "github", "stripe", "slack", "discord" or "hmac". The secret (the public
+// key, hex encoded, for discord) is read from the SecretEnv environment
+// variable or, when unset, from the SecretKeyring entry of the keyring.
+// ToleranceSeconds bounds the age of signed timestamps (300 by default).
+//
+// The remaining fields configure the "hmac" scheme: the signature travels in
+// SignatureHeader (X-Webhook-Signature) as Encoding ("hex" or "base64") of the
+// Algorithm ("sha256", "sha1" or "sha512") HMAC of the body, after Prefix.
+// With TimestampHeader set the signed payload is "<timestamp>.<body>".
+type WebhookSourceConfig struct {
+	Scheme           string `json:"scheme" mapstructure:"scheme"`
+	SecretEnv        string `json:"secret_env,omitempty" mapstructure:"secret_env"`
+	SecretKeyring    string `json:"secret_keyring,omitempty" mapstructure:"secret_keyring"`
+	ToleranceSeconds int    `json:"tolerance_seconds,omitempty" mapstructure:"tolerance_seconds"`
+	SignatureHeader  string `json:"signature_header,omitempty" mapstructure:"signature_header"`
+	TimestampHeader  string `json:"timestamp_header,omitempty" mapstructure:"timestamp_header"`
+	Prefix           string `json:"prefix,omitempty" mapstructure:"prefix"`
+	Algorithm        string `json:"algorithm,omitempty" mapstructure:"algorithm"`
+	Encoding         string `json:"encoding,omitempty" mapstructure:"encoding"`
 }
 
 func newMCPServerConfig() *MCPServerConfig     { return &MCPServerConfig{} }
@@ -323,6 +627,9 @@ func (c *MCPServerConfig) GetSettings() map[string]interface{} {
 	settings := make(map[string]interface{})
 	settings["address"] = c.Address
 	settings["port"] = c.Port
+	settings["policy_rules"] = len(c.Policy.Rules)
+	settings["tools_dir"] = c.ToolsDir
+	settings["job_ttl_minutes"] = c.JobTTLMinutes
 	return settings
 }
 
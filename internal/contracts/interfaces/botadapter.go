@@ -54,3 +54,20 @@ type IAdapter interface {
 	GetChannels(guildID string) ([]Channel, error)
 	PingAdapter(msg string) error
 }
+
+// Button is a clickable action attached to a message. ID comes back with
+// the click; Style is "primary", "success" or "danger".
+type Button struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+	Style string `json:"style,omitempty"`
+}
+
+// IInteractiveAdapter is implemented by adapters that post buttons and edit
+// the messages they posted.
+type IInteractiveAdapter interface {
+	SendButtons(channelID, content string, buttons []Button) (messageID string, err error)
+	// EditMessage replaces the content and buttons of a message; no buttons
+	// removes them.
+	EditMessage(channelID, messageID, content string, buttons []Button) error
+}
@@ -0,0 +1,240 @@
+package approval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+)
+
+// Presenter shows requests on a chat platform, with buttons to decide them,
+// and keeps the message in step with the request.
+type Presenter interface {
+	// Platform names the presenter; it keys Request.Messages.
+	Platform() string
+	// Present posts req and returns a reference to the message, or "" when
+	// the platform cannot edit it later.
+	Present(ctx context.Context, req Request) (string, error)
+	// Update rewrites the message after a vote, an escalation or the
+	// decision.
+	Update(ctx context.Context, ref string, req Request) error
+}
+
+// callbackPrefix marks the button payloads of approvals. Telegram limits
+// them to 64 bytes, which "approval:approve:<uuid>" fits.
+const callbackPrefix = "approval:"
+
+// AddPresenter shows the requests filed from now on through p.
+func (m *Manager) AddPresenter(p Presenter) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.presenters = append(m.presenters, p)
+}
+
+// CallbackData is the payload of the button approving or rejecting a
+// request.
+func CallbackData(requestID string, approve bool) string {
+	if approve {
+		return callbackPrefix + "approve:" + requestID
+	}
+	return callbackPrefix + "reject:" + requestID
+}
+
+// ParseCallbackData reads a payload built by CallbackData; ok is false for
+// the payloads of other buttons.
+func ParseCallbackData(data string) (requestID string, approve bool, ok bool) {
+	rest, found := strings.CutPrefix(data, callbackPrefix)
+	if !found {
+		return "", false, false
+	}
+	verb, requestID, found := strings.Cut(rest, ":")
+	if !found || requestID == "" {
+		return "", false, false
+	}
+	switch verb {
+	case "approve":
+		return requestID, true, true
+	case "reject":
+		return requestID, false, true
+	}
+	return "", false, false
+}
+
+// Message renders req for chat platforms: what is asked, who may decide,
+// the votes so far and, once decided, the outcome. It is plain text so every
+// platform shows it alike.
+func Message(req Request) string {
+	var b strings.Builder
+	switch req.Status {
+	case StatusApproved:
+		fmt.Fprintf(&b, "✅ Aprovado: %s\n", req.Action)
+	case StatusRejected:
+		fmt.Fprintf(&b, "🚫 Rejeitado: %s\n", req.Action)
+	case StatusExpired:
+		fmt.Fprintf(&b, "⌛ Expirado sem decisão: %s\n", req.Action)
+	default:
+		fmt.Fprintf(&b, "⏳ Aprovação necessária: %s\n", req.Action)
+	}
+	if command, _ := req.Details["command"].(string); command != "" {
+		fmt.Fprintf(&b, "Comando: %s\n", command)
+	}
+	requester, _ := req.Details["username"].(string)
+	if requester == "" {
+		requester = req.RequestedBy
+	}
+	if requester != "" {
+		fmt.Fprintf(&b, "Solicitado por: %s\n", requester)
+	}
+	fmt.Fprintf(&b, "ID: %s\n", req.ID)
+
+	if len(req.Votes) > 0 {
+		votes := make([]string, 0, len(req.Votes))
+		for _, vote := range req.Votes {
+			mark := "✅"
+			if !vote.Approved {
+				mark = "❌"
+			}
+			name := vote.Approver.Name
+			if name == "" {
+				name = vote.Approver.ID
+			}
+			votes = append(votes, mark+" "+name)
+		}
+		fmt.Fprintf(&b, "Votos: %s\n", strings.Join(votes, ", "))
+	}
+
+	if req.Status != StatusPending {
+		if req.DecidedAt != nil {
+			fmt.Fprintf(&b, "Decidido em: %s\n", req.DecidedAt.Format(time.RFC3339))
+		}
+		return strings.TrimRight(b.String(), "\n")
+	}
+
+	roles := "qualquer aprovador"
+	if len(req.Policy.Roles) > 0 {
+		roles = strings.Join(req.Policy.Roles, ", ")
+	}
+	if req.Escalated && len(req.Policy.EscalationRoles) > 0 {
+		roles += " (escalado: " + strings.Join(req.Policy.EscalationRoles, ", ") + ")"
+	}
+	approvals := 0
+	for _, vote := range req.Votes {
+		if vote.Approved {
+			approvals++
+		}
+	}
+	fmt.Fprintf(&b, "Aprovadores: %s\n", roles)
+	fmt.Fprintf(&b, "Aprovações: %d de %d\n", approvals, req.Policy.Quorum)
+	fmt.Fprintf(&b, "Expira em: %s", req.ExpiresAt.Format(time.RFC3339))
+	return b.String()
+}
+
+// present posts a new request through every presenter and records the
+// messages so later updates edit them, even after a restart.
+func (m *Manager) present(ctx context.Context, req *Request) {
+	m.mu.Lock()
+	presenters := append([]Presenter(nil), m.presenters...)
+	m.mu.Unlock()
+
+	for _, presenter := range presenters {
+		ref, err := presenter.Present(ctx, *req)
+		if err != nil {
+			gl.Log("warn", fmt.Sprintf("approval request %s not presented on %s", req.ID, presenter.Platform()), err)
+			continue
+		}
+		if ref == "" {
+			continue
+		}
+		if req.Messages == nil {
+			req.Messages = make(map[string]string)
+		}
+		req.Messages[presenter.Platform()] = ref
+		m.recordMessage(ctx, req.ID, presenter.Platform(), ref)
+	}
+}
+
+func (m *Manager) recordMessage(ctx context.Context, requestID, platform, ref string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	req, err := m.requestLocked(ctx, requestID)
+	if err != nil {
+		gl.Log("error", fmt.Sprintf("approval message of %s not recorded", requestID), err)
+		return
+	}
+	if _, pending := m.pending[requestID]; !pending {
+		// Decided while being posted: the new message shows the outcome too
+		go m.refresh(requestID)
+	}
+	if req.Messages == nil {
+		req.Messages = make(map[string]string)
+	}
+	req.Messages[platform] = ref
+	if err := m.save(ctx, req); err != nil {
+		gl.Log("error", fmt.Sprintf("approval message of %s not recorded", requestID), err)
+	}
+}
+
+// requestLocked returns the pending request itself, or the decided one
+// loaded from the store. Called with m.mu held.
+func (m *Manager) requestLocked(ctx context.Context, requestID string) (*Request, error) {
+	if p, ok := m.pending[requestID]; ok {
+		return p.req, nil
+	}
+	record, err := m.store.GetRequest(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+	decisions, err := m.store.ListDecisions(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+	return requestFromRecord(record, decisions)
+}
+
+// refresh rewrites the messages presenting a request with its current state.
+func (m *Manager) refresh(requestID string) {
+	m.presentMu.Lock()
+	defer m.presentMu.Unlock()
+
+	ctx := context.Background()
+	req, err := m.GetRequest(ctx, requestID)
+	if err != nil {
+		gl.Log("warn", fmt.Sprintf("approval messages of %s not updated", requestID), err)
+		return
+	}
+	m.mu.Lock()
+	presenters := append([]Presenter(nil), m.presenters...)
+	m.mu.Unlock()
+
+	for _, presenter := range presenters {
+		ref := req.Messages[presenter.Platform()]
+		if ref == "" {
+			continue
+		}
+		if err := presenter.Update(ctx, ref, *req); err != nil {
+			gl.Log("warn", fmt.Sprintf("approval message of %s not updated on %s", requestID, presenter.Platform()), err)
+		}
+	}
+}
+
+// ChatError explains to whoever clicked a button why their decision was
+// refused.
+func ChatError(err error) string {
+	switch {
+	case errors.Is(err, ErrNotEligible):
+		return "🚫 Você não pode decidir esta solicitação"
+	case errors.Is(err, ErrAlreadyVoted):
+		return "Você já votou nesta solicitação"
+	case errors.Is(err, ErrNotPending):
+		return "Esta solicitação já foi decidida"
+	case errors.Is(err, ErrExpired):
+		return "⌛ Esta solicitação expirou"
+	case errors.Is(err, ErrNotFound):
+		return "Solicitação não encontrada"
+	}
+	return "❌ Não foi possível registrar a decisão"
+}
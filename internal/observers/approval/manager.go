@@ -3,40 +3,128 @@ package approval
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
 	"github.com/kubex-ecosystem/gobe/internal/config"
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
 	"github.com/kubex-ecosystem/gobe/internal/observers/events"
+	"github.com/kubex-ecosystem/gobe/internal/services/webhooks/outbound"
 
 	"github.com/google/uuid"
 )
 
+// Store persists approval requests and their audit trail.
+type Store = svc.ApprovalStore
+
+var (
+	ErrNotFound     = svc.ErrApprovalRequestNotFound
+	ErrNotPending   = errors.New("approval request already decided")
+	ErrExpired      = errors.New("approval request expired")
+	ErrNotEligible  = errors.New("approver not allowed to decide this request")
+	ErrAlreadyVoted = errors.New("approver already voted on this request")
+)
+
+// Kinds of the audit trail entries.
+const (
+	KindRequested = "requested"
+	KindApprove   = "approve"
+	KindReject    = "reject"
+	KindEscalated = "escalated"
+	KindExpired   = "expired"
+	KindApproved  = "approved"
+	KindRejected  = "rejected"
+)
+
+// Events broadcast on the event stream.
+const (
+	EventRequest   = "approval_request"
+	EventVote      = "approval_vote"
+	EventEscalated = "approval_escalated"
+	EventResult    = "approval_result"
+)
+
 type Manager struct {
-	config           config.ApprovalConfig
-	eventStream      *events.Stream
-	pendingApprovals map[string]*Request
-	mu               sync.RWMutex
+	config      config.ApprovalConfig
+	eventStream *events.Stream
+	now         func() time.Time
+
+	mu         sync.Mutex
+	store      Store
+	policies   map[string]Policy
+	handlers   map[string]Handler
+	presenters []Presenter
+	pending    map[string]*pendingRequest
+	events     *outbound.Dispatcher
+
+	// presentMu serializes message updates so the last one shows the
+	// latest state
+	presentMu sync.Mutex
+}
+
+// Approver is who votes on a request and the roles they hold.
+type Approver struct {
+	ID    string   `json:"id"`
+	Name  string   `json:"name,omitempty"`
+	Roles []string `json:"roles,omitempty"`
+}
+
+// Vote is the decision of one approver.
+type Vote struct {
+	Approver Approver  `json:"approver"`
+	Approved bool      `json:"approved"`
+	Comment  string    `json:"comment,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+// Decision is an entry of the audit trail of a request.
+type Decision struct {
+	Kind     string    `json:"kind"`
+	Approver Approver  `json:"approver"`
+	Comment  string    `json:"comment,omitempty"`
+	At       time.Time `json:"at"`
 }
 
 type Request struct {
-	ID        string                 `json:"id"`
-	Action    string                 `json:"action"`
-	Platform  string                 `json:"platform"`
-	Details   map[string]interface{} `json:"details"`
-	CreatedAt time.Time              `json:"created_at"`
-	ExpiresAt time.Time              `json:"expires_at"`
-	Status    Status                 `json:"status"`
+	ID          string                 `json:"id"`
+	Action      string                 `json:"action"`
+	Platform    string                 `json:"platform"`
+	RequestedBy string                 `json:"requested_by,omitempty"`
+	Details     map[string]interface{} `json:"details"`
+	Policy      Policy                 `json:"policy"`
+	CreatedAt   time.Time              `json:"created_at"`
+	ExpiresAt   time.Time              `json:"expires_at"`
+	Status      Status                 `json:"status"`
+	Escalated   bool                   `json:"escalated"`
+	Votes       []Vote                 `json:"votes,omitempty"`
+	DecidedAt   *time.Time             `json:"decided_at,omitempty"`
+	// Messages holds, per platform, the message presenting the request.
+	Messages map[string]string `json:"messages,omitempty"`
 }
 
+// Response is the state of a request after a vote. ApproverID is the
+// approver whose vote decided it, empty while pending or when it expired.
 type Response struct {
 	RequestID  string    `json:"request_id"`
 	Approved   bool      `json:"approved"`
+	Status     Status    `json:"status"`
 	ApproverID string    `json:"approver_id"`
+	Approvers  []string  `json:"approvers,omitempty"`
+	Approvals  int       `json:"approvals"`
+	Quorum     int       `json:"quorum"`
 	Timestamp  time.Time `json:"timestamp"`
 }
 
+// Handler runs once a request of its action is decided, even when the
+// decision comes after a restart.
+type Handler func(ctx context.Context, req Request, resp Response)
+
 type Status int
 
 const (
@@ -46,114 +134,621 @@ const (
 	StatusExpired
 )
 
+var statusNames = map[Status]string{
+	StatusPending:  "pending",
+	StatusApproved: "approved",
+	StatusRejected: "rejected",
+	StatusExpired:  "expired",
+}
+
+func (s Status) String() string {
+	if name, ok := statusNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("status(%d)", int(s))
+}
+
+func parseStatus(name string) Status {
+	for status, n := range statusNames {
+		if n == name {
+			return status
+		}
+	}
+	return StatusPending
+}
+
+type pendingRequest struct {
+	req    *Request
+	done   chan struct{}
+	resp   *Response
+	timers []*time.Timer
+}
+
+// NewManager returns a manager keeping its requests in memory until SetStore
+// gives it a persistent store.
 func NewManager(config config.ApprovalConfig, eventStream *events.Stream) *Manager {
-	return &Manager{
-		config:           config,
-		eventStream:      eventStream,
-		pendingApprovals: make(map[string]*Request),
+	m := &Manager{
+		config:      config,
+		eventStream: eventStream,
+		now:         time.Now,
+		store:       NewMemoryStore(),
+		policies:    make(map[string]Policy),
+		handlers:    make(map[string]Handler),
+		pending:     make(map[string]*pendingRequest),
+	}
+	for action, policy := range config.Policies {
+		m.policies[action] = PolicyFromConfig(policy, m.defaultTimeout())
+	}
+	return m
+}
+
+func (m *Manager) defaultTimeout() time.Duration {
+	if m.config.ApprovalTimeoutMinutes > 0 {
+		return time.Duration(m.config.ApprovalTimeoutMinutes) * time.Minute
+	}
+	return DefaultTimeout
+}
+
+// SetPolicy sets the policy of requests filed for action from now on; "*"
+// applies to actions without one.
+func (m *Manager) SetPolicy(action string, policy Policy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.policies[action] = policy.withDefaults(m.defaultTimeout())
+}
+
+// PolicyFor returns the policy a request for action is filed under.
+func (m *Manager) PolicyFor(action string) Policy {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.policyFor(action)
+}
+
+func (m *Manager) policyFor(action string) Policy {
+	if policy, ok := m.policies[action]; ok {
+		return policy
+	}
+	if policy, ok := m.policies[DefaultPolicy]; ok {
+		return policy
+	}
+	return Policy{}.withDefaults(m.defaultTimeout())
+}
+
+// Handle runs handler once each request of action is decided.
+func (m *Manager) Handle(action string, handler Handler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers[action] = handler
+}
+
+// SetDispatcher notifies the outbound webhook subscribers of every decision
+// through d.
+func (m *Manager) SetDispatcher(d *outbound.Dispatcher) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = d
+}
+
+// SetStore moves the manager to store and resumes the requests it left
+// pending: their votes are restored from the audit trail, overdue ones
+// expire and the others wait for their expiry again. Call it before filing
+// requests; the ones pending in the previous store are not carried over.
+func (m *Manager) SetStore(store Store) error {
+	ctx := context.Background()
+	records, err := store.ListRequests(ctx, StatusPending.String())
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	for _, p := range m.pending {
+		p.stopTimers()
+	}
+	m.store = store
+	m.pending = make(map[string]*pendingRequest)
+	for i := range records {
+		decisions, err := store.ListDecisions(ctx, records[i].ID)
+		if err != nil {
+			m.mu.Unlock()
+			return err
+		}
+		req, err := requestFromRecord(&records[i], decisions)
+		if err != nil {
+			gl.Log("warn", fmt.Sprintf("approval request %s not restored", records[i].ID), err)
+			continue
+		}
+		p := &pendingRequest{req: req, done: make(chan struct{})}
+		m.pending[req.ID] = p
+		m.schedule(p)
 	}
+	restored := len(m.pending)
+	m.mu.Unlock()
+
+	if restored > 0 {
+		gl.Log("info", fmt.Sprintf("%d approval requests restored", restored))
+	}
+	return nil
 }
 
+// RequestApproval files req and waits for its decision.
 func (m *Manager) RequestApproval(ctx context.Context, req Request) (*Response, error) {
+	filed, err := m.Submit(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return m.Wait(ctx, filed.ID)
+}
+
+// Submit files req under the policy of its action and returns at once; the
+// decision is reported to the action handler, Wait and the event stream.
+func (m *Manager) Submit(ctx context.Context, req Request) (*Request, error) {
+	m.mu.Lock()
 	req.ID = uuid.New().String()
-	req.CreatedAt = time.Now()
-	req.ExpiresAt = time.Now().Add(time.Duration(m.config.ApprovalTimeoutMinutes) * time.Minute)
+	req.CreatedAt = m.now()
+	req.Policy = m.policyFor(req.Action)
+	req.ExpiresAt = req.CreatedAt.Add(req.Policy.Timeout)
 	req.Status = StatusPending
+	req.Escalated = false
+	req.Votes = nil
+	req.DecidedAt = nil
+	req.Messages = nil
 
-	m.mu.Lock()
-	m.pendingApprovals[req.ID] = &req
+	if err := m.save(ctx, &req); err != nil {
+		m.mu.Unlock()
+		return nil, err
+	}
+	m.audit(ctx, req.ID, KindRequested, Approver{ID: req.RequestedBy}, "")
+	p := &pendingRequest{req: &req, done: make(chan struct{})}
+	m.pending[req.ID] = p
+	m.schedule(p)
+	filed := req.clone()
 	m.mu.Unlock()
 
-	// Broadcast approval request to frontend
-	m.eventStream.Broadcast(events.Event{
-		Type: "approval_request",
-		Data: req,
-	})
+	m.broadcast(EventRequest, filed)
+	m.present(ctx, filed)
+	return filed, nil
+}
 
-	// Wait for approval with timeout
-	return m.waitForApproval(ctx, req.ID)
+// Wait blocks until the request is decided or ctx is done.
+func (m *Manager) Wait(ctx context.Context, requestID string) (*Response, error) {
+	m.mu.Lock()
+	p, ok := m.pending[requestID]
+	m.mu.Unlock()
+	if !ok {
+		req, err := m.GetRequest(ctx, requestID)
+		if err != nil {
+			return nil, err
+		}
+		return responseFor(req, decidingApprover(req), timeOr(req.DecidedAt, m.now())), nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-p.done:
+		resp := *p.resp
+		return &resp, nil
+	}
 }
 
+// ProcessApproval records the vote of approverID, who holds no roles.
 func (m *Manager) ProcessApproval(requestID string, approved bool, approverID string) error {
+	_, err := m.Decide(context.Background(), requestID, approved, Approver{ID: approverID}, "")
+	return err
+}
+
+// Decide records the vote of approver. The request is approved once the
+// quorum of its policy is reached and rejected by any rejection; until then
+// the response reports it pending.
+func (m *Manager) Decide(ctx context.Context, requestID string, approved bool, approver Approver, comment string) (*Response, error) {
+	m.mu.Lock()
+	p, ok := m.pending[requestID]
+	if !ok {
+		m.mu.Unlock()
+		if _, err := m.store.GetRequest(ctx, requestID); err != nil {
+			return nil, err
+		}
+		return nil, ErrNotPending
+	}
+	req := p.req
+	now := m.now()
+
+	if !now.Before(req.ExpiresAt) {
+		resp, final := m.expireLocked(ctx, p)
+		m.mu.Unlock()
+		m.finished(final, resp)
+		return nil, ErrExpired
+	}
+	if approver.ID == "" || !req.Policy.allows(approver, req.Escalated) {
+		m.mu.Unlock()
+		return nil, ErrNotEligible
+	}
+	for _, vote := range req.Votes {
+		if vote.Approver.ID == approver.ID {
+			m.mu.Unlock()
+			return nil, ErrAlreadyVoted
+		}
+	}
+
+	req.Votes = append(req.Votes, Vote{Approver: approver, Approved: approved, Comment: comment, At: now})
+	kind := KindApprove
+	if !approved {
+		kind = KindReject
+	}
+	m.audit(ctx, req.ID, kind, approver, comment)
+
+	switch {
+	case !approved:
+		resp, final := m.finishLocked(ctx, p, StatusRejected, approver.ID, KindRejected, approver, comment)
+		m.mu.Unlock()
+		m.finished(final, resp)
+		return resp, nil
+	case len(responseFor(req, "", now).Approvers) >= req.Policy.Quorum:
+		resp, final := m.finishLocked(ctx, p, StatusApproved, approver.ID, KindApproved, approver, comment)
+		m.mu.Unlock()
+		m.finished(final, resp)
+		return resp, nil
+	}
+
+	if err := m.save(ctx, req); err != nil {
+		gl.Log("error", fmt.Sprintf("approval request %s not saved", req.ID), err)
+	}
+	resp := responseFor(req, "", now)
+	m.mu.Unlock()
+
+	m.broadcast(EventVote, resp)
+	go m.refresh(requestID)
+	return resp, nil
+}
+
+// GetPendingApprovals returns the requests waiting for a decision, oldest
+// first.
+func (m *Manager) GetPendingApprovals() []*Request {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	req, exists := m.pendingApprovals[requestID]
-	if !exists {
-		return fmt.Errorf("approval request not found: %s", requestID)
+	now := m.now()
+	pending := make([]*Request, 0, len(m.pending))
+	for _, p := range m.pending {
+		if now.Before(p.req.ExpiresAt) {
+			pending = append(pending, p.req.clone())
+		}
 	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].CreatedAt.Before(pending[j].CreatedAt) })
+	return pending
+}
 
-	if time.Now().After(req.ExpiresAt) {
-		req.Status = StatusExpired
-		return fmt.Errorf("approval request expired")
+// GetRequest returns a request, pending or decided.
+func (m *Manager) GetRequest(ctx context.Context, requestID string) (*Request, error) {
+	m.mu.Lock()
+	if p, ok := m.pending[requestID]; ok {
+		req := p.req.clone()
+		m.mu.Unlock()
+		return req, nil
 	}
+	store := m.store
+	m.mu.Unlock()
 
-	if approved {
-		req.Status = StatusApproved
-	} else {
-		req.Status = StatusRejected
+	record, err := store.GetRequest(ctx, requestID)
+	if err != nil {
+		return nil, err
 	}
+	decisions, err := store.ListDecisions(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+	return requestFromRecord(record, decisions)
+}
+
+// History returns the audit trail of a request, oldest first.
+func (m *Manager) History(ctx context.Context, requestID string) ([]Decision, error) {
+	m.mu.Lock()
+	store := m.store
+	m.mu.Unlock()
 
-	response := Response{
-		RequestID:  requestID,
-		Approved:   approved,
-		ApproverID: approverID,
-		Timestamp:  time.Now(),
+	if _, err := store.GetRequest(ctx, requestID); err != nil {
+		return nil, err
+	}
+	records, err := store.ListDecisions(ctx, requestID)
+	if err != nil {
+		return nil, err
 	}
+	history := make([]Decision, 0, len(records))
+	for _, record := range records {
+		history = append(history, decisionFromRecord(record))
+	}
+	return history, nil
+}
+
+// Close stops the expiry and escalation timers. Pending requests stay in
+// the store and resume on the next SetStore.
+func (m *Manager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, p := range m.pending {
+		p.stopTimers()
+	}
+}
 
-	// Notify frontend of approval result
-	m.eventStream.Broadcast(events.Event{
-		Type: "approval_result",
-		Data: response,
-	})
+// schedule arms the expiry and escalation of p. Called with m.mu held.
+func (m *Manager) schedule(p *pendingRequest) {
+	id := p.req.ID
+	now := m.now()
+	p.timers = append(p.timers, time.AfterFunc(p.req.ExpiresAt.Sub(now), func() { m.expire(id) }))
 
-	return nil
+	policy := p.req.Policy
+	if p.req.Escalated || policy.EscalateAfter <= 0 {
+		return
+	}
+	escalateAt := p.req.CreatedAt.Add(policy.EscalateAfter)
+	if escalateAt.Before(p.req.ExpiresAt) {
+		p.timers = append(p.timers, time.AfterFunc(escalateAt.Sub(now), func() { m.escalate(id) }))
+	}
 }
 
-func (m *Manager) waitForApproval(ctx context.Context, requestID string) (*Response, error) {
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
-
-	timeout := time.After(time.Duration(m.config.ApprovalTimeoutMinutes) * time.Minute)
-
-	for {
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		case <-timeout:
-			return nil, fmt.Errorf("approval timeout")
-		case <-ticker.C:
-			m.mu.RLock()
-			req, exists := m.pendingApprovals[requestID]
-			if !exists {
-				m.mu.RUnlock()
-				return nil, fmt.Errorf("approval request not found")
-			}
+func (p *pendingRequest) stopTimers() {
+	for _, timer := range p.timers {
+		timer.Stop()
+	}
+	p.timers = nil
+}
 
-			if req.Status != StatusPending {
-				response := &Response{
-					RequestID: requestID,
-					Approved:  req.Status == StatusApproved,
-					Timestamp: time.Now(),
-				}
-				m.mu.RUnlock()
-				return response, nil
-			}
-			m.mu.RUnlock()
+func (m *Manager) expire(requestID string) {
+	m.mu.Lock()
+	p, ok := m.pending[requestID]
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+	resp, final := m.expireLocked(context.Background(), p)
+	m.mu.Unlock()
+	m.finished(final, resp)
+}
+
+// expireLocked closes p as expired, or rejected when its policy rejects
+// unanswered requests.
+func (m *Manager) expireLocked(ctx context.Context, p *pendingRequest) (*Response, *Request) {
+	if !p.req.Policy.AutoReject {
+		return m.finishLocked(ctx, p, StatusExpired, "", KindExpired, Approver{}, "")
+	}
+	m.audit(ctx, p.req.ID, KindExpired, Approver{}, "")
+	return m.finishLocked(ctx, p, StatusRejected, "", KindRejected, Approver{}, "rejected on expiry")
+}
+
+func (m *Manager) escalate(requestID string) {
+	m.mu.Lock()
+	p, ok := m.pending[requestID]
+	if !ok || p.req.Escalated {
+		m.mu.Unlock()
+		return
+	}
+	p.req.Escalated = true
+	ctx := context.Background()
+	if err := m.save(ctx, p.req); err != nil {
+		gl.Log("error", fmt.Sprintf("approval request %s not saved", requestID), err)
+	}
+	m.audit(ctx, requestID, KindEscalated, Approver{}, strings.Join(p.req.Policy.EscalationRoles, ","))
+	req := p.req.clone()
+	m.mu.Unlock()
+
+	gl.Log("info", fmt.Sprintf("approval request %s escalated", requestID))
+	m.broadcast(EventEscalated, req)
+	go m.refresh(requestID)
+}
+
+// finishLocked records the outcome of p, releases its waiters and returns
+// what finished reports. Called with m.mu held.
+func (m *Manager) finishLocked(ctx context.Context, p *pendingRequest, status Status, decidedBy, kind string, approver Approver, comment string) (*Response, *Request) {
+	req := p.req
+	now := m.now()
+	req.Status = status
+	req.DecidedAt = &now
+	p.stopTimers()
+
+	if err := m.save(ctx, req); err != nil {
+		gl.Log("error", fmt.Sprintf("approval request %s not saved", req.ID), err)
+	}
+	m.audit(ctx, req.ID, kind, approver, comment)
+
+	resp := responseFor(req, decidedBy, now)
+	p.resp = resp
+	delete(m.pending, req.ID)
+	close(p.done)
+	return resp, req.clone()
+}
+
+// finished announces a decided request and runs its action handler.
+func (m *Manager) finished(req *Request, resp *Response) {
+	m.broadcast(EventResult, resp)
+	go m.refresh(req.ID)
+
+	m.mu.Lock()
+	handler := m.handlers[req.Action]
+	events := m.events
+	m.mu.Unlock()
+	events.Emit(outbound.EventApprovalDecided, resp)
+	if handler != nil {
+		go handler(context.Background(), *req, *resp)
+	}
+}
+
+func (m *Manager) broadcast(eventType string, data interface{}) {
+	if m.eventStream == nil {
+		return
+	}
+	if !m.eventStream.TryBroadcast(events.Event{Type: eventType, Data: data}) {
+		gl.Log("warn", fmt.Sprintf("%s event dropped: event stream busy", eventType))
+	}
+}
+
+func (m *Manager) save(ctx context.Context, req *Request) error {
+	record, err := req.record()
+	if err != nil {
+		return err
+	}
+	return m.store.SaveRequest(ctx, record)
+}
+
+func (m *Manager) audit(ctx context.Context, requestID, kind string, approver Approver, comment string) {
+	record := &svc.ApprovalDecisionRecord{
+		RequestID:    requestID,
+		Kind:         kind,
+		ApproverID:   approver.ID,
+		ApproverName: approver.Name,
+		Roles:        strings.Join(approver.Roles, ","),
+		Comment:      comment,
+		CreatedAt:    m.now(),
+	}
+	if err := m.store.AppendDecision(ctx, record); err != nil {
+		gl.Log("error", fmt.Sprintf("approval decision %s of %s not recorded", kind, requestID), err)
+	}
+}
+
+func (r *Request) clone() *Request {
+	c := *r
+	c.Votes = append([]Vote(nil), r.Votes...)
+	if r.Messages != nil {
+		c.Messages = make(map[string]string, len(r.Messages))
+		for k, v := range r.Messages {
+			c.Messages[k] = v
 		}
 	}
+	if r.Details != nil {
+		c.Details = make(map[string]interface{}, len(r.Details))
+		for k, v := range r.Details {
+			c.Details[k] = v
+		}
+	}
+	return &c
 }
 
-func (m *Manager) GetPendingApprovals() []*Request {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+func (r *Request) record() (*svc.ApprovalRequestRecord, error) {
+	details, err := json.Marshal(r.Details)
+	if err != nil {
+		return nil, fmt.Errorf("encoding approval details: %w", err)
+	}
+	policy, err := json.Marshal(r.Policy)
+	if err != nil {
+		return nil, fmt.Errorf("encoding approval policy: %w", err)
+	}
+	var messages []byte
+	if len(r.Messages) > 0 {
+		if messages, err = json.Marshal(r.Messages); err != nil {
+			return nil, fmt.Errorf("encoding approval messages: %w", err)
+		}
+	}
+	return &svc.ApprovalRequestRecord{
+		ID:          r.ID,
+		Action:      r.Action,
+		Platform:    r.Platform,
+		RequestedBy: r.RequestedBy,
+		Details:     string(details),
+		Policy:      string(policy),
+		Messages:    string(messages),
+		Status:      r.Status.String(),
+		Escalated:   r.Escalated,
+		CreatedAt:   r.CreatedAt,
+		ExpiresAt:   r.ExpiresAt,
+		DecidedAt:   r.DecidedAt,
+	}, nil
+}
+
+// requestFromRecord rebuilds a request, taking its votes from the audit
+// trail.
+func requestFromRecord(record *svc.ApprovalRequestRecord, decisions []svc.ApprovalDecisionRecord) (*Request, error) {
+	req := &Request{
+		ID:          record.ID,
+		Action:      record.Action,
+		Platform:    record.Platform,
+		RequestedBy: record.RequestedBy,
+		CreatedAt:   record.CreatedAt,
+		ExpiresAt:   record.ExpiresAt,
+		Status:      parseStatus(record.Status),
+		Escalated:   record.Escalated,
+		DecidedAt:   record.DecidedAt,
+	}
+	if record.Details != "" {
+		if err := json.Unmarshal([]byte(record.Details), &req.Details); err != nil {
+			return nil, fmt.Errorf("decoding approval details: %w", err)
+		}
+	}
+	if record.Policy != "" {
+		if err := json.Unmarshal([]byte(record.Policy), &req.Policy); err != nil {
+			return nil, fmt.Errorf("decoding approval policy: %w", err)
+		}
+	}
+	if record.Messages != "" {
+		if err := json.Unmarshal([]byte(record.Messages), &req.Messages); err != nil {
+			return nil, fmt.Errorf("decoding approval messages: %w", err)
+		}
+	}
+	for _, record := range decisions {
+		if record.Kind != KindApprove && record.Kind != KindReject {
+			continue
+		}
+		decision := decisionFromRecord(record)
+		req.Votes = append(req.Votes, Vote{
+			Approver: decision.Approver,
+			Approved: record.Kind == KindApprove,
+			Comment:  decision.Comment,
+			At:       decision.At,
+		})
+	}
+	return req, nil
+}
+
+func decisionFromRecord(record svc.ApprovalDecisionRecord) Decision {
+	decision := Decision{
+		Kind:     record.Kind,
+		Approver: Approver{ID: record.ApproverID, Name: record.ApproverName},
+		Comment:  record.Comment,
+		At:       record.CreatedAt,
+	}
+	if record.Roles != "" {
+		decision.Approver.Roles = strings.Split(record.Roles, ",")
+	}
+	return decision
+}
 
-	var pending []*Request
-	for _, req := range m.pendingApprovals {
-		if req.Status == StatusPending && time.Now().Before(req.ExpiresAt) {
-			pending = append(pending, req)
+func responseFor(req *Request, decidedBy string, at time.Time) *Response {
+	resp := &Response{
+		RequestID:  req.ID,
+		Approved:   req.Status == StatusApproved,
+		Status:     req.Status,
+		ApproverID: decidedBy,
+		Quorum:     req.Policy.Quorum,
+		Timestamp:  at,
+	}
+	for _, vote := range req.Votes {
+		if vote.Approved {
+			resp.Approvers = append(resp.Approvers, vote.Approver.ID)
 		}
 	}
+	resp.Approvals = len(resp.Approvers)
+	return resp
+}
 
-	return pending
+// decidingApprover returns who cast the vote that decided req: the
+// rejection, or the approval that reached the quorum.
+func decidingApprover(req *Request) string {
+	switch req.Status {
+	case StatusRejected:
+		for _, vote := range req.Votes {
+			if !vote.Approved {
+				return vote.Approver.ID
+			}
+		}
+	case StatusApproved:
+		if len(req.Votes) > 0 {
+			return req.Votes[len(req.Votes)-1].Approver.ID
+		}
+	}
+	return ""
+}
+
+func timeOr(t *time.Time, fallback time.Time) time.Time {
+	if t == nil {
+		return fallback
+	}
+	return *t
 }
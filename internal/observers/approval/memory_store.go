@@ -0,0 +1,86 @@
+package approval
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
+)
+
+// MemoryStore keeps approval requests in memory. It serves tests and hubs
+// running without a database; requests are lost on restart.
+type MemoryStore struct {
+	mu        sync.RWMutex
+	requests  map[string]svc.ApprovalRequestRecord
+	decisions map[string][]svc.ApprovalDecisionRecord
+}
+
+// NewMemoryStore returns an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		requests:  make(map[string]svc.ApprovalRequestRecord),
+		decisions: make(map[string][]svc.ApprovalDecisionRecord),
+	}
+}
+
+func (m *MemoryStore) SaveRequest(ctx context.Context, record *svc.ApprovalRequestRecord) error {
+	if record.ID == "" {
+		record.ID = uuid.NewString()
+	}
+	record.UpdatedAt = time.Now().UTC()
+	m.mu.Lock()
+	m.requests[record.ID] = *record
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *MemoryStore) GetRequest(ctx context.Context, id string) (*svc.ApprovalRequestRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	record, ok := m.requests[id]
+	if !ok {
+		return nil, svc.ErrApprovalRequestNotFound
+	}
+	return &record, nil
+}
+
+func (m *MemoryStore) ListRequests(ctx context.Context, status string) ([]svc.ApprovalRequestRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	records := make([]svc.ApprovalRequestRecord, 0)
+	for _, record := range m.requests {
+		if status == "" || record.Status == status {
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if !records[i].CreatedAt.Equal(records[j].CreatedAt) {
+			return records[i].CreatedAt.Before(records[j].CreatedAt)
+		}
+		return records[i].ID < records[j].ID
+	})
+	return records, nil
+}
+
+func (m *MemoryStore) AppendDecision(ctx context.Context, record *svc.ApprovalDecisionRecord) error {
+	if record.ID == "" {
+		record.ID = uuid.NewString()
+	}
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now().UTC()
+	}
+	m.mu.Lock()
+	m.decisions[record.RequestID] = append(m.decisions[record.RequestID], *record)
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *MemoryStore) ListDecisions(ctx context.Context, requestID string) ([]svc.ApprovalDecisionRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]svc.ApprovalDecisionRecord(nil), m.decisions[requestID]...), nil
+}
@@ -0,0 +1,80 @@
+package approval
+
+import (
+	"strings"
+	"time"
+
+	"github.com/kubex-ecosystem/gobe/internal/config"
+)
+
+// DefaultTimeout is the expiry of requests when neither the policy nor
+// ApprovalTimeoutMinutes sets one.
+const DefaultTimeout = 30 * time.Minute
+
+// DefaultPolicy is the key of the policy applied to actions without one.
+const DefaultPolicy = "*"
+
+// Policy decides who may approve an action and how many approvals it needs.
+// A single rejection from an eligible approver rejects the request.
+type Policy struct {
+	// Roles an approver needs one of; anyone may decide when empty.
+	Roles  []string `json:"roles,omitempty"`
+	Quorum int      `json:"quorum"`
+	// Timeout is how long a request waits for its quorum. Unanswered
+	// requests expire, or are rejected with AutoReject.
+	Timeout    time.Duration `json:"timeout"`
+	AutoReject bool          `json:"auto_reject,omitempty"`
+	// EscalateAfter lets approvers holding EscalationRoles decide once the
+	// request waited that long.
+	EscalateAfter   time.Duration `json:"escalate_after,omitempty"`
+	EscalationRoles []string      `json:"escalation_roles,omitempty"`
+}
+
+// PolicyFromConfig converts cfg, using defaultTimeout when it sets none.
+func PolicyFromConfig(cfg config.ApprovalPolicyConfig, defaultTimeout time.Duration) Policy {
+	policy := Policy{
+		Roles:           cfg.Roles,
+		Quorum:          cfg.Quorum,
+		Timeout:         time.Duration(cfg.TimeoutMinutes) * time.Minute,
+		AutoReject:      cfg.AutoReject,
+		EscalateAfter:   time.Duration(cfg.EscalateAfterMinutes) * time.Minute,
+		EscalationRoles: cfg.EscalationRoles,
+	}
+	return policy.withDefaults(defaultTimeout)
+}
+
+func (p Policy) withDefaults(defaultTimeout time.Duration) Policy {
+	if p.Quorum < 1 {
+		p.Quorum = 1
+	}
+	if p.Timeout <= 0 {
+		p.Timeout = defaultTimeout
+	}
+	if p.Timeout <= 0 {
+		p.Timeout = DefaultTimeout
+	}
+	return p
+}
+
+// allows reports whether approver may vote, counting the escalation roles
+// once the request escalated.
+func (p Policy) allows(approver Approver, escalated bool) bool {
+	if len(p.Roles) == 0 {
+		return true
+	}
+	if holdsAny(approver.Roles, p.Roles) {
+		return true
+	}
+	return escalated && holdsAny(approver.Roles, p.EscalationRoles)
+}
+
+func holdsAny(have, want []string) bool {
+	for _, w := range want {
+		for _, h := range have {
+			if strings.EqualFold(h, w) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,301 @@
+package events
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultQueueSize bounds the events waiting for a slow client. Events
+// beyond it are dropped and reported with a stream_gap notice.
+const DefaultQueueSize = 256
+
+// Client receives the events of a stream on Send. Set Topics and
+// LastEventID before registering the client; use SetTopics, AddTopics and
+// RemoveTopics afterwards. Send is closed when the client is unregistered or
+// the stream is closed.
+type Client struct {
+	ID   string
+	Send chan Event
+	// Topics selects the event types the client receives with glob patterns
+	// ("approval_*", "webhook.*"). A client without topics receives every
+	// event.
+	Topics []string
+	// LastEventID resumes the stream after this event: the buffered events
+	// that followed it are sent first.
+	LastEventID uint64
+	// QueueSize overrides DefaultQueueSize.
+	QueueSize int
+
+	mu            sync.Mutex
+	queue         []Event
+	gap           uint64
+	delivered     uint64
+	dropped       uint64
+	lastDelivered uint64
+	connectedAt   time.Time
+	wake          chan struct{}
+	done          chan struct{}
+	stopOnce      sync.Once
+}
+
+// ClientStats reports the backpressure of a client. Queued counts the
+// events accepted but not yet read from Send.
+type ClientStats struct {
+	ID          string    `json:"id"`
+	Topics      []string  `json:"topics"`
+	ConnectedAt time.Time `json:"connected_at"`
+	Delivered   uint64    `json:"delivered"`
+	Dropped     uint64    `json:"dropped"`
+	Queued      int       `json:"queued"`
+	LastEventID uint64    `json:"last_event_id"`
+}
+
+// NewClient returns a client with a random ID subscribed to topics.
+func NewClient(topics []string, lastEventID uint64) (*Client, error) {
+	if err := validateTopics(topics); err != nil {
+		return nil, err
+	}
+	return &Client{
+		ID:          uuid.New().String(),
+		Send:        make(chan Event, 64),
+		Topics:      topics,
+		LastEventID: lastEventID,
+	}, nil
+}
+
+// ClientFromRequest builds a client from the "topics" query parameter
+// (comma separated, may repeat) and the "last_event_id" query parameter or
+// the Last-Event-ID header sent by reconnecting EventSource clients.
+func ClientFromRequest(r *http.Request) (*Client, error) {
+	var topics []string
+	for _, value := range r.URL.Query()["topics"] {
+		topics = append(topics, ParseTopics(value)...)
+	}
+
+	lastID := r.URL.Query().Get("last_event_id")
+	if lastID == "" {
+		lastID = r.Header.Get("Last-Event-ID")
+	}
+	var after uint64
+	if lastID != "" {
+		id, err := strconv.ParseUint(lastID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid last event id %q", lastID)
+		}
+		after = id
+	}
+	return NewClient(topics, after)
+}
+
+// ParseTopics splits a comma separated list of topic patterns.
+func ParseTopics(value string) []string {
+	var topics []string
+	for _, topic := range strings.Split(value, ",") {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			topics = append(topics, topic)
+		}
+	}
+	return topics
+}
+
+func validateTopics(topics []string) error {
+	for _, topic := range topics {
+		if _, err := path.Match(topic, ""); err != nil {
+			return fmt.Errorf("invalid topic pattern %q", topic)
+		}
+	}
+	return nil
+}
+
+// SetTopics replaces the topics of the client.
+func (c *Client) SetTopics(topics []string) error {
+	if err := validateTopics(topics); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Topics = append([]string(nil), topics...)
+	return nil
+}
+
+// AddTopics subscribes the client to more topics.
+func (c *Client) AddTopics(topics []string) error {
+	if err := validateTopics(topics); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, topic := range topics {
+		if !containsTopic(c.Topics, topic) {
+			c.Topics = append(c.Topics, topic)
+		}
+	}
+	return nil
+}
+
+// RemoveTopics unsubscribes the client from topics. Removing the last topic
+// makes the client receive every event again.
+func (c *Client) RemoveTopics(topics []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	kept := c.Topics[:0]
+	for _, topic := range c.Topics {
+		if !containsTopic(topics, topic) {
+			kept = append(kept, topic)
+		}
+	}
+	c.Topics = kept
+}
+
+// CurrentTopics returns a copy of the topics of the client.
+func (c *Client) CurrentTopics() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.Topics...)
+}
+
+func containsTopic(topics []string, topic string) bool {
+	for _, t := range topics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Client) matches(eventType string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.Topics) == 0 {
+		return true
+	}
+	for _, topic := range c.Topics {
+		if topic == eventType {
+			return true
+		}
+		if ok, _ := path.Match(topic, eventType); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Stats returns the backpressure counters of the client.
+func (c *Client) Stats() ClientStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return ClientStats{
+		ID:          c.ID,
+		Topics:      append([]string(nil), c.Topics...),
+		ConnectedAt: c.connectedAt,
+		Delivered:   c.delivered,
+		Dropped:     c.dropped,
+		Queued:      len(c.queue) + len(c.Send),
+		LastEventID: c.lastDelivered,
+	}
+}
+
+// start begins delivering backlog, then the events offered to the client.
+func (c *Client) start(backlog []Event) {
+	c.mu.Lock()
+	if c.Send == nil {
+		c.Send = make(chan Event, 64)
+	}
+	c.wake = make(chan struct{}, 1)
+	c.done = make(chan struct{})
+	c.queue = append(c.queue[:0], backlog...)
+	c.connectedAt = time.Now()
+	wake, done := c.wake, c.done
+	c.mu.Unlock()
+	go c.pump(wake, done)
+}
+
+// stop ends the delivery and closes Send.
+func (c *Client) stop() {
+	c.stopOnce.Do(func() {
+		c.mu.Lock()
+		done := c.done
+		c.mu.Unlock()
+		if done == nil {
+			close(c.Send)
+			return
+		}
+		close(done)
+	})
+}
+
+// offer queues event when the client subscribed to its type. A full queue
+// drops the event; the client is told how many it missed once it catches up.
+func (c *Client) offer(event Event) {
+	if !c.matches(event.Type) {
+		return
+	}
+	limit := c.QueueSize
+	if limit <= 0 {
+		limit = DefaultQueueSize
+	}
+
+	c.mu.Lock()
+	if len(c.queue) >= limit {
+		c.dropped++
+		c.gap++
+		c.mu.Unlock()
+		return
+	}
+	c.queue = append(c.queue, event)
+	wake := c.wake
+	c.mu.Unlock()
+
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+func (c *Client) pump(wake, done chan struct{}) {
+	defer close(c.Send)
+	for {
+		c.mu.Lock()
+		batch, gap := c.queue, c.gap
+		c.queue, c.gap = nil, 0
+		c.mu.Unlock()
+
+		for _, event := range batch {
+			if !c.deliver(event, done) {
+				return
+			}
+		}
+		// the dropped events came after the whole batch
+		if gap > 0 && !c.deliver(gapNotice("slow_client", gap), done) {
+			return
+		}
+
+		select {
+		case <-wake:
+		case <-done:
+			return
+		}
+	}
+}
+
+func (c *Client) deliver(event Event, done chan struct{}) bool {
+	select {
+	case c.Send <- event:
+		c.mu.Lock()
+		c.delivered++
+		if event.ID > 0 {
+			c.lastDelivered = event.ID
+		}
+		c.mu.Unlock()
+		return true
+	case <-done:
+		return false
+	}
+}
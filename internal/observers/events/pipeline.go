@@ -0,0 +1,480 @@
+package events
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kubex-ecosystem/gobe/internal/config"
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+	"github.com/kubex-ecosystem/gobe/internal/utils/backoff"
+)
+
+// Pipeline event types.
+const (
+	EventProcessingStarted   = "message_processing_started"
+	EventProcessingRetrying  = "message_processing_retrying"
+	EventProcessingCompleted = "message_processing_completed"
+	EventProcessingFailed    = "message_processing_failed"
+)
+
+var (
+	// ErrQueueFull is returned by Submit when QueueSize jobs are waiting.
+	ErrQueueFull = errors.New("message queue is full")
+	// ErrPipelineClosed is returned by Submit after Close.
+	ErrPipelineClosed = errors.New("message pipeline closed")
+	// ErrNoPipeline is returned by Stream.ProcessMessage before SetPipeline.
+	ErrNoPipeline = errors.New("no message pipeline configured")
+)
+
+// PipelineOptions tunes the worker pool of a Pipeline.
+type PipelineOptions struct {
+	Workers   int
+	QueueSize int
+	// Aging is how long a job waits to gain one priority level, so low
+	// priority jobs are not starved by a steady flow of urgent ones.
+	Aging time.Duration
+	// Timeout bounds each attempt of a job.
+	Timeout     time.Duration
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+}
+
+// DefaultPipelineOptions runs four workers and gives up on a job after three
+// attempts.
+var DefaultPipelineOptions = PipelineOptions{
+	Workers:     4,
+	QueueSize:   1000,
+	Aging:       30 * time.Second,
+	Timeout:     2 * time.Minute,
+	MaxAttempts: 3,
+	Backoff:     2 * time.Second,
+	MaxBackoff:  30 * time.Second,
+}
+
+// PipelineOptionsFromConfig reads the "pipeline" config section:
+// Workers, QueueSize, AgingSeconds, TimeoutSeconds, MaxAttempts,
+// BackoffSeconds and MaxBackoffSeconds replace the DefaultPipelineOptions
+// they name when positive.
+func PipelineOptionsFromConfig(cfg config.PipelineConfig) PipelineOptions {
+	opts := DefaultPipelineOptions
+	if cfg.Workers > 0 {
+		opts.Workers = cfg.Workers
+	}
+	if cfg.QueueSize > 0 {
+		opts.QueueSize = cfg.QueueSize
+	}
+	if cfg.AgingSeconds > 0 {
+		opts.Aging = time.Duration(cfg.AgingSeconds) * time.Second
+	}
+	if cfg.TimeoutSeconds > 0 {
+		opts.Timeout = time.Duration(cfg.TimeoutSeconds) * time.Second
+	}
+	if cfg.MaxAttempts > 0 {
+		opts.MaxAttempts = cfg.MaxAttempts
+	}
+	if cfg.BackoffSeconds > 0 {
+		opts.Backoff = time.Duration(cfg.BackoffSeconds) * time.Second
+	}
+	if cfg.MaxBackoffSeconds > 0 {
+		opts.MaxBackoff = time.Duration(cfg.MaxBackoffSeconds) * time.Second
+	}
+	return opts
+}
+
+// Delay returns the pause after the given failed attempt (1 for the first),
+// doubling from Backoff up to MaxBackoff.
+func (o PipelineOptions) Delay(attempt int) time.Duration {
+	return backoff.Delay(attempt, o.Backoff, o.MaxBackoff)
+}
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityNormal:
+		return "normal"
+	case PriorityHigh:
+		return "high"
+	case PriorityUrgent:
+		return "urgent"
+	default:
+		return fmt.Sprintf("priority(%d)", int(p))
+	}
+}
+
+// StageFunc runs a step of the pipeline. Its output is recorded in
+// result.Outputs under the stage name, where later stages find it.
+type StageFunc func(ctx context.Context, job MessageProcessingJob, result *JobResult) (interface{}, error)
+
+// Stage is a named step of the pipeline. A failed stage is retried, and the
+// stages before it are not run again; errors wrapped with Permanent fail the
+// job right away.
+type Stage struct {
+	Name string
+	Run  StageFunc
+}
+
+// JobResult collects the outputs of the stages a job went through.
+type JobResult struct {
+	Outputs map[string]interface{} `json:"outputs,omitempty"`
+	// StoppedAt is the stage that ended the job early, and Reason why.
+	StoppedAt string `json:"stopped_at,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+
+	stopped bool
+}
+
+// Stop ends the job successfully once the current stage returns.
+func (r *JobResult) Stop(reason string) {
+	r.stopped = true
+	r.Reason = reason
+}
+
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent.
+func IsPermanent(err error) bool {
+	var perm *permanentError
+	return errors.As(err, &perm)
+}
+
+// PipelineStats reports the queue depth and the outcome of the jobs
+// processed so far. Delayed jobs wait for a retry and are not queued yet.
+type PipelineStats struct {
+	Workers          int            `json:"workers"`
+	Busy             int            `json:"busy"`
+	Queued           int            `json:"queued"`
+	QueuedByPriority map[string]int `json:"queued_by_priority"`
+	Delayed          int            `json:"delayed"`
+	OldestWaitMs     int64          `json:"oldest_wait_ms"`
+	Submitted        uint64         `json:"submitted"`
+	Rejected         uint64         `json:"rejected"`
+	Completed        uint64         `json:"completed"`
+	Failed           uint64         `json:"failed"`
+	Retried          uint64         `json:"retried"`
+}
+
+// Pipeline runs message jobs through its stages on a pool of workers and
+// reports their progress on a stream.
+type Pipeline struct {
+	stream *Stream
+	stages []Stage
+	opts   PipelineOptions
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	ready   *sync.Cond
+	queue   jobQueue
+	seq     uint64
+	closed  bool
+	busy    int
+	delayed int
+	stats   PipelineStats
+	now     func() time.Time
+}
+
+// pipelineJob is a job with its progress through the stages.
+type pipelineJob struct {
+	job      MessageProcessingJob
+	result   JobResult
+	next     int
+	attempts int
+	started  time.Time
+	seq      uint64
+}
+
+// NewPipeline starts opts.Workers workers running jobs through stages.
+// Progress events are broadcast on stream, which may be nil.
+func NewPipeline(stream *Stream, opts PipelineOptions, stages ...Stage) *Pipeline {
+	if opts.Workers <= 0 {
+		opts.Workers = DefaultPipelineOptions.Workers
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = DefaultPipelineOptions.QueueSize
+	}
+	if opts.Aging <= 0 {
+		opts.Aging = DefaultPipelineOptions.Aging
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultPipelineOptions.Timeout
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultPipelineOptions.MaxAttempts
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = DefaultPipelineOptions.Backoff
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pipeline{
+		stream: stream,
+		stages: stages,
+		opts:   opts,
+		ctx:    ctx,
+		cancel: cancel,
+		now:    time.Now,
+	}
+	p.ready = sync.NewCond(&p.mu)
+	p.queue.aging = opts.Aging
+	p.stats.Workers = opts.Workers
+
+	for i := 0; i < opts.Workers; i++ {
+		p.wg.Add(1)
+		go p.worker()
+	}
+	return p
+}
+
+// Submit queues job. A job without ID gets a random one.
+func (p *Pipeline) Submit(job MessageProcessingJob) error {
+	if job.ID == "" {
+		job.ID = uuid.New().String()
+	}
+	if job.CreatedAt.IsZero() {
+		job.CreatedAt = p.now()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrPipelineClosed
+	}
+	if p.queue.Len() >= p.opts.QueueSize {
+		p.stats.Rejected++
+		return ErrQueueFull
+	}
+	p.stats.Submitted++
+	p.push(&pipelineJob{job: job, result: JobResult{Outputs: map[string]interface{}{}}})
+	return nil
+}
+
+// push queues item; the caller holds p.mu.
+func (p *Pipeline) push(item *pipelineJob) {
+	p.seq++
+	item.seq = p.seq
+	heap.Push(&p.queue, item)
+	p.ready.Signal()
+}
+
+// Stats returns the queue depth and job counters.
+func (p *Pipeline) Stats() PipelineStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats := p.stats
+	stats.Busy = p.busy
+	stats.Delayed = p.delayed
+	stats.Queued = p.queue.Len()
+	stats.QueuedByPriority = map[string]int{}
+	now := p.now()
+	for _, item := range p.queue.items {
+		stats.QueuedByPriority[item.job.Priority.String()]++
+		if wait := now.Sub(item.job.CreatedAt).Milliseconds(); wait > stats.OldestWaitMs {
+			stats.OldestWaitMs = wait
+		}
+	}
+	return stats
+}
+
+// Close stops the workers once their current job is done. Queued jobs are
+// dropped.
+func (p *Pipeline) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.ready.Broadcast()
+	p.mu.Unlock()
+
+	p.cancel()
+	p.wg.Wait()
+	return nil
+}
+
+func (p *Pipeline) worker() {
+	defer p.wg.Done()
+	for {
+		p.mu.Lock()
+		for p.queue.Len() == 0 && !p.closed {
+			p.ready.Wait()
+		}
+		if p.closed {
+			p.mu.Unlock()
+			return
+		}
+		item := heap.Pop(&p.queue).(*pipelineJob)
+		p.busy++
+		p.mu.Unlock()
+
+		p.run(item)
+
+		p.mu.Lock()
+		p.busy--
+		p.mu.Unlock()
+	}
+}
+
+func (p *Pipeline) run(item *pipelineJob) {
+	item.attempts++
+	if item.attempts == 1 {
+		item.started = p.now()
+		p.emit(EventProcessingStarted, item, map[string]interface{}{
+			"waited_ms": item.started.Sub(item.job.CreatedAt).Milliseconds(),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(p.ctx, p.opts.Timeout)
+	stage, err := p.runStages(ctx, item)
+	cancel()
+
+	if err == nil {
+		p.mu.Lock()
+		p.stats.Completed++
+		p.mu.Unlock()
+		p.emit(EventProcessingCompleted, item, map[string]interface{}{
+			"duration_ms": p.now().Sub(item.started).Milliseconds(),
+			"result":      item.result,
+		})
+		return
+	}
+
+	if IsPermanent(err) || item.attempts >= p.opts.MaxAttempts || p.ctx.Err() != nil {
+		p.mu.Lock()
+		p.stats.Failed++
+		p.mu.Unlock()
+		gl.Log("warn", fmt.Sprintf("Message job %s failed at stage %s", item.job.ID, stage), err)
+		p.emit(EventProcessingFailed, item, map[string]interface{}{
+			"stage":       stage,
+			"error":       err.Error(),
+			"duration_ms": p.now().Sub(item.started).Milliseconds(),
+			"result":      item.result,
+		})
+		return
+	}
+
+	delay := p.opts.Delay(item.attempts)
+	p.mu.Lock()
+	p.stats.Retried++
+	p.delayed++
+	p.mu.Unlock()
+	p.emit(EventProcessingRetrying, item, map[string]interface{}{
+		"stage":       stage,
+		"error":       err.Error(),
+		"retry_in_ms": delay.Milliseconds(),
+	})
+	time.AfterFunc(delay, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.delayed--
+		if !p.closed {
+			p.push(item)
+		}
+	})
+}
+
+// runStages runs the stages item has not been through yet and returns the
+// name of the stage that failed.
+func (p *Pipeline) runStages(ctx context.Context, item *pipelineJob) (string, error) {
+	for item.next < len(p.stages) {
+		stage := p.stages[item.next]
+		output, err := runStage(ctx, stage, item.job, &item.result)
+		if err == nil && ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		if err != nil {
+			return stage.Name, err
+		}
+		if output != nil {
+			item.result.Outputs[stage.Name] = output
+		}
+		item.next++
+		if item.result.stopped {
+			item.result.StoppedAt = stage.Name
+			return "", nil
+		}
+	}
+	return "", nil
+}
+
+func runStage(ctx context.Context, stage Stage, job MessageProcessingJob, result *JobResult) (output interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = Permanent(fmt.Errorf("stage %s panicked: %v", stage.Name, r))
+		}
+	}()
+	return stage.Run(ctx, job, result)
+}
+
+func (p *Pipeline) emit(eventType string, item *pipelineJob, data map[string]interface{}) {
+	if p.stream == nil {
+		return
+	}
+	data["job_id"] = item.job.ID
+	data["platform"] = item.job.Platform
+	data["priority"] = item.job.Priority.String()
+	data["attempt"] = item.attempts
+	// never hold a worker on a stream nobody is draining
+	if !p.stream.TryBroadcast(Event{Type: eventType, Data: data}) {
+		gl.Log("debug", fmt.Sprintf("Dropped %s event of message job %s", eventType, item.job.ID))
+	}
+}
+
+// jobQueue orders jobs by priority with aging: a job's rank is the time it
+// was created moved back by one aging interval per priority level, so
+// waiting an interval is worth one level. Ties keep submission order.
+type jobQueue struct {
+	items []*pipelineJob
+	aging time.Duration
+}
+
+func (q *jobQueue) rank(item *pipelineJob) time.Time {
+	return item.job.CreatedAt.Add(-time.Duration(item.job.Priority) * q.aging)
+}
+
+func (q jobQueue) Len() int { return len(q.items) }
+
+func (q jobQueue) Less(i, j int) bool {
+	ri, rj := q.rank(q.items[i]), q.rank(q.items[j])
+	if !ri.Equal(rj) {
+		return ri.Before(rj)
+	}
+	return q.items[i].seq < q.items[j].seq
+}
+
+func (q jobQueue) Swap(i, j int) {
+	q.items[i], q.items[j] = q.items[j], q.items[i]
+}
+
+func (q *jobQueue) Push(x interface{}) {
+	q.items = append(q.items, x.(*pipelineJob))
+}
+
+func (q *jobQueue) Pop() interface{} {
+	old := q.items
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	q.items = old[:n-1]
+	return item
+}
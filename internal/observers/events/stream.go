@@ -5,27 +5,35 @@ import (
 	"sync"
 	"time"
 
-	"github.com/gorilla/websocket"
-
 	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
 )
 
-type Stream struct {
-	clients      map[string]*Client
-	register     chan *Client
-	unregister   chan *Client
-	broadcast    chan Event
-	messageQueue chan MessageProcessingJob
-	mu           sync.RWMutex
-}
+// HistorySize is how many events a stream keeps for clients resuming with
+// a last event ID.
+const HistorySize = 1024
+
+// EventStreamGap is the type of the notice a client receives in place of
+// events it missed: dropped because it was too slow, or no longer in the
+// history when it resumed.
+const EventStreamGap = "stream_gap"
 
-type Client struct {
-	ID   string
-	Conn *websocket.Conn
-	Send chan Event
+type Stream struct {
+	clients    map[string]*Client
+	register   chan *Client
+	unregister chan *Client
+	broadcast  chan Event
+	pipeline   *Pipeline
+	closed     chan struct{}
+	closeOnce  sync.Once
+	mu         sync.RWMutex
+	history    *history
+	lastID     uint64
 }
 
+// Event is a message of the stream. ID increases by one for each event
+// broadcast; notices generated for a single client have no ID.
 type Event struct {
+	ID        uint64      `json:"id,omitempty"`
 	Type      string      `json:"type"`
 	Data      interface{} `json:"data"`
 	Timestamp time.Time   `json:"timestamp"`
@@ -48,112 +56,234 @@ const (
 	PriorityUrgent
 )
 
+// StreamStats describes a stream and the backpressure of its clients.
+type StreamStats struct {
+	LastEventID uint64         `json:"last_event_id"`
+	Buffered    int            `json:"buffered"`
+	Clients     []ClientStats  `json:"clients"`
+	Pipeline    *PipelineStats `json:"pipeline,omitempty"`
+}
+
 func NewStream() *Stream {
 	return &Stream{
-		clients:      make(map[string]*Client),
-		register:     make(chan *Client),
-		unregister:   make(chan *Client),
-		broadcast:    make(chan Event, 256),
-		messageQueue: make(chan MessageProcessingJob, 100),
+		clients:    make(map[string]*Client),
+		register:   make(chan *Client),
+		unregister: make(chan *Client),
+		broadcast:  make(chan Event, 256),
+		closed:     make(chan struct{}),
+		history:    newHistory(HistorySize),
 	}
 }
 
+// Run numbers and dispatches the broadcast events until Close is called.
 func (s *Stream) Run() {
 	for {
 		select {
+		case <-s.closed:
+			s.mu.Lock()
+			for id, client := range s.clients {
+				client.stop()
+				delete(s.clients, id)
+			}
+			s.mu.Unlock()
+			return
+
 		case client := <-s.register:
 			s.mu.Lock()
+			client.start(s.replay(client))
 			s.clients[client.ID] = client
 			s.mu.Unlock()
-			go s.handleClient(client)
 
 		case client := <-s.unregister:
 			s.mu.Lock()
-			if _, ok := s.clients[client.ID]; ok {
+			if current, ok := s.clients[client.ID]; ok && current == client {
 				delete(s.clients, client.ID)
-				close(client.Send)
 			}
 			s.mu.Unlock()
+			client.stop()
 
 		case event := <-s.broadcast:
-			s.mu.RLock()
+			s.mu.Lock()
+			s.lastID++
+			event.ID = s.lastID
+			s.history.add(event)
 			for _, client := range s.clients {
-				select {
-				case client.Send <- event:
-				default:
-					close(client.Send)
-					delete(s.clients, client.ID)
-				}
+				client.offer(event)
 			}
-			s.mu.RUnlock()
+			s.mu.Unlock()
+		}
+	}
+}
 
-		case job := <-s.messageQueue:
-			go s.processMessageJob(job)
+// replay returns the buffered events a registering client resumes with,
+// preceded by a gap notice when some of them are no longer buffered.
+func (s *Stream) replay(client *Client) []Event {
+	after := client.LastEventID
+	if after == 0 || after == s.lastID {
+		return nil
+	}
+
+	var out []Event
+	oldest := s.history.oldestID()
+	switch {
+	case after > s.lastID:
+		// the client saw IDs of a previous run of the stream
+		out = append(out, gapNotice("reset", 0))
+	case oldest > 0 && after+1 < oldest:
+		out = append(out, gapNotice("history", oldest-after-1))
+	}
+	if after > s.lastID {
+		after = 0
+	}
+	for _, event := range s.history.since(after) {
+		if client.matches(event.Type) {
+			out = append(out, event)
 		}
 	}
+	return out
 }
 
 func (s *Stream) RegisterClient(client *Client) {
-	s.register <- client
+	select {
+	case s.register <- client:
+	case <-s.closed:
+		client.stop()
+	}
 }
 
 func (s *Stream) UnregisterClient(client *Client) {
-	s.unregister <- client
+	select {
+	case s.unregister <- client:
+	case <-s.closed:
+	}
 }
 
 func (s *Stream) Broadcast(event Event) {
 	event.Timestamp = time.Now()
-	s.broadcast <- event
+	select {
+	case s.broadcast <- event:
+	case <-s.closed:
+	}
 }
 
-func (s *Stream) ProcessMessage(job MessageProcessingJob) {
-	job.CreatedAt = time.Now()
-	s.messageQueue <- job
+// TryBroadcast queues an event without blocking. It reports false when the
+// broadcast buffer is full (or the stream is not running) and the event was dropped.
+func (s *Stream) TryBroadcast(event Event) bool {
+	event.Timestamp = time.Now()
+	select {
+	case <-s.closed:
+		return false
+	default:
+	}
+	select {
+	case s.broadcast <- event:
+		return true
+	default:
+		return false
+	}
 }
 
-func (s *Stream) handleClient(client *Client) {
-	defer func() {
-		s.UnregisterClient(client)
-		client.Conn.Close()
-	}()
+// SetPipeline makes ProcessMessage submit jobs to pipeline.
+func (s *Stream) SetPipeline(pipeline *Pipeline) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pipeline = pipeline
+}
 
-	for event := range client.Send {
-		client.Conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-		if err := client.Conn.WriteJSON(event); err != nil {
-			gl.Log("error", "WebSocket write error: "+err.Error())
-			return
-		}
+// ProcessMessage submits job to the pipeline set with SetPipeline, whose
+// progress is reported as message_processing_* events.
+func (s *Stream) ProcessMessage(job MessageProcessingJob) error {
+	s.mu.RLock()
+	pipeline := s.pipeline
+	s.mu.RUnlock()
+	if pipeline == nil {
+		return ErrNoPipeline
 	}
+	return pipeline.Submit(job)
 }
 
-func (s *Stream) processMessageJob(job MessageProcessingJob) {
-	// Notify frontend that processing started
-	s.Broadcast(Event{
-		Type: "message_processing_started",
-		Data: map[string]interface{}{
-			"job_id":   job.ID,
-			"platform": job.Platform,
-			"priority": job.Priority,
-		},
-	})
+// Stats returns the last event ID, the size of the history, the
+// backpressure of each client and the queue depth of the pipeline.
+func (s *Stream) Stats() StreamStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	stats := StreamStats{
+		LastEventID: s.lastID,
+		Buffered:    s.history.len(),
+		Clients:     make([]ClientStats, 0, len(s.clients)),
+	}
+	for _, client := range s.clients {
+		stats.Clients = append(stats.Clients, client.Stats())
+	}
+	if s.pipeline != nil {
+		pipelineStats := s.pipeline.Stats()
+		stats.Pipeline = &pipelineStats
+	}
+	return stats
+}
 
-	// Simulate processing time
-	time.Sleep(1 * time.Second)
-
-	// Notify processing completed
-	s.Broadcast(Event{
-		Type: "message_processing_completed",
-		Data: map[string]interface{}{
-			"job_id":   job.ID,
-			"platform": job.Platform,
-			"result":   "Mensagem processada com sucesso",
-		},
-	})
+// LastEventID returns the ID of the latest broadcast event.
+func (s *Stream) LastEventID() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastID
 }
 
+// Close stops the stream and disconnects its clients. It is safe to call
+// more than once.
 func (s *Stream) Close() {
-	close(s.broadcast)
-	close(s.messageQueue)
-	close(s.register)
-	close(s.unregister)
+	s.closeOnce.Do(func() {
+		close(s.closed)
+		gl.Log("info", "Event stream closed")
+	})
+}
+
+func gapNotice(reason string, missed uint64) Event {
+	data := map[string]interface{}{"reason": reason}
+	if missed > 0 {
+		data["missed"] = missed
+	}
+	return Event{Type: EventStreamGap, Data: data, Timestamp: time.Now()}
+}
+
+// history is the ring buffer of the latest events.
+type history struct {
+	events []Event
+	start  int
+	size   int
+}
+
+func newHistory(capacity int) *history {
+	return &history{events: make([]Event, capacity)}
+}
+
+func (h *history) add(event Event) {
+	if h.size < len(h.events) {
+		h.events[(h.start+h.size)%len(h.events)] = event
+		h.size++
+		return
+	}
+	h.events[h.start] = event
+	h.start = (h.start + 1) % len(h.events)
+}
+
+func (h *history) len() int { return h.size }
+
+func (h *history) oldestID() uint64 {
+	if h.size == 0 {
+		return 0
+	}
+	return h.events[h.start].ID
+}
+
+// since returns the buffered events with an ID above id, oldest first.
+func (h *history) since(id uint64) []Event {
+	var out []Event
+	for i := 0; i < h.size; i++ {
+		event := h.events[(h.start+i)%len(h.events)]
+		if event.ID > id {
+			out = append(out, event)
+		}
+	}
+	return out
 }
@@ -0,0 +1,164 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+)
+
+// SSEKeepAlive is the interval of the comments sent to idle SSE clients so
+// proxies do not close the connection.
+const SSEKeepAlive = 15 * time.Second
+
+const wsWriteTimeout = 10 * time.Second
+
+// ServeSSE streams the events of client as Server-Sent Events until the
+// request is done or the stream is closed. Each event carries its ID, so a
+// reconnecting EventSource resumes with the Last-Event-ID header.
+func (s *Stream) ServeSSE(w http.ResponseWriter, r *http.Request, client *Client) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, ": connected %s\n\n", client.ID)
+	flusher.Flush()
+
+	s.RegisterClient(client)
+	defer s.UnregisterClient(client)
+
+	keepAlive := time.NewTicker(SSEKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-client.Send:
+			if !ok {
+				return
+			}
+			if err := writeSSE(w, event); err != nil {
+				gl.Log("debug", "SSE client gone", client.ID, err)
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// writeSSE writes event as a default "message" event so EventSource.onmessage
+// sees every type; the type is part of the JSON data.
+func writeSSE(w http.ResponseWriter, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if event.ID > 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", event.ID); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+	return err
+}
+
+// controlMessage is what a WebSocket client sends to the stream.
+type controlMessage struct {
+	Type   string   `json:"type"`
+	Topics []string `json:"topics"`
+}
+
+// ServeWebSocket streams the events of client over conn until either side
+// closes. The client may send {"type":"subscribe"|"unsubscribe","topics":[...]}
+// to change its topics, {"type":"stats"} for its backpressure counters and
+// {"type":"ping"}.
+func (s *Stream) ServeWebSocket(conn *websocket.Conn, client *Client) {
+	var writeMu sync.Mutex
+	write := func(v interface{}) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		_ = conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		return conn.WriteJSON(v)
+	}
+
+	s.RegisterClient(client)
+	defer s.UnregisterClient(client)
+
+	if err := write(map[string]interface{}{
+		"type":          "connection",
+		"status":        "connected",
+		"client_id":     client.ID,
+		"topics":        client.CurrentTopics(),
+		"last_event_id": s.LastEventID(),
+		"message":       "WebSocket connected successfully",
+		"timestamp":     time.Now().Unix(),
+	}); err != nil {
+		return
+	}
+
+	go func() {
+		// a failed write or a closed stream ends the read loop too
+		defer conn.Close()
+		for event := range client.Send {
+			if err := write(event); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		messageType, message, err := conn.ReadMessage()
+		if err != nil {
+			gl.Log("info", fmt.Sprintf("WebSocket client %s disconnected: %v", client.ID, err))
+			return
+		}
+		if messageType != websocket.TextMessage {
+			continue
+		}
+
+		var msg controlMessage
+		if err := json.Unmarshal(message, &msg); err != nil {
+			_ = write(map[string]interface{}{"type": "error", "message": "invalid message"})
+			continue
+		}
+
+		switch msg.Type {
+		case "subscribe", "unsubscribe":
+			if msg.Type == "subscribe" {
+				err = client.AddTopics(msg.Topics)
+			} else {
+				client.RemoveTopics(msg.Topics)
+			}
+			if err != nil {
+				_ = write(map[string]interface{}{"type": "error", "message": err.Error()})
+				continue
+			}
+			_ = write(map[string]interface{}{"type": "subscribed", "topics": client.CurrentTopics()})
+		case "stats":
+			_ = write(map[string]interface{}{"type": "stats", "stats": client.Stats()})
+		case "ping":
+			_ = write(map[string]interface{}{"type": "pong", "timestamp": time.Now().Unix()})
+		case "test":
+			_ = write(map[string]interface{}{"type": "test_response", "message": "WebSocket is working!"})
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package hub
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kubex-ecosystem/gobe/internal/contracts/interfaces"
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+	"github.com/kubex-ecosystem/gobe/internal/observers/approval"
+	"github.com/kubex-ecosystem/gobe/internal/services/mcp"
+)
+
+// Actions held for approval; each one can have its own policy under
+// approval.policies.
+const (
+	ApprovalShellCommand = "shell_command"
+	ApprovalDeploy       = "deploy"
+	ApprovalScale        = "scale"
+)
+
+// registerApprovalHandlers posts the held actions with their buttons and
+// runs them once they are decided, including the ones restored after a
+// restart.
+func (h *DiscordMCPHub) registerApprovalHandlers() {
+	h.approvalManager.AddPresenter(&discordPresenter{adapter: h.discordAdapter})
+	h.approvalManager.Handle(ApprovalShellCommand, h.runApprovedShellCommand)
+	h.approvalManager.Handle(ApprovalDeploy, h.runApprovedGobeCommand)
+	h.approvalManager.Handle(ApprovalScale, h.runApprovedGobeCommand)
+}
+
+// ApproverFor returns the approver acting as principal, with the roles the
+// MCP policy bindings grant it.
+func (h *DiscordMCPHub) ApproverFor(principal *mcp.Principal) approval.Approver {
+	resolved := h.mcpPolicy.Resolve(principal)
+	approver := approval.Approver{ID: resolved.ID, Name: resolved.Username, Roles: resolved.Roles}
+	if approver.ID == "" {
+		approver.ID = resolved.Username
+	}
+	return approver
+}
+
+// holdForApproval files action for approval; the presenters post it with
+// the buttons deciding it. Details only hold strings so they survive the
+// store unchanged.
+func (h *DiscordMCPHub) holdForApproval(ctx context.Context, msg interfaces.Message, action string, details map[string]interface{}) error {
+	details["channel_id"] = msg.ChannelID
+	details["guild_id"] = msg.GuildID
+	details["username"] = msg.User.Username
+
+	req, err := h.approvalManager.Submit(ctx, approval.Request{
+		Action:      action,
+		Platform:    "discord",
+		RequestedBy: msg.User.ID,
+		Details:     details,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to request approval: %w", err)
+	}
+	gl.Log("info", fmt.Sprintf("⏳ %s de %s aguardando aprovação: %s", action, msg.User.Username, req.ID))
+	return nil
+}
+
+// discordPresenter posts requests to the channel they came from, with
+// approve and reject buttons answered on the interactions endpoint.
+type discordPresenter struct {
+	adapter interfaces.IAdapter
+}
+
+func (p *discordPresenter) Platform() string { return "discord" }
+
+func (p *discordPresenter) Present(ctx context.Context, req approval.Request) (string, error) {
+	channelID := approvalDetail(req, "channel_id")
+	if req.Platform != "discord" || channelID == "" {
+		return "", nil
+	}
+	interactive, ok := p.adapter.(interfaces.IInteractiveAdapter)
+	if !ok {
+		return "", p.adapter.SendMessage(channelID, approval.Message(req)+"\nDecida em /api/v1/discord/approve?id="+req.ID)
+	}
+	messageID, err := interactive.SendButtons(channelID, approval.Message(req), approvalButtons(req))
+	if err != nil || messageID == "" {
+		return "", err
+	}
+	return channelID + "/" + messageID, nil
+}
+
+func (p *discordPresenter) Update(ctx context.Context, ref string, req approval.Request) error {
+	interactive, ok := p.adapter.(interfaces.IInteractiveAdapter)
+	channelID, messageID, found := strings.Cut(ref, "/")
+	if !ok || !found {
+		return nil
+	}
+	return interactive.EditMessage(channelID, messageID, approval.Message(req), approvalButtons(req))
+}
+
+// approvalButtons decide a pending request; decided ones have none.
+func approvalButtons(req approval.Request) []interfaces.Button {
+	if req.Status != approval.StatusPending {
+		return nil
+	}
+	return []interfaces.Button{
+		{ID: approval.CallbackData(req.ID, true), Label: "✅ Aprovar", Style: "success"},
+		{ID: approval.CallbackData(req.ID, false), Label: "❌ Rejeitar", Style: "danger"},
+	}
+}
+
+func (h *DiscordMCPHub) runApprovedShellCommand(ctx context.Context, req approval.Request, resp approval.Response) {
+	channelID := approvalDetail(req, "channel_id")
+	if !resp.Approved {
+		h.reportNotApproved(channelID, req, resp)
+		return
+	}
+
+	// The tool still runs under the MCP policy of whoever asked for it
+	ctx = mcp.WithPrincipal(ctx, requesterPrincipal(req))
+	command := approvalDetail(req, "command")
+	output, err := h.executeMCPTool(ctx, "execute_shell_command", map[string]interface{}{
+		"command": command,
+		"user_id": req.RequestedBy,
+	})
+	if err != nil {
+		gl.Log("error", fmt.Sprintf("❌ Comando aprovado %s falhou", req.ID), err)
+		h.sendApprovalMessage(channelID, fmt.Sprintf("❌ Erro na execução de `%s`: %v", command, err))
+		return
+	}
+	h.sendApprovalMessage(channelID, fmt.Sprintf("🤖 **Comando aprovado por %s executado**\n\n%s",
+		strings.Join(resp.Approvers, ", "), output))
+}
+
+func (h *DiscordMCPHub) runApprovedGobeCommand(ctx context.Context, req approval.Request, resp approval.Response) {
+	channelID := approvalDetail(req, "channel_id")
+	if !resp.Approved {
+		h.reportNotApproved(channelID, req, resp)
+		return
+	}
+	if err := h.processGobeCommand(ctx, approvalDetail(req, "command"), approvalDetail(req, "params")); err != nil {
+		gl.Log("error", fmt.Sprintf("❌ Comando aprovado %s falhou", req.ID), err)
+		h.sendApprovalMessage(channelID, fmt.Sprintf("❌ Erro ao executar `%s`: %v", req.Action, err))
+	}
+}
+
+// reportNotApproved tells the channel when no edited message shows the
+// outcome.
+func (h *DiscordMCPHub) reportNotApproved(channelID string, req approval.Request, resp approval.Response) {
+	if req.Messages["discord"] != "" {
+		return
+	}
+	reason := "expirou sem aprovação"
+	if resp.Status == approval.StatusRejected {
+		reason = "foi rejeitado"
+		if resp.ApproverID != "" {
+			reason += " por " + resp.ApproverID
+		}
+	}
+	h.sendApprovalMessage(channelID, fmt.Sprintf("🚫 `%s` (%s) %s", req.Action, req.ID, reason))
+}
+
+func (h *DiscordMCPHub) sendApprovalMessage(channelID, content string) {
+	if channelID == "" {
+		return
+	}
+	if err := h.discordAdapter.SendMessage(channelID, content); err != nil {
+		gl.Log("warn", "Failed to post approval outcome", err)
+	}
+}
+
+func requesterPrincipal(req approval.Request) *mcp.Principal {
+	return &mcp.Principal{
+		ID:        req.RequestedBy,
+		Username:  approvalDetail(req, "username"),
+		Source:    req.Platform,
+		GuildID:   approvalDetail(req, "guild_id"),
+		ChannelID: approvalDetail(req, "channel_id"),
+	}
+}
+
+func approvalDetail(req approval.Request, key string) string {
+	value, _ := req.Details[key].(string)
+	return value
+}
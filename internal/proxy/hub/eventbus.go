@@ -0,0 +1,62 @@
+package hub
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kubex-ecosystem/gobe/internal/config"
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+	"github.com/kubex-ecosystem/gobe/internal/observers/events"
+	"github.com/kubex-ecosystem/gobe/internal/services/mcp"
+	"github.com/kubex-ecosystem/gobe/internal/sockets/zmq"
+)
+
+// DefaultBusPrincipal is who the commands of the event bus run as when
+// zmq.principal is unset.
+const DefaultBusPrincipal = "zmq"
+
+// newEventBus starts the ZeroMQ transport forwarding stream, or returns nil
+// when zmq is disabled or cannot bind; the hub works without it.
+func newEventBus(cfg config.ZMQConfig, stream *events.Stream) *zmq.Transport {
+	if !cfg.Enabled {
+		return nil
+	}
+	bus, err := zmq.NewTransport(zmq.OptionsFromConfig(cfg))
+	if err != nil {
+		gl.Log("error", "Failed to start the ZMQ event bus", err)
+		return nil
+	}
+	if err := bus.ForwardStream(stream); err != nil {
+		gl.Log("error", "Failed to forward the event stream to ZMQ", err)
+	}
+	gl.Log("info", fmt.Sprintf("📡 ZMQ event bus publishing on %s", bus.Endpoint()))
+	return bus
+}
+
+// GetEventBus returns the ZeroMQ transport, or nil when zmq is disabled.
+func (h *DiscordMCPHub) GetEventBus() *zmq.Transport {
+	return h.eventBus
+}
+
+// HandleBusCommands runs the tools asked on the event bus through submit,
+// as the principal named by zmq.principal, so the MCP policy applies to
+// them like to any other caller. Their progress comes back on the jobs
+// topics.
+func (h *DiscordMCPHub) HandleBusCommands(submit func(ctx context.Context, tool string, args map[string]interface{}) (mcp.Job, error)) {
+	if h.eventBus == nil {
+		return
+	}
+	name := h.config.ZMQ.Principal
+	if name == "" {
+		name = DefaultBusPrincipal
+	}
+	h.eventBus.HandleCommands(func(ctx context.Context, cmd zmq.Command) (interface{}, error) {
+		ctx = mcp.WithPrincipal(ctx, &mcp.Principal{ID: name, Username: name, Source: "zmq"})
+		job, err := submit(ctx, cmd.Tool, cmd.Args)
+		if err != nil {
+			return nil, err
+		}
+		gl.Log("info", fmt.Sprintf("ZMQ command %s started job %s", cmd.ID, job.ID))
+		return job, nil
+	})
+}
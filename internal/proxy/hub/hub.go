@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
 	"strings"
 	"sync"
 
@@ -19,6 +20,7 @@ import (
 	"github.com/kubex-ecosystem/gobe/internal/services/chatbot/discord"
 	"github.com/kubex-ecosystem/gobe/internal/services/llm"
 	"github.com/kubex-ecosystem/gobe/internal/services/mcp"
+	"github.com/kubex-ecosystem/gobe/internal/sockets/zmq"
 	"github.com/spf13/viper"
 )
 
@@ -28,13 +30,15 @@ type DiscordMCPHub struct {
 	llmClient       *llm.Client
 	approvalManager *approval.Manager
 	eventStream     *events.Stream
+	pipeline        *events.Pipeline
 	mcpServer       *mcp.Server
 	mcpRegistry     mcp.Registry // Registry MCP real
-	// zmqPublisher    *zmq.Publisher
-	gobeCtlClient *gobe_ctl.Client // ⚙️ K8s Integration
-	gobeClient    *gobe.Client     // 🔗 GoBE Integration
-	mu            sync.RWMutex
-	running       bool
+	mcpPolicy       *mcp.RulePolicy
+	eventBus        *zmq.Transport   // nil unless zmq.enabled
+	gobeCtlClient   *gobe_ctl.Client // ⚙️ K8s Integration
+	gobeClient      *gobe.Client     // 🔗 GoBE Integration
+	mu              sync.RWMutex
+	running         bool
 }
 
 func NewDiscordMCPHub(cfg *config.Config) (*DiscordMCPHub, error) {
@@ -57,8 +61,8 @@ func NewDiscordMCPHub(cfg *config.Config) (*DiscordMCPHub, error) {
 	// ✅ Approval System
 	approvalManager := approval.NewManager(cfg.Approval, eventStream)
 
-	// //  ZMQ Publisher
-	// zmqPublisher := zmq.NewPublisher(cfg.ZMQ)
+	// 📡 ZeroMQ event bus, alongside AMQP
+	eventBus := newEventBus(cfg.ZMQ, eventStream)
 
 	// 🔗 GoBE Integration
 	var gobeClient *gobe.Client
@@ -82,8 +86,12 @@ func NewDiscordMCPHub(cfg *config.Config) (*DiscordMCPHub, error) {
 		gl.Log("info", fmt.Sprintf("⚙️ gobe client initialized - Namespace: %s", cfg.GobeCtl.Namespace))
 	}
 
+	// 🔐 MCP authorization policy shared by every execution path
+	mcpPolicy := mcp.NewPolicy(cfg.MCP.Policy)
+
 	// 🔧 Initialize MCP Registry
 	mcpRegistry := mcp.NewRegistry()
+	mcpRegistry.SetPolicy(mcpPolicy)
 	// Register built-in tools
 	err = mcp.RegisterBuiltinTools(mcpRegistry)
 	if err != nil {
@@ -99,18 +107,46 @@ func NewDiscordMCPHub(cfg *config.Config) (*DiscordMCPHub, error) {
 		approvalManager: approvalManager,
 		eventStream:     eventStream,
 		mcpRegistry:     mcpRegistry,
-		// zmqPublisher:    zmqPublisher,
-		gobeCtlClient: gobeCtlClient,
-		gobeClient:    gobeClient,
+		mcpPolicy:       mcpPolicy,
+		eventBus:        eventBus,
+		gobeCtlClient:   gobeCtlClient,
+		gobeClient:      gobeClient,
 	}
 
+	// 📥 Message pipeline behind eventStream.ProcessMessage
+	hub.pipeline = events.NewPipeline(eventStream, events.PipelineOptionsFromConfig(cfg.Pipeline), hub.messageStages()...)
+	eventStream.SetPipeline(hub.pipeline)
+
+	// ⏳ Risky commands wait for their approval policy
+	hub.registerApprovalHandlers()
+
 	// 🔌 MCP Server (needs hub as handler)
 	mcpServer, err := mcp.NewServer(hub)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create MCP server: %w", err)
 	}
+	mcpServer.SetPolicy(mcpPolicy)
 	hub.mcpServer = mcpServer
 
+	// Advertise registry tools (with their JSON Schemas) to MCP clients
+	mcpServer.RegisterRegistryTools(mcpRegistry)
+
+	// 📄 Declarative tools from manifests, reloaded when the files change
+	if cfg.MCP.ToolsDir != "" {
+		loader := mcp.NewManifestLoader(os.ExpandEnv(cfg.MCP.ToolsDir), mcpRegistry, mcp.ManifestBackends{})
+		loader.OnChange(func(removed []string) {
+			mcpServer.SyncRegistryTools(mcpRegistry, removed)
+		})
+		if _, err := loader.Load(); err != nil {
+			gl.Log("warn", "Some MCP tool manifests were rejected", err)
+		}
+		go func() {
+			if err := loader.Watch(context.Background()); err != nil {
+				gl.Log("error", "MCP tool manifest watcher stopped", err)
+			}
+		}()
+	}
+
 	return hub, nil
 }
 
@@ -162,17 +198,6 @@ func (h *DiscordMCPHub) StartMCPServer() {
 }
 
 func (h *DiscordMCPHub) handleDiscordMessage(msg interfaces.Message) {
-	// Create processing job
-	job := events.MessageProcessingJob{
-		ID:       fmt.Sprintf("discord_%s_%d", msg.ChannelID, msg.Timestamp.Unix()),
-		Platform: "discord",
-		Message:  msg,
-		Priority: events.PriorityNormal,
-	}
-
-	// Send to event stream for processing
-	h.eventStream.ProcessMessage(job)
-
 	// Simple test commands
 	if strings.HasPrefix(msg.Content, "!ping") {
 		h.discordAdapter.SendMessage(msg.ChannelID, "🏓 Pong! Bot está funcionando!")
@@ -204,14 +229,8 @@ func (h *DiscordMCPHub) handleDiscordMessage(msg interfaces.Message) {
 		return
 	}
 
-	// For other messages, check intelligent triage first
-	shouldProcess, processType := h.intelligentTriage(msg)
-	if shouldProcess {
-		gl.Log("notice", fmt.Sprintf("🎯 Triagem detectou: %s - processando com LLM", processType))
-		h.ProcessMessageWithLLM(context.Background(), msg)
-	} else {
-		gl.Log("notice", fmt.Sprintf("⏭️ Mensagem ignorada pela triagem inteligente: %s", msg.Content))
-	}
+	// Other messages go through the pipeline: triage, LLM analysis and MCP tools
+	h.submitMessage(msg)
 }
 
 func (h *DiscordMCPHub) ProcessMessageWithLLM(ctx context.Context, iMsg interface{}) error {
@@ -359,17 +378,7 @@ func (h *DiscordMCPHub) processCommandMessage(ctx context.Context, msg interface
 func (h *DiscordMCPHub) processQuestionMessage(ctx context.Context, msg interfaces.Message) error {
 	gl.Log("notice", fmt.Sprintf("❓ Processando pergunta: %s", msg.Content))
 
-	// Analyze message with LLM
-	analysis, err := h.llmClient.AnalyzeMessage(ctx, llm.AnalysisRequest{
-		Platform: "discord",
-		Content:  msg.Content,
-		UserID:   msg.User.ID,
-		Context: map[string]interface{}{
-			"channel_id": msg.ChannelID,
-			"guild_id":   msg.GuildID,
-			"type":       "question",
-		},
-	})
+	analysis, err := h.analyzeMessage(ctx, msg, "question")
 	if err != nil {
 		gl.Log("error", fmt.Sprintf("❌ Erro na análise LLM: %v", err))
 		// Fallback para resposta simples
@@ -377,27 +386,14 @@ func (h *DiscordMCPHub) processQuestionMessage(ctx context.Context, msg interfac
 		return h.discordAdapter.SendMessage(msg.ChannelID, response)
 	}
 
-	if analysis.ShouldRespond {
-		response := fmt.Sprintf("💡 **Resposta à sua pergunta:**\n\n%s\n\n🔍 Confiança: %.0f%%", analysis.SuggestedResponse, analysis.Confidence*100)
-		return h.discordAdapter.SendMessage(msg.ChannelID, response)
-	}
-
-	return nil
+	_, err = h.replyToAnalysis(msg, "question", analysis)
+	return err
 }
 
 func (h *DiscordMCPHub) processTaskMessage(ctx context.Context, msg interfaces.Message) error {
 	gl.Log("notice", fmt.Sprintf("📋 Processando solicitação de tarefa: %s", msg.Content))
 
-	analysis, err := h.llmClient.AnalyzeMessage(ctx, llm.AnalysisRequest{
-		Platform: "discord",
-		Content:  msg.Content,
-		UserID:   msg.User.ID,
-		Context: map[string]interface{}{
-			"channel_id": msg.ChannelID,
-			"guild_id":   msg.GuildID,
-			"type":       "task_request",
-		},
-	})
+	analysis, err := h.analyzeMessage(ctx, msg, "task_request")
 	if err != nil {
 		gl.Log("error", "❌ Erro na análise LLM: %v", err)
 		// Fallback para criação simples de tarefa
@@ -406,29 +402,14 @@ func (h *DiscordMCPHub) processTaskMessage(ctx context.Context, msg interfaces.M
 		return h.discordAdapter.SendMessage(msg.ChannelID, response)
 	}
 
-	if analysis.ShouldCreateTask {
-		h.createTaskFromMessage(msg, analysis)
-		response := fmt.Sprintf("📋 **Tarefa criada com sucesso!**\n\n📌 **Título:** %s\n📝 **Descrição:** %s\n🏷️ **Tags:** %v\n👤 **Criado por:** %s",
-			analysis.TaskTitle, analysis.TaskDescription, analysis.TaskTags, msg.User.Username)
-		return h.discordAdapter.SendMessage(msg.ChannelID, response)
-	}
-
-	return nil
+	_, err = h.replyToAnalysis(msg, "task_request", analysis)
+	return err
 }
 
 func (h *DiscordMCPHub) processAnalysisMessage(ctx context.Context, msg interfaces.Message) error {
 	gl.Log("notice", fmt.Sprintf("🔍 Processando pedido de análise: %s", msg.Content))
 
-	analysis, err := h.llmClient.AnalyzeMessage(ctx, llm.AnalysisRequest{
-		Platform: "discord",
-		Content:  msg.Content,
-		UserID:   msg.User.ID,
-		Context: map[string]interface{}{
-			"channel_id": msg.ChannelID,
-			"guild_id":   msg.GuildID,
-			"type":       "analysis",
-		},
-	})
+	analysis, err := h.analyzeMessage(ctx, msg, "analysis")
 	if err != nil {
 		gl.Log("error", fmt.Sprintf("❌ Erro na análise LLM: %v", err))
 		// Fallback para análise simples
@@ -437,28 +418,14 @@ func (h *DiscordMCPHub) processAnalysisMessage(ctx context.Context, msg interfac
 		return h.discordAdapter.SendMessage(msg.ChannelID, response)
 	}
 
-	if analysis.ShouldRespond {
-		response := fmt.Sprintf("🔍 **Análise completa:**\n\n%s\n\n📊 Detalhes técnicos:\n• Confiança: %.0f%%\n• Processado em: %s",
-			analysis.SuggestedResponse, analysis.Confidence*100, msg.Timestamp.Format("15:04:05"))
-		return h.discordAdapter.SendMessage(msg.ChannelID, response)
-	}
-
-	return nil
+	_, err = h.replyToAnalysis(msg, "analysis", analysis)
+	return err
 }
 
 func (h *DiscordMCPHub) processCasualMessage(ctx context.Context, msg interfaces.Message) error {
 	gl.Log("notice", fmt.Sprintf("💬 Processando mensagem casual: %s", msg.Content))
 
-	analysis, err := h.llmClient.AnalyzeMessage(ctx, llm.AnalysisRequest{
-		Platform: "discord",
-		Content:  msg.Content,
-		UserID:   msg.User.ID,
-		Context: map[string]interface{}{
-			"channel_id": msg.ChannelID,
-			"guild_id":   msg.GuildID,
-			"type":       "casual",
-		},
-	})
+	analysis, err := h.analyzeMessage(ctx, msg, "casual")
 	if err != nil {
 		gl.Log("error", fmt.Sprintf("❌ Erro na análise LLM: %v", err))
 		// Fallback para resposta casual
@@ -474,11 +441,53 @@ func (h *DiscordMCPHub) processCasualMessage(ctx context.Context, msg interfaces
 		return h.discordAdapter.SendMessage(msg.ChannelID, response)
 	}
 
-	if analysis.ShouldRespond {
-		return h.discordAdapter.SendMessage(msg.ChannelID, analysis.SuggestedResponse)
-	}
+	_, err = h.replyToAnalysis(msg, "casual", analysis)
+	return err
+}
 
-	return nil
+// analyzeMessage asks the LLM about msg, telling it how triage classified it.
+func (h *DiscordMCPHub) analyzeMessage(ctx context.Context, msg interfaces.Message, processType string) (*llm.AnalysisResponse, error) {
+	return h.llmClient.AnalyzeMessage(ctx, llm.AnalysisRequest{
+		Platform: "discord",
+		Content:  msg.Content,
+		UserID:   msg.User.ID,
+		Context: map[string]interface{}{
+			"channel_id": msg.ChannelID,
+			"guild_id":   msg.GuildID,
+			"type":       processType,
+		},
+	})
+}
+
+// replyToAnalysis posts the answer the analysis suggests for msg, creating
+// the task it asks for. It reports whether a message was sent.
+func (h *DiscordMCPHub) replyToAnalysis(msg interfaces.Message, processType string, analysis *llm.AnalysisResponse) (bool, error) {
+	var response string
+	switch processType {
+	case "question":
+		if analysis.ShouldRespond {
+			response = fmt.Sprintf("💡 **Resposta à sua pergunta:**\n\n%s\n\n🔍 Confiança: %.0f%%", analysis.SuggestedResponse, analysis.Confidence*100)
+		}
+	case "task_request":
+		if analysis.ShouldCreateTask {
+			h.createTaskFromMessage(msg, analysis)
+			response = fmt.Sprintf("📋 **Tarefa criada com sucesso!**\n\n📌 **Título:** %s\n📝 **Descrição:** %s\n🏷️ **Tags:** %v\n👤 **Criado por:** %s",
+				analysis.TaskTitle, analysis.TaskDescription, analysis.TaskTags, msg.User.Username)
+		}
+	case "analysis":
+		if analysis.ShouldRespond {
+			response = fmt.Sprintf("🔍 **Análise completa:**\n\n%s\n\n📊 Detalhes técnicos:\n• Confiança: %.0f%%\n• Processado em: %s",
+				analysis.SuggestedResponse, analysis.Confidence*100, msg.Timestamp.Format("15:04:05"))
+		}
+	default:
+		if analysis.ShouldRespond {
+			response = analysis.SuggestedResponse
+		}
+	}
+	if response == "" {
+		return false, nil
+	}
+	return true, h.discordAdapter.SendMessage(msg.ChannelID, response)
 }
 
 func (h *DiscordMCPHub) createTaskFromMessage(msg interfaces.Message, analysis *llm.AnalysisResponse) {
@@ -493,10 +502,7 @@ func (h *DiscordMCPHub) createTaskFromMessage(msg interfaces.Message, analysis *
 		"tags":        analysis.TaskTags,
 	}
 
-	// Publish task creation to ZMQ
-	// h.zmqPublisher.PublishMessage("task.create", task)
-
-	// Notify frontend
+	// Notify frontend (and the ZMQ bus, which forwards the stream)
 	h.eventStream.Broadcast(events.Event{
 		Type: "task_created",
 		Data: task,
@@ -504,6 +510,21 @@ func (h *DiscordMCPHub) createTaskFromMessage(msg interfaces.Message, analysis *
 }
 
 func (h *DiscordMCPHub) processSystemCommandMessage(ctx context.Context, msg interfaces.Message) error {
+	_, err := h.runSystemCommand(ctx, msg)
+	return err
+}
+
+// systemCommandResult reports the MCP tool a system command ran. Error is
+// the tool failure, already posted to the channel.
+type systemCommandResult struct {
+	Tool   string `json:"tool,omitempty"`
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// runSystemCommand runs the MCP tool a system command message asks for and
+// posts its result. The result is nil for commands answered otherwise.
+func (h *DiscordMCPHub) runSystemCommand(ctx context.Context, msg interfaces.Message) (*systemCommandResult, error) {
 	gl.Log("notice", fmt.Sprintf("🔧 Processando comando de sistema: %s", msg.Content))
 
 	content := strings.ToLower(msg.Content)
@@ -526,11 +547,11 @@ func (h *DiscordMCPHub) processSystemCommandMessage(ctx context.Context, msg int
 	if h.gobeClient != nil {
 		switch {
 		case strings.Contains(content, "deploy") && strings.Contains(content, "app"):
-			return h.handleDeployCommand(ctx, msg)
+			return nil, h.handleDeployCommand(ctx, msg)
 		case strings.Contains(content, "scale") && (strings.Contains(content, "deployment") || strings.Contains(content, "pod")):
-			return h.handleScaleCommand(ctx, msg)
+			return nil, h.handleScaleCommand(ctx, msg)
 		case strings.Contains(content, "cluster info") || strings.Contains(content, "info do cluster"):
-			return h.processGobeCommand(ctx, "cluster_info", "{}")
+			return nil, h.processGobeCommand(ctx, "cluster_info", "{}")
 		}
 	}
 
@@ -558,30 +579,36 @@ func (h *DiscordMCPHub) processSystemCommandMessage(ctx context.Context, msg int
 		// Extrair comando shell da mensagem
 		shellCmd := h.extractShellCommand(msg.Content)
 		if shellCmd == "" {
-			return h.discordAdapter.SendMessage(channelID, "❌ Comando não encontrado. Use: 'executar [comando]'")
+			return nil, h.discordAdapter.SendMessage(channelID, "❌ Comando não encontrado. Use: 'executar [comando]'")
+		}
+		if h.isRiskyCommand(shellCmd) {
+			return nil, h.holdForApproval(ctx, msg, ApprovalShellCommand, map[string]interface{}{"command": shellCmd})
 		}
 		mcpCommand = "execute_shell_command"
 		params = map[string]interface{}{
-			"command":              shellCmd,
-			"user_id":              userID,
-			"require_confirmation": h.isRiskyCommand(shellCmd),
+			"command": shellCmd,
+			"user_id": userID,
 		}
 
 	default:
 		// Se não conseguir detectar comando específico, usar LLM para interpretar
-		return h.processWithLLMForSystemCommand(ctx, msg)
+		return nil, h.processWithLLMForSystemCommand(ctx, msg)
 	}
 
-	// Executar comando via MCP Server
-	result, err := h.executeMCPTool(ctx, mcpCommand, params)
+	// Executar comando via MCP Server, identificando o autor para a política MCP
+	ctx = mcp.WithPrincipal(ctx, principalFromMessage(msg))
+	result := &systemCommandResult{Tool: h.mapDiscordToMCPTool(mcpCommand)}
+	output, err := h.executeMCPTool(ctx, mcpCommand, params)
 	if err != nil {
 		gl.Log("error", "❌ Erro ao executar comando MCP: %v", err)
-		return h.discordAdapter.SendMessage(channelID, fmt.Sprintf("❌ Erro na execução: %v", err))
+		result.Error = err.Error()
+		return result, h.discordAdapter.SendMessage(channelID, fmt.Sprintf("❌ Erro na execução: %v", err))
 	}
+	result.Output = output
 
 	// Enviar resultado para Discord
-	response := fmt.Sprintf("🤖 **Comando executado por %s**\n\n%s", msg.User.Username, result)
-	return h.discordAdapter.SendMessage(channelID, response)
+	response := fmt.Sprintf("🤖 **Comando executado por %s**\n\n%s", msg.User.Username, output)
+	return result, h.discordAdapter.SendMessage(channelID, response)
 }
 
 func (h *DiscordMCPHub) extractShellCommand(content string) string {
@@ -829,6 +856,11 @@ func (h *DiscordMCPHub) executeMCPTool(ctx context.Context, toolName string, par
 			// Convert result to Discord-friendly string
 			return h.formatMCPResultForDiscord(mcpToolName, result)
 		}
+		// Never fall back to the legacy implementation when the policy refused the call
+		var authErr *mcp.AuthorizationError
+		if errors.As(err, &authErr) {
+			return "", fmt.Errorf("❌ ACESSO NEGADO: %s", authErr.Reason)
+		}
 		gl.Log("warn", fmt.Sprintf("MCP tool execution failed, falling back to legacy implementation: %s", toolName), err)
 	}
 
@@ -983,6 +1015,17 @@ func (h *DiscordMCPHub) isUserAuthorized(userID string) bool {
 	return false
 }
 
+// principalFromMessage builds the MCP policy identity of a chat message author.
+func principalFromMessage(msg interfaces.Message) *mcp.Principal {
+	return &mcp.Principal{
+		ID:        msg.User.ID,
+		Username:  msg.User.Username,
+		Source:    "discord",
+		GuildID:   msg.GuildID,
+		ChannelID: msg.ChannelID,
+	}
+}
+
 func (h *DiscordMCPHub) GetEventStream() *events.Stream {
 	return h.eventStream
 }
@@ -1004,8 +1047,12 @@ func (h *DiscordMCPHub) Shutdown(ctx context.Context) error {
 	}
 
 	h.discordAdapter.Disconnect()
+	h.pipeline.Close()
+	h.approvalManager.Close()
 	h.eventStream.Close()
-	// h.zmqPublisher.Close()
+	if h.eventBus != nil {
+		h.eventBus.Close()
+	}
 	h.running = false
 
 	gl.Log("info", "Discord MCP Hub shutdown complete")
@@ -1175,7 +1222,7 @@ func (h *DiscordMCPHub) handleDeployCommand(ctx context.Context, msg interfaces.
 	params := fmt.Sprintf(`{"app_name": "%s", "version": "%s", "image": "%s", "values": {}}`,
 		appName, version, image)
 
-	return h.processGobeCommand(ctx, "deploy_app", params)
+	return h.holdForApproval(ctx, msg, ApprovalDeploy, map[string]interface{}{"command": "deploy_app", "params": params})
 }
 
 func (h *DiscordMCPHub) handleScaleCommand(ctx context.Context, msg interfaces.Message) error {
@@ -1209,5 +1256,5 @@ func (h *DiscordMCPHub) handleScaleCommand(ctx context.Context, msg interfaces.M
 	// Create JSON params for gobe
 	params := fmt.Sprintf(`{"app_name": "%s", "replicas": %d}`, appName, replicas)
 
-	return h.processGobeCommand(ctx, "scale_deployment", params)
+	return h.holdForApproval(ctx, msg, ApprovalScale, map[string]interface{}{"command": "scale_deployment", "params": params})
 }
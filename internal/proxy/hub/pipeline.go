@@ -0,0 +1,141 @@
+package hub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kubex-ecosystem/gobe/internal/contracts/interfaces"
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+	"github.com/kubex-ecosystem/gobe/internal/observers/events"
+	"github.com/kubex-ecosystem/gobe/internal/services/llm"
+)
+
+// screening is the output of the first stage: how triage classified the
+// message.
+type screening struct {
+	ProcessType string `json:"process_type"`
+}
+
+// messageStages is the chain chat messages go through: triage decides
+// whether the hub answers, the LLM analyzes what is asked and the action
+// stage runs the MCP tool or posts the suggested reply.
+func (h *DiscordMCPHub) messageStages() []events.Stage {
+	return []events.Stage{
+		{Name: "screening", Run: h.screenMessage},
+		{Name: "analysis", Run: h.analyzeJob},
+		{Name: "action", Run: h.actOnJob},
+	}
+}
+
+// submitMessage queues msg on the message pipeline.
+func (h *DiscordMCPHub) submitMessage(msg interfaces.Message) {
+	job := events.MessageProcessingJob{
+		Platform: "discord",
+		Message:  msg,
+		Priority: messagePriority(msg),
+	}
+	if msg.ID != "" {
+		job.ID = "discord_" + msg.ID
+	}
+	if err := h.eventStream.ProcessMessage(job); err != nil {
+		gl.Log("warn", fmt.Sprintf("⚠️ Mensagem não enfileirada: %s", msg.Content), err)
+	}
+}
+
+// messagePriority puts messages asking for urgency first, then direct
+// messages, then channel chatter.
+func messagePriority(msg interfaces.Message) events.Priority {
+	content := strings.ToLower(msg.Content)
+	switch {
+	case strings.Contains(content, "urgent"):
+		return events.PriorityUrgent
+	case msg.GuildID == "":
+		return events.PriorityHigh
+	default:
+		return events.PriorityNormal
+	}
+}
+
+func jobMessage(job events.MessageProcessingJob) (interfaces.Message, error) {
+	msg, ok := job.Message.(interfaces.Message)
+	if !ok {
+		return msg, events.Permanent(fmt.Errorf("unsupported message type %T", job.Message))
+	}
+	return msg, nil
+}
+
+func jobScreening(result *events.JobResult) screening {
+	screen, _ := result.Outputs["screening"].(screening)
+	return screen
+}
+
+func (h *DiscordMCPHub) screenMessage(ctx context.Context, job events.MessageProcessingJob, result *events.JobResult) (interface{}, error) {
+	msg, err := jobMessage(job)
+	if err != nil {
+		return nil, err
+	}
+	shouldProcess, processType := h.intelligentTriage(msg)
+	switch {
+	case !shouldProcess:
+		result.Stop("triage: no answer needed")
+	case processType == "command":
+		// Commands are answered as soon as they arrive
+		result.Stop("triage: command")
+	}
+	return screening{ProcessType: processType}, nil
+}
+
+func (h *DiscordMCPHub) analyzeJob(ctx context.Context, job events.MessageProcessingJob, result *events.JobResult) (interface{}, error) {
+	screen := jobScreening(result)
+	if screen.ProcessType == "system_command" {
+		// The tool is picked from the message itself
+		return nil, nil
+	}
+	if h.llmClient == nil {
+		return nil, events.Permanent(errors.New("LLM client not initialized"))
+	}
+	msg, err := jobMessage(job)
+	if err != nil {
+		return nil, err
+	}
+	return h.analyzeMessage(ctx, msg, screen.ProcessType)
+}
+
+// actOnJob posts to the channel, which is not idempotent: its failures are
+// permanent so a retry never answers twice.
+func (h *DiscordMCPHub) actOnJob(ctx context.Context, job events.MessageProcessingJob, result *events.JobResult) (interface{}, error) {
+	msg, err := jobMessage(job)
+	if err != nil {
+		return nil, err
+	}
+	screen := jobScreening(result)
+
+	if screen.ProcessType == "system_command" {
+		command, err := h.runSystemCommand(ctx, msg)
+		if err != nil {
+			return command, events.Permanent(err)
+		}
+		if command == nil {
+			return nil, nil
+		}
+		if command.Error != "" {
+			return command, events.Permanent(errors.New(command.Error))
+		}
+		return command, nil
+	}
+
+	analysis, ok := result.Outputs["analysis"].(*llm.AnalysisResponse)
+	if !ok || analysis == nil {
+		return nil, events.Permanent(errors.New("no analysis to act on"))
+	}
+	replied, err := h.replyToAnalysis(msg, screen.ProcessType, analysis)
+	if err != nil {
+		return nil, events.Permanent(err)
+	}
+	return map[string]interface{}{
+		"replied":      replied,
+		"task_created": screen.ProcessType == "task_request" && analysis.ShouldCreateTask,
+	}, nil
+}
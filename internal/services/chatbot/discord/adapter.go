@@ -19,6 +19,8 @@ type Adapter struct {
 	messageHandler atomic.Value // func(interfaces.Message)
 }
 
+var _ interfaces.IInteractiveAdapter = (*Adapter)(nil)
+
 func NewAdapter(cfg config.DiscordConfig, purpose string) (interfaces.IAdapter, error) {
 	// dev mode: no session
 	if cfg.Bot.Token == "dev_token" {
@@ -80,6 +82,60 @@ func (a *Adapter) SendMessage(channelID, content string, opts ...interfaces.Send
 	return nil
 }
 
+func (a *Adapter) SendButtons(channelID, content string, buttons []interfaces.Button) (string, error) {
+	if a.session == nil {
+		gl.Log("info", fmt.Sprintf("Dev mode - would send to %s with %d buttons: %s", channelID, len(buttons), content))
+		return "", nil
+	}
+	msg, err := a.session.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content:    content,
+		Components: actionRow(buttons),
+	})
+	if err != nil {
+		gl.Log("error", fmt.Sprintf("send message: %v", err))
+		return "", err
+	}
+	return msg.ID, nil
+}
+
+func (a *Adapter) EditMessage(channelID, messageID, content string, buttons []interfaces.Button) error {
+	if a.session == nil {
+		gl.Log("info", fmt.Sprintf("Dev mode - would edit %s/%s: %s", channelID, messageID, content))
+		return nil
+	}
+	components := actionRow(buttons)
+	_, err := a.session.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:         messageID,
+		Channel:    channelID,
+		Content:    &content,
+		Components: &components,
+	})
+	if err != nil {
+		gl.Log("error", fmt.Sprintf("edit message: %v", err))
+		return err
+	}
+	return nil
+}
+
+// actionRow lays buttons out on a single row; Discord allows five per row.
+func actionRow(buttons []interfaces.Button) []discordgo.MessageComponent {
+	if len(buttons) == 0 {
+		return []discordgo.MessageComponent{}
+	}
+	row := discordgo.ActionsRow{}
+	for _, button := range buttons {
+		style := discordgo.PrimaryButton
+		switch button.Style {
+		case "success":
+			style = discordgo.SuccessButton
+		case "danger":
+			style = discordgo.DangerButton
+		}
+		row.Components = append(row.Components, discordgo.Button{Label: button.Label, Style: style, CustomID: button.ID})
+	}
+	return []discordgo.MessageComponent{row}
+}
+
 func (a *Adapter) GetChannels(guildID string) ([]interfaces.Channel, error) {
 	if a.session == nil {
 		return []interfaces.Channel{
@@ -0,0 +1,63 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kubex-ecosystem/gobe/internal/observers/approval"
+)
+
+// ApprovalPresenter posts approval requests to a chat with an inline
+// keyboard; clicks come back as callback queries on the webhook.
+type ApprovalPresenter struct {
+	service *Service
+	chatID  int64
+}
+
+// NewApprovalPresenter presents requests in chatID.
+func NewApprovalPresenter(service *Service, chatID int64) *ApprovalPresenter {
+	return &ApprovalPresenter{service: service, chatID: chatID}
+}
+
+func (p *ApprovalPresenter) Platform() string { return "telegram" }
+
+func (p *ApprovalPresenter) Present(ctx context.Context, req approval.Request) (string, error) {
+	messageID, err := p.service.Send(OutgoingMessage{
+		ChatID:   p.chatID,
+		Text:     approval.Message(req),
+		Keyboard: ApprovalKeyboard(req),
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d:%d", p.chatID, messageID), nil
+}
+
+func (p *ApprovalPresenter) Update(ctx context.Context, ref string, req approval.Request) error {
+	chat, message, found := strings.Cut(ref, ":")
+	if !found {
+		return fmt.Errorf("invalid telegram message reference %q", ref)
+	}
+	chatID, err := strconv.ParseInt(chat, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid telegram message reference %q", ref)
+	}
+	messageID, err := strconv.ParseInt(message, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid telegram message reference %q", ref)
+	}
+	return p.service.EditMessageText(chatID, messageID, approval.Message(req), ApprovalKeyboard(req))
+}
+
+// ApprovalKeyboard decides a pending request; decided ones have none.
+func ApprovalKeyboard(req approval.Request) [][]InlineButton {
+	if req.Status != approval.StatusPending {
+		return nil
+	}
+	return [][]InlineButton{{
+		{Text: "✅ Aprovar", CallbackData: approval.CallbackData(req.ID, true)},
+		{Text: "❌ Rejeitar", CallbackData: approval.CallbackData(req.ID, false)},
+	}}
+}
@@ -5,10 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/kubex-ecosystem/gobe/internal/config"
 )
 
+// DefaultAPIURL is the Bot API used when the configuration sets none.
+const DefaultAPIURL = "https://api.telegram.org"
+
 // Service interacts with Telegram Bot API.
 type Service struct {
 	cfg    config.TelegramConfig
@@ -27,22 +31,91 @@ func (s *Service) Config() config.TelegramConfig { return s.cfg }
 type OutgoingMessage struct {
 	ChatID int64  `json:"chat_id"`
 	Text   string `json:"text"`
+	// Keyboard is shown under the message, one slice per row.
+	Keyboard [][]InlineButton `json:"-"`
+}
+
+// InlineButton is a button of an inline keyboard; CallbackData comes back
+// in the callback query of the click.
+type InlineButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data"`
+}
+
+type inlineKeyboard struct {
+	InlineKeyboard [][]InlineButton `json:"inline_keyboard"`
+}
+
+// apiResponse is the envelope of every Bot API answer.
+type apiResponse struct {
+	OK          bool            `json:"ok"`
+	Description string          `json:"description"`
+	Result      json.RawMessage `json:"result"`
 }
 
 // SendMessage sends a message using Telegram Bot API.
 func (s *Service) SendMessage(msg OutgoingMessage) error {
-	if !s.cfg.Enabled {
-		return fmt.Errorf("telegram integration disabled")
-	}
+	_, err := s.Send(msg)
+	return err
+}
+
+// Send sends msg and returns the ID Telegram gave it.
+func (s *Service) Send(msg OutgoingMessage) (int64, error) {
 	body := map[string]any{
 		"chat_id": msg.ChatID,
 		"text":    msg.Text,
 	}
+	if len(msg.Keyboard) > 0 {
+		body["reply_markup"] = inlineKeyboard{InlineKeyboard: msg.Keyboard}
+	}
+	var sent struct {
+		MessageID int64 `json:"message_id"`
+	}
+	if err := s.call("sendMessage", body, &sent); err != nil {
+		return 0, err
+	}
+	return sent.MessageID, nil
+}
+
+// EditMessageText replaces the text and keyboard of a message the bot
+// sent; an empty keyboard removes it.
+func (s *Service) EditMessageText(chatID, messageID int64, text string, keyboard [][]InlineButton) error {
+	body := map[string]any{
+		"chat_id":      chatID,
+		"message_id":   messageID,
+		"text":         text,
+		"reply_markup": inlineKeyboard{InlineKeyboard: keyboard},
+	}
+	if keyboard == nil {
+		body["reply_markup"] = inlineKeyboard{InlineKeyboard: [][]InlineButton{}}
+	}
+	return s.call("editMessageText", body, nil)
+}
+
+// AnswerCallbackQuery stops the loading indicator of a clicked button,
+// showing text to whoever clicked it.
+func (s *Service) AnswerCallbackQuery(callbackQueryID, text string) error {
+	return s.call("answerCallbackQuery", map[string]any{
+		"callback_query_id": callbackQueryID,
+		"text":              text,
+	}, nil)
+}
+
+// call posts body to a Bot API method and decodes its result into result
+// when not nil.
+func (s *Service) call(method string, body map[string]any, result any) error {
+	if !s.cfg.Enabled {
+		return fmt.Errorf("telegram integration disabled")
+	}
 	b, err := json.Marshal(body)
 	if err != nil {
 		return err
 	}
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", s.cfg.BotToken)
+	apiURL := strings.TrimRight(s.cfg.APIURL, "/")
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	url := fmt.Sprintf("%s/bot%s/%s", apiURL, s.cfg.BotToken, method)
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(b))
 	if err != nil {
 		return err
@@ -53,8 +126,20 @@ func (s *Service) SendMessage(msg OutgoingMessage) error {
 		return err
 	}
 	defer resp.Body.Close()
-	if resp.StatusCode >= 400 {
-		return fmt.Errorf("telegram send failed: %s", resp.Status)
+
+	var answer apiResponse
+	decodeErr := json.NewDecoder(resp.Body).Decode(&answer)
+	if resp.StatusCode >= 400 || (decodeErr == nil && !answer.OK) {
+		if answer.Description != "" {
+			return fmt.Errorf("telegram %s failed: %s: %s", method, resp.Status, answer.Description)
+		}
+		return fmt.Errorf("telegram %s failed: %s", method, resp.Status)
+	}
+	if result == nil {
+		return nil
+	}
+	if decodeErr != nil {
+		return fmt.Errorf("telegram %s: %w", method, decodeErr)
 	}
-	return nil
+	return json.Unmarshal(answer.Result, result)
 }
@@ -0,0 +1,293 @@
+// Package cache keeps the answers of deterministic gateway chats so repeated
+// requests are served without calling the provider again.
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kubex-ecosystem/gobe/internal/config"
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+	gateway "github.com/kubex-ecosystem/gobe/internal/services/gateway"
+)
+
+// DefaultTTL is how long an answer is served when neither the configuration
+// nor the request sets a max-age.
+const DefaultTTL = 10 * time.Minute
+
+// Outcomes reported through the request Directive and the X-Cache header.
+const (
+	StatusHit    = "HIT"
+	StatusMiss   = "MISS"
+	StatusBypass = "BYPASS"
+)
+
+// Entry is a cached answer: the chunks streamed by the provider that
+// answered, replayed as-is on a hit.
+type Entry struct {
+	Provider string              `json:"provider"`
+	Model    string              `json:"model"`
+	Chunks   []gateway.ChatChunk `json:"chunks"`
+	StoredAt time.Time           `json:"stored_at"`
+}
+
+// Stats are the hit/miss counters of a Cache.
+type Stats struct {
+	Backend  string  `json:"backend"`
+	Hits     int64   `json:"hits"`
+	Misses   int64   `json:"misses"`
+	Bypassed int64   `json:"bypassed"`
+	Stores   int64   `json:"stores"`
+	Errors   int64   `json:"errors"`
+	HitRatio float64 `json:"hit_ratio"`
+}
+
+// Cache serves repeated chats from a Backend. A chat is cacheable when its
+// temperature is 0, its metadata sets "cache": true or the client sent a
+// Cache-Control max-age; chats with "cache": false never are.
+type Cache struct {
+	backend Backend
+	ttl     time.Duration
+	now     func() time.Time
+
+	hits, misses, bypassed, stores, errors atomic.Int64
+}
+
+// New returns a cache storing answers in backend for ttl (DefaultTTL when
+// ttl is not positive).
+func New(backend Backend, ttl time.Duration) *Cache {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Cache{backend: backend, ttl: ttl, now: time.Now}
+}
+
+// Key identifies req by provider, model, messages, temperature and tools.
+func Key(req gateway.ChatRequest) string {
+	canonical, _ := json.Marshal(struct {
+		Provider    string            `json:"provider"`
+		Model       string            `json:"model"`
+		Messages    []gateway.Message `json:"messages"`
+		Temperature float32           `json:"temperature"`
+		Tools       []gateway.Tool    `json:"tools,omitempty"`
+	}{req.Provider, req.Model, req.Messages, req.Temperature, req.Tools})
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:])
+}
+
+// Cacheable tells whether req may be served from and stored in the cache,
+// counting and reporting a bypass when it may not.
+func (c *Cache) Cacheable(ctx context.Context, req gateway.ChatRequest) bool {
+	directive := DirectiveFrom(ctx)
+	optIn, set := req.Meta["cache"].(bool)
+	switch {
+	case directive.NoStore, set && !optIn:
+	case req.Temperature == 0, optIn, directive.MaxAge > 0:
+		return true
+	}
+	c.bypassed.Add(1)
+	directive.report(StatusBypass, 0)
+	return false
+}
+
+// Lookup returns the cached answer of req. Clients sending
+// Cache-Control: no-cache skip the lookup but still refresh the entry.
+func (c *Cache) Lookup(ctx context.Context, req gateway.ChatRequest) (*Entry, bool) {
+	directive := DirectiveFrom(ctx)
+	if directive.NoCache {
+		c.misses.Add(1)
+		directive.report(StatusMiss, 0)
+		return nil, false
+	}
+
+	raw, ok, err := c.backend.Get(ctx, Key(req))
+	if err != nil {
+		c.errors.Add(1)
+		gl.Log("warn", "gateway cache lookup failed", err)
+	}
+	var entry Entry
+	if ok && err == nil {
+		if err = json.Unmarshal(raw, &entry); err != nil {
+			c.errors.Add(1)
+			gl.Log("warn", "dropping undecodable gateway cache entry", err)
+		}
+	}
+	if !ok || err != nil {
+		c.misses.Add(1)
+		directive.report(StatusMiss, 0)
+		return nil, false
+	}
+
+	c.hits.Add(1)
+	directive.report(StatusHit, c.now().Sub(entry.StoredAt))
+	return &entry, true
+}
+
+// Replay streams entry as a fresh answer. The final usage is flagged as
+// cached and costs nothing.
+func (c *Cache) Replay(ctx context.Context, entry *Entry) <-chan gateway.ChatChunk {
+	out := make(chan gateway.ChatChunk, len(entry.Chunks))
+	go func() {
+		defer close(out)
+		for _, chunk := range entry.Chunks {
+			if chunk.Usage != nil {
+				usage := *chunk.Usage
+				usage.Cached = true
+				usage.CostUSD = 0
+				usage.LatencyMS = 0
+				usage.Attempts = nil
+				chunk.Usage = &usage
+			}
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Record forwards stream and stores it under the key of req once it
+// finishes without error.
+func (c *Cache) Record(ctx context.Context, req gateway.ChatRequest, provider string, stream <-chan gateway.ChatChunk) <-chan gateway.ChatChunk {
+	ttl := c.ttl
+	if maxAge := DirectiveFrom(ctx).MaxAge; maxAge > 0 {
+		ttl = maxAge
+	}
+
+	out := make(chan gateway.ChatChunk, 32)
+	go func() {
+		defer close(out)
+		entry := Entry{Provider: provider, Model: req.Model}
+		failed := false
+		for chunk := range stream {
+			if chunk.Error != "" {
+				failed = true
+			}
+			if chunk.Content != "" || chunk.ToolCall != nil || chunk.Done {
+				entry.Chunks = append(entry.Chunks, gateway.ChatChunk{
+					Content:  chunk.Content,
+					Done:     chunk.Done,
+					Usage:    chunk.Usage,
+					ToolCall: chunk.ToolCall,
+				})
+			}
+			if chunk.Done && !failed {
+				if chunk.Usage != nil && chunk.Usage.Model != "" {
+					entry.Model = chunk.Usage.Model
+				}
+				c.store(context.WithoutCancel(ctx), Key(req), entry, ttl)
+			}
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				for range stream {
+				}
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func (c *Cache) store(ctx context.Context, key string, entry Entry, ttl time.Duration) {
+	entry.StoredAt = c.now().UTC()
+	raw, err := json.Marshal(entry)
+	if err == nil {
+		err = c.backend.Set(ctx, key, raw, ttl)
+	}
+	if err != nil {
+		c.errors.Add(1)
+		gl.Log("warn", "failed to store gateway cache entry", err)
+		return
+	}
+	c.stores.Add(1)
+}
+
+// Stats returns the counters since the cache was created.
+func (c *Cache) Stats() Stats {
+	stats := Stats{
+		Backend:  c.backend.Name(),
+		Hits:     c.hits.Load(),
+		Misses:   c.misses.Load(),
+		Bypassed: c.bypassed.Load(),
+		Stores:   c.stores.Load(),
+		Errors:   c.errors.Load(),
+	}
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(lookups)
+	}
+	return stats
+}
+
+// Directive carries the Cache-Control of an HTTP request to the cache and
+// the cache outcome back to the HTTP layer.
+type Directive struct {
+	NoCache bool
+	NoStore bool
+	MaxAge  time.Duration
+
+	mu     sync.Mutex
+	status string
+	age    time.Duration
+}
+
+type directiveKey struct{}
+
+// WithDirective attaches d to ctx.
+func WithDirective(ctx context.Context, d *Directive) context.Context {
+	return context.WithValue(ctx, directiveKey{}, d)
+}
+
+// DirectiveFrom returns the directive of ctx, or an empty one that discards
+// outcomes.
+func DirectiveFrom(ctx context.Context) *Directive {
+	if d, ok := ctx.Value(directiveKey{}).(*Directive); ok && d != nil {
+		return d
+	}
+	return &Directive{}
+}
+
+// Outcome returns the cache status of the request (empty when the cache was
+// not consulted) and, on a hit, the age of the entry.
+func (d *Directive) Outcome() (string, time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.status, d.age
+}
+
+func (d *Directive) report(status string, age time.Duration) {
+	d.mu.Lock()
+	d.status, d.age = status, age
+	d.mu.Unlock()
+}
+
+// FromConfig builds the cache described by cfg, or returns nil when cfg
+// turns caching off.
+func FromConfig(cfg config.GatewayCacheConfig) (*Cache, error) {
+	ttl := time.Duration(cfg.TTLSeconds) * time.Second
+	switch strings.ToLower(strings.TrimSpace(cfg.Backend)) {
+	case "", "memory", "lru":
+		return New(NewLRU(cfg.MaxEntries), ttl), nil
+	case "redis":
+		if cfg.RedisURL == "" {
+			return nil, fmt.Errorf("gateway cache: redis backend requires redis_url")
+		}
+		backend, err := NewRedisFromURL(cfg.RedisURL, cfg.RedisPrefix)
+		if err != nil {
+			return nil, fmt.Errorf("gateway cache: %w", err)
+		}
+		return New(backend, ttl), nil
+	case "off", "none", "disabled":
+		return nil, nil
+	}
+	return nil, fmt.Errorf("gateway cache: unknown backend %q", cfg.Backend)
+}
@@ -0,0 +1,233 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
+	"github.com/kubex-ecosystem/gobe/internal/config"
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+	gateway "github.com/kubex-ecosystem/gobe/internal/services/gateway"
+)
+
+// AnonymousTenant books the calls made without x-tenant-id.
+const AnonymousTenant = "anonymous"
+
+// Store persists ledger records. svc.UsageLedgerService keeps them in the
+// database; MemoryStore keeps them in memory.
+type Store = svc.UsageLedgerService
+
+// Limit caps the USD spend per UTC day and per UTC month. Zero means
+// unlimited.
+type Limit struct {
+	DailyUSD   float64 `json:"daily_usd,omitempty"`
+	MonthlyUSD float64 `json:"monthly_usd,omitempty"`
+}
+
+// Budgets holds the default limit and the per-tenant overrides.
+type Budgets struct {
+	Default Limit
+	Tenants map[string]Limit
+}
+
+// For returns the limit of tenant.
+func (b Budgets) For(tenant string) Limit {
+	if limit, ok := b.Tenants[tenant]; ok {
+		return limit
+	}
+	return b.Default
+}
+
+// BudgetError reports a tenant whose budget for Period is spent.
+type BudgetError struct {
+	Tenant   string    `json:"tenant"`
+	Period   string    `json:"period"`
+	LimitUSD float64   `json:"limit_usd"`
+	SpentUSD float64   `json:"spent_usd"`
+	ResetsAt time.Time `json:"resets_at"`
+}
+
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("%s budget of tenant %q exhausted: spent $%.4f of $%.4f", e.Period, e.Tenant, e.SpentUSD, e.LimitUSD)
+}
+
+// Ledger prices the usage of gateway calls, records it in a Store and
+// checks tenant budgets against the recorded spend.
+type Ledger struct {
+	store Store
+	now   func() time.Time
+
+	mu      sync.RWMutex
+	prices  PriceTable
+	budgets Budgets
+}
+
+// New returns a ledger that prices usage with prices.
+func New(store Store, prices PriceTable) *Ledger {
+	return &Ledger{
+		store:  store,
+		now:    time.Now,
+		prices: prices,
+	}
+}
+
+// SetPrices replaces the price table.
+func (l *Ledger) SetPrices(prices PriceTable) {
+	l.mu.Lock()
+	l.prices = prices
+	l.mu.Unlock()
+}
+
+// SetBudgets replaces the tenant budgets.
+func (l *Ledger) SetBudgets(budgets Budgets) {
+	l.mu.Lock()
+	l.budgets = budgets
+	l.mu.Unlock()
+}
+
+// SetClock replaces the clock used to stamp records and compute budget
+// periods.
+func (l *Ledger) SetClock(now func() time.Time) {
+	l.now = now
+}
+
+// ApplyConfig adds the configured prices to the defaults and installs the
+// configured budgets.
+func (l *Ledger) ApplyConfig(cfg config.GatewayConfig) {
+	override := make(PriceTable, len(cfg.Prices))
+	for model, price := range cfg.Prices {
+		override[model] = ModelPrice{InputPerMillion: price.InputPerMillion, OutputPerMillion: price.OutputPerMillion}
+	}
+	l.SetPrices(DefaultPrices.Merge(override))
+
+	budgets := Budgets{
+		Default: Limit{DailyUSD: cfg.Budgets.DailyUSD, MonthlyUSD: cfg.Budgets.MonthlyUSD},
+		Tenants: make(map[string]Limit, len(cfg.Budgets.Tenants)),
+	}
+	for tenant, limit := range cfg.Budgets.Tenants {
+		budgets.Tenants[tenant] = Limit{DailyUSD: limit.DailyUSD, MonthlyUSD: limit.MonthlyUSD}
+	}
+	l.SetBudgets(budgets)
+}
+
+// RecordUsage prices usage when its model has a price and stores it with the
+// tenant, user and kind found in the request metadata. Answers replayed from
+// the response cache are recorded at no cost.
+func (l *Ledger) RecordUsage(ctx context.Context, req gateway.ChatRequest, usage *gateway.Usage) {
+	if usage == nil {
+		return
+	}
+
+	l.mu.RLock()
+	cost, ok := l.prices.Cost(usage.Model, usage.PromptTokens, usage.CompletionTokens)
+	l.mu.RUnlock()
+	if ok && !usage.Cached {
+		usage.CostUSD = cost
+	}
+
+	provider := usage.Provider
+	if provider == "" {
+		provider = req.Provider
+	}
+	record := &svc.UsageRecord{
+		TenantID:         tenantOrAnonymous(requestValue(req, "tenant_id", "x-tenant-id")),
+		UserID:           requestValue(req, "user_id", "x-user-id"),
+		Kind:             requestKind(req),
+		Provider:         provider,
+		Model:            usage.Model,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+		CostUSD:          usage.CostUSD,
+		LatencyMS:        usage.LatencyMS,
+		CreatedAt:        l.now().UTC(),
+	}
+	if record.TotalTokens == 0 {
+		record.TotalTokens = record.PromptTokens + record.CompletionTokens
+	}
+	if err := l.store.RecordUsage(ctx, record); err != nil {
+		gl.Log("error", "failed to record gateway usage", err)
+	}
+}
+
+// Totals sums the records matching filter, grouped by one of
+// svc.UsageGroups or not at all when groupBy is empty.
+func (l *Ledger) Totals(ctx context.Context, filter svc.UsageFilter, groupBy string) ([]svc.UsageTotals, error) {
+	return l.store.SumUsage(ctx, filter, groupBy)
+}
+
+// Records lists the records matching filter, newest first.
+func (l *Ledger) Records(ctx context.Context, filter svc.UsageFilter) ([]svc.UsageRecord, error) {
+	return l.store.ListUsage(ctx, filter)
+}
+
+// Spent returns what tenant spent since from.
+func (l *Ledger) Spent(ctx context.Context, tenant string, from time.Time) (float64, error) {
+	totals, err := l.store.SumUsage(ctx, svc.UsageFilter{TenantID: tenant, From: from}, "")
+	if err != nil || len(totals) == 0 {
+		return 0, err
+	}
+	return totals[0].CostUSD, nil
+}
+
+// CheckBudget returns a *BudgetError when tenant (AnonymousTenant when
+// empty) spent its daily or monthly budget. Other errors come from the store.
+func (l *Ledger) CheckBudget(ctx context.Context, tenant string) error {
+	tenant = tenantOrAnonymous(tenant)
+	l.mu.RLock()
+	limit := l.budgets.For(tenant)
+	l.mu.RUnlock()
+
+	now := l.now().UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	periods := []struct {
+		name  string
+		limit float64
+		from  time.Time
+		reset time.Time
+	}{
+		{"daily", limit.DailyUSD, day, day.AddDate(0, 0, 1)},
+		{"monthly", limit.MonthlyUSD, month, month.AddDate(0, 1, 0)},
+	}
+	for _, period := range periods {
+		if period.limit <= 0 {
+			continue
+		}
+		spent, err := l.Spent(ctx, tenant, period.from)
+		if err != nil {
+			return err
+		}
+		if spent >= period.limit {
+			return &BudgetError{Tenant: tenant, Period: period.name, LimitUSD: period.limit, SpentUSD: spent, ResetsAt: period.reset}
+		}
+	}
+	return nil
+}
+
+// requestKind is the "kind" metadata of req ("chat" when unset), telling
+// chat, advise and OpenAI facade calls apart in the ledger.
+func requestKind(req gateway.ChatRequest) string {
+	if kind, ok := req.Meta["kind"].(string); ok && kind != "" {
+		return kind
+	}
+	return "chat"
+}
+
+func tenantOrAnonymous(tenant string) string {
+	if tenant == "" {
+		return AnonymousTenant
+	}
+	return tenant
+}
+
+func requestValue(req gateway.ChatRequest, metaKey, header string) string {
+	if value, ok := req.Meta[metaKey].(string); ok && strings.TrimSpace(value) != "" {
+		return strings.TrimSpace(value)
+	}
+	return strings.TrimSpace(req.Headers[header])
+}
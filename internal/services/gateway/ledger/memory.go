@@ -0,0 +1,123 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
+)
+
+// MemoryStore keeps ledger records in memory. It serves tests and gateways
+// running without a database; records are lost on restart.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	records []svc.UsageRecord
+}
+
+// NewMemoryStore returns an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (m *MemoryStore) RecordUsage(ctx context.Context, record *svc.UsageRecord) error {
+	if record.ID == "" {
+		record.ID = uuid.NewString()
+	}
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now().UTC()
+	}
+	m.mu.Lock()
+	m.records = append(m.records, *record)
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *MemoryStore) ListUsage(ctx context.Context, filter svc.UsageFilter) ([]svc.UsageRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	records := make([]svc.UsageRecord, 0)
+	for _, record := range m.records {
+		if matches(record, filter) {
+			records = append(records, record)
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].CreatedAt.After(records[j].CreatedAt) })
+	if filter.Limit > 0 && len(records) > filter.Limit {
+		records = records[:filter.Limit]
+	}
+	return records, nil
+}
+
+func (m *MemoryStore) SumUsage(ctx context.Context, filter svc.UsageFilter, groupBy string) ([]svc.UsageTotals, error) {
+	if _, ok := svc.UsageGroups[groupBy]; groupBy != "" && !ok {
+		return nil, fmt.Errorf("unknown usage group %q", groupBy)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	groups := make(map[string]*svc.UsageTotals)
+	order := make([]string, 0)
+	if groupBy == "" {
+		groups[""] = &svc.UsageTotals{}
+		order = append(order, "")
+	}
+	for _, record := range m.records {
+		if !matches(record, filter) {
+			continue
+		}
+		key := groupKey(record, groupBy)
+		totals, ok := groups[key]
+		if !ok {
+			totals = &svc.UsageTotals{Key: key}
+			groups[key] = totals
+			order = append(order, key)
+		}
+		totals.Requests++
+		totals.PromptTokens += int64(record.PromptTokens)
+		totals.CompletionTokens += int64(record.CompletionTokens)
+		totals.TotalTokens += int64(record.TotalTokens)
+		totals.CostUSD += record.CostUSD
+	}
+
+	result := make([]svc.UsageTotals, 0, len(order))
+	for _, key := range order {
+		result = append(result, *groups[key])
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].CostUSD > result[j].CostUSD })
+	return result, nil
+}
+
+func matches(record svc.UsageRecord, filter svc.UsageFilter) bool {
+	switch {
+	case filter.TenantID != "" && record.TenantID != filter.TenantID,
+		filter.UserID != "" && record.UserID != filter.UserID,
+		filter.Provider != "" && record.Provider != filter.Provider,
+		filter.Model != "" && record.Model != filter.Model,
+		!filter.From.IsZero() && record.CreatedAt.Before(filter.From),
+		!filter.To.IsZero() && !record.CreatedAt.Before(filter.To):
+		return false
+	}
+	return true
+}
+
+func groupKey(record svc.UsageRecord, groupBy string) string {
+	switch groupBy {
+	case "tenant":
+		return record.TenantID
+	case "user":
+		return record.UserID
+	case "provider":
+		return record.Provider
+	case "model":
+		return record.Model
+	case "kind":
+		return record.Kind
+	}
+	return ""
+}
@@ -0,0 +1,74 @@
+// Package ledger prices gateway usage, stores it per tenant, user, provider
+// and model, and enforces tenant budgets.
+package ledger
+
+import "strings"
+
+// ModelPrice is the USD price per million tokens of a model.
+type ModelPrice struct {
+	InputPerMillion  float64 `json:"input_per_million"`
+	OutputPerMillion float64 `json:"output_per_million"`
+}
+
+// PriceTable maps model names to prices. A key matches the model with that
+// exact name or, failing that, the longest key the model name starts with, so
+// "gpt-4o" covers "gpt-4o-2024-08-06" while "gpt-4o-mini" keeps its own price.
+type PriceTable map[string]ModelPrice
+
+// DefaultPrices are list prices of the hosted providers' common models.
+// Models without a price keep the provider's own estimate.
+var DefaultPrices = PriceTable{
+	"gpt-4o":            {InputPerMillion: 2.50, OutputPerMillion: 10.00},
+	"gpt-4o-mini":       {InputPerMillion: 0.15, OutputPerMillion: 0.60},
+	"gpt-4-turbo":       {InputPerMillion: 10.00, OutputPerMillion: 30.00},
+	"gpt-4":             {InputPerMillion: 30.00, OutputPerMillion: 60.00},
+	"gpt-3.5-turbo":     {InputPerMillion: 0.50, OutputPerMillion: 1.50},
+	"claude-3-5-sonnet": {InputPerMillion: 3.00, OutputPerMillion: 15.00},
+	"claude-3-5-haiku":  {InputPerMillion: 0.80, OutputPerMillion: 4.00},
+	"claude-3-opus":     {InputPerMillion: 15.00, OutputPerMillion: 75.00},
+	"claude-3-haiku":    {InputPerMillion: 0.25, OutputPerMillion: 1.25},
+	"gemini-1.5-pro":    {InputPerMillion: 1.25, OutputPerMillion: 5.00},
+	"gemini-1.5-flash":  {InputPerMillion: 0.075, OutputPerMillion: 0.30},
+	"gemini-2.0-flash":  {InputPerMillion: 0.10, OutputPerMillion: 0.40},
+	"llama-3.1-70b":     {InputPerMillion: 0.59, OutputPerMillion: 0.79},
+	"llama-3.1-8b":      {InputPerMillion: 0.05, OutputPerMillion: 0.08},
+	"mixtral-8x7b":      {InputPerMillion: 0.24, OutputPerMillion: 0.24},
+}
+
+// Lookup returns the price of model.
+func (t PriceTable) Lookup(model string) (ModelPrice, bool) {
+	if price, ok := t[model]; ok {
+		return price, true
+	}
+	best := ""
+	for key := range t {
+		if len(key) > len(best) && strings.HasPrefix(model, key) {
+			best = key
+		}
+	}
+	if best == "" {
+		return ModelPrice{}, false
+	}
+	return t[best], true
+}
+
+// Cost prices a call to model. ok is false when the model has no price.
+func (t PriceTable) Cost(model string, promptTokens, completionTokens int) (cost float64, ok bool) {
+	price, ok := t.Lookup(model)
+	if !ok {
+		return 0, false
+	}
+	return (float64(promptTokens)*price.InputPerMillion + float64(completionTokens)*price.OutputPerMillion) / 1_000_000, true
+}
+
+// Merge returns a copy of t with the prices of override added or replaced.
+func (t PriceTable) Merge(override PriceTable) PriceTable {
+	merged := make(PriceTable, len(t)+len(override))
+	for model, price := range t {
+		merged[model] = price
+	}
+	for model, price := range override {
+		merged[model] = price
+	}
+	return merged
+}
@@ -94,6 +94,9 @@ func (a *anthropicProvider) Chat(ctx context.Context, req gateway.ChatRequest) (
 	if req.Temperature > 0 {
 		body["temperature"] = req.Temperature
 	}
+	if len(req.Tools) > 0 {
+		body["tools"] = toAnthropicTools(req.Tools)
+	}
 
 	bodyBytes, err := json.Marshal(body)
 	if err != nil {
@@ -126,12 +129,13 @@ func (a *anthropicProvider) Chat(ctx context.Context, req gateway.ChatRequest) (
 
 		if resp.StatusCode != http.StatusOK {
 			body, _ := io.ReadAll(resp.Body)
-			responseChan <- gateway.ChatChunk{Done: true, Error: fmt.Sprintf("anthropic api error %d: %s", resp.StatusCode, string(body))}
+			responseChan <- gateway.ChatChunk{Done: true, StatusCode: resp.StatusCode, Error: fmt.Sprintf("anthropic api error %d: %s", resp.StatusCode, string(body))}
 			return
 		}
 
 		scanner := bufio.NewScanner(resp.Body)
 		var inputTokens, outputTokens int
+		toolBlocks := make(map[int]*toolCallBuffer)
 
 		for scanner.Scan() {
 			line := scanner.Text()
@@ -154,10 +158,28 @@ func (a *anthropicProvider) Chat(ctx context.Context, req gateway.ChatRequest) (
 				if event.Message != nil && event.Message.Usage != nil {
 					inputTokens = event.Message.Usage.InputTokens
 				}
+			case "content_block_start":
+				if event.ContentBlock != nil && event.ContentBlock.Type == "tool_use" {
+					toolBlocks[event.Index] = &toolCallBuffer{id: event.ContentBlock.ID, name: event.ContentBlock.Name}
+				}
 			case "content_block_delta":
 				if event.Delta != nil && event.Delta.Text != "" {
 					responseChan <- gateway.ChatChunk{Content: event.Delta.Text}
 				}
+				if event.Delta != nil && event.Delta.Type == "input_json_delta" {
+					if buf, ok := toolBlocks[event.Index]; ok {
+						buf.args.WriteString(event.Delta.PartialJSON)
+					}
+				}
+			case "content_block_stop":
+				if buf, ok := toolBlocks[event.Index]; ok {
+					delete(toolBlocks, event.Index)
+					responseChan <- gateway.ChatChunk{ToolCall: &gateway.ToolCall{
+						ID:   buf.id,
+						Name: buf.name,
+						Args: toolArgsMap(buf.args.String()),
+					}}
+				}
 			case "message_delta":
 				if event.Delta != nil && event.Delta.Usage != nil {
 					outputTokens = event.Delta.Usage.OutputTokens
@@ -207,6 +229,27 @@ func (a *anthropicProvider) resolveKey(req gateway.ChatRequest) string {
 func toAnthropicMessages(messages []gateway.Message) []map[string]interface{} {
 	result := make([]map[string]interface{}, 0, len(messages))
 	for _, msg := range messages {
+		// Tool results go back as tool_result blocks of a user turn; consecutive
+		// results are merged so they answer the same assistant turn
+		if msg.Role == "tool" {
+			block := map[string]interface{}{
+				"type":        "tool_result",
+				"tool_use_id": msg.ToolCallID,
+				"content":     msg.Content,
+			}
+			if n := len(result); n > 0 && result[n-1]["role"] == "user" {
+				if blocks, ok := result[n-1]["content"].([]map[string]interface{}); ok && len(blocks) > 0 && blocks[0]["type"] == "tool_result" {
+					result[n-1]["content"] = append(blocks, block)
+					continue
+				}
+			}
+			result = append(result, map[string]interface{}{
+				"role":    "user",
+				"content": []map[string]interface{}{block},
+			})
+			continue
+		}
+
 		// Anthropic uses "user" and "assistant" roles
 		role := msg.Role
 		if role == "system" {
@@ -215,30 +258,60 @@ func toAnthropicMessages(messages []gateway.Message) []map[string]interface{} {
 			msg.Content = "System: " + msg.Content
 		}
 
+		content := make([]map[string]interface{}, 0, 1+len(msg.ToolCalls))
+		if msg.Content != "" || len(msg.ToolCalls) == 0 {
+			content = append(content, map[string]interface{}{
+				"type": "text",
+				"text": msg.Content,
+			})
+		}
+		for _, call := range msg.ToolCalls {
+			content = append(content, map[string]interface{}{
+				"type":  "tool_use",
+				"id":    call.ID,
+				"name":  call.Name,
+				"input": toolArgsObject(call.Args),
+			})
+		}
+
 		result = append(result, map[string]interface{}{
-			"role": role,
-			"content": []map[string]interface{}{
-				{
-					"type": "text",
-					"text": msg.Content,
-				},
-			},
+			"role":    role,
+			"content": content,
+		})
+	}
+	return result
+}
+
+func toAnthropicTools(tools []gateway.Tool) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(tools))
+	for _, tool := range tools {
+		result = append(result, map[string]interface{}{
+			"name":         tool.Name,
+			"description":  tool.Description,
+			"input_schema": toolParameters(tool),
 		})
 	}
 	return result
 }
 
 type anthropicStreamEvent struct {
-	Type    string `json:"type"`
+	Type         string `json:"type"`
+	Index        int    `json:"index"`
+	ContentBlock *struct {
+		Type string `json:"type"`
+		ID   string `json:"id,omitempty"`
+		Name string `json:"name,omitempty"`
+	} `json:"content_block,omitempty"`
 	Message *struct {
 		Usage *struct {
 			InputTokens int `json:"input_tokens"`
 		} `json:"usage"`
 	} `json:"message,omitempty"`
 	Delta *struct {
-		Type  string `json:"type,omitempty"`
-		Text  string `json:"text,omitempty"`
-		Usage *struct {
+		Type        string `json:"type,omitempty"`
+		Text        string `json:"text,omitempty"`
+		PartialJSON string `json:"partial_json,omitempty"`
+		Usage       *struct {
 			OutputTokens int `json:"output_tokens"`
 		} `json:"usage,omitempty"`
 	} `json:"delta,omitempty"`
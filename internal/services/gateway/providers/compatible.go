@@ -0,0 +1,353 @@
+package providers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	gateway "github.com/kubex-ecosystem/gobe/internal/services/gateway"
+)
+
+const (
+	defaultOllamaBaseURL = "http://localhost:11434"
+	modelDiscoveryTTL    = time.Minute
+)
+
+// compatibleProvider talks to any server exposing the OpenAI chat completions
+// API (Ollama, llama.cpp server, vLLM, LM Studio). The API key is optional and
+// models can be discovered from the server when no default is configured.
+// Base URLs may be given with or without the trailing /v1.
+//
+// Metadata keys:
+//   - auth_header: header carrying the key (default "Authorization")
+//   - auth_scheme: prefix of the key value (default "Bearer" for Authorization)
+//   - models_path: discovery endpoint relative to the base URL
+//   - timeout_seconds: request timeout (default 120)
+type compatibleProvider struct {
+	name         string
+	kind         string
+	baseURL      string
+	apiKey       string
+	authHeader   string
+	authScheme   string
+	defaultModel string
+	modelsPath   string
+	client       *http.Client
+
+	mu           sync.Mutex
+	models       []string
+	discoveredAt time.Time
+	discoverErr  error
+}
+
+func newOpenAICompatibleProvider(cfg Config) (gateway.Provider, error) {
+	baseURL := strings.TrimSpace(cfg.BaseURL)
+	if baseURL == "" {
+		return nil, errors.New("openai-compatible provider requires a base url")
+	}
+	return newCompatibleProvider(cfg, "openai-compatible", baseURL, "/v1/models"), nil
+}
+
+func newOllamaProvider(cfg Config) (gateway.Provider, error) {
+	baseURL := strings.TrimSpace(cfg.BaseURL)
+	if baseURL == "" {
+		baseURL = defaultOllamaBaseURL
+	}
+	return newCompatibleProvider(cfg, "ollama", baseURL, "/api/tags"), nil
+}
+
+func newCompatibleProvider(cfg Config, kind, baseURL, modelsPath string) *compatibleProvider {
+	authHeader := metadataString(cfg.Metadata, "auth_header")
+	if authHeader == "" {
+		authHeader = "Authorization"
+	}
+	authScheme, ok := cfg.Metadata["auth_scheme"].(string)
+	if !ok && strings.EqualFold(authHeader, "Authorization") {
+		authScheme = "Bearer"
+	}
+	if path := metadataString(cfg.Metadata, "models_path"); path != "" {
+		modelsPath = path
+	}
+	timeout := 2 * time.Minute
+	if seconds, ok := cfg.Metadata["timeout_seconds"].(float64); ok && seconds > 0 {
+		timeout = time.Duration(seconds * float64(time.Second))
+	}
+
+	return &compatibleProvider{
+		name:         cfg.Name,
+		kind:         kind,
+		baseURL:      strings.TrimSuffix(strings.TrimRight(baseURL, "/"), "/v1"),
+		apiKey:       staticAPIKey(cfg),
+		authHeader:   authHeader,
+		authScheme:   strings.TrimSpace(authScheme),
+		defaultModel: strings.TrimSpace(cfg.DefaultModel),
+		modelsPath:   modelsPath,
+		client:       &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *compatibleProvider) Name() string { return p.name }
+
+// Available reports whether a model can be served. With a default model it
+// does not touch the network; otherwise it needs the server to list a model.
+func (p *compatibleProvider) Available() error {
+	if p.defaultModel != "" {
+		return nil
+	}
+	models, err := p.Models(context.Background())
+	if err != nil {
+		return err
+	}
+	if len(models) == 0 {
+		return fmt.Errorf("%s server at %s serves no models", p.kind, p.baseURL)
+	}
+	return nil
+}
+
+func (p *compatibleProvider) Notify(ctx context.Context, event gateway.NotificationEvent) error {
+	return nil
+}
+
+// Models lists the models served by the backend. Results are cached for a
+// minute so routing and model listings do not hit the server on every call.
+func (p *compatibleProvider) Models(ctx context.Context) ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.discoveredAt.IsZero() && time.Since(p.discoveredAt) < modelDiscoveryTTL {
+		return append([]string(nil), p.models...), p.discoverErr
+	}
+
+	p.models, p.discoverErr = p.discover(ctx)
+	p.discoveredAt = time.Now()
+	return append([]string(nil), p.models...), p.discoverErr
+}
+
+func (p *compatibleProvider) discover(ctx context.Context) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+p.modelsPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s models request: %w", p.kind, err)
+	}
+	p.authorize(httpReq, p.apiKey)
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("%s model discovery failed: %w", p.kind, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("%s model discovery error %d: %s", p.kind, resp.StatusCode, string(body))
+	}
+
+	// OpenAI lists {"data":[{"id":...}]}, Ollama's native API {"models":[{"name":...}]}
+	var listing struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&listing); err != nil {
+		return nil, fmt.Errorf("failed to decode %s model list: %w", p.kind, err)
+	}
+
+	models := make([]string, 0, len(listing.Data)+len(listing.Models))
+	for _, item := range listing.Data {
+		if item.ID != "" {
+			models = append(models, item.ID)
+		}
+	}
+	for _, item := range listing.Models {
+		if item.Name != "" {
+			models = append(models, item.Name)
+		}
+	}
+	return models, nil
+}
+
+// model picks the request model, then the configured default, then the first
+// discovered model.
+func (p *compatibleProvider) model(ctx context.Context, requested string) (string, error) {
+	if model := strings.TrimSpace(requested); model != "" {
+		return model, nil
+	}
+	if p.defaultModel != "" {
+		return p.defaultModel, nil
+	}
+	models, err := p.Models(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(models) == 0 {
+		return "", fmt.Errorf("%s model not specified and none discovered", p.kind)
+	}
+	return models[0], nil
+}
+
+func (p *compatibleProvider) authorize(httpReq *http.Request, key string) {
+	if key == "" {
+		return
+	}
+	value := key
+	if p.authScheme != "" {
+		value = p.authScheme + " " + key
+	}
+	httpReq.Header.Set(p.authHeader, value)
+}
+
+func (p *compatibleProvider) resolveKey(req gateway.ChatRequest) string {
+	if key := externalAPIKey(req); key != "" {
+		return key
+	}
+	return p.apiKey
+}
+
+func (p *compatibleProvider) Chat(ctx context.Context, req gateway.ChatRequest) (<-chan gateway.ChatChunk, error) {
+	if len(req.Messages) == 0 {
+		return nil, fmt.Errorf("%s chat requires at least one message", p.kind)
+	}
+
+	model, err := p.model(ctx, req.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	body := map[string]interface{}{
+		"model":          model,
+		"messages":       toOpenAIMessages(req.Messages),
+		"stream":         true,
+		"stream_options": map[string]interface{}{"include_usage": true},
+	}
+	if req.Temperature > 0 {
+		body["temperature"] = req.Temperature
+	}
+	if len(req.Tools) > 0 {
+		body["tools"] = toOpenAITools(req.Tools)
+	}
+
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s request: %w", p.kind, err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/v1/chat/completions", bytes.NewReader(bodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s request: %w", p.kind, err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "text/event-stream")
+	p.authorize(httpReq, p.resolveKey(req))
+
+	responseChan := make(chan gateway.ChatChunk, 32)
+
+	go func() {
+		defer close(responseChan)
+
+		start := time.Now()
+		resp, err := p.client.Do(httpReq)
+		if err != nil {
+			responseChan <- gateway.ChatChunk{Done: true, Error: fmt.Sprintf("%s request failed: %v", p.kind, err)}
+			return
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			responseChan <- gateway.ChatChunk{Done: true, StatusCode: resp.StatusCode, Error: fmt.Sprintf("%s api error %d: %s", p.kind, resp.StatusCode, string(body))}
+			return
+		}
+
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		promptTokens := 0
+		completionTokens := 0
+		totalTokens := 0
+		var toolCalls openAIToolAccumulator
+
+		for scanner.Scan() {
+			payload, ok := strings.CutPrefix(scanner.Text(), "data:")
+			if !ok {
+				continue
+			}
+			payload = strings.TrimSpace(payload)
+			if payload == "[DONE]" {
+				break
+			}
+
+			var chunk openAIStreamChunk
+			if err := json.Unmarshal([]byte(payload), &chunk); err != nil {
+				continue
+			}
+
+			if len(chunk.Choices) > 0 {
+				if delta := chunk.Choices[0].Delta.Content; delta != "" {
+					select {
+					case responseChan <- gateway.ChatChunk{Content: delta}:
+					case <-ctx.Done():
+						return
+					}
+				}
+				toolCalls.add(chunk.Choices[0].Delta.ToolCalls)
+			}
+			if chunk.Usage != nil {
+				promptTokens = chunk.Usage.PromptTokens
+				completionTokens = chunk.Usage.CompletionTokens
+				totalTokens = chunk.Usage.TotalTokens
+			}
+		}
+
+		if err := scanner.Err(); err != nil {
+			if errors.Is(err, context.Canceled) {
+				return
+			}
+			responseChan <- gateway.ChatChunk{Done: true, Error: fmt.Sprintf("%s stream error: %v", p.kind, err)}
+			return
+		}
+
+		for _, call := range toolCalls.toolCalls() {
+			call := call
+			responseChan <- gateway.ChatChunk{ToolCall: &call}
+		}
+
+		if totalTokens == 0 {
+			totalTokens = promptTokens + completionTokens
+		}
+
+		// Local backends have no per-token price
+		responseChan <- gateway.ChatChunk{
+			Done: true,
+			Usage: &gateway.Usage{
+				PromptTokens:     promptTokens,
+				CompletionTokens: completionTokens,
+				TotalTokens:      totalTokens,
+				LatencyMS:        time.Since(start).Milliseconds(),
+				Provider:         p.name,
+				Model:            model,
+			},
+		}
+	}()
+
+	return responseChan, nil
+}
+
+func metadataString(metadata map[string]interface{}, key string) string {
+	if metadata == nil {
+		return ""
+	}
+	raw, _ := metadata[key].(string)
+	return strings.TrimSpace(raw)
+}
@@ -19,6 +19,10 @@ func New(cfg Config) (gateway.Provider, error) {
 		return newAnthropicProvider(cfg)
 	case "gemini":
 		return newGeminiProvider(cfg)
+	case "ollama":
+		return newOllamaProvider(cfg)
+	case "openai-compatible":
+		return newOpenAICompatibleProvider(cfg)
 	default:
 		return nil, fmt.Errorf("unsupported provider type: %s", cfg.Type)
 	}
@@ -94,13 +94,17 @@ func (g *geminiProvider) Chat(ctx context.Context, req gateway.ChatRequest) (<-c
 	if req.Temperature > 0 {
 		body["generationConfig"].(map[string]interface{})["temperature"] = req.Temperature
 	}
+	if len(req.Tools) > 0 {
+		body["tools"] = toGeminiTools(req.Tools)
+	}
 
 	bodyBytes, err := json.Marshal(body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal gemini request: %w", err)
 	}
 
-	url := fmt.Sprintf("%s/v1beta/models/%s:streamGenerateContent?key=%s",
+	// alt=sse makes the stream use "data:" lines instead of a JSON array
+	url := fmt.Sprintf("%s/v1beta/models/%s:streamGenerateContent?alt=sse&key=%s",
 		strings.TrimRight(g.baseURL, "/"), model, key)
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(bodyBytes))
 	if err != nil {
@@ -125,7 +129,7 @@ func (g *geminiProvider) Chat(ctx context.Context, req gateway.ChatRequest) (<-c
 
 		if resp.StatusCode != http.StatusOK {
 			body, _ := io.ReadAll(resp.Body)
-			responseChan <- gateway.ChatChunk{Done: true, Error: fmt.Sprintf("gemini api error %d: %s", resp.StatusCode, string(body))}
+			responseChan <- gateway.ChatChunk{Done: true, StatusCode: resp.StatusCode, Error: fmt.Sprintf("gemini api error %d: %s", resp.StatusCode, string(body))}
 			return
 		}
 
@@ -133,6 +137,7 @@ func (g *geminiProvider) Chat(ctx context.Context, req gateway.ChatRequest) (<-c
 		var totalTokens int
 		var promptTokens int
 		var candidateTokens int
+		toolCalls := 0
 
 		for scanner.Scan() {
 			line := scanner.Text()
@@ -157,6 +162,15 @@ func (g *geminiProvider) Chat(ctx context.Context, req gateway.ChatRequest) (<-c
 						if part.Text != "" {
 							responseChan <- gateway.ChatChunk{Content: part.Text}
 						}
+						// Gemini sends function calls whole and without IDs
+						if part.FunctionCall != nil {
+							toolCalls++
+							responseChan <- gateway.ChatChunk{ToolCall: &gateway.ToolCall{
+								ID:   fmt.Sprintf("call_%d", toolCalls),
+								Name: part.FunctionCall.Name,
+								Args: toolArgsObject(part.FunctionCall.Args),
+							}}
+						}
 					}
 				}
 			}
@@ -210,6 +224,25 @@ func toGeminiContents(messages []gateway.Message) []map[string]interface{} {
 	result := make([]map[string]interface{}, 0, len(messages))
 
 	for _, msg := range messages {
+		// Tool results are functionResponse parts; consecutive results share a turn
+		if msg.Role == "tool" {
+			part := map[string]interface{}{
+				"functionResponse": map[string]interface{}{
+					"name":     msg.Name,
+					"response": geminiFunctionResponse(msg.Content),
+				},
+			}
+			if n := len(result); n > 0 && result[n-1]["role"] == "function" {
+				result[n-1]["parts"] = append(result[n-1]["parts"].([]map[string]interface{}), part)
+				continue
+			}
+			result = append(result, map[string]interface{}{
+				"role":  "function",
+				"parts": []map[string]interface{}{part},
+			})
+			continue
+		}
+
 		// Gemini uses "user" and "model" roles
 		role := msg.Role
 		if role == "assistant" {
@@ -220,23 +253,89 @@ func toGeminiContents(messages []gateway.Message) []map[string]interface{} {
 			msg.Content = "System: " + msg.Content
 		}
 
-		result = append(result, map[string]interface{}{
-			"role": role,
-			"parts": []map[string]interface{}{
-				{
-					"text": msg.Content,
+		parts := make([]map[string]interface{}, 0, 1+len(msg.ToolCalls))
+		if msg.Content != "" || len(msg.ToolCalls) == 0 {
+			parts = append(parts, map[string]interface{}{
+				"text": msg.Content,
+			})
+		}
+		for _, call := range msg.ToolCalls {
+			parts = append(parts, map[string]interface{}{
+				"functionCall": map[string]interface{}{
+					"name": call.Name,
+					"args": toolArgsObject(call.Args),
 				},
-			},
+			})
+		}
+
+		result = append(result, map[string]interface{}{
+			"role":  role,
+			"parts": parts,
 		})
 	}
 	return result
 }
 
+func toGeminiTools(tools []gateway.Tool) []map[string]interface{} {
+	declarations := make([]map[string]interface{}, 0, len(tools))
+	for _, tool := range tools {
+		declarations = append(declarations, map[string]interface{}{
+			"name":        tool.Name,
+			"description": tool.Description,
+			"parameters":  geminiSchema(toolParameters(tool)),
+		})
+	}
+	return []map[string]interface{}{{"functionDeclarations": declarations}}
+}
+
+// geminiSchema strips the JSON Schema keywords Gemini's OpenAPI subset rejects.
+func geminiSchema(schema map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(schema))
+	for key, value := range schema {
+		switch key {
+		case "$schema", "$id", "additionalProperties", "default":
+			continue
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if key == "properties" {
+				props := make(map[string]interface{}, len(v))
+				for name, prop := range v {
+					if propSchema, ok := prop.(map[string]interface{}); ok {
+						props[name] = geminiSchema(propSchema)
+					} else {
+						props[name] = prop
+					}
+				}
+				out[key] = props
+			} else {
+				out[key] = geminiSchema(v)
+			}
+		default:
+			out[key] = value
+		}
+	}
+	return out
+}
+
+// geminiFunctionResponse wraps a tool result; Gemini requires a JSON object.
+func geminiFunctionResponse(content string) map[string]interface{} {
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(content), &obj); err == nil && obj != nil {
+		return obj
+	}
+	return map[string]interface{}{"content": content}
+}
+
 type geminiStreamChunk struct {
 	Candidates []struct {
 		Content *struct {
 			Parts []struct {
-				Text string `json:"text"`
+				Text         string `json:"text"`
+				FunctionCall *struct {
+					Name string                 `json:"name"`
+					Args map[string]interface{} `json:"args"`
+				} `json:"functionCall,omitempty"`
 			} `json:"parts"`
 		} `json:"content"`
 		FinishReason string `json:"finishReason,omitempty"`
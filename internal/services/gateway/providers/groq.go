@@ -85,11 +85,6 @@ func (p *groqProvider) Chat(ctx context.Context, req gateway.ChatRequest) (<-cha
 		return nil, errors.New("groq chat requires at least one message")
 	}
 
-	messages := make([]groqMessage, 0, len(req.Messages))
-	for _, msg := range req.Messages {
-		messages = append(messages, groqMessage{Role: msg.Role, Content: msg.Content})
-	}
-
 	model := strings.TrimSpace(req.Model)
 	if model == "" {
 		model = p.defaultModel
@@ -97,9 +92,12 @@ func (p *groqProvider) Chat(ctx context.Context, req gateway.ChatRequest) (<-cha
 
 	groqReq := groqRequest{
 		Model:    model,
-		Messages: messages,
+		Messages: toOpenAIMessages(req.Messages),
 		Stream:   true,
 	}
+	if len(req.Tools) > 0 {
+		groqReq.Tools = toOpenAITools(req.Tools)
+	}
 
 	if req.Temperature > 0 {
 		groqReq.Temperature = &req.Temperature
@@ -138,7 +136,7 @@ func (p *groqProvider) Chat(ctx context.Context, req gateway.ChatRequest) (<-cha
 
 		if resp.StatusCode != http.StatusOK {
 			body, _ := io.ReadAll(resp.Body)
-			responseChan <- gateway.ChatChunk{Done: true, Error: fmt.Sprintf("groq api error %d: %s", resp.StatusCode, string(body))}
+			responseChan <- gateway.ChatChunk{Done: true, StatusCode: resp.StatusCode, Error: fmt.Sprintf("groq api error %d: %s", resp.StatusCode, string(body))}
 			return
 		}
 
@@ -146,6 +144,7 @@ func (p *groqProvider) Chat(ctx context.Context, req gateway.ChatRequest) (<-cha
 		promptTokens := 0
 		completionTokens := 0
 		totalTokens := 0
+		var toolCalls openAIToolAccumulator
 
 		for scanner.Scan() {
 			line := scanner.Text()
@@ -172,6 +171,7 @@ func (p *groqProvider) Chat(ctx context.Context, req gateway.ChatRequest) (<-cha
 						return
 					}
 				}
+				toolCalls.add(choice.Delta.ToolCalls)
 				if choice.FinishReason != nil && *choice.FinishReason != "" && chunk.Usage != nil {
 					promptTokens = chunk.Usage.PromptTokens
 					completionTokens = chunk.Usage.CompletionTokens
@@ -188,6 +188,11 @@ func (p *groqProvider) Chat(ctx context.Context, req gateway.ChatRequest) (<-cha
 			return
 		}
 
+		for _, call := range toolCalls.toolCalls() {
+			call := call
+			responseChan <- gateway.ChatChunk{ToolCall: &call}
+		}
+
 		if totalTokens == 0 {
 			totalTokens = promptTokens + completionTokens
 		}
@@ -235,17 +240,13 @@ func estimateGroqCost(model string, inputTokens, outputTokens int) float64 {
 }
 
 type groqRequest struct {
-	Model       string        `json:"model"`
-	Messages    []groqMessage `json:"messages"`
-	Stream      bool          `json:"stream"`
-	Temperature *float32      `json:"temperature,omitempty"`
-	MaxTokens   *int          `json:"max_tokens,omitempty"`
-	TopP        *float32      `json:"top_p,omitempty"`
-}
-
-type groqMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Model       string                   `json:"model"`
+	Messages    []openAIMessage          `json:"messages"`
+	Stream      bool                     `json:"stream"`
+	Temperature *float32                 `json:"temperature,omitempty"`
+	MaxTokens   *int                     `json:"max_tokens,omitempty"`
+	TopP        *float32                 `json:"top_p,omitempty"`
+	Tools       []map[string]interface{} `json:"tools,omitempty"`
 }
 
 type groqStreamChunk struct {
@@ -256,8 +257,9 @@ type groqStreamChunk struct {
 	Choices []struct {
 		Index int `json:"index"`
 		Delta struct {
-			Role    string `json:"role,omitempty"`
-			Content string `json:"content,omitempty"`
+			Role      string                `json:"role,omitempty"`
+			Content   string                `json:"content,omitempty"`
+			ToolCalls []openAIToolCallDelta `json:"tool_calls,omitempty"`
 		} `json:"delta"`
 		FinishReason *string `json:"finish_reason"`
 	} `json:"choices"`
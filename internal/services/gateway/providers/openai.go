@@ -81,6 +81,9 @@ func (o *openAIProvider) Chat(ctx context.Context, req gateway.ChatRequest) (<-c
 		"temperature": req.Temperature,
 		"stream":      true,
 	}
+	if len(req.Tools) > 0 {
+		body["tools"] = toOpenAITools(req.Tools)
+	}
 
 	bodyBytes, err := json.Marshal(body)
 	if err != nil {
@@ -112,7 +115,7 @@ func (o *openAIProvider) Chat(ctx context.Context, req gateway.ChatRequest) (<-c
 
 		if resp.StatusCode != http.StatusOK {
 			body, _ := io.ReadAll(resp.Body)
-			responseChan <- gateway.ChatChunk{Done: true, Error: fmt.Sprintf("openai api error %d: %s", resp.StatusCode, string(body))}
+			responseChan <- gateway.ChatChunk{Done: true, StatusCode: resp.StatusCode, Error: fmt.Sprintf("openai api error %d: %s", resp.StatusCode, string(body))}
 			return
 		}
 
@@ -120,6 +123,7 @@ func (o *openAIProvider) Chat(ctx context.Context, req gateway.ChatRequest) (<-c
 		totalTokens := 0
 		promptTokens := 0
 		completionTokens := 0
+		var toolCalls openAIToolAccumulator
 
 		for scanner.Scan() {
 			line := scanner.Text()
@@ -142,6 +146,7 @@ func (o *openAIProvider) Chat(ctx context.Context, req gateway.ChatRequest) (<-c
 				if delta != "" {
 					responseChan <- gateway.ChatChunk{Content: delta}
 				}
+				toolCalls.add(chunk.Choices[0].Delta.ToolCalls)
 			}
 
 			if chunk.Usage != nil {
@@ -159,6 +164,11 @@ func (o *openAIProvider) Chat(ctx context.Context, req gateway.ChatRequest) (<-c
 			return
 		}
 
+		for _, call := range toolCalls.toolCalls() {
+			call := call
+			responseChan <- gateway.ChatChunk{ToolCall: &call}
+		}
+
 		latency := time.Since(start).Milliseconds()
 		responseChan <- gateway.ChatChunk{
 			Done: true,
@@ -184,21 +194,11 @@ func (o *openAIProvider) resolveKey(req gateway.ChatRequest) string {
 	return o.apiKey
 }
 
-func toOpenAIMessages(messages []gateway.Message) []map[string]string {
-	result := make([]map[string]string, 0, len(messages))
-	for _, msg := range messages {
-		result = append(result, map[string]string{
-			"role":    msg.Role,
-			"content": msg.Content,
-		})
-	}
-	return result
-}
-
 type openAIStreamChunk struct {
 	Choices []struct {
 		Delta struct {
-			Content string `json:"content"`
+			Content   string                `json:"content"`
+			ToolCalls []openAIToolCallDelta `json:"tool_calls,omitempty"`
 		} `json:"delta"`
 	} `json:"choices"`
 	Usage *struct {
@@ -0,0 +1,179 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	gateway "github.com/kubex-ecosystem/gobe/internal/services/gateway"
+)
+
+// toolParameters decodes a tool's JSON Schema, defaulting to an empty object schema.
+func toolParameters(tool gateway.Tool) map[string]interface{} {
+	params := map[string]interface{}{}
+	if len(tool.Parameters) > 0 {
+		_ = json.Unmarshal(tool.Parameters, &params)
+	}
+	if _, ok := params["type"]; !ok {
+		params["type"] = "object"
+	}
+	if _, ok := params["properties"]; !ok {
+		params["properties"] = map[string]interface{}{}
+	}
+	return params
+}
+
+// toolArgsJSON encodes tool call arguments as the JSON string OpenAI-style APIs expect.
+func toolArgsJSON(args interface{}) string {
+	if args == nil {
+		return "{}"
+	}
+	if raw, ok := args.(string); ok {
+		return raw
+	}
+	data, err := json.Marshal(args)
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
+
+// toolArgsMap decodes streamed tool arguments, tolerating empty payloads.
+func toolArgsMap(raw string) map[string]interface{} {
+	args := map[string]interface{}{}
+	if strings.TrimSpace(raw) == "" {
+		return args
+	}
+	if err := json.Unmarshal([]byte(raw), &args); err != nil {
+		return map[string]interface{}{"_raw": raw}
+	}
+	return args
+}
+
+// toolArgsObject returns tool call arguments as a JSON object for APIs that
+// take structured input (Anthropic, Gemini).
+func toolArgsObject(args interface{}) map[string]interface{} {
+	switch v := args.(type) {
+	case map[string]interface{}:
+		return v
+	case string:
+		return toolArgsMap(v)
+	case nil:
+		return map[string]interface{}{}
+	default:
+		return toolArgsMap(toolArgsJSON(v))
+	}
+}
+
+// ---- OpenAI-compatible wire format (OpenAI, Groq) ----
+
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    string           `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+type openAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type openAIToolCallDelta struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id,omitempty"`
+	Function struct {
+		Name      string `json:"name,omitempty"`
+		Arguments string `json:"arguments,omitempty"`
+	} `json:"function"`
+}
+
+func toOpenAIMessages(messages []gateway.Message) []openAIMessage {
+	result := make([]openAIMessage, 0, len(messages))
+	for _, msg := range messages {
+		out := openAIMessage{
+			Role:       msg.Role,
+			Content:    msg.Content,
+			ToolCallID: msg.ToolCallID,
+		}
+		for _, call := range msg.ToolCalls {
+			tc := openAIToolCall{ID: call.ID, Type: "function"}
+			tc.Function.Name = call.Name
+			tc.Function.Arguments = toolArgsJSON(call.Args)
+			out.ToolCalls = append(out.ToolCalls, tc)
+		}
+		result = append(result, out)
+	}
+	return result
+}
+
+func toOpenAITools(tools []gateway.Tool) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(tools))
+	for _, tool := range tools {
+		result = append(result, map[string]interface{}{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name":        tool.Name,
+				"description": tool.Description,
+				"parameters":  toolParameters(tool),
+			},
+		})
+	}
+	return result
+}
+
+// openAIToolAccumulator joins the fragments of streamed tool calls, which
+// arrive keyed by index with the arguments split across chunks.
+type openAIToolAccumulator struct {
+	calls map[int]*toolCallBuffer
+}
+
+type toolCallBuffer struct {
+	id   string
+	name string
+	args strings.Builder
+}
+
+func (a *openAIToolAccumulator) add(deltas []openAIToolCallDelta) {
+	if a.calls == nil {
+		a.calls = make(map[int]*toolCallBuffer)
+	}
+	for _, delta := range deltas {
+		buf, ok := a.calls[delta.Index]
+		if !ok {
+			buf = &toolCallBuffer{}
+			a.calls[delta.Index] = buf
+		}
+		if delta.ID != "" {
+			buf.id = delta.ID
+		}
+		if delta.Function.Name != "" {
+			buf.name += delta.Function.Name
+		}
+		buf.args.WriteString(delta.Function.Arguments)
+	}
+}
+
+func (a *openAIToolAccumulator) toolCalls() []gateway.ToolCall {
+	indexes := make([]int, 0, len(a.calls))
+	for idx := range a.calls {
+		indexes = append(indexes, idx)
+	}
+	sort.Ints(indexes)
+
+	calls := make([]gateway.ToolCall, 0, len(indexes))
+	for _, idx := range indexes {
+		buf := a.calls[idx]
+		id := buf.id
+		if id == "" {
+			id = fmt.Sprintf("call_%d", idx)
+		}
+		calls = append(calls, gateway.ToolCall{ID: id, Name: buf.name, Args: toolArgsMap(buf.args.String())})
+	}
+	return calls
+}
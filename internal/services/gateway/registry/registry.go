@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
 	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
@@ -17,10 +18,21 @@ type Registry struct {
 	mu          sync.RWMutex
 	entries     map[string]*gateway.ProviderEntry
 	providerSvc svc.ProvidersService
+	health      map[string]*entryHealth
+	cooldown    time.Duration
 }
 
 var ErrProviderNotFound = errors.New("gateway: provider not found")
 
+// DefaultCooldown is how long routing skips an entry after it failed.
+const DefaultCooldown = 30 * time.Second
+
+// entryHealth survives reloads so a flapping provider stays benched.
+type entryHealth struct {
+	until     time.Time
+	lastError string
+}
+
 type providerPayload struct {
 	Type         string                 `json:"type"`
 	BaseURL      string                 `json:"base_url"`
@@ -34,7 +46,43 @@ func New(providerSvc svc.ProvidersService) *Registry {
 	return &Registry{
 		entries:     make(map[string]*gateway.ProviderEntry),
 		providerSvc: providerSvc,
+		health:      make(map[string]*entryHealth),
+		cooldown:    DefaultCooldown,
+	}
+}
+
+// SetCooldown changes how long failed entries are skipped. A non-positive
+// value uses DefaultCooldown.
+func (r *Registry) SetCooldown(d time.Duration) {
+	if d <= 0 {
+		d = DefaultCooldown
 	}
+	r.mu.Lock()
+	r.cooldown = d
+	r.mu.Unlock()
+}
+
+// MarkUnhealthy benches the entry for the cool-down period.
+func (r *Registry) MarkUnhealthy(name string, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.health[name] = &entryHealth{until: time.Now().Add(r.cooldown), lastError: reason}
+	gl.Log("warn", "gateway provider benched", name, reason)
+}
+
+// MarkHealthy clears the cool-down of the entry.
+func (r *Registry) MarkHealthy(name string) {
+	r.mu.Lock()
+	delete(r.health, name)
+	r.mu.Unlock()
+}
+
+// CoolingDown reports whether the entry is benched after a recent failure.
+func (r *Registry) CoolingDown(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	h, ok := r.health[name]
+	return ok && time.Now().Before(h.until)
 }
 
 func (r *Registry) Reload() error {
@@ -137,6 +185,12 @@ func (r *Registry) Summaries() []gateway.ProviderSummary {
 				summary.Available = true
 			}
 		}
+		if h, ok := r.health[name]; ok && time.Now().Before(h.until) {
+			until := h.until
+			summary.Available = false
+			summary.LastError = h.lastError
+			summary.CooldownUntil = &until
+		}
 
 		summaries = append(summaries, summary)
 	}
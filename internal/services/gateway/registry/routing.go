@@ -0,0 +1,357 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"time"
+
+	"github.com/kubex-ecosystem/gobe/internal/config"
+	t "github.com/kubex-ecosystem/gobe/internal/services/gateway"
+)
+
+// SetRoutes replaces the routing policies. Route names must not be empty and
+// every route needs at least one target.
+func (s *Service) SetRoutes(routes []t.RoutePolicy) error {
+	next := make(map[string]t.RoutePolicy, len(routes))
+	for _, route := range routes {
+		if route.Name == "" {
+			return fmt.Errorf("gateway: route without name")
+		}
+		if len(route.Targets) == 0 {
+			return fmt.Errorf("gateway: route %s has no targets", route.Name)
+		}
+		switch route.Strategy {
+		case "", t.RouteFallback, t.RouteWeighted:
+		default:
+			return fmt.Errorf("gateway: route %s has unknown strategy %q", route.Name, route.Strategy)
+		}
+		if _, dup := next[route.Name]; dup {
+			return fmt.Errorf("gateway: route %s declared twice", route.Name)
+		}
+		next[route.Name] = route
+	}
+
+	s.mu.Lock()
+	s.routes = next
+	s.mu.Unlock()
+	return nil
+}
+
+// Routes returns the routing policies sorted by name.
+func (s *Service) Routes() []t.RoutePolicy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	routes := make([]t.RoutePolicy, 0, len(s.routes))
+	for _, route := range s.routes {
+		routes = append(routes, route)
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].Name < routes[j].Name })
+	return routes
+}
+
+// SetRetryPolicy sets the retry policy of routes without their own and of
+// requests addressed to a single entry.
+func (s *Service) SetRetryPolicy(policy t.RetryPolicy) {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 1
+	}
+	s.mu.Lock()
+	s.retry = policy
+	s.mu.Unlock()
+}
+
+// SetCooldown changes how long a failing entry is skipped by routing.
+func (s *Service) SetCooldown(d time.Duration) {
+	s.registry.SetCooldown(d)
+}
+
+// router builds the provider that serves req: a route when req.Provider (or,
+// without provider, req.Model) names one, otherwise the named entry alone.
+func (s *Service) router(req t.ChatRequest) (*routedProvider, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	name := req.Provider
+	if name == "" {
+		name = req.Model
+	}
+	if route, ok := s.routes[name]; ok {
+		retry := s.retry
+		if route.Retry != nil {
+			retry = *route.Retry
+		}
+		return &routedProvider{registry: s.registry, route: route.Name, targets: orderTargets(route), retry: retry}, nil
+	}
+
+	if req.Provider == "" {
+		return nil, fmt.Errorf("gateway: chat request missing provider")
+	}
+	return &routedProvider{
+		registry: s.registry,
+		route:    req.Provider,
+		targets:  []t.RouteTarget{{Provider: req.Provider, Model: req.Model}},
+		retry:    s.retry,
+	}, nil
+}
+
+// orderTargets returns the targets in the order they are tried. Weighted
+// routes draw targets by weight without replacement.
+func orderTargets(route t.RoutePolicy) []t.RouteTarget {
+	targets := append([]t.RouteTarget(nil), route.Targets...)
+	if route.Strategy != t.RouteWeighted {
+		return targets
+	}
+
+	ordered := make([]t.RouteTarget, 0, len(targets))
+	for len(targets) > 0 {
+		total := 0
+		for _, target := range targets {
+			total += targetWeight(target)
+		}
+		pick := rand.IntN(total)
+		for i, target := range targets {
+			pick -= targetWeight(target)
+			if pick < 0 {
+				ordered = append(ordered, target)
+				targets = append(targets[:i], targets[i+1:]...)
+				break
+			}
+		}
+	}
+	return ordered
+}
+
+func targetWeight(target t.RouteTarget) int {
+	if target.Weight <= 0 {
+		return 1
+	}
+	return target.Weight
+}
+
+// routedProvider tries the targets of a route until one starts answering.
+// Once a target answered, later calls (tool-loop steps) stick to it.
+type routedProvider struct {
+	registry *Registry
+	route    string
+	targets  []t.RouteTarget
+	retry    t.RetryPolicy
+	chosen   *t.ProviderEntry
+	model    string
+}
+
+func (p *routedProvider) Name() string {
+	if p.chosen != nil {
+		return p.chosen.Config.Name
+	}
+	return p.route
+}
+
+func (p *routedProvider) Available() error {
+	return nil
+}
+
+func (p *routedProvider) Notify(ctx context.Context, event t.NotificationEvent) error {
+	if p.chosen == nil {
+		return nil
+	}
+	return p.chosen.Provider.Notify(ctx, event)
+}
+
+func (p *routedProvider) Chat(ctx context.Context, req t.ChatRequest) (<-chan t.ChatChunk, error) {
+	targets := p.targets
+	if p.chosen != nil {
+		targets = []t.RouteTarget{{Provider: p.chosen.Config.Name, Model: p.model}}
+	} else {
+		targets = p.healthyFirst(targets)
+	}
+
+	routeErr := &t.RouteError{Route: p.route}
+	for _, target := range targets {
+		entry, err := p.registry.Resolve(target.Provider)
+		if err != nil {
+			routeErr.Attempts = append(routeErr.Attempts, t.Attempt{Provider: target.Provider, Error: err.Error()})
+			routeErr.Err = err
+			continue
+		}
+		if err := entry.Provider.Available(); err != nil {
+			p.registry.MarkUnhealthy(entry.Config.Name, err.Error())
+			routeErr.Attempts = append(routeErr.Attempts, t.Attempt{Provider: entry.Config.Name, Error: err.Error()})
+			routeErr.Err = err
+			continue
+		}
+
+		model := target.Model
+		if model == "" {
+			model = entry.Config.DefaultModel
+		}
+		callReq := req
+		callReq.Provider = entry.Config.Name
+		callReq.Model = model
+
+		for try := 1; ; try++ {
+			start := time.Now()
+			attempt := t.Attempt{Provider: entry.Config.Name, Model: model}
+
+			stream, err := entry.Provider.Chat(ctx, callReq)
+			if err != nil {
+				// Rejected before sending; another attempt would fail the same way
+				attempt.Error = err.Error()
+				routeErr.Attempts = append(routeErr.Attempts, attempt)
+				routeErr.Err = err
+				break
+			}
+
+			var first t.ChatChunk
+			var open bool
+			select {
+			case first, open = <-stream:
+			case <-ctx.Done():
+				drain(stream)
+				return nil, ctx.Err()
+			}
+			attempt.LatencyMS = time.Since(start).Milliseconds()
+
+			if open && first.Error != "" {
+				attempt.StatusCode = first.StatusCode
+				attempt.Error = first.Error
+				routeErr.Attempts = append(routeErr.Attempts, attempt)
+				routeErr.Err = errors.New(first.Error)
+				drain(stream)
+
+				if !t.Retryable(first) {
+					break
+				}
+				if try >= p.retry.MaxAttempts {
+					p.registry.MarkUnhealthy(entry.Config.Name, first.Error)
+					break
+				}
+				select {
+				case <-time.After(p.retry.Delay(try)):
+					continue
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+			}
+
+			p.registry.MarkHealthy(entry.Config.Name)
+			p.chosen = entry
+			p.model = model
+			attempts := append(routeErr.Attempts, attempt)
+			return relayRouted(ctx, first, open, stream, entry.Config.Name, model, attempts), nil
+		}
+	}
+
+	return nil, routeErr
+}
+
+// healthyFirst moves benched entries to the end: they are only tried when
+// every healthy target failed.
+func (p *routedProvider) healthyFirst(targets []t.RouteTarget) []t.RouteTarget {
+	healthy := make([]t.RouteTarget, 0, len(targets))
+	var benched []t.RouteTarget
+	for _, target := range targets {
+		if p.registry.CoolingDown(target.Provider) {
+			benched = append(benched, target)
+			continue
+		}
+		healthy = append(healthy, target)
+	}
+	return append(healthy, benched...)
+}
+
+// relayRouted re-emits the stream of the chosen target, recording the
+// routing attempts in its usage.
+func relayRouted(ctx context.Context, first t.ChatChunk, open bool, stream <-chan t.ChatChunk, provider, model string, attempts []t.Attempt) <-chan t.ChatChunk {
+	out := make(chan t.ChatChunk, 32)
+	go func() {
+		defer close(out)
+
+		forward := func(chunk t.ChatChunk) bool {
+			if chunk.Done && chunk.Usage == nil {
+				chunk.Usage = &t.Usage{Provider: provider, Model: model}
+			}
+			if chunk.Usage != nil {
+				usage := *chunk.Usage
+				if usage.Provider == "" {
+					usage.Provider = provider
+				}
+				usage.Attempts = attempts
+				chunk.Usage = &usage
+			}
+			select {
+			case out <- chunk:
+				return true
+			case <-ctx.Done():
+				drain(stream)
+				return false
+			}
+		}
+
+		if !open {
+			return
+		}
+		if !forward(first) {
+			return
+		}
+		for chunk := range stream {
+			if !forward(chunk) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// drain consumes the rest of a provider stream so its goroutine can exit.
+func drain(stream <-chan t.ChatChunk) {
+	go func() {
+		for range stream {
+		}
+	}()
+}
+
+// ApplyConfig installs the routes, retry policy and cool-down of cfg.
+// Unset values keep their defaults.
+func (s *Service) ApplyConfig(cfg config.GatewayConfig) error {
+	routes := make([]t.RoutePolicy, 0, len(cfg.Routes))
+	for _, rc := range cfg.Routes {
+		route := t.RoutePolicy{Name: rc.Name, Strategy: rc.Strategy}
+		for _, target := range rc.Targets {
+			route.Targets = append(route.Targets, t.RouteTarget{Provider: target.Provider, Model: target.Model, Weight: target.Weight})
+		}
+		if rc.Retry != nil {
+			retry := retryPolicy(*rc.Retry)
+			route.Retry = &retry
+		}
+		routes = append(routes, route)
+	}
+	if err := s.SetRoutes(routes); err != nil {
+		return err
+	}
+
+	if cfg.Retry != (config.GatewayRetryConfig{}) {
+		s.SetRetryPolicy(retryPolicy(cfg.Retry))
+	}
+	if cfg.CooldownSeconds > 0 {
+		s.SetCooldown(time.Duration(cfg.CooldownSeconds) * time.Second)
+	}
+	return nil
+}
+
+func retryPolicy(rc config.GatewayRetryConfig) t.RetryPolicy {
+	policy := t.DefaultRetryPolicy
+	if rc.MaxAttempts > 0 {
+		policy.MaxAttempts = rc.MaxAttempts
+	}
+	if rc.BackoffMS > 0 {
+		policy.Backoff = time.Duration(rc.BackoffMS) * time.Millisecond
+	}
+	if rc.MaxBackoffMS > 0 {
+		policy.MaxBackoff = time.Duration(rc.MaxBackoffMS) * time.Millisecond
+	}
+	return policy
+}
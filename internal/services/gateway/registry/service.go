@@ -2,15 +2,23 @@ package registry
 
 import (
 	"context"
-	"fmt"
+	"sync"
 
 	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
 
 	t "github.com/kubex-ecosystem/gobe/internal/services/gateway"
+	"github.com/kubex-ecosystem/gobe/internal/services/gateway/cache"
 )
 
 type Service struct {
 	registry *Registry
+
+	mu       sync.RWMutex
+	routes   map[string]t.RoutePolicy
+	retry    t.RetryPolicy
+	recorder t.UsageRecorder
+	cache    *cache.Cache
 }
 
 func NewService(providerSvc svc.ProvidersService) (*Service, error) {
@@ -18,30 +26,126 @@ func NewService(providerSvc svc.ProvidersService) (*Service, error) {
 	if err := reg.Reload(); err != nil {
 		return nil, err
 	}
-	return &Service{registry: reg}, nil
+	return &Service{
+		registry: reg,
+		routes:   make(map[string]t.RoutePolicy),
+		retry:    t.DefaultRetryPolicy,
+	}, nil
 }
 
+// Chat streams a completion. req.Provider names a registry entry or a route;
+// failed calls are retried and routes fall back to their other targets
+// before anything is streamed. The returned config is the entry that answered.
+// Cacheable requests are answered from the response cache when possible.
 func (s *Service) Chat(ctx context.Context, req t.ChatRequest) (<-chan t.ChatChunk, t.ProviderConfig, error) {
-	if req.Provider == "" {
-		return nil, t.ProviderConfig{}, fmt.Errorf("gateway: chat request missing provider")
+	router, err := s.router(req)
+	if err != nil {
+		return nil, t.ProviderConfig{}, err
+	}
+
+	responses := s.responseCache()
+	cacheable := responses != nil && responses.Cacheable(ctx, req)
+	if cacheable {
+		if entry, ok := responses.Lookup(ctx, req); ok {
+			cfg, err := s.registry.ConfigFor(entry.Provider)
+			if err != nil {
+				cfg = t.ProviderConfig{Name: entry.Provider, DefaultModel: entry.Model}
+			}
+			return s.metered(ctx, req, responses.Replay(ctx, entry)), cfg, nil
+		}
 	}
 
-	entry, err := s.registry.Resolve(req.Provider)
+	stream, err := router.Chat(ctx, req)
 	if err != nil {
 		return nil, t.ProviderConfig{}, err
 	}
+	if cacheable {
+		stream = responses.Record(ctx, req, router.chosen.Config.Name, stream)
+	}
+
+	return s.metered(ctx, req, stream), router.chosen.Config, nil
+}
 
-	config := entry.Config
-	if req.Model == "" {
-		req.Model = config.DefaultModel
+// ChatWithTools runs a chat in which the model may call the tools of executor.
+// See gateway.RunToolLoop for the step semantics. Every step goes to the
+// entry that answered the first one. Tool calls may have side effects, so
+// chats run with an executor never use the response cache.
+func (s *Service) ChatWithTools(ctx context.Context, req t.ChatRequest, executor t.ToolExecutor, maxSteps int) (<-chan t.ChatChunk, t.ProviderConfig, error) {
+	if executor == nil {
+		return s.Chat(ctx, req)
 	}
 
-	stream, err := entry.Provider.Chat(ctx, req)
+	router, err := s.router(req)
 	if err != nil {
 		return nil, t.ProviderConfig{}, err
 	}
 
-	return stream, config, nil
+	stream, err := t.RunToolLoop(ctx, router, req, executor, maxSteps)
+	if err != nil {
+		return nil, t.ProviderConfig{}, err
+	}
+	return s.metered(ctx, req, stream), router.chosen.Config, nil
+}
+
+// SetUsageRecorder makes every finished chat report its usage to recorder,
+// which may price it before it reaches the client.
+func (s *Service) SetUsageRecorder(recorder t.UsageRecorder) {
+	s.mu.Lock()
+	s.recorder = recorder
+	s.mu.Unlock()
+}
+
+// SetCache makes cacheable chats go through responses; nil disables caching.
+func (s *Service) SetCache(responses *cache.Cache) {
+	s.mu.Lock()
+	s.cache = responses
+	s.mu.Unlock()
+}
+
+// CacheStats returns the response cache counters, or false when caching is
+// disabled.
+func (s *Service) CacheStats() (cache.Stats, bool) {
+	responses := s.responseCache()
+	if responses == nil {
+		return cache.Stats{}, false
+	}
+	return responses.Stats(), true
+}
+
+func (s *Service) responseCache() *cache.Cache {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cache
+}
+
+// metered hands the final usage of stream to the usage recorder, if any.
+func (s *Service) metered(ctx context.Context, req t.ChatRequest, stream <-chan t.ChatChunk) <-chan t.ChatChunk {
+	s.mu.RLock()
+	recorder := s.recorder
+	s.mu.RUnlock()
+	if recorder == nil {
+		return stream
+	}
+
+	out := make(chan t.ChatChunk, 32)
+	go func() {
+		defer close(out)
+		for chunk := range stream {
+			if chunk.Done && chunk.Usage != nil {
+				usage := *chunk.Usage
+				// The call was paid for even if the client already left
+				recorder.RecordUsage(context.WithoutCancel(ctx), req, &usage)
+				chunk.Usage = &usage
+			}
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				drain(stream)
+				return
+			}
+		}
+	}()
+	return out
 }
 
 func (s *Service) ProviderSummaries() []t.ProviderSummary {
@@ -52,6 +156,31 @@ func (s *Service) ProviderConfig(name string) (t.ProviderConfig, error) {
 	return s.registry.ConfigFor(name)
 }
 
+// DiscoverModels asks the entries able to list their backend's models (local
+// Ollama and OpenAI-compatible servers) for them, keyed by entry name.
+// Unreachable backends are logged and left out.
+func (s *Service) DiscoverModels(ctx context.Context) map[string][]string {
+	s.registry.mu.RLock()
+	listers := make(map[string]t.ModelLister)
+	for name, entry := range s.registry.entries {
+		if lister, ok := entry.Provider.(t.ModelLister); ok {
+			listers[name] = lister
+		}
+	}
+	s.registry.mu.RUnlock()
+
+	discovered := make(map[string][]string, len(listers))
+	for name, lister := range listers {
+		models, err := lister.Models(ctx)
+		if err != nil {
+			gl.Log("warn", "gateway model discovery failed", name, err)
+			continue
+		}
+		discovered[name] = models
+	}
+	return discovered
+}
+
 func (s *Service) Reload() error {
 	return s.registry.Reload()
 }
@@ -0,0 +1,80 @@
+package gateway
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kubex-ecosystem/gobe/internal/utils/backoff"
+)
+
+// Routing strategies for a RoutePolicy.
+const (
+	// RouteFallback tries the targets in the declared order.
+	RouteFallback = "fallback"
+	// RouteWeighted picks the first target at random by weight; the others
+	// remain fallbacks in weighted order.
+	RouteWeighted = "weighted"
+)
+
+// RoutePolicy maps a logical model name to the provider entries that can
+// serve it. Requests naming the policy as provider (or as model, when no
+// provider is given) are routed through its targets.
+type RoutePolicy struct {
+	Name     string        `json:"name"`
+	Strategy string        `json:"strategy,omitempty"`
+	Targets  []RouteTarget `json:"targets"`
+	Retry    *RetryPolicy  `json:"retry,omitempty"`
+}
+
+// RouteTarget is one provider entry of a route. An empty Model uses the
+// entry's default model.
+type RouteTarget struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model,omitempty"`
+	Weight   int    `json:"weight,omitempty"`
+}
+
+// RetryPolicy controls how often a target is retried after a retryable
+// failure (HTTP 429, 5xx or a transport error) before moving on.
+type RetryPolicy struct {
+	MaxAttempts int           `json:"max_attempts"`
+	Backoff     time.Duration `json:"backoff"`
+	MaxBackoff  time.Duration `json:"max_backoff"`
+}
+
+// DefaultRetryPolicy retries a failing target once after a short pause.
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 2, Backoff: 250 * time.Millisecond, MaxBackoff: 2 * time.Second}
+
+// Delay returns the pause before the given retry (1 for the first retry),
+// doubling from Backoff up to MaxBackoff.
+func (p RetryPolicy) Delay(retry int) time.Duration {
+	return backoff.Delay(retry, p.Backoff, p.MaxBackoff)
+}
+
+// Retryable reports whether a failed first chunk is worth retrying on the
+// same provider: rate limits, server errors and failures without a status.
+func Retryable(chunk ChatChunk) bool {
+	return chunk.StatusCode == 0 || chunk.StatusCode == 429 || chunk.StatusCode >= 500
+}
+
+// RouteError is returned when every target of a route failed. Err is the
+// last failure.
+type RouteError struct {
+	Route    string
+	Attempts []Attempt
+	Err      error
+}
+
+func (e *RouteError) Unwrap() error { return e.Err }
+
+func (e *RouteError) Error() string {
+	if len(e.Attempts) == 0 {
+		return fmt.Sprintf("gateway: no provider available for %s", e.Route)
+	}
+	parts := make([]string, 0, len(e.Attempts))
+	for _, attempt := range e.Attempts {
+		parts = append(parts, attempt.Provider+": "+attempt.Error)
+	}
+	return fmt.Sprintf("gateway: all providers failed for %s (%s)", e.Route, strings.Join(parts, "; "))
+}
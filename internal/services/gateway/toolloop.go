@@ -0,0 +1,163 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DefaultMaxToolSteps bounds the model→tool→model round trips of RunToolLoop.
+const DefaultMaxToolSteps = 5
+
+// RunToolLoop runs a chat in which the model may call the tools of executor.
+// Each step streams the model output and its tool calls; the calls are run and
+// their results fed back until the model answers without calling tools or
+// maxSteps is reached. Usage of every step is summed into the final chunk.
+func RunToolLoop(ctx context.Context, provider Provider, req ChatRequest, executor ToolExecutor, maxSteps int) (<-chan ChatChunk, error) {
+	if maxSteps <= 0 {
+		maxSteps = DefaultMaxToolSteps
+	}
+	req.Tools = executor.Tools()
+
+	// The first call is made up front so configuration errors surface to the caller
+	first, err := provider.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan ChatChunk, 32)
+	go func() {
+		defer close(out)
+
+		messages := append([]Message(nil), req.Messages...)
+		total := &Usage{Provider: provider.Name(), Model: req.Model}
+		stream := first
+
+		for step := 1; ; step++ {
+			content, calls, ok := relayToolStep(ctx, stream, out, step, total)
+			if !ok {
+				return
+			}
+			if len(calls) == 0 {
+				emit(ctx, out, ChatChunk{Done: true, Usage: total, Step: step})
+				return
+			}
+			if step >= maxSteps {
+				emit(ctx, out, ChatChunk{Done: true, Usage: total, Step: step, Error: fmt.Sprintf("gateway: tool loop stopped after %d steps", maxSteps)})
+				return
+			}
+
+			messages = append(messages, Message{Role: "assistant", Content: content, ToolCalls: calls})
+			for _, call := range calls {
+				result := runToolCall(ctx, executor, call)
+				if !emit(ctx, out, ChatChunk{ToolResult: result, Step: step}) {
+					return
+				}
+				messages = append(messages, Message{
+					Role:       "tool",
+					Content:    toolResultContent(result),
+					ToolCallID: call.ID,
+					Name:       call.Name,
+				})
+			}
+
+			next := req
+			next.Messages = messages
+			stream, err = provider.Chat(ctx, next)
+			if err != nil {
+				emit(ctx, out, ChatChunk{Done: true, Usage: total, Step: step + 1, Error: err.Error()})
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// relayToolStep forwards one provider stream, collecting its text and tool
+// calls. It returns ok=false when the stream failed or the client went away.
+func relayToolStep(ctx context.Context, stream <-chan ChatChunk, out chan<- ChatChunk, step int, total *Usage) (string, []ToolCall, bool) {
+	var content strings.Builder
+	var calls []ToolCall
+
+	for chunk := range stream {
+		if chunk.Usage != nil {
+			total.PromptTokens += chunk.Usage.PromptTokens
+			total.CompletionTokens += chunk.Usage.CompletionTokens
+			total.TotalTokens += chunk.Usage.TotalTokens
+			total.LatencyMS += chunk.Usage.LatencyMS
+			total.CostUSD += chunk.Usage.CostUSD
+			if chunk.Usage.Model != "" {
+				total.Model = chunk.Usage.Model
+			}
+			total.Attempts = append(total.Attempts, chunk.Usage.Attempts...)
+		}
+		if chunk.Error != "" {
+			emit(ctx, out, ChatChunk{Done: true, Error: chunk.Error, Usage: total, Step: step})
+			drain(stream)
+			return "", nil, false
+		}
+		if chunk.Content != "" {
+			content.WriteString(chunk.Content)
+			if !emit(ctx, out, ChatChunk{Content: chunk.Content, Step: step}) {
+				drain(stream)
+				return "", nil, false
+			}
+		}
+		if chunk.ToolCall != nil {
+			calls = append(calls, *chunk.ToolCall)
+			if !emit(ctx, out, ChatChunk{ToolCall: chunk.ToolCall, Step: step}) {
+				drain(stream)
+				return "", nil, false
+			}
+		}
+	}
+	return content.String(), calls, ctx.Err() == nil
+}
+
+// drain consumes the rest of a provider stream so its goroutine can exit.
+func drain(stream <-chan ChatChunk) {
+	go func() {
+		for range stream {
+		}
+	}()
+}
+
+func runToolCall(ctx context.Context, executor ToolExecutor, call ToolCall) *ToolResult {
+	args, _ := call.Args.(map[string]interface{})
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+
+	result := &ToolResult{ID: call.ID, Name: call.Name}
+	value, err := executor.Exec(ctx, call.Name, args)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Result = value
+	return result
+}
+
+// toolResultContent renders a tool result as the text fed back to the model.
+func toolResultContent(result *ToolResult) string {
+	payload := map[string]interface{}{"result": result.Result}
+	if result.Error != "" {
+		payload = map[string]interface{}{"error": result.Error}
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Sprintf(`{"error":%q}`, err.Error())
+	}
+	return string(data)
+}
+
+func emit(ctx context.Context, out chan<- ChatChunk, chunk ChatChunk) bool {
+	select {
+	case out <- chunk:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
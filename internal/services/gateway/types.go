@@ -1,11 +1,20 @@
 // Package gateway defines interfaces and types for interacting with various AI model providers.
 package gateway
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
 
+// Message is one turn of a conversation. Assistant turns that call tools carry
+// ToolCalls; the answers go back as "tool" turns referencing ToolCallID.
 type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role       string     `json:"role"`
+	Content    string     `json:"content"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+	Name       string     `json:"name,omitempty"`
 }
 
 type ChatRequest struct {
@@ -16,29 +25,70 @@ type ChatRequest struct {
 	Stream      bool                   `json:"stream"`
 	Meta        map[string]interface{} `json:"meta,omitempty"`
 	Headers     map[string]string      `json:"-"`
+	Tools       []Tool                 `json:"tools,omitempty"`
+}
+
+// Tool describes a function the model may call. Parameters is a JSON Schema
+// object; providers translate it to their native tool format.
+type Tool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
 }
 
 type ToolCall struct {
+	ID   string      `json:"id,omitempty"`
 	Name string      `json:"name"`
 	Args interface{} `json:"args"`
 }
 
+// ToolResult is the outcome of running a ToolCall, fed back to the model.
+type ToolResult struct {
+	ID     string      `json:"id,omitempty"`
+	Name   string      `json:"name"`
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// ToolExecutor exposes tools to a chat and runs the calls the model makes.
+type ToolExecutor interface {
+	Tools() []Tool
+	Exec(ctx context.Context, name string, args map[string]interface{}) (interface{}, error)
+}
+
 type Usage struct {
-	PromptTokens     int     `json:"prompt_tokens"`
-	CompletionTokens int     `json:"completion_tokens"`
-	TotalTokens      int     `json:"total_tokens"`
-	LatencyMS        int64   `json:"latency_ms"`
-	CostUSD          float64 `json:"cost_usd"`
-	Provider         string  `json:"provider"`
-	Model            string  `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	TotalTokens      int       `json:"total_tokens"`
+	LatencyMS        int64     `json:"latency_ms"`
+	CostUSD          float64   `json:"cost_usd"`
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model"`
+	Attempts         []Attempt `json:"attempts,omitempty"`
+	// Cached is set when the answer was replayed from the response cache.
+	Cached bool `json:"cached,omitempty"`
+}
+
+// Attempt records one provider call made while routing a request. The
+// successful call, if any, is the last one.
+type Attempt struct {
+	Provider   string `json:"provider"`
+	Model      string `json:"model,omitempty"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+	LatencyMS  int64  `json:"latency_ms"`
 }
 
 type ChatChunk struct {
-	Content  string    `json:"content,omitempty"`
-	Done     bool      `json:"done"`
-	Usage    *Usage    `json:"usage,omitempty"`
-	Error    string    `json:"error,omitempty"`
-	ToolCall *ToolCall `json:"tool_call,omitempty"`
+	Content    string      `json:"content,omitempty"`
+	Done       bool        `json:"done"`
+	Usage      *Usage      `json:"usage,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	ToolCall   *ToolCall   `json:"tool_call,omitempty"`
+	ToolResult *ToolResult `json:"tool_result,omitempty"`
+	Step       int         `json:"step,omitempty"`
+	// StatusCode is the upstream HTTP status of a failed request, if any.
+	StatusCode int `json:"status_code,omitempty"`
 }
 
 type NotificationEvent struct {
@@ -53,6 +103,18 @@ type Provider interface {
 	Notify(ctx context.Context, event NotificationEvent) error
 }
 
+// UsageRecorder prices and stores the usage of finished chat calls. It may
+// fill usage.CostUSD before the usage is streamed to the client.
+type UsageRecorder interface {
+	RecordUsage(ctx context.Context, req ChatRequest, usage *Usage)
+}
+
+// ModelLister is implemented by providers that can discover the models their
+// backend serves, such as local Ollama or OpenAI-compatible servers.
+type ModelLister interface {
+	Models(ctx context.Context) ([]string, error)
+}
+
 type ProviderConfig struct {
 	Name         string                 `json:"name"`
 	Type         string                 `json:"type"`
@@ -72,6 +134,8 @@ type ProviderSummary struct {
 	Available    bool                   `json:"available"`
 	LastError    string                 `json:"last_error,omitempty"`
 	Metadata     map[string]interface{} `json:"metadata,omitempty"`
+	// CooldownUntil is set while routing skips the entry after a failure.
+	CooldownUntil *time.Time `json:"cooldown_until,omitempty"`
 }
 
 type ProviderEntry struct {
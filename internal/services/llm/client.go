@@ -1,4 +1,5 @@
-// Package llm provides a client for interacting with LLM APIs (OpenAI, Gemini) to analyze Discord messages.
+// Package llm provides a client for interacting with LLM APIs (OpenAI, Gemini, Groq and local
+// OpenAI-compatible servers such as Ollama) to analyze Discord messages.
 package llm
 
 import (
@@ -27,7 +28,7 @@ type Client struct {
 	config     config.LLMConfig
 	cache      *cache.Cache
 	devMode    bool
-	provider   string // "openai", "gemini", "groq", "dev"
+	provider   string // "openai", "gemini", "groq", "ollama", "openai-compatible", "dev"
 	httpClient *http.Client
 	mu         sync.Mutex
 }
@@ -107,8 +108,11 @@ func NewClient(config config.LLMConfig) (*Client, error) {
 		}
 	}
 
+	// Local servers run without keys, so they never fall back to development mode
+	local := detectedProvider == "ollama" || detectedProvider == "openai-compatible"
+
 	// Set development mode if no valid API key
-	devMode := apiKey == "dev_api_key" || apiKey == "" || detectedProvider == ""
+	devMode := !local && (apiKey == "dev_api_key" || apiKey == "" || detectedProvider == "")
 	if devMode {
 		detectedProvider = "dev"
 		apiKey = "dev_api_key"
@@ -123,6 +127,9 @@ func NewClient(config config.LLMConfig) (*Client, error) {
 		gl.Log("debug", fmt.Sprintf("   APIKey: '%s' (len=%d)", apiKey, len(apiKey)))
 	}
 	gl.Log("info", fmt.Sprintf("   Model: %s", config.Model))
+	if local {
+		gl.Log("info", fmt.Sprintf("   BaseURL: %s", config.BaseURL))
+	}
 	gl.Log("info", fmt.Sprintf("   Temperature: %.2f", config.Temperature))
 	gl.Log("info", fmt.Sprintf("   MaxTokens: %d", config.MaxTokens))
 	gl.Log("info", fmt.Sprintf("   TopP: %.2f", config.TopP))
@@ -132,7 +139,7 @@ func NewClient(config config.LLMConfig) (*Client, error) {
 	gl.Log("info", fmt.Sprintf("   DevMode: %v", devMode))
 
 	// Validate provider
-	validProviders := []string{"openai", "gemini", "groq", "dev"}
+	validProviders := []string{"openai", "gemini", "groq", "ollama", "openai-compatible", "dev"}
 	isValidProvider := false
 	for _, vp := range validProviders {
 		if detectedProvider == vp {
@@ -166,6 +173,13 @@ func NewClient(config config.LLMConfig) (*Client, error) {
 	case "groq":
 		// Groq client will be created on-demand in analyzeWithGroq
 		gl.Log("info", "Groq client will be initialized on-demand")
+	case "ollama", "openai-compatible":
+		client, err := newLocalClient(detectedProvider, config.BaseURL, apiKey)
+		if err != nil {
+			return nil, err
+		}
+		openaiClient = client
+		gl.Log("info", fmt.Sprintf("Initialized %s client", detectedProvider))
 	case "dev":
 		gl.Log("info", "Running in development mode - using mock responses")
 	}
@@ -197,7 +211,8 @@ func (c *Client) AnalyzeMessage(ctx context.Context, req AnalysisRequest) (*Anal
 	switch c.provider {
 	case "dev":
 		response = c.mockAnalysis(req)
-	case "openai":
+	case "openai", "ollama", "openai-compatible":
+		// Local servers speak the OpenAI chat completions API
 		response, err = c.analyzeWithOpenAI(ctx, req)
 	case "gemini":
 		response, err = c.analyzeWithGemini(ctx, req)
@@ -342,10 +357,36 @@ func (c *Client) analyzeWithOpenAI(ctx context.Context, req AnalysisRequest) (*A
 	if err != nil {
 		return nil, fmt.Errorf("OpenAI API error: %w", err)
 	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("%s returned no choices", c.provider)
+	}
 
 	return c.parseAnalysisResponse(resp.Choices[0].Message.Content), nil
 }
 
+// newLocalClient builds an OpenAI client for an Ollama or OpenAI-compatible
+// server. Ollama defaults to OLLAMA_HOST or http://localhost:11434; other
+// servers need an explicit base URL. The key is optional.
+func newLocalClient(provider, baseURL, apiKey string) (*openai.Client, error) {
+	baseURL = strings.TrimSpace(baseURL)
+	if baseURL == "" && provider == "ollama" {
+		baseURL = os.Getenv("OLLAMA_HOST")
+		if baseURL == "" {
+			baseURL = "http://localhost:11434"
+		}
+		if !strings.Contains(baseURL, "://") {
+			baseURL = "http://" + baseURL
+		}
+	}
+	if baseURL == "" {
+		return nil, fmt.Errorf("%s provider requires base_url", provider)
+	}
+
+	clientConfig := openai.DefaultConfig(apiKey)
+	clientConfig.BaseURL = strings.TrimSuffix(strings.TrimRight(baseURL, "/"), "/v1") + "/v1"
+	return openai.NewClientWithConfig(clientConfig), nil
+}
+
 // Gemini API structures
 
 type GeminiRequest struct {
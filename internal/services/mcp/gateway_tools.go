@@ -0,0 +1,81 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/kubex-ecosystem/gobe/internal/services/gateway"
+)
+
+// providerToolNameRx is the alphabet OpenAI, Anthropic and Gemini accept for
+// function names; registry names such as "system.status" are rewritten to it.
+var providerToolNameRx = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+type gatewayTools struct {
+	registry Registry
+	tools    []gateway.Tool
+	names    map[string]string // provider name -> registry name
+}
+
+// GatewayTools offers the registry tools matching patterns (names or globs;
+// empty means all) that the caller in ctx is allowed to run. Calls are
+// executed through Registry.Exec, so policies and schemas still apply.
+func GatewayTools(ctx context.Context, reg Registry, patterns []string) gateway.ToolExecutor {
+	specs := reg.List()
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Name < specs[j].Name })
+
+	gt := &gatewayTools{registry: reg, names: make(map[string]string)}
+	for _, spec := range specs {
+		if len(patterns) > 0 && !matchesToolPattern(patterns, spec.Name) {
+			continue
+		}
+		if err := reg.Authorize(ctx, spec.Name); err != nil {
+			continue
+		}
+
+		name := providerToolName(spec.Name, gt.names)
+		gt.names[name] = spec.Name
+
+		description := spec.Description
+		if description == "" {
+			description = spec.Title
+		}
+		params := spec.InputSchema
+		if len(params) == 0 {
+			params = emptyObjectSchema
+		}
+		gt.tools = append(gt.tools, gateway.Tool{Name: name, Description: description, Parameters: params})
+	}
+	return gt
+}
+
+// Tools implements gateway.ToolExecutor.
+func (g *gatewayTools) Tools() []gateway.Tool {
+	return g.tools
+}
+
+// Exec implements gateway.ToolExecutor.
+func (g *gatewayTools) Exec(ctx context.Context, name string, args map[string]interface{}) (interface{}, error) {
+	toolName, ok := g.names[name]
+	if !ok {
+		return nil, fmt.Errorf("tool not offered to the model: %s", name)
+	}
+	return g.registry.Exec(ctx, toolName, args)
+}
+
+// providerToolName maps a registry name to a unique provider-safe name.
+func providerToolName(name string, taken map[string]string) string {
+	base := providerToolNameRx.ReplaceAllString(name, "_")
+	if len(base) > 60 {
+		base = base[:60]
+	}
+	candidate := base
+	for i := 2; ; i++ {
+		if _, exists := taken[candidate]; !exists {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s_%d", base, i)
+	}
+}
@@ -0,0 +1,342 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+	"github.com/kubex-ecosystem/gobe/internal/observers/events"
+)
+
+// DefaultJobTTL is how long the result of a finished job is kept.
+const DefaultJobTTL = 15 * time.Minute
+
+// JobStatus is the lifecycle state of an asynchronous tool call.
+type JobStatus string
+
+const (
+	JobPending   JobStatus = "pending"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+	JobCancelled JobStatus = "cancelled"
+)
+
+// Done reports whether the status is terminal.
+func (s JobStatus) Done() bool {
+	return s == JobSucceeded || s == JobFailed || s == JobCancelled
+}
+
+// Event types published on the event stream for job updates.
+const (
+	EventJobStarted  = "mcp_job_started"
+	EventJobProgress = "mcp_job_progress"
+	EventJobFinished = "mcp_job_finished"
+)
+
+var (
+	// ErrJobNotFound is returned for unknown, expired or foreign job IDs.
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobFinished is returned when cancelling a job that already ended.
+	ErrJobFinished = errors.New("job already finished")
+)
+
+// Job is a snapshot of an asynchronous tool call.
+type Job struct {
+	ID         string      `json:"id"`
+	Tool       string      `json:"tool"`
+	Status     JobStatus   `json:"status"`
+	Progress   float64     `json:"progress"`
+	Total      float64     `json:"total,omitempty"`
+	Message    string      `json:"message,omitempty"`
+	Result     interface{} `json:"result,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	Owner      string      `json:"owner,omitempty"`
+	CreatedAt  time.Time   `json:"created_at"`
+	StartedAt  *time.Time  `json:"started_at,omitempty"`
+	FinishedAt *time.Time  `json:"finished_at,omitempty"`
+	ExpiresAt  *time.Time  `json:"expires_at,omitempty"`
+}
+
+// ProgressFunc receives progress updates from a running tool.
+// total is zero when the amount of work is unknown.
+type ProgressFunc func(progress, total float64, message string)
+
+type progressCtxKey struct{}
+
+// WithProgress attaches a progress receiver to ctx. Receivers already on the
+// context keep getting updates.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	if parent, ok := ctx.Value(progressCtxKey{}).(ProgressFunc); ok {
+		next := fn
+		fn = func(progress, total float64, message string) {
+			parent(progress, total, message)
+			next(progress, total, message)
+		}
+	}
+	return context.WithValue(ctx, progressCtxKey{}, fn)
+}
+
+// ReportProgress lets a tool handler publish its progress. It is a no-op when
+// nobody is listening, so handlers can call it unconditionally.
+func ReportProgress(ctx context.Context, progress, total float64, message string) {
+	if ctx == nil {
+		return
+	}
+	if fn, ok := ctx.Value(progressCtxKey{}).(ProgressFunc); ok {
+		fn(progress, total, message)
+	}
+}
+
+type jobEntry struct {
+	job    Job
+	cancel context.CancelFunc
+}
+
+// JobManager runs registry tools in the background and keeps their results
+// for a limited time.
+type JobManager struct {
+	registry Registry
+	ttl      time.Duration
+
+	mu     sync.Mutex
+	jobs   map[string]*jobEntry
+	stream *events.Stream
+}
+
+// NewJobManager creates a job manager for reg. A non-positive ttl uses DefaultJobTTL.
+func NewJobManager(reg Registry, ttl time.Duration) *JobManager {
+	if ttl <= 0 {
+		ttl = DefaultJobTTL
+	}
+	return &JobManager{
+		registry: reg,
+		ttl:      ttl,
+		jobs:     make(map[string]*jobEntry),
+	}
+}
+
+// SetEventStream publishes job updates on stream. A nil stream disables publishing.
+func (m *JobManager) SetEventStream(stream *events.Stream) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stream = stream
+}
+
+// SetTTL changes the retention of jobs that finish from now on.
+// A non-positive ttl uses DefaultJobTTL.
+func (m *JobManager) SetTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = DefaultJobTTL
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ttl = ttl
+}
+
+// Submit authorizes and validates the call, then runs it in the background.
+// The job outlives ctx but keeps the caller identity it carries.
+func (m *JobManager) Submit(ctx context.Context, toolName string, args map[string]interface{}) (Job, error) {
+	if toolName == "" {
+		return Job{}, fmt.Errorf("tool name cannot be empty")
+	}
+	if err := m.registry.Authorize(ctx, toolName); err != nil {
+		return Job{}, err
+	}
+	if err := m.registry.Validate(toolName, args); err != nil {
+		return Job{}, err
+	}
+
+	jobCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	entry := &jobEntry{
+		job: Job{
+			ID:        uuid.NewString(),
+			Tool:      toolName,
+			Status:    JobPending,
+			Owner:     principalOwner(PrincipalFromContext(ctx)),
+			CreatedAt: time.Now(),
+		},
+		cancel: cancel,
+	}
+	id := entry.job.ID
+	jobCtx = WithProgress(jobCtx, func(progress, total float64, message string) {
+		m.progress(id, progress, total, message)
+	})
+
+	m.mu.Lock()
+	m.pruneLocked(time.Now())
+	m.jobs[id] = entry
+	job := entry.job
+	m.mu.Unlock()
+
+	gl.Log("info", "MCP job submitted", id, toolName)
+	go m.run(jobCtx, entry, args)
+	return job, nil
+}
+
+// Get returns the job with the given ID if the caller in ctx may see it.
+func (m *JobManager) Get(ctx context.Context, id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pruneLocked(time.Now())
+
+	entry, ok := m.jobs[id]
+	if !ok || !canAccessJob(entry.job, PrincipalFromContext(ctx)) {
+		return Job{}, ErrJobNotFound
+	}
+	return entry.job, nil
+}
+
+// Cancel stops a pending or running job. Handlers observe the cancellation
+// through their context; the job is reported as cancelled right away.
+func (m *JobManager) Cancel(ctx context.Context, id string) (Job, error) {
+	m.mu.Lock()
+	m.pruneLocked(time.Now())
+
+	entry, ok := m.jobs[id]
+	if !ok || !canAccessJob(entry.job, PrincipalFromContext(ctx)) {
+		m.mu.Unlock()
+		return Job{}, ErrJobNotFound
+	}
+	if entry.job.Status.Done() {
+		job := entry.job
+		m.mu.Unlock()
+		return job, ErrJobFinished
+	}
+
+	m.finishLocked(entry, JobCancelled, nil, "cancelled by "+PrincipalFromContext(ctx).String())
+	entry.cancel()
+	job := entry.job
+	stream := m.stream
+	m.mu.Unlock()
+
+	gl.Log("info", "MCP job cancelled", id, job.Tool)
+	publishJobEvent(stream, EventJobFinished, job)
+	return job, nil
+}
+
+func (m *JobManager) run(ctx context.Context, entry *jobEntry, args map[string]interface{}) {
+	defer entry.cancel()
+
+	m.mu.Lock()
+	if entry.job.Status.Done() {
+		m.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	entry.job.Status = JobRunning
+	entry.job.StartedAt = &now
+	started := entry.job
+	stream := m.stream
+	m.mu.Unlock()
+	publishJobEvent(stream, EventJobStarted, started)
+
+	result, err := m.registry.Exec(ctx, started.Tool, args)
+
+	m.mu.Lock()
+	if entry.job.Status.Done() {
+		// Cancelled while running; the handler's late result is discarded
+		m.mu.Unlock()
+		return
+	}
+	switch {
+	case err != nil && errors.Is(ctx.Err(), context.Canceled):
+		m.finishLocked(entry, JobCancelled, nil, err.Error())
+	case err != nil:
+		m.finishLocked(entry, JobFailed, nil, err.Error())
+	default:
+		m.finishLocked(entry, JobSucceeded, result, "")
+	}
+	finished := entry.job
+	stream = m.stream
+	m.mu.Unlock()
+
+	gl.Log("info", "MCP job finished", finished.ID, finished.Tool, finished.Status)
+	publishJobEvent(stream, EventJobFinished, finished)
+}
+
+func (m *JobManager) progress(id string, progress, total float64, message string) {
+	m.mu.Lock()
+	entry, ok := m.jobs[id]
+	if !ok || entry.job.Status.Done() {
+		m.mu.Unlock()
+		return
+	}
+	entry.job.Progress = progress
+	entry.job.Total = total
+	if message != "" {
+		entry.job.Message = message
+	}
+	job := entry.job
+	stream := m.stream
+	m.mu.Unlock()
+
+	publishJobEvent(stream, EventJobProgress, job)
+}
+
+func (m *JobManager) finishLocked(entry *jobEntry, status JobStatus, result interface{}, errMsg string) {
+	now := time.Now()
+	expires := now.Add(m.ttl)
+	entry.job.Status = status
+	entry.job.Result = result
+	entry.job.Error = errMsg
+	entry.job.FinishedAt = &now
+	entry.job.ExpiresAt = &expires
+}
+
+// pruneLocked drops finished jobs whose retention expired.
+func (m *JobManager) pruneLocked(now time.Time) {
+	for id, entry := range m.jobs {
+		if entry.job.ExpiresAt != nil && now.After(*entry.job.ExpiresAt) {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+// canAccessJob lets the submitter and admins see a job. Jobs submitted
+// without a principal have no owner and are reserved to admins.
+func canAccessJob(job Job, caller *Principal) bool {
+	if caller == nil {
+		return false
+	}
+	if containsFold(caller.Roles, AuthAdmin) {
+		return true
+	}
+	return job.Owner != "" && principalOwner(caller) == job.Owner
+}
+
+func principalOwner(p *Principal) string {
+	if p == nil {
+		return ""
+	}
+	if p.ID != "" {
+		return p.ID
+	}
+	return p.Username
+}
+
+// publishJobEvent pushes a job update to the event stream without blocking
+// the job when no consumer is draining it.
+func publishJobEvent(stream *events.Stream, eventType string, job Job) {
+	if stream == nil {
+		return
+	}
+	data := map[string]interface{}{
+		"job_id":   job.ID,
+		"tool":     job.Tool,
+		"status":   job.Status,
+		"progress": job.Progress,
+		"total":    job.Total,
+		"message":  job.Message,
+	}
+	if job.Error != "" {
+		data["error"] = job.Error
+	}
+	if !stream.TryBroadcast(events.Event{Type: eventType, Data: data}) {
+		gl.Log("debug", "Event stream saturated, job update dropped", job.ID, eventType)
+	}
+}
@@ -0,0 +1,551 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/kubex-ecosystem/gobe/internal/app/security/execsafe"
+	"github.com/kubex-ecosystem/gobe/internal/services/gateway"
+	"gopkg.in/yaml.v3"
+)
+
+var toolNameRx = regexp.MustCompile(`^[A-Za-z0-9_.\-]{1,64}$`)
+
+// ToolManifest declares an MCP tool bound to a backend. Exactly one of
+// Command, HTTP, Prompt or Builtin must be set.
+type ToolManifest struct {
+	Name         string                 `yaml:"name" json:"name"`
+	Title        string                 `yaml:"title,omitempty" json:"title,omitempty"`
+	Description  string                 `yaml:"description,omitempty" json:"description,omitempty"`
+	Auth         string                 `yaml:"auth,omitempty" json:"auth,omitempty"`
+	InputSchema  map[string]interface{} `yaml:"inputSchema,omitempty" json:"inputSchema,omitempty"`
+	OutputSchema map[string]interface{} `yaml:"outputSchema,omitempty" json:"outputSchema,omitempty"`
+
+	Command *CommandBackend `yaml:"command,omitempty" json:"command,omitempty"`
+	HTTP    *HTTPBackend    `yaml:"http,omitempty" json:"http,omitempty"`
+	Prompt  *PromptBackend  `yaml:"prompt,omitempty" json:"prompt,omitempty"`
+	Builtin string          `yaml:"builtin,omitempty" json:"builtin,omitempty"`
+}
+
+// CommandBackend runs a binary through execsafe, never through a shell.
+// Each Args entry is a text/template over the call arguments; entries that
+// render empty are dropped. Command tools require "admin" unless Auth is set.
+// Limits and Sandbox map to the execsafe.CommandSpec fields of the same name.
+type CommandBackend struct {
+	Binary       string           `yaml:"binary" json:"binary"`
+	Args         []string         `yaml:"args,omitempty" json:"args,omitempty"`
+	AllowedFlags []string         `yaml:"allowedFlags,omitempty" json:"allowedFlags,omitempty"`
+	ArgPattern   string           `yaml:"argPattern,omitempty" json:"argPattern,omitempty"`
+	MaxArgs      int              `yaml:"maxArgs,omitempty" json:"maxArgs,omitempty"`
+	Timeout      string           `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	WorkDir      string           `yaml:"workDir,omitempty" json:"workDir,omitempty"`
+	MaxOutputKB  int              `yaml:"maxOutputKB,omitempty" json:"maxOutputKB,omitempty"`
+	Env          []string         `yaml:"env,omitempty" json:"env,omitempty"`
+	Limits       execsafe.Limits  `yaml:"limits,omitempty" json:"limits,omitempty"`
+	Sandbox      execsafe.Sandbox `yaml:"sandbox,omitempty" json:"sandbox,omitempty"`
+}
+
+// HTTPBackend calls an HTTP endpoint. URL, header values and Body are
+// text/templates over the call arguments (use urlquery for URL parts).
+// ${VAR} in header values is expanded from the environment at load time.
+type HTTPBackend struct {
+	Method    string            `yaml:"method,omitempty" json:"method,omitempty"`
+	URL       string            `yaml:"url" json:"url"`
+	Headers   map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	Body      string            `yaml:"body,omitempty" json:"body,omitempty"`
+	Timeout   string            `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	MaxBodyKB int               `yaml:"maxBodyKB,omitempty" json:"maxBodyKB,omitempty"`
+}
+
+// PromptBackend renders Template with the call arguments and sends it to a gateway provider.
+type PromptBackend struct {
+	Provider    string  `yaml:"provider" json:"provider"`
+	Model       string  `yaml:"model,omitempty" json:"model,omitempty"`
+	System      string  `yaml:"system,omitempty" json:"system,omitempty"`
+	Template    string  `yaml:"template" json:"template"`
+	Temperature float32 `yaml:"temperature,omitempty" json:"temperature,omitempty"`
+}
+
+// ChatService is the part of the gateway used by prompt manifests.
+type ChatService interface {
+	Chat(ctx context.Context, req gateway.ChatRequest) (<-chan gateway.ChatChunk, gateway.ProviderConfig, error)
+}
+
+// ManifestBackends carries the runtime dependencies of manifest tools.
+// Missing dependencies only fail the calls that need them.
+type ManifestBackends struct {
+	Chat       ChatService
+	HTTPClient *http.Client
+}
+
+// manifestFile holds either a single tool or a "tools" list.
+type manifestFile struct {
+	ToolManifest `yaml:",inline"`
+	Tools        []ToolManifest `yaml:"tools,omitempty"`
+}
+
+// IsManifestFile reports whether path has a manifest extension.
+func IsManifestFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// ParseManifestFile reads the tools declared in a YAML or JSON file.
+func ParseManifestFile(path string) ([]ToolManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// JSON is a subset of YAML, so one decoder handles both formats
+	var file manifestFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+
+	switch {
+	case len(file.Tools) > 0 && file.Name != "":
+		return nil, fmt.Errorf("%s: declare either a single tool or a tools list, not both", filepath.Base(path))
+	case len(file.Tools) > 0:
+		return file.Tools, nil
+	case file.Name != "":
+		return []ToolManifest{file.ToolManifest}, nil
+	default:
+		return nil, fmt.Errorf("%s: no tools declared", filepath.Base(path))
+	}
+}
+
+// ToolSpec builds the registry spec of the manifest and compiles its schemas.
+func (m ToolManifest) ToolSpec(backends ManifestBackends) (ToolSpec, error) {
+	if !toolNameRx.MatchString(m.Name) {
+		return ToolSpec{}, fmt.Errorf("invalid tool name %q", m.Name)
+	}
+
+	backendCount := 0
+	for _, set := range []bool{m.Command != nil, m.HTTP != nil, m.Prompt != nil, m.Builtin != ""} {
+		if set {
+			backendCount++
+		}
+	}
+	if backendCount != 1 {
+		return ToolSpec{}, fmt.Errorf("tool %s: exactly one of command, http, prompt or builtin is required", m.Name)
+	}
+
+	spec := ToolSpec{
+		Name:        m.Name,
+		Title:       m.Title,
+		Description: m.Description,
+		Auth:        m.Auth,
+	}
+
+	if m.Builtin != "" {
+		base, ok := builtinToolSpec(m.Builtin)
+		if !ok {
+			return ToolSpec{}, fmt.Errorf("tool %s: unknown builtin %q", m.Name, m.Builtin)
+		}
+		spec.Handler = base.Handler
+		spec.InputSchema = base.InputSchema
+		spec.OutputSchema = base.OutputSchema
+		if spec.Title == "" {
+			spec.Title = base.Title
+		}
+		if spec.Description == "" {
+			spec.Description = base.Description
+		}
+		if spec.Auth == "" {
+			spec.Auth = base.Auth
+		}
+	}
+
+	if m.InputSchema != nil {
+		raw, err := json.Marshal(m.InputSchema)
+		if err != nil {
+			return ToolSpec{}, fmt.Errorf("tool %s: invalid inputSchema: %w", m.Name, err)
+		}
+		spec.InputSchema = raw
+	}
+	if m.OutputSchema != nil {
+		raw, err := json.Marshal(m.OutputSchema)
+		if err != nil {
+			return ToolSpec{}, fmt.Errorf("tool %s: invalid outputSchema: %w", m.Name, err)
+		}
+		spec.OutputSchema = raw
+	}
+
+	var err error
+	switch {
+	case m.Command != nil:
+		if spec.Auth == "" {
+			spec.Auth = AuthAdmin
+		}
+		spec.Handler, err = m.commandHandler()
+	case m.HTTP != nil:
+		spec.Handler, err = m.httpHandler(backends)
+	case m.Prompt != nil:
+		spec.Handler, err = m.promptHandler(backends)
+	}
+	if err != nil {
+		return ToolSpec{}, fmt.Errorf("tool %s: %w", m.Name, err)
+	}
+
+	if _, err := compileToolSchema(&spec); err != nil {
+		return ToolSpec{}, err
+	}
+	return spec, nil
+}
+
+func (m ToolManifest) commandHandler() (ToolHandler, error) {
+	cb := m.Command
+	if strings.TrimSpace(cb.Binary) == "" {
+		return nil, fmt.Errorf("command.binary is required")
+	}
+
+	argTemplates, err := parseTemplates(m.Name, cb.Args)
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := parseManifestDuration(cb.Timeout, 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("command.timeout: %w", err)
+	}
+
+	validators := []execsafe.ArgValidator{}
+	if cb.MaxArgs > 0 {
+		maxArgs := cb.MaxArgs
+		validators = append(validators, func(args []string) error {
+			if len(args) > maxArgs {
+				return fmt.Errorf("%s: too many arguments", m.Name)
+			}
+			return nil
+		})
+	}
+	if len(cb.AllowedFlags) > 0 {
+		validators = append(validators, execsafe.OneOfFlags(cb.AllowedFlags...))
+	}
+	if cb.ArgPattern != "" {
+		rx, err := regexp.Compile(cb.ArgPattern)
+		if err != nil {
+			return nil, fmt.Errorf("command.argPattern: %w", err)
+		}
+		validators = append(validators, execsafe.RegexValidator(rx))
+	}
+
+	reg := execsafe.NewRegistry()
+	reg.Register(m.Name, execsafe.CommandSpec{
+		Binary:       cb.Binary,
+		ArgsValidate: execsafe.Chain(validators...),
+		Timeout:      timeout,
+		WorkDir:      cb.WorkDir,
+		MaxOutputKB:  cb.MaxOutputKB,
+		EnvAllowList: cb.Env,
+		Limits:       cb.Limits,
+		Sandbox:      cb.Sandbox,
+	})
+
+	name := m.Name
+	schema := m.InputSchema
+	return func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		data := templateData(schema, args)
+		argv := make([]string, 0, len(argTemplates))
+		for _, tpl := range argTemplates {
+			value, err := renderTemplate(tpl, data)
+			if err != nil {
+				return nil, err
+			}
+			if value != "" {
+				argv = append(argv, value)
+			}
+		}
+
+		ReportProgress(ctx, 0, 1, "running "+cb.Binary)
+		res, err := execsafe.RunSafe(ctx, reg, name, argv)
+		ReportProgress(ctx, 1, 1, "finished "+cb.Binary)
+		if res == nil {
+			return nil, err
+		}
+
+		result := map[string]interface{}{
+			"status":      "success",
+			"command":     cb.Binary,
+			"args":        argv,
+			"exit_code":   res.ExitCode,
+			"stdout":      res.Stdout,
+			"stderr":      res.Stderr,
+			"truncated":   res.Truncated,
+			"duration_ms": res.Duration.Milliseconds(),
+		}
+		if err != nil {
+			result["status"] = "error"
+			result["error"] = err.Error()
+		}
+		return result, nil
+	}, nil
+}
+
+func (m ToolManifest) httpHandler(backends ManifestBackends) (ToolHandler, error) {
+	hb := m.HTTP
+	if strings.TrimSpace(hb.URL) == "" {
+		return nil, fmt.Errorf("http.url is required")
+	}
+
+	method := strings.ToUpper(hb.Method)
+	if method == "" {
+		method = http.MethodGet
+		if hb.Body != "" {
+			method = http.MethodPost
+		}
+	}
+
+	urlTemplate, err := parseTemplate(m.Name+".url", hb.URL)
+	if err != nil {
+		return nil, err
+	}
+	var bodyTemplate *template.Template
+	if hb.Body != "" {
+		if bodyTemplate, err = parseTemplate(m.Name+".body", hb.Body); err != nil {
+			return nil, err
+		}
+	}
+	headerTemplates := make(map[string]*template.Template, len(hb.Headers))
+	for key, value := range hb.Headers {
+		tpl, err := parseTemplate(m.Name+".header."+key, os.ExpandEnv(value))
+		if err != nil {
+			return nil, err
+		}
+		headerTemplates[key] = tpl
+	}
+
+	timeout, err := parseManifestDuration(hb.Timeout, 10*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("http.timeout: %w", err)
+	}
+	maxBody := hb.MaxBodyKB
+	if maxBody <= 0 {
+		maxBody = 1024
+	}
+
+	client := backends.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	schema := m.InputSchema
+	return func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		data := templateData(schema, args)
+
+		target, err := renderTemplate(urlTemplate, data)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := url.Parse(target)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+			return nil, fmt.Errorf("invalid url %q", target)
+		}
+
+		var body io.Reader
+		if bodyTemplate != nil {
+			rendered, err := renderTemplate(bodyTemplate, data)
+			if err != nil {
+				return nil, err
+			}
+			body = strings.NewReader(rendered)
+		}
+
+		cctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(cctx, method, target, body)
+		if err != nil {
+			return nil, err
+		}
+		for key, tpl := range headerTemplates {
+			value, err := renderTemplate(tpl, data)
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set(key, value)
+		}
+		if body != nil && req.Header.Get("Content-Type") == "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		limit := int64(maxBody) << 10
+		raw, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+		if err != nil {
+			return nil, err
+		}
+		truncated := int64(len(raw)) > limit
+		if truncated {
+			raw = raw[:limit]
+		}
+
+		if resp.StatusCode >= 400 {
+			return nil, fmt.Errorf("http %d: %s", resp.StatusCode, execsafe.SanitizeOneLine(string(raw)))
+		}
+
+		var decoded interface{} = string(raw)
+		if !truncated {
+			var v interface{}
+			if json.Unmarshal(raw, &v) == nil {
+				decoded = v
+			}
+		}
+
+		return map[string]interface{}{
+			"status":    resp.StatusCode,
+			"body":      decoded,
+			"truncated": truncated,
+		}, nil
+	}, nil
+}
+
+func (m ToolManifest) promptHandler(backends ManifestBackends) (ToolHandler, error) {
+	pb := m.Prompt
+	if pb.Provider == "" {
+		return nil, fmt.Errorf("prompt.provider is required")
+	}
+	if strings.TrimSpace(pb.Template) == "" {
+		return nil, fmt.Errorf("prompt.template is required")
+	}
+
+	userTemplate, err := parseTemplate(m.Name+".prompt", pb.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := m.InputSchema
+	return func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		if backends.Chat == nil {
+			return nil, fmt.Errorf("prompt tools require the gateway service")
+		}
+
+		prompt, err := renderTemplate(userTemplate, templateData(schema, args))
+		if err != nil {
+			return nil, err
+		}
+
+		messages := make([]gateway.Message, 0, 2)
+		if pb.System != "" {
+			messages = append(messages, gateway.Message{Role: "system", Content: pb.System})
+		}
+		messages = append(messages, gateway.Message{Role: "user", Content: prompt})
+
+		stream, cfg, err := backends.Chat.Chat(ctx, gateway.ChatRequest{
+			Provider:    pb.Provider,
+			Model:       pb.Model,
+			Messages:    messages,
+			Temperature: pb.Temperature,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var content strings.Builder
+		var usage *gateway.Usage
+		for chunk := range stream {
+			if chunk.Error != "" {
+				return nil, fmt.Errorf("provider %s: %s", pb.Provider, chunk.Error)
+			}
+			content.WriteString(chunk.Content)
+			if chunk.Usage != nil {
+				usage = chunk.Usage
+			}
+		}
+
+		model := pb.Model
+		if model == "" {
+			model = cfg.DefaultModel
+		}
+		result := map[string]interface{}{
+			"content":  content.String(),
+			"provider": pb.Provider,
+			"model":    model,
+		}
+		if usage != nil {
+			result["usage"] = usage
+		}
+		return result, nil
+	}, nil
+}
+
+func parseTemplates(name string, texts []string) ([]*template.Template, error) {
+	out := make([]*template.Template, 0, len(texts))
+	for i, text := range texts {
+		tpl, err := parseTemplate(fmt.Sprintf("%s.args[%d]", name, i), text)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, tpl)
+	}
+	return out, nil
+}
+
+func parseTemplate(name, text string) (*template.Template, error) {
+	tpl, err := template.New(name).Option("missingkey=error").Funcs(template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template %s: %w", name, err)
+	}
+	return tpl, nil
+}
+
+func renderTemplate(tpl *template.Template, data map[string]interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", tpl.Name(), err)
+	}
+	return buf.String(), nil
+}
+
+// templateData exposes the call arguments to templates. Optional properties
+// declared by the schema render as empty strings when absent.
+func templateData(schema map[string]interface{}, args map[string]interface{}) map[string]interface{} {
+	data := make(map[string]interface{}, len(args))
+	if props, ok := schema["properties"].(map[string]interface{}); ok {
+		for name := range props {
+			data[name] = ""
+		}
+	}
+	for k, v := range args {
+		data[k] = v
+	}
+	return data
+}
+
+func parseManifestDuration(value string, fallback time.Duration) (time.Duration, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("must be positive")
+	}
+	return d, nil
+}
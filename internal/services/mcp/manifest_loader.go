@@ -0,0 +1,341 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+)
+
+// manifestReloadDelay coalesces the burst of events editors emit on save.
+const manifestReloadDelay = 250 * time.Millisecond
+
+// ManifestReport is the outcome of loading or validating one manifest file.
+type ManifestReport struct {
+	File  string   `json:"file"`
+	Tools []string `json:"tools,omitempty"`
+	Err   error    `json:"-"`
+}
+
+// ManifestLoader registers the tools declared in a directory of manifests and
+// keeps the registry in sync with the files. Each file is applied atomically:
+// a file that fails to parse or validate keeps its previously loaded tools.
+type ManifestLoader struct {
+	dir      string
+	registry Registry
+	backends ManifestBackends
+	onChange func(removed []string)
+
+	mu    sync.Mutex
+	owned map[string][]string // file -> tool names
+}
+
+// NewManifestLoader creates a loader for dir that registers tools into registry.
+func NewManifestLoader(dir string, registry Registry, backends ManifestBackends) *ManifestLoader {
+	return &ManifestLoader{
+		dir:      dir,
+		registry: registry,
+		backends: backends,
+		owned:    make(map[string][]string),
+	}
+}
+
+// OnChange sets a callback run after a load added, changed or removed tools.
+// removed lists the tool names that no longer exist.
+func (l *ManifestLoader) OnChange(fn func(removed []string)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onChange = fn
+}
+
+// Load reads every manifest in the directory and applies it to the registry.
+// The returned error joins the errors of the files that were rejected.
+func (l *ManifestLoader) Load() ([]ManifestReport, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	files, err := listManifestFiles(l.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	reports, specs := buildManifests(files, l.backends)
+
+	// Tools of rejected files stay registered and keep their names claimed
+	claimed := make(map[string]string)
+	for _, report := range reports {
+		if report.Err != nil {
+			for _, name := range l.owned[report.File] {
+				claimed[name] = report.File
+			}
+		}
+	}
+	for i := range reports {
+		if reports[i].Err != nil {
+			continue
+		}
+		reports[i].Err = l.claim(reports[i], claimed)
+	}
+
+	newOwned := make(map[string][]string)
+	for _, report := range reports {
+		if report.Err != nil {
+			if names, ok := l.owned[report.File]; ok {
+				newOwned[report.File] = names
+			}
+			continue
+		}
+		newOwned[report.File] = report.Tools
+	}
+
+	var errs []error
+	changed := false
+	for _, report := range reports {
+		if report.Err != nil {
+			gl.Log("error", "MCP tool manifest rejected", report.File, report.Err)
+			errs = append(errs, report.Err)
+			continue
+		}
+		remove := releasedNames(l.owned[report.File], claimed)
+		if err := l.registry.Replace(remove, specs[report.File]); err != nil {
+			gl.Log("error", "MCP tool manifest rejected", report.File, err)
+			errs = append(errs, fmt.Errorf("%s: %w", filepath.Base(report.File), err))
+			if names, ok := l.owned[report.File]; ok {
+				newOwned[report.File] = names
+			} else {
+				delete(newOwned, report.File)
+			}
+			continue
+		}
+		changed = true
+	}
+
+	// Files that disappeared take their tools with them
+	var removed []string
+	for file, names := range l.owned {
+		if _, still := newOwned[file]; still {
+			continue
+		}
+		gone := releasedNames(names, claimed)
+		if err := l.registry.Replace(gone, nil); err != nil {
+			errs = append(errs, err)
+			newOwned[file] = names
+			continue
+		}
+		removed = append(removed, gone...)
+		changed = true
+	}
+	for file, names := range l.owned {
+		if current, ok := newOwned[file]; ok {
+			removed = append(removed, missingNames(names, current, claimed)...)
+		}
+	}
+
+	l.owned = newOwned
+	if changed && l.onChange != nil {
+		l.onChange(removed)
+	}
+
+	gl.Log("info", "MCP tool manifests loaded", l.dir, len(reports))
+	return reports, errors.Join(errs...)
+}
+
+// Watch reloads the manifests whenever a file in the directory changes,
+// until ctx is cancelled.
+func (l *ManifestLoader) Watch(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(l.dir); err != nil {
+		return err
+	}
+
+	var timer *time.Timer
+	reload := make(chan struct{}, 1)
+	for {
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if !IsManifestFile(event.Name) || event.Op == fsnotify.Chmod {
+				continue
+			}
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.AfterFunc(manifestReloadDelay, func() {
+				select {
+				case reload <- struct{}{}:
+				default:
+				}
+			})
+		case <-reload:
+			if _, err := l.Load(); err != nil {
+				gl.Log("warn", "MCP tool manifests reloaded with errors", err)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			gl.Log("error", "MCP tool manifest watcher error", err)
+		}
+	}
+}
+
+// claim reserves the names of a valid file, rejecting names owned by another
+// file or by a tool registered in Go.
+func (l *ManifestLoader) claim(report ManifestReport, claimed map[string]string) error {
+	mine := make(map[string]bool, len(l.owned[report.File]))
+	for _, name := range l.owned[report.File] {
+		mine[name] = true
+	}
+
+	for _, name := range report.Tools {
+		if other, taken := claimed[name]; taken && other != report.File {
+			return fmt.Errorf("%s: tool %s is already declared in %s", filepath.Base(report.File), name, filepath.Base(other))
+		}
+		if _, exists := l.registry.GetTool(name); exists && !mine[name] && !l.ownedByAny(name) {
+			return fmt.Errorf("%s: tool %s conflicts with a built-in tool", filepath.Base(report.File), name)
+		}
+	}
+	for _, name := range report.Tools {
+		claimed[name] = report.File
+	}
+	return nil
+}
+
+func (l *ManifestLoader) ownedByAny(name string) bool {
+	for _, names := range l.owned {
+		for _, n := range names {
+			if n == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ValidateManifests parses and builds every manifest in dir without
+// registering anything. It reports duplicate tool names across files.
+func ValidateManifests(dir string) ([]ManifestReport, error) {
+	files, err := listManifestFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	reports, _ := buildManifests(files, ManifestBackends{})
+	seen := make(map[string]string)
+	for i := range reports {
+		if reports[i].Err != nil {
+			continue
+		}
+		for _, name := range reports[i].Tools {
+			if _, ok := builtinToolSpec(name); ok {
+				reports[i].Err = fmt.Errorf("%s: tool %s conflicts with a built-in tool", filepath.Base(reports[i].File), name)
+				break
+			}
+			if other, dup := seen[name]; dup {
+				reports[i].Err = fmt.Errorf("%s: tool %s is already declared in %s", filepath.Base(reports[i].File), name, filepath.Base(other))
+				break
+			}
+			seen[name] = reports[i].File
+		}
+	}
+	return reports, nil
+}
+
+func buildManifests(files []string, backends ManifestBackends) ([]ManifestReport, map[string][]ToolSpec) {
+	reports := make([]ManifestReport, 0, len(files))
+	specs := make(map[string][]ToolSpec, len(files))
+
+	for _, file := range files {
+		report := ManifestReport{File: file}
+		manifests, err := ParseManifestFile(file)
+		if err != nil {
+			report.Err = err
+			reports = append(reports, report)
+			continue
+		}
+
+		names := make(map[string]bool, len(manifests))
+		for _, m := range manifests {
+			spec, err := m.ToolSpec(backends)
+			if err == nil && names[spec.Name] {
+				err = fmt.Errorf("tool %s is declared twice", spec.Name)
+			}
+			if err != nil {
+				report.Err = fmt.Errorf("%s: %w", filepath.Base(file), err)
+				break
+			}
+			names[spec.Name] = true
+			report.Tools = append(report.Tools, spec.Name)
+			specs[file] = append(specs[file], spec)
+		}
+		if report.Err != nil {
+			report.Tools = nil
+			delete(specs, file)
+		}
+		reports = append(reports, report)
+	}
+	return reports, specs
+}
+
+func listManifestFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest directory: %w", err)
+	}
+
+	files := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !IsManifestFile(entry.Name()) {
+			continue
+		}
+		files = append(files, filepath.Join(dir, entry.Name()))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// releasedNames returns the names of names not re-claimed by another file.
+func releasedNames(names []string, claimed map[string]string) []string {
+	out := make([]string, 0, len(names))
+	for _, name := range names {
+		if _, taken := claimed[name]; !taken {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// missingNames returns the names of before that are absent from after and
+// not re-claimed by another file.
+func missingNames(before, after []string, claimed map[string]string) []string {
+	keep := make(map[string]bool, len(after))
+	for _, name := range after {
+		keep[name] = true
+	}
+	out := []string{}
+	for _, name := range before {
+		if _, taken := claimed[name]; !keep[name] && !taken {
+			out = append(out, name)
+		}
+	}
+	return out
+}
@@ -0,0 +1,323 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/kubex-ecosystem/gobe/internal/config"
+	"github.com/kubex-ecosystem/gobe/internal/contracts/types"
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+)
+
+// Well-known values for ToolSpec.Auth.
+const (
+	AuthNone          = "none"
+	AuthAuthenticated = "authenticated"
+	AuthAdmin         = "admin"
+)
+
+// Principal identifies the caller of an MCP tool, either from JWT claims
+// (HTTP paths) or from a chat adapter message (Discord, Telegram, ...).
+type Principal struct {
+	ID        string   `json:"id,omitempty"`
+	Username  string   `json:"username,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+	Scopes    []string `json:"scopes,omitempty"`
+	Source    string   `json:"source,omitempty"`
+	GuildID   string   `json:"guild_id,omitempty"`
+	ChannelID string   `json:"channel_id,omitempty"`
+}
+
+// String returns a short, log-friendly representation of the principal.
+func (p *Principal) String() string {
+	if p == nil || (p.ID == "" && p.Username == "") {
+		return "anonymous"
+	}
+	if p.Username != "" && p.ID != "" {
+		return fmt.Sprintf("%s(%s)", p.Username, p.ID)
+	}
+	if p.ID != "" {
+		return p.ID
+	}
+	return p.Username
+}
+
+// AuthorizationError is returned when a policy refuses a tool call.
+type AuthorizationError struct {
+	Tool      string `json:"tool"`
+	Principal string `json:"principal"`
+	Reason    string `json:"reason"`
+}
+
+// Error implements the error interface.
+func (e *AuthorizationError) Error() string {
+	return fmt.Sprintf("access denied to tool %s for %s: %s", e.Tool, e.Principal, e.Reason)
+}
+
+// Policy decides whether a principal may run a tool.
+type Policy interface {
+	Authorize(ctx context.Context, spec ToolSpec, principal *Principal) error
+}
+
+type principalCtxKey struct{}
+
+// WithPrincipal attaches an explicit caller identity to the context.
+// It takes precedence over JWT claims placed by the authentication middleware.
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, principalCtxKey{}, principal)
+}
+
+// PrincipalFromContext resolves the caller identity carried by ctx.
+// It returns nil for anonymous callers.
+func PrincipalFromContext(ctx context.Context) *Principal {
+	if ctx == nil {
+		return nil
+	}
+	if p, ok := ctx.Value(principalCtxKey{}).(*Principal); ok && p != nil {
+		return p
+	}
+
+	switch claims := ctx.Value(types.CtxKey("user")).(type) {
+	case jwt.MapClaims:
+		return principalFromClaims(claims)
+	case map[string]interface{}:
+		return principalFromClaims(claims)
+	case *jwt.RegisteredClaims:
+		if claims != nil {
+			return &Principal{ID: claims.Subject, Source: "http"}
+		}
+	}
+
+	if userID := ctx.Value(types.CtxKey("userID")); userID != nil {
+		return &Principal{ID: fmt.Sprintf("%v", userID), Source: "http"}
+	}
+	return nil
+}
+
+// principalFromClaims maps JWT claims to a Principal. Tokens issued by the
+// token service embed the user under "UserImpl"; external tokens are expected
+// to use the usual "sub", "roles" and "scope" claims.
+func principalFromClaims(claims map[string]interface{}) *Principal {
+	p := &Principal{Source: "http"}
+
+	p.ID, _ = claims["sub"].(string)
+	p.Username, _ = claims["preferred_username"].(string)
+	if p.Username == "" {
+		p.Username, _ = claims["username"].(string)
+	}
+
+	if user, ok := claims["UserImpl"].(map[string]interface{}); ok {
+		if p.ID == "" {
+			p.ID, _ = user["id"].(string)
+		}
+		if p.Username == "" {
+			p.Username, _ = user["username"].(string)
+		}
+		if roleID, ok := user["role_id"].(string); ok && roleID != "" {
+			p.Roles = append(p.Roles, roleID)
+		}
+	}
+
+	p.Roles = append(p.Roles, claimStrings(claims["roles"])...)
+	if role, ok := claims["role"].(string); ok && role != "" {
+		p.Roles = append(p.Roles, role)
+	}
+
+	if scope, ok := claims["scope"].(string); ok {
+		p.Scopes = append(p.Scopes, strings.Fields(scope)...)
+	}
+	p.Scopes = append(p.Scopes, claimStrings(claims["scopes"])...)
+	p.Scopes = append(p.Scopes, claimStrings(claims["scp"])...)
+
+	return p
+}
+
+func claimStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+	case []string:
+		return v
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+// RulePolicy evaluates role bindings, allow/deny rules and ToolSpec.Auth.
+//
+// Evaluation order: a matching deny rule always wins; otherwise a matching
+// allow rule grants access; otherwise the tool's Auth requirement decides.
+// Auth is "none"/"" (public), "authenticated", or a comma-separated list of
+// roles and "scope:<name>" entries of which the caller needs at least one.
+type RulePolicy struct {
+	bindings []config.MCPRoleBinding
+	rules    []config.MCPPolicyRule
+}
+
+// NewPolicy builds a RulePolicy from configuration.
+func NewPolicy(cfg config.MCPPolicyConfig) *RulePolicy {
+	return &RulePolicy{
+		bindings: append([]config.MCPRoleBinding(nil), cfg.Bindings...),
+		rules:    append([]config.MCPPolicyRule(nil), cfg.Rules...),
+	}
+}
+
+// DefaultPolicy enforces only each tool's Auth requirement.
+func DefaultPolicy() *RulePolicy {
+	return NewPolicy(config.MCPPolicyConfig{})
+}
+
+// Authorize implements Policy.
+func (p *RulePolicy) Authorize(ctx context.Context, spec ToolSpec, principal *Principal) error {
+	caller := p.resolve(principal)
+
+	for _, rule := range p.rules {
+		if !strings.EqualFold(rule.Effect, "deny") {
+			continue
+		}
+		if ruleMatchesTool(rule, spec.Name) && ruleMatchesPrincipal(rule, caller) {
+			return p.deny(spec, caller, "denied by policy rule")
+		}
+	}
+
+	for _, rule := range p.rules {
+		if !strings.EqualFold(rule.Effect, "allow") {
+			continue
+		}
+		if ruleMatchesTool(rule, spec.Name) && ruleMatchesPrincipal(rule, caller) {
+			return nil
+		}
+	}
+
+	auth := strings.TrimSpace(strings.ToLower(spec.Auth))
+	switch auth {
+	case "", AuthNone:
+		return nil
+	case AuthAuthenticated:
+		if caller.ID != "" || caller.Username != "" {
+			return nil
+		}
+		return p.deny(spec, caller, "authentication required")
+	}
+
+	for _, requirement := range strings.Split(auth, ",") {
+		requirement = strings.TrimSpace(requirement)
+		if scope, ok := strings.CutPrefix(requirement, "scope:"); ok {
+			if containsFold(caller.Scopes, scope) {
+				return nil
+			}
+			continue
+		}
+		if containsFold(caller.Roles, requirement) {
+			return nil
+		}
+	}
+
+	return p.deny(spec, caller, fmt.Sprintf("requires %s", spec.Auth))
+}
+
+// Resolve returns a copy of the principal with the roles its bindings grant.
+func (p *RulePolicy) Resolve(principal *Principal) *Principal {
+	return p.resolve(principal)
+}
+
+// resolve returns a copy of the principal with roles granted by bindings.
+func (p *RulePolicy) resolve(principal *Principal) *Principal {
+	caller := &Principal{}
+	if principal != nil {
+		*caller = *principal
+		caller.Roles = append([]string(nil), principal.Roles...)
+	}
+
+	for _, binding := range p.bindings {
+		if binding.Role == "" || containsFold(caller.Roles, binding.Role) {
+			continue
+		}
+		if matchesIdentity(binding.Users, caller) ||
+			(caller.GuildID != "" && containsFold(binding.Guilds, caller.GuildID)) ||
+			(caller.ChannelID != "" && containsFold(binding.Channels, caller.ChannelID)) {
+			caller.Roles = append(caller.Roles, binding.Role)
+		}
+	}
+	return caller
+}
+
+func (p *RulePolicy) deny(spec ToolSpec, caller *Principal, reason string) error {
+	gl.Log("warn", "MCP tool access denied", spec.Name, caller.String(), reason)
+	return &AuthorizationError{Tool: spec.Name, Principal: caller.String(), Reason: reason}
+}
+
+func ruleMatchesTool(rule config.MCPPolicyRule, tool string) bool {
+	return matchesToolPattern(rule.Tools, tool)
+}
+
+// matchesToolPattern reports whether tool equals or glob-matches any pattern.
+func matchesToolPattern(patterns []string, tool string) bool {
+	for _, pattern := range patterns {
+		if pattern == "*" || pattern == tool {
+			return true
+		}
+		if ok, err := path.Match(pattern, tool); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleMatchesPrincipal reports whether every non-empty selector of the rule
+// matches the caller. A rule without selectors matches everyone.
+func ruleMatchesPrincipal(rule config.MCPPolicyRule, caller *Principal) bool {
+	if len(rule.Roles) > 0 && !intersectsFold(rule.Roles, caller.Roles) {
+		return false
+	}
+	if len(rule.Scopes) > 0 && !intersectsFold(rule.Scopes, caller.Scopes) {
+		return false
+	}
+	if len(rule.Users) > 0 && !matchesIdentity(rule.Users, caller) {
+		return false
+	}
+	if len(rule.Guilds) > 0 && !containsFold(rule.Guilds, caller.GuildID) {
+		return false
+	}
+	if len(rule.Channels) > 0 && !containsFold(rule.Channels, caller.ChannelID) {
+		return false
+	}
+	return true
+}
+
+func matchesIdentity(users []string, caller *Principal) bool {
+	return (caller.ID != "" && containsFold(users, caller.ID)) ||
+		(caller.Username != "" && containsFold(users, caller.Username))
+}
+
+func containsFold(values []string, target string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func intersectsFold(a, b []string) bool {
+	for _, v := range a {
+		if containsFold(b, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -3,6 +3,7 @@ package mcp
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
@@ -22,70 +23,104 @@ func RegisterBuiltinTools(registry Registry) error {
 		return fmt.Errorf("registry cannot be nil")
 	}
 
-	// Register system.status tool
+	for _, spec := range builtinToolSpecs() {
+		if err := registry.Register(spec); err != nil {
+			gl.Log("error", "Failed to register builtin tool", spec.Name, err)
+			return fmt.Errorf("failed to register %s: %w", spec.Name, err)
+		}
+	}
+
+	gl.Log("info", "Built-in MCP tools registered successfully")
+	return nil
+}
+
+// builtinToolSpec returns the spec of a built-in tool, used by manifests
+// that expose a builtin under another name or policy.
+func builtinToolSpec(name string) (ToolSpec, bool) {
+	for _, spec := range builtinToolSpecs() {
+		if spec.Name == name {
+			return spec, true
+		}
+	}
+	return ToolSpec{}, false
+}
+
+// builtinToolSpecs declares the tools implemented in Go.
+func builtinToolSpecs() []ToolSpec {
+	// system.status tool
 	statusSpec := ToolSpec{
 		Name:        "system.status",
 		Title:       "System Status",
 		Description: "Get comprehensive system status including health, version, and runtime metrics",
 		Auth:        "none",
-		Args: map[string]interface{}{
-			"detailed": map[string]interface{}{
-				"type":        "boolean",
-				"description": "Include detailed system metrics",
-				"default":     false,
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"detailed": {
+					"type": "boolean",
+					"description": "Include detailed system metrics",
+					"default": false
+				}
+			}
+		}`),
+		OutputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"status":    {"type": "string"},
+				"timestamp": {"type": "integer"},
+				"uptime":    {"type": "string"},
+				"version":   {"type": "string"},
+				"health":    {"type": "object"}
 			},
-		},
+			"required": ["status", "timestamp", "uptime", "version", "health"]
+		}`),
 		Handler: systemStatusHandler,
 	}
 
-	err := registry.Register(statusSpec)
-	if err != nil {
-		gl.Log("error", "Failed to register system.status tool", err)
-		return fmt.Errorf("failed to register system.status: %w", err)
-	}
-
-	// Register shell.command tool
+	// shell.command tool
 	shellSpec := ToolSpec{
 		Name:        "shell.command",
 		Title:       "Shell Command",
 		Description: "Execute safe shell commands with whitelist validation",
 		Auth:        "admin",
-		Args: map[string]interface{}{
-			"command": map[string]interface{}{
-				"type":        "string",
-				"description": "Command to execute (from whitelist)",
-				"required":    true,
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"command": {
+					"type": "string",
+					"description": "Command to execute (from whitelist)",
+					"minLength": 1
+				},
+				"args": {
+					"type": "array",
+					"description": "Command arguments",
+					"items": {"type": "string"},
+					"default": []
+				}
 			},
-			"args": map[string]interface{}{
-				"type":        "array",
-				"description": "Command arguments",
-				"default":     []string{},
+			"required": ["command"]
+		}`),
+		OutputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"status":  {"type": "string", "enum": ["success", "error"]},
+				"command": {"type": "string"},
+				"output":  {"type": "string"}
 			},
-		},
+			"required": ["status"]
+		}`),
 		Handler: shellCommandHandler,
 	}
 
-	err = registry.Register(shellSpec)
-	if err != nil {
-		gl.Log("error", "Failed to register shell.command tool", err)
-		return fmt.Errorf("failed to register shell.command: %w", err)
-	}
-
-	gl.Log("info", "Built-in MCP tools registered successfully")
-	return nil
+	return []ToolSpec{statusSpec, shellSpec}
 }
 
 // systemStatusHandler handles the system.status tool execution
 func systemStatusHandler(ctx context.Context, args map[string]interface{}) (interface{}, error) {
 	gl.Log("debug", "Executing system.status tool")
 
-	// Get detailed flag (default false)
-	detailed := false
-	if detailedArg, exists := args["detailed"]; exists {
-		if detailedVal, ok := detailedArg.(bool); ok {
-			detailed = detailedVal
-		}
-	}
+	// Arguments were validated against the input schema (defaults applied)
+	detailed, _ := args["detailed"].(bool)
 
 	// Get basic system info using manage controller patterns
 	serverController := manage.NewServerController()
@@ -256,23 +291,10 @@ func checkAMQPConnection() map[string]interface{} {
 func shellCommandHandler(ctx context.Context, args map[string]interface{}) (interface{}, error) {
 	gl.Log("info", "Executing shell.command tool")
 
-	// Get command and args
-	command, ok := args["command"].(string)
-	if !ok || command == "" {
-		return nil, fmt.Errorf("command parameter is required")
-	}
-
-	// Get args (optional)
-	var cmdArgs []string
-	if argsInterface, exists := args["args"]; exists {
-		if argsList, ok := argsInterface.([]interface{}); ok {
-			for _, arg := range argsList {
-				if argStr, ok := arg.(string); ok {
-					cmdArgs = append(cmdArgs, argStr)
-				}
-			}
-		}
-	}
+	// Arguments were validated against the input schema: command is a
+	// non-empty string and args (if present) only holds strings.
+	command, _ := args["command"].(string)
+	cmdArgs := stringSliceArg(args["args"])
 
 	// Security: Only allow safe commands (whitelist approach)
 	allowedCommands := []string{
@@ -302,8 +324,10 @@ func shellCommandHandler(ctx context.Context, args map[string]interface{}) (inte
 	defer cancel()
 
 	// Execute command
+	ReportProgress(ctx, 0, 1, "running "+command)
 	cmd := exec.CommandContext(cmdCtx, command, cmdArgs...)
 	output, err := cmd.CombinedOutput()
+	ReportProgress(ctx, 1, 1, "finished "+command)
 
 	result := map[string]interface{}{
 		"command":   command,
@@ -326,3 +350,22 @@ func shellCommandHandler(ctx context.Context, args map[string]interface{}) (inte
 
 	return result, nil
 }
+
+// stringSliceArg converts a validated JSON array argument into a []string.
+// It accepts both decoded JSON ([]interface{}) and native Go slices.
+func stringSliceArg(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				out = append(out, str)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
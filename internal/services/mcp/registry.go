@@ -3,20 +3,25 @@ package mcp
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"sync"
 
 	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
 )
 
-// ToolSpec defines the specification for an MCP tool
+// ToolSpec defines the specification for an MCP tool.
+// InputSchema and OutputSchema are JSON Schema documents; when InputSchema is
+// empty it is derived from the legacy Args map at registration time.
 type ToolSpec struct {
-	Name        string                 `json:"name"`
-	Title       string                 `json:"title"`
-	Description string                 `json:"description"`
-	Auth        string                 `json:"auth,omitempty"`
-	Args        map[string]interface{} `json:"args,omitempty"`
-	Handler     ToolHandler            `json:"-"`
+	Name         string                 `json:"name"`
+	Title        string                 `json:"title"`
+	Description  string                 `json:"description"`
+	Auth         string                 `json:"auth,omitempty"`
+	Args         map[string]interface{} `json:"args,omitempty"`
+	InputSchema  json.RawMessage        `json:"inputSchema,omitempty"`
+	OutputSchema json.RawMessage        `json:"outputSchema,omitempty"`
+	Handler      ToolHandler            `json:"-"`
 }
 
 // ToolHandler defines the function signature for tool handlers
@@ -25,25 +30,57 @@ type ToolHandler func(context.Context, map[string]interface{}) (interface{}, err
 // Registry interface for managing MCP tools
 type Registry interface {
 	Register(spec ToolSpec) error
+	Replace(remove []string, specs []ToolSpec) error
 	List() []ToolSpec
 	Exec(ctx context.Context, toolName string, args map[string]interface{}) (interface{}, error)
+	Validate(toolName string, args map[string]interface{}) error
 	GetTool(name string) (*ToolSpec, bool)
+	SetPolicy(policy Policy)
+	Authorize(ctx context.Context, toolName string) error
 }
 
 // registry implements the Registry interface
 type registry struct {
-	mu    sync.RWMutex
-	tools map[string]ToolSpec
+	mu      sync.RWMutex
+	tools   map[string]ToolSpec
+	schemas map[string]*toolSchema
+	policy  Policy
 }
 
 // NewRegistry creates a new MCP tools registry
 func NewRegistry() Registry {
 	gl.Log("debug", "Creating new MCP registry")
 	return &registry{
-		tools: make(map[string]ToolSpec),
+		tools:   make(map[string]ToolSpec),
+		schemas: make(map[string]*toolSchema),
+		policy:  DefaultPolicy(),
 	}
 }
 
+// SetPolicy replaces the authorization policy applied by Exec.
+// A nil policy restores the default, which only enforces ToolSpec.Auth.
+func (r *registry) SetPolicy(policy Policy) {
+	if policy == nil {
+		policy = DefaultPolicy()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.policy = policy
+}
+
+// Authorize checks whether the caller carried by ctx may run the named tool.
+func (r *registry) Authorize(ctx context.Context, toolName string) error {
+	r.mu.RLock()
+	tool, exists := r.tools[toolName]
+	policy := r.policy
+	r.mu.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("tool not found: %s", toolName)
+	}
+	return policy.Authorize(ctx, tool, PrincipalFromContext(ctx))
+}
+
 // Register adds a new tool to the registry
 func (r *registry) Register(spec ToolSpec) error {
 	if spec.Name == "" {
@@ -54,6 +91,12 @@ func (r *registry) Register(spec ToolSpec) error {
 		return fmt.Errorf("tool handler cannot be nil for tool: %s", spec.Name)
 	}
 
+	compiled, err := compileToolSchema(&spec)
+	if err != nil {
+		gl.Log("error", "Invalid tool schema", spec.Name, err)
+		return err
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -62,11 +105,46 @@ func (r *registry) Register(spec ToolSpec) error {
 	}
 
 	r.tools[spec.Name] = spec
+	r.schemas[spec.Name] = compiled
 	gl.Log("info", "Tool registered successfully", spec.Name, spec.Description)
 
 	return nil
 }
 
+// Replace atomically removes the named tools and registers specs in their place.
+// Every spec is validated and compiled first; on error the registry is left untouched.
+func (r *registry) Replace(remove []string, specs []ToolSpec) error {
+	compiled := make([]*toolSchema, len(specs))
+	for i := range specs {
+		if specs[i].Name == "" {
+			return fmt.Errorf("tool name cannot be empty")
+		}
+		if specs[i].Handler == nil {
+			return fmt.Errorf("tool handler cannot be nil for tool: %s", specs[i].Name)
+		}
+		schema, err := compileToolSchema(&specs[i])
+		if err != nil {
+			return err
+		}
+		compiled[i] = schema
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, name := range remove {
+		delete(r.tools, name)
+		delete(r.schemas, name)
+	}
+	for i, spec := range specs {
+		r.tools[spec.Name] = spec
+		r.schemas[spec.Name] = compiled[i]
+	}
+
+	gl.Log("info", "Tools replaced", len(remove), len(specs))
+	return nil
+}
+
 // List returns all registered tools
 func (r *registry) List() []ToolSpec {
 	r.mu.RLock()
@@ -84,7 +162,9 @@ func (r *registry) List() []ToolSpec {
 	return tools
 }
 
-// Exec executes a tool by name with the provided arguments
+// Exec executes a tool by name with the provided arguments. Arguments off
+// the input schema are rejected before the handler runs; results off the
+// output schema are only logged.
 func (r *registry) Exec(ctx context.Context, toolName string, args map[string]interface{}) (interface{}, error) {
 	if toolName == "" {
 		return nil, fmt.Errorf("tool name cannot be empty")
@@ -92,12 +172,25 @@ func (r *registry) Exec(ctx context.Context, toolName string, args map[string]in
 
 	r.mu.RLock()
 	tool, exists := r.tools[toolName]
+	schema := r.schemas[toolName]
+	policy := r.policy
 	r.mu.RUnlock()
 
 	if !exists {
 		return nil, fmt.Errorf("tool not found: %s", toolName)
 	}
 
+	if err := policy.Authorize(ctx, tool, PrincipalFromContext(ctx)); err != nil {
+		return nil, err
+	}
+
+	// Reject bad calls before the handler runs
+	args, err := schema.validateInput(tool, args)
+	if err != nil {
+		gl.Log("warn", "Tool arguments rejected", toolName, err)
+		return nil, err
+	}
+
 	gl.Log("info", "Executing tool", toolName, len(args))
 
 	result, err := tool.Handler(ctx, args)
@@ -106,10 +199,31 @@ func (r *registry) Exec(ctx context.Context, toolName string, args map[string]in
 		return nil, fmt.Errorf("tool execution failed: %w", err)
 	}
 
+	// The handler already ran and its side effects happened: a result off
+	// its schema is reported, not turned into an error a client would retry.
+	if err := schema.validateOutput(tool, result); err != nil {
+		gl.Log("warn", "Tool result does not match output schema", toolName, err)
+	}
+
 	gl.Log("debug", "Tool executed successfully", toolName)
 	return result, nil
 }
 
+// Validate checks args against the tool's input schema without running it.
+func (r *registry) Validate(toolName string, args map[string]interface{}) error {
+	r.mu.RLock()
+	tool, exists := r.tools[toolName]
+	schema := r.schemas[toolName]
+	r.mu.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("tool not found: %s", toolName)
+	}
+
+	_, err := schema.validateInput(tool, args)
+	return err
+}
+
 // GetTool returns a specific tool by name
 func (r *registry) GetTool(name string) (*ToolSpec, bool) {
 	r.mu.RLock()
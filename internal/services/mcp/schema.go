@@ -0,0 +1,204 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// emptyObjectSchema is used for tools that declare neither a schema nor legacy args.
+var emptyObjectSchema = json.RawMessage(`{"type":"object","properties":{}}`)
+
+// FieldError describes a single schema violation for a tool call.
+type FieldError struct {
+	Field   string      `json:"field"`
+	Type    string      `json:"type"`
+	Message string      `json:"message"`
+	Value   interface{} `json:"value,omitempty"`
+}
+
+// ValidationError is returned by Registry.Exec when arguments (or a result)
+// do not match the schema declared by the tool.
+type ValidationError struct {
+	Tool   string       `json:"tool"`
+	Target string       `json:"target"` // "input" or "output"
+	Errors []FieldError `json:"errors"`
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		parts = append(parts, fmt.Sprintf("%s: %s", fe.Field, fe.Message))
+	}
+	return fmt.Sprintf("invalid %s for tool %s: %s", e.Target, e.Tool, strings.Join(parts, "; "))
+}
+
+// toolSchema holds the compiled schemas of a registered tool.
+type toolSchema struct {
+	input  *gojsonschema.Schema
+	output *gojsonschema.Schema
+}
+
+// compileToolSchema normalizes the declared schemas of a spec and compiles them.
+// The spec is updated in place so List/GetTool always expose an input schema.
+func compileToolSchema(spec *ToolSpec) (*toolSchema, error) {
+	if len(spec.InputSchema) == 0 {
+		raw, err := schemaFromArgs(spec.Args)
+		if err != nil {
+			return nil, fmt.Errorf("invalid args for tool %s: %w", spec.Name, err)
+		}
+		spec.InputSchema = raw
+	}
+
+	compiled := &toolSchema{}
+
+	input, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(spec.InputSchema))
+	if err != nil {
+		return nil, fmt.Errorf("invalid input schema for tool %s: %w", spec.Name, err)
+	}
+	compiled.input = input
+
+	if len(spec.OutputSchema) > 0 {
+		output, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(spec.OutputSchema))
+		if err != nil {
+			return nil, fmt.Errorf("invalid output schema for tool %s: %w", spec.Name, err)
+		}
+		compiled.output = output
+	}
+
+	return compiled, nil
+}
+
+// validateInput applies top-level defaults to args and validates them against the input schema.
+func (s *toolSchema) validateInput(spec ToolSpec, args map[string]interface{}) (map[string]interface{}, error) {
+	args = applySchemaDefaults(spec.InputSchema, args)
+	if s == nil || s.input == nil {
+		return args, nil
+	}
+	return args, validateAgainst(s.input, spec.Name, "input", args)
+}
+
+// validateOutput validates a handler result against the output schema, if any.
+func (s *toolSchema) validateOutput(spec ToolSpec, result interface{}) error {
+	if s == nil || s.output == nil {
+		return nil
+	}
+	return validateAgainst(s.output, spec.Name, "output", result)
+}
+
+func validateAgainst(schema *gojsonschema.Schema, toolName, target string, value interface{}) error {
+	// Round-trip through JSON so typed Go values (structs, []string, ints)
+	// are checked the same way a remote client's payload would be.
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s for tool %s: %w", target, toolName, err)
+	}
+
+	result, err := schema.Validate(gojsonschema.NewBytesLoader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to validate %s for tool %s: %w", target, toolName, err)
+	}
+	if result.Valid() {
+		return nil
+	}
+
+	verr := &ValidationError{Tool: toolName, Target: target}
+	for _, re := range result.Errors() {
+		field := re.Field()
+		// gojsonschema reports missing properties against the parent object
+		if re.Type() == "required" {
+			if prop, ok := re.Details()["property"].(string); ok {
+				if field == gojsonschema.STRING_ROOT_SCHEMA_PROPERTY {
+					field = prop
+				} else {
+					field = field + "." + prop
+				}
+			}
+		}
+		verr.Errors = append(verr.Errors, FieldError{
+			Field:   field,
+			Type:    re.Type(),
+			Message: re.Description(),
+			Value:   re.Value(),
+		})
+	}
+	return verr
+}
+
+// schemaFromArgs converts the legacy free-form Args map into a JSON Schema object.
+// Each entry is treated as a property schema; a non-standard "required": true
+// flag on a property is lifted into the object's "required" list.
+func schemaFromArgs(args map[string]interface{}) (json.RawMessage, error) {
+	if len(args) == 0 {
+		return emptyObjectSchema, nil
+	}
+
+	properties := make(map[string]interface{}, len(args))
+	required := make([]string, 0)
+
+	for name, raw := range args {
+		prop, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("argument %s must be an object schema", name)
+		}
+
+		cleaned := make(map[string]interface{}, len(prop))
+		for k, v := range prop {
+			if k == "required" {
+				if isRequired, ok := v.(bool); ok && isRequired {
+					required = append(required, name)
+				}
+				continue
+			}
+			cleaned[k] = v
+		}
+		properties[name] = cleaned
+	}
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+
+	return json.Marshal(schema)
+}
+
+// applySchemaDefaults fills missing top-level properties with their declared defaults.
+// The caller's map is never mutated.
+func applySchemaDefaults(raw json.RawMessage, args map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(args))
+	for k, v := range args {
+		out[k] = v
+	}
+	if len(raw) == 0 {
+		return out
+	}
+
+	var schema struct {
+		Properties map[string]struct {
+			Default json.RawMessage `json:"default"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return out
+	}
+
+	for name, prop := range schema.Properties {
+		if _, exists := out[name]; exists || len(prop.Default) == 0 {
+			continue
+		}
+		var def interface{}
+		if err := json.Unmarshal(prop.Default, &def); err == nil {
+			out[name] = def
+		}
+	}
+	return out
+}
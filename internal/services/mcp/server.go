@@ -4,6 +4,7 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -17,6 +18,7 @@ import (
 	"github.com/kubex-ecosystem/gobe/internal/commons/embedkit/helpers"
 	"github.com/kubex-ecosystem/gobe/internal/contracts/interfaces"
 	"github.com/kubex-ecosystem/gobe/internal/contracts/types"
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
 	"github.com/kubex-ecosystem/gobe/internal/observers/events"
 
 	"github.com/mark3labs/mcp-go/mcp"
@@ -27,6 +29,7 @@ type Server struct {
 	mcpServer   *server.MCPServer
 	hub         MCPHandler
 	MCPRegistry execsafe.Registry
+	policy      Policy
 
 	startedAt time.Time
 	userTag   string
@@ -53,13 +56,14 @@ type MCPHandler interface {
 	GetEventStream() *events.Stream
 }
 
-var systemInfoAllowList = map[string]struct{}{
-	"1344830702780420157": {}, // Apenas você!
-	"1400577637461659759": {},
-	"880669325143461898":  {},
-	"kblom":               {},
-	"admin":               {},
-	"faelmori":            {},
+// serverToolAuth declares the Auth requirement of the hand-wired MCP server tools.
+// Access beyond these defaults is granted through the configured Policy.
+var serverToolAuth = map[string]string{
+	"analyze_discord_message":  AuthNone,
+	"send_discord_message":     AuthNone,
+	"create_task_from_message": AuthNone,
+	"get_system_info":          AuthAdmin,
+	"execute_shell_command":    AuthAdmin,
 }
 
 func NewMCPServer(hub MCPHandler) (IMCPServer, error) {
@@ -83,6 +87,7 @@ func NewServer(hub MCPHandler) (*Server, error) {
 	srv := &Server{
 		mcpServer: mcpServer,
 		hub:       hub,
+		policy:    DefaultPolicy(),
 		startedAt: time.Now(),
 	}
 
@@ -156,6 +161,26 @@ func NewServer(hub MCPHandler) (*Server, error) {
 	return srv, nil
 }
 
+// SetPolicy replaces the authorization policy applied to the hand-wired tools.
+// Registry tools are authorized by the registry's own policy.
+func (s *Server) SetPolicy(policy Policy) {
+	if policy == nil {
+		policy = DefaultPolicy()
+	}
+	s.policy = policy
+}
+
+// authorize checks the caller of a hand-wired tool. The identity comes only
+// from the context (JWT middleware, ServeStdio's LocalPrincipal or an
+// adapter); without one the caller is anonymous, never a tool argument.
+func (s *Server) authorize(ctx context.Context, toolName string) *mcp.CallToolResult {
+	spec := ToolSpec{Name: toolName, Auth: serverToolAuth[toolName]}
+	if err := s.policy.Authorize(ctx, spec, PrincipalFromContext(ctx)); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("❌ ACESSO NEGADO: %v", err))
+	}
+	return nil
+}
+
 func (s *Server) RegisterTools() {
 	// Analyze Discord Message Tool
 	analyzeTool := mcp.NewTool("analyze_discord_message",
@@ -196,7 +221,6 @@ func (s *Server) RegisterTools() {
 	systemInfoTool := mcp.NewTool("get_system_info",
 		mcp.WithDescription("Get real-time system information (CPU, RAM, disk usage)"),
 		mcp.WithString("info_type", mcp.Required()), // "cpu", "memory", "disk", "all"
-		mcp.WithString("user_id", mcp.Required()),   // Para validação de segurança
 	)
 
 	systemInfoHandler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -212,7 +236,6 @@ func (s *Server) RegisterTools() {
 	shellTool := mcp.NewTool("execute_shell_command",
 		mcp.WithDescription("Execute shell command on host system - REQUIRES ADMIN"),
 		mcp.WithString("command", mcp.Required()),
-		mcp.WithString("user_id", mcp.Required()),
 		mcp.WithBoolean("require_confirmation"),
 	)
 
@@ -244,6 +267,109 @@ func (s *Server) RegisterTools() {
 	s.mcpServer.AddTool(taskTool, taskHandler)
 }
 
+// RegisterRegistryTools publishes every tool of a dynamic Registry on the MCP server,
+// advertising the tool's JSON Schema as inputSchema/outputSchema.
+func (s *Server) RegisterRegistryTools(reg Registry) {
+	if reg == nil {
+		return
+	}
+	for _, spec := range reg.List() {
+		s.mcpServer.AddTool(ToMCPTool(spec), registryToolHandler(reg, spec.Name))
+	}
+}
+
+// SyncRegistryTools republishes the registry after it changed, dropping the
+// tools listed in removed. Connected clients get a tools/list_changed notification.
+func (s *Server) SyncRegistryTools(reg Registry, removed []string) {
+	if len(removed) > 0 {
+		s.mcpServer.DeleteTools(removed...)
+	}
+	s.RegisterRegistryTools(reg)
+}
+
+// ToMCPTool converts a registry ToolSpec into its mark3labs representation.
+func ToMCPTool(spec ToolSpec) mcp.Tool {
+	inputSchema := spec.InputSchema
+	if len(inputSchema) == 0 {
+		inputSchema = emptyObjectSchema
+	}
+
+	description := spec.Description
+	if description == "" {
+		description = spec.Title
+	}
+
+	tool := mcp.NewToolWithRawSchema(spec.Name, description, inputSchema)
+	if len(spec.OutputSchema) > 0 {
+		tool.RawOutputSchema = spec.OutputSchema
+	}
+	if spec.Title != "" {
+		tool.Annotations.Title = spec.Title
+	}
+	return tool
+}
+
+// registryToolHandler adapts Registry.Exec to the mark3labs tool handler signature.
+func registryToolHandler(reg Registry, name string) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+		if args == nil {
+			args = map[string]interface{}{}
+		}
+		if meta := request.Params.Meta; meta != nil && meta.ProgressToken != nil {
+			ctx = WithProgress(ctx, progressNotifier(ctx, meta.ProgressToken))
+		}
+
+		result, err := reg.Exec(ctx, name, args)
+		if err != nil {
+			var verr *ValidationError
+			var authErr *AuthorizationError
+			if errors.As(err, &authErr) {
+				return mcp.NewToolResultError(fmt.Sprintf("❌ ACESSO NEGADO: %v", authErr)), nil
+			}
+			if errors.As(err, &verr) {
+				res := mcp.NewToolResultStructured(verr, verr.Error())
+				res.IsError = true
+				return res, nil
+			}
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to encode result: %v", err)), nil
+		}
+		// structuredContent must be a JSON object per the MCP spec
+		if _, ok := result.(map[string]interface{}); ok {
+			return mcp.NewToolResultStructured(result, string(data)), nil
+		}
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
+
+// progressNotifier forwards ReportProgress calls to the MCP client as
+// notifications/progress for the request that carried token.
+func progressNotifier(ctx context.Context, token mcp.ProgressToken) ProgressFunc {
+	return func(progress, total float64, message string) {
+		srv := server.ServerFromContext(ctx)
+		if srv == nil {
+			return
+		}
+		params := map[string]any{
+			"progressToken": token,
+			"progress":      progress,
+		}
+		if total > 0 {
+			params["total"] = total
+		}
+		if message != "" {
+			params["message"] = message
+		}
+		// Progress is best effort; the session may already be gone
+		_ = srv.SendNotificationToClient(ctx, "notifications/progress", params)
+	}
+}
+
 func (s *Server) RegisterResources() {
 	// Discord Events Resource
 	eventsResource := mcp.NewResource(
@@ -311,6 +437,13 @@ func (s *Server) RegisterResources() {
 }
 
 func (s *Server) HandleAnalyzeMessage(ctx context.Context, params map[string]interface{}) (*mcp.CallToolResult, error) {
+	if denied := s.authorize(ctx, "analyze_discord_message"); denied != nil {
+		return denied, nil
+	}
+	if s.hub == nil {
+		return mcp.NewToolResultError("Discord hub is not available on this server"), nil
+	}
+
 	content, _ := params["message_content"].(string)
 	channelID, _ := params["channel_id"].(string)
 	userID, _ := params["user_id"].(string)
@@ -333,6 +466,13 @@ func (s *Server) HandleAnalyzeMessage(ctx context.Context, params map[string]int
 }
 
 func (s *Server) HandleSendMessage(ctx context.Context, params map[string]interface{}) (*mcp.CallToolResult, error) {
+	if denied := s.authorize(ctx, "send_discord_message"); denied != nil {
+		return denied, nil
+	}
+	if s.hub == nil {
+		return mcp.NewToolResultError("Discord hub is not available on this server"), nil
+	}
+
 	channelID, _ := params["channel_id"].(string)
 	content, _ := params["content"].(string)
 
@@ -345,6 +485,10 @@ func (s *Server) HandleSendMessage(ctx context.Context, params map[string]interf
 }
 
 func (s *Server) HandleCreateTask(ctx context.Context, params map[string]interface{}) (*mcp.CallToolResult, error) {
+	if denied := s.authorize(ctx, "create_task_from_message"); denied != nil {
+		return denied, nil
+	}
+
 	messageID, _ := params["message_id"].(string)
 	title, _ := params["task_title"].(string)
 	description, _ := params["task_description"].(string)
@@ -369,11 +513,10 @@ func (s *Server) HandleSystemInfo(ctx context.Context, params map[string]interfa
 		infoType = "all"
 	}
 
-	userID, _ := params["user_id"].(string)
-
-	if _, ok := systemInfoAllowList[userID]; !ok {
-		return mcp.NewToolResultError(fmt.Sprintf("❌ Usuário %s não autorizado para comandos do sistema", userID)), nil
+	if denied := s.authorize(ctx, "get_system_info"); denied != nil {
+		return denied, nil
 	}
+	userID := PrincipalFromContext(ctx).String()
 
 	cpuInfo, cpuErr := s.GetCPUInfo()
 	memoryInfo, memoryErr := s.GetMemoryInfo()
@@ -478,26 +621,11 @@ func (s *Server) HandleSystemInfo(ctx context.Context, params map[string]interfa
 
 func (s *Server) HandleShellCommand(ctx context.Context, params map[string]interface{}) (*mcp.CallToolResult, error) {
 	command, _ := params["command"].(string)
-	userID, _ := params["user_id"].(string)
 	requireConfirmation, _ := params["require_confirmation"].(bool)
 
-	// 🔒 SUPER Validação de Segurança
-	adminUsers := []string{
-		"1344830702780420157", // Apenas você!
-		"1400577637461659759",
-		"880669325143461898",
-	}
-
-	isAdmin := false
-	for _, admin := range adminUsers {
-		if userID == admin {
-			isAdmin = true
-			break
-		}
-	}
-
-	if !isAdmin {
-		return mcp.NewToolResultError("❌ ACESSO NEGADO: Apenas administradores podem executar comandos shell"), nil
+	// 🔒 Validação de Segurança via política MCP
+	if denied := s.authorize(ctx, "execute_shell_command"); denied != nil {
+		return denied, nil
 	}
 
 	// 🚫 Blacklist de comandos perigosos
@@ -513,7 +641,7 @@ func (s *Server) HandleShellCommand(ctx context.Context, params map[string]inter
 	}
 
 	// Log da execução
-	fmt.Printf("🔧 SHELL EXECUTION by %s: %s\n", userID, command)
+	gl.Log("info", fmt.Sprintf("🔧 SHELL EXECUTION by %s: %s", PrincipalFromContext(ctx), command))
 
 	output, err := s.executeShellCommand(command)
 	if err != nil {
@@ -0,0 +1,91 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+	"github.com/kubex-ecosystem/gobe/internal/sockets/messagery"
+)
+
+// TaskQueue receives the MCP tasks published on gobe.events with a
+// "mcp.task.<tool>" routing key.
+const TaskQueue = "gobe.mcp.tasks"
+
+// taskKeyPrefix is the routing key prefix of the tasks in TaskQueue.
+const taskKeyPrefix = "mcp.task."
+
+// Task asks gobe to run an MCP tool over AMQP. Tool defaults to the last
+// segment of the routing key.
+type Task struct {
+	ID   string                 `json:"id,omitempty"`
+	Tool string                 `json:"tool,omitempty"`
+	Args map[string]interface{} `json:"args,omitempty"`
+}
+
+// TaskResult is sent to the reply_to queue of a task, with the task's
+// correlation ID.
+type TaskResult struct {
+	ID     string      `json:"id,omitempty"`
+	Tool   string      `json:"tool"`
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// TaskHandler runs the tasks of TaskQueue through reg as principal. Tasks
+// the registry refuses (unknown tool, denied, invalid arguments) are
+// dead-lettered at once; other failures are retried. When reply is set and
+// the task has a reply_to, the result or final error is sent back.
+func TaskHandler(reg Registry, principal *Principal, reply func(d messagery.Delivery, body []byte) error) messagery.Handler {
+	return messagery.JSONHandler(func(ctx context.Context, task Task, d messagery.Delivery) error {
+		if task.Tool == "" {
+			task.Tool = strings.TrimPrefix(d.RoutingKey, taskKeyPrefix)
+		}
+		if task.ID == "" {
+			task.ID = d.CorrelationId
+		}
+		if task.Tool == "" || strings.Contains(task.Tool, "*") {
+			return messagery.Permanent(errors.New("task has no tool"))
+		}
+
+		ctx = WithPrincipal(ctx, principal)
+		err := reg.Authorize(ctx, task.Tool)
+		if err == nil {
+			err = reg.Validate(task.Tool, task.Args)
+		}
+		if err != nil {
+			sendTaskResult(reply, d, TaskResult{ID: task.ID, Tool: task.Tool, Error: err.Error()})
+			return messagery.Permanent(err)
+		}
+
+		result, err := reg.Exec(ctx, task.Tool, task.Args)
+		if err != nil {
+			var validation *ValidationError
+			if errors.As(err, &validation) {
+				err = messagery.Permanent(err)
+			}
+			if errors.Is(err, messagery.ErrPermanent) || messagery.FinalAttempt(ctx) {
+				sendTaskResult(reply, d, TaskResult{ID: task.ID, Tool: task.Tool, Error: err.Error()})
+			}
+			return err
+		}
+		sendTaskResult(reply, d, TaskResult{ID: task.ID, Tool: task.Tool, Result: result})
+		return nil
+	})
+}
+
+func sendTaskResult(reply func(d messagery.Delivery, body []byte) error, d messagery.Delivery, result TaskResult) {
+	if reply == nil || d.ReplyTo == "" {
+		return
+	}
+	body, err := json.Marshal(result)
+	if err != nil {
+		gl.Log("error", "Failed to marshal MCP task result", result.ID, err)
+		return
+	}
+	if err := reply(d, body); err != nil {
+		gl.Log("warn", "Failed to reply to MCP task", result.ID, err)
+	}
+}
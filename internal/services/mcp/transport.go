@@ -0,0 +1,109 @@
+package mcp
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/user"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Transport names accepted by `gobe mcp-server --transport`.
+const (
+	TransportStdio = "stdio"
+	TransportHTTP  = "http"
+)
+
+// DefaultHTTPBasePath is where the HTTP transports are mounted on the gin router.
+const DefaultHTTPBasePath = "/mcp"
+
+// MCPServer returns the underlying mark3labs server.
+func (s *Server) MCPServer() *server.MCPServer {
+	return s.mcpServer
+}
+
+// ServeStdio serves MCP as newline-delimited JSON-RPC over in/out until ctx is
+// cancelled or in is closed. Calls run as the local OS user, so admin tools
+// require a policy binding for that user.
+func (s *Server) ServeStdio(ctx context.Context, in io.Reader, out io.Writer) error {
+	stdio := server.NewStdioServer(s.mcpServer)
+	stdio.SetErrorLogger(log.New(os.Stderr, "mcp-stdio: ", log.LstdFlags))
+
+	principal := LocalPrincipal()
+	stdio.SetContextFunc(func(ctx context.Context) context.Context {
+		return WithPrincipal(ctx, principal)
+	})
+
+	return stdio.Listen(ctx, in, out)
+}
+
+// LocalPrincipal identifies the OS user running the process.
+func LocalPrincipal() *Principal {
+	p := &Principal{Source: TransportStdio}
+	if u, err := user.Current(); err == nil {
+		p.ID = u.Uid
+		p.Username = u.Username
+	}
+	return p
+}
+
+// HTTPTransport serves the MCP server over HTTP under a base path:
+//
+//	GET  {base}/sse             SSE stream (2024-11-05 transport)
+//	POST {base}/message         SSE client messages
+//	GET|POST|DELETE {base}      streamable HTTP (2025-03-26 transport)
+//
+// The routes must sit behind the JWT authentication middleware: tool calls
+// run as the principal of the claims it stores, and a request without claims
+// is anonymous, so admin and user tools are denied to it.
+type HTTPTransport struct {
+	basePath   string
+	sse        *server.SSEServer
+	streamable *server.StreamableHTTPServer
+}
+
+// NewHTTPTransport builds the SSE and streamable HTTP handlers for basePath.
+func (s *Server) NewHTTPTransport(basePath string) *HTTPTransport {
+	basePath = "/" + strings.Trim(basePath, "/")
+	if basePath == "/" {
+		basePath = DefaultHTTPBasePath
+	}
+
+	return &HTTPTransport{
+		basePath:   basePath,
+		sse:        server.NewSSEServer(s.mcpServer, server.WithStaticBasePath(basePath), server.WithSSEContextFunc(httpPrincipal)),
+		streamable: server.NewStreamableHTTPServer(s.mcpServer, server.WithEndpointPath(basePath), server.WithHTTPContextFunc(httpPrincipal)),
+	}
+}
+
+// httpPrincipal pins the caller of an HTTP request, resolved from the JWT
+// claims on the request, on the context its tool calls run with.
+func httpPrincipal(ctx context.Context, r *http.Request) context.Context {
+	principal := PrincipalFromContext(r.Context())
+	if principal == nil {
+		principal = &Principal{Source: TransportHTTP}
+	}
+	return WithPrincipal(ctx, principal)
+}
+
+// BasePath returns the path the transport is served under.
+func (t *HTTPTransport) BasePath() string { return t.basePath }
+
+// SSEPath returns the path of the SSE stream endpoint.
+func (t *HTTPTransport) SSEPath() string { return t.sse.CompleteSsePath() }
+
+// MessagePath returns the path SSE clients post their messages to.
+func (t *HTTPTransport) MessagePath() string { return t.sse.CompleteMessagePath() }
+
+// SSEHandler handles GET {base}/sse.
+func (t *HTTPTransport) SSEHandler() http.Handler { return t.sse.SSEHandler() }
+
+// MessageHandler handles POST {base}/message.
+func (t *HTTPTransport) MessageHandler() http.Handler { return t.sse.MessageHandler() }
+
+// StreamableHandler handles GET, POST and DELETE on {base}.
+func (t *HTTPTransport) StreamableHandler() http.Handler { return t.streamable }
@@ -1,41 +1,230 @@
-// Package manager fornece implementações para o gerenciamento de cronjobs usando GoroutinePool.
+// Package manager fornece implementações para o gerenciamento de cronjobs.
 package manager
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
+	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
 	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+	"github.com/kubex-ecosystem/gobe/internal/services/scheduler/cron"
 	pl "github.com/kubex-ecosystem/gobe/internal/services/scheduler/services"
+	tp "github.com/kubex-ecosystem/gobe/internal/services/scheduler/types"
+	l "github.com/kubex-ecosystem/logz"
+	"gorm.io/gorm"
 )
 
-// CronJobScheduler gerencia a execução de cronjobs usando o GoroutinePool.
+// JobStore lê os cronjobs persistidos; svc.CronRepo satisfaz.
+type JobStore interface {
+	FindByID(ctx context.Context, id uuid.UUID) (*svc.CronModel, error)
+	FindAll(ctx context.Context) ([]*svc.CronModel, error)
+}
+
+// CronJobScheduler agenda os cronjobs persistidos em instâncias de cron.Cron,
+// uma por fuso horário (metadata.timezone), e os executa pelo executor.
+// Cada entrada passa pela cadeia Recover + política de sobreposição
+// (metadata.overlap: skip, delay ou allow). Schedule, Sync e Unschedule
+// atualizam as entradas com o agendador em execução.
 type CronJobScheduler struct {
-	pool         *pl.GoroutinePool
-	ICronService pl.ICronService // Interface para interagir com o serviço de cronjobs
+	jobs     JobStore
+	executor tp.Executor
+	logger   l.Logger
+	// Location é o fuso dos cronjobs sem metadata.timezone (time.Local por padrão).
+	Location *time.Location
+
+	mu      sync.Mutex
+	started bool
+	crons   map[string]*cron.Cron
+	entries map[uuid.UUID]*cronEntry
 }
 
-// NewCronJobScheduler cria uma nova instância do CronJobScheduler.
-func NewCronJobScheduler(pool *pl.GoroutinePool, ICronService pl.ICronService) *CronJobScheduler {
+// cronEntry é o agendamento de um cronjob.
+type cronEntry struct {
+	cron   *cron.Cron
+	id     cron.EntryID
+	key    string
+	policy string
+	model  *svc.CronModel
+}
+
+// NewCronJobScheduler cria um agendador para os cronjobs de jobs.
+func NewCronJobScheduler(jobs JobStore, executor tp.Executor) *CronJobScheduler {
 	return &CronJobScheduler{
-		pool:         pool,
-		ICronService: ICronService,
-	}
-}
-
-// Start inicia o loop de verificação e execução de cronjobs.
-func (s *CronJobScheduler) Start() {
-	go func() {
-		ticker := time.NewTicker(1 * time.Minute) // Verifica os cronjobs a cada minuto
-		defer ticker.Stop()
-		for range ticker.C {
-			cronJobs, err := s.ICronService.GetScheduledCronJobs()
-			if err != nil {
-				gl.Log("error", "Error fetching scheduled cronjobs: %v", err)
-				continue
-			}
-			for _, job := range cronJobs {
-				s.pool.Submit(job)
-			}
+		jobs:     jobs,
+		executor: executor,
+		logger:   l.GetLogger("cron"),
+		Location: time.Local,
+		crons:    make(map[string]*cron.Cron),
+		entries:  make(map[uuid.UUID]*cronEntry),
+	}
+}
+
+// Start carrega os cronjobs persistidos e inicia os agendadores. Os cronjobs
+// com agendamento inválido são ignorados.
+func (s *CronJobScheduler) Start(ctx context.Context) error {
+	models, err := s.jobs.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.started = true
+	for _, c := range s.crons {
+		c.Start()
+	}
+	s.mu.Unlock()
+	for _, model := range models {
+		if err := s.Schedule(model); err != nil {
+			gl.Log("warn", fmt.Sprintf("Skipping cron job %s: %v", model.ID, err))
 		}
-	}()
+	}
+	return nil
+}
+
+// Stop encerra os agendadores; as execuções em andamento terminam.
+func (s *CronJobScheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.started = false
+	for _, c := range s.crons {
+		c.Stop()
+	}
+}
+
+// Schedule agenda model, substituindo o agendamento anterior quando a
+// expressão, o fuso ou a política mudam. Cronjobs inativos ou encerrados
+// são removidos.
+func (s *CronJobScheduler) Schedule(model *svc.CronModel) error {
+	if model == nil {
+		return nil
+	}
+	if !model.IsActive || strings.TrimSpace(model.CronExpression) == "" || ended(model, time.Now()) {
+		s.Unschedule(model.ID)
+		return nil
+	}
+	schedule, err := pl.ParseSchedule(model)
+	if err == nil {
+		err = pl.ValidateSchedule(model)
+	}
+	if err != nil {
+		s.Unschedule(model.ID)
+		return err
+	}
+	loc, _ := pl.LocationOf(model, s.Location)
+	policy, _ := pl.OverlapOf(model)
+	key := strings.Join([]string{model.CronType, strings.TrimSpace(model.CronExpression), loc.String(), policy}, "|")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry, ok := s.entries[model.ID]; ok {
+		if entry.key == key {
+			entry.model = model
+			return nil
+		}
+		entry.cron.Remove(entry.id)
+		delete(s.entries, model.ID)
+	}
+	entry := &cronEntry{cron: s.cronFor(loc), key: key, policy: policy, model: model}
+	id := model.ID
+	job := cron.NewChain(cron.Recover(s.logger), overlap(policy, s.logger)).Then(cron.FuncJob(func() { s.run(id) }))
+	entry.id = entry.cron.Schedule(schedule, job)
+	s.entries[id] = entry
+	return nil
+}
+
+// Sync relê o cronjob id e atualiza seu agendamento; um cronjob removido
+// deixa de ser agendado.
+func (s *CronJobScheduler) Sync(ctx context.Context, id uuid.UUID) error {
+	model, err := s.jobs.FindByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && model == nil) {
+		s.Unschedule(id)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.Schedule(model)
+}
+
+// Unschedule remove o agendamento do cronjob id.
+func (s *CronJobScheduler) Unschedule(id uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry, ok := s.entries[id]; ok {
+		entry.cron.Remove(entry.id)
+		delete(s.entries, id)
+	}
+}
+
+// Next retorna a próxima execução agendada do cronjob id; zero antes de
+// Start.
+func (s *CronJobScheduler) Next(id uuid.UUID) (time.Time, bool) {
+	s.mu.Lock()
+	entry, ok := s.entries[id]
+	s.mu.Unlock()
+	if !ok {
+		return time.Time{}, false
+	}
+	return entry.cron.Entry(entry.id).Next, true
+}
+
+// cronFor retorna o agendador do fuso loc, criando-o se preciso.
+func (s *CronJobScheduler) cronFor(loc *time.Location) *cron.Cron {
+	c, ok := s.crons[loc.String()]
+	if !ok {
+		c = cron.New(cron.WithLocation(loc), cron.WithLogger(s.logger))
+		s.crons[loc.String()] = c
+		if s.started {
+			c.Start()
+		}
+	}
+	return c
+}
+
+// run executa o cronjob id, com as retentativas do job, se estiver na sua
+// janela (StartsAt, EndsAt).
+func (s *CronJobScheduler) run(id uuid.UUID) {
+	s.mu.Lock()
+	entry, ok := s.entries[id]
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+	model, now := entry.model, time.Now()
+	if ended(model, now) {
+		s.Unschedule(id)
+		return
+	}
+	if !model.StartsAt.IsZero() && now.Before(model.StartsAt) {
+		return
+	}
+	// uma execução manual em andamento também conta como sobreposição
+	if entry.policy == pl.OverlapSkip && s.executor.Running(id) {
+		gl.Log("info", fmt.Sprintf("Skipping cron job %s: still running", id))
+		return
+	}
+	job := tp.NewCronJob(model.ID, model.UserID, model.Name, model.CronExpression,
+		model.MaxRetries, time.Duration(model.RetryInterval)*time.Second, s.executor)
+	if err := job.Run(); err != nil {
+		gl.Log("warn", fmt.Sprintf("Cron job %s failed: %v", id, err))
+	}
+}
+
+func ended(model *svc.CronModel, now time.Time) bool {
+	return model.EndsAt != nil && now.After(*model.EndsAt)
+}
+
+// overlap retorna o JobWrapper da política de sobreposição policy.
+func overlap(policy string, logger l.Logger) cron.JobWrapper {
+	switch policy {
+	case pl.OverlapDelay:
+		return cron.DelayIfStillRunning(logger)
+	case pl.OverlapAllow:
+		return func(j cron.Job) cron.Job { return j }
+	}
+	return cron.SkipIfStillRunning(logger)
 }
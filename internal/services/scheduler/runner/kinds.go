@@ -0,0 +1,309 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kubex-ecosystem/gobe/internal/app/security/execsafe"
+	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
+	"github.com/kubex-ecosystem/gobe/internal/services/gateway"
+)
+
+// Kinds of work a cron job runs, named by Metadata["kind"]. Jobs without a
+// kind are http jobs when they have an APIEndpoint and command jobs
+// otherwise.
+//
+//   - command runs Command, split like a shell command line but without a
+//     shell, when its binary is allowed.
+//   - http sends Method (GET) to APIEndpoint with Headers; Payload is the
+//     JSON body of the other methods.
+//   - mcp_tool runs the MCP tool Metadata["tool"] with Payload as arguments.
+//   - llm sends the prompt Command (or Payload["prompt"]) through the
+//     gateway to Metadata["provider"] and Metadata["model"], after the
+//     optional Metadata["system"] prompt.
+//   - amqp publishes Payload to the routing key Metadata["routing_key"] of
+//     Metadata["exchange"] (gobe.events) through the message broker.
+const (
+	KindCommand = "command"
+	KindHTTP    = "http"
+	KindMCPTool = "mcp_tool"
+	KindLLM     = "llm"
+	KindAMQP    = "amqp"
+)
+
+// defaultExchange receives the amqp jobs without an exchange.
+const defaultExchange = "gobe.events"
+
+// result is the outcome of a run before it is logged.
+type result struct {
+	output     string
+	exitCode   int
+	statusCode int
+	truncated  bool
+	err        error
+}
+
+func kindOf(job *svc.CronModel) string {
+	if kind := strings.ToLower(metaString(job, "kind")); kind != "" {
+		return kind
+	}
+	if strings.TrimSpace(job.APIEndpoint) != "" {
+		return KindHTTP
+	}
+	return KindCommand
+}
+
+func metaString(job *svc.CronModel, key string) string {
+	if value, ok := job.Metadata[key].(string); ok {
+		return strings.TrimSpace(value)
+	}
+	return ""
+}
+
+// Validate reports, wrapping ErrInvalidJob, why job could not run: an
+// unknown kind or a missing field of its kind.
+func Validate(job *svc.CronModel) error {
+	var err error
+	switch kind := kindOf(job); kind {
+	case KindCommand:
+		_, err = execsafe.ParseCommand(job.Command)
+	case KindHTTP:
+		_, err = endpointOf(job)
+	case KindMCPTool:
+		if metaString(job, "tool") == "" {
+			err = errors.New("metadata.tool is required")
+		}
+	case KindLLM:
+		if promptOf(job) == "" {
+			err = errors.New("an llm job needs a prompt")
+		}
+	case KindAMQP:
+		if metaString(job, "routing_key") == "" {
+			err = errors.New("metadata.routing_key is required")
+		}
+	default:
+		err = fmt.Errorf("unknown kind %q", kind)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidJob, err)
+	}
+	return nil
+}
+
+func endpointOf(job *svc.CronModel) (*url.URL, error) {
+	endpoint, err := url.Parse(strings.TrimSpace(job.APIEndpoint))
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, errors.New("api_endpoint must be an http(s) URL")
+	}
+	return endpoint, nil
+}
+
+func promptOf(job *svc.CronModel) string {
+	if prompt := strings.TrimSpace(job.Command); prompt != "" {
+		return prompt
+	}
+	prompt, _ := job.Payload["prompt"].(string)
+	return strings.TrimSpace(prompt)
+}
+
+func (r *Runner) execute(ctx context.Context, job *svc.CronModel, kind string) result {
+	if err := Validate(job); err != nil {
+		return result{err: err}
+	}
+	switch kind {
+	case KindCommand:
+		return r.runCommand(ctx, job)
+	case KindHTTP:
+		return r.runHTTP(ctx, job)
+	case KindMCPTool:
+		return r.runTool(ctx, job)
+	case KindLLM:
+		return r.runPrompt(ctx, job)
+	case KindAMQP:
+		return r.runPublish(job)
+	}
+	return result{err: fmt.Errorf("%w: unknown kind %q", ErrInvalidJob, kind)}
+}
+
+func (r *Runner) runCommand(ctx context.Context, job *svc.CronModel) result {
+	if len(r.options.AllowedCommands) == 0 {
+		return result{err: fmt.Errorf("%w: no command is allowed (cron.allowed_commands)", ErrUnavailable)}
+	}
+	parsed, err := execsafe.ParseCommand(job.Command)
+	if err != nil {
+		return result{err: fmt.Errorf("%w: %v", ErrInvalidJob, err)}
+	}
+	timeout := r.options.Timeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	res, err := execsafe.Exec(ctx, parsed.Name, parsed.Args, execsafe.Options{
+		Timeout:   timeout,
+		Allowlist: r.options.AllowedCommands,
+		MaxBytes:  r.options.MaxOutputBytes,
+	})
+	output := res.Stdout
+	if res.Stderr != "" {
+		if output != "" && !strings.HasSuffix(output, "\n") {
+			output += "\n"
+		}
+		output += res.Stderr
+	}
+	return result{output: output, exitCode: res.ExitCode, truncated: res.Truncated, err: err}
+}
+
+func (r *Runner) runHTTP(ctx context.Context, job *svc.CronModel) result {
+	endpoint, err := endpointOf(job)
+	if err != nil {
+		return result{err: fmt.Errorf("%w: %v", ErrInvalidJob, err)}
+	}
+	method := strings.ToUpper(strings.TrimSpace(job.Method))
+	if method == "" {
+		method = http.MethodGet
+	}
+	var body io.Reader
+	if len(job.Payload) > 0 && method != http.MethodGet && method != http.MethodHead {
+		data, err := json.Marshal(job.Payload)
+		if err != nil {
+			return result{err: fmt.Errorf("%w: payload: %v", ErrInvalidJob, err)}
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint.String(), body)
+	if err != nil {
+		return result{err: fmt.Errorf("%w: %v", ErrInvalidJob, err)}
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range job.Headers {
+		req.Header.Set(name, fmt.Sprint(value))
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return result{exitCode: -1, err: err}
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(r.options.MaxOutputBytes)+1))
+	res := result{output: string(data), statusCode: resp.StatusCode}
+	if len(data) > r.options.MaxOutputBytes {
+		res.truncated = true
+	}
+	switch {
+	case err != nil:
+		res.err = fmt.Errorf("reading the response: %w", err)
+	case resp.StatusCode >= http.StatusBadRequest:
+		res.err = fmt.Errorf("%s %s: HTTP %d", method, endpoint.Redacted(), resp.StatusCode)
+	}
+	return res
+}
+
+func (r *Runner) runTool(ctx context.Context, job *svc.CronModel) result {
+	r.mu.Lock()
+	run := r.tools
+	r.mu.Unlock()
+	if run == nil {
+		return result{err: fmt.Errorf("%w: no MCP registry", ErrUnavailable)}
+	}
+	args := map[string]interface{}(job.Payload)
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	out, err := run(ctx, metaString(job, "tool"), args)
+	if err != nil {
+		return result{err: err}
+	}
+	return result{output: render(out)}
+}
+
+func (r *Runner) runPrompt(ctx context.Context, job *svc.CronModel) result {
+	r.mu.Lock()
+	chat := r.chat
+	r.mu.Unlock()
+	if chat == nil {
+		return result{err: fmt.Errorf("%w: no LLM gateway", ErrUnavailable)}
+	}
+
+	var messages []gateway.Message
+	if system := metaString(job, "system"); system != "" {
+		messages = append(messages, gateway.Message{Role: "system", Content: system})
+	}
+	messages = append(messages, gateway.Message{Role: "user", Content: promptOf(job)})
+	stream, _, err := chat.Chat(ctx, gateway.ChatRequest{
+		Provider: metaString(job, "provider"),
+		Model:    metaString(job, "model"),
+		Messages: messages,
+		Meta:     map[string]interface{}{"source": "cron", "cron_job_id": job.ID.String()},
+	})
+	if err != nil {
+		return result{err: err}
+	}
+
+	// the stream is drained to the end so that its producer can finish
+	var answer strings.Builder
+	res := result{}
+	for chunk := range stream {
+		if chunk.Error != "" && res.err == nil {
+			res.err = fmt.Errorf("llm: %s", chunk.Error)
+			res.statusCode = chunk.StatusCode
+		}
+		if answer.Len() <= r.options.MaxOutputBytes {
+			answer.WriteString(chunk.Content)
+		}
+	}
+	res.output = answer.String()
+	return res
+}
+
+func (r *Runner) runPublish(job *svc.CronModel) result {
+	key := metaString(job, "routing_key")
+	exchange := metaString(job, "exchange")
+	if exchange == "" {
+		exchange = defaultExchange
+	}
+	r.mu.Lock()
+	broker := r.broker
+	r.mu.Unlock()
+	if broker == nil {
+		return result{err: fmt.Errorf("%w: no message broker", ErrUnavailable)}
+	}
+
+	payload := map[string]interface{}(job.Payload)
+	if payload == nil {
+		payload = map[string]interface{}{}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return result{err: fmt.Errorf("%w: payload: %v", ErrInvalidJob, err)}
+	}
+	if err := broker.Publish(exchange, key, body); err != nil {
+		return result{err: err}
+	}
+	return result{output: fmt.Sprintf("published %d bytes to %s with key %s on %s", len(body), exchange, key, broker.Kind())}
+}
+
+// render turns a tool result into the text of the execution log.
+func render(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
@@ -0,0 +1,93 @@
+package runner
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
+)
+
+// MemoryStore keeps the execution log in memory. It serves tests and
+// runners without a database; runs are lost on restart.
+type MemoryStore struct {
+	mu   sync.RWMutex
+	runs map[string]svc.CronExecutionRecord
+}
+
+// NewMemoryStore returns an empty in-memory execution log.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{runs: make(map[string]svc.CronExecutionRecord)}
+}
+
+func (m *MemoryStore) SaveExecution(ctx context.Context, record *svc.CronExecutionRecord) error {
+	if record.ID == "" {
+		record.ID = uuid.NewString()
+	}
+	if record.ExecutionTime.IsZero() {
+		record.ExecutionTime = time.Now().UTC()
+	}
+	if record.Status == "" {
+		record.Status = svc.CronExecutionRunning
+	}
+	m.mu.Lock()
+	m.runs[record.ID] = *record
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *MemoryStore) UpdateExecution(ctx context.Context, record *svc.CronExecutionRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.runs[record.ID]; !ok {
+		return svc.ErrCronExecutionNotFound
+	}
+	m.runs[record.ID] = *record
+	return nil
+}
+
+func (m *MemoryStore) GetExecution(ctx context.Context, id string) (*svc.CronExecutionRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	record, ok := m.runs[id]
+	if !ok {
+		return nil, svc.ErrCronExecutionNotFound
+	}
+	return &record, nil
+}
+
+func (m *MemoryStore) ListExecutions(ctx context.Context, cronJobID string, limit int) ([]svc.CronExecutionRecord, error) {
+	m.mu.RLock()
+	records := make([]svc.CronExecutionRecord, 0)
+	for _, record := range m.runs {
+		if record.CronJobID == cronJobID {
+			records = append(records, record)
+		}
+	}
+	m.mu.RUnlock()
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].ExecutionTime.Equal(records[j].ExecutionTime) {
+			return records[i].ID > records[j].ID
+		}
+		return records[i].ExecutionTime.After(records[j].ExecutionTime)
+	})
+	if limit > 0 && len(records) > limit {
+		records = records[:limit]
+	}
+	return records, nil
+}
+
+func (m *MemoryStore) PurgeExecutions(ctx context.Context, cutoff time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var purged int64
+	for id, record := range m.runs {
+		if record.Status != svc.CronExecutionRunning && record.ExecutionTime.Before(cutoff) {
+			delete(m.runs, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,395 @@
+// Package runner executes the work of the cron jobs: a command through
+// execsafe, an HTTP call, an MCP tool, an LLM prompt through the gateway or
+// a broker publish. Every run is written to the execution log and announced
+// with the cron.job.executed outbound event.
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
+	"github.com/kubex-ecosystem/gobe/internal/config"
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+	"github.com/kubex-ecosystem/gobe/internal/services/gateway"
+	"github.com/kubex-ecosystem/gobe/internal/services/webhooks/outbound"
+	"github.com/kubex-ecosystem/gobe/internal/sockets/messagery"
+)
+
+// What started a run, recorded in the execution log.
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+	TriggerWebhook  = "webhook"
+)
+
+var (
+	// ErrJobNotFound is returned by RunByID for unknown cron jobs.
+	ErrJobNotFound = errors.New("cron job not found")
+	// ErrInvalidJob is returned for jobs whose kind or fields cannot run.
+	ErrInvalidJob = errors.New("invalid cron job")
+	// ErrUnavailable is returned when the kind of a job needs something the
+	// runner lacks: allowed commands, an MCP registry, the gateway or a
+	// broker.
+	ErrUnavailable = errors.New("cron job kind unavailable")
+	// ErrCancelled is the error of the runs stopped by Cancel.
+	ErrCancelled = errors.New("cron job run cancelled")
+)
+
+// JobStore reads and saves cron jobs; svc.CronRepo fits.
+type JobStore interface {
+	FindByID(ctx context.Context, id uuid.UUID) (*svc.CronModel, error)
+	FindAll(ctx context.Context) ([]*svc.CronModel, error)
+	Update(ctx context.Context, job *svc.CronModel) (*svc.CronModel, error)
+}
+
+// ToolRunner runs an MCP tool; mcp.Registry.Exec fits.
+type ToolRunner func(ctx context.Context, tool string, args map[string]interface{}) (interface{}, error)
+
+// Chatter streams a completion; the gateway registry.Service fits.
+type Chatter interface {
+	Chat(ctx context.Context, req gateway.ChatRequest) (<-chan gateway.ChatChunk, gateway.ProviderConfig, error)
+}
+
+// Options tunes a Runner.
+type Options struct {
+	// AllowedCommands lists the binaries command jobs may run; command
+	// jobs are refused when it is empty.
+	AllowedCommands []string
+	// MaxOutputBytes caps the output kept for each run.
+	MaxOutputBytes int
+	// Timeout bounds the runs of the jobs without an ExecTimeout.
+	Timeout time.Duration
+	// Retention is how long finished runs stay in the execution log; zero
+	// keeps them.
+	Retention time.Duration
+}
+
+// DefaultOptions keeps 64KB of output per run for 30 days.
+var DefaultOptions = Options{
+	MaxOutputBytes: 64 << 10,
+	Timeout:        30 * time.Second,
+	Retention:      30 * 24 * time.Hour,
+}
+
+// OptionsFromConfig reads the "cron" config section: AllowedCommands is
+// taken as is, MaxOutputKB sets MaxOutputBytes, and RetentionDays sets
+// Retention (a negative value keeps runs forever). Timeout keeps
+// DefaultOptions; Principal is read by the router.
+func OptionsFromConfig(cfg config.CronConfig) Options {
+	opts := DefaultOptions
+	opts.AllowedCommands = cfg.AllowedCommands
+	if cfg.MaxOutputKB > 0 {
+		opts.MaxOutputBytes = cfg.MaxOutputKB << 10
+	}
+	switch {
+	case cfg.RetentionDays < 0:
+		opts.Retention = 0
+	case cfg.RetentionDays > 0:
+		opts.Retention = time.Duration(cfg.RetentionDays) * 24 * time.Hour
+	}
+	return opts
+}
+
+// purgeInterval spaces the purges of the execution log.
+const purgeInterval = time.Hour
+
+// Runner runs cron jobs and logs their runs. The MCP registry, gateway and
+// broker are optional; the jobs needing a missing one fail with
+// ErrUnavailable.
+type Runner struct {
+	jobs    JobStore
+	store   svc.CronExecutionStore
+	options Options
+	client  *http.Client
+
+	mu        sync.Mutex
+	tools     ToolRunner
+	chat      Chatter
+	broker    messagery.Broker
+	events    *outbound.Dispatcher
+	running   map[string]*activeRun
+	lastPurge time.Time
+}
+
+type activeRun struct {
+	jobID     uuid.UUID
+	cancel    context.CancelFunc
+	cancelled bool
+}
+
+// New returns a runner loading the jobs from jobs and logging their runs in
+// store.
+func New(jobs JobStore, store svc.CronExecutionStore, opts Options) *Runner {
+	if opts.MaxOutputBytes <= 0 {
+		opts.MaxOutputBytes = DefaultOptions.MaxOutputBytes
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultOptions.Timeout
+	}
+	return &Runner{
+		jobs:      jobs,
+		store:     store,
+		options:   opts,
+		client:    &http.Client{},
+		running:   make(map[string]*activeRun),
+		lastPurge: time.Now(),
+	}
+}
+
+// SetToolRunner lets mcp_tool jobs run through run.
+func (r *Runner) SetToolRunner(run ToolRunner) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tools = run
+}
+
+// SetChatter lets llm jobs prompt through chat.
+func (r *Runner) SetChatter(chat Chatter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.chat = chat
+}
+
+// SetBroker lets amqp jobs publish through b.
+func (r *Runner) SetBroker(b messagery.Broker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.broker = b
+}
+
+// SetDispatcher notifies the outbound webhook subscribers of every run
+// through d.
+func (r *Runner) SetDispatcher(d *outbound.Dispatcher) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = d
+}
+
+// RunByID loads the cron job id and runs it once; see Run.
+func (r *Runner) RunByID(ctx context.Context, id uuid.UUID, trigger string) (*svc.CronExecutionRecord, error) {
+	job, err := r.jobs.FindByID(ctx, id)
+	if err != nil || job == nil {
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, id)
+	}
+	return r.Run(ctx, job, trigger, 0)
+}
+
+// Execute runs attempt (0 for the first) of the cron job id as a scheduled
+// run. With Cancel and Running, it lets the scheduler jobs drive the
+// runner.
+func (r *Runner) Execute(ctx context.Context, id uuid.UUID, attempt int) error {
+	job, err := r.jobs.FindByID(ctx, id)
+	if err != nil || job == nil {
+		return fmt.Errorf("%w: %s", ErrJobNotFound, id)
+	}
+	_, err = r.Run(ctx, job, TriggerSchedule, attempt)
+	return err
+}
+
+// Run runs job once, within its ExecTimeout, and returns the record written
+// to the execution log. The error is the failure of the run, also held by
+// the record. The job's last run status, message and time are updated.
+func (r *Runner) Run(ctx context.Context, job *svc.CronModel, trigger string, attempt int) (*svc.CronExecutionRecord, error) {
+	record := &svc.CronExecutionRecord{
+		ID:            uuid.NewString(),
+		CronJobID:     job.ID.String(),
+		Kind:          kindOf(job),
+		Trigger:       trigger,
+		Status:        svc.CronExecutionRunning,
+		RetryCount:    attempt,
+		ExecutionTime: time.Now().UTC(),
+	}
+	if job.UserID != uuid.Nil {
+		record.UserID = job.UserID.String()
+	}
+	// the log outlives a caller that goes away mid-run
+	logCtx := context.WithoutCancel(ctx)
+	if err := r.store.SaveExecution(logCtx, record); err != nil {
+		gl.Log("error", "Failed to log cron job run", job.ID.String(), err)
+	}
+
+	timeout := r.timeout(job)
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	active := r.track(record.ID, job.ID, cancel)
+	res := r.execute(runCtx, job, record.Kind)
+	cancelled := r.untrack(record.ID, active)
+	cancel()
+
+	switch {
+	case cancelled:
+		res.err, res.exitCode = ErrCancelled, -1
+	case res.err != nil && errors.Is(runCtx.Err(), context.DeadlineExceeded):
+		res.err, res.exitCode = fmt.Errorf("timed out after %s", timeout), -1
+	}
+	r.finish(record, res)
+	if err := r.store.UpdateExecution(logCtx, record); err != nil {
+		gl.Log("error", "Failed to log cron job run", job.ID.String(), err)
+	}
+	r.updateJob(logCtx, job.ID, record)
+	r.emitExecuted(record)
+	r.maybePurge()
+	return record, res.err
+}
+
+// Cancel stops the runs of the cron job id in progress and returns how many
+// there were.
+func (r *Runner) Cancel(id uuid.UUID) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, active := range r.running {
+		if active.jobID == id && !active.cancelled {
+			active.cancelled = true
+			active.cancel()
+			n++
+		}
+	}
+	return n
+}
+
+// Running reports whether a run of the cron job id is in progress.
+func (r *Runner) Running(id uuid.UUID) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, active := range r.running {
+		if active.jobID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Executions returns the last limit runs of the cron job id, newest first.
+func (r *Runner) Executions(ctx context.Context, id uuid.UUID, limit int) ([]svc.CronExecutionRecord, error) {
+	return r.store.ListExecutions(ctx, id.String(), limit)
+}
+
+func (r *Runner) timeout(job *svc.CronModel) time.Duration {
+	if job.ExecTimeout > 0 {
+		return time.Duration(job.ExecTimeout) * time.Second
+	}
+	return r.options.Timeout
+}
+
+func (r *Runner) track(id string, jobID uuid.UUID, cancel context.CancelFunc) *activeRun {
+	active := &activeRun{jobID: jobID, cancel: cancel}
+	r.mu.Lock()
+	r.running[id] = active
+	r.mu.Unlock()
+	return active
+}
+
+// untrack forgets a finished run and reports whether it was cancelled.
+func (r *Runner) untrack(id string, active *activeRun) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.running, id)
+	return active.cancelled
+}
+
+// finish fills record with the outcome of its run.
+func (r *Runner) finish(record *svc.CronExecutionRecord, res result) {
+	finished := time.Now().UTC()
+	record.FinishedAt = &finished
+	record.DurationMs = finished.Sub(record.ExecutionTime).Milliseconds()
+	record.Output, record.Truncated = truncate(res.output, r.options.MaxOutputBytes)
+	record.Truncated = record.Truncated || res.truncated
+	record.ExitCode = res.exitCode
+	record.StatusCode = res.statusCode
+	record.Status = svc.CronExecutionSuccess
+	if res.err != nil {
+		record.Status = svc.CronExecutionFailure
+		record.ErrorMessage = res.err.Error()
+		if record.ExitCode == 0 {
+			record.ExitCode = 1
+		}
+	}
+}
+
+// updateJob records the outcome of a run on its job. The job is read again
+// so that the edits made during the run are kept.
+func (r *Runner) updateJob(ctx context.Context, id uuid.UUID, record *svc.CronExecutionRecord) {
+	job, err := r.jobs.FindByID(ctx, id)
+	if err != nil || job == nil {
+		gl.Log("warn", "Cron job disappeared during its run", id.String())
+		return
+	}
+	job.LastRunStatus = record.Status
+	job.LastRunMessage = record.ErrorMessage
+	if job.LastRunMessage == "" {
+		job.LastRunMessage = fmt.Sprintf("%s run completed in %dms", record.Kind, record.DurationMs)
+	}
+	job.LastRunTime = record.FinishedAt
+	job.LastExecutedAt = record.FinishedAt
+	if _, err := r.jobs.Update(ctx, job); err != nil {
+		gl.Log("error", "Failed to update cron job after its run", id.String(), err)
+	}
+}
+
+// maybePurge drops the expired runs of the execution log, at most once per
+// purgeInterval.
+func (r *Runner) maybePurge() {
+	if r.options.Retention <= 0 {
+		return
+	}
+	r.mu.Lock()
+	if time.Since(r.lastPurge) < purgeInterval {
+		r.mu.Unlock()
+		return
+	}
+	r.lastPurge = time.Now()
+	r.mu.Unlock()
+
+	go func() {
+		cutoff := time.Now().UTC().Add(-r.options.Retention)
+		purged, err := r.store.PurgeExecutions(context.Background(), cutoff)
+		if err != nil {
+			gl.Log("error", "Failed to purge cron executions", err)
+			return
+		}
+		if purged > 0 {
+			gl.Log("info", fmt.Sprintf("Purged %d cron executions", purged))
+		}
+	}()
+}
+
+// emitExecuted notifies the outbound webhook subscribers of a run.
+func (r *Runner) emitExecuted(record *svc.CronExecutionRecord) {
+	data := map[string]interface{}{
+		"cron_job_id":  record.CronJobID,
+		"execution_id": record.ID,
+		"kind":         record.Kind,
+		"trigger":      record.Trigger,
+		"status":       "succeeded",
+		"exit_code":    record.ExitCode,
+		"duration_ms":  record.DurationMs,
+		"executed_at":  record.ExecutionTime,
+	}
+	if record.Status != svc.CronExecutionSuccess {
+		data["status"] = "failed"
+		data["error"] = record.ErrorMessage
+	}
+	r.mu.Lock()
+	events := r.events
+	r.mu.Unlock()
+	events.Emit(outbound.EventCronJobExecuted, data)
+}
+
+// truncate cuts s to max bytes on a rune boundary.
+func truncate(s string, max int) (string, bool) {
+	if max <= 0 || len(s) <= max {
+		return s, false
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut], true
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
+	"github.com/kubex-ecosystem/gobe/internal/services/scheduler/cron"
+)
+
+// Overlap policies of a cron job, named by Metadata["overlap"]: what a run
+// due while the previous one is still going does.
+const (
+	// OverlapSkip drops the run (the default).
+	OverlapSkip = "skip"
+	// OverlapDelay starts the run once the previous one is over.
+	OverlapDelay = "delay"
+	// OverlapAllow starts the run at once, next to the previous one.
+	OverlapAllow = "allow"
+)
+
+// ParseSchedule returns the schedule of a cron job. Interval jobs take a
+// duration ("90s", "1h"); the others a standard crontab expression or a
+// descriptor ("@hourly", "@every 5m").
+func ParseSchedule(model *svc.CronModel) (cron.Schedule, error) {
+	expression := strings.TrimSpace(model.CronExpression)
+	if model.CronType == "interval" {
+		if every, err := time.ParseDuration(expression); err == nil {
+			if every <= 0 {
+				return nil, fmt.Errorf("interval must be positive: %s", expression)
+			}
+			return cron.Every(every), nil
+		}
+	}
+	return cron.ParseStandard(expression)
+}
+
+// LocationOf returns the time zone of Metadata["timezone"] ("Europe/Lisbon",
+// "UTC"), in which the schedule of a cron job is read, or fallback.
+func LocationOf(model *svc.CronModel, fallback *time.Location) (*time.Location, error) {
+	name, _ := model.Metadata["timezone"].(string)
+	if name = strings.TrimSpace(name); name == "" {
+		return fallback, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q", name)
+	}
+	return loc, nil
+}
+
+// OverlapOf returns the overlap policy of Metadata["overlap"], OverlapSkip
+// by default.
+func OverlapOf(model *svc.CronModel) (string, error) {
+	policy, _ := model.Metadata["overlap"].(string)
+	switch policy = strings.ToLower(strings.TrimSpace(policy)); policy {
+	case "":
+		return OverlapSkip, nil
+	case OverlapSkip, OverlapDelay, OverlapAllow:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown overlap policy %q (skip, delay or allow)", policy)
+}
+
+// ValidateSchedule reports why the schedule, time zone or overlap policy of
+// a cron job is invalid.
+func ValidateSchedule(model *svc.CronModel) error {
+	if _, err := ParseSchedule(model); err != nil {
+		return fmt.Errorf("invalid schedule: %w", err)
+	}
+	if _, err := LocationOf(model, time.Local); err != nil {
+		return err
+	}
+	_, err := OverlapOf(model)
+	return err
+}
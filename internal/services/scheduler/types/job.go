@@ -2,7 +2,11 @@
 package types
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -10,6 +14,19 @@ import (
 	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
 )
 
+// ErrNoExecutor is returned by the jobs created without an Executor.
+var ErrNoExecutor = errors.New("job has no executor")
+
+// Executor runs the attempts of cron jobs; runner.Runner fits.
+type Executor interface {
+	// Execute runs attempt (0 for the first) of the cron job id.
+	Execute(ctx context.Context, id uuid.UUID, attempt int) error
+	// Cancel stops the runs of the cron job id in progress.
+	Cancel(id uuid.UUID) int
+	// Running reports whether a run of the cron job id is in progress.
+	Running(id uuid.UUID) bool
+}
+
 type IJob interface {
 	Mu() *t.Mutexes
 	Ref() *t.Reference
@@ -28,8 +45,24 @@ type Job struct {
 	Schedule string
 	Command  string
 
-	userID uuid.UUID
-	Status JobStatus // Adicionado para rastrear o status do job
+	// CronJobID is the persisted cron job the executor runs.
+	CronJobID uuid.UUID
+	// MaxRetries failed attempts are retried, RetryInterval apart.
+	MaxRetries    int
+	RetryInterval time.Duration
+
+	userID   uuid.UUID
+	Status   JobStatus // Adicionado para rastrear o status do job
+	executor Executor
+	state    *jobState
+}
+
+// jobState is shared by the copies of a job.
+type jobState struct {
+	mu       sync.Mutex
+	attempts int
+	ctx      context.Context
+	cancel   context.CancelFunc
 }
 
 func NewJob(id int, name, schedule, command string) IJob {
@@ -41,6 +74,22 @@ func NewJob(id int, name, schedule, command string) IJob {
 	}
 }
 
+// NewCronJob returns a job running the persisted cron job cronJobID
+// through executor.
+func NewCronJob(cronJobID, userID uuid.UUID, name, schedule string, maxRetries int, retryInterval time.Duration, executor Executor) IJob {
+	return &Job{
+		Name:          name,
+		Schedule:      schedule,
+		CronJobID:     cronJobID,
+		MaxRetries:    maxRetries,
+		RetryInterval: retryInterval,
+		userID:        userID,
+		Status:        JobStatusPending,
+		executor:      executor,
+		state:         &jobState{},
+	}
+}
+
 func (j *Job) Mu() *t.Mutexes {
 	return j.Mutexes
 }
@@ -50,18 +99,86 @@ func (j *Job) Ref() *t.Reference {
 func (j *Job) GetUserID() uuid.UUID {
 	return j.userID
 }
+
+// Run runs the job, retrying a failed run up to MaxRetries times unless the
+// job is cancelled.
 func (j *Job) Run() error {
-	gl.Log("info", fmt.Sprintf("Running job: %s (ID: %d)", j.Name, j.ID))
-	// Implement the logic to execute the command.
-	return nil
+	gl.Log("info", fmt.Sprintf("Running job: %s (ID: %s)", j.Name, j.CronJobID))
+	if j.executor == nil {
+		return ErrNoExecutor
+	}
+	ctx := j.start()
+	err := j.attempt(ctx, 0)
+	for err != nil && ctx.Err() == nil {
+		attempts := j.attempts()
+		if attempts > j.MaxRetries {
+			break
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(j.RetryInterval):
+			gl.Log("info", fmt.Sprintf("Retrying job: %s (ID: %s)", j.Name, j.CronJobID))
+			err = j.attempt(ctx, attempts)
+		}
+	}
+	return err
 }
-func (j *Job) Retry() error {
-	gl.Log("info", fmt.Sprintf("Retrying job: %s (ID: %d)", j.Name, j.ID))
 
-	return nil
+// Retry runs one more attempt of the job now.
+func (j *Job) Retry() error {
+	gl.Log("info", fmt.Sprintf("Retrying job: %s (ID: %s)", j.Name, j.CronJobID))
+	if j.executor == nil {
+		return ErrNoExecutor
+	}
+	return j.attempt(j.start(), j.attempts())
 }
+
+// Cancel stops the run in progress and the retries still to come.
 func (j *Job) Cancel() error {
-	gl.Log("info", fmt.Sprintf("Cancelling job: %s (ID: %d)", j.Name, j.ID))
-	// Implement cancel logic.
+	gl.Log("info", fmt.Sprintf("Cancelling job: %s (ID: %s)", j.Name, j.CronJobID))
+	if j.executor == nil {
+		return ErrNoExecutor
+	}
+	j.state.mu.Lock()
+	if j.state.cancel != nil {
+		j.state.cancel()
+	}
+	j.state.mu.Unlock()
+	j.executor.Cancel(j.CronJobID)
 	return nil
 }
+
+// start returns the context of the job's attempts, renewed once the job was
+// cancelled.
+func (j *Job) start() context.Context {
+	j.state.mu.Lock()
+	defer j.state.mu.Unlock()
+	if j.state.ctx == nil || j.state.ctx.Err() != nil {
+		j.state.ctx, j.state.cancel = context.WithCancel(context.Background())
+	}
+	return j.state.ctx
+}
+
+// attempts returns how many attempts the job made.
+func (j *Job) attempts() int {
+	j.state.mu.Lock()
+	defer j.state.mu.Unlock()
+	return j.state.attempts
+}
+
+func (j *Job) attempt(ctx context.Context, n int) error {
+	j.state.mu.Lock()
+	j.state.attempts = n + 1
+	j.Status = JobStatusRunning
+	j.state.mu.Unlock()
+
+	err := j.executor.Execute(ctx, j.CronJobID, n)
+
+	j.state.mu.Lock()
+	j.Status = JobStatusCompleted
+	if err != nil {
+		j.Status = JobStatusFailed
+	}
+	j.state.mu.Unlock()
+	return err
+}
@@ -0,0 +1,153 @@
+package webhooks
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPath is a compiled JSONPath expression. The supported subset covers
+// what rule mappings need: "$", ".name", "['name']", "[n]" (negative counts
+// from the end) and the "*" / "[*]" wildcards, which make the result a list.
+type jsonPath struct {
+	expr  string
+	steps []pathStep
+	multi bool
+}
+
+type pathStep struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+func compileJSONPath(expr string) (*jsonPath, error) {
+	p := &jsonPath{expr: expr}
+	if !strings.HasPrefix(expr, "$") {
+		return nil, fmt.Errorf("jsonpath %q must start with $", expr)
+	}
+
+	rest := expr[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			name := rest[:end]
+			rest = rest[end:]
+			switch name {
+			case "":
+				return nil, fmt.Errorf("jsonpath %q: empty name", expr)
+			case "*":
+				p.steps = append(p.steps, pathStep{wildcard: true})
+				p.multi = true
+			default:
+				p.steps = append(p.steps, pathStep{key: name})
+			}
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("jsonpath %q: unclosed [", expr)
+			}
+			inner := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+			step, err := bracketStep(inner)
+			if err != nil {
+				return nil, fmt.Errorf("jsonpath %q: %w", expr, err)
+			}
+			if step.wildcard {
+				p.multi = true
+			}
+			p.steps = append(p.steps, step)
+		default:
+			return nil, fmt.Errorf("jsonpath %q: unexpected %q", expr, rest[0])
+		}
+	}
+	return p, nil
+}
+
+func bracketStep(inner string) (pathStep, error) {
+	if inner == "*" {
+		return pathStep{wildcard: true}, nil
+	}
+	if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+		return pathStep{key: inner[1 : len(inner)-1]}, nil
+	}
+	index, err := strconv.Atoi(inner)
+	if err != nil {
+		return pathStep{}, fmt.Errorf("invalid subscript [%s]", inner)
+	}
+	return pathStep{index: index, isIndex: true}, nil
+}
+
+// eval applies the path to a document decoded by encoding/json. Paths with a
+// wildcard return the list of matches; others report whether the value
+// exists.
+func (p *jsonPath) eval(doc interface{}) (interface{}, bool) {
+	nodes := []interface{}{doc}
+	for _, step := range p.steps {
+		var next []interface{}
+		for _, node := range nodes {
+			next = append(next, step.apply(node)...)
+		}
+		nodes = next
+		if len(nodes) == 0 {
+			break
+		}
+	}
+
+	if p.multi {
+		if nodes == nil {
+			nodes = []interface{}{}
+		}
+		return nodes, true
+	}
+	if len(nodes) == 0 {
+		return nil, false
+	}
+	return nodes[0], true
+}
+
+func (s pathStep) apply(node interface{}) []interface{} {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		if s.wildcard {
+			keys := make([]string, 0, len(value))
+			for key := range value {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			out := make([]interface{}, 0, len(keys))
+			for _, key := range keys {
+				out = append(out, value[key])
+			}
+			return out
+		}
+		if s.isIndex {
+			return nil
+		}
+		if child, ok := value[s.key]; ok {
+			return []interface{}{child}
+		}
+	case []interface{}:
+		if s.wildcard {
+			return append([]interface{}(nil), value...)
+		}
+		if !s.isIndex {
+			return nil
+		}
+		index := s.index
+		if index < 0 {
+			index += len(value)
+		}
+		if index >= 0 && index < len(value) {
+			return []interface{}{value[index]}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package webhooks
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
+)
+
+// MemoryStore keeps webhook events in memory. It serves tests and gateways
+// running without a database; events are lost on restart.
+type MemoryStore struct {
+	mu     sync.RWMutex
+	events map[string]svc.WebhookEventRecord
+}
+
+// NewMemoryStore returns an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{events: make(map[string]svc.WebhookEventRecord)}
+}
+
+func (m *MemoryStore) SaveEvent(ctx context.Context, record *svc.WebhookEventRecord) error {
+	if record.ID == "" {
+		record.ID = uuid.NewString()
+	}
+	if record.ReceivedAt.IsZero() {
+		record.ReceivedAt = time.Now().UTC()
+	}
+	if record.Status == "" {
+		record.Status = svc.WebhookEventReceived
+	}
+	m.mu.Lock()
+	m.events[record.ID] = *record
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *MemoryStore) UpdateEvent(ctx context.Context, record *svc.WebhookEventRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.events[record.ID]; !ok {
+		return svc.ErrWebhookEventNotFound
+	}
+	m.events[record.ID] = *record
+	return nil
+}
+
+func (m *MemoryStore) GetEvent(ctx context.Context, id string) (*svc.WebhookEventRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	record, ok := m.events[id]
+	if !ok {
+		return nil, svc.ErrWebhookEventNotFound
+	}
+	return &record, nil
+}
+
+func (m *MemoryStore) ListEvents(ctx context.Context, filter svc.WebhookEventFilter) ([]svc.WebhookEventRecord, error) {
+	records := m.matching(filter)
+	sort.Slice(records, func(i, j int) bool { return newer(records[i], records[j]) })
+
+	page := make([]svc.WebhookEventRecord, 0, len(records))
+	for _, record := range records {
+		if !filter.Before.IsZero() && !newer(svc.WebhookEventRecord{ReceivedAt: filter.Before, ID: filter.BeforeID}, record) {
+			continue
+		}
+		page = append(page, record)
+	}
+	if filter.Offset > 0 {
+		page = page[min(filter.Offset, len(page)):]
+	}
+	if filter.Limit > 0 && len(page) > filter.Limit {
+		page = page[:filter.Limit]
+	}
+	return page, nil
+}
+
+func (m *MemoryStore) CountEvents(ctx context.Context, filter svc.WebhookEventFilter) (int64, error) {
+	return int64(len(m.matching(filter))), nil
+}
+
+func (m *MemoryStore) CountByStatus(ctx context.Context) (map[string]int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	counts := make(map[string]int64)
+	for _, record := range m.events {
+		counts[record.Status]++
+	}
+	return counts, nil
+}
+
+func (m *MemoryStore) ClaimEvents(ctx context.Context, limit int, now, lockUntil time.Time) ([]svc.WebhookEventRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pending := make([]svc.WebhookEventRecord, 0)
+	for _, record := range m.events {
+		expired := record.Status == svc.WebhookEventProcessing && record.LockedUntil != nil && record.LockedUntil.Before(now)
+		if record.Status == svc.WebhookEventReceived || expired {
+			pending = append(pending, record)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return newer(pending[j], pending[i]) })
+	if limit > 0 && len(pending) > limit {
+		pending = pending[:limit]
+	}
+	for i := range pending {
+		pending[i].Status = svc.WebhookEventProcessing
+		pending[i].LockedUntil = &lockUntil
+		m.events[pending[i].ID] = pending[i]
+	}
+	return pending, nil
+}
+
+func (m *MemoryStore) RetryFailed(ctx context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var retried int64
+	for id, record := range m.events {
+		if record.Status == svc.WebhookEventFailed {
+			record.Status = svc.WebhookEventReceived
+			record.Error = ""
+			m.events[id] = record
+			retried++
+		}
+	}
+	return retried, nil
+}
+
+func (m *MemoryStore) PurgeEvents(ctx context.Context, cutoff time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var purged int64
+	for id, record := range m.events {
+		finished := record.Status == svc.WebhookEventCompleted || record.Status == svc.WebhookEventFailed ||
+			record.Status == svc.WebhookEventRejected
+		if finished && record.ReceivedAt.Before(cutoff) {
+			delete(m.events, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (m *MemoryStore) matching(filter svc.WebhookEventFilter) []svc.WebhookEventRecord {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	records := make([]svc.WebhookEventRecord, 0)
+	for _, record := range m.events {
+		switch {
+		case filter.Source != "" && record.Source != filter.Source,
+			filter.EventType != "" && record.EventType != filter.EventType,
+			filter.Status != "" && record.Status != filter.Status,
+			!filter.From.IsZero() && record.ReceivedAt.Before(filter.From),
+			!filter.To.IsZero() && !record.ReceivedAt.Before(filter.To):
+			continue
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// newer orders events like the database: newest first, ties broken by id.
+func newer(a, b svc.WebhookEventRecord) bool {
+	if !a.ReceivedAt.Equal(b.ReceivedAt) {
+		return a.ReceivedAt.After(b.ReceivedAt)
+	}
+	return a.ID > b.ID
+}
+
+// MemoryRuleStore keeps webhook rules in memory. It is the rule store of
+// services running without a database.
+type MemoryRuleStore struct {
+	mu    sync.RWMutex
+	rules map[string]svc.WebhookRuleRecord
+}
+
+// NewMemoryRuleStore returns an empty in-memory rule store.
+func NewMemoryRuleStore() *MemoryRuleStore {
+	return &MemoryRuleStore{rules: make(map[string]svc.WebhookRuleRecord)}
+}
+
+func (m *MemoryRuleStore) SaveRule(ctx context.Context, record *svc.WebhookRuleRecord) error {
+	if record.ID == "" {
+		record.ID = uuid.NewString()
+	}
+	m.mu.Lock()
+	m.rules[record.ID] = *record
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *MemoryRuleStore) GetRule(ctx context.Context, id string) (*svc.WebhookRuleRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	record, ok := m.rules[id]
+	if !ok {
+		return nil, svc.ErrWebhookRuleNotFound
+	}
+	return &record, nil
+}
+
+func (m *MemoryRuleStore) ListRules(ctx context.Context, enabledOnly bool) ([]svc.WebhookRuleRecord, error) {
+	m.mu.RLock()
+	records := make([]svc.WebhookRuleRecord, 0, len(m.rules))
+	for _, record := range m.rules {
+		if enabledOnly && !record.Enabled {
+			continue
+		}
+		records = append(records, record)
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	})
+	return records, nil
+}
+
+func (m *MemoryRuleStore) DeleteRule(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.rules[id]; !ok {
+		return svc.ErrWebhookRuleNotFound
+	}
+	delete(m.rules, id)
+	return nil
+}
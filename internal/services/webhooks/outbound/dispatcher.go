@@ -0,0 +1,702 @@
+// Package outbound delivers gobe events to the URLs subscribed to them, with
+// signed requests, retries with exponential backoff and a dead-letter state.
+package outbound
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
+	"github.com/kubex-ecosystem/gobe/internal/config"
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+	"github.com/kubex-ecosystem/gobe/internal/utils/backoff"
+)
+
+// Event types emitted by gobe.
+const (
+	EventWebhookReceived      = "webhook.received"
+	EventCronJobExecuted      = "cron.job.executed"
+	EventApprovalDecided      = "approval.decided"
+	EventAnalyzerNotification = "analyzer.notification"
+)
+
+// Headers of a delivery. The signature is "sha256=" followed by the hex
+// HMAC-SHA256 of "<timestamp>.<body>" with the subscription secret, or with
+// Options.Secret for the deliveries of Send.
+const (
+	HeaderEvent     = "X-Gobe-Event"
+	HeaderEventID   = "X-Gobe-Event-Id"
+	HeaderDelivery  = "X-Gobe-Delivery"
+	HeaderTimestamp = "X-Gobe-Timestamp"
+	HeaderSignature = "X-Gobe-Signature"
+)
+
+// Store persists subscriptions and deliveries. svc.WebhookSubscriptionStore
+// keeps them in the database; MemoryStore keeps them in memory.
+type Store = svc.WebhookSubscriptionStore
+
+// ErrInvalidSubscription is returned for subscriptions without a valid
+// http(s) URL or without event types.
+var ErrInvalidSubscription = errors.New("invalid webhook subscription")
+
+// ErrNoSecret is returned by Send when no secret is set to sign one-off
+// deliveries.
+var ErrNoSecret = errors.New("outbound webhook secret not set")
+
+// Options tunes the delivery worker.
+type Options struct {
+	MaxAttempts  int
+	Backoff      time.Duration
+	MaxBackoff   time.Duration
+	Timeout      time.Duration
+	PollInterval time.Duration
+	BatchSize    int
+	// Lease is how long a worker owns the deliveries it claimed.
+	Lease time.Duration
+	// Secret signs the deliveries queued by Send, which have no subscription
+	// secret.
+	Secret string
+	// AllowPrivateTargets lets deliveries reach loopback, link-local and
+	// private addresses, for subscribers on the same host or network.
+	AllowPrivateTargets bool
+	// Key encrypts the subscription secrets in the store (KeySize bytes).
+	// Without it a random key is used, which a persistent store cannot
+	// outlive: the secrets it sealed are lost on restart.
+	Key []byte
+}
+
+// DefaultOptions gives up on a delivery after 8 attempts spread over about
+// two hours.
+var DefaultOptions = Options{
+	MaxAttempts:  8,
+	Backoff:      30 * time.Second,
+	MaxBackoff:   time.Hour,
+	Timeout:      10 * time.Second,
+	PollInterval: 5 * time.Second,
+	BatchSize:    20,
+	Lease:        time.Minute,
+}
+
+// OptionsFromConfig reads the "webhooks.outbound" config section:
+// MaxAttempts, BackoffSeconds, MaxBackoffSeconds and TimeoutSeconds replace
+// the DefaultOptions they name when positive, and AllowPrivateTargets is
+// copied. The secret and the key are not read from config.
+func OptionsFromConfig(cfg config.WebhookOutboundConfig) Options {
+	opts := DefaultOptions
+	if cfg.MaxAttempts > 0 {
+		opts.MaxAttempts = cfg.MaxAttempts
+	}
+	if cfg.BackoffSeconds > 0 {
+		opts.Backoff = time.Duration(cfg.BackoffSeconds) * time.Second
+	}
+	if cfg.MaxBackoffSeconds > 0 {
+		opts.MaxBackoff = time.Duration(cfg.MaxBackoffSeconds) * time.Second
+	}
+	if cfg.TimeoutSeconds > 0 {
+		opts.Timeout = time.Duration(cfg.TimeoutSeconds) * time.Second
+	}
+	opts.AllowPrivateTargets = cfg.AllowPrivateTargets
+	return opts
+}
+
+// Delay returns the pause after the given failed attempt (1 for the first),
+// doubling from Backoff up to MaxBackoff.
+func (o Options) Delay(attempt int) time.Duration {
+	return backoff.Delay(attempt, o.Backoff, o.MaxBackoff)
+}
+
+// Event is the body of every delivery.
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// Subscription registers a URL for the events matching EventTypes: exact
+// types, "prefix.*" or "*". Secret signs the deliveries; it is only returned
+// when the subscription is created.
+type Subscription struct {
+	ID          string    `json:"id"`
+	URL         string    `json:"url"`
+	EventTypes  []string  `json:"event_types"`
+	Secret      string    `json:"secret,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// SubscriptionUpdate changes the non-nil fields of a subscription.
+type SubscriptionUpdate struct {
+	URL         *string  `json:"url,omitempty"`
+	EventTypes  []string `json:"event_types,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	Active      *bool    `json:"active,omitempty"`
+}
+
+// Delivery is an event sent to one URL, as listed in the delivery log.
+type Delivery struct {
+	ID             string          `json:"id"`
+	SubscriptionID string          `json:"subscription_id,omitempty"`
+	URL            string          `json:"url"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	ResponseStatus int             `json:"response_status,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+}
+
+// DeliveryQuery selects a page of the delivery log, newest first.
+type DeliveryQuery struct {
+	SubscriptionID string
+	Status         string
+	EventType      string
+	Offset         int
+	Limit          int
+}
+
+// Dispatcher queues events for the matching subscriptions and delivers them
+// in the background.
+type Dispatcher struct {
+	store  Store
+	opts   Options
+	client *http.Client
+	wake   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	now    func() time.Time
+}
+
+// NewDispatcher creates a dispatcher persisting in store (in memory when
+// store is nil) and starts its delivery worker. It fails when opts.Key is
+// set with the wrong length.
+func NewDispatcher(store Store, opts Options) (*Dispatcher, error) {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	switch len(opts.Key) {
+	case 0:
+		opts.Key = make([]byte, KeySize)
+		if _, err := rand.Read(opts.Key); err != nil {
+			return nil, err
+		}
+	case KeySize:
+	default:
+		return nil, fmt.Errorf("outbound webhook key must be %d bytes, got %d", KeySize, len(opts.Key))
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultOptions.MaxAttempts
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = DefaultOptions.Backoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = DefaultOptions.MaxBackoff
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultOptions.Timeout
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultOptions.PollInterval
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultOptions.BatchSize
+	}
+	if opts.Lease <= 0 {
+		opts.Lease = DefaultOptions.Lease
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Dispatcher{
+		store:  store,
+		opts:   opts,
+		client: newClient(opts.Timeout, opts.AllowPrivateTargets),
+		wake:   make(chan struct{}, 1),
+		ctx:    ctx,
+		cancel: cancel,
+		now:    time.Now,
+	}
+	go d.worker()
+	return d, nil
+}
+
+// Close stops the delivery worker. Queued deliveries stay in the store.
+func (d *Dispatcher) Close() error {
+	d.cancel()
+	return nil
+}
+
+// Subscribe registers sub. A random secret is generated when sub.Secret is
+// empty; the returned subscription carries it.
+func (d *Dispatcher) Subscribe(ctx context.Context, sub Subscription) (*Subscription, error) {
+	if err := d.validate(sub.URL, sub.EventTypes); err != nil {
+		return nil, err
+	}
+	if sub.Secret == "" {
+		raw := make([]byte, 32)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, err
+		}
+		sub.Secret = hex.EncodeToString(raw)
+	}
+
+	sealed, err := sealSecret(d.opts.Key, sub.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	now := d.now().UTC()
+	record := &svc.WebhookSubscriptionRecord{
+		URL:         sub.URL,
+		EventTypes:  strings.Join(sub.EventTypes, ","),
+		Secret:      sealed,
+		Description: sub.Description,
+		Active:      true,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := d.store.SaveSubscription(ctx, record); err != nil {
+		return nil, err
+	}
+	created := subscriptionFromRecord(*record)
+	created.Secret = sub.Secret
+	return &created, nil
+}
+
+// UpdateSubscription applies update to the subscription id.
+func (d *Dispatcher) UpdateSubscription(ctx context.Context, id string, update SubscriptionUpdate) (*Subscription, error) {
+	record, err := d.store.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if update.URL != nil {
+		record.URL = *update.URL
+	}
+	if update.EventTypes != nil {
+		record.EventTypes = strings.Join(update.EventTypes, ",")
+	}
+	if update.Description != nil {
+		record.Description = *update.Description
+	}
+	if update.Active != nil {
+		record.Active = *update.Active
+	}
+	if err := d.validate(record.URL, splitTypes(record.EventTypes)); err != nil {
+		return nil, err
+	}
+	record.UpdatedAt = d.now().UTC()
+	if err := d.store.SaveSubscription(ctx, record); err != nil {
+		return nil, err
+	}
+	sub := subscriptionFromRecord(*record)
+	return &sub, nil
+}
+
+func (d *Dispatcher) GetSubscription(ctx context.Context, id string) (*Subscription, error) {
+	record, err := d.store.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	sub := subscriptionFromRecord(*record)
+	return &sub, nil
+}
+
+func (d *Dispatcher) ListSubscriptions(ctx context.Context) ([]Subscription, error) {
+	records, err := d.store.ListSubscriptions(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	subs := make([]Subscription, 0, len(records))
+	for _, record := range records {
+		subs = append(subs, subscriptionFromRecord(record))
+	}
+	return subs, nil
+}
+
+// DeleteSubscription removes a subscription. Its queued deliveries are dead
+// on their next attempt.
+func (d *Dispatcher) DeleteSubscription(ctx context.Context, id string) error {
+	return d.store.DeleteSubscription(ctx, id)
+}
+
+// Publish queues event data of eventType for every active subscription
+// matching it and returns how many deliveries were queued.
+func (d *Dispatcher) Publish(ctx context.Context, eventType string, data interface{}) (int, error) {
+	subs, err := d.store.ListSubscriptions(ctx, true)
+	if err != nil {
+		return 0, err
+	}
+	var matching []svc.WebhookSubscriptionRecord
+	for _, sub := range subs {
+		if matches(splitTypes(sub.EventTypes), eventType) {
+			matching = append(matching, sub)
+		}
+	}
+	if len(matching) == 0 {
+		return 0, nil
+	}
+
+	event, payload, err := d.encode(eventType, data)
+	if err != nil {
+		return 0, err
+	}
+	queued := 0
+	for _, sub := range matching {
+		if err := d.queueRecord(ctx, &svc.WebhookDeliveryRecord{}, sub.ID, sub.URL, event, payload); err != nil {
+			return queued, err
+		}
+		queued++
+	}
+	d.kick()
+	return queued, nil
+}
+
+// Emit publishes an event on behalf of another service. It does nothing on
+// a nil dispatcher (no database); failures are logged, not returned, so
+// that emitting never fails the operation that produced the event.
+func (d *Dispatcher) Emit(eventType string, data interface{}) {
+	if d == nil {
+		return
+	}
+	if _, err := d.Publish(context.Background(), eventType, data); err != nil {
+		gl.Log("error", "Failed to queue outbound webhook", "type", eventType, "error", err)
+	}
+}
+
+// Send queues a one-off delivery of event data to target, with the same
+// retries and delivery log as subscriptions. It is signed with
+// Options.Secret and fails with ErrNoSecret when it is not set.
+func (d *Dispatcher) Send(ctx context.Context, target, eventType string, data interface{}) (*Delivery, error) {
+	if d.opts.Secret == "" {
+		return nil, ErrNoSecret
+	}
+	if err := d.validate(target, []string{eventType}); err != nil {
+		return nil, err
+	}
+	event, payload, err := d.encode(eventType, data)
+	if err != nil {
+		return nil, err
+	}
+	record := &svc.WebhookDeliveryRecord{}
+	if err := d.queueRecord(ctx, record, "", target, event, payload); err != nil {
+		return nil, err
+	}
+	d.kick()
+	delivery := deliveryFromRecord(*record)
+	return &delivery, nil
+}
+
+// Redeliver queues a new delivery of the event of delivery id, to the same
+// subscription or URL, with a fresh attempt budget.
+func (d *Dispatcher) Redeliver(ctx context.Context, id string) (*Delivery, error) {
+	original, err := d.store.GetDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	record := &svc.WebhookDeliveryRecord{
+		SubscriptionID: original.SubscriptionID,
+		URL:            original.URL,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		Status:         svc.WebhookDeliveryPending,
+		NextAttemptAt:  d.now().UTC(),
+		CreatedAt:      d.now().UTC(),
+	}
+	if err := d.store.SaveDelivery(ctx, record); err != nil {
+		return nil, err
+	}
+	d.kick()
+	delivery := deliveryFromRecord(*record)
+	return &delivery, nil
+}
+
+// GetDelivery returns delivery id from the delivery log.
+func (d *Dispatcher) GetDelivery(ctx context.Context, id string) (*Delivery, error) {
+	record, err := d.store.GetDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	delivery := deliveryFromRecord(*record)
+	return &delivery, nil
+}
+
+// ListDeliveries returns a page of the delivery log and the number of
+// deliveries matching query.
+func (d *Dispatcher) ListDeliveries(ctx context.Context, query DeliveryQuery) ([]Delivery, int64, error) {
+	records, total, err := d.store.ListDeliveries(ctx, svc.WebhookDeliveryFilter{
+		SubscriptionID: query.SubscriptionID,
+		Status:         query.Status,
+		EventType:      query.EventType,
+		Offset:         query.Offset,
+		Limit:          query.Limit,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	deliveries := make([]Delivery, 0, len(records))
+	for _, record := range records {
+		deliveries = append(deliveries, deliveryFromRecord(record))
+	}
+	return deliveries, total, nil
+}
+
+// DeliverPending sends a batch of due deliveries concurrently, so that a
+// slow subscriber does not hold back the others, and returns how many
+// succeeded. Deliveries left sending by a crashed worker are sent again once
+// their lease expires.
+func (d *Dispatcher) DeliverPending(ctx context.Context) (int, error) {
+	now := d.now().UTC()
+	records, err := d.store.ClaimDeliveries(ctx, d.opts.BatchSize, now, now.Add(d.opts.Lease))
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		delivered int
+		errs      []error
+	)
+	for i := range records {
+		record := &records[i]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.attempt(ctx, record)
+			err := d.store.SaveDelivery(ctx, record)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+			} else if record.Status == svc.WebhookDeliverySucceeded {
+				delivered++
+			}
+		}()
+	}
+	wg.Wait()
+	return delivered, errors.Join(errs...)
+}
+
+func (d *Dispatcher) worker() {
+	ticker := time.NewTicker(d.opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+		if _, err := d.DeliverPending(d.ctx); err != nil && d.ctx.Err() == nil {
+			gl.Log("error", "Failed to deliver outbound webhooks", err)
+		}
+	}
+}
+
+// kick wakes the worker up without waiting for the next poll.
+func (d *Dispatcher) kick() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// attempt sends record once and updates its status, attempts and schedule.
+func (d *Dispatcher) attempt(ctx context.Context, record *svc.WebhookDeliveryRecord) {
+	record.Attempts++
+	record.LockedUntil = nil
+
+	secret := d.opts.Secret
+	if record.SubscriptionID != "" {
+		sub, err := d.store.GetSubscription(ctx, record.SubscriptionID)
+		if err != nil {
+			record.Status = svc.WebhookDeliveryDead
+			record.LastError = err.Error()
+			return
+		}
+		if secret, err = openSecret(d.opts.Key, sub.Secret); err != nil {
+			record.Status = svc.WebhookDeliveryDead
+			record.LastError = err.Error()
+			return
+		}
+	}
+	if secret == "" {
+		record.Status = svc.WebhookDeliveryDead
+		record.LastError = ErrNoSecret.Error()
+		return
+	}
+
+	status, err := d.post(ctx, record, secret)
+	record.ResponseStatus = status
+	if err == nil {
+		delivered := d.now().UTC()
+		record.Status = svc.WebhookDeliverySucceeded
+		record.LastError = ""
+		record.DeliveredAt = &delivered
+		return
+	}
+
+	record.LastError = err.Error()
+	if record.Attempts >= d.opts.MaxAttempts {
+		record.Status = svc.WebhookDeliveryDead
+		gl.Log("warn", "Outbound webhook moved to dead letter", "delivery", record.ID, "url", record.URL, "error", err)
+		return
+	}
+	record.Status = svc.WebhookDeliveryPending
+	record.NextAttemptAt = d.now().UTC().Add(d.opts.Delay(record.Attempts))
+}
+
+func (d *Dispatcher) post(ctx context.Context, record *svc.WebhookDeliveryRecord, secret string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.opts.Timeout)
+	defer cancel()
+
+	body := []byte(record.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, record.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(d.now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gobe-webhooks")
+	req.Header.Set(HeaderEvent, record.EventType)
+	req.Header.Set(HeaderEventID, record.EventID)
+	req.Header.Set(HeaderDelivery, record.ID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the X-Gobe-Signature value of body sent at timestamp.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (d *Dispatcher) encode(eventType string, data interface{}) (Event, []byte, error) {
+	event := Event{ID: uuid.NewString(), Type: eventType, CreatedAt: d.now().UTC(), Data: data}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return event, nil, fmt.Errorf("encode %s event: %w", eventType, err)
+	}
+	return event, payload, nil
+}
+
+func (d *Dispatcher) queueRecord(ctx context.Context, record *svc.WebhookDeliveryRecord, subscriptionID, target string, event Event, payload []byte) error {
+	*record = svc.WebhookDeliveryRecord{
+		SubscriptionID: subscriptionID,
+		URL:            target,
+		EventID:        event.ID,
+		EventType:      event.Type,
+		Payload:        string(payload),
+		Status:         svc.WebhookDeliveryPending,
+		NextAttemptAt:  event.CreatedAt,
+		CreatedAt:      event.CreatedAt,
+	}
+	return d.store.SaveDelivery(ctx, record)
+}
+
+func (d *Dispatcher) validate(target string, eventTypes []string) error {
+	parsed, err := url.Parse(target)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http(s) URL", ErrInvalidSubscription)
+	}
+	if !d.opts.AllowPrivateTargets {
+		if err := checkHost(parsed.Hostname()); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidSubscription, err)
+		}
+	}
+	if len(eventTypes) == 0 {
+		return fmt.Errorf("%w: at least one event type is required", ErrInvalidSubscription)
+	}
+	for _, eventType := range eventTypes {
+		if strings.TrimSpace(eventType) == "" || strings.Contains(eventType, ",") {
+			return fmt.Errorf("%w: invalid event type %q", ErrInvalidSubscription, eventType)
+		}
+	}
+	return nil
+}
+
+func splitTypes(eventTypes string) []string {
+	if eventTypes == "" {
+		return nil
+	}
+	return strings.Split(eventTypes, ",")
+}
+
+// matches reports whether eventType is selected by one of the patterns.
+func matches(patterns []string, eventType string) bool {
+	for _, pattern := range patterns {
+		switch {
+		case pattern == "*" || pattern == eventType:
+			return true
+		case strings.HasSuffix(pattern, ".*") && strings.HasPrefix(eventType, strings.TrimSuffix(pattern, "*")):
+			return true
+		}
+	}
+	return false
+}
+
+func subscriptionFromRecord(record svc.WebhookSubscriptionRecord) Subscription {
+	return Subscription{
+		ID:          record.ID,
+		URL:         record.URL,
+		EventTypes:  splitTypes(record.EventTypes),
+		Description: record.Description,
+		Active:      record.Active,
+		CreatedAt:   record.CreatedAt,
+		UpdatedAt:   record.UpdatedAt,
+	}
+}
+
+func deliveryFromRecord(record svc.WebhookDeliveryRecord) Delivery {
+	return Delivery{
+		ID:             record.ID,
+		SubscriptionID: record.SubscriptionID,
+		URL:            record.URL,
+		EventID:        record.EventID,
+		EventType:      record.EventType,
+		Payload:        json.RawMessage(record.Payload),
+		Status:         record.Status,
+		Attempts:       record.Attempts,
+		NextAttemptAt:  record.NextAttemptAt,
+		ResponseStatus: record.ResponseStatus,
+		LastError:      record.LastError,
+		CreatedAt:      record.CreatedAt,
+		DeliveredAt:    record.DeliveredAt,
+	}
+}
@@ -0,0 +1,154 @@
+package outbound
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
+)
+
+// MemoryStore keeps subscriptions and deliveries in memory. It serves tests
+// and gateways running without a database; everything is lost on restart.
+type MemoryStore struct {
+	mu            sync.Mutex
+	subscriptions map[string]svc.WebhookSubscriptionRecord
+	deliveries    map[string]svc.WebhookDeliveryRecord
+}
+
+// NewMemoryStore returns an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		subscriptions: make(map[string]svc.WebhookSubscriptionRecord),
+		deliveries:    make(map[string]svc.WebhookDeliveryRecord),
+	}
+}
+
+func (m *MemoryStore) SaveSubscription(ctx context.Context, record *svc.WebhookSubscriptionRecord) error {
+	if record.ID == "" {
+		record.ID = uuid.NewString()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subscriptions[record.ID] = *record
+	return nil
+}
+
+func (m *MemoryStore) GetSubscription(ctx context.Context, id string) (*svc.WebhookSubscriptionRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record, ok := m.subscriptions[id]
+	if !ok {
+		return nil, svc.ErrWebhookSubscriptionNotFound
+	}
+	return &record, nil
+}
+
+func (m *MemoryStore) ListSubscriptions(ctx context.Context, activeOnly bool) ([]svc.WebhookSubscriptionRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	records := make([]svc.WebhookSubscriptionRecord, 0, len(m.subscriptions))
+	for _, record := range m.subscriptions {
+		if !activeOnly || record.Active {
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if !records[i].CreatedAt.Equal(records[j].CreatedAt) {
+			return records[i].CreatedAt.Before(records[j].CreatedAt)
+		}
+		return records[i].ID < records[j].ID
+	})
+	return records, nil
+}
+
+func (m *MemoryStore) DeleteSubscription(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.subscriptions[id]; !ok {
+		return svc.ErrWebhookSubscriptionNotFound
+	}
+	delete(m.subscriptions, id)
+	return nil
+}
+
+func (m *MemoryStore) SaveDelivery(ctx context.Context, record *svc.WebhookDeliveryRecord) error {
+	if record.ID == "" {
+		record.ID = uuid.NewString()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deliveries[record.ID] = *record
+	return nil
+}
+
+func (m *MemoryStore) GetDelivery(ctx context.Context, id string) (*svc.WebhookDeliveryRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record, ok := m.deliveries[id]
+	if !ok {
+		return nil, svc.ErrWebhookDeliveryNotFound
+	}
+	return &record, nil
+}
+
+func (m *MemoryStore) ListDeliveries(ctx context.Context, filter svc.WebhookDeliveryFilter) ([]svc.WebhookDeliveryRecord, int64, error) {
+	m.mu.Lock()
+	var records []svc.WebhookDeliveryRecord
+	for _, record := range m.deliveries {
+		if filter.SubscriptionID != "" && record.SubscriptionID != filter.SubscriptionID {
+			continue
+		}
+		if filter.Status != "" && record.Status != filter.Status {
+			continue
+		}
+		if filter.EventType != "" && record.EventType != filter.EventType {
+			continue
+		}
+		records = append(records, record)
+	}
+	m.mu.Unlock()
+
+	sort.Slice(records, func(i, j int) bool {
+		if !records[i].CreatedAt.Equal(records[j].CreatedAt) {
+			return records[i].CreatedAt.After(records[j].CreatedAt)
+		}
+		return records[i].ID > records[j].ID
+	})
+	total := int64(len(records))
+	if filter.Offset > 0 {
+		if filter.Offset >= len(records) {
+			return nil, total, nil
+		}
+		records = records[filter.Offset:]
+	}
+	if filter.Limit > 0 && len(records) > filter.Limit {
+		records = records[:filter.Limit]
+	}
+	return records, total, nil
+}
+
+func (m *MemoryStore) ClaimDeliveries(ctx context.Context, limit int, now, lockUntil time.Time) ([]svc.WebhookDeliveryRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var due []svc.WebhookDeliveryRecord
+	for _, record := range m.deliveries {
+		pending := record.Status == svc.WebhookDeliveryPending && !record.NextAttemptAt.After(now)
+		stale := record.Status == svc.WebhookDeliverySending && record.LockedUntil != nil && record.LockedUntil.Before(now)
+		if pending || stale {
+			due = append(due, record)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].NextAttemptAt.Before(due[j].NextAttemptAt) })
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+	for i := range due {
+		due[i].Status = svc.WebhookDeliverySending
+		due[i].LockedUntil = &lockUntil
+		m.deliveries[due[i].ID] = due[i]
+	}
+	return due, nil
+}
@@ -0,0 +1,55 @@
+package outbound
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// KeySize is the length of Options.Key.
+const KeySize = chacha20poly1305.KeySize
+
+// sealedPrefix marks the subscription secrets sealed with the dispatcher
+// key; stored secrets without it predate the encryption and are read as is.
+const sealedPrefix = "sealed:"
+
+// sealSecret encrypts a subscription secret with XChaCha20-Poly1305 for
+// storage.
+func sealSecret(key []byte, secret string) (string, error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(secret)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(secret), nil)
+	return sealedPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// openSecret decrypts a stored subscription secret.
+func openSecret(key []byte, stored string) (string, error) {
+	encoded, ok := strings.CutPrefix(stored, sealedPrefix)
+	if !ok {
+		return stored, nil
+	}
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", errors.New("malformed subscription secret")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	secret, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("open subscription secret: %w", err)
+	}
+	return string(secret), nil
+}
@@ -0,0 +1,74 @@
+package outbound
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenTarget is returned for delivery URLs pointing at loopback,
+// link-local, private or otherwise internal addresses, unless
+// Options.AllowPrivateTargets is set.
+var ErrForbiddenTarget = errors.New("webhook target address not allowed")
+
+// sharedAddressSpace is the carrier-grade NAT range, not covered by
+// netip.Addr.IsPrivate.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// forbiddenAddr reports whether addr is not a public unicast address.
+func forbiddenAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return !addr.IsValid() ||
+		addr.IsUnspecified() ||
+		addr.IsLoopback() ||
+		addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() ||
+		sharedAddressSpace.Contains(addr)
+}
+
+// checkHost refuses internal IP literals before anything is queued. Names
+// are checked when dialing, once resolved.
+func checkHost(host string) error {
+	if addr, err := netip.ParseAddr(host); err == nil && forbiddenAddr(addr) {
+		return ErrForbiddenTarget
+	}
+	return nil
+}
+
+// newClient returns the HTTP client of the deliveries. Unless allowPrivate
+// is set, every connection, redirects included, is checked after the name is
+// resolved, so that a public name resolving to an internal address is
+// refused too. Proxies are not used then: the proxy address would be checked
+// instead of the target.
+func newClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !allowPrivate {
+		dialer := &net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				addrPort, err := netip.ParseAddrPort(address)
+				if err != nil {
+					return fmt.Errorf("%w: %s", ErrForbiddenTarget, address)
+				}
+				if forbiddenAddr(addrPort.Addr()) {
+					return fmt.Errorf("%w: %s", ErrForbiddenTarget, addrPort.Addr())
+				}
+				return nil
+			},
+		}
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, address)
+		}
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
@@ -0,0 +1,530 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+)
+
+// Actions a rule can run. ActionMCPTool and ActionCronRun are available once
+// the router registers them (see ToolAction and CronAction).
+const (
+	// ActionMCPTool runs the MCP tool named by Target with the rule input as
+	// arguments.
+	ActionMCPTool = "mcp_tool"
+	// ActionCronRun starts a run of the cron job whose ID is Target.
+	ActionCronRun = "cron_run"
+	// ActionChat posts to a chat channel; Target is "<adapter>:<channel>",
+	// for instance "discord:1234". The text is the "text" input.
+	ActionChat = "chat"
+	// ActionAMQP publishes to the routing key Target of the "exchange" param
+	// (gobe.events by default). The body is the mapped input, or the whole
+	// event without a mapping.
+	ActionAMQP = "amqp"
+	// ActionPublish publishes like ActionAMQP on another transport; Target
+	// is "<transport>:<topic>", for instance "zmq:alerts.github".
+	ActionPublish = "publish"
+	// ActionTransform replaces the event payload seen by the following rules
+	// with the rule input.
+	ActionTransform = "transform"
+)
+
+// ErrInvalidRule is returned for rules with an unknown action, a bad pattern
+// or mapping, or a target the action cannot use.
+var ErrInvalidRule = errors.New("invalid webhook rule")
+
+// RuleStore persists rules. svc.WebhookRuleStore keeps them in the
+// database; MemoryRuleStore keeps them in memory.
+type RuleStore = svc.WebhookRuleStore
+
+// Rule maps the events whose source and type match the Source and EventType
+// globs (empty matches everything) to an action. Enabled rules run in
+// ascending Priority; StopOnMatch skips the rules after a matching one.
+//
+// The input of the action is Params overlaid with Mapping, whose values are
+// JSONPath expressions evaluated against the event
+// ({"id", "source", "event_type", "payload", "headers", "timestamp"}).
+type Rule struct {
+	ID          string                 `json:"id"`
+	Name        string                 `json:"name"`
+	Source      string                 `json:"source,omitempty"`
+	EventType   string                 `json:"event_type,omitempty"`
+	Action      string                 `json:"action"`
+	Target      string                 `json:"target,omitempty"`
+	Params      map[string]interface{} `json:"params,omitempty"`
+	Mapping     map[string]string      `json:"mapping,omitempty"`
+	Priority    int                    `json:"priority"`
+	StopOnMatch bool                   `json:"stop_on_match"`
+	Enabled     bool                   `json:"enabled"`
+	CreatedAt   time.Time              `json:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+}
+
+// Action runs a rule for an event. input holds the rule params overlaid
+// with the values its mapping extracted from the event.
+type Action func(ctx context.Context, rule Rule, event *WebhookEvent, input map[string]interface{}) error
+
+// ToolRunner runs an MCP tool; mcp.Registry.Exec fits.
+type ToolRunner func(ctx context.Context, tool string, args map[string]interface{}) (interface{}, error)
+
+// ToolAction returns the ActionMCPTool action running tools through run.
+func ToolAction(run ToolRunner) Action {
+	return func(ctx context.Context, rule Rule, event *WebhookEvent, input map[string]interface{}) error {
+		result, err := run(ctx, rule.Target, input)
+		if err != nil {
+			return err
+		}
+		gl.Log("info", "Webhook rule ran MCP tool", "rule", rule.ID, "tool", rule.Target, "event", event.ID.String(), "result", result)
+		return nil
+	}
+}
+
+// CronAction returns the ActionCronRun action starting runs through run.
+func CronAction(run func(ctx context.Context, jobID uuid.UUID) error) Action {
+	return func(ctx context.Context, rule Rule, event *WebhookEvent, input map[string]interface{}) error {
+		jobID, err := uuid.Parse(rule.Target)
+		if err != nil {
+			return fmt.Errorf("invalid cron job id %q", rule.Target)
+		}
+		return run(ctx, jobID)
+	}
+}
+
+// ChatSender posts text to a channel of a chat adapter.
+type ChatSender func(ctx context.Context, channel, text string) error
+
+var (
+	chatSendersMu sync.RWMutex
+	chatSenders   = make(map[string]ChatSender)
+)
+
+// RegisterChatSender makes adapter ("discord", "telegram", ...) available
+// to ActionChat rules. The chat routes register their adapters at startup.
+func RegisterChatSender(adapter string, sender ChatSender) {
+	chatSendersMu.Lock()
+	defer chatSendersMu.Unlock()
+	chatSenders[adapter] = sender
+}
+
+func chatSender(adapter string) ChatSender {
+	chatSendersMu.RLock()
+	defer chatSendersMu.RUnlock()
+	return chatSenders[adapter]
+}
+
+// Publisher sends body on topic through a message transport.
+type Publisher func(ctx context.Context, topic string, body []byte) error
+
+// ReceivedTopic prefixes the topic every received webhook is published on,
+// followed by its source.
+const ReceivedTopic = "webhook.received."
+
+var (
+	publishersMu sync.RWMutex
+	publishers   = make(map[string]Publisher)
+)
+
+// RegisterPublisher makes transport ("zmq", ...) available to ActionPublish
+// rules and publishes every received webhook on it, as AMQP does. The routes
+// owning the transports register them at startup.
+func RegisterPublisher(transport string, publisher Publisher) {
+	publishersMu.Lock()
+	defer publishersMu.Unlock()
+	if publisher == nil {
+		delete(publishers, transport)
+		return
+	}
+	publishers[transport] = publisher
+}
+
+func transportPublisher(transport string) Publisher {
+	publishersMu.RLock()
+	defer publishersMu.RUnlock()
+	return publishers[transport]
+}
+
+// publishReceived publishes event on every registered transport.
+func publishReceived(ctx context.Context, event *WebhookEvent) {
+	publishersMu.RLock()
+	targets := make(map[string]Publisher, len(publishers))
+	for transport, publisher := range publishers {
+		targets[transport] = publisher
+	}
+	publishersMu.RUnlock()
+	if len(targets) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		gl.Log("error", "Failed to marshal webhook event", err)
+		return
+	}
+	for transport, publish := range targets {
+		if err := publish(ctx, ReceivedTopic+event.Source, body); err != nil {
+			gl.Log("error", fmt.Sprintf("Failed to publish webhook event to %s", transport), err)
+		}
+	}
+}
+
+// SetRuleStore replaces the store of the rules (in memory by default).
+func (ws *WebhookService) SetRuleStore(store RuleStore) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.rules = store
+}
+
+// RegisterAction makes kind available to rules, replacing the action of
+// that kind if any.
+func (ws *WebhookService) RegisterAction(kind string, action Action) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.actions[kind] = action
+}
+
+func (ws *WebhookService) ruleStore() RuleStore {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	return ws.rules
+}
+
+func (ws *WebhookService) action(kind string) Action {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	return ws.actions[kind]
+}
+
+// CreateRule validates and stores rule.
+func (ws *WebhookService) CreateRule(ctx context.Context, rule Rule) (*Rule, error) {
+	if err := ws.validateRule(rule); err != nil {
+		return nil, err
+	}
+	now := ws.now().UTC()
+	rule.ID = ""
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+	return ws.saveRule(ctx, rule)
+}
+
+// UpdateRule replaces rule id with rule, keeping its creation time.
+func (ws *WebhookService) UpdateRule(ctx context.Context, id string, rule Rule) (*Rule, error) {
+	existing, err := ws.ruleStore().GetRule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := ws.validateRule(rule); err != nil {
+		return nil, err
+	}
+	rule.ID = existing.ID
+	rule.CreatedAt = existing.CreatedAt
+	rule.UpdatedAt = ws.now().UTC()
+	return ws.saveRule(ctx, rule)
+}
+
+// GetRule returns rule id.
+func (ws *WebhookService) GetRule(ctx context.Context, id string) (*Rule, error) {
+	record, err := ws.ruleStore().GetRule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	rule := ruleFromRecord(*record)
+	return &rule, nil
+}
+
+// ListRules returns every rule in evaluation order.
+func (ws *WebhookService) ListRules(ctx context.Context) ([]Rule, error) {
+	records, err := ws.ruleStore().ListRules(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]Rule, 0, len(records))
+	for _, record := range records {
+		rules = append(rules, ruleFromRecord(record))
+	}
+	return rules, nil
+}
+
+// DeleteRule removes rule id.
+func (ws *WebhookService) DeleteRule(ctx context.Context, id string) error {
+	return ws.ruleStore().DeleteRule(ctx, id)
+}
+
+func (ws *WebhookService) saveRule(ctx context.Context, rule Rule) (*Rule, error) {
+	record, err := rule.record()
+	if err != nil {
+		return nil, err
+	}
+	if err := ws.ruleStore().SaveRule(ctx, record); err != nil {
+		return nil, err
+	}
+	saved := ruleFromRecord(*record)
+	return &saved, nil
+}
+
+func (ws *WebhookService) validateRule(rule Rule) error {
+	if ws.action(rule.Action) == nil {
+		return fmt.Errorf("%w: unknown action %q", ErrInvalidRule, rule.Action)
+	}
+	for _, pattern := range []string{rule.Source, rule.EventType} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%w: bad pattern %q", ErrInvalidRule, pattern)
+		}
+	}
+	for key, expr := range rule.Mapping {
+		if _, err := compileJSONPath(expr); err != nil {
+			return fmt.Errorf("%w: mapping %q: %v", ErrInvalidRule, key, err)
+		}
+	}
+
+	switch rule.Action {
+	case ActionMCPTool, ActionAMQP:
+		if rule.Target == "" {
+			return fmt.Errorf("%w: %s needs a target", ErrInvalidRule, rule.Action)
+		}
+	case ActionCronRun:
+		if _, err := uuid.Parse(rule.Target); err != nil {
+			return fmt.Errorf("%w: target must be a cron job id", ErrInvalidRule)
+		}
+	case ActionChat:
+		adapter, channel, _ := strings.Cut(rule.Target, ":")
+		if adapter == "" || channel == "" {
+			return fmt.Errorf("%w: target must be <adapter>:<channel>", ErrInvalidRule)
+		}
+	case ActionPublish:
+		transport, topic, _ := strings.Cut(rule.Target, ":")
+		if transport == "" || topic == "" {
+			return fmt.Errorf("%w: target must be <transport>:<topic>", ErrInvalidRule)
+		}
+	case ActionTransform:
+		if len(rule.Mapping) == 0 {
+			return fmt.Errorf("%w: transform needs a mapping", ErrInvalidRule)
+		}
+	}
+	return nil
+}
+
+// enabledRules loads the rules a batch of events is processed with.
+func (ws *WebhookService) enabledRules(ctx context.Context) ([]Rule, error) {
+	records, err := ws.ruleStore().ListRules(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]Rule, 0, len(records))
+	for _, record := range records {
+		rules = append(rules, ruleFromRecord(record))
+	}
+	return rules, nil
+}
+
+// processWebhookEvent runs the rules matching event in order. An event that
+// no rule matches completes without action.
+func (ws *WebhookService) processWebhookEvent(ctx context.Context, event *WebhookEvent, rules []Rule) error {
+	gl.Log("info", "Processing webhook event", "id", event.ID.String(), "source", event.Source, "type", event.EventType)
+
+	matched := 0
+	for _, rule := range rules {
+		if !rule.matches(event) {
+			continue
+		}
+		matched++
+
+		action := ws.action(rule.Action)
+		if action == nil {
+			return fmt.Errorf("rule %s: action %q unavailable", rule.ID, rule.Action)
+		}
+		input, err := rule.input(event)
+		if err != nil {
+			return fmt.Errorf("rule %s: %w", rule.ID, err)
+		}
+		if err := action(ctx, rule, event, input); err != nil {
+			return fmt.Errorf("rule %s: %w", rule.ID, err)
+		}
+		if rule.StopOnMatch {
+			break
+		}
+	}
+
+	if matched == 0 {
+		gl.Log("debug", "No webhook rule matched", "source", event.Source, "type", event.EventType)
+	}
+	return nil
+}
+
+func (r Rule) matches(event *WebhookEvent) bool {
+	return globMatch(r.Source, event.Source) && globMatch(r.EventType, event.EventType)
+}
+
+func globMatch(pattern, value string) bool {
+	if pattern == "" {
+		return true
+	}
+	ok, _ := path.Match(pattern, value)
+	return ok
+}
+
+// input overlays Params with the mapped values of event. Paths that select
+// nothing leave their key out.
+func (r Rule) input(event *WebhookEvent) (map[string]interface{}, error) {
+	input := make(map[string]interface{}, len(r.Params)+len(r.Mapping))
+	for key, value := range r.Params {
+		input[key] = value
+	}
+	if len(r.Mapping) == 0 {
+		return input, nil
+	}
+
+	doc := event.document()
+	for key, expr := range r.Mapping {
+		p, err := compileJSONPath(expr)
+		if err != nil {
+			return nil, err
+		}
+		if value, ok := p.eval(doc); ok {
+			input[key] = value
+		}
+	}
+	return input, nil
+}
+
+// document is the JSON view of the event that mappings are evaluated
+// against.
+func (e *WebhookEvent) document() map[string]interface{} {
+	headers := make(map[string]interface{}, len(e.Headers))
+	for key, value := range e.Headers {
+		headers[key] = value
+	}
+	payload := map[string]interface{}{}
+	if e.Payload != nil {
+		payload = e.Payload
+	}
+	return map[string]interface{}{
+		"id":         e.ID.String(),
+		"source":     e.Source,
+		"event_type": e.EventType,
+		"payload":    payload,
+		"headers":    headers,
+		"timestamp":  e.Timestamp.Format(time.RFC3339Nano),
+	}
+}
+
+func transformAction(ctx context.Context, rule Rule, event *WebhookEvent, input map[string]interface{}) error {
+	event.Payload = input
+	return nil
+}
+
+func (ws *WebhookService) amqpAction(ctx context.Context, rule Rule, event *WebhookEvent, input map[string]interface{}) error {
+	if ws.broker == nil || !ws.broker.IsReady() {
+		return errors.New("message broker unavailable")
+	}
+	exchange, _ := rule.Params["exchange"].(string)
+	if exchange == "" {
+		exchange = "gobe.events"
+	}
+
+	var body interface{} = event
+	if len(rule.Mapping) > 0 {
+		body = input
+	}
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return ws.broker.Publish(exchange, rule.Target, raw)
+}
+
+func publishAction(ctx context.Context, rule Rule, event *WebhookEvent, input map[string]interface{}) error {
+	transport, topic, _ := strings.Cut(rule.Target, ":")
+	publish := transportPublisher(transport)
+	if publish == nil {
+		return fmt.Errorf("transport %q unavailable", transport)
+	}
+
+	var body interface{} = event
+	if len(rule.Mapping) > 0 {
+		body = input
+	}
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return publish(ctx, topic, raw)
+}
+
+// maxChatText keeps default chat messages within the Discord limit.
+const maxChatText = 1800
+
+func chatAction(ctx context.Context, rule Rule, event *WebhookEvent, input map[string]interface{}) error {
+	adapter, channel, _ := strings.Cut(rule.Target, ":")
+	send := chatSender(adapter)
+	if send == nil {
+		return fmt.Errorf("chat adapter %q unavailable", adapter)
+	}
+
+	text, _ := input["text"].(string)
+	if text == "" {
+		payload, _ := json.Marshal(event.Payload)
+		text = fmt.Sprintf("[%s] %s\n%s", event.Source, event.EventType, payload)
+		if len(text) > maxChatText {
+			text = strings.ToValidUTF8(text[:maxChatText], "") + "…"
+		}
+	}
+	return send(ctx, channel, text)
+}
+
+func (r Rule) record() (*svc.WebhookRuleRecord, error) {
+	params, err := json.Marshal(r.Params)
+	if err != nil {
+		return nil, fmt.Errorf("encode rule params: %w", err)
+	}
+	mapping, err := json.Marshal(r.Mapping)
+	if err != nil {
+		return nil, fmt.Errorf("encode rule mapping: %w", err)
+	}
+	return &svc.WebhookRuleRecord{
+		ID:          r.ID,
+		Name:        r.Name,
+		Source:      r.Source,
+		EventType:   r.EventType,
+		Action:      r.Action,
+		Target:      r.Target,
+		Params:      string(params),
+		Mapping:     string(mapping),
+		Priority:    r.Priority,
+		StopOnMatch: r.StopOnMatch,
+		Enabled:     r.Enabled,
+		CreatedAt:   r.CreatedAt,
+		UpdatedAt:   r.UpdatedAt,
+	}, nil
+}
+
+func ruleFromRecord(record svc.WebhookRuleRecord) Rule {
+	rule := Rule{
+		ID:          record.ID,
+		Name:        record.Name,
+		Source:      record.Source,
+		EventType:   record.EventType,
+		Action:      record.Action,
+		Target:      record.Target,
+		Priority:    record.Priority,
+		StopOnMatch: record.StopOnMatch,
+		Enabled:     record.Enabled,
+		CreatedAt:   record.CreatedAt,
+		UpdatedAt:   record.UpdatedAt,
+	}
+	if err := json.Unmarshal([]byte(record.Params), &rule.Params); err != nil && record.Params != "" {
+		gl.Log("warn", "Undecodable webhook rule params", record.ID, err)
+	}
+	if err := json.Unmarshal([]byte(record.Mapping), &rule.Mapping); err != nil && record.Mapping != "" {
+		gl.Log("warn", "Undecodable webhook rule mapping", record.ID, err)
+	}
+	return rule
+}
@@ -0,0 +1,110 @@
+package verify
+
+import (
+	"crypto/sha1"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	krs "github.com/kubex-ecosystem/gobe/internal/app/security/external"
+	cm "github.com/kubex-ecosystem/gobe/internal/commons"
+	"github.com/kubex-ecosystem/gobe/internal/config"
+)
+
+// FromConfig builds the registry of the "webhooks" config section. A source
+// whose verifier cannot be built (unknown scheme, missing secret) refuses
+// every delivery, and the problem is reported in the returned error.
+func FromConfig(cfg config.WebhooksConfig) (*Registry, error) {
+	registry := NewRegistry(time.Duration(cfg.ReplayWindowSeconds) * time.Second)
+	registry.Require(!cfg.AllowUnsigned)
+
+	var errs []error
+	for source, sourceCfg := range cfg.Sources {
+		verifier, err := NewVerifier(sourceCfg)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("webhook source %q: %w", source, err))
+			verifier = refuse{err: err}
+		}
+		registry.Register(source, verifier)
+	}
+	return registry, errors.Join(errs...)
+}
+
+// NewVerifier builds the verifier of one source.
+func NewVerifier(cfg config.WebhookSourceConfig) (Verifier, error) {
+	secret, err := Secret(cfg.SecretEnv, cfg.SecretKeyring)
+	if err != nil {
+		return nil, err
+	}
+	tolerance := DefaultTolerance
+	if cfg.ToleranceSeconds > 0 {
+		tolerance = time.Duration(cfg.ToleranceSeconds) * time.Second
+	}
+
+	switch cfg.Scheme {
+	case "github":
+		return NewGitHub(secret), nil
+	case "stripe":
+		v := NewStripe(secret)
+		v.Tolerance = tolerance
+		return v, nil
+	case "slack":
+		v := NewSlack(secret)
+		v.Tolerance = tolerance
+		return v, nil
+	case "discord":
+		v, err := NewDiscord(secret)
+		if err != nil {
+			return nil, err
+		}
+		v.Tolerance = tolerance
+		return v, nil
+	case "hmac":
+		v := NewHMAC(secret)
+		v.Tolerance = tolerance
+		v.Prefix = cfg.Prefix
+		if cfg.SignatureHeader != "" {
+			v.SignatureHeader = cfg.SignatureHeader
+		}
+		v.TimestampHeader = cfg.TimestampHeader
+		switch cfg.Algorithm {
+		case "", "sha256":
+		case "sha1":
+			v.Hash = sha1.New
+		case "sha512":
+			v.Hash = sha512.New
+		default:
+			return nil, fmt.Errorf("unknown hmac algorithm %q", cfg.Algorithm)
+		}
+		switch cfg.Encoding {
+		case "", "hex":
+		case "base64":
+			v.Base64 = true
+		default:
+			return nil, fmt.Errorf("unknown signature encoding %q", cfg.Encoding)
+		}
+		return v, nil
+	default:
+		return nil, fmt.Errorf("unknown signature scheme %q", cfg.Scheme)
+	}
+}
+
+// Secret reads a webhook secret from the env variable, or from the keyring
+// entry "gobe-<keyringName>" when the variable is unset.
+func Secret(env, keyringName string) (string, error) {
+	if env != "" {
+		if value := os.Getenv(env); value != "" {
+			return value, nil
+		}
+	}
+	if keyringName == "" {
+		return "", fmt.Errorf("secret not set (env %q)", env)
+	}
+	value, err := krs.NewKeyringService(cm.KeyringService, "gobe-"+keyringName).RetrievePassword()
+	if err != nil {
+		return "", fmt.Errorf("secret %q not found in keyring: %w", keyringName, err)
+	}
+	return value, nil
+}
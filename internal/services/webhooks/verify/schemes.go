@@ -0,0 +1,238 @@
+package verify
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GitHub verifies the X-Hub-Signature-256 header of GitHub deliveries.
+type GitHub struct {
+	secret []byte
+}
+
+func NewGitHub(secret string) *GitHub {
+	return &GitHub{secret: []byte(secret)}
+}
+
+func (v *GitHub) Verify(header http.Header, body []byte, _ time.Time) (string, error) {
+	signature := header.Get("X-Hub-Signature-256")
+	if signature == "" {
+		return "", ErrMissingSignature
+	}
+	expected := sign(sha256.New, v.secret, body)
+	digest, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok || !equalHex(expected, digest) {
+		return "", ErrInvalidSignature
+	}
+	return hex.EncodeToString(expected), nil
+}
+
+// Stripe verifies the Stripe-Signature header ("t=<unix>,v1=<hex>,...") of
+// Stripe deliveries. Any of the v1 signatures may match, which covers secret
+// rolling.
+type Stripe struct {
+	secret    []byte
+	Tolerance time.Duration
+}
+
+func NewStripe(secret string) *Stripe {
+	return &Stripe{secret: []byte(secret), Tolerance: DefaultTolerance}
+}
+
+func (v *Stripe) Verify(header http.Header, body []byte, now time.Time) (string, error) {
+	value := header.Get("Stripe-Signature")
+	if value == "" {
+		return "", ErrMissingSignature
+	}
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(value, ",") {
+		key, val, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = val
+		case "v1":
+			signatures = append(signatures, val)
+		}
+	}
+	if timestamp == "" || len(signatures) == 0 {
+		return "", ErrInvalidSignature
+	}
+	if err := checkTimestamp(timestamp, now, v.Tolerance); err != nil {
+		return "", err
+	}
+
+	expected := sign(sha256.New, v.secret, []byte(timestamp+"."), body)
+	for _, signature := range signatures {
+		if equalHex(expected, signature) {
+			return timestamp + ":" + hex.EncodeToString(expected), nil
+		}
+	}
+	return "", ErrInvalidSignature
+}
+
+// Slack verifies the X-Slack-Signature header ("v0=<hex>") of Slack
+// requests, signed with the app's signing secret.
+type Slack struct {
+	secret    []byte
+	Tolerance time.Duration
+}
+
+func NewSlack(secret string) *Slack {
+	return &Slack{secret: []byte(secret), Tolerance: DefaultTolerance}
+}
+
+func (v *Slack) Verify(header http.Header, body []byte, now time.Time) (string, error) {
+	signature := header.Get("X-Slack-Signature")
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	if signature == "" || timestamp == "" {
+		return "", ErrMissingSignature
+	}
+	if err := checkTimestamp(timestamp, now, v.Tolerance); err != nil {
+		return "", err
+	}
+	expected := sign(sha256.New, v.secret, []byte("v0:"+timestamp+":"), body)
+	digest, ok := strings.CutPrefix(signature, "v0=")
+	if !ok || !equalHex(expected, digest) {
+		return "", ErrInvalidSignature
+	}
+	return timestamp + ":" + hex.EncodeToString(expected), nil
+}
+
+// Discord verifies the Ed25519 signature (X-Signature-Ed25519 over
+// X-Signature-Timestamp and the body) of Discord interactions.
+type Discord struct {
+	key       ed25519.PublicKey
+	Tolerance time.Duration
+}
+
+// NewDiscord takes the hex encoded public key of the Discord application.
+func NewDiscord(publicKey string) (*Discord, error) {
+	key, err := hex.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid discord public key")
+	}
+	return &Discord{key: key, Tolerance: DefaultTolerance}, nil
+}
+
+func (v *Discord) Verify(header http.Header, body []byte, now time.Time) (string, error) {
+	signature := header.Get("X-Signature-Ed25519")
+	timestamp := header.Get("X-Signature-Timestamp")
+	if signature == "" || timestamp == "" {
+		return "", ErrMissingSignature
+	}
+	if err := checkTimestamp(timestamp, now, v.Tolerance); err != nil {
+		return "", err
+	}
+	raw, err := hex.DecodeString(signature)
+	if err != nil || len(raw) != ed25519.SignatureSize {
+		return "", ErrInvalidSignature
+	}
+	if !ed25519.Verify(v.key, append([]byte(timestamp), body...), raw) {
+		return "", ErrInvalidSignature
+	}
+	return timestamp + ":" + hex.EncodeToString(raw), nil
+}
+
+// HMAC verifies a generic HMAC signature carried in SignatureHeader after
+// Prefix. With TimestampHeader set, the signed payload is
+// "<timestamp>.<body>" and the timestamp must be within Tolerance.
+type HMAC struct {
+	secret          []byte
+	Hash            func() hash.Hash
+	Base64          bool
+	SignatureHeader string
+	TimestampHeader string
+	Prefix          string
+	Tolerance       time.Duration
+}
+
+// NewHMAC returns a hex encoded HMAC-SHA256 verifier reading
+// X-Webhook-Signature.
+func NewHMAC(secret string) *HMAC {
+	return &HMAC{
+		secret:          []byte(secret),
+		Hash:            sha256.New,
+		SignatureHeader: "X-Webhook-Signature",
+		Tolerance:       DefaultTolerance,
+	}
+}
+
+func (v *HMAC) Verify(header http.Header, body []byte, now time.Time) (string, error) {
+	signature := header.Get(v.SignatureHeader)
+	if signature == "" {
+		return "", ErrMissingSignature
+	}
+	digest, ok := strings.CutPrefix(signature, v.Prefix)
+	if !ok {
+		return "", ErrInvalidSignature
+	}
+
+	var prefix []byte
+	var timestamp string
+	if v.TimestampHeader != "" {
+		if timestamp = header.Get(v.TimestampHeader); timestamp == "" {
+			return "", ErrMissingSignature
+		}
+		if err := checkTimestamp(timestamp, now, v.Tolerance); err != nil {
+			return "", err
+		}
+		prefix = []byte(timestamp + ".")
+	}
+
+	expected := sign(v.Hash, v.secret, prefix, body)
+	valid := false
+	if v.Base64 {
+		raw, err := base64.StdEncoding.DecodeString(digest)
+		valid = err == nil && hmac.Equal(expected, raw)
+	} else {
+		valid = equalHex(expected, digest)
+	}
+	if !valid {
+		return "", ErrInvalidSignature
+	}
+
+	if timestamp != "" {
+		return timestamp + ":" + hex.EncodeToString(expected), nil
+	}
+	return hex.EncodeToString(expected), nil
+}
+
+func sign(h func() hash.Hash, secret []byte, parts ...[]byte) []byte {
+	mac := hmac.New(h, secret)
+	for _, part := range parts {
+		mac.Write(part)
+	}
+	return mac.Sum(nil)
+}
+
+func equalHex(expected []byte, digest string) bool {
+	raw, err := hex.DecodeString(digest)
+	return err == nil && hmac.Equal(expected, raw)
+}
+
+// checkTimestamp accepts unix timestamps (seconds) within tolerance of now,
+// in either direction to allow for clock skew.
+func checkTimestamp(value string, now time.Time, tolerance time.Duration) error {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if tolerance <= 0 {
+		return nil
+	}
+	skew := now.Sub(time.Unix(seconds, 0))
+	if skew > tolerance || skew < -tolerance {
+		return ErrStaleTimestamp
+	}
+	return nil
+}
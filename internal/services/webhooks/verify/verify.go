@@ -0,0 +1,178 @@
+// Package verify checks the signatures of inbound webhook deliveries and
+// refuses replayed ones.
+package verify
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Reasons a delivery is refused. The message of each error is the reason
+// recorded with the rejected delivery.
+var (
+	ErrMissingSignature = errors.New("missing signature")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrStaleTimestamp   = errors.New("timestamp outside tolerance")
+	ErrReplayed         = errors.New("replayed delivery")
+	ErrUnsignedSource   = errors.New("source requires a signature")
+	ErrMissingID        = errors.New("missing delivery id")
+)
+
+// DefaultTolerance bounds the age of signed timestamps.
+const DefaultTolerance = 5 * time.Minute
+
+// DefaultReplayWindow is how long delivery IDs are remembered.
+const DefaultReplayWindow = 24 * time.Hour
+
+// Verifier checks the signature of a delivery. It returns the signed value
+// it checked, in a canonical form (the lowercase hex digest, prefixed by the
+// signed timestamp when the scheme has one), from which the registry derives
+// the delivery ID. Unsigned headers such as X-GitHub-Delivery are not used:
+// a sender could change them to replay a delivery.
+type Verifier interface {
+	Verify(header http.Header, body []byte, now time.Time) (string, error)
+}
+
+// Registry holds the verifier of each webhook source and the delivery IDs
+// seen during the replay window. The IDs are kept in memory only: they are
+// lost on restart and not shared between replicas, so each instance refuses
+// the replays it saw itself since it started.
+//
+// Deliveries of sources without a verifier are refused unless Require(false)
+// is called.
+type Registry struct {
+	mu        sync.Mutex
+	verifiers map[string]Verifier
+	required  bool
+	window    time.Duration
+	seen      map[string]*list.Element
+	order     *list.List
+	now       func() time.Time
+}
+
+type seenID struct {
+	key     string
+	expires time.Time
+}
+
+// NewRegistry returns an empty registry remembering delivery IDs for window
+// (DefaultReplayWindow when zero).
+func NewRegistry(window time.Duration) *Registry {
+	if window <= 0 {
+		window = DefaultReplayWindow
+	}
+	return &Registry{
+		verifiers: make(map[string]Verifier),
+		window:    window,
+		required:  true,
+		seen:      make(map[string]*list.Element),
+		order:     list.New(),
+		now:       time.Now,
+	}
+}
+
+// Register sets the verifier of source.
+func (r *Registry) Register(source string, verifier Verifier) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.verifiers[source] = verifier
+}
+
+// Require sets whether the deliveries of sources without a verifier are
+// refused, which is the default.
+func (r *Registry) Require(required bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.required = required
+}
+
+// Verify checks a delivery of source and returns its delivery ID, a hash of
+// the signed value and the body. The ID is remembered: a second delivery
+// with the same ID fails with ErrReplayed until the replay window passes or
+// Release is called. Deliveries of unverified sources have no ID.
+func (r *Registry) Verify(source string, header http.Header, body []byte) (string, error) {
+	r.mu.Lock()
+	verifier, ok := r.verifiers[source]
+	required := r.required
+	r.mu.Unlock()
+
+	if !ok {
+		if required {
+			return "", ErrUnsignedSource
+		}
+		return "", nil
+	}
+
+	now := r.now()
+	signed, err := verifier.Verify(header, body, now)
+	if err != nil {
+		return "", err
+	}
+	if signed == "" {
+		return "", ErrMissingID
+	}
+
+	id := deliveryID(signed, body)
+	key := source + "\x00" + id
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.expire(now)
+	if _, dup := r.seen[key]; dup {
+		return id, ErrReplayed
+	}
+	r.seen[key] = r.order.PushBack(seenID{key: key, expires: now.Add(r.window)})
+	return id, nil
+}
+
+// Release forgets a delivery ID, so that the sender can retry a delivery
+// that was verified but could not be stored.
+func (r *Registry) Release(source, id string) {
+	if r == nil || id == "" {
+		return
+	}
+	key := source + "\x00" + id
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if elem, ok := r.seen[key]; ok {
+		r.order.Remove(elem)
+		delete(r.seen, key)
+	}
+}
+
+// deliveryID hashes the signed value of a delivery with its body.
+func deliveryID(signed string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(signed))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// expire drops the IDs whose window passed. IDs are appended in time order,
+// so the expired ones are at the front.
+func (r *Registry) expire(now time.Time) {
+	for elem := r.order.Front(); elem != nil; elem = r.order.Front() {
+		entry := elem.Value.(seenID)
+		if entry.expires.After(now) {
+			return
+		}
+		r.order.Remove(elem)
+		delete(r.seen, entry.key)
+	}
+}
+
+// refuse is the verifier of a source whose secret could not be loaded: its
+// deliveries are refused rather than accepted unchecked.
+type refuse struct {
+	err error
+}
+
+func (v refuse) Verify(http.Header, []byte, time.Time) (string, error) {
+	return "", fmt.Errorf("verifier unavailable: %w", v.err)
+}
@@ -3,16 +3,31 @@ package webhooks
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
+	"github.com/kubex-ecosystem/gobe/internal/config"
 	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+	"github.com/kubex-ecosystem/gobe/internal/services/webhooks/outbound"
 	messagery "github.com/kubex-ecosystem/gobe/internal/sockets/messagery"
 )
 
+// Store persists webhook events. svc.WebhookEventStore keeps them in the
+// database; MemoryStore keeps them in memory.
+type Store = svc.WebhookEventStore
+
+// ErrInvalidCursor is returned by ListEvents for cursors it did not issue.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
 // WebhookEvent represents a webhook event received from external services
 type WebhookEvent struct {
 	ID        uuid.UUID              `json:"id"`
@@ -24,28 +39,128 @@ type WebhookEvent struct {
 	Processed bool                   `json:"processed"`
 	Status    string                 `json:"status"`
 	Error     string                 `json:"error,omitempty"`
+	Attempts  int                    `json:"attempts"`
+}
+
+// Options tunes the background worker and the retention of the service.
+type Options struct {
+	// Retention is how long finished events are kept; zero keeps them.
+	Retention     time.Duration
+	PurgeInterval time.Duration
+	PollInterval  time.Duration
+	BatchSize     int
+	// Lease is how long a worker owns the events it claimed.
+	Lease time.Duration
+}
+
+// DefaultOptions keeps finished events for 30 days.
+var DefaultOptions = Options{
+	Retention:     30 * 24 * time.Hour,
+	PurgeInterval: time.Hour,
+	PollInterval:  5 * time.Second,
+	BatchSize:     50,
+	Lease:         time.Minute,
+}
+
+// OptionsFromConfig reads the webhook section of the config. RetentionDays
+// sets Retention (a negative value keeps events forever), PurgeIntervalMinutes,
+// PollSeconds and LeaseSeconds set the purge, poll and lease durations, and
+// BatchSize the number of events claimed per poll. Zero values keep the
+// DefaultOptions.
+func OptionsFromConfig(cfg config.WebhooksConfig) Options {
+	opts := DefaultOptions
+	switch {
+	case cfg.RetentionDays < 0:
+		opts.Retention = 0
+	case cfg.RetentionDays > 0:
+		opts.Retention = time.Duration(cfg.RetentionDays) * 24 * time.Hour
+	}
+	if cfg.PurgeIntervalMinutes > 0 {
+		opts.PurgeInterval = time.Duration(cfg.PurgeIntervalMinutes) * time.Minute
+	}
+	if cfg.PollSeconds > 0 {
+		opts.PollInterval = time.Duration(cfg.PollSeconds) * time.Second
+	}
+	if cfg.BatchSize > 0 {
+		opts.BatchSize = cfg.BatchSize
+	}
+	if cfg.LeaseSeconds > 0 {
+		opts.Lease = time.Duration(cfg.LeaseSeconds) * time.Second
+	}
+	return opts
+}
+
+// EventQuery selects a page of events, newest first. Cursor is the
+// NextCursor of the previous page; Offset is kept for older clients.
+type EventQuery struct {
+	Source    string
+	EventType string
+	Status    string
+	From      time.Time
+	To        time.Time
+	Cursor    string
+	Offset    int
+	Limit     int
+}
+
+// EventPage is a page of events. NextCursor is empty on the last page.
+type EventPage struct {
+	Events     []WebhookEvent
+	Total      int64
+	NextCursor string
 }
 
 // WebhookService provides functional webhook handling
 type WebhookService struct {
-	amqp      *messagery.AMQP
-	events    []WebhookEvent
-	mu        sync.RWMutex
+	broker    messagery.Broker
+	store     Store
+	opts      Options
 	ctx       context.Context
 	cancel    context.CancelFunc
 	startTime time.Time
+	now       func() time.Time
+
+	mu      sync.RWMutex
+	rules   RuleStore
+	actions map[string]Action
+	events  *outbound.Dispatcher
 }
 
-// NewWebhookService creates a new functional webhook service
-func NewWebhookService(amqp *messagery.AMQP) *WebhookService {
+// NewWebhookService creates a webhook service persisting events in store (in
+// memory when store is nil) and starts its background worker.
+func NewWebhookService(broker messagery.Broker, store Store, opts Options) *WebhookService {
 	ctx, cancel := context.WithCancel(context.Background())
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultOptions.PollInterval
+	}
+	if opts.PurgeInterval <= 0 {
+		opts.PurgeInterval = DefaultOptions.PurgeInterval
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultOptions.BatchSize
+	}
+	if opts.Lease <= 0 {
+		opts.Lease = DefaultOptions.Lease
+	}
 
 	service := &WebhookService{
-		amqp:      amqp,
-		events:    make([]WebhookEvent, 0),
+		broker:    broker,
+		store:     store,
+		opts:      opts,
 		ctx:       ctx,
 		cancel:    cancel,
 		startTime: time.Now(),
+		now:       time.Now,
+		rules:     NewMemoryRuleStore(),
+	}
+	service.actions = map[string]Action{
+		ActionAMQP:      service.amqpAction,
+		ActionChat:      chatAction,
+		ActionPublish:   publishAction,
+		ActionTransform: transformAction,
 	}
 
 	// Start background processor
@@ -55,232 +170,275 @@ func NewWebhookService(amqp *messagery.AMQP) *WebhookService {
 	return service
 }
 
-// ReceiveWebhook processes an incoming webhook and queues it for processing
-func (ws *WebhookService) ReceiveWebhook(source, eventType string, payload map[string]interface{}, headers map[string]string) (*WebhookEvent, error) {
+// SetDispatcher forwards every received event to the outbound webhook
+// subscribers through d.
+func (ws *WebhookService) SetDispatcher(d *outbound.Dispatcher) {
 	ws.mu.Lock()
 	defer ws.mu.Unlock()
+	ws.events = d
+}
+
+func (ws *WebhookService) dispatcher() *outbound.Dispatcher {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	return ws.events
+}
+
+// credentialHeaders are dropped from the headers of an event, together with
+// any header whose name mentions a signature, token or secret.
+var credentialHeaders = map[string]struct{}{
+	"Authorization":       {},
+	"Proxy-Authorization": {},
+	"Cookie":              {},
+	"Set-Cookie":          {},
+	"X-Api-Key":           {},
+}
+
+// storedHeaders returns the headers of a delivery without its credentials
+// and signatures: they are persisted and passed on to rules, transports and
+// outbound subscribers.
+func storedHeaders(headers map[string]string) map[string]string {
+	stored := make(map[string]string, len(headers))
+	for key, value := range headers {
+		name := http.CanonicalHeaderKey(key)
+		if _, ok := credentialHeaders[name]; ok {
+			continue
+		}
+		lower := strings.ToLower(name)
+		if strings.Contains(lower, "signature") || strings.Contains(lower, "token") || strings.Contains(lower, "secret") {
+			continue
+		}
+		stored[key] = value
+	}
+	return stored
+}
 
+// ReceiveWebhook stores an incoming webhook and queues it for processing.
+// Credential and signature headers are not kept.
+func (ws *WebhookService) ReceiveWebhook(source, eventType string, payload map[string]interface{}, headers map[string]string) (*WebhookEvent, error) {
 	event := WebhookEvent{
 		ID:        uuid.New(),
 		Source:    source,
 		EventType: eventType,
 		Payload:   payload,
-		Headers:   headers,
-		Timestamp: time.Now(),
+		Headers:   storedHeaders(headers),
+		Timestamp: ws.now().UTC(),
 		Processed: false,
-		Status:    "received",
+		Status:    svc.WebhookEventReceived,
 	}
 
-	// Store event in memory (in production, this would be in database)
-	ws.events = append(ws.events, event)
+	record, err := event.record()
+	if err != nil {
+		return nil, err
+	}
+	if err := ws.store.SaveEvent(ws.ctx, record); err != nil {
+		return nil, fmt.Errorf("store webhook event: %w", err)
+	}
 
-	// Publish to AMQP queue for async processing
-	if ws.amqp != nil && ws.amqp.IsReady() {
+	// Publish to the message broker for async processing
+	if ws.broker != nil && ws.broker.IsReady() {
 		eventBytes, err := json.Marshal(event)
 		if err != nil {
 			gl.Log("error", "Failed to marshal webhook event", err)
 		} else {
-			err = ws.amqp.Publish("gobe.events", "webhook.received", eventBytes)
+			err = ws.broker.Publish("gobe.events", "webhook.received", eventBytes)
 			if err != nil {
-				gl.Log("error", "Failed to publish webhook event to AMQP", err)
+				gl.Log("error", "Failed to publish webhook event to the message broker", err)
 			} else {
-				gl.Log("info", "Webhook event published to AMQP", event.ID.String())
+				gl.Log("info", "Webhook event published to the message broker", event.ID.String())
 			}
 		}
 	}
 
+	// and to the other transports, such as the ZMQ event bus
+	publishReceived(ws.ctx, &event)
+
+	ws.dispatcher().Emit(outbound.EventWebhookReceived, event)
+
 	gl.Log("info", "Webhook received", "source", source, "type", eventType, "id", event.ID.String())
 	return &event, nil
 }
 
-// GetWebhookEvent retrieves a specific webhook event by ID
-func (ws *WebhookService) GetWebhookEvent(id uuid.UUID) (*WebhookEvent, error) {
-	ws.mu.RLock()
-	defer ws.mu.RUnlock()
+// RecordRejected stores a delivery refused before processing (for instance
+// for a bad signature) with the reason, so that refusals can be audited. The
+// body, credential and signature headers are not kept.
+func (ws *WebhookService) RecordRejected(source, eventType string, headers map[string]string, reason string) (*WebhookEvent, error) {
+	event := WebhookEvent{
+		ID:        uuid.New(),
+		Source:    source,
+		EventType: eventType,
+		Payload:   map[string]interface{}{},
+		Headers:   storedHeaders(headers),
+		Timestamp: ws.now().UTC(),
+		Status:    svc.WebhookEventRejected,
+		Error:     reason,
+	}
 
-	for _, event := range ws.events {
-		if event.ID == id {
-			return &event, nil
-		}
+	record, err := event.record()
+	if err != nil {
+		return nil, err
+	}
+	if err := ws.store.SaveEvent(ws.ctx, record); err != nil {
+		return nil, fmt.Errorf("store rejected webhook: %w", err)
 	}
 
-	return nil, fmt.Errorf("webhook event not found: %s", id.String())
+	gl.Log("warn", "Webhook rejected", "source", source, "reason", reason, "id", event.ID.String())
+	return &event, nil
 }
 
-// ListWebhookEvents returns all webhook events with optional filtering
-func (ws *WebhookService) ListWebhookEvents(limit int, offset int, source string) ([]WebhookEvent, int, error) {
-	ws.mu.RLock()
-	defer ws.mu.RUnlock()
+// GetWebhookEvent retrieves a specific webhook event by ID
+func (ws *WebhookService) GetWebhookEvent(id uuid.UUID) (*WebhookEvent, error) {
+	record, err := ws.store.GetEvent(ws.ctx, id.String())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, id.String())
+	}
+	event := eventFromRecord(*record)
+	return &event, nil
+}
 
-	var filtered []WebhookEvent
+// ListEvents returns a page of the events matching query, newest first.
+func (ws *WebhookService) ListEvents(ctx context.Context, query EventQuery) (*EventPage, error) {
+	filter := svc.WebhookEventFilter{
+		Source:    query.Source,
+		EventType: query.EventType,
+		Status:    query.Status,
+		From:      query.From,
+		To:        query.To,
+		Limit:     query.Limit,
+	}
+	total, err := ws.store.CountEvents(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
 
-	// Filter by source if specified
-	if source != "" {
-		for _, event := range ws.events {
-			if event.Source == source {
-				filtered = append(filtered, event)
-			}
+	if query.Cursor != "" {
+		if filter.Before, filter.BeforeID, err = decodeCursor(query.Cursor); err != nil {
+			return nil, err
 		}
 	} else {
-		filtered = ws.events
+		filter.Offset = query.Offset
 	}
-
-	total := len(filtered)
-
-	// Apply pagination
-	if offset >= total {
-		return []WebhookEvent{}, total, nil
+	records, err := ws.store.ListEvents(ctx, filter)
+	if err != nil {
+		return nil, err
 	}
 
-	end := offset + limit
-	if end > total {
-		end = total
+	page := &EventPage{Events: make([]WebhookEvent, 0, len(records)), Total: total}
+	for _, record := range records {
+		page.Events = append(page.Events, eventFromRecord(record))
 	}
-
-	// Sort by timestamp (newest first)
-	result := make([]WebhookEvent, end-offset)
-	copy(result, filtered[offset:end])
-
-	return result, total, nil
+	if query.Limit > 0 && len(records) == query.Limit {
+		last := records[len(records)-1]
+		page.NextCursor = encodeCursor(last.ReceivedAt, last.ID)
+	}
+	return page, nil
 }
 
-// processWebhooksWorker runs in background to process queued webhooks
+// processWebhooksWorker runs in background to process stored webhooks and
+// purge the expired ones
 func (ws *WebhookService) processWebhooksWorker() {
-	ticker := time.NewTicker(5 * time.Second) // Process every 5 seconds
+	ticker := time.NewTicker(ws.opts.PollInterval)
 	defer ticker.Stop()
 
+	var purge <-chan time.Time
+	if ws.opts.Retention > 0 {
+		purgeTicker := time.NewTicker(ws.opts.PurgeInterval)
+		defer purgeTicker.Stop()
+		purge = purgeTicker.C
+	}
+
 	for {
 		select {
 		case <-ws.ctx.Done():
 			gl.Log("info", "Webhook processor worker shutting down")
 			return
 		case <-ticker.C:
-			ws.processQueuedWebhooks()
+			if _, err := ws.ProcessPending(ws.ctx); err != nil {
+				gl.Log("error", "Failed to process webhook events", err)
+			}
+		case <-purge:
+			if _, err := ws.Purge(ws.ctx); err != nil {
+				gl.Log("error", "Failed to purge webhook events", err)
+			}
 		}
 	}
 }
 
-// processQueuedWebhooks processes pending webhook events
-func (ws *WebhookService) processQueuedWebhooks() {
-	ws.mu.Lock()
-	defer ws.mu.Unlock()
+// ProcessPending claims a batch of pending events from the store and
+// processes them, returning how many completed. Events left processing by a
+// crashed worker are claimed again once their lease expires.
+func (ws *WebhookService) ProcessPending(ctx context.Context) (int, error) {
+	rules, err := ws.enabledRules(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("load webhook rules: %w", err)
+	}
+
+	now := ws.now().UTC()
+	records, err := ws.store.ClaimEvents(ctx, ws.opts.BatchSize, now, now.Add(ws.opts.Lease))
+	if err != nil {
+		return 0, err
+	}
 
 	processed := 0
-	for i := range ws.events {
-		if !ws.events[i].Processed && ws.events[i].Status == "received" {
-			// Simulate webhook processing
-			ws.events[i].Status = "processing"
-
-			// Process the webhook (placeholder logic)
-			success := ws.processWebhookEvent(&ws.events[i])
-
-			if success {
-				ws.events[i].Status = "completed"
-				ws.events[i].Processed = true
-				processed++
-			} else {
-				ws.events[i].Status = "failed"
-				ws.events[i].Error = "Processing failed"
-			}
+	for _, record := range records {
+		event := eventFromRecord(record)
+		record.Attempts++
+		record.LockedUntil = nil
+		if err := ws.processWebhookEvent(ctx, &event, rules); err != nil {
+			gl.Log("warn", "Webhook event failed", "id", record.ID, "error", err)
+			record.Status = svc.WebhookEventFailed
+			record.Error = err.Error()
+		} else {
+			finished := ws.now().UTC()
+			record.Status = svc.WebhookEventCompleted
+			record.Error = ""
+			record.ProcessedAt = &finished
+			processed++
+		}
+		if err := ws.store.UpdateEvent(ctx, &record); err != nil {
+			return processed, err
 		}
 	}
 
 	if processed > 0 {
 		gl.Log("info", "Processed webhook events", "count", processed)
 	}
+	return processed, nil
 }
 
-// processWebhookEvent processes a single webhook event
-func (ws *WebhookService) processWebhookEvent(event *WebhookEvent) bool {
-	gl.Log("info", "Processing webhook event", "id", event.ID.String(), "source", event.Source, "type", event.EventType)
-
-	// Simulate processing based on event type
-	switch event.EventType {
-	case "github.push":
-		return ws.processGitHubPush(event)
-	case "discord.message":
-		return ws.processDiscordMessage(event)
-	case "stripe.payment":
-		return ws.processStripePayment(event)
-	case "user.created":
-		return ws.processUserCreated(event)
-	default:
-		gl.Log("info", "Generic webhook processing", "type", event.EventType)
-		return true // Default to success for unknown types
+// Purge deletes the finished events older than the retention period.
+func (ws *WebhookService) Purge(ctx context.Context) (int64, error) {
+	if ws.opts.Retention <= 0 {
+		return 0, nil
 	}
-}
-
-// processGitHubPush handles GitHub push events
-func (ws *WebhookService) processGitHubPush(event *WebhookEvent) bool {
-	gl.Log("info", "Processing GitHub push webhook", "repo", event.Payload["repository"])
-
-	// Publish notification about the push
-	if ws.amqp != nil && ws.amqp.IsReady() {
-		notification := map[string]interface{}{
-			"type":       "github_push",
-			"repository": event.Payload["repository"],
-			"commits":    event.Payload["commits"],
-			"timestamp":  time.Now(),
-		}
-
-		notifBytes, _ := json.Marshal(notification)
-		ws.amqp.Publish("gobe.notifications", "", notifBytes)
+	purged, err := ws.store.PurgeEvents(ctx, ws.now().UTC().Add(-ws.opts.Retention))
+	if purged > 0 {
+		gl.Log("info", "Purged webhook events", "count", purged)
 	}
-
-	return true
-}
-
-// processDiscordMessage handles Discord message events
-func (ws *WebhookService) processDiscordMessage(event *WebhookEvent) bool {
-	gl.Log("info", "Processing Discord message webhook", "channel", event.Payload["channel_id"])
-
-	// Could trigger bot responses or logging
-	return true
-}
-
-// processStripePayment handles Stripe payment events
-func (ws *WebhookService) processStripePayment(event *WebhookEvent) bool {
-	gl.Log("info", "Processing Stripe payment webhook", "amount", event.Payload["amount"])
-
-	// Update user billing, send emails, etc.
-	return true
-}
-
-// processUserCreated handles user creation events
-func (ws *WebhookService) processUserCreated(event *WebhookEvent) bool {
-	gl.Log("info", "Processing user created webhook", "user_id", event.Payload["user_id"])
-
-	// Send welcome email, create profile, etc.
-	return true
+	return purged, err
 }
 
 // GetStats returns webhook service statistics
 func (ws *WebhookService) GetStats() map[string]interface{} {
-	ws.mu.RLock()
-	defer ws.mu.RUnlock()
-
-	var totalEvents, processedEvents, failedEvents, pendingEvents int
+	counts, err := ws.store.CountByStatus(ws.ctx)
+	if err != nil {
+		gl.Log("error", "Failed to count webhook events", err)
+	}
 
-	for _, event := range ws.events {
-		totalEvents++
-		if event.Processed {
-			processedEvents++
-		}
-		if event.Status == "failed" {
-			failedEvents++
-		}
-		if event.Status == "received" {
-			pendingEvents++
-		}
+	var totalEvents int64
+	for _, count := range counts {
+		totalEvents += count
 	}
 
 	return map[string]interface{}{
 		"total_events":     totalEvents,
-		"processed_events": processedEvents,
-		"failed_events":    failedEvents,
-		"pending_events":   pendingEvents,
+		"processed_events": counts[svc.WebhookEventCompleted],
+		"failed_events":    counts[svc.WebhookEventFailed],
+		"rejected_events":  counts[svc.WebhookEventRejected],
+		"pending_events":   counts[svc.WebhookEventReceived] + counts[svc.WebhookEventProcessing],
 		"uptime_seconds":   time.Since(ws.startTime).Seconds(),
-		"amqp_connected":   ws.amqp != nil && ws.amqp.IsReady(),
+		"amqp_connected":   ws.broker != nil && ws.broker.IsReady(),
+		"retention_days":   ws.opts.Retention.Hours() / 24,
 		"last_updated":     time.Now().Unix(),
 	}
 }
@@ -294,19 +452,75 @@ func (ws *WebhookService) Close() error {
 
 // RetryFailedWebhooks retries all failed webhook events
 func (ws *WebhookService) RetryFailedWebhooks() (int, error) {
-	ws.mu.Lock()
-	defer ws.mu.Unlock()
-
-	retried := 0
-	for i := range ws.events {
-		if ws.events[i].Status == "failed" {
-			ws.events[i].Status = "received"
-			ws.events[i].Processed = false
-			ws.events[i].Error = ""
-			retried++
-		}
+	retried, err := ws.store.RetryFailed(ws.ctx)
+	if err != nil {
+		return 0, err
 	}
 
 	gl.Log("info", "Retried failed webhook events", "count", retried)
-	return retried, nil
+	return int(retried), nil
+}
+
+func (e WebhookEvent) record() (*svc.WebhookEventRecord, error) {
+	payload, err := json.Marshal(e.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("encode webhook payload: %w", err)
+	}
+	headers, err := json.Marshal(e.Headers)
+	if err != nil {
+		return nil, fmt.Errorf("encode webhook headers: %w", err)
+	}
+	return &svc.WebhookEventRecord{
+		ID:         e.ID.String(),
+		Source:     e.Source,
+		EventType:  e.EventType,
+		Payload:    string(payload),
+		Headers:    string(headers),
+		Status:     e.Status,
+		Error:      e.Error,
+		Attempts:   e.Attempts,
+		ReceivedAt: e.Timestamp,
+	}, nil
+}
+
+func eventFromRecord(record svc.WebhookEventRecord) WebhookEvent {
+	id, _ := uuid.Parse(record.ID)
+	event := WebhookEvent{
+		ID:        id,
+		Source:    record.Source,
+		EventType: record.EventType,
+		Timestamp: record.ReceivedAt,
+		Processed: record.Status == svc.WebhookEventCompleted,
+		Status:    record.Status,
+		Error:     record.Error,
+		Attempts:  record.Attempts,
+	}
+	if err := json.Unmarshal([]byte(record.Payload), &event.Payload); err != nil {
+		gl.Log("warn", "Undecodable webhook payload", record.ID, err)
+	}
+	if err := json.Unmarshal([]byte(record.Headers), &event.Headers); err != nil {
+		gl.Log("warn", "Undecodable webhook headers", record.ID, err)
+	}
+	return event
+}
+
+// encodeCursor points after the event received at ts with id.
+func encodeCursor(ts time.Time, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(ts.UnixNano(), 10) + ":" + id))
+}
+
+func decodeCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	ts, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	return time.Unix(0, ts).UTC(), id, nil
 }
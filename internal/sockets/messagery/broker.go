@@ -0,0 +1,139 @@
+package messagery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/kubex-ecosystem/gobe/internal/config"
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+)
+
+// Kinds of broker, as set in config.BrokerConfig.
+const (
+	BrokerMemory = "memory"
+	BrokerAMQP   = "amqp"
+	BrokerNATS   = "nats"
+)
+
+// Broker carries the messages of gobe with AMQP semantics (exchanges,
+// queues and bindings), whatever the server behind it. Consumers read it
+// through the Channel it opens.
+type Broker interface {
+	ChannelSource
+	// Kind is BrokerMemory, BrokerAMQP or BrokerNATS.
+	Kind() string
+	// Start connects in the background and declares DefaultTopology,
+	// again after each reconnection. Calls after the first do nothing.
+	Start(ctx context.Context) error
+	// Publish sends body to exchange with routing key key.
+	Publish(exchange, key string, body []byte) error
+	// Reply sends body to the reply_to queue of d with its correlation ID.
+	Reply(d Delivery, body []byte) error
+	ConnectionStats() map[string]interface{}
+	Close() error
+}
+
+// NewBroker returns the broker selected by cfg, not started. The "amqp"
+// broker reads RabbitMQ from dbConfig.
+func NewBroker(cfg config.BrokerConfig, dbConfig *DBConfig) (Broker, error) {
+	switch cfg.Kind {
+	case "", BrokerMemory:
+		return NewMemoryBroker(cfg.MemoryMaxLength), nil
+	case BrokerAMQP:
+		if dbConfig == nil {
+			return nil, errors.New("amqp broker needs the database config")
+		}
+		url := GetRabbitMQURL(dbConfig)
+		if url == "" {
+			return nil, errors.New("rabbitmq is not configured")
+		}
+		a := NewAMQP()
+		a.URL = url
+		return a, nil
+	case BrokerNATS:
+		return NewNATSBroker(cfg.NATS), nil
+	default:
+		return nil, fmt.Errorf("unknown broker %q", cfg.Kind)
+	}
+}
+
+// Topology is a set of exchanges, queues and bindings, all durable.
+type Topology struct {
+	Exchanges []ExchangeSpec
+	Queues    []QueueSpec
+	Bindings  []BindingSpec
+}
+
+// ExchangeSpec declares an exchange of kind "direct", "topic" or "fanout".
+type ExchangeSpec struct {
+	Name string
+	Kind string
+}
+
+// QueueSpec declares a queue; Args takes the AMQP queue arguments brokers
+// support: x-message-ttl, x-dead-letter-exchange and
+// x-dead-letter-routing-key.
+type QueueSpec struct {
+	Name string
+	Args amqp.Table
+}
+
+// BindingSpec routes the messages of Exchange matching Key to Queue.
+type BindingSpec struct {
+	Queue    string
+	Exchange string
+	Key      string
+}
+
+// DefaultTopology is declared by every broker when it connects.
+var DefaultTopology = Topology{
+	Exchanges: []ExchangeSpec{
+		{Name: "gobe.events", Kind: "topic"},
+		{Name: "gobe.logs", Kind: "direct"},
+		{Name: "gobe.notifications", Kind: "fanout"},
+		{Name: DeadLetterExchange, Kind: "direct"},
+	},
+	Queues: []QueueSpec{
+		{Name: "gobe.system.logs"},
+		{Name: "gobe.system.events"},
+		{Name: "gobe.mcp.tasks"},
+	},
+	Bindings: []BindingSpec{
+		{Queue: "gobe.system.logs", Exchange: "gobe.logs", Key: "system"},
+		{Queue: "gobe.system.events", Exchange: "gobe.events", Key: "system.*"},
+		{Queue: "gobe.mcp.tasks", Exchange: "gobe.events", Key: "mcp.task.*"},
+	},
+}
+
+// TopologyDeclarer declares exchanges, queues and bindings on a broker.
+type TopologyDeclarer interface {
+	DeclareExchange(name, kind string) error
+	DeclareQueue(name string, args amqp.Table) error
+	BindQueue(name, key, exchange string) error
+}
+
+// DeclareTopology declares topo through d, exchanges first.
+func DeclareTopology(d TopologyDeclarer, topo Topology) error {
+	for _, exchange := range topo.Exchanges {
+		if err := d.DeclareExchange(exchange.Name, exchange.Kind); err != nil {
+			return fmt.Errorf("failed to declare exchange %s: %w", exchange.Name, err)
+		}
+		gl.Log("debug", "Declared exchange", exchange.Name, exchange.Kind)
+	}
+	for _, queue := range topo.Queues {
+		if err := d.DeclareQueue(queue.Name, queue.Args); err != nil {
+			return fmt.Errorf("failed to declare queue %s: %w", queue.Name, err)
+		}
+		gl.Log("debug", "Declared queue", queue.Name)
+	}
+	for _, binding := range topo.Bindings {
+		if err := d.BindQueue(binding.Queue, binding.Key, binding.Exchange); err != nil {
+			return fmt.Errorf("failed to bind queue %s to exchange %s: %w", binding.Queue, binding.Exchange, err)
+		}
+		gl.Log("debug", "Bound queue", binding.Queue, "to exchange", binding.Exchange, "with key", binding.Key)
+	}
+	return nil
+}
@@ -0,0 +1,669 @@
+package messagery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/kubex-ecosystem/gobe/internal/config"
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+	"github.com/kubex-ecosystem/gobe/internal/utils/backoff"
+)
+
+// DeadLetterExchange receives the messages consumers gave up on, routed by
+// the name of their queue. Each consumed queue has a "<queue>.dlq" bound to
+// it.
+const DeadLetterExchange = "gobe.dlx"
+
+// Headers set on the messages a consumer retries or dead-letters.
+const (
+	HeaderAttempts = "x-gobe-attempts"
+	HeaderError    = "x-gobe-error"
+	HeaderQueue    = "x-gobe-queue"
+	// HeaderRoutingKey keeps the routing key the message was first
+	// published with; handlers see it again on retries.
+	HeaderRoutingKey = "x-gobe-routing-key"
+)
+
+// DefaultDrainTimeout is how long Shutdown lets a consumer finish the
+// messages it holds when neither its context nor DrainTimeout bounds it.
+const DefaultDrainTimeout = 30 * time.Second
+
+// ErrPermanent marks failures retrying cannot fix: the message goes
+// straight to the dead-letter queue.
+var ErrPermanent = errors.New("permanent failure")
+
+// Permanent wraps err so the consumer dead-letters the message at once.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrPermanent, err)
+}
+
+// Delivery is a message received from a queue.
+type Delivery = amqp.Delivery
+
+// Handler processes a delivery. The consumer acknowledges it when the
+// handler returns nil and retries or dead-letters it otherwise; handlers
+// never ack themselves.
+type Handler func(ctx context.Context, d Delivery) error
+
+// JSONHandler decodes the body of each delivery into a T before calling fn.
+// Bodies that do not decode are dead-lettered without retries.
+func JSONHandler[T any](fn func(ctx context.Context, msg T, d Delivery) error) Handler {
+	return func(ctx context.Context, d Delivery) error {
+		var msg T
+		if err := json.Unmarshal(d.Body, &msg); err != nil {
+			return Permanent(fmt.Errorf("decode %T: %w", msg, err))
+		}
+		return fn(ctx, msg, d)
+	}
+}
+
+type attemptCtxKey struct{}
+
+type attemptInfo struct {
+	attempt, total int
+}
+
+// Attempt returns which attempt at its message a handler is running (1 for
+// the first) and how many the consumer makes, or zeros outside a consumer.
+func Attempt(ctx context.Context) (attempt, total int) {
+	info, _ := ctx.Value(attemptCtxKey{}).(attemptInfo)
+	return info.attempt, info.total
+}
+
+// FinalAttempt reports whether a failure of the running handler
+// dead-letters its message.
+func FinalAttempt(ctx context.Context) bool {
+	attempt, total := Attempt(ctx)
+	return attempt > 0 && attempt >= total
+}
+
+// ConsumerOptions tunes the consumer of a queue.
+type ConsumerOptions struct {
+	// Prefetch is how many unacknowledged messages the broker sends ahead.
+	Prefetch int
+	// Concurrency is the number of workers handling messages.
+	Concurrency int
+	// MaxAttempts counts the first delivery; the last failure dead-letters.
+	MaxAttempts   int
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+}
+
+// DefaultConsumerOptions tries a message 5 times over about a minute.
+var DefaultConsumerOptions = ConsumerOptions{
+	Prefetch:      10,
+	Concurrency:   4,
+	MaxAttempts:   5,
+	RetryDelay:    5 * time.Second,
+	MaxRetryDelay: 5 * time.Minute,
+}
+
+// ConsumerOptionsFromConfig returns the options of queue: its entry under
+// cfg.Queues, then cfg.Consumer, then DefaultConsumerOptions.
+func ConsumerOptionsFromConfig(cfg config.AMQPConfig, queue string) ConsumerOptions {
+	opts := DefaultConsumerOptions
+	for _, c := range []config.AMQPConsumerConfig{cfg.Consumer, cfg.Queues[queue]} {
+		if c.Prefetch > 0 {
+			opts.Prefetch = c.Prefetch
+		}
+		if c.Concurrency > 0 {
+			opts.Concurrency = c.Concurrency
+		}
+		if c.MaxAttempts > 0 {
+			opts.MaxAttempts = c.MaxAttempts
+		}
+		if c.RetryDelaySeconds > 0 {
+			opts.RetryDelay = time.Duration(c.RetryDelaySeconds) * time.Second
+		}
+		if c.MaxRetryDelaySeconds > 0 {
+			opts.MaxRetryDelay = time.Duration(c.MaxRetryDelaySeconds) * time.Second
+		}
+	}
+	return opts
+}
+
+// Delay returns the pause after the given failed attempt (1 for the first),
+// doubling from RetryDelay up to MaxRetryDelay.
+func (o ConsumerOptions) Delay(attempt int) time.Duration {
+	return backoff.Delay(attempt, o.RetryDelay, o.MaxRetryDelay)
+}
+
+func (o ConsumerOptions) withDefaults() ConsumerOptions {
+	if o.Prefetch <= 0 {
+		o.Prefetch = DefaultConsumerOptions.Prefetch
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = DefaultConsumerOptions.Concurrency
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = DefaultConsumerOptions.MaxAttempts
+	}
+	if o.RetryDelay <= 0 {
+		o.RetryDelay = DefaultConsumerOptions.RetryDelay
+	}
+	return o
+}
+
+// Channel is what a consumer needs from an AMQP channel. Publish returns
+// once the broker confirmed the message.
+type Channel interface {
+	TopologyDeclarer
+	Qos(prefetch int) error
+	Consume(queue, tag string) (<-chan Delivery, error)
+	Cancel(tag string) error
+	Publish(ctx context.Context, exchange, key string, msg amqp.Publishing) error
+	Close() error
+}
+
+// ChannelSource opens consumer channels; every Broker is one.
+type ChannelSource interface {
+	IsReady() bool
+	ConsumerChannel() (Channel, error)
+}
+
+// QueueStats reports the consumer of a queue.
+type QueueStats struct {
+	Queue        string     `json:"queue"`
+	Running      bool       `json:"running"`
+	Prefetch     int        `json:"prefetch"`
+	Concurrency  int        `json:"concurrency"`
+	MaxAttempts  int        `json:"max_attempts"`
+	InFlight     int64      `json:"in_flight"`
+	Received     uint64     `json:"received"`
+	Succeeded    uint64     `json:"succeeded"`
+	Retried      uint64     `json:"retried"`
+	DeadLettered uint64     `json:"dead_lettered"`
+	LastError    string     `json:"last_error,omitempty"`
+	LastErrorAt  *time.Time `json:"last_error_at,omitempty"`
+}
+
+// Consumer consumes queues with their registered handlers, retrying failed
+// messages through TTL queues ("<queue>.retry.<delay>", which dead-letter
+// back into the queue) and dead-lettering them to DeadLetterExchange once
+// their attempts are exhausted. It consumes again after the connection
+// comes back.
+type Consumer struct {
+	source ChannelSource
+	// ReadyPoll is how often a consumer checks whether the connection is
+	// back.
+	ReadyPoll time.Duration
+	// DrainTimeout bounds Shutdown when its context has no deadline.
+	DrainTimeout time.Duration
+
+	mu      sync.Mutex
+	queues  map[string]*queueConsumer
+	started bool
+
+	// ctx stops consuming; work stops the handlers once draining timed out
+	ctx        context.Context
+	cancel     context.CancelFunc
+	work       context.Context
+	cancelWork context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+type queueConsumer struct {
+	name    string
+	tag     string
+	opts    ConsumerOptions
+	handler Handler
+
+	running      atomic.Bool
+	inFlight     atomic.Int64
+	received     atomic.Uint64
+	succeeded    atomic.Uint64
+	retried      atomic.Uint64
+	deadLettered atomic.Uint64
+
+	mu          sync.Mutex
+	lastError   string
+	lastErrorAt time.Time
+}
+
+// NewConsumer returns a consumer reading from the channels of source.
+func NewConsumer(source ChannelSource) *Consumer {
+	ctx, cancel := context.WithCancel(context.Background())
+	work, cancelWork := context.WithCancel(context.Background())
+	return &Consumer{
+		source:       source,
+		ReadyPoll:    time.Second,
+		DrainTimeout: DefaultDrainTimeout,
+		queues:       make(map[string]*queueConsumer),
+		ctx:          ctx,
+		cancel:       cancel,
+		work:         work,
+		cancelWork:   cancelWork,
+	}
+}
+
+// Handle consumes queue with handler once the consumer starts.
+func (c *Consumer) Handle(queue string, opts ConsumerOptions, handler Handler) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.started {
+		return fmt.Errorf("consumer already started, cannot handle %s", queue)
+	}
+	if _, exists := c.queues[queue]; exists {
+		return fmt.Errorf("queue %s already has a handler", queue)
+	}
+	c.queues[queue] = &queueConsumer{
+		name:    queue,
+		tag:     fmt.Sprintf("gobe.%s.%d", queue, time.Now().UnixNano()),
+		opts:    opts.withDefaults(),
+		handler: handler,
+	}
+	return nil
+}
+
+// Start consumes every handled queue in the background.
+func (c *Consumer) Start() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.started {
+		return errors.New("consumer already started")
+	}
+	if c.ctx.Err() != nil {
+		return errors.New("consumer shut down")
+	}
+	c.started = true
+	for _, q := range c.queues {
+		c.wg.Add(1)
+		go c.supervise(q)
+	}
+	return nil
+}
+
+// Shutdown stops consuming and waits for the messages being handled,
+// including those already prefetched, until ctx is done (or DrainTimeout
+// without a deadline). Messages left unacknowledged then are redelivered by
+// the broker.
+func (c *Consumer) Shutdown(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); !ok {
+		timeout := c.DrainTimeout
+		if timeout <= 0 {
+			timeout = DefaultDrainTimeout
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	c.cancel()
+
+	drained := make(chan struct{})
+	go func() {
+		c.wg.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		c.cancelWork()
+		return nil
+	case <-ctx.Done():
+		c.cancelWork()
+		<-drained
+		return fmt.Errorf("amqp consumers not drained: %w", ctx.Err())
+	}
+}
+
+// Stats returns the counters of every handled queue, sorted by name.
+func (c *Consumer) Stats() []QueueStats {
+	c.mu.Lock()
+	queues := make([]*queueConsumer, 0, len(c.queues))
+	for _, q := range c.queues {
+		queues = append(queues, q)
+	}
+	c.mu.Unlock()
+
+	stats := make([]QueueStats, 0, len(queues))
+	for _, q := range queues {
+		stats = append(stats, q.stats())
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Queue < stats[j].Queue })
+	return stats
+}
+
+// supervise consumes q until shutdown, again each time the channel closes.
+func (c *Consumer) supervise(q *queueConsumer) {
+	defer c.wg.Done()
+	for {
+		if c.ctx.Err() != nil {
+			return
+		}
+		if c.source.IsReady() {
+			if err := c.consume(q); err != nil {
+				q.recordError(err)
+				gl.Log("warn", fmt.Sprintf("AMQP consumer of %s stopped", q.name), err)
+			}
+		}
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-time.After(c.ReadyPoll):
+		}
+	}
+}
+
+// consume runs the workers of q on a new channel until its deliveries end.
+func (c *Consumer) consume(q *queueConsumer) error {
+	ch, err := c.source.ConsumerChannel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	if err := declareRetryTopology(ch, q); err != nil {
+		return err
+	}
+	if err := ch.Qos(q.opts.Prefetch); err != nil {
+		return fmt.Errorf("failed to set prefetch of %s: %w", q.name, err)
+	}
+	deliveries, err := ch.Consume(q.name, q.tag)
+	if err != nil {
+		return fmt.Errorf("failed to consume %s: %w", q.name, err)
+	}
+	gl.Log("info", fmt.Sprintf("Consuming AMQP queue %s (prefetch %d, %d workers)", q.name, q.opts.Prefetch, q.opts.Concurrency))
+
+	// On shutdown the broker stops sending and closes deliveries once the
+	// prefetched messages are read
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-c.ctx.Done():
+			if err := ch.Cancel(q.tag); err != nil {
+				gl.Log("warn", fmt.Sprintf("Failed to cancel AMQP consumer of %s", q.name), err)
+			}
+		case <-stop:
+		}
+	}()
+
+	q.running.Store(true)
+	defer q.running.Store(false)
+	var workers sync.WaitGroup
+	for i := 0; i < q.opts.Concurrency; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for d := range deliveries {
+				c.process(ch, q, d)
+			}
+		}()
+	}
+	workers.Wait()
+
+	if c.ctx.Err() != nil {
+		return nil
+	}
+	return fmt.Errorf("deliveries of %s closed", q.name)
+}
+
+// declareRetryTopology declares q, its dead-letter queue and one TTL queue
+// per retry delay.
+func declareRetryTopology(ch Channel, q *queueConsumer) error {
+	if err := ch.DeclareQueue(q.name, nil); err != nil {
+		return fmt.Errorf("failed to declare queue %s: %w", q.name, err)
+	}
+	if err := ch.DeclareExchange(DeadLetterExchange, "direct"); err != nil {
+		return fmt.Errorf("failed to declare exchange %s: %w", DeadLetterExchange, err)
+	}
+	dlq := q.name + ".dlq"
+	if err := ch.DeclareQueue(dlq, nil); err != nil {
+		return fmt.Errorf("failed to declare queue %s: %w", dlq, err)
+	}
+	if err := ch.BindQueue(dlq, q.name, DeadLetterExchange); err != nil {
+		return fmt.Errorf("failed to bind queue %s: %w", dlq, err)
+	}
+
+	declared := make(map[string]bool)
+	for attempt := 1; attempt < q.opts.MaxAttempts; attempt++ {
+		delay := q.opts.Delay(attempt)
+		name := retryQueue(q.name, delay)
+		if declared[name] {
+			continue
+		}
+		declared[name] = true
+		err := ch.DeclareQueue(name, amqp.Table{
+			"x-message-ttl":             delay.Milliseconds(),
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": q.name,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to declare queue %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// retryQueue names the TTL queue holding messages of queue for delay. The
+// delay is part of the name since the TTL of a queue cannot change.
+func retryQueue(queue string, delay time.Duration) string {
+	return fmt.Sprintf("%s.retry.%s", queue, delay)
+}
+
+// process handles d and then acknowledges it, once a failed message was
+// safely moved to its retry or dead-letter queue.
+func (c *Consumer) process(ch Channel, q *queueConsumer, d Delivery) {
+	q.received.Add(1)
+	q.inFlight.Add(1)
+	defer q.inFlight.Add(-1)
+
+	if key, ok := d.Headers[HeaderRoutingKey].(string); ok && key != "" {
+		d.RoutingKey = key
+	}
+	attempts := attemptsOf(d) + 1
+	ctx := context.WithValue(c.work, attemptCtxKey{}, attemptInfo{attempt: attempts, total: q.opts.MaxAttempts})
+	err := q.call(ctx, d)
+	if err == nil {
+		q.succeeded.Add(1)
+		if ackErr := d.Ack(false); ackErr != nil {
+			gl.Log("warn", fmt.Sprintf("Failed to ack message of %s", q.name), ackErr)
+		}
+		return
+	}
+	q.recordError(err)
+
+	exchange, key := DeadLetterExchange, q.name
+	retry := !errors.Is(err, ErrPermanent) && attempts < q.opts.MaxAttempts
+	if retry {
+		exchange, key = "", retryQueue(q.name, q.opts.Delay(attempts))
+	}
+
+	// The move must not be abandoned because the consumer is shutting down
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if pubErr := ch.Publish(ctx, exchange, key, republishing(d, q.name, attempts, err)); pubErr != nil {
+		gl.Log("error", fmt.Sprintf("Failed to move failed message of %s, requeueing it", q.name), pubErr)
+		_ = d.Nack(false, true)
+		return
+	}
+	if retry {
+		q.retried.Add(1)
+		gl.Log("warn", fmt.Sprintf("Message of %s failed (attempt %d of %d), retrying in %s", q.name, attempts, q.opts.MaxAttempts, q.opts.Delay(attempts)), err)
+	} else {
+		q.deadLettered.Add(1)
+		gl.Log("error", fmt.Sprintf("Message of %s dead-lettered after %d attempts", q.name, attempts), err)
+	}
+	if ackErr := d.Ack(false); ackErr != nil {
+		gl.Log("warn", fmt.Sprintf("Failed to ack message of %s", q.name), ackErr)
+	}
+}
+
+// call runs the handler, turning a panic into an error.
+func (q *queueConsumer) call(ctx context.Context, d Delivery) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panic: %v", r)
+		}
+	}()
+	return q.handler(ctx, d)
+}
+
+// attemptsOf returns how many times d already failed.
+func attemptsOf(d Delivery) int {
+	switch n := d.Headers[HeaderAttempts].(type) {
+	case int32:
+		return int(n)
+	case int64:
+		return int(n)
+	case int:
+		return n
+	case string:
+		// brokers without typed headers
+		attempts, _ := strconv.Atoi(n)
+		return attempts
+	}
+	return 0
+}
+
+// republishing copies d with the attempts so far and the last error.
+func republishing(d Delivery, queue string, attempts int, err error) amqp.Publishing {
+	headers := amqp.Table{}
+	for k, v := range d.Headers {
+		headers[k] = v
+	}
+	headers[HeaderAttempts] = int32(attempts)
+	headers[HeaderError] = err.Error()
+	headers[HeaderQueue] = queue
+	headers[HeaderRoutingKey] = d.RoutingKey
+	return amqp.Publishing{
+		Headers:         headers,
+		ContentType:     d.ContentType,
+		ContentEncoding: d.ContentEncoding,
+		DeliveryMode:    amqp.Persistent,
+		Priority:        d.Priority,
+		CorrelationId:   d.CorrelationId,
+		ReplyTo:         d.ReplyTo,
+		MessageId:       d.MessageId,
+		Timestamp:       d.Timestamp,
+		Type:            d.Type,
+		AppId:           d.AppId,
+		Body:            d.Body,
+	}
+}
+
+func (q *queueConsumer) recordError(err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.lastError = err.Error()
+	q.lastErrorAt = time.Now()
+}
+
+func (q *queueConsumer) stats() QueueStats {
+	stats := QueueStats{
+		Queue:        q.name,
+		Running:      q.running.Load(),
+		Prefetch:     q.opts.Prefetch,
+		Concurrency:  q.opts.Concurrency,
+		MaxAttempts:  q.opts.MaxAttempts,
+		InFlight:     q.inFlight.Load(),
+		Received:     q.received.Load(),
+		Succeeded:    q.succeeded.Load(),
+		Retried:      q.retried.Load(),
+		DeadLettered: q.deadLettered.Load(),
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.lastError != "" {
+		at := q.lastErrorAt
+		stats.LastError = q.lastError
+		stats.LastErrorAt = &at
+	}
+	return stats
+}
+
+// ConsumerChannel opens a channel in confirm mode for a consumer.
+func (a *AMQP) ConsumerChannel() (Channel, error) {
+	a.mu.RLock()
+	conn := a.Conn
+	a.mu.RUnlock()
+	if conn == nil || conn.IsClosed() {
+		return nil, errors.New("amqp connection is not open")
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open amqp channel: %w", err)
+	}
+	if err := ch.Confirm(false); err != nil {
+		_ = ch.Close()
+		return nil, fmt.Errorf("failed to set confirm mode: %w", err)
+	}
+	return &amqpChannel{ch: ch}, nil
+}
+
+// Reply publishes body to the reply_to queue of d with its correlation ID,
+// for request/reply over AMQP.
+func (a *AMQP) Reply(d Delivery, body []byte) error {
+	if d.ReplyTo == "" {
+		return nil
+	}
+	if !a.ready.Load() {
+		return errors.New("amqp not ready")
+	}
+	a.mu.RLock()
+	channel := a.Chan
+	a.mu.RUnlock()
+	if channel == nil {
+		return errors.New("amqp channel is nil")
+	}
+	return channel.Publish("", d.ReplyTo, false, false, amqp.Publishing{
+		ContentType:   "application/json",
+		CorrelationId: d.CorrelationId,
+		Timestamp:     time.Now(),
+		Body:          body,
+	})
+}
+
+// amqpChannel adapts *amqp.Channel to Channel; Publish needs confirm mode.
+type amqpChannel struct {
+	ch *amqp.Channel
+}
+
+func (c *amqpChannel) Qos(prefetch int) error { return c.ch.Qos(prefetch, 0, false) }
+
+func (c *amqpChannel) Consume(queue, tag string) (<-chan Delivery, error) {
+	return c.ch.Consume(queue, tag, false, false, false, false, nil)
+}
+
+func (c *amqpChannel) Cancel(tag string) error { return c.ch.Cancel(tag, false) }
+
+func (c *amqpChannel) DeclareExchange(name, kind string) error {
+	return c.ch.ExchangeDeclare(name, kind, true, false, false, false, nil)
+}
+
+func (c *amqpChannel) DeclareQueue(name string, args amqp.Table) error {
+	_, err := c.ch.QueueDeclare(name, true, false, false, false, args)
+	return err
+}
+
+func (c *amqpChannel) BindQueue(name, key, exchange string) error {
+	return c.ch.QueueBind(name, key, exchange, false, nil)
+}
+
+func (c *amqpChannel) Publish(ctx context.Context, exchange, key string, msg amqp.Publishing) error {
+	confirmation, err := c.ch.PublishWithDeferredConfirmWithContext(ctx, exchange, key, false, false, msg)
+	if err != nil {
+		return err
+	}
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return errors.New("publish nack received")
+	}
+	return nil
+}
+
+func (c *amqpChannel) Close() error { return c.ch.Close() }
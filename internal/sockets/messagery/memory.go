@@ -0,0 +1,633 @@
+package messagery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+)
+
+// DefaultMemoryMaxLength caps each queue of the in-process broker.
+const DefaultMemoryMaxLength = 10000
+
+// memoryMaxPrefetch bounds the deliveries of a consumer without Qos.
+const memoryMaxPrefetch = 1000
+
+// ErrBrokerClosed is returned by a broker, or a channel, after Close.
+var ErrBrokerClosed = errors.New("broker closed")
+
+// MemoryBroker is the in-process broker, for zero-config and single-binary
+// deployments. It routes like RabbitMQ (default, direct, topic and fanout
+// exchanges) and honours message TTLs and dead-lettering, but messages only
+// live in memory: they are lost on restart, and a full queue drops its
+// oldest message.
+type MemoryBroker struct {
+	maxLength int
+
+	mu        sync.Mutex
+	exchanges map[string]string
+	queues    map[string]*memoryQueue
+	bindings  map[string][]BindingSpec
+	started   bool
+	closed    bool
+	tag       uint64
+
+	published  atomic.Uint64
+	unroutable atomic.Uint64
+	dropped    atomic.Uint64
+}
+
+type memoryQueue struct {
+	name       string
+	ttl        time.Duration
+	deadLetter bool
+	dlx        string
+	dlrk       string
+	messages   []*memoryMessage
+	consumers  []*memoryConsumer
+	next       int
+}
+
+type memoryMessage struct {
+	exchange    string
+	key         string
+	msg         amqp.Publishing
+	redelivered bool
+	timer       *time.Timer
+}
+
+type memoryConsumer struct {
+	channel *memoryChannel
+	queue   *memoryQueue
+	tag     string
+	out     chan Delivery
+	unacked int
+}
+
+// memoryChannel is a channel of a MemoryBroker; its state is guarded by
+// the broker lock.
+type memoryChannel struct {
+	broker    *MemoryBroker
+	prefetch  int
+	consumers map[string]*memoryConsumer
+	unacked   map[uint64]memoryUnacked
+	closed    bool
+}
+
+type memoryUnacked struct {
+	consumer *memoryConsumer
+	message  *memoryMessage
+}
+
+// NewMemoryBroker returns an in-process broker whose queues hold up to
+// maxLength messages (DefaultMemoryMaxLength when not positive).
+func NewMemoryBroker(maxLength int) *MemoryBroker {
+	if maxLength <= 0 {
+		maxLength = DefaultMemoryMaxLength
+	}
+	return &MemoryBroker{
+		maxLength: maxLength,
+		exchanges: make(map[string]string),
+		queues:    make(map[string]*memoryQueue),
+		bindings:  make(map[string][]BindingSpec),
+	}
+}
+
+// Kind returns BrokerMemory.
+func (b *MemoryBroker) Kind() string { return BrokerMemory }
+
+// Start declares DefaultTopology.
+func (b *MemoryBroker) Start(ctx context.Context) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrBrokerClosed
+	}
+	if b.started {
+		b.mu.Unlock()
+		return nil
+	}
+	b.started = true
+	b.mu.Unlock()
+	if err := DeclareTopology(b, DefaultTopology); err != nil {
+		return err
+	}
+	gl.Log("info", "In-process message broker ready")
+	return nil
+}
+
+// IsReady reports whether the broker started and was not closed.
+func (b *MemoryBroker) IsReady() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.started && !b.closed
+}
+
+// ConsumerChannel opens a channel.
+func (b *MemoryBroker) ConsumerChannel() (Channel, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrBrokerClosed
+	}
+	return &memoryChannel{
+		broker:    b,
+		consumers: make(map[string]*memoryConsumer),
+		unacked:   make(map[uint64]memoryUnacked),
+	}, nil
+}
+
+// DeclareExchange declares a "direct", "topic" or "fanout" exchange.
+func (b *MemoryBroker) DeclareExchange(name, kind string) error {
+	switch kind {
+	case "direct", "topic", "fanout":
+	default:
+		return fmt.Errorf("unsupported exchange kind %q", kind)
+	}
+	if name == "" {
+		return errors.New("exchange name is required")
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrBrokerClosed
+	}
+	if existing, ok := b.exchanges[name]; ok && existing != kind {
+		return fmt.Errorf("exchange %s already declared as %s", name, existing)
+	}
+	b.exchanges[name] = kind
+	return nil
+}
+
+// DeclareQueue declares a queue. Declaring it again keeps its arguments.
+func (b *MemoryBroker) DeclareQueue(name string, args amqp.Table) error {
+	if name == "" {
+		return errors.New("queue name is required")
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrBrokerClosed
+	}
+	if _, ok := b.queues[name]; ok {
+		return nil
+	}
+	q := &memoryQueue{name: name}
+	switch ttl := args["x-message-ttl"].(type) {
+	case int:
+		q.ttl = time.Duration(ttl) * time.Millisecond
+	case int32:
+		q.ttl = time.Duration(ttl) * time.Millisecond
+	case int64:
+		q.ttl = time.Duration(ttl) * time.Millisecond
+	}
+	if dlx, ok := args["x-dead-letter-exchange"].(string); ok {
+		q.deadLetter = true
+		q.dlx = dlx
+		q.dlrk, _ = args["x-dead-letter-routing-key"].(string)
+	}
+	b.queues[name] = q
+	return nil
+}
+
+// BindQueue routes the messages of exchange matching key to the queue.
+func (b *MemoryBroker) BindQueue(name, key, exchange string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrBrokerClosed
+	}
+	if _, ok := b.queues[name]; !ok {
+		return fmt.Errorf("queue %s not found", name)
+	}
+	if _, ok := b.exchanges[exchange]; !ok {
+		return fmt.Errorf("exchange %s not found", exchange)
+	}
+	binding := BindingSpec{Queue: name, Exchange: exchange, Key: key}
+	for _, existing := range b.bindings[exchange] {
+		if existing == binding {
+			return nil
+		}
+	}
+	b.bindings[exchange] = append(b.bindings[exchange], binding)
+	return nil
+}
+
+// Publish sends body to exchange as persistent JSON.
+func (b *MemoryBroker) Publish(exchange, key string, body []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.publishLocked(exchange, key, amqp.Publishing{
+		DeliveryMode: amqp.Persistent,
+		ContentType:  "application/json",
+		Timestamp:    time.Now(),
+		Body:         body,
+	})
+}
+
+// Reply publishes body to the reply_to queue of d.
+func (b *MemoryBroker) Reply(d Delivery, body []byte) error {
+	if d.ReplyTo == "" {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.publishLocked("", d.ReplyTo, amqp.Publishing{
+		ContentType:   "application/json",
+		CorrelationId: d.CorrelationId,
+		Timestamp:     time.Now(),
+		Body:          body,
+	})
+}
+
+// ConnectionStats returns the counters of the broker and the depth of its
+// queues.
+func (b *MemoryBroker) ConnectionStats() map[string]interface{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	queues := make(map[string]interface{}, len(b.queues))
+	for name, q := range b.queues {
+		queues[name] = map[string]int{"messages": len(q.messages), "consumers": len(q.consumers)}
+	}
+	return map[string]interface{}{
+		"broker":     BrokerMemory,
+		"ready":      b.started && !b.closed,
+		"published":  b.published.Load(),
+		"unroutable": b.unroutable.Load(),
+		"dropped":    b.dropped.Load(),
+		"queues":     queues,
+	}
+}
+
+// Close stops every consumer and discards the queued messages.
+func (b *MemoryBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	for _, q := range b.queues {
+		for _, m := range q.messages {
+			m.stop()
+		}
+		q.messages = nil
+		for _, c := range q.consumers {
+			delete(c.channel.consumers, c.tag)
+			close(c.out)
+		}
+		q.consumers = nil
+	}
+	return nil
+}
+
+// publishLocked routes msg to the queues bound to exchange, or to the
+// queue named key on the default exchange.
+func (b *MemoryBroker) publishLocked(exchange, key string, msg amqp.Publishing) error {
+	if b.closed {
+		return ErrBrokerClosed
+	}
+	var targets []*memoryQueue
+	if exchange == "" {
+		if q, ok := b.queues[key]; ok {
+			targets = append(targets, q)
+		}
+	} else {
+		kind, ok := b.exchanges[exchange]
+		if !ok {
+			return fmt.Errorf("exchange %s not found", exchange)
+		}
+		seen := make(map[string]bool)
+		for _, binding := range b.bindings[exchange] {
+			if seen[binding.Queue] || !bindingMatches(kind, binding.Key, key) {
+				continue
+			}
+			seen[binding.Queue] = true
+			targets = append(targets, b.queues[binding.Queue])
+		}
+	}
+
+	b.published.Add(1)
+	if len(targets) == 0 {
+		b.unroutable.Add(1)
+		return nil
+	}
+	for _, q := range targets {
+		b.enqueueLocked(q, &memoryMessage{exchange: exchange, key: key, msg: msg})
+	}
+	return nil
+}
+
+func (b *MemoryBroker) enqueueLocked(q *memoryQueue, m *memoryMessage) {
+	if len(q.messages) >= b.maxLength {
+		q.messages[0].stop()
+		q.messages = q.messages[1:]
+		b.dropped.Add(1)
+	}
+	q.messages = append(q.messages, m)
+	if q.ttl > 0 {
+		m.timer = time.AfterFunc(q.ttl, func() { b.expire(q, m) })
+	}
+	b.dispatchLocked(q)
+}
+
+// expire dead-letters m if it is still waiting in q.
+func (b *MemoryBroker) expire(q *memoryQueue, m *memoryMessage) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, queued := range q.messages {
+		if queued == m {
+			q.messages = append(q.messages[:i], q.messages[i+1:]...)
+			b.deadLetterLocked(q, m)
+			return
+		}
+	}
+}
+
+// deadLetterLocked routes m to the dead-letter exchange of q, if it has one.
+func (b *MemoryBroker) deadLetterLocked(q *memoryQueue, m *memoryMessage) {
+	if !q.deadLetter || b.closed {
+		return
+	}
+	key := q.dlrk
+	if key == "" {
+		key = m.key
+	}
+	if err := b.publishLocked(q.dlx, key, m.msg); err != nil {
+		gl.Log("warn", fmt.Sprintf("Failed to dead-letter a message of %s", q.name), err)
+	}
+}
+
+// dispatchLocked hands the messages of q to its consumers, round robin,
+// while they have room under their prefetch.
+func (b *MemoryBroker) dispatchLocked(q *memoryQueue) {
+	for len(q.messages) > 0 && len(q.consumers) > 0 {
+		var target *memoryConsumer
+		for i := 0; i < len(q.consumers); i++ {
+			c := q.consumers[(q.next+i)%len(q.consumers)]
+			if c.unacked < c.limit() {
+				target = c
+				q.next = (q.next + i + 1) % len(q.consumers)
+				break
+			}
+		}
+		if target == nil {
+			return
+		}
+		m := q.messages[0]
+		q.messages = q.messages[1:]
+		m.stop()
+
+		b.tag++
+		target.unacked++
+		target.channel.unacked[b.tag] = memoryUnacked{consumer: target, message: m}
+		target.out <- Delivery{
+			Acknowledger:    target.channel,
+			Headers:         m.msg.Headers,
+			ContentType:     m.msg.ContentType,
+			ContentEncoding: m.msg.ContentEncoding,
+			DeliveryMode:    m.msg.DeliveryMode,
+			Priority:        m.msg.Priority,
+			CorrelationId:   m.msg.CorrelationId,
+			ReplyTo:         m.msg.ReplyTo,
+			Expiration:      m.msg.Expiration,
+			MessageId:       m.msg.MessageId,
+			Timestamp:       m.msg.Timestamp,
+			Type:            m.msg.Type,
+			UserId:          m.msg.UserId,
+			AppId:           m.msg.AppId,
+			ConsumerTag:     target.tag,
+			DeliveryTag:     b.tag,
+			Redelivered:     m.redelivered,
+			Exchange:        m.exchange,
+			RoutingKey:      m.key,
+			Body:            m.msg.Body,
+		}
+	}
+}
+
+// limit is how many unacknowledged messages c may hold; it never exceeds
+// the buffer of c.out, so dispatching never blocks.
+func (c *memoryConsumer) limit() int {
+	if c.channel.prefetch > 0 && c.channel.prefetch < cap(c.out) {
+		return c.channel.prefetch
+	}
+	return cap(c.out)
+}
+
+func (m *memoryMessage) stop() {
+	if m.timer != nil {
+		m.timer.Stop()
+		m.timer = nil
+	}
+}
+
+// bindingMatches reports whether a binding with pattern receives the
+// messages of key on an exchange of kind.
+func bindingMatches(kind, pattern, key string) bool {
+	switch kind {
+	case "fanout":
+		return true
+	case "topic":
+		return topicMatches(strings.Split(pattern, "."), strings.Split(key, "."))
+	default:
+		return pattern == key
+	}
+}
+
+// topicMatches matches AMQP topic words: "*" is one word and "#" zero or
+// more.
+func topicMatches(pattern, words []string) bool {
+	if len(pattern) == 0 {
+		return len(words) == 0
+	}
+	if pattern[0] == "#" {
+		for i := 0; i <= len(words); i++ {
+			if topicMatches(pattern[1:], words[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(words) == 0 || (pattern[0] != "*" && pattern[0] != words[0]) {
+		return false
+	}
+	return topicMatches(pattern[1:], words[1:])
+}
+
+func (c *memoryChannel) DeclareExchange(name, kind string) error {
+	return c.broker.DeclareExchange(name, kind)
+}
+
+func (c *memoryChannel) DeclareQueue(name string, args amqp.Table) error {
+	return c.broker.DeclareQueue(name, args)
+}
+
+func (c *memoryChannel) BindQueue(name, key, exchange string) error {
+	return c.broker.BindQueue(name, key, exchange)
+}
+
+func (c *memoryChannel) Qos(prefetch int) error {
+	c.broker.mu.Lock()
+	defer c.broker.mu.Unlock()
+	c.prefetch = prefetch
+	return nil
+}
+
+func (c *memoryChannel) Consume(queue, tag string) (<-chan Delivery, error) {
+	b := c.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c.closed || b.closed {
+		return nil, ErrBrokerClosed
+	}
+	q, ok := b.queues[queue]
+	if !ok {
+		return nil, fmt.Errorf("queue %s not found", queue)
+	}
+	if _, exists := c.consumers[tag]; exists {
+		return nil, fmt.Errorf("consumer tag %s already in use", tag)
+	}
+	size := c.prefetch
+	if size <= 0 || size > memoryMaxPrefetch {
+		size = memoryMaxPrefetch
+	}
+	consumer := &memoryConsumer{channel: c, queue: q, tag: tag, out: make(chan Delivery, size)}
+	c.consumers[tag] = consumer
+	q.consumers = append(q.consumers, consumer)
+	b.dispatchLocked(q)
+	return consumer.out, nil
+}
+
+// Cancel stops the consumer; its deliveries channel closes once the
+// messages already handed to it are read.
+func (c *memoryChannel) Cancel(tag string) error {
+	c.broker.mu.Lock()
+	defer c.broker.mu.Unlock()
+	consumer, ok := c.consumers[tag]
+	if !ok {
+		return nil
+	}
+	c.cancelLocked(consumer)
+	return nil
+}
+
+func (c *memoryChannel) cancelLocked(consumer *memoryConsumer) {
+	delete(c.consumers, consumer.tag)
+	q := consumer.queue
+	for i, other := range q.consumers {
+		if other == consumer {
+			q.consumers = append(q.consumers[:i], q.consumers[i+1:]...)
+			break
+		}
+	}
+	if len(q.consumers) > 0 {
+		q.next %= len(q.consumers)
+	}
+	close(consumer.out)
+}
+
+func (c *memoryChannel) Publish(ctx context.Context, exchange, key string, msg amqp.Publishing) error {
+	c.broker.mu.Lock()
+	defer c.broker.mu.Unlock()
+	if c.closed {
+		return ErrBrokerClosed
+	}
+	return c.broker.publishLocked(exchange, key, msg)
+}
+
+// Close cancels the consumers of the channel and requeues the messages it
+// did not acknowledge.
+func (c *memoryChannel) Close() error {
+	b := c.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	for _, consumer := range c.consumers {
+		c.cancelLocked(consumer)
+	}
+
+	tags := make([]uint64, 0, len(c.unacked))
+	for tag := range c.unacked {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i] > tags[j] })
+	requeued := make(map[*memoryQueue]bool)
+	for _, tag := range tags {
+		u := c.unacked[tag]
+		delete(c.unacked, tag)
+		if b.closed {
+			continue
+		}
+		u.message.redelivered = true
+		q := u.consumer.queue
+		q.messages = append([]*memoryMessage{u.message}, q.messages...)
+		requeued[q] = true
+	}
+	for q := range requeued {
+		b.dispatchLocked(q)
+	}
+	return nil
+}
+
+// Ack implements amqp.Acknowledger.
+func (c *memoryChannel) Ack(tag uint64, multiple bool) error {
+	c.broker.mu.Lock()
+	defer c.broker.mu.Unlock()
+	u, err := c.settleLocked(tag)
+	if err != nil {
+		return err
+	}
+	c.broker.dispatchLocked(u.consumer.queue)
+	return nil
+}
+
+// Nack implements amqp.Acknowledger: the message goes back to the head of
+// its queue, or to its dead-letter exchange.
+func (c *memoryChannel) Nack(tag uint64, multiple, requeue bool) error {
+	b := c.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	u, err := c.settleLocked(tag)
+	if err != nil {
+		return err
+	}
+	q := u.consumer.queue
+	if requeue {
+		u.message.redelivered = true
+		q.messages = append([]*memoryMessage{u.message}, q.messages...)
+	} else {
+		b.deadLetterLocked(q, u.message)
+	}
+	b.dispatchLocked(q)
+	return nil
+}
+
+// Reject implements amqp.Acknowledger.
+func (c *memoryChannel) Reject(tag uint64, requeue bool) error {
+	return c.Nack(tag, false, requeue)
+}
+
+func (c *memoryChannel) settleLocked(tag uint64) (memoryUnacked, error) {
+	if c.closed || c.broker.closed {
+		return memoryUnacked{}, ErrBrokerClosed
+	}
+	u, ok := c.unacked[tag]
+	if !ok {
+		return memoryUnacked{}, fmt.Errorf("unknown delivery tag %d", tag)
+	}
+	delete(c.unacked, tag)
+	u.consumer.unacked--
+	return u, nil
+}
@@ -0,0 +1,1060 @@
+package messagery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/kubex-ecosystem/gobe/internal/config"
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+)
+
+// DefaultNATSURL is where the "nats" broker connects when none is set.
+const DefaultNATSURL = "nats://localhost:4222"
+
+// DefaultNATSStreamPrefix names the streams and subjects of the "nats"
+// broker when the configuration sets no prefix.
+const DefaultNATSStreamPrefix = "GOBE"
+
+const (
+	// natsPullExpires is how long a pull request waits for a message.
+	natsPullExpires = 5 * time.Second
+	// natsAckWait is how long JetStream waits for an ack before
+	// redelivering; unsettled deliveries report progress before it ends.
+	natsAckWait        = 30 * time.Second
+	natsMaxAckPending  = 1000
+	natsRequestTimeout = 10 * time.Second
+	natsReconnectWait  = time.Second
+	natsMaxBackoff     = 30 * time.Second
+)
+
+// ErrNoResponders is returned when publishing to an exchange no JetStream
+// stream captures.
+var ErrNoResponders = nats.ErrNoResponders
+
+// Headers carrying the AMQP properties of a message over NATS.
+const (
+	natsHeaderExchange      = "Gobe-Exchange"
+	natsHeaderRoutingKey    = "Gobe-Routing-Key"
+	natsHeaderReplyTo       = "Gobe-Reply-To"
+	natsHeaderCorrelationID = "Gobe-Correlation-Id"
+	natsHeaderMessageID     = "Gobe-Message-Id"
+	natsHeaderType          = "Gobe-Type"
+	natsHeaderAppID         = "Gobe-App-Id"
+	natsHeaderTimestamp     = "Gobe-Timestamp"
+	natsHeaderContentType   = "Content-Type"
+)
+
+var natsNameUnsafe = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// NATSBroker carries the messages of gobe on NATS JetStream, mapping the
+// AMQP topology onto streams:
+//
+//   - each exchange is a stream capturing "<prefix>.x.<exchange>.>",
+//   - the default exchange is a stream capturing "<prefix>.q.<queue>",
+//   - each queue is a set of durable pull consumers, one on the default
+//     stream and one per binding, filtered by its routing key.
+//
+// Streams keep messages while a consumer has not acknowledged them. Queues
+// with a message TTL and a dead-letter exchange are served by the broker,
+// which dead-letters their messages once expired. Topic bindings may only
+// use "#" as their last word.
+//
+// The connection, its reconnections and the JetStream API are handled by
+// nats.go; the broker declares the topology again after each reconnection.
+type NATSBroker struct {
+	url    string
+	auth   config.NATSConfig
+	prefix string
+
+	mu         sync.RWMutex
+	conn       *nats.Conn
+	js         jetstream.JetStream
+	session    context.Context
+	endSession context.CancelFunc
+	delayers   map[string]bool
+	exchanges  map[string]string
+	queues     map[string]*natsQueue
+	started    bool
+	closed     bool
+	lastError  error
+	lastErrAt  time.Time
+
+	ready      atomic.Bool
+	reconnects atomic.Int64
+	published  atomic.Uint64
+	tag        atomic.Uint64
+}
+
+type natsQueue struct {
+	name       string
+	ttl        time.Duration
+	deadLetter bool
+	dlx        string
+	dlrk       string
+	sources    []natsSource
+}
+
+// natsSource is a durable consumer feeding a queue.
+type natsSource struct {
+	stream   string
+	consumer string
+	filter   string
+}
+
+// NewNATSBroker returns a broker for the NATS server of cfg.
+func NewNATSBroker(cfg config.NATSConfig) *NATSBroker {
+	prefix := cfg.StreamPrefix
+	if prefix == "" {
+		prefix = DefaultNATSStreamPrefix
+	}
+	return &NATSBroker{
+		url:       cfg.URL,
+		auth:      cfg,
+		prefix:    natsNameUnsafe.ReplaceAllString(prefix, "_"),
+		exchanges: make(map[string]string),
+		queues:    make(map[string]*natsQueue),
+	}
+}
+
+// Kind returns BrokerNATS.
+func (b *NATSBroker) Kind() string { return BrokerNATS }
+
+// Start records DefaultTopology and connects in the background, declaring
+// the topology on each connection. The broker closes with ctx.
+func (b *NATSBroker) Start(ctx context.Context) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrBrokerClosed
+	}
+	if b.started {
+		b.mu.Unlock()
+		return nil
+	}
+	b.started = true
+	b.mu.Unlock()
+
+	if err := DeclareTopology(b, DefaultTopology); err != nil {
+		return err
+	}
+	serverURL := b.url
+	if serverURL == "" {
+		serverURL = DefaultNATSURL
+	}
+	conn, err := nats.Connect(serverURL, b.options()...)
+	if err != nil {
+		b.mu.Lock()
+		b.started = false
+		b.mu.Unlock()
+		return fmt.Errorf("nats: %w", err)
+	}
+	b.mu.Lock()
+	b.conn = conn
+	b.mu.Unlock()
+	context.AfterFunc(ctx, func() { b.Close() })
+	return nil
+}
+
+// options keeps the connection up for good: the first connection and the
+// reconnections are retried every natsReconnectWait.
+func (b *NATSBroker) options() []nats.Option {
+	opts := []nats.Option{
+		nats.Name("gobe"),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+		nats.ReconnectWait(natsReconnectWait),
+		nats.ConnectHandler(b.connected),
+		nats.ReconnectHandler(func(conn *nats.Conn) {
+			b.reconnects.Add(1)
+			b.connected(conn)
+		}),
+		nats.DisconnectErrHandler(b.disconnected),
+		nats.ReconnectErrHandler(func(conn *nats.Conn, err error) { b.recordError(err) }),
+		nats.ErrorHandler(b.asyncError),
+	}
+	if b.auth.Token != "" {
+		opts = append(opts, nats.Token(b.auth.Token))
+	}
+	if b.auth.User != "" {
+		opts = append(opts, nats.UserInfo(b.auth.User, b.auth.Password))
+	}
+	return opts
+}
+
+// IsReady reports whether the broker is connected and its topology declared.
+// The connection is checked too: nats.go reports disconnections
+// asynchronously.
+func (b *NATSBroker) IsReady() bool {
+	if !b.ready.Load() {
+		return false
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.conn != nil && b.conn.IsConnected()
+}
+
+// connected opens a session on conn, which ends when it disconnects.
+func (b *NATSBroker) connected(conn *nats.Conn) {
+	js, err := jetstream.New(conn)
+	if err != nil {
+		b.recordError(err)
+		return
+	}
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	if b.endSession != nil {
+		b.endSession()
+	}
+	session, cancel := context.WithCancel(context.Background())
+	b.session, b.endSession = session, cancel
+	b.mu.Unlock()
+	go b.serve(session, conn, js)
+}
+
+// serve declares the topology, retrying until it succeeds or the session
+// ends, and then runs the TTL queues.
+func (b *NATSBroker) serve(session context.Context, conn *nats.Conn, js jetstream.JetStream) {
+	backoff := time.Second
+	for {
+		err := b.declareAll(session, js)
+		if err == nil {
+			break
+		}
+		if session.Err() != nil {
+			return
+		}
+		b.recordError(err)
+		gl.Log("warn", fmt.Sprintf("NATS broker topology not declared, retrying in %s", backoff), err)
+		select {
+		case <-session.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, natsMaxBackoff)
+	}
+
+	b.mu.Lock()
+	if session.Err() != nil {
+		b.mu.Unlock()
+		return
+	}
+	b.js = js
+	b.delayers = make(map[string]bool)
+	for _, q := range b.queues {
+		b.startDelayerLocked(q)
+	}
+	b.mu.Unlock()
+	b.ready.Store(true)
+	gl.Log("info", fmt.Sprintf("NATS broker connected to %s (server %s)", natsRedactedURL(b.url), conn.ConnectedServerVersion()))
+}
+
+// disconnected ends the session; consumers reopen their channels once the
+// broker reconnects.
+func (b *NATSBroker) disconnected(conn *nats.Conn, err error) {
+	b.ready.Store(false)
+	b.mu.Lock()
+	if b.endSession != nil {
+		b.endSession()
+		b.endSession = nil
+	}
+	b.js = nil
+	closed := b.closed
+	b.mu.Unlock()
+	if closed {
+		return
+	}
+	if err == nil {
+		err = nats.ErrDisconnected
+	}
+	b.recordError(err)
+	gl.Log("warn", "NATS broker disconnected, reconnecting", err)
+}
+
+// asyncError records the errors nats.go reports out of band: "-ERR" from
+// the server and slow consumers. Pulled messages a slow consumer dropped
+// are redelivered by JetStream once their ack wait ends.
+func (b *NATSBroker) asyncError(conn *nats.Conn, sub *nats.Subscription, err error) {
+	b.recordError(err)
+	if errors.Is(err, nats.ErrSlowConsumer) {
+		gl.Log("warn", "NATS broker is a slow consumer, messages will be redelivered", err)
+		return
+	}
+	gl.Log("warn", "NATS server reported an error", err)
+}
+
+// declareAll declares every stream and consumer recorded so far.
+func (b *NATSBroker) declareAll(ctx context.Context, js jetstream.JetStream) error {
+	b.mu.RLock()
+	exchanges := make([]string, 0, len(b.exchanges))
+	for name := range b.exchanges {
+		exchanges = append(exchanges, name)
+	}
+	var sources []natsSource
+	for _, q := range b.queues {
+		sources = append(sources, q.sources...)
+	}
+	b.mu.RUnlock()
+
+	if err := b.ensureStream(ctx, js, b.queueStream(), []string{b.prefix + ".q.>"}); err != nil {
+		return err
+	}
+	for _, name := range exchanges {
+		if err := b.ensureExchange(ctx, js, name); err != nil {
+			return err
+		}
+	}
+	for _, source := range sources {
+		if err := b.ensureConsumer(ctx, js, source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeclareExchange declares a "direct", "topic" or "fanout" exchange.
+func (b *NATSBroker) DeclareExchange(name, kind string) error {
+	switch kind {
+	case "direct", "topic", "fanout":
+	default:
+		return fmt.Errorf("unsupported exchange kind %q", kind)
+	}
+	if err := natsValidSubject(name); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	if existing, ok := b.exchanges[name]; ok && existing != kind {
+		b.mu.Unlock()
+		return fmt.Errorf("exchange %s already declared as %s", name, existing)
+	}
+	b.exchanges[name] = kind
+	js := b.js
+	b.mu.Unlock()
+	if js == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), natsRequestTimeout)
+	defer cancel()
+	return b.ensureExchange(ctx, js, name)
+}
+
+// DeclareQueue declares a queue. Declaring it again keeps its arguments.
+func (b *NATSBroker) DeclareQueue(name string, args amqp.Table) error {
+	if err := natsValidSubject(name); err != nil {
+		return err
+	}
+	q := &natsQueue{name: name}
+	switch ttl := args["x-message-ttl"].(type) {
+	case int:
+		q.ttl = time.Duration(ttl) * time.Millisecond
+	case int32:
+		q.ttl = time.Duration(ttl) * time.Millisecond
+	case int64:
+		q.ttl = time.Duration(ttl) * time.Millisecond
+	}
+	if dlx, ok := args["x-dead-letter-exchange"].(string); ok {
+		q.deadLetter = true
+		q.dlx = dlx
+		q.dlrk, _ = args["x-dead-letter-routing-key"].(string)
+	}
+	source := natsSource{stream: b.queueStream(), consumer: natsName("q", name), filter: b.prefix + ".q." + name}
+	q.sources = []natsSource{source}
+
+	b.mu.Lock()
+	if _, ok := b.queues[name]; ok {
+		b.mu.Unlock()
+		return nil
+	}
+	b.queues[name] = q
+	js := b.js
+	b.mu.Unlock()
+	if js == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), natsRequestTimeout)
+	defer cancel()
+	if err := b.ensureConsumer(ctx, js, source); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	b.startDelayerLocked(q)
+	b.mu.Unlock()
+	return nil
+}
+
+// BindQueue routes the messages of exchange matching key to the queue.
+func (b *NATSBroker) BindQueue(name, key, exchange string) error {
+	b.mu.Lock()
+	q, ok := b.queues[name]
+	if !ok {
+		b.mu.Unlock()
+		return fmt.Errorf("queue %s not found", name)
+	}
+	kind, ok := b.exchanges[exchange]
+	if !ok {
+		b.mu.Unlock()
+		return fmt.Errorf("exchange %s not found", exchange)
+	}
+	filter, err := b.bindingFilter(exchange, kind, key)
+	if err != nil {
+		b.mu.Unlock()
+		return err
+	}
+	source := natsSource{
+		stream:   b.exchangeStream(exchange),
+		consumer: natsName("b", name+" "+exchange+" "+key),
+		filter:   filter,
+	}
+	for _, existing := range q.sources {
+		if existing == source {
+			b.mu.Unlock()
+			return nil
+		}
+	}
+	q.sources = append(q.sources, source)
+	js := b.js
+	b.mu.Unlock()
+	if js == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), natsRequestTimeout)
+	defer cancel()
+	return b.ensureConsumer(ctx, js, source)
+}
+
+func (b *NATSBroker) queueStream() string { return b.prefix + "_QUEUES" }
+
+func (b *NATSBroker) exchangeStream(exchange string) string {
+	return natsName(b.prefix+"_X", exchange)
+}
+
+// subject returns the subject of a message sent to exchange with key.
+func (b *NATSBroker) subject(exchange, key string) (string, error) {
+	if exchange == "" {
+		return b.prefix + ".q." + key, natsValidSubject(key)
+	}
+	b.mu.RLock()
+	kind := b.exchanges[exchange]
+	b.mu.RUnlock()
+	if kind == "fanout" || key == "" {
+		return b.prefix + ".x." + exchange, nil
+	}
+	return b.prefix + ".x." + exchange + "." + key, natsValidSubject(key)
+}
+
+// bindingFilter returns the subject filter of a binding: AMQP "*" is NATS
+// "*" and a final "#" is ">".
+func (b *NATSBroker) bindingFilter(exchange, kind, key string) (string, error) {
+	base := b.prefix + ".x." + exchange
+	if kind == "fanout" || key == "" {
+		return base, nil
+	}
+	words := strings.Split(key, ".")
+	for i, word := range words {
+		switch {
+		case word == "#" && kind == "topic" && i == len(words)-1:
+			words[i] = ">"
+		case (word == "#" || word == "*") && kind == "topic":
+			if word == "#" {
+				return "", fmt.Errorf("binding key %q: nats only supports # as the last word", key)
+			}
+		case word == "" || strings.ContainsAny(word, "*> \t"):
+			return "", fmt.Errorf("invalid binding key %q", key)
+		}
+	}
+	return base + "." + strings.Join(words, "."), nil
+}
+
+// natsValidSubject checks that s is made of non-empty literal tokens.
+func natsValidSubject(s string) error {
+	for _, token := range strings.Split(s, ".") {
+		if token == "" || strings.ContainsAny(token, "*> \t\r\n") {
+			return fmt.Errorf("invalid nats subject %q", s)
+		}
+	}
+	return nil
+}
+
+// natsName turns s into a stream or consumer name, kept unique by a hash.
+func natsName(kind, s string) string {
+	h := fnv.New32a()
+	h.Write([]byte(s))
+	return fmt.Sprintf("%s_%s_%08x", kind, strings.Trim(natsNameUnsafe.ReplaceAllString(s, "_"), "_"), h.Sum32())
+}
+
+func (b *NATSBroker) ensureExchange(ctx context.Context, js jetstream.JetStream, exchange string) error {
+	subject := b.prefix + ".x." + exchange
+	return b.ensureStream(ctx, js, b.exchangeStream(exchange), []string{subject, subject + ".>"})
+}
+
+func (b *NATSBroker) ensureStream(ctx context.Context, js jetstream.JetStream, name string, subjects []string) error {
+	_, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:      name,
+		Subjects:  subjects,
+		Retention: jetstream.InterestPolicy,
+		Storage:   jetstream.FileStorage,
+		Discard:   jetstream.DiscardOld,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to declare stream %s: %w", name, err)
+	}
+	return nil
+}
+
+func (b *NATSBroker) ensureConsumer(ctx context.Context, js jetstream.JetStream, source natsSource) error {
+	_, err := js.CreateOrUpdateConsumer(ctx, source.stream, jetstream.ConsumerConfig{
+		Durable:       source.consumer,
+		DeliverPolicy: jetstream.DeliverAllPolicy,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       natsAckWait,
+		MaxDeliver:    -1,
+		MaxAckPending: natsMaxAckPending,
+		FilterSubject: source.filter,
+		ReplayPolicy:  jetstream.ReplayInstantPolicy,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to declare consumer %s: %w", source.consumer, err)
+	}
+	return nil
+}
+
+// Publish sends body to exchange as persistent JSON.
+func (b *NATSBroker) Publish(exchange, key string, body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), natsRequestTimeout)
+	defer cancel()
+	return b.publish(ctx, b.current(), exchange, key, amqp.Publishing{
+		DeliveryMode: amqp.Persistent,
+		ContentType:  "application/json",
+		Timestamp:    time.Now(),
+		Body:         body,
+	})
+}
+
+// Reply publishes body to the reply_to queue of d.
+func (b *NATSBroker) Reply(d Delivery, body []byte) error {
+	if d.ReplyTo == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), natsRequestTimeout)
+	defer cancel()
+	return b.publish(ctx, b.current(), "", d.ReplyTo, amqp.Publishing{
+		ContentType:   "application/json",
+		CorrelationId: d.CorrelationId,
+		Timestamp:     time.Now(),
+		Body:          body,
+	})
+}
+
+// current returns the JetStream context of the session, nil while the
+// broker is not ready.
+func (b *NATSBroker) current() jetstream.JetStream {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.js
+}
+
+// publish sends msg through JetStream and waits for the stream to store it.
+func (b *NATSBroker) publish(ctx context.Context, js jetstream.JetStream, exchange, key string, msg amqp.Publishing) error {
+	if js == nil {
+		return errors.New("nats not ready")
+	}
+	subject, err := b.subject(exchange, key)
+	if err != nil {
+		return err
+	}
+	_, err = js.PublishMsg(ctx, &nats.Msg{Subject: subject, Header: natsHeader(exchange, key, msg), Data: msg.Body})
+	if errors.Is(err, jetstream.ErrNoStreamResponse) {
+		return fmt.Errorf("no stream for exchange %q: %w", exchange, ErrNoResponders)
+	}
+	if err != nil {
+		return err
+	}
+	b.published.Add(1)
+	return nil
+}
+
+// natsHeader carries the properties of msg.
+func natsHeader(exchange, key string, msg amqp.Publishing) nats.Header {
+	header := make(nats.Header, len(msg.Headers)+8)
+	for k, v := range msg.Headers {
+		header.Set(k, fmt.Sprint(v))
+	}
+	set := func(key, value string) {
+		if value != "" {
+			header.Set(key, value)
+		}
+	}
+	set(natsHeaderExchange, exchange)
+	set(natsHeaderRoutingKey, key)
+	set(natsHeaderContentType, msg.ContentType)
+	set(natsHeaderReplyTo, msg.ReplyTo)
+	set(natsHeaderCorrelationID, msg.CorrelationId)
+	set(natsHeaderMessageID, msg.MessageId)
+	set(natsHeaderType, msg.Type)
+	set(natsHeaderAppID, msg.AppId)
+	if !msg.Timestamp.IsZero() {
+		header.Set(natsHeaderTimestamp, msg.Timestamp.UTC().Format(time.RFC3339Nano))
+	}
+	return header
+}
+
+// natsPublishing rebuilds the message a delivery was published with.
+func natsPublishing(msg jetstream.Msg) amqp.Publishing {
+	pub := amqp.Publishing{Headers: amqp.Table{}, DeliveryMode: amqp.Persistent, Body: msg.Data()}
+	for k := range msg.Headers() {
+		v := msg.Headers().Get(k)
+		switch k {
+		case natsHeaderExchange, natsHeaderRoutingKey:
+		case natsHeaderContentType:
+			pub.ContentType = v
+		case natsHeaderReplyTo:
+			pub.ReplyTo = v
+		case natsHeaderCorrelationID:
+			pub.CorrelationId = v
+		case natsHeaderMessageID:
+			pub.MessageId = v
+		case natsHeaderType:
+			pub.Type = v
+		case natsHeaderAppID:
+			pub.AppId = v
+		case natsHeaderTimestamp:
+			pub.Timestamp, _ = time.Parse(time.RFC3339Nano, v)
+		default:
+			pub.Headers[k] = v
+		}
+	}
+	if pub.Timestamp.IsZero() {
+		if meta, err := msg.Metadata(); err == nil {
+			pub.Timestamp = meta.Timestamp
+		}
+	}
+	return pub
+}
+
+// next pulls one message of consumer, or nil when none came before the
+// pull expired.
+func next(ctx context.Context, consumer jetstream.Consumer) (jetstream.Msg, error) {
+	pullCtx, cancel := context.WithTimeout(ctx, natsPullExpires)
+	defer cancel()
+	msg, err := consumer.Next(jetstream.FetchContext(pullCtx))
+	if err != nil {
+		if ctx.Err() == nil && (errors.Is(err, nats.ErrTimeout) || errors.Is(err, context.DeadlineExceeded)) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return msg, nil
+}
+
+// startDelayerLocked serves q when it holds messages for a while before
+// dead-lettering them, once per session.
+func (b *NATSBroker) startDelayerLocked(q *natsQueue) {
+	if q.ttl <= 0 || !q.deadLetter || b.js == nil || b.delayers[q.name] {
+		return
+	}
+	b.delayers[q.name] = true
+	go b.delay(b.session, b.js, q)
+}
+
+// delay dead-letters the messages of q once they are older than its TTL,
+// handing back the younger ones until they are due.
+func (b *NATSBroker) delay(ctx context.Context, js jetstream.JetStream, q *natsQueue) {
+	source := q.sources[0]
+	var consumer jetstream.Consumer
+	for ctx.Err() == nil {
+		var msg jetstream.Msg
+		var err error
+		if consumer == nil {
+			consumer, err = js.Consumer(ctx, source.stream, source.consumer)
+		}
+		if err == nil {
+			msg, err = next(ctx, consumer)
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				gl.Log("warn", fmt.Sprintf("NATS delayed queue %s failed", q.name), err)
+				time.Sleep(time.Second)
+			}
+			continue
+		}
+		if msg == nil {
+			continue
+		}
+		meta, err := msg.Metadata()
+		if err != nil {
+			msg.Term()
+			continue
+		}
+		// the TTL runs from the time the stream stored the message
+		if wait := time.Until(meta.Timestamp.Add(q.ttl)); wait > 0 {
+			msg.NakWithDelay(wait)
+			continue
+		}
+		if err := b.deadLetter(ctx, js, q, msg.Headers().Get(natsHeaderRoutingKey), natsPublishing(msg)); err != nil {
+			gl.Log("warn", fmt.Sprintf("Failed to dead-letter a message of %s", q.name), err)
+			msg.NakWithDelay(time.Second)
+			continue
+		}
+		msg.Ack()
+	}
+}
+
+// deadLetter routes pub to the dead-letter exchange of q.
+func (b *NATSBroker) deadLetter(ctx context.Context, js jetstream.JetStream, q *natsQueue, key string, pub amqp.Publishing) error {
+	if q.dlrk != "" {
+		key = q.dlrk
+	}
+	return b.publish(ctx, js, q.dlx, key, pub)
+}
+
+// ConnectionStats describes the connection of the broker.
+func (b *NATSBroker) ConnectionStats() map[string]interface{} {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	stats := map[string]interface{}{
+		"broker":     BrokerNATS,
+		"ready":      b.ready.Load(),
+		"url":        natsRedactedURL(b.url),
+		"reconnects": b.reconnects.Load(),
+		"published":  b.published.Load(),
+		"queues":     len(b.queues),
+	}
+	lastError, lastErrAt := b.lastError, b.lastErrAt
+	if b.conn != nil && b.conn.IsConnected() {
+		stats["server_id"] = b.conn.ConnectedServerId()
+		stats["server_version"] = b.conn.ConnectedServerVersion()
+	} else if b.conn != nil && b.conn.LastError() != nil {
+		// nats.go keeps the "-ERR" refusing the connection (authorization
+		// violation...) to itself while it retries
+		lastError, lastErrAt = b.conn.LastError(), time.Now()
+	}
+	if lastError != nil {
+		stats["last_error"] = lastError.Error()
+		stats["last_error_time"] = lastErrAt.Unix()
+	}
+	return stats
+}
+
+func (b *NATSBroker) recordError(err error) {
+	if err == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastError = err
+	b.lastErrAt = time.Now()
+}
+
+// Close disconnects the broker.
+func (b *NATSBroker) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	if b.endSession != nil {
+		b.endSession()
+		b.endSession = nil
+	}
+	b.js = nil
+	conn := b.conn
+	b.mu.Unlock()
+	b.ready.Store(false)
+	if conn != nil {
+		conn.Close()
+	}
+	return nil
+}
+
+func natsRedactedURL(raw string) string {
+	if raw == "" {
+		return DefaultNATSURL
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	if _, ok := u.User.Password(); !ok {
+		// a token
+		u.User = url.User("xxxxx")
+	}
+	return u.Redacted()
+}
+
+// ConsumerChannel opens a channel on the current session.
+func (b *NATSBroker) ConsumerChannel() (Channel, error) {
+	b.mu.RLock()
+	js, session := b.js, b.session
+	connected := b.conn != nil && b.conn.IsConnected()
+	b.mu.RUnlock()
+	if js == nil || !connected {
+		return nil, errors.New("nats not connected")
+	}
+	ctx, cancel := context.WithCancel(session)
+	return &natsChannel{
+		broker:    b,
+		js:        js,
+		ctx:       ctx,
+		cancel:    cancel,
+		consumers: make(map[string]*natsConsumer),
+	}, nil
+}
+
+// natsChannel consumes queues by pulling from their consumers during one
+// session; it ends with the session.
+type natsChannel struct {
+	broker *NATSBroker
+	js     jetstream.JetStream
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu        sync.Mutex
+	prefetch  int
+	consumers map[string]*natsConsumer
+}
+
+type natsConsumer struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (c *natsChannel) DeclareExchange(name, kind string) error {
+	return c.broker.DeclareExchange(name, kind)
+}
+
+func (c *natsChannel) DeclareQueue(name string, args amqp.Table) error {
+	return c.broker.DeclareQueue(name, args)
+}
+
+func (c *natsChannel) BindQueue(name, key, exchange string) error {
+	return c.broker.BindQueue(name, key, exchange)
+}
+
+func (c *natsChannel) Qos(prefetch int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.prefetch = prefetch
+	return nil
+}
+
+// Consume pulls the messages of every consumer feeding queue, holding at
+// most the prefetch count unacknowledged.
+func (c *natsChannel) Consume(queue, tag string) (<-chan Delivery, error) {
+	c.broker.mu.RLock()
+	q, ok := c.broker.queues[queue]
+	var sources []natsSource
+	if ok {
+		sources = append(sources, q.sources...)
+	}
+	c.broker.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("queue %s not found", queue)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ctx.Err() != nil {
+		return nil, ErrBrokerClosed
+	}
+	if _, exists := c.consumers[tag]; exists {
+		return nil, fmt.Errorf("consumer tag %s already in use", tag)
+	}
+	prefetch := c.prefetch
+	if prefetch <= 0 || prefetch > natsMaxAckPending {
+		prefetch = natsMaxAckPending
+	}
+	ctx, cancel := context.WithCancel(c.ctx)
+	consumer := &natsConsumer{cancel: cancel, done: make(chan struct{})}
+	c.consumers[tag] = consumer
+
+	out := make(chan Delivery, prefetch)
+	slots := make(chan struct{}, prefetch)
+	var pullers sync.WaitGroup
+	for _, source := range sources {
+		pullers.Add(1)
+		go func(source natsSource) {
+			defer pullers.Done()
+			c.pull(ctx, q, source, tag, slots, out)
+		}(source)
+	}
+	go func() {
+		pullers.Wait()
+		close(out)
+		close(consumer.done)
+	}()
+	return out, nil
+}
+
+func (c *natsChannel) pull(ctx context.Context, q *natsQueue, source natsSource, tag string, slots chan struct{}, out chan<- Delivery) {
+	var consumer jetstream.Consumer
+	for {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		var msg jetstream.Msg
+		var err error
+		if consumer == nil {
+			consumer, err = c.js.Consumer(ctx, source.stream, source.consumer)
+		}
+		if err == nil {
+			msg, err = next(ctx, consumer)
+		}
+		if msg == nil {
+			<-slots
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				gl.Log("warn", fmt.Sprintf("NATS pull for %s failed", q.name), err)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(time.Second):
+				}
+			}
+			continue
+		}
+		out <- c.delivery(q, msg, tag, func() { <-slots })
+	}
+}
+
+// delivery turns msg into a Delivery settled through JetStream.
+func (c *natsChannel) delivery(q *natsQueue, msg jetstream.Msg, tag string, release func()) Delivery {
+	pub := natsPublishing(msg)
+	redelivered := false
+	if meta, err := msg.Metadata(); err == nil {
+		redelivered = meta.NumDelivered > 1
+	}
+	acker := &natsAcker{broker: c.broker, js: c.js, queue: q, msg: msg, release: release, session: c.ctx.Done(), stop: make(chan struct{})}
+	go acker.progress()
+	return Delivery{
+		Acknowledger:  acker,
+		Headers:       pub.Headers,
+		ContentType:   pub.ContentType,
+		DeliveryMode:  pub.DeliveryMode,
+		CorrelationId: pub.CorrelationId,
+		ReplyTo:       pub.ReplyTo,
+		MessageId:     pub.MessageId,
+		Timestamp:     pub.Timestamp,
+		Type:          pub.Type,
+		AppId:         pub.AppId,
+		ConsumerTag:   tag,
+		DeliveryTag:   c.broker.tag.Add(1),
+		Redelivered:   redelivered,
+		Exchange:      msg.Headers().Get(natsHeaderExchange),
+		RoutingKey:    msg.Headers().Get(natsHeaderRoutingKey),
+		Body:          msg.Data(),
+	}
+}
+
+// Cancel stops pulling for the consumer; its deliveries channel closes
+// once the messages already pulled are read.
+func (c *natsChannel) Cancel(tag string) error {
+	c.mu.Lock()
+	consumer, ok := c.consumers[tag]
+	delete(c.consumers, tag)
+	c.mu.Unlock()
+	if ok {
+		consumer.cancel()
+	}
+	return nil
+}
+
+func (c *natsChannel) Publish(ctx context.Context, exchange, key string, msg amqp.Publishing) error {
+	if c.ctx.Err() != nil {
+		return ErrBrokerClosed
+	}
+	return c.broker.publish(ctx, c.js, exchange, key, msg)
+}
+
+// Close stops every consumer of the channel. Messages it did not
+// acknowledge are redelivered by JetStream.
+func (c *natsChannel) Close() error {
+	c.cancel()
+	c.mu.Lock()
+	consumers := c.consumers
+	c.consumers = make(map[string]*natsConsumer)
+	c.mu.Unlock()
+	for _, consumer := range consumers {
+		<-consumer.done
+	}
+	return nil
+}
+
+// natsAcker settles one delivery, reporting progress until then so
+// JetStream does not redeliver it.
+type natsAcker struct {
+	broker  *NATSBroker
+	js      jetstream.JetStream
+	queue   *natsQueue
+	msg     jetstream.Msg
+	release func()
+	session <-chan struct{}
+	stop    chan struct{}
+	once    sync.Once
+}
+
+func (a *natsAcker) progress() {
+	ticker := time.NewTicker(natsAckWait / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.stop:
+			return
+		case <-a.session:
+			return
+		case <-ticker.C:
+			a.msg.InProgress()
+		}
+	}
+}
+
+func (a *natsAcker) settle(settle func() error) error {
+	err := errors.New("delivery already settled")
+	a.once.Do(func() {
+		close(a.stop)
+		a.release()
+		err = settle()
+	})
+	return err
+}
+
+// Ack implements amqp.Acknowledger.
+func (a *natsAcker) Ack(tag uint64, multiple bool) error { return a.settle(a.msg.Ack) }
+
+// Nack implements amqp.Acknowledger: the message is redelivered, or sent
+// to the dead-letter exchange of its queue.
+func (a *natsAcker) Nack(tag uint64, multiple, requeue bool) error {
+	if requeue {
+		return a.settle(a.msg.Nak)
+	}
+	if a.queue.deadLetter {
+		ctx, cancel := context.WithTimeout(context.Background(), natsRequestTimeout)
+		defer cancel()
+		if err := a.broker.deadLetter(ctx, a.js, a.queue, a.msg.Headers().Get(natsHeaderRoutingKey), natsPublishing(a.msg)); err != nil {
+			a.settle(a.msg.Nak)
+			return err
+		}
+	}
+	return a.settle(a.msg.Term)
+}
+
+// Reject implements amqp.Acknowledger.
+func (a *natsAcker) Reject(tag uint64, requeue bool) error { return a.Nack(tag, false, requeue) }
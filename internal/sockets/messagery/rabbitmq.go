@@ -19,12 +19,14 @@ import (
 
 type DBConfig = t.DBConfig
 
+// AMQP is the RabbitMQ broker.
 type AMQP struct {
 	URL           string
 	Conn          *amqp.Connection
 	Chan          *amqp.Channel
 	ready         atomic.Bool
 	reconnecting  atomic.Bool
+	started       atomic.Bool
 	mu            sync.RWMutex
 	lastError     error
 	lastErrorTime time.Time
@@ -40,6 +42,26 @@ func NewAMQP() *AMQP {
 	}
 }
 
+// Kind returns BrokerAMQP.
+func (a *AMQP) Kind() string { return BrokerAMQP }
+
+// Start connects to URL in the background.
+func (a *AMQP) Start(ctx context.Context) error {
+	if a.URL == "" {
+		return errors.New("amqp url is not set")
+	}
+	if !a.started.CompareAndSwap(false, true) {
+		return nil
+	}
+	go func() {
+		logf := func(format string, args ...any) { gl.Log("debug", fmt.Sprintf(format, args...)) }
+		if err := a.Connect(ctx, a.URL, logf); err != nil {
+			gl.Log("error", "AMQP connection failed", err)
+		}
+	}()
+	return nil
+}
+
 func (a *AMQP) Connect(ctx context.Context, url string, logf func(string, ...any)) error {
 	a.URL = url
 	backoff := []time.Duration{500 * time.Millisecond, 1 * time.Second, 2 * time.Second, 5 * time.Second, 10 * time.Second, 30 * time.Second}
@@ -173,89 +195,7 @@ func (a *AMQP) declareTopology() error {
 	if a.Chan == nil {
 		return errors.New("channel is nil")
 	}
-
-	// Declare default exchanges and queues for the GoBE application
-	exchanges := []struct {
-		name       string
-		kind       string
-		durable    bool
-		autoDelete bool
-	}{
-		{"gobe.events", "topic", true, false},
-		{"gobe.logs", "direct", true, false},
-		{"gobe.notifications", "fanout", true, false},
-	}
-
-	// Declare exchanges
-	for _, exchange := range exchanges {
-		err := a.Chan.ExchangeDeclare(
-			exchange.name,
-			exchange.kind,
-			exchange.durable,
-			exchange.autoDelete,
-			false, // internal
-			false, // noWait
-			nil,   // arguments
-		)
-		if err != nil {
-			return fmt.Errorf("failed to declare exchange %s: %w", exchange.name, err)
-		}
-		gl.Log("info", "Declared AMQP exchange", exchange.name, exchange.kind)
-	}
-
-	// Declare default queues
-	queues := []struct {
-		name       string
-		durable    bool
-		autoDelete bool
-		exclusive  bool
-	}{
-		{"gobe.system.logs", true, false, false},
-		{"gobe.system.events", true, false, false},
-		{"gobe.mcp.tasks", true, false, false},
-	}
-
-	for _, queue := range queues {
-		_, err := a.Chan.QueueDeclare(
-			queue.name,
-			queue.durable,
-			queue.autoDelete,
-			queue.exclusive,
-			false, // noWait
-			nil,   // arguments
-		)
-		if err != nil {
-			return fmt.Errorf("failed to declare queue %s: %w", queue.name, err)
-		}
-		gl.Log("info", "Declared AMQP queue", queue.name)
-	}
-
-	// Bind queues to exchanges
-	bindings := []struct {
-		queue    string
-		exchange string
-		key      string
-	}{
-		{"gobe.system.logs", "gobe.logs", "system"},
-		{"gobe.system.events", "gobe.events", "system.*"},
-		{"gobe.mcp.tasks", "gobe.events", "mcp.task.*"},
-	}
-
-	for _, binding := range bindings {
-		err := a.Chan.QueueBind(
-			binding.queue,
-			binding.key,
-			binding.exchange,
-			false, // noWait
-			nil,   // arguments
-		)
-		if err != nil {
-			return fmt.Errorf("failed to bind queue %s to exchange %s: %w", binding.queue, binding.exchange, err)
-		}
-		gl.Log("info", "Bound AMQP queue", binding.queue, "to exchange", binding.exchange, "with key", binding.key)
-	}
-
-	return nil
+	return DeclareTopology(&amqpChannel{ch: a.Chan}, DefaultTopology)
 }
 
 func (a *AMQP) PublishReliable(exchange, key string, body []byte) error {
@@ -375,6 +315,7 @@ func (a *AMQP) ConnectionStats() map[string]interface{} {
 	defer a.mu.RUnlock()
 
 	stats := map[string]interface{}{
+		"broker":              BrokerAMQP,
 		"ready":               a.ready.Load(),
 		"reconnecting":        a.reconnecting.Load(),
 		"connection_attempts": atomic.LoadInt64(&a.connAttempts),
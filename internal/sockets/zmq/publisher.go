@@ -0,0 +1,135 @@
+// Package zmq runs the ZeroMQ PUB and SUB sockets of gobe on
+// github.com/go-zeromq/zmq4, a pure Go ZMTP 3 implementation, so gobe
+// interoperates with libzmq peers (pyzmq, czmq, ...) without cgo.
+package zmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-zeromq/zmq4"
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+)
+
+// DefaultQueueSize is the high-water mark of a publisher: messages beyond
+// it are dropped, as libzmq does, instead of slowing down the caller.
+const DefaultQueueSize = 1000
+
+// ErrClosed is returned by sockets used after Close.
+var ErrClosed = errors.New("zmq socket closed")
+
+// logger routes the logs of the zmq4 sockets to the gobe log.
+var logger = log.New(logWriter{}, "", 0)
+
+type logWriter struct{}
+
+func (logWriter) Write(p []byte) (int, error) {
+	gl.Log("debug", "zmq4: "+strings.TrimSpace(string(p)))
+	return len(p), nil
+}
+
+// PublisherStats counts the messages of a publisher.
+type PublisherStats struct {
+	Endpoint string `json:"endpoint"`
+	// Topics are the prefixes the connected subscribers asked for.
+	Topics    []string `json:"topics,omitempty"`
+	Published uint64   `json:"published"`
+}
+
+// Publisher is a PUB socket bound to a TCP endpoint. Each message goes to
+// the subscribers with a subscription prefixing its first frame.
+type Publisher struct {
+	socket zmq4.Socket
+	cancel context.CancelFunc
+
+	mu     sync.RWMutex
+	closed bool
+
+	published atomic.Uint64
+}
+
+// NewPublisher binds a PUB socket to endpoint ("tcp://*:5555"). queueSize
+// falls back to DefaultQueueSize when not positive.
+func NewPublisher(endpoint string, queueSize int) (*Publisher, error) {
+	if err := validEndpoint(endpoint); err != nil {
+		return nil, err
+	}
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	socket := zmq4.NewPub(ctx, zmq4.WithLogger(logger))
+	if err := socket.SetOption(zmq4.OptionHWM, queueSize); err != nil {
+		cancel()
+		socket.Close()
+		return nil, err
+	}
+	if err := socket.Listen(endpoint); err != nil {
+		cancel()
+		socket.Close()
+		return nil, fmt.Errorf("failed to bind zmq publisher to %s: %w", endpoint, err)
+	}
+	p := &Publisher{socket: socket, cancel: cancel}
+	gl.Log("info", fmt.Sprintf("ZMQ publisher bound to %s", p.Endpoint()))
+	return p, nil
+}
+
+// Endpoint returns the endpoint the publisher listens on, with the actual
+// port when bound to port 0.
+func (p *Publisher) Endpoint() string {
+	return "tcp://" + p.socket.Addr().String()
+}
+
+// Publish sends topic followed by parts as one multipart message. It never
+// blocks: once DefaultQueueSize messages wait to be sent, new ones are
+// dropped.
+func (p *Publisher) Publish(topic string, parts ...[]byte) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrClosed
+	}
+	if err := p.socket.Send(zmq4.NewMsgFrom(append([][]byte{[]byte(topic)}, parts...)...)); err != nil {
+		return err
+	}
+	p.published.Add(1)
+	return nil
+}
+
+// Stats returns the counters of the publisher.
+func (p *Publisher) Stats() PublisherStats {
+	stats := PublisherStats{Endpoint: p.Endpoint(), Published: p.published.Load()}
+	if topics, ok := p.socket.(zmq4.Topics); ok {
+		stats.Topics = topics.Topics()
+	}
+	return stats
+}
+
+// Close unbinds the publisher and disconnects its subscribers. Messages
+// still queued are discarded.
+func (p *Publisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	err := p.socket.Close()
+	p.cancel()
+	return err
+}
+
+// validEndpoint accepts the "tcp://host:port" endpoints gobe binds and
+// connects to.
+func validEndpoint(endpoint string) error {
+	address, ok := strings.CutPrefix(endpoint, "tcp://")
+	if !ok || !strings.Contains(address, ":") {
+		return fmt.Errorf("invalid zmq endpoint %q: want tcp://host:port", endpoint)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package zmq
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-zeromq/zmq4"
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+)
+
+// DefaultReconnectInterval is the pause before a subscriber dials a
+// publisher again.
+const DefaultReconnectInterval = time.Second
+
+// dialTimeout bounds a single attempt to reach a publisher.
+const dialTimeout = 10 * time.Second
+
+// Message is a message received by a subscriber: Topic is its first frame
+// and Body the frames after it, joined.
+type Message struct {
+	Topic  string
+	Body   []byte
+	Frames [][]byte
+}
+
+// Handler receives the messages of a subscriber, one at a time.
+type Handler func(msg Message)
+
+// Subscriber is a set of SUB sockets, one per connected publisher. Each
+// reconnects when its publisher goes away and subscribes again.
+type Subscriber struct {
+	handler   Handler
+	reconnect time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	topics  map[string]struct{}
+	sockets []zmq4.Socket
+	// deliver serializes the handler across publishers
+	deliver sync.Mutex
+}
+
+// NewSubscriber returns a subscriber passing messages to handler. reconnect
+// falls back to DefaultReconnectInterval when not positive.
+func NewSubscriber(handler Handler, reconnect time.Duration) *Subscriber {
+	if reconnect <= 0 {
+		reconnect = DefaultReconnectInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Subscriber{
+		handler:   handler,
+		reconnect: reconnect,
+		ctx:       ctx,
+		cancel:    cancel,
+		topics:    make(map[string]struct{}),
+	}
+}
+
+// Connect dials the publisher at endpoint ("tcp://host:5555") in the
+// background, for as long as the subscriber is open.
+func (s *Subscriber) Connect(endpoint string) error {
+	if err := validEndpoint(endpoint); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctx.Err() != nil {
+		return ErrClosed
+	}
+	socket := zmq4.NewSub(s.ctx,
+		zmq4.WithAutomaticReconnect(true),
+		zmq4.WithDialerRetry(s.reconnect),
+		zmq4.WithDialerMaxRetries(-1),
+		zmq4.WithDialerTimeout(dialTimeout),
+		zmq4.WithLogger(logger),
+	)
+	for prefix := range s.topics {
+		if err := socket.SetOption(zmq4.OptionSubscribe, prefix); err != nil {
+			socket.Close()
+			return err
+		}
+	}
+	s.sockets = append(s.sockets, socket)
+	s.wg.Add(1)
+	go s.run(endpoint, socket)
+	return nil
+}
+
+// Subscribe receives the messages whose topic starts with prefix; "" receives
+// every message.
+func (s *Subscriber) Subscribe(prefix string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.topics[prefix]; ok {
+		return
+	}
+	s.topics[prefix] = struct{}{}
+	s.setOption(zmq4.OptionSubscribe, prefix)
+}
+
+// Unsubscribe cancels a subscription made with Subscribe.
+func (s *Subscriber) Unsubscribe(prefix string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.topics[prefix]; !ok {
+		return
+	}
+	delete(s.topics, prefix)
+	s.setOption(zmq4.OptionUnsubscribe, prefix)
+}
+
+// setOption applies a subscription change to every socket; a socket that
+// cannot send it gets it again when it reconnects.
+func (s *Subscriber) setOption(name, prefix string) {
+	for _, socket := range s.sockets {
+		if err := socket.SetOption(name, prefix); err != nil {
+			gl.Log("debug", fmt.Sprintf("ZMQ subscriber could not send %s %q", name, prefix), err)
+		}
+	}
+}
+
+// Close disconnects from every publisher and waits for the handler to
+// return.
+func (s *Subscriber) Close() error {
+	s.cancel()
+	s.mu.Lock()
+	for _, socket := range s.sockets {
+		socket.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return nil
+}
+
+// run dials endpoint until it answers, then hands the messages of socket
+// to the handler until the subscriber is closed. zmq4 redials and
+// subscribes again when the connection drops.
+func (s *Subscriber) run(endpoint string, socket zmq4.Socket) {
+	defer s.wg.Done()
+	if err := socket.Dial(endpoint); err != nil {
+		if s.ctx.Err() == nil {
+			gl.Log("error", fmt.Sprintf("ZMQ subscriber gave up on %s", endpoint), err)
+		}
+		return
+	}
+	for {
+		msg, err := socket.Recv()
+		if s.ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			gl.Log("debug", fmt.Sprintf("ZMQ subscriber lost %s, reconnecting every %s", endpoint, s.reconnect), err)
+			continue
+		}
+		if len(msg.Frames) == 0 {
+			continue
+		}
+		s.dispatch(msg.Frames)
+	}
+}
+
+func (s *Subscriber) dispatch(frames [][]byte) {
+	msg := Message{Topic: string(frames[0]), Frames: frames}
+	for _, frame := range frames[1:] {
+		msg.Body = append(msg.Body, frame...)
+	}
+	s.deliver.Lock()
+	defer s.deliver.Unlock()
+	s.handler(msg)
+}
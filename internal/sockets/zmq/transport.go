@@ -0,0 +1,345 @@
+package zmq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kubex-ecosystem/gobe/internal/config"
+	gl "github.com/kubex-ecosystem/gobe/internal/module/kbx"
+	"github.com/kubex-ecosystem/gobe/internal/observers/events"
+)
+
+// Topics of the transport, after its prefix.
+const (
+	// TopicEvents carries the events of a stream: "gobe.events.<type>".
+	TopicEvents = "events."
+	// TopicJobs carries the MCP job updates of a stream:
+	// "gobe.jobs.started", "gobe.jobs.progress" and "gobe.jobs.finished".
+	TopicJobs = "jobs."
+	// TopicCommands is where subscribed publishers send commands.
+	TopicCommands = "commands."
+	// TopicReplies acknowledges commands: "gobe.replies.accepted" or
+	// "gobe.replies.failed".
+	TopicReplies = "replies."
+)
+
+// DefaultPort is the port of the PUB socket when the configuration sets none.
+const DefaultPort = 5555
+
+// jobEventPrefix marks the MCP job events of a stream (mcp.EventJob*).
+const jobEventPrefix = "mcp_job_"
+
+// ErrNotPublishing is returned by Publish on a transport without a PUB
+// socket.
+var ErrNotPublishing = errors.New("zmq transport has no publisher")
+
+// Options configures a transport.
+type Options struct {
+	// Endpoint is where the PUB socket binds; empty disables publishing.
+	Endpoint string
+	// Connect lists the publishers whose commands are received.
+	Connect           []string
+	TopicPrefix       string
+	QueueSize         int
+	ReconnectInterval time.Duration
+}
+
+// DefaultOptions publishes under "gobe." and receives no commands.
+var DefaultOptions = Options{
+	TopicPrefix:       "gobe.",
+	QueueSize:         DefaultQueueSize,
+	ReconnectInterval: DefaultReconnectInterval,
+}
+
+// OptionsFromConfig reads the "zmq" config section. The PUB socket binds
+// cfg.Address ("tcp://*") on cfg.Port (DefaultPort) unless the address
+// already has a port, and cfg.Subscribe lists the publishers to connect to.
+// TopicPrefix, QueueSize and ReconnectIntervalSeconds keep DefaultOptions
+// when unset.
+func OptionsFromConfig(cfg config.ZMQConfig) Options {
+	opts := DefaultOptions
+	opts.Endpoint = endpointFromConfig(cfg)
+	opts.Connect = cfg.Subscribe
+	if cfg.TopicPrefix != "" {
+		opts.TopicPrefix = cfg.TopicPrefix
+	}
+	if cfg.QueueSize > 0 {
+		opts.QueueSize = cfg.QueueSize
+	}
+	if cfg.ReconnectIntervalSeconds > 0 {
+		opts.ReconnectInterval = time.Duration(cfg.ReconnectIntervalSeconds) * time.Second
+	}
+	return opts
+}
+
+func endpointFromConfig(cfg config.ZMQConfig) string {
+	address := cfg.Address
+	if address == "" {
+		address = "*"
+	}
+	if !strings.Contains(address, "://") {
+		address = "tcp://" + address
+	}
+	if _, _, err := net.SplitHostPort(strings.TrimPrefix(address, "tcp://")); err == nil {
+		return address
+	}
+	port := cfg.Port
+	if port <= 0 {
+		port = DefaultPort
+	}
+	return address + ":" + strconv.Itoa(port)
+}
+
+// Command asks gobe to run an MCP tool. External services publish it as
+// JSON on a commands topic, in the frame after the topic or after a space
+// in the topic frame itself.
+type Command struct {
+	ID   string                 `json:"id,omitempty"`
+	Tool string                 `json:"tool"`
+	Args map[string]interface{} `json:"args,omitempty"`
+	// Topic is the topic the command arrived on.
+	Topic string `json:"-"`
+}
+
+// CommandReply is published on TopicReplies once a command was handled.
+type CommandReply struct {
+	ID     string      `json:"id,omitempty"`
+	Tool   string      `json:"tool,omitempty"`
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// CommandHandler runs a command and returns what the accepted reply carries.
+type CommandHandler func(ctx context.Context, cmd Command) (interface{}, error)
+
+// TransportStats describes the sockets of a transport.
+type TransportStats struct {
+	Publisher        *PublisherStats `json:"publisher,omitempty"`
+	Subscribed       []string        `json:"subscribed,omitempty"`
+	CommandsAccepted uint64          `json:"commands_accepted"`
+	CommandsFailed   uint64          `json:"commands_failed"`
+}
+
+// Transport is the ZeroMQ event bus of gobe: it publishes stream events,
+// job updates and whatever Publish is given on topic-prefixed PUB messages,
+// and runs the commands received from the publishers it subscribes to.
+type Transport struct {
+	opts Options
+	pub  *Publisher
+	sub  *Subscriber
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.RWMutex
+	commands CommandHandler
+	forwards []forward
+
+	accepted atomic.Uint64
+	failed   atomic.Uint64
+}
+
+type forward struct {
+	stream *events.Stream
+	client *events.Client
+}
+
+// NewTransport binds the PUB socket and connects the SUB socket described
+// by opts.
+func NewTransport(opts Options) (*Transport, error) {
+	if opts.TopicPrefix == "" {
+		opts.TopicPrefix = DefaultOptions.TopicPrefix
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &Transport{opts: opts, ctx: ctx, cancel: cancel}
+
+	if opts.Endpoint != "" {
+		pub, err := NewPublisher(opts.Endpoint, opts.QueueSize)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		t.pub = pub
+	}
+	if len(opts.Connect) > 0 {
+		t.sub = NewSubscriber(t.handleCommand, opts.ReconnectInterval)
+		t.sub.Subscribe(opts.TopicPrefix + TopicCommands)
+		for _, endpoint := range opts.Connect {
+			if err := t.sub.Connect(endpoint); err != nil {
+				t.Close()
+				return nil, err
+			}
+		}
+		gl.Log("info", fmt.Sprintf("ZMQ transport receiving commands from %s", strings.Join(opts.Connect, ", ")))
+	}
+	return t, nil
+}
+
+// Endpoint returns the endpoint of the PUB socket, or "" without one.
+func (t *Transport) Endpoint() string {
+	if t.pub == nil {
+		return ""
+	}
+	return t.pub.Endpoint()
+}
+
+// Publish sends body on the prefixed topic.
+func (t *Transport) Publish(topic string, body []byte) error {
+	if t.pub == nil {
+		return ErrNotPublishing
+	}
+	return t.pub.Publish(t.opts.TopicPrefix+topic, body)
+}
+
+// PublishJSON sends v encoded as JSON on the prefixed topic.
+func (t *Transport) PublishJSON(topic string, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal zmq message: %w", err)
+	}
+	return t.Publish(topic, body)
+}
+
+// ForwardStream publishes every event of stream: MCP job updates on
+// TopicJobs and the others on TopicEvents. The stream must be running or
+// about to run.
+func (t *Transport) ForwardStream(stream *events.Stream) error {
+	client, err := events.NewClient(nil, stream.LastEventID())
+	if err != nil {
+		return err
+	}
+	client.QueueSize = t.opts.QueueSize
+
+	t.mu.Lock()
+	t.forwards = append(t.forwards, forward{stream: stream, client: client})
+	t.mu.Unlock()
+
+	go stream.RegisterClient(client)
+	go func() {
+		for {
+			select {
+			case <-t.ctx.Done():
+				return
+			case event, ok := <-client.Send:
+				if !ok {
+					return
+				}
+				if err := t.PublishJSON(streamTopic(event.Type), event); err != nil && !errors.Is(err, ErrClosed) {
+					gl.Log("debug", "ZMQ event not published", event.Type, err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// streamTopic returns the topic of a stream event.
+func streamTopic(eventType string) string {
+	if status, ok := strings.CutPrefix(eventType, jobEventPrefix); ok {
+		return TopicJobs + status
+	}
+	return TopicEvents + eventType
+}
+
+// HandleCommands runs the received commands through handler; without one
+// they are refused.
+func (t *Transport) HandleCommands(handler CommandHandler) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.commands = handler
+}
+
+func (t *Transport) handleCommand(msg Message) {
+	body := msg.Body
+	topic := msg.Topic
+	if len(msg.Frames) == 1 {
+		// single-frame "<topic> <json>" messages
+		var payload string
+		topic, payload, _ = strings.Cut(msg.Topic, " ")
+		body = []byte(payload)
+	}
+
+	var cmd Command
+	if err := json.Unmarshal(body, &cmd); err != nil {
+		t.reply(cmd, nil, fmt.Errorf("invalid command: %w", err))
+		return
+	}
+	cmd.Topic = topic
+	if cmd.Tool == "" {
+		t.reply(cmd, nil, errors.New("invalid command: tool is required"))
+		return
+	}
+
+	t.mu.RLock()
+	handler := t.commands
+	t.mu.RUnlock()
+	if handler == nil {
+		t.reply(cmd, nil, errors.New("commands are not handled"))
+		return
+	}
+	result, err := handler(t.ctx, cmd)
+	t.reply(cmd, result, err)
+}
+
+func (t *Transport) reply(cmd Command, result interface{}, err error) {
+	reply := CommandReply{ID: cmd.ID, Tool: cmd.Tool, Result: result}
+	topic := TopicReplies + "accepted"
+	if err != nil {
+		t.failed.Add(1)
+		reply.Error = err.Error()
+		topic = TopicReplies + "failed"
+		gl.Log("warn", fmt.Sprintf("ZMQ command %s refused", cmd.ID), err)
+	} else {
+		t.accepted.Add(1)
+	}
+	if t.pub == nil {
+		return
+	}
+	if err := t.PublishJSON(topic, reply); err != nil && !errors.Is(err, ErrClosed) {
+		gl.Log("debug", "ZMQ command reply not published", cmd.ID, err)
+	}
+}
+
+// Stats returns the counters of the transport.
+func (t *Transport) Stats() TransportStats {
+	stats := TransportStats{
+		Subscribed:       t.opts.Connect,
+		CommandsAccepted: t.accepted.Load(),
+		CommandsFailed:   t.failed.Load(),
+	}
+	if t.pub != nil {
+		pubStats := t.pub.Stats()
+		stats.Publisher = &pubStats
+	}
+	return stats
+}
+
+// Close stops forwarding streams and closes both sockets.
+func (t *Transport) Close() error {
+	t.cancel()
+
+	t.mu.Lock()
+	forwards := t.forwards
+	t.forwards = nil
+	t.mu.Unlock()
+	for _, f := range forwards {
+		go f.stream.UnregisterClient(f.client)
+	}
+
+	var err error
+	if t.sub != nil {
+		err = t.sub.Close()
+	}
+	if t.pub != nil {
+		err = errors.Join(err, t.pub.Close())
+	}
+	return err
+}
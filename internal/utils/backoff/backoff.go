@@ -0,0 +1,24 @@
+// Package backoff computes exponential retry delays.
+package backoff
+
+import (
+	"math"
+	"time"
+)
+
+// Delay returns the pause after the given failed attempt (1 for the first):
+// base for the first attempt, doubling for each further one, up to max when
+// max is positive.
+func Delay(attempt int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay > 0 && delay <= math.MaxInt64/2; i++ {
+		if max > 0 && delay >= max {
+			break
+		}
+		delay *= 2
+	}
+	if max > 0 && delay > max {
+		return max
+	}
+	return delay
+}
@@ -0,0 +1,380 @@
+package testsamqp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/kubex-ecosystem/gobe/internal/config"
+	"github.com/kubex-ecosystem/gobe/internal/services/mcp"
+	"github.com/kubex-ecosystem/gobe/internal/sockets/messagery"
+)
+
+const queue = "test.queue"
+
+// fakeBroker implements messagery.ChannelSource and messagery.Channel over
+// in-memory deliveries, recording what the consumer acks and publishes.
+type fakeBroker struct {
+	mu         sync.Mutex
+	deliveries chan messagery.Delivery
+	cancelled  bool
+	queues     map[string]amqp.Table
+	bindings   []string
+	published  []publishedMsg
+	acked      []uint64
+	nacked     []uint64
+	publishErr error
+	prefetch   int
+	tag        uint64
+}
+
+type publishedMsg struct {
+	exchange, key string
+	msg           amqp.Publishing
+}
+
+func newBroker() *fakeBroker {
+	return &fakeBroker{
+		deliveries: make(chan messagery.Delivery, 16),
+		queues:     make(map[string]amqp.Table),
+	}
+}
+
+func (b *fakeBroker) IsReady() bool { return true }
+
+func (b *fakeBroker) ConsumerChannel() (messagery.Channel, error) { return b, nil }
+
+func (b *fakeBroker) Qos(prefetch int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.prefetch = prefetch
+	return nil
+}
+
+func (b *fakeBroker) Consume(queue, tag string) (<-chan messagery.Delivery, error) {
+	return b.deliveries, nil
+}
+
+func (b *fakeBroker) Cancel(tag string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.cancelled {
+		b.cancelled = true
+		close(b.deliveries)
+	}
+	return nil
+}
+
+func (b *fakeBroker) DeclareExchange(name, kind string) error { return nil }
+
+func (b *fakeBroker) DeclareQueue(name string, args amqp.Table) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.queues[name] = args
+	return nil
+}
+
+func (b *fakeBroker) BindQueue(name, key, exchange string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.bindings = append(b.bindings, exchange+"/"+key+"->"+name)
+	return nil
+}
+
+func (b *fakeBroker) Publish(ctx context.Context, exchange, key string, msg amqp.Publishing) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.publishErr != nil {
+		return b.publishErr
+	}
+	b.published = append(b.published, publishedMsg{exchange: exchange, key: key, msg: msg})
+	return nil
+}
+
+func (b *fakeBroker) Close() error { return nil }
+
+func (b *fakeBroker) Ack(tag uint64, multiple bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.acked = append(b.acked, tag)
+	return nil
+}
+
+func (b *fakeBroker) Nack(tag uint64, multiple, requeue bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nacked = append(b.nacked, tag)
+	return nil
+}
+
+func (b *fakeBroker) Reject(tag uint64, requeue bool) error { return b.Nack(tag, false, requeue) }
+
+// deliver sends body with headers and returns its delivery tag.
+func (b *fakeBroker) deliver(body string, headers amqp.Table) uint64 {
+	b.mu.Lock()
+	b.tag++
+	tag := b.tag
+	b.mu.Unlock()
+	b.deliveries <- messagery.Delivery{
+		Acknowledger: b,
+		DeliveryTag:  tag,
+		Headers:      headers,
+		RoutingKey:   "mcp.task.test.echo",
+		ReplyTo:      "replies",
+		Body:         []byte(body),
+	}
+	return tag
+}
+
+// settled waits until n deliveries were acked or nacked.
+func (b *fakeBroker) settled(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		b.mu.Lock()
+		done := len(b.acked)+len(b.nacked) >= n
+		b.mu.Unlock()
+		if done {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("deliveries not settled")
+}
+
+func startConsumer(t *testing.T, b *fakeBroker, opts messagery.ConsumerOptions, handler messagery.Handler) *messagery.Consumer {
+	t.Helper()
+	consumer := messagery.NewConsumer(b)
+	consumer.ReadyPoll = 10 * time.Millisecond
+	if err := consumer.Handle(queue, opts, handler); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+	if err := consumer.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		consumer.Shutdown(ctx)
+	})
+	return consumer
+}
+
+func TestConsumer_DecodesAndAcks(t *testing.T) {
+	b := newBroker()
+	type order struct {
+		ID string `json:"id"`
+	}
+	got := make(chan string, 1)
+	consumer := startConsumer(t, b, messagery.ConsumerOptions{Prefetch: 3}, messagery.JSONHandler(func(ctx context.Context, msg order, d messagery.Delivery) error {
+		got <- msg.ID
+		return nil
+	}))
+
+	tag := b.deliver(`{"id":"o-1"}`, nil)
+	b.settled(t, 1)
+	if id := <-got; id != "o-1" {
+		t.Fatalf("decoded %q", id)
+	}
+	if len(b.acked) != 1 || b.acked[0] != tag || len(b.published) != 0 {
+		t.Fatalf("acked %v, published %v", b.acked, b.published)
+	}
+	if b.prefetch != 3 {
+		t.Fatalf("prefetch = %d", b.prefetch)
+	}
+	stats := consumer.Stats()
+	if len(stats) != 1 || stats[0].Received != 1 || stats[0].Succeeded != 1 || !stats[0].Running {
+		t.Fatalf("stats = %+v", stats)
+	}
+}
+
+func TestConsumer_RetriesThenDeadLetters(t *testing.T) {
+	b := newBroker()
+	opts := messagery.ConsumerOptions{MaxAttempts: 3, RetryDelay: time.Second, MaxRetryDelay: time.Minute}
+	consumer := startConsumer(t, b, opts, func(ctx context.Context, d messagery.Delivery) error {
+		return errors.New("backend down")
+	})
+
+	b.deliver(`{}`, nil)
+	b.settled(t, 1)
+	b.deliver(`{}`, amqp.Table{messagery.HeaderAttempts: int32(2)})
+	b.settled(t, 2)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.published) != 2 || len(b.acked) != 2 {
+		t.Fatalf("published %d, acked %d", len(b.published), len(b.acked))
+	}
+	retry := b.published[0]
+	if retry.exchange != "" || retry.key != queue+".retry.1s" || retry.msg.Headers[messagery.HeaderAttempts] != int32(1) {
+		t.Fatalf("retry published to %q/%q with %v", retry.exchange, retry.key, retry.msg.Headers)
+	}
+	if retry.msg.Headers[messagery.HeaderError] != "backend down" {
+		t.Fatalf("error header = %v", retry.msg.Headers[messagery.HeaderError])
+	}
+	dead := b.published[1]
+	if dead.exchange != messagery.DeadLetterExchange || dead.key != queue {
+		t.Fatalf("dead letter published to %q/%q", dead.exchange, dead.key)
+	}
+
+	// one TTL queue per delay, dead-lettering back into the queue
+	ttl := b.queues[queue+".retry.2s"]
+	if ttl["x-message-ttl"] != int64(2000) || ttl["x-dead-letter-routing-key"] != queue {
+		t.Fatalf("retry queue args = %v", ttl)
+	}
+	if _, ok := b.queues[queue+".dlq"]; !ok {
+		t.Fatalf("dead-letter queue not declared: %v", b.queues)
+	}
+	stats := consumer.Stats()[0]
+	if stats.Retried != 1 || stats.DeadLettered != 1 || stats.LastError != "backend down" {
+		t.Fatalf("stats = %+v", stats)
+	}
+}
+
+func TestConsumer_PermanentFailuresSkipRetries(t *testing.T) {
+	b := newBroker()
+	startConsumer(t, b, messagery.ConsumerOptions{}, messagery.JSONHandler(func(ctx context.Context, msg map[string]string, d messagery.Delivery) error {
+		return nil
+	}))
+
+	b.deliver(`not json`, nil)
+	b.settled(t, 1)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.published) != 1 || b.published[0].exchange != messagery.DeadLetterExchange {
+		t.Fatalf("published %+v", b.published)
+	}
+}
+
+func TestConsumer_RequeuesWhenMoveFails(t *testing.T) {
+	b := newBroker()
+	b.publishErr = errors.New("channel closed")
+	startConsumer(t, b, messagery.ConsumerOptions{}, func(ctx context.Context, d messagery.Delivery) error {
+		panic("boom")
+	})
+
+	tag := b.deliver(`{}`, nil)
+	b.settled(t, 1)
+	if len(b.nacked) != 1 || b.nacked[0] != tag || len(b.acked) != 0 {
+		t.Fatalf("acked %v, nacked %v", b.acked, b.nacked)
+	}
+}
+
+func TestConsumer_ShutdownDrains(t *testing.T) {
+	b := newBroker()
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+	consumer := startConsumer(t, b, messagery.ConsumerOptions{Concurrency: 1}, func(ctx context.Context, d messagery.Delivery) error {
+		started <- struct{}{}
+		select {
+		case <-release:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+
+	b.deliver(`{}`, nil)
+	b.deliver(`{}`, nil)
+	<-started
+
+	done := make(chan error, 1)
+	go func() { done <- consumer.Shutdown(context.Background()) }()
+	select {
+	case err := <-done:
+		t.Fatalf("Shutdown returned before draining: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if len(b.acked) != 2 {
+		t.Fatalf("acked %v, the prefetched message must be handled too", b.acked)
+	}
+
+	// past the deadline, handlers are cancelled
+	b = newBroker()
+	consumer = startConsumer(t, b, messagery.ConsumerOptions{}, func(ctx context.Context, d messagery.Delivery) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	b.deliver(`{}`, nil)
+	time.Sleep(20 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := consumer.Shutdown(ctx); err == nil {
+		t.Fatalf("Shutdown reported a drain past its deadline")
+	}
+}
+
+func TestConsumerOptionsFromConfig(t *testing.T) {
+	cfg := config.AMQPConfig{
+		Consumer: config.AMQPConsumerConfig{Prefetch: 20, MaxAttempts: 2},
+		Queues:   map[string]config.AMQPConsumerConfig{queue: {Prefetch: 1, RetryDelaySeconds: 10, MaxRetryDelaySeconds: 30}},
+	}
+	opts := messagery.ConsumerOptionsFromConfig(cfg, queue)
+	if opts.Prefetch != 1 || opts.MaxAttempts != 2 || opts.Concurrency != messagery.DefaultConsumerOptions.Concurrency {
+		t.Fatalf("options = %+v", opts)
+	}
+	if other := messagery.ConsumerOptionsFromConfig(cfg, "other"); other.Prefetch != 20 {
+		t.Fatalf("other queue prefetch = %d", other.Prefetch)
+	}
+	for attempt, want := range map[int]time.Duration{1: 10 * time.Second, 2: 20 * time.Second, 3: 30 * time.Second, 9: 30 * time.Second} {
+		if got := opts.Delay(attempt); got != want {
+			t.Fatalf("Delay(%d) = %s, want %s", attempt, got, want)
+		}
+	}
+}
+
+func TestTaskHandler_RunsToolsAndReplies(t *testing.T) {
+	registry := mcp.NewRegistry()
+	err := registry.Register(mcp.ToolSpec{
+		Name: "test.echo",
+		Handler: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			if p := mcp.PrincipalFromContext(ctx); p == nil || p.ID != "amqp" {
+				return nil, errors.New("wrong principal")
+			}
+			return args["text"], nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	var mu sync.Mutex
+	var replies []mcp.TaskResult
+	reply := func(d messagery.Delivery, body []byte) error {
+		var result mcp.TaskResult
+		json.Unmarshal(body, &result)
+		mu.Lock()
+		replies = append(replies, result)
+		mu.Unlock()
+		return nil
+	}
+
+	b := newBroker()
+	handler := mcp.TaskHandler(registry, &mcp.Principal{ID: "amqp", Source: "amqp"}, reply)
+	startConsumer(t, b, messagery.ConsumerOptions{Concurrency: 1}, handler)
+
+	b.deliver(`{"id":"t-1","args":{"text":"hi"}}`, nil)
+	b.deliver(`{"id":"t-2","tool":"test.missing"}`, nil)
+	b.settled(t, 2)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(replies) != 2 || replies[0].Tool != "test.echo" || replies[0].Result != "hi" {
+		t.Fatalf("replies = %+v", replies)
+	}
+	if replies[1].Error == "" {
+		t.Fatalf("unknown tool replied %+v", replies[1])
+	}
+	// the unknown tool is dead-lettered without retries
+	if len(b.published) != 1 || b.published[0].exchange != messagery.DeadLetterExchange {
+		t.Fatalf("published %+v", b.published)
+	}
+}
@@ -0,0 +1,180 @@
+package testsapproval
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	telegram_controller "github.com/kubex-ecosystem/gobe/internal/app/controllers/app/chatbots/telegram"
+	"github.com/kubex-ecosystem/gobe/internal/config"
+	"github.com/kubex-ecosystem/gobe/internal/observers/approval"
+	"github.com/kubex-ecosystem/gobe/internal/services/chatbot/telegram"
+	"github.com/kubex-ecosystem/gobe/internal/services/mcp"
+)
+
+// botCall is a request the fake Bot API received.
+type botCall struct {
+	Method string
+	Body   map[string]interface{}
+}
+
+// fakeBotAPI answers Bot API methods, giving sent messages the ID 7.
+func fakeBotAPI(t *testing.T) (*httptest.Server, chan botCall) {
+	t.Helper()
+	calls := make(chan botCall, 16)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		calls <- botCall{Method: method, Body: body}
+		result := `true`
+		if method == "sendMessage" {
+			result = `{"message_id":7}`
+		}
+		w.Write([]byte(`{"ok":true,"result":` + result + `}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, calls
+}
+
+func nextCall(t *testing.T, calls chan botCall, method string) botCall {
+	t.Helper()
+	for {
+		select {
+		case call := <-calls:
+			if call.Method == method {
+				return call
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("no %s call", method)
+		}
+	}
+}
+
+// approvalHub grants roles by user ID, as the MCP bindings would.
+type approvalHub struct {
+	manager *approval.Manager
+	roles   map[string][]string
+}
+
+func (h *approvalHub) GetApprovalManager() *approval.Manager { return h.manager }
+
+func (h *approvalHub) ApproverFor(principal *mcp.Principal) approval.Approver {
+	return approval.Approver{ID: principal.ID, Name: principal.Username, Roles: h.roles[principal.ID]}
+}
+
+func postUpdate(router *gin.Engine, secret string, update map[string]interface{}) int {
+	body, _ := json.Marshal(update)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/telegram/webhook", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		req.Header.Set("X-Telegram-Bot-Api-Secret-Token", secret)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func callbackQuery(userID float64, data string) map[string]interface{} {
+	return map[string]interface{}{"callback_query": map[string]interface{}{
+		"id":      "query-1",
+		"data":    data,
+		"from":    map[string]interface{}{"id": userID, "username": "user"},
+		"message": map[string]interface{}{"message_id": 7.0, "chat": map[string]interface{}{"id": 42.0}},
+	}}
+}
+
+func telegramRouter(cfg config.TelegramConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	controller := telegram_controller.NewController(nil, telegram.NewService(cfg))
+	router.POST("/api/v1/telegram/webhook", controller.HandleWebhook)
+	return router
+}
+
+func TestParseCallbackData(t *testing.T) {
+	if id, approve, ok := approval.ParseCallbackData(approval.CallbackData("abc", true)); !ok || !approve || id != "abc" {
+		t.Fatalf("approve payload = %q %v %v", id, approve, ok)
+	}
+	if id, approve, ok := approval.ParseCallbackData(approval.CallbackData("abc", false)); !ok || approve || id != "abc" {
+		t.Fatalf("reject payload = %q %v %v", id, approve, ok)
+	}
+	for _, data := range []string{"", "approval:", "approval:approve:", "approval:maybe:abc", "menu:approve:abc"} {
+		if _, _, ok := approval.ParseCallbackData(data); ok {
+			t.Fatalf("%q parsed as an approval payload", data)
+		}
+	}
+}
+
+func TestTelegram_InlineKeyboardDecidesAndEditsMessage(t *testing.T) {
+	server, calls := fakeBotAPI(t)
+	cfg := config.TelegramConfig{Enabled: true, BotToken: "token", APIURL: server.URL, WebhookSecret: "s3cret"}
+
+	manager := newManager(t, config.ApprovalConfig{})
+	manager.SetPolicy("deploy", approval.Policy{Roles: []string{"ops"}, Timeout: time.Minute})
+	manager.AddPresenter(telegram.NewApprovalPresenter(telegram.NewService(cfg), 42))
+	telegram_controller.SetApprovalHub(&approvalHub{manager: manager, roles: map[string][]string{"200": {"ops"}}})
+	t.Cleanup(func() { telegram_controller.SetApprovalHub(nil) })
+
+	req := submit(t, manager, "deploy")
+	sent := nextCall(t, calls, "sendMessage")
+	markup, _ := json.Marshal(sent.Body["reply_markup"])
+	if sent.Body["chat_id"] != 42.0 || !strings.Contains(string(markup), approval.CallbackData(req.ID, true)) {
+		t.Fatalf("sendMessage = %+v", sent.Body)
+	}
+	if stored, _ := manager.GetRequest(context.Background(), req.ID); stored.Messages["telegram"] != "42:7" {
+		t.Fatalf("messages = %+v", stored.Messages)
+	}
+
+	router := telegramRouter(cfg)
+	if code := postUpdate(router, "wrong", callbackQuery(200, approval.CallbackData(req.ID, true))); code != http.StatusUnauthorized {
+		t.Fatalf("wrong secret = %d", code)
+	}
+
+	postUpdate(router, "s3cret", callbackQuery(100, approval.CallbackData(req.ID, true)))
+	if answer := nextCall(t, calls, "answerCallbackQuery"); answer.Body["text"] != approval.ChatError(approval.ErrNotEligible) {
+		t.Fatalf("answer to a user without role = %+v", answer.Body)
+	}
+
+	postUpdate(router, "s3cret", callbackQuery(200, approval.CallbackData(req.ID, true)))
+	if answer := nextCall(t, calls, "answerCallbackQuery"); answer.Body["text"] != "✅ Aprovado" {
+		t.Fatalf("answer to the approver = %+v", answer.Body)
+	}
+	edited := nextCall(t, calls, "editMessageText")
+	text, _ := edited.Body["text"].(string)
+	markup, _ = json.Marshal(edited.Body["reply_markup"])
+	if edited.Body["message_id"] != 7.0 || !strings.HasPrefix(text, "✅ Aprovado") || string(markup) != `{"inline_keyboard":[]}` {
+		t.Fatalf("editMessageText = %+v", edited.Body)
+	}
+
+	resp := wait(t, manager, req.ID)
+	if resp.ApproverID != "200" {
+		t.Fatalf("response = %+v", resp)
+	}
+}
+
+func TestTelegram_UnverifiedUpdatesCannotDecide(t *testing.T) {
+	server, calls := fakeBotAPI(t)
+	cfg := config.TelegramConfig{Enabled: true, BotToken: "token", APIURL: server.URL}
+
+	manager := newManager(t, config.ApprovalConfig{})
+	telegram_controller.SetApprovalHub(&approvalHub{manager: manager})
+	t.Cleanup(func() { telegram_controller.SetApprovalHub(nil) })
+	req := submit(t, manager, "deploy")
+
+	if code := postUpdate(telegramRouter(cfg), "", callbackQuery(200, approval.CallbackData(req.ID, true))); code != http.StatusOK {
+		t.Fatalf("update = %d", code)
+	}
+	if answer := nextCall(t, calls, "answerCallbackQuery"); !strings.Contains(answer.Body["text"].(string), "webhook_secret") {
+		t.Fatalf("answer = %+v", answer.Body)
+	}
+	if pending := manager.GetPendingApprovals(); len(pending) != 1 || len(pending[0].Votes) != 0 {
+		t.Fatalf("pending = %+v", pending)
+	}
+}
@@ -0,0 +1,311 @@
+package testsapproval
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kubex-ecosystem/gobe/internal/config"
+	"github.com/kubex-ecosystem/gobe/internal/observers/approval"
+)
+
+var (
+	alice = approval.Approver{ID: "alice", Name: "Alice", Roles: []string{"ops"}}
+	bob   = approval.Approver{ID: "bob", Roles: []string{"OPS"}}
+	carol = approval.Approver{ID: "carol", Roles: []string{"admin"}}
+	dave  = approval.Approver{ID: "dave"}
+)
+
+func newManager(t *testing.T, cfg config.ApprovalConfig) *approval.Manager {
+	t.Helper()
+	manager := approval.NewManager(cfg, nil)
+	t.Cleanup(manager.Close)
+	return manager
+}
+
+func submit(t *testing.T, manager *approval.Manager, action string) *approval.Request {
+	t.Helper()
+	req, err := manager.Submit(context.Background(), approval.Request{
+		Action:      action,
+		Platform:    "discord",
+		RequestedBy: "requester",
+		Details:     map[string]interface{}{"command": "rm -rf /tmp/cache"},
+	})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	return req
+}
+
+func decide(t *testing.T, manager *approval.Manager, id string, approved bool, approver approval.Approver) *approval.Response {
+	t.Helper()
+	resp, err := manager.Decide(context.Background(), id, approved, approver, "")
+	if err != nil {
+		t.Fatalf("Decide by %s: %v", approver.ID, err)
+	}
+	return resp
+}
+
+func wait(t *testing.T, manager *approval.Manager, id string) *approval.Response {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	resp, err := manager.Wait(ctx, id)
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	return resp
+}
+
+func historyKinds(t *testing.T, manager *approval.Manager, id string) []string {
+	t.Helper()
+	history, err := manager.History(context.Background(), id)
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	kinds := make([]string, 0, len(history))
+	for _, decision := range history {
+		kinds = append(kinds, decision.Kind)
+	}
+	return kinds
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestManager_QuorumRolesAndAuditTrail(t *testing.T) {
+	manager := newManager(t, config.ApprovalConfig{})
+	manager.SetPolicy("deploy", approval.Policy{Roles: []string{"ops"}, Quorum: 2, Timeout: time.Minute})
+
+	handled := make(chan approval.Response, 1)
+	manager.Handle("deploy", func(ctx context.Context, req approval.Request, resp approval.Response) {
+		if req.Details["command"] != "rm -rf /tmp/cache" {
+			t.Errorf("handler details = %+v", req.Details)
+		}
+		handled <- resp
+	})
+
+	req := submit(t, manager, "deploy")
+	if req.Policy.Quorum != 2 || req.Status != approval.StatusPending || len(manager.GetPendingApprovals()) != 1 {
+		t.Fatalf("submitted = %+v", req)
+	}
+
+	waited := make(chan *approval.Response, 1)
+	go func() {
+		resp, _ := manager.Wait(context.Background(), req.ID)
+		waited <- resp
+	}()
+
+	if _, err := manager.Decide(context.Background(), req.ID, true, dave, ""); !errors.Is(err, approval.ErrNotEligible) {
+		t.Fatalf("Decide without role = %v", err)
+	}
+	if resp := decide(t, manager, req.ID, true, alice); resp.Status != approval.StatusPending || resp.Approvals != 1 {
+		t.Fatalf("first vote = %+v", resp)
+	}
+	if _, err := manager.Decide(context.Background(), req.ID, true, alice, ""); !errors.Is(err, approval.ErrAlreadyVoted) {
+		t.Fatalf("second vote of the same approver = %v", err)
+	}
+
+	resp := decide(t, manager, req.ID, true, bob)
+	if !resp.Approved || resp.ApproverID != "bob" || !equal(resp.Approvers, []string{"alice", "bob"}) {
+		t.Fatalf("quorum vote = %+v", resp)
+	}
+	if got := <-waited; got == nil || got.Status != approval.StatusApproved || got.ApproverID != "bob" {
+		t.Fatalf("Wait = %+v", got)
+	}
+	select {
+	case got := <-handled:
+		if !got.Approved {
+			t.Fatalf("handler response = %+v", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handler not called")
+	}
+
+	if _, err := manager.Decide(context.Background(), req.ID, false, carol, ""); !errors.Is(err, approval.ErrNotPending) {
+		t.Fatalf("Decide after the decision = %v", err)
+	}
+	if _, err := manager.Decide(context.Background(), "missing", true, alice, ""); !errors.Is(err, approval.ErrNotFound) {
+		t.Fatalf("Decide unknown request = %v", err)
+	}
+	if kinds := historyKinds(t, manager, req.ID); !equal(kinds, []string{"requested", "approve", "approve", "approved"}) {
+		t.Fatalf("history = %v", kinds)
+	}
+	if len(manager.GetPendingApprovals()) != 0 {
+		t.Fatal("decided request still pending")
+	}
+}
+
+func TestManager_SingleRejectionVetoes(t *testing.T) {
+	manager := newManager(t, config.ApprovalConfig{})
+	manager.SetPolicy("scale", approval.Policy{Quorum: 3, Timeout: time.Minute})
+	req := submit(t, manager, "scale")
+
+	decide(t, manager, req.ID, true, alice)
+	resp, err := manager.Decide(context.Background(), req.ID, false, bob, "not during the freeze")
+	if err != nil {
+		t.Fatalf("Decide: %v", err)
+	}
+	if resp.Status != approval.StatusRejected || resp.ApproverID != "bob" {
+		t.Fatalf("veto = %+v", resp)
+	}
+
+	history, _ := manager.History(context.Background(), req.ID)
+	last := history[len(history)-1]
+	if last.Kind != approval.KindRejected || last.Approver.ID != "bob" || last.Comment != "not during the freeze" {
+		t.Fatalf("last decision = %+v", last)
+	}
+}
+
+func TestManager_ExpiryAndAutoReject(t *testing.T) {
+	manager := newManager(t, config.ApprovalConfig{})
+	manager.SetPolicy("shell_command", approval.Policy{Timeout: 20 * time.Millisecond})
+	manager.SetPolicy("deploy", approval.Policy{Timeout: 20 * time.Millisecond, AutoReject: true})
+
+	expired := submit(t, manager, "shell_command")
+	if resp := wait(t, manager, expired.ID); resp.Status != approval.StatusExpired || resp.Approved {
+		t.Fatalf("expired = %+v", resp)
+	}
+	if kinds := historyKinds(t, manager, expired.ID); !equal(kinds, []string{"requested", "expired"}) {
+		t.Fatalf("expired history = %v", kinds)
+	}
+
+	rejected := submit(t, manager, "deploy")
+	if resp := wait(t, manager, rejected.ID); resp.Status != approval.StatusRejected || resp.ApproverID != "" {
+		t.Fatalf("auto-rejected = %+v", resp)
+	}
+	if kinds := historyKinds(t, manager, rejected.ID); !equal(kinds, []string{"requested", "expired", "rejected"}) {
+		t.Fatalf("auto-rejected history = %v", kinds)
+	}
+	if _, err := manager.Decide(context.Background(), rejected.ID, true, alice, ""); !errors.Is(err, approval.ErrNotPending) {
+		t.Fatalf("Decide after expiry = %v", err)
+	}
+}
+
+func TestManager_Escalation(t *testing.T) {
+	manager := newManager(t, config.ApprovalConfig{})
+	manager.SetPolicy("deploy", approval.Policy{
+		Roles:           []string{"ops"},
+		Timeout:         time.Minute,
+		EscalateAfter:   20 * time.Millisecond,
+		EscalationRoles: []string{"admin"},
+	})
+	req := submit(t, manager, "deploy")
+
+	if _, err := manager.Decide(context.Background(), req.ID, true, carol, ""); !errors.Is(err, approval.ErrNotEligible) {
+		t.Fatalf("escalation role before escalating = %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		current, err := manager.GetRequest(context.Background(), req.ID)
+		if err != nil {
+			t.Fatalf("GetRequest: %v", err)
+		}
+		if current.Escalated {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("request not escalated")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if resp := decide(t, manager, req.ID, true, carol); !resp.Approved {
+		t.Fatalf("escalated vote = %+v", resp)
+	}
+	if kinds := historyKinds(t, manager, req.ID); !equal(kinds, []string{"requested", "escalated", "approve", "approved"}) {
+		t.Fatalf("history = %v", kinds)
+	}
+}
+
+func TestManager_RestoresPendingRequests(t *testing.T) {
+	cfg := config.ApprovalConfig{Policies: map[string]config.ApprovalPolicyConfig{
+		"deploy": {Roles: []string{"ops"}, Quorum: 2, TimeoutMinutes: 10},
+	}}
+	store := approval.NewMemoryStore()
+
+	before := approval.NewManager(cfg, nil)
+	if err := before.SetStore(store); err != nil {
+		t.Fatalf("SetStore: %v", err)
+	}
+	req := submit(t, before, "deploy")
+	decide(t, before, req.ID, true, alice)
+	before.SetPolicy("shell_command", approval.Policy{Timeout: 20 * time.Millisecond})
+	overdue := submit(t, before, "shell_command")
+	before.Close()
+	time.Sleep(30 * time.Millisecond)
+
+	after := newManager(t, cfg)
+	handled := make(chan approval.Request, 1)
+	after.Handle("deploy", func(ctx context.Context, req approval.Request, resp approval.Response) {
+		handled <- req
+	})
+	if err := after.SetStore(store); err != nil {
+		t.Fatalf("SetStore: %v", err)
+	}
+
+	if resp := wait(t, after, overdue.ID); resp.Status != approval.StatusExpired {
+		t.Fatalf("overdue request = %+v", resp)
+	}
+	pending := after.GetPendingApprovals()
+	if len(pending) != 1 || pending[0].ID != req.ID || len(pending[0].Votes) != 1 || pending[0].Policy.Quorum != 2 {
+		t.Fatalf("restored = %+v", pending)
+	}
+	if pending[0].Details["command"] != "rm -rf /tmp/cache" || pending[0].RequestedBy != "requester" {
+		t.Fatalf("restored details = %+v", pending[0])
+	}
+
+	if _, err := after.Decide(context.Background(), req.ID, true, alice, ""); !errors.Is(err, approval.ErrAlreadyVoted) {
+		t.Fatalf("vote restored = %v", err)
+	}
+	if resp := decide(t, after, req.ID, true, bob); !resp.Approved || !equal(resp.Approvers, []string{"alice", "bob"}) {
+		t.Fatalf("decision after restart = %+v", resp)
+	}
+	select {
+	case got := <-handled:
+		if got.ID != req.ID || got.Status != approval.StatusApproved {
+			t.Fatalf("handled = %+v", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handler not called after restart")
+	}
+
+	decided, err := after.GetRequest(context.Background(), req.ID)
+	if err != nil || decided.Status != approval.StatusApproved || decided.DecidedAt == nil {
+		t.Fatalf("GetRequest = %+v, %v", decided, err)
+	}
+	if resp := wait(t, after, req.ID); resp.ApproverID != "bob" || resp.Approvals != 2 {
+		t.Fatalf("Wait on a decided request = %+v", resp)
+	}
+}
+
+func TestManager_ProcessApprovalRecordsApprover(t *testing.T) {
+	manager := newManager(t, config.ApprovalConfig{ApprovalTimeoutMinutes: 5})
+	req := submit(t, manager, "anything")
+	if got := req.ExpiresAt.Sub(req.CreatedAt); got != 5*time.Minute {
+		t.Fatalf("default timeout = %v", got)
+	}
+
+	if err := manager.ProcessApproval(req.ID, true, "alice"); err != nil {
+		t.Fatalf("ProcessApproval: %v", err)
+	}
+	resp := wait(t, manager, req.ID)
+	if !resp.Approved || resp.ApproverID != "alice" {
+		t.Fatalf("response = %+v", resp)
+	}
+	history, _ := manager.History(context.Background(), req.ID)
+	if len(history) != 3 || history[1].Approver.ID != "alice" || history[2].Approver.ID != "alice" {
+		t.Fatalf("history = %+v", history)
+	}
+}
@@ -0,0 +1,212 @@
+package testsbroker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kubex-ecosystem/gobe/internal/sockets/messagery"
+)
+
+func startMemory(t *testing.T, maxLength int) *messagery.MemoryBroker {
+	t.Helper()
+	b := messagery.NewMemoryBroker(maxLength)
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() { b.Close() })
+	return b
+}
+
+// consume returns the deliveries of queue on a new channel.
+func consume(t *testing.T, source messagery.ChannelSource, queue string) (messagery.Channel, <-chan messagery.Delivery) {
+	t.Helper()
+	ch, err := source.ConsumerChannel()
+	if err != nil {
+		t.Fatalf("ConsumerChannel: %v", err)
+	}
+	deliveries, err := ch.Consume(queue, "test."+queue)
+	if err != nil {
+		t.Fatalf("Consume %s: %v", queue, err)
+	}
+	return ch, deliveries
+}
+
+func receive(t *testing.T, deliveries <-chan messagery.Delivery) messagery.Delivery {
+	t.Helper()
+	select {
+	case d := <-deliveries:
+		return d
+	case <-time.After(5 * time.Second):
+		t.Fatal("no delivery")
+	}
+	return messagery.Delivery{}
+}
+
+func nothing(t *testing.T, deliveries <-chan messagery.Delivery) {
+	t.Helper()
+	select {
+	case d := <-deliveries:
+		t.Fatalf("unexpected delivery %s (%s)", d.Body, d.RoutingKey)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestMemoryBroker_RoutesLikeAMQP(t *testing.T) {
+	b := startMemory(t, 0)
+	for _, queue := range []string{"one", "all", "fan", "direct"} {
+		if err := b.DeclareQueue(queue, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bindings := []messagery.BindingSpec{
+		{Queue: "one", Exchange: "gobe.events", Key: "order.*"},
+		{Queue: "all", Exchange: "gobe.events", Key: "order.#"},
+		{Queue: "fan", Exchange: "gobe.notifications"},
+		{Queue: "direct", Exchange: "gobe.logs", Key: "audit"},
+	}
+	if err := messagery.DeclareTopology(b, messagery.Topology{Bindings: bindings}); err != nil {
+		t.Fatal(err)
+	}
+	_, one := consume(t, b, "one")
+	_, all := consume(t, b, "all")
+	_, fan := consume(t, b, "fan")
+	_, direct := consume(t, b, "direct")
+
+	b.Publish("gobe.events", "order.created", []byte("1"))
+	b.Publish("gobe.events", "order.item.added", []byte("2"))
+	b.Publish("gobe.notifications", "whatever", []byte("3"))
+	b.Publish("gobe.logs", "audit", []byte("4"))
+	b.Publish("gobe.logs", "other", []byte("5"))
+	b.Publish("", "direct", []byte("6"))
+
+	if d := receive(t, one); string(d.Body) != "1" || d.RoutingKey != "order.created" || d.Exchange != "gobe.events" {
+		t.Fatalf("one got %s via %s/%s", d.Body, d.Exchange, d.RoutingKey)
+	}
+	nothing(t, one)
+	if got := string(receive(t, all).Body) + string(receive(t, all).Body); got != "12" {
+		t.Fatalf("all got %q", got)
+	}
+	if d := receive(t, fan); string(d.Body) != "3" {
+		t.Fatalf("fan got %s", d.Body)
+	}
+	if got := string(receive(t, direct).Body) + string(receive(t, direct).Body); got != "46" {
+		t.Fatalf("direct got %q", got)
+	}
+	nothing(t, direct)
+
+	stats := b.ConnectionStats()
+	if stats["broker"] != messagery.BrokerMemory || stats["unroutable"] != uint64(1) {
+		t.Fatalf("stats = %v", stats)
+	}
+}
+
+func TestMemoryBroker_DropsOldestWhenFull(t *testing.T) {
+	b := startMemory(t, 2)
+	b.DeclareQueue("small", nil)
+	for _, body := range []string{"1", "2", "3"} {
+		if err := b.Publish("", "small", []byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, deliveries := consume(t, b, "small")
+	if got := string(receive(t, deliveries).Body) + string(receive(t, deliveries).Body); got != "23" {
+		t.Fatalf("got %q", got)
+	}
+	if dropped := b.ConnectionStats()["dropped"]; dropped != uint64(1) {
+		t.Fatalf("dropped = %v", dropped)
+	}
+}
+
+func TestMemoryBroker_ClosingAChannelRequeuesUnacked(t *testing.T) {
+	b := startMemory(t, 0)
+	b.DeclareQueue("work", nil)
+	b.Publish("", "work", []byte("job"))
+
+	first, deliveries := consume(t, b, "work")
+	if d := receive(t, deliveries); d.Redelivered {
+		t.Fatal("first delivery marked redelivered")
+	}
+	first.Close()
+
+	_, deliveries = consume(t, b, "work")
+	d := receive(t, deliveries)
+	if string(d.Body) != "job" || !d.Redelivered {
+		t.Fatalf("got %s, redelivered %v", d.Body, d.Redelivered)
+	}
+	if err := d.Ack(false); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMemoryBroker_ConsumerRetriesThroughTTLQueues(t *testing.T) {
+	b := startMemory(t, 0)
+	consumer := messagery.NewConsumer(b)
+
+	var mu sync.Mutex
+	var keys []string
+	done := make(chan struct{})
+	opts := messagery.ConsumerOptions{MaxAttempts: 3, RetryDelay: 20 * time.Millisecond, MaxRetryDelay: time.Second}
+	err := consumer.Handle("gobe.mcp.tasks", opts, func(ctx context.Context, d messagery.Delivery) error {
+		mu.Lock()
+		defer mu.Unlock()
+		keys = append(keys, d.RoutingKey)
+		if attempt, _ := messagery.Attempt(ctx); attempt == 1 {
+			return errors.New("not yet")
+		}
+		close(done)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer consumer.Shutdown(context.Background())
+
+	// the retry topology is declared once the consumer runs
+	deadline := time.Now().Add(5 * time.Second)
+	for len(consumer.Stats()) == 0 || !consumer.Stats()[0].Running {
+		if time.Now().After(deadline) {
+			t.Fatal("consumer did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := b.Publish("gobe.events", "mcp.task.echo", []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("message was not retried")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	// the routing key survives the trip through the retry queue
+	if len(keys) != 2 || keys[0] != "mcp.task.echo" || keys[1] != "mcp.task.echo" {
+		t.Fatalf("routing keys = %v", keys)
+	}
+}
+
+func TestNewBroker_SelectsKind(t *testing.T) {
+	cases := map[string]string{"": messagery.BrokerMemory, "memory": messagery.BrokerMemory, "nats": messagery.BrokerNATS}
+	for kind, want := range cases {
+		b, err := messagery.NewBroker(brokerConfig(kind), nil)
+		if err != nil {
+			t.Fatalf("%q: %v", kind, err)
+		}
+		if b.Kind() != want {
+			t.Fatalf("%q: kind %s, want %s", kind, b.Kind(), want)
+		}
+	}
+	if _, err := messagery.NewBroker(brokerConfig("amqp"), nil); err == nil {
+		t.Fatal("amqp without a database config should fail")
+	}
+	if _, err := messagery.NewBroker(brokerConfig("kafka"), nil); err == nil {
+		t.Fatal("unknown kind should fail")
+	}
+}
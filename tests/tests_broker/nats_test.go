@@ -0,0 +1,339 @@
+package testsbroker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/kubex-ecosystem/gobe/internal/config"
+	"github.com/kubex-ecosystem/gobe/internal/sockets/messagery"
+)
+
+const natsToken = "s3cret"
+
+func brokerConfig(kind string) config.BrokerConfig {
+	return config.BrokerConfig{Kind: kind}
+}
+
+// runNATSServer runs an in-process nats-server with JetStream, storing its
+// streams in dir. Port 0 picks a free port.
+func runNATSServer(t *testing.T, port int, dir string) *server.Server {
+	t.Helper()
+	if port == 0 {
+		port = server.RANDOM_PORT
+	}
+	s, err := server.NewServer(&server.Options{
+		Host:          "127.0.0.1",
+		Port:          port,
+		JetStream:     true,
+		StoreDir:      dir,
+		Authorization: natsToken,
+		NoLog:         true,
+		NoSigs:        true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Start()
+	if !s.ReadyForConnections(10 * time.Second) {
+		t.Fatal("nats-server not ready")
+	}
+	t.Cleanup(s.Shutdown)
+	return s
+}
+
+func natsPort(s *server.Server) int { return s.Addr().(*net.TCPAddr).Port }
+
+func startNATS(t *testing.T, s *server.Server) *messagery.NATSBroker {
+	t.Helper()
+	b := messagery.NewNATSBroker(config.NATSConfig{URL: fmt.Sprintf("nats://%s@127.0.0.1:%d", natsToken, natsPort(s))})
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() { b.Close() })
+	waitFor(t, "broker ready", b.IsReady)
+	return b
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// inspect opens a JetStream client of its own on s.
+func inspect(t *testing.T, s *server.Server) jetstream.JetStream {
+	t.Helper()
+	nc, err := nats.Connect(s.ClientURL(), nats.Token(natsToken))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(nc.Close)
+	js, err := jetstream.New(nc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return js
+}
+
+// consumers returns the durable consumers of every stream by filter subject.
+func consumers(t *testing.T, js jetstream.JetStream) map[string]*jetstream.ConsumerInfo {
+	t.Helper()
+	ctx := context.Background()
+	found := make(map[string]*jetstream.ConsumerInfo)
+	streams := js.ListStreams(ctx)
+	for info := range streams.Info() {
+		stream, err := js.Stream(ctx, info.Config.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		infos := stream.ListConsumers(ctx)
+		for consumer := range infos.Info() {
+			found[consumer.Config.FilterSubject] = consumer
+		}
+		if err := infos.Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := streams.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return found
+}
+
+func TestNATSBroker_DeclaresTopologyOnJetStream(t *testing.T) {
+	s := runNATSServer(t, 0, t.TempDir())
+	b := startNATS(t, s)
+	js := inspect(t, s)
+	ctx := context.Background()
+
+	// the queues stream and one stream per exchange
+	var names []string
+	for name := range js.StreamNames(ctx).Name() {
+		names = append(names, name)
+	}
+	queues, err := js.Stream(ctx, "GOBE_QUEUES")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subjects := queues.CachedInfo().Config.Subjects; len(names) != 5 || len(subjects) != 1 || subjects[0] != "GOBE.q.>" {
+		t.Fatalf("streams %v, queues stream %v", names, subjects)
+	}
+	declared := consumers(t, js)
+	for _, filter := range []string{
+		"GOBE.q.gobe.mcp.tasks",
+		"GOBE.x.gobe.events.mcp.task.*",
+		"GOBE.x.gobe.events.system.*",
+		"GOBE.x.gobe.logs.system",
+	} {
+		if declared[filter] == nil {
+			t.Fatalf("no consumer filtered on %s", filter)
+		}
+	}
+
+	b.DeclareQueue("orders", nil)
+	if err := b.BindQueue("orders", "order.#", "gobe.events"); err != nil {
+		t.Fatal(err)
+	}
+	if consumers(t, js)["GOBE.x.gobe.events.order.>"] == nil {
+		t.Fatal("# not mapped to >")
+	}
+	if err := b.BindQueue("orders", "order.#.done", "gobe.events"); err == nil {
+		t.Fatal("# before the last word should be refused")
+	}
+	if err := b.BindQueue("orders", "x", "missing"); err == nil {
+		t.Fatal("binding to an undeclared exchange should fail")
+	}
+}
+
+func TestNATSBroker_PublishesConsumesAndAcks(t *testing.T) {
+	s := runNATSServer(t, 0, t.TempDir())
+	b := startNATS(t, s)
+	js := inspect(t, s)
+
+	_, deliveries := consume(t, b, "gobe.mcp.tasks")
+	if err := b.Publish("gobe.events", "mcp.task.echo", []byte(`{"id":"t1"}`)); err != nil {
+		t.Fatal(err)
+	}
+	d := receive(t, deliveries)
+	if string(d.Body) != `{"id":"t1"}` || d.Exchange != "gobe.events" || d.RoutingKey != "mcp.task.echo" || d.ContentType != "application/json" {
+		t.Fatalf("delivery %s via %s/%s (%s)", d.Body, d.Exchange, d.RoutingKey, d.ContentType)
+	}
+	if d.Redelivered {
+		t.Fatal("first delivery marked redelivered")
+	}
+	if err := d.Ack(false); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the ack", func() bool {
+		info := consumers(t, js)["GOBE.x.gobe.events.mcp.task.*"]
+		return info != nil && info.AckFloor.Consumer == 1 && info.NumAckPending == 0
+	})
+
+	if err := b.Publish("nowhere", "key", []byte(`{}`)); !errors.Is(err, messagery.ErrNoResponders) {
+		t.Fatalf("publish without a stream = %v", err)
+	}
+	if stats := b.ConnectionStats(); stats["broker"] != messagery.BrokerNATS || stats["published"] != uint64(1) || stats["server_version"] != server.VERSION {
+		t.Fatalf("stats = %v", stats)
+	}
+}
+
+func TestNATSBroker_NackRedeliversOrDeadLetters(t *testing.T) {
+	s := runNATSServer(t, 0, t.TempDir())
+	b := startNATS(t, s)
+	if err := b.DeclareQueue("jobs", map[string]interface{}{"x-dead-letter-exchange": messagery.DeadLetterExchange}); err != nil {
+		t.Fatal(err)
+	}
+	b.DeclareQueue("jobs.dlq", nil)
+	if err := b.BindQueue("jobs.dlq", "jobs", messagery.DeadLetterExchange); err != nil {
+		t.Fatal(err)
+	}
+
+	_, deliveries := consume(t, b, "jobs")
+	_, dead := consume(t, b, "jobs.dlq")
+	if err := b.Publish("", "jobs", []byte(`{"n":1}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := receive(t, deliveries).Nack(false, true); err != nil {
+		t.Fatal(err)
+	}
+	d := receive(t, deliveries)
+	if !d.Redelivered {
+		t.Fatal("requeued message not marked redelivered")
+	}
+	if err := d.Nack(false, false); err != nil {
+		t.Fatal(err)
+	}
+	if d := receive(t, dead); string(d.Body) != `{"n":1}` || d.Exchange != messagery.DeadLetterExchange {
+		t.Fatalf("dead letter %s via %s", d.Body, d.Exchange)
+	}
+	nothing(t, deliveries)
+}
+
+func TestNATSBroker_ConsumerRetriesThroughDelayedQueue(t *testing.T) {
+	s := runNATSServer(t, 0, t.TempDir())
+	b := startNATS(t, s)
+	consumer := messagery.NewConsumer(b)
+
+	var mu sync.Mutex
+	var attempts []time.Time
+	done := make(chan struct{})
+	opts := messagery.ConsumerOptions{MaxAttempts: 3, RetryDelay: 300 * time.Millisecond, MaxRetryDelay: time.Second}
+	err := consumer.Handle("gobe.mcp.tasks", opts, func(ctx context.Context, d messagery.Delivery) error {
+		mu.Lock()
+		defer mu.Unlock()
+		attempt, _ := messagery.Attempt(ctx)
+		attempts = append(attempts, time.Now())
+		if d.RoutingKey != "mcp.task.echo" {
+			return messagery.Permanent(fmt.Errorf("routing key %q", d.RoutingKey))
+		}
+		if attempt == 1 {
+			return errors.New("not yet")
+		}
+		close(done)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer consumer.Shutdown(context.Background())
+
+	js := inspect(t, s)
+	waitFor(t, "the retry queue", func() bool { return consumers(t, js)["GOBE.q.gobe.mcp.tasks.retry.300ms"] != nil })
+	if err := b.Publish("gobe.events", "mcp.task.echo", []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(15 * time.Second):
+		t.Fatal("message was not retried")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	// the delayed queue held the message until it was due
+	if len(attempts) != 2 || attempts[1].Sub(attempts[0]) < 300*time.Millisecond {
+		t.Fatalf("attempts at %v", attempts)
+	}
+}
+
+func TestNATSBroker_ReconnectsAndRedeclares(t *testing.T) {
+	dir := t.TempDir()
+	s := runNATSServer(t, 0, dir)
+	port := natsPort(s)
+	b := startNATS(t, s)
+	consumer := messagery.NewConsumer(b)
+	consumer.ReadyPoll = 50 * time.Millisecond
+	received := make(chan string, 2)
+	consumer.Handle("gobe.system.events", messagery.ConsumerOptions{}, func(ctx context.Context, d messagery.Delivery) error {
+		received <- string(d.Body)
+		return nil
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer consumer.Shutdown(context.Background())
+
+	s.Shutdown()
+	waitFor(t, "the disconnection", func() bool { return !b.IsReady() })
+	if err := b.Publish("gobe.events", "system.down", []byte(`"lost"`)); err == nil {
+		t.Fatal("publish while disconnected succeeded")
+	}
+
+	// a fresh server without the streams: the broker declares them again
+	s = runNATSServer(t, port, t.TempDir())
+	waitFor(t, "the reconnection", b.IsReady)
+	if stats := b.ConnectionStats(); stats["reconnects"].(int64) < 1 || stats["last_error"] == nil {
+		t.Fatalf("stats = %v", stats)
+	}
+	waitFor(t, "the consumer", func() bool { return consumer.Stats()[0].Running })
+	if err := b.Publish("gobe.events", "system.up", []byte(`"back"`)); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case body := <-received:
+		if body != `"back"` {
+			t.Fatalf("received %s", body)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("nothing consumed after the reconnection")
+	}
+}
+
+func TestNATSBroker_ReportsServerErrors(t *testing.T) {
+	s := runNATSServer(t, 0, t.TempDir())
+	b := messagery.NewNATSBroker(config.NATSConfig{URL: fmt.Sprintf("nats://127.0.0.1:%d", natsPort(s)), Token: "wrong"})
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer b.Close()
+
+	// the server answers -ERR 'Authorization Violation'; the broker keeps
+	// retrying and reports it
+	waitFor(t, "the error", func() bool {
+		return strings.Contains(strings.ToLower(fmt.Sprint(b.ConnectionStats()["last_error"])), "authorization")
+	})
+	if b.IsReady() {
+		t.Fatal("broker ready with a wrong token")
+	}
+	if err := b.Publish("gobe.events", "system.x", []byte(`{}`)); err == nil {
+		t.Fatal("publish without a connection succeeded")
+	}
+}
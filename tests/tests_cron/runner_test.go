@@ -0,0 +1,346 @@
+package testscron
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
+	"github.com/kubex-ecosystem/gobe/internal/services/gateway"
+	"github.com/kubex-ecosystem/gobe/internal/services/scheduler/runner"
+	"github.com/kubex-ecosystem/gobe/internal/sockets/messagery"
+	"gorm.io/gorm"
+)
+
+// jobStore keeps cron jobs in memory, standing for svc.CronRepo.
+type jobStore struct {
+	mu   sync.Mutex
+	jobs map[uuid.UUID]svc.CronModel
+}
+
+func newJobStore(jobs ...*svc.CronModel) *jobStore {
+	s := &jobStore{jobs: make(map[uuid.UUID]svc.CronModel)}
+	for _, job := range jobs {
+		if job.ID == uuid.Nil {
+			job.ID = uuid.New()
+		}
+		s.jobs[job.ID] = *job
+	}
+	return s
+}
+
+func (s *jobStore) FindByID(ctx context.Context, id uuid.UUID) (*svc.CronModel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &job, nil
+}
+
+func (s *jobStore) FindAll(ctx context.Context) ([]*svc.CronModel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var jobs []*svc.CronModel
+	for _, job := range s.jobs {
+		job := job
+		jobs = append(jobs, &job)
+	}
+	return jobs, nil
+}
+
+func (s *jobStore) Update(ctx context.Context, job *svc.CronModel) (*svc.CronModel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = *job
+	return job, nil
+}
+
+func newRunner(t *testing.T, opts runner.Options, jobs ...*svc.CronModel) (*runner.Runner, *jobStore, *runner.MemoryStore) {
+	t.Helper()
+	store := newJobStore(jobs...)
+	log := runner.NewMemoryStore()
+	return runner.New(store, log, opts), store, log
+}
+
+func run(t *testing.T, r *runner.Runner, job *svc.CronModel) (*svc.CronExecutionRecord, error) {
+	t.Helper()
+	record, err := r.RunByID(context.Background(), job.ID, runner.TriggerManual)
+	if record == nil {
+		t.Fatalf("RunByID returned no record: %v", err)
+	}
+	return record, err
+}
+
+func TestRunner_CommandJob(t *testing.T) {
+	job := &svc.CronModel{Name: "hello", Command: `echo "hello cron"`}
+	opts := runner.DefaultOptions
+	opts.AllowedCommands = []string{"echo"}
+	r, jobs, log := newRunner(t, opts, job)
+
+	record, err := run(t, r, job)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if record.Kind != runner.KindCommand || record.Status != svc.CronExecutionSuccess || record.ExitCode != 0 {
+		t.Fatalf("record = %+v", record)
+	}
+	if strings.TrimSpace(record.Output) != "hello cron" {
+		t.Fatalf("output = %q", record.Output)
+	}
+	if record.FinishedAt == nil || record.Trigger != runner.TriggerManual {
+		t.Fatalf("record = %+v", record)
+	}
+
+	logged, err := log.ListExecutions(context.Background(), job.ID.String(), 0)
+	if err != nil || len(logged) != 1 || logged[0].Output != record.Output {
+		t.Fatalf("execution log = %+v, %v", logged, err)
+	}
+	updated, _ := jobs.FindByID(context.Background(), job.ID)
+	if updated.LastRunStatus != svc.CronExecutionSuccess || updated.LastRunTime == nil {
+		t.Fatalf("job after run = %+v", updated)
+	}
+}
+
+func TestRunner_CommandJobFailures(t *testing.T) {
+	failing := &svc.CronModel{Name: "false", Command: "false"}
+	other := &svc.CronModel{Name: "ls", Command: "ls -la"}
+	opts := runner.DefaultOptions
+	opts.AllowedCommands = []string{"false"}
+	r, jobs, _ := newRunner(t, opts, failing, other)
+
+	record, err := run(t, r, failing)
+	if err == nil || record.Status != svc.CronExecutionFailure || record.ExitCode != 1 {
+		t.Fatalf("record = %+v, err %v", record, err)
+	}
+	if job, _ := jobs.FindByID(context.Background(), failing.ID); job.LastRunStatus != svc.CronExecutionFailure || job.LastRunMessage == "" {
+		t.Fatalf("job after run = %+v", job)
+	}
+	if _, err := run(t, r, other); err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Fatalf("ls ran outside the allowlist: %v", err)
+	}
+
+	closed, _, _ := newRunner(t, runner.DefaultOptions, failing)
+	if _, err := run(t, closed, failing); !errors.Is(err, runner.ErrUnavailable) {
+		t.Fatalf("command ran without allowed commands: %v", err)
+	}
+}
+
+func TestRunner_TimeoutAndCancel(t *testing.T) {
+	slow := &svc.CronModel{Name: "slow", Command: "sleep 5", ExecTimeout: 1}
+	opts := runner.DefaultOptions
+	opts.AllowedCommands = []string{"sleep"}
+	r, _, _ := newRunner(t, opts, slow)
+
+	start := time.Now()
+	record, err := run(t, r, slow)
+	if err == nil || !strings.Contains(err.Error(), "timed out") || record.ExitCode != -1 {
+		t.Fatalf("record = %+v, err %v", record, err)
+	}
+	if time.Since(start) > 4*time.Second {
+		t.Fatal("the timeout did not stop the command")
+	}
+
+	slow.ExecTimeout = 30
+	r, _, _ = newRunner(t, opts, slow)
+	done := make(chan error, 1)
+	go func() {
+		_, err := r.RunByID(context.Background(), slow.ID, runner.TriggerSchedule)
+		done <- err
+	}()
+	deadline := time.Now().Add(2 * time.Second)
+	for !r.Running(slow.ID) {
+		if time.Now().After(deadline) {
+			t.Fatal("run did not start")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := r.Cancel(slow.ID); n != 1 {
+		t.Fatalf("Cancel = %d", n)
+	}
+	select {
+	case err := <-done:
+		if !errors.Is(err, runner.ErrCancelled) {
+			t.Fatalf("err = %v", err)
+		}
+	case <-time.After(4 * time.Second):
+		t.Fatal("cancelled run did not stop")
+	}
+	if r.Running(slow.ID) {
+		t.Fatal("run still tracked after cancel")
+	}
+}
+
+func TestRunner_HTTPJob(t *testing.T) {
+	var got struct {
+		method, auth string
+		body         map[string]interface{}
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		got.method, got.auth = req.Method, req.Header.Get("Authorization")
+		data, _ := io.ReadAll(req.Body)
+		_ = json.Unmarshal(data, &got.body)
+		if req.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte("upstream down"))
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	ok := &svc.CronModel{
+		Name:        "ping",
+		Method:      "POST",
+		APIEndpoint: server.URL + "/ping",
+		Headers:     map[string]any{"Authorization": "Bearer token"},
+		Payload:     map[string]any{"report": "daily"},
+	}
+	failing := &svc.CronModel{Name: "fail", APIEndpoint: server.URL + "/fail"}
+	r, _, _ := newRunner(t, runner.DefaultOptions, ok, failing)
+
+	record, err := run(t, r, ok)
+	if err != nil || record.Kind != runner.KindHTTP || record.StatusCode != http.StatusOK || record.Output != `{"ok":true}` {
+		t.Fatalf("record = %+v, err %v", record, err)
+	}
+	if got.method != "POST" || got.auth != "Bearer token" || got.body["report"] != "daily" {
+		t.Fatalf("request = %+v", got)
+	}
+
+	record, err = run(t, r, failing)
+	if err == nil || record.StatusCode != http.StatusBadGateway || record.Output != "upstream down" || record.ExitCode != 1 {
+		t.Fatalf("record = %+v, err %v", record, err)
+	}
+	if got.method != "GET" {
+		t.Fatalf("method = %s, want the GET default", got.method)
+	}
+}
+
+// chatter answers every prompt with its reversed words.
+type chatter struct{ req gateway.ChatRequest }
+
+func (c *chatter) Chat(ctx context.Context, req gateway.ChatRequest) (<-chan gateway.ChatChunk, gateway.ProviderConfig, error) {
+	c.req = req
+	words := strings.Fields(req.Messages[len(req.Messages)-1].Content)
+	stream := make(chan gateway.ChatChunk, len(words)+1)
+	for i := len(words) - 1; i >= 0; i-- {
+		stream <- gateway.ChatChunk{Content: words[i] + " "}
+	}
+	stream <- gateway.ChatChunk{Done: true}
+	close(stream)
+	return stream, gateway.ProviderConfig{Name: req.Provider}, nil
+}
+
+func TestRunner_ToolPromptAndPublishJobs(t *testing.T) {
+	tool := &svc.CronModel{Name: "tool", Metadata: map[string]any{"kind": "mcp_tool", "tool": "system.info"}, Payload: map[string]any{"verbose": true}}
+	prompt := &svc.CronModel{Name: "prompt", Command: "summarize the night", Metadata: map[string]any{"kind": "llm", "provider": "groq", "system": "be brief"}}
+	publish := &svc.CronModel{Name: "publish", Metadata: map[string]any{"kind": "amqp", "routing_key": "report.ready"}, Payload: map[string]any{"day": "monday"}}
+	r, _, _ := newRunner(t, runner.DefaultOptions, tool, prompt, publish)
+
+	// without the services, the jobs are unavailable
+	for _, job := range []*svc.CronModel{tool, prompt} {
+		if _, err := run(t, r, job); !errors.Is(err, runner.ErrUnavailable) {
+			t.Fatalf("%s: err = %v", job.Name, err)
+		}
+	}
+
+	var toolArgs map[string]interface{}
+	r.SetToolRunner(func(ctx context.Context, name string, args map[string]interface{}) (interface{}, error) {
+		toolArgs = args
+		return map[string]interface{}{"tool": name, "up": true}, nil
+	})
+	record, err := run(t, r, tool)
+	if err != nil || record.Output != `{"tool":"system.info","up":true}` || toolArgs["verbose"] != true {
+		t.Fatalf("tool record = %+v, err %v, args %v", record, err, toolArgs)
+	}
+
+	chat := &chatter{}
+	r.SetChatter(chat)
+	record, err = run(t, r, prompt)
+	if err != nil || strings.TrimSpace(record.Output) != "night the summarize" {
+		t.Fatalf("prompt record = %+v, err %v", record, err)
+	}
+	if chat.req.Provider != "groq" || len(chat.req.Messages) != 2 || chat.req.Messages[0].Role != "system" {
+		t.Fatalf("chat request = %+v", chat.req)
+	}
+
+	broker := messagery.NewMemoryBroker(0)
+	if err := broker.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer broker.Close()
+	broker.DeclareQueue("reports", nil)
+	if err := messagery.DeclareTopology(broker, messagery.Topology{Bindings: []messagery.BindingSpec{{Queue: "reports", Exchange: "gobe.events", Key: "report.*"}}}); err != nil {
+		t.Fatal(err)
+	}
+	r.SetBroker(broker)
+	if record, err = run(t, r, publish); err != nil {
+		t.Fatalf("publish record = %+v, err %v", record, err)
+	}
+	ch, err := broker.ConsumerChannel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	deliveries, err := ch.Consume("reports", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case d := <-deliveries:
+		if string(d.Body) != `{"day":"monday"}` || d.RoutingKey != "report.ready" {
+			t.Fatalf("delivery %s via %s", d.Body, d.RoutingKey)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("nothing published")
+	}
+}
+
+func TestRunner_ExecutionLogNewestFirst(t *testing.T) {
+	job := &svc.CronModel{Name: "log", Metadata: map[string]any{"kind": "mcp_tool", "tool": "noop"}}
+	r, _, _ := newRunner(t, runner.DefaultOptions, job)
+	calls := 0
+	r.SetToolRunner(func(ctx context.Context, name string, args map[string]interface{}) (interface{}, error) {
+		calls++
+		return calls, nil
+	})
+	for i := 0; i < 3; i++ {
+		run(t, r, job)
+		time.Sleep(2 * time.Millisecond)
+	}
+	logs, err := r.Executions(context.Background(), job.ID, 2)
+	if err != nil || len(logs) != 2 || logs[0].Output != "3" || logs[1].Output != "2" {
+		t.Fatalf("logs = %+v, err %v", logs, err)
+	}
+	if _, err := r.RunByID(context.Background(), uuid.New(), runner.TriggerManual); !errors.Is(err, runner.ErrJobNotFound) {
+		t.Fatalf("unknown job: %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := map[string]*svc.CronModel{
+		"unknown kind":       {Metadata: map[string]any{"kind": "ftp"}},
+		"empty command":      {},
+		"shell metachars":    {Command: "ls; rm -rf /"},
+		"bad endpoint":       {APIEndpoint: "ftp://example.com"},
+		"tool without name":  {Metadata: map[string]any{"kind": "mcp_tool"}},
+		"llm without prompt": {Metadata: map[string]any{"kind": "llm"}},
+		"amqp without key":   {Metadata: map[string]any{"kind": "amqp"}},
+	}
+	for name, job := range cases {
+		if err := runner.Validate(job); !errors.Is(err, runner.ErrInvalidJob) {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+	if err := runner.Validate(&svc.CronModel{Command: "echo ok"}); err != nil {
+		t.Fatalf("valid job: %v", err)
+	}
+}
@@ -0,0 +1,264 @@
+package testscron
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
+	"github.com/kubex-ecosystem/gobe/internal/services/scheduler/manager"
+	"github.com/kubex-ecosystem/gobe/internal/services/scheduler/services"
+	tp "github.com/kubex-ecosystem/gobe/internal/services/scheduler/types"
+)
+
+// executor fails the first failures attempts of every job.
+type executor struct {
+	mu       sync.Mutex
+	failures int
+	attempts []int
+	running  map[uuid.UUID]bool
+	inflight int
+	block    chan struct{}
+}
+
+func (e *executor) Execute(ctx context.Context, id uuid.UUID, attempt int) error {
+	e.mu.Lock()
+	e.attempts = append(e.attempts, attempt)
+	e.inflight++
+	block := e.block
+	e.mu.Unlock()
+	defer func() {
+		e.mu.Lock()
+		e.inflight--
+		e.mu.Unlock()
+	}()
+	if block != nil {
+		select {
+		case <-block:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if attempt < e.failures {
+		return errors.New("boom")
+	}
+	return nil
+}
+
+func (e *executor) Cancel(id uuid.UUID) int { return 0 }
+
+func (e *executor) Running(id uuid.UUID) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.running[id] || e.inflight > 0
+}
+
+func (e *executor) calls() []int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]int(nil), e.attempts...)
+}
+
+func TestJob_RetriesFailedRuns(t *testing.T) {
+	exec := &executor{failures: 2}
+	job := tp.NewCronJob(uuid.New(), uuid.New(), "retry", "* * * * *", 3, time.Millisecond, exec)
+	if err := job.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := exec.calls(); len(got) != 3 || got[2] != 2 {
+		t.Fatalf("attempts = %v", got)
+	}
+	if job.(*tp.Job).Status != tp.JobStatusCompleted {
+		t.Fatalf("status = %s", job.(*tp.Job).Status)
+	}
+
+	exec = &executor{failures: 10}
+	job = tp.NewCronJob(uuid.New(), uuid.New(), "give up", "* * * * *", 2, time.Millisecond, exec)
+	if err := job.Run(); err == nil {
+		t.Fatal("Run succeeded")
+	}
+	if got := exec.calls(); len(got) != 3 {
+		t.Fatalf("attempts = %v, want 1 run and 2 retries", got)
+	}
+	if job.(*tp.Job).Status != tp.JobStatusFailed {
+		t.Fatalf("status = %s", job.(*tp.Job).Status)
+	}
+	if err := job.Retry(); err == nil || exec.calls()[3] != 3 {
+		t.Fatalf("Retry: %v, attempts %v", err, exec.calls())
+	}
+
+	if err := tp.NewJob(1, "legacy", "* * * * *", "echo").Run(); !errors.Is(err, tp.ErrNoExecutor) {
+		t.Fatalf("job without executor: %v", err)
+	}
+}
+
+func TestJob_CancelStopsRetries(t *testing.T) {
+	exec := &executor{failures: 10, block: make(chan struct{})}
+	job := tp.NewCronJob(uuid.New(), uuid.New(), "cancel", "* * * * *", 5, time.Hour, exec)
+	done := make(chan error, 1)
+	go func() { done <- job.Run() }()
+	for len(exec.calls()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if err := job.Cancel(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Run = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Cancel did not stop the job")
+	}
+	if got := exec.calls(); len(got) != 1 {
+		t.Fatalf("attempts = %v", got)
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	from := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		model *svc.CronModel
+		next  time.Time
+	}{
+		{&svc.CronModel{CronExpression: "30 * * * *"}, from.Add(30 * time.Minute)},
+		{&svc.CronModel{CronExpression: "@hourly"}, from.Add(time.Hour)},
+		{&svc.CronModel{CronExpression: "5m", CronType: "interval"}, from.Add(5 * time.Minute)},
+		{&svc.CronModel{CronExpression: "@every 2m", CronType: "interval"}, from.Add(2 * time.Minute)},
+	}
+	for _, c := range cases {
+		schedule, err := services.ParseSchedule(c.model)
+		if err != nil {
+			t.Fatalf("%s: %v", c.model.CronExpression, err)
+		}
+		if next := schedule.Next(from); !next.Equal(c.next) {
+			t.Errorf("%s: next = %s, want %s", c.model.CronExpression, next, c.next)
+		}
+	}
+	for _, expression := range []string{"", "61 * * * *", "-5m"} {
+		if _, err := services.ParseSchedule(&svc.CronModel{CronExpression: expression, CronType: "interval"}); err == nil {
+			t.Errorf("%q parsed", expression)
+		}
+	}
+
+	for _, metadata := range []map[string]any{{"timezone": "Mars/Olympus"}, {"overlap": "queue"}} {
+		if err := services.ValidateSchedule(&svc.CronModel{CronExpression: "@hourly", Metadata: metadata}); err == nil {
+			t.Errorf("%v validated", metadata)
+		}
+	}
+	if err := services.ValidateSchedule(&svc.CronModel{CronExpression: "@hourly", Metadata: map[string]any{"timezone": "Asia/Tokyo", "overlap": "Delay"}}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func startScheduler(t *testing.T, store *jobStore, exec tp.Executor) *manager.CronJobScheduler {
+	t.Helper()
+	scheduler := manager.NewCronJobScheduler(store, exec)
+	if err := scheduler.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(scheduler.Stop)
+	return scheduler
+}
+
+func TestCronJobScheduler_LoadsAndSyncsJobs(t *testing.T) {
+	ended := time.Now().Add(-time.Hour)
+	hourly := &svc.CronModel{Name: "hourly", CronExpression: "0 * * * *", IsActive: true}
+	tokyo := &svc.CronModel{Name: "tokyo", CronExpression: "0 9 * * *", IsActive: true, Metadata: map[string]any{"timezone": "Asia/Tokyo"}}
+	inactive := &svc.CronModel{Name: "inactive", CronExpression: "* * * * *"}
+	over := &svc.CronModel{Name: "over", CronExpression: "* * * * *", IsActive: true, EndsAt: &ended}
+	broken := &svc.CronModel{Name: "broken", CronExpression: "not a schedule", IsActive: true}
+	store := newJobStore(hourly, tokyo, inactive, over, broken)
+	scheduler := startScheduler(t, store, &executor{})
+
+	for _, job := range []*svc.CronModel{inactive, over, broken} {
+		if _, ok := scheduler.Next(job.ID); ok {
+			t.Errorf("%s is scheduled", job.Name)
+		}
+	}
+	next, ok := scheduler.Next(hourly.ID)
+	if !ok || next.Minute() != 0 || time.Until(next) > time.Hour {
+		t.Fatalf("hourly next = %s, %v", next, ok)
+	}
+	next, _ = scheduler.Next(tokyo.ID)
+	if local := next.In(mustLoad(t, "Asia/Tokyo")); local.Hour() != 9 || local.Minute() != 0 {
+		t.Fatalf("tokyo next = %s", local)
+	}
+
+	// reschedule, disable and delete through the store, as the routes do
+	hourly.CronExpression = "30 * * * *"
+	store.Update(context.Background(), hourly)
+	if err := scheduler.Sync(context.Background(), hourly.ID); err != nil {
+		t.Fatal(err)
+	}
+	if next, _ := scheduler.Next(hourly.ID); next.Minute() != 30 {
+		t.Fatalf("rescheduled next = %s", next)
+	}
+	inactive.IsActive = true
+	store.Update(context.Background(), inactive)
+	scheduler.Sync(context.Background(), inactive.ID)
+	if _, ok := scheduler.Next(inactive.ID); !ok {
+		t.Fatal("enabled job is not scheduled")
+	}
+	tokyo.IsActive = false
+	store.Update(context.Background(), tokyo)
+	scheduler.Sync(context.Background(), tokyo.ID)
+	if _, ok := scheduler.Next(tokyo.ID); ok {
+		t.Fatal("disabled job is still scheduled")
+	}
+	store.mu.Lock()
+	delete(store.jobs, hourly.ID)
+	store.mu.Unlock()
+	scheduler.Sync(context.Background(), hourly.ID)
+	if _, ok := scheduler.Next(hourly.ID); ok {
+		t.Fatal("deleted job is still scheduled")
+	}
+}
+
+func TestCronJobScheduler_OverlapPolicies(t *testing.T) {
+	for _, policy := range []string{"skip", "delay", "allow"} {
+		policy := policy
+		t.Run(policy, func(t *testing.T) {
+			t.Parallel()
+			job := &svc.CronModel{Name: policy, CronExpression: "1s", CronType: "interval", IsActive: true, Metadata: map[string]any{"overlap": policy}}
+			exec := &executor{block: make(chan struct{})}
+			scheduler := startScheduler(t, newJobStore(job), exec)
+
+			// the first run blocks while the schedule fires again
+			time.Sleep(2500 * time.Millisecond)
+			scheduler.Stop()
+			blocked := len(exec.calls())
+			close(exec.block)
+			time.Sleep(100 * time.Millisecond)
+			after := len(exec.calls())
+
+			switch policy {
+			case "skip":
+				if blocked != 1 || after != 1 {
+					t.Fatalf("runs: %d while blocked, %d after", blocked, after)
+				}
+			case "delay":
+				if blocked != 1 || after < 2 {
+					t.Fatalf("runs: %d while blocked, %d after", blocked, after)
+				}
+			case "allow":
+				if blocked < 2 || after != blocked {
+					t.Fatalf("runs: %d while blocked, %d after", blocked, after)
+				}
+			}
+		})
+	}
+}
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
@@ -0,0 +1,277 @@
+package testsevents
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kubex-ecosystem/gobe/internal/observers/events"
+)
+
+func fastPipelineOptions() events.PipelineOptions {
+	return events.PipelineOptions{
+		Workers:     1,
+		QueueSize:   10,
+		Aging:       time.Hour,
+		Timeout:     time.Second,
+		MaxAttempts: 3,
+		Backoff:     time.Millisecond,
+		MaxBackoff:  5 * time.Millisecond,
+	}
+}
+
+func newPipeline(t *testing.T, stream *events.Stream, opts events.PipelineOptions, stages ...events.Stage) *events.Pipeline {
+	t.Helper()
+	pipeline := events.NewPipeline(stream, opts, stages...)
+	t.Cleanup(func() { pipeline.Close() })
+	return pipeline
+}
+
+// pipelineEvents subscribes to the message_processing_* events of stream.
+func pipelineEvents(t *testing.T, stream *events.Stream) *events.Client {
+	t.Helper()
+	client := newClient(t, []string{"message_processing_*"}, 0)
+	stream.RegisterClient(client)
+	waitForClients(t, stream, 1)
+	return client
+}
+
+// untilFinished returns the events of a job up to its completed or failed
+// event.
+func untilFinished(t *testing.T, client *events.Client) []events.Event {
+	t.Helper()
+	var got []events.Event
+	for {
+		event := receive(t, client)
+		got = append(got, event)
+		if event.Type == events.EventProcessingCompleted || event.Type == events.EventProcessingFailed {
+			return got
+		}
+	}
+}
+
+func eventData(event events.Event) map[string]interface{} {
+	data, _ := event.Data.(map[string]interface{})
+	return data
+}
+
+// gate blocks the first job on a single worker so the next ones queue up.
+type gate struct {
+	entered chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func newGate() *gate {
+	return &gate{entered: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (g *gate) stage(order *[]string, mu *sync.Mutex) events.Stage {
+	return events.Stage{Name: "record", Run: func(ctx context.Context, job events.MessageProcessingJob, result *events.JobResult) (interface{}, error) {
+		if job.ID == "blocker" {
+			g.once.Do(func() { close(g.entered) })
+			<-g.release
+			return nil, nil
+		}
+		mu.Lock()
+		*order = append(*order, job.ID)
+		mu.Unlock()
+		return nil, nil
+	}}
+}
+
+func TestPipeline_PriorityOrderWithAging(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		order []string
+		g     = newGate()
+	)
+	pipeline := newPipeline(t, nil, fastPipelineOptions(), g.stage(&order, &mu))
+
+	if err := pipeline.Submit(events.MessageProcessingJob{ID: "blocker"}); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	<-g.entered
+
+	now := time.Now()
+	jobs := []events.MessageProcessingJob{
+		{ID: "low", Priority: events.PriorityLow, CreatedAt: now},
+		{ID: "normal", Priority: events.PriorityNormal, CreatedAt: now},
+		{ID: "urgent", Priority: events.PriorityUrgent, CreatedAt: now},
+		{ID: "high", Priority: events.PriorityHigh, CreatedAt: now},
+		// waited four aging intervals: ahead of a fresh urgent job
+		{ID: "old-low", Priority: events.PriorityLow, CreatedAt: now.Add(-4 * time.Hour)},
+	}
+	for _, job := range jobs {
+		if err := pipeline.Submit(job); err != nil {
+			t.Fatalf("Submit %s: %v", job.ID, err)
+		}
+	}
+
+	stats := pipeline.Stats()
+	if stats.Queued != 5 || stats.Busy != 1 || stats.QueuedByPriority["low"] != 2 || stats.OldestWaitMs < (4*time.Hour).Milliseconds() {
+		t.Fatalf("stats = %+v", stats)
+	}
+
+	close(g.release)
+	deadline := time.Now().Add(2 * time.Second)
+	for pipeline.Stats().Completed != 6 {
+		if time.Now().After(deadline) {
+			t.Fatalf("jobs not completed: %+v", pipeline.Stats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	want := []string{"old-low", "urgent", "high", "normal", "low"}
+	mu.Lock()
+	defer mu.Unlock()
+	for i := range want {
+		if i >= len(order) || order[i] != want[i] {
+			t.Fatalf("order = %v, want %v", order, want)
+		}
+	}
+}
+
+func TestPipeline_RetryResumesAtFailedStage(t *testing.T) {
+	stream := newStream(t)
+	client := pipelineEvents(t, stream)
+
+	screened, analyzed := 0, 0
+	stream.SetPipeline(newPipeline(t, stream, fastPipelineOptions(),
+		events.Stage{Name: "screening", Run: func(ctx context.Context, job events.MessageProcessingJob, result *events.JobResult) (interface{}, error) {
+			screened++
+			return "question", nil
+		}},
+		events.Stage{Name: "analysis", Run: func(ctx context.Context, job events.MessageProcessingJob, result *events.JobResult) (interface{}, error) {
+			analyzed++
+			if analyzed == 1 {
+				return nil, errors.New("llm unavailable")
+			}
+			return map[string]interface{}{"kind": result.Outputs["screening"]}, nil
+		}},
+	))
+
+	if err := stream.ProcessMessage(events.MessageProcessingJob{ID: "job-1", Platform: "discord", Priority: events.PriorityHigh}); err != nil {
+		t.Fatalf("ProcessMessage: %v", err)
+	}
+
+	got := untilFinished(t, client)
+	if len(got) != 3 || got[0].Type != events.EventProcessingStarted || got[1].Type != events.EventProcessingRetrying || got[2].Type != events.EventProcessingCompleted {
+		t.Fatalf("events = %+v", got)
+	}
+	if retry := eventData(got[1]); retry["stage"] != "analysis" || retry["error"] != "llm unavailable" {
+		t.Fatalf("retrying = %+v", retry)
+	}
+	done := eventData(got[2])
+	result, _ := done["result"].(events.JobResult)
+	if done["job_id"] != "job-1" || done["priority"] != "high" || done["attempt"] != 2 {
+		t.Fatalf("completed = %+v", done)
+	}
+	if analysis, _ := result.Outputs["analysis"].(map[string]interface{}); analysis["kind"] != "question" {
+		t.Fatalf("result = %+v", result)
+	}
+	if screened != 1 || analyzed != 2 {
+		t.Fatalf("screening ran %d times, analysis %d", screened, analyzed)
+	}
+
+	stats := stream.Stats().Pipeline
+	if stats == nil || stats.Completed != 1 || stats.Retried != 1 || stats.Queued != 0 {
+		t.Fatalf("pipeline stats = %+v", stats)
+	}
+}
+
+func TestPipeline_FailuresAndStop(t *testing.T) {
+	stream := newStream(t)
+	client := pipelineEvents(t, stream)
+
+	attempts := 0
+	opts := fastPipelineOptions()
+	opts.Timeout = 20 * time.Millisecond
+	opts.MaxAttempts = 2
+	acted := false
+	stream.SetPipeline(newPipeline(t, stream, opts,
+		events.Stage{Name: "screening", Run: func(ctx context.Context, job events.MessageProcessingJob, result *events.JobResult) (interface{}, error) {
+			switch job.ID {
+			case "ignored":
+				result.Stop("triage: no answer needed")
+			case "broken":
+				return nil, events.Permanent(errors.New("unsupported message"))
+			case "slow":
+				attempts++
+				<-ctx.Done()
+				return nil, ctx.Err()
+			case "panics":
+				panic("boom")
+			}
+			return nil, nil
+		}},
+		events.Stage{Name: "action", Run: func(ctx context.Context, job events.MessageProcessingJob, result *events.JobResult) (interface{}, error) {
+			acted = true
+			return nil, nil
+		}},
+	))
+
+	stream.ProcessMessage(events.MessageProcessingJob{ID: "ignored"})
+	got := untilFinished(t, client)
+	done := eventData(got[len(got)-1])
+	if result, _ := done["result"].(events.JobResult); got[len(got)-1].Type != events.EventProcessingCompleted || result.StoppedAt != "screening" || acted {
+		t.Fatalf("stopped job = %+v, acted = %v", done, acted)
+	}
+
+	stream.ProcessMessage(events.MessageProcessingJob{ID: "broken"})
+	got = untilFinished(t, client)
+	if len(got) != 2 || eventData(got[1])["error"] != "unsupported message" || eventData(got[1])["stage"] != "screening" {
+		t.Fatalf("permanent failure events = %+v", got)
+	}
+
+	stream.ProcessMessage(events.MessageProcessingJob{ID: "slow"})
+	got = untilFinished(t, client)
+	last := got[len(got)-1]
+	if last.Type != events.EventProcessingFailed || eventData(last)["error"] != context.DeadlineExceeded.Error() || attempts != 2 {
+		t.Fatalf("timeout events = %+v, attempts = %d", got, attempts)
+	}
+
+	stream.ProcessMessage(events.MessageProcessingJob{ID: "panics"})
+	got = untilFinished(t, client)
+	if len(got) != 2 || got[1].Type != events.EventProcessingFailed {
+		t.Fatalf("panic events = %+v", got)
+	}
+
+	if stats := stream.Stats().Pipeline; stats.Failed != 3 || stats.Completed != 1 {
+		t.Fatalf("pipeline stats = %+v", stats)
+	}
+}
+
+func TestPipeline_QueueLimitAndClose(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		order []string
+		g     = newGate()
+	)
+	opts := fastPipelineOptions()
+	opts.QueueSize = 2
+	pipeline := events.NewPipeline(nil, opts, g.stage(&order, &mu))
+
+	pipeline.Submit(events.MessageProcessingJob{ID: "blocker"})
+	<-g.entered
+	pipeline.Submit(events.MessageProcessingJob{ID: "a"})
+	pipeline.Submit(events.MessageProcessingJob{ID: "b"})
+	if err := pipeline.Submit(events.MessageProcessingJob{ID: "c"}); !errors.Is(err, events.ErrQueueFull) {
+		t.Fatalf("Submit over the limit = %v", err)
+	}
+	if stats := pipeline.Stats(); stats.Rejected != 1 || stats.Submitted != 3 {
+		t.Fatalf("stats = %+v", stats)
+	}
+
+	close(g.release)
+	pipeline.Close()
+	if err := pipeline.Submit(events.MessageProcessingJob{ID: "d"}); !errors.Is(err, events.ErrPipelineClosed) {
+		t.Fatalf("Submit after Close = %v", err)
+	}
+
+	if err := events.NewStream().ProcessMessage(events.MessageProcessingJob{}); !errors.Is(err, events.ErrNoPipeline) {
+		t.Fatalf("ProcessMessage without pipeline = %v", err)
+	}
+}
@@ -0,0 +1,287 @@
+package testsevents
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubex-ecosystem/gobe/internal/observers/events"
+)
+
+func newStream(t *testing.T) *events.Stream {
+	t.Helper()
+	stream := events.NewStream()
+	go stream.Run()
+	t.Cleanup(stream.Close)
+	return stream
+}
+
+func newClient(t *testing.T, topics []string, lastEventID uint64) *events.Client {
+	t.Helper()
+	client, err := events.NewClient(topics, lastEventID)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return client
+}
+
+func receive(t *testing.T, client *events.Client) events.Event {
+	t.Helper()
+	select {
+	case event, ok := <-client.Send:
+		if !ok {
+			t.Fatalf("client %s closed", client.ID)
+		}
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no event for client %s", client.ID)
+	}
+	return events.Event{}
+}
+
+func expectNothing(t *testing.T, client *events.Client) {
+	t.Helper()
+	select {
+	case event := <-client.Send:
+		t.Fatalf("unexpected event %+v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// waitFor blocks until the stream numbered id, so later registrations see it.
+func waitFor(t *testing.T, stream *events.Stream, id uint64) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for stream.LastEventID() < id {
+		if time.Now().After(deadline) {
+			t.Fatalf("stream stuck at event %d, want %d", stream.LastEventID(), id)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestStream_IDsAndTopicFilter(t *testing.T) {
+	stream := newStream(t)
+	all := newClient(t, nil, 0)
+	approvals := newClient(t, []string{"approval_*"}, 0)
+	stream.RegisterClient(all)
+	stream.RegisterClient(approvals)
+
+	stream.Broadcast(events.Event{Type: "message_received"})
+	stream.Broadcast(events.Event{Type: "approval_requested"})
+
+	first, second := receive(t, all), receive(t, all)
+	if first.ID != 1 || second.ID != 2 || first.Type != "message_received" {
+		t.Fatalf("all received %+v then %+v", first, second)
+	}
+	if event := receive(t, approvals); event.ID != 2 || event.Type != "approval_requested" {
+		t.Fatalf("approvals received %+v", event)
+	}
+	expectNothing(t, approvals)
+
+	// Topics change mid-session
+	if err := approvals.AddTopics([]string{"message_*"}); err != nil {
+		t.Fatalf("AddTopics: %v", err)
+	}
+	stream.Broadcast(events.Event{Type: "message_sent"})
+	if event := receive(t, approvals); event.ID != 3 {
+		t.Fatalf("after subscribe received %+v", event)
+	}
+
+	if err := approvals.SetTopics([]string{"[bad"}); err == nil {
+		t.Fatalf("SetTopics accepted an invalid pattern")
+	}
+}
+
+func TestStream_ReplayAfterLastEventID(t *testing.T) {
+	stream := newStream(t)
+	for _, kind := range []string{"a.one", "b.two", "a.three", "a.four"} {
+		stream.Broadcast(events.Event{Type: kind})
+	}
+	waitFor(t, stream, 4)
+
+	client := newClient(t, []string{"a.*"}, 1)
+	stream.RegisterClient(client)
+	if event := receive(t, client); event.ID != 3 {
+		t.Fatalf("first replayed %+v, want 3", event)
+	}
+	if event := receive(t, client); event.ID != 4 {
+		t.Fatalf("second replayed %+v, want 4", event)
+	}
+
+	stream.Broadcast(events.Event{Type: "a.five"})
+	if event := receive(t, client); event.ID != 5 {
+		t.Fatalf("live event %+v, want 5", event)
+	}
+
+	// An ID from a previous run of the stream resets the client
+	stale := newClient(t, nil, 99)
+	stream.RegisterClient(stale)
+	if event := receive(t, stale); event.Type != events.EventStreamGap {
+		t.Fatalf("stale client received %+v, want a gap notice", event)
+	}
+	if event := receive(t, stale); event.ID != 1 {
+		t.Fatalf("stale client replayed %+v, want 1", event)
+	}
+}
+
+func TestStream_ReplayBeyondHistoryReportsGap(t *testing.T) {
+	stream := newStream(t)
+	total := uint64(events.HistorySize + 10)
+	for i := uint64(0); i < total; i++ {
+		stream.Broadcast(events.Event{Type: "tick"})
+	}
+	waitFor(t, stream, total)
+
+	client := newClient(t, nil, 5)
+	client.QueueSize = events.HistorySize
+	stream.RegisterClient(client)
+
+	gap := receive(t, client)
+	data, _ := gap.Data.(map[string]interface{})
+	if gap.Type != events.EventStreamGap || data["reason"] != "history" || data["missed"] != uint64(5) {
+		t.Fatalf("gap = %+v", gap)
+	}
+	if event := receive(t, client); event.ID != 11 {
+		t.Fatalf("first buffered event %+v, want 11", event)
+	}
+}
+
+func TestStream_SlowClientDropsAndReports(t *testing.T) {
+	stream := newStream(t)
+	slow := newClient(t, nil, 0)
+	slow.Send = make(chan events.Event)
+	slow.QueueSize = 3
+	stream.RegisterClient(slow)
+
+	for i := 0; i < 20; i++ {
+		stream.Broadcast(events.Event{Type: "tick"})
+	}
+	waitFor(t, stream, 20)
+
+	stats := stream.Stats()
+	if len(stats.Clients) != 1 || stats.LastEventID != 20 || stats.Buffered != 20 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	client := stats.Clients[0]
+	if client.Dropped == 0 || client.Delivered != 0 || client.Dropped+uint64(client.Queued) > 20 {
+		t.Fatalf("client stats = %+v", client)
+	}
+
+	var last events.Event
+	for {
+		last = receive(t, slow)
+		if last.Type == events.EventStreamGap {
+			break
+		}
+	}
+	data, _ := last.Data.(map[string]interface{})
+	if data["reason"] != "slow_client" || data["missed"] != slow.Stats().Dropped {
+		t.Fatalf("gap = %+v, dropped = %d", last, slow.Stats().Dropped)
+	}
+}
+
+func TestStream_CloseDisconnectsClients(t *testing.T) {
+	stream := newStream(t)
+	client := newClient(t, nil, 0)
+	stream.RegisterClient(client)
+	waitForClients(t, stream, 1)
+	stream.Close()
+
+	select {
+	case _, ok := <-client.Send:
+		if ok {
+			t.Fatalf("received an event after Close")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Send not closed")
+	}
+	// Registering on a closed stream closes the client right away
+	late := newClient(t, nil, 0)
+	stream.RegisterClient(late)
+	if _, ok := <-late.Send; ok {
+		t.Fatalf("late client received an event")
+	}
+}
+
+func waitForClients(t *testing.T, stream *events.Stream, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for len(stream.Stats().Clients) != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("stream has %d clients, want %d", len(stream.Stats().Clients), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestStream_SSE(t *testing.T) {
+	stream := newStream(t)
+	stream.Broadcast(events.Event{Type: "webhook.received", Data: "old"})
+	stream.Broadcast(events.Event{Type: "approval_requested"})
+	waitFor(t, stream, 2)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client, err := events.ClientFromRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		stream.ServeSSE(w, r, client)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"?topics=webhook.*", nil)
+	req.Header.Set("Last-Event-ID", "0")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	waitForClients(t, stream, 1)
+	stream.Broadcast(events.Event{Type: "approval_decided"})
+	stream.Broadcast(events.Event{Type: "webhook.received", Data: "new"})
+
+	reader := bufio.NewReader(resp.Body)
+	var id, data string
+	for data == "" {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	var event events.Event
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		t.Fatalf("data %q: %v", data, err)
+	}
+	if id != "4" || event.Type != "webhook.received" || event.Data != "new" {
+		t.Fatalf("id = %s, event = %+v", id, event)
+	}
+
+	bad, err := http.Get(server.URL + "?last_event_id=abc")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	bad.Body.Close()
+	if bad.StatusCode != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", bad.StatusCode)
+	}
+}
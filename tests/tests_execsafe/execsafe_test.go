@@ -3,6 +3,9 @@ package testsexecsafe
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
@@ -10,6 +13,13 @@ import (
 	"github.com/kubex-ecosystem/gobe/internal/app/security/execsafe"
 )
 
+// TestMain lets the test binary serve as the sandbox trampoline of the
+// isolated commands it runs, as cmd/main.go does for gobe.
+func TestMain(m *testing.M) {
+	execsafe.RunSandbox()
+	os.Exit(m.Run())
+}
+
 func TestTruncateKB(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -368,3 +378,93 @@ func TestRegistryOperations(t *testing.T) {
 		t.Error("Registry.Get() should return false for non-existent command")
 	}
 }
+
+func runSpec(t *testing.T, spec execsafe.CommandSpec, args ...string) (*execsafe.ExecResult, error) {
+	t.Helper()
+	reg := execsafe.NewRegistry()
+	reg.Register("cmd", spec)
+	return execsafe.RunSafe(context.Background(), reg, "cmd", args)
+}
+
+func TestRunSafeCapsOutputWhileStreaming(t *testing.T) {
+	res, err := runSpec(t, execsafe.CommandSpec{Binary: "head", MaxOutputKB: 1}, "-c", "5000000", "/dev/zero")
+	if err != nil {
+		t.Fatalf("RunSafe() unexpected error: %v", err)
+	}
+	if !res.Truncated {
+		t.Error("RunSafe() should report truncated output")
+	}
+	if len(res.Stdout) > 1024+len("\n…(truncated)…") {
+		t.Errorf("RunSafe() stdout has %d bytes, want at most the 1KB cap", len(res.Stdout))
+	}
+}
+
+func TestRunSafeLimits(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("limits require Linux")
+	}
+	spec := execsafe.CommandSpec{
+		Binary:  "sh",
+		Timeout: 5 * time.Second,
+		Limits:  execsafe.Limits{OpenFiles: 64, AddressSpaceMB: 512, CPUSeconds: 5},
+		Sandbox: execsafe.Sandbox{NoNewPrivileges: true},
+	}
+	for _, tt := range []struct{ script, want string }{
+		{"ulimit -n", "64"},
+		{"ulimit -v", "524288"},
+		{"ulimit -t", "5"},
+		{"grep NoNewPrivs /proc/self/status", "1"},
+	} {
+		res, err := runSpec(t, spec, "-c", tt.script)
+		if err != nil {
+			t.Fatalf("%s: RunSafe() unexpected error: %v", tt.script, err)
+		}
+		if fields := strings.Fields(res.Stdout); len(fields) == 0 || fields[len(fields)-1] != tt.want {
+			t.Errorf("%s: stdout = %q, want %s", tt.script, res.Stdout, tt.want)
+		}
+	}
+}
+
+func TestRunSafeSandbox(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("sandbox requires Linux")
+	}
+	if _, err := runSpec(t, execsafe.CommandSpec{Binary: "true", Sandbox: execsafe.Sandbox{NoNetwork: true}}); err != nil {
+		t.Skipf("user namespaces unavailable: %v", err)
+	}
+
+	sh := func(sandbox execsafe.Sandbox, script string) (*execsafe.ExecResult, error) {
+		return runSpec(t, execsafe.CommandSpec{Binary: "sh", Timeout: 5 * time.Second, Sandbox: sandbox}, "-c", script)
+	}
+
+	res, err := sh(execsafe.Sandbox{PIDNamespace: true}, "echo $$")
+	if err != nil || strings.TrimSpace(res.Stdout) != "1" {
+		t.Errorf("PID namespace: pid = %q, err = %v; want 1", res.Stdout, err)
+	}
+
+	res, err = sh(execsafe.Sandbox{NoNetwork: true}, "cat /proc/net/dev")
+	if err != nil {
+		t.Fatalf("NoNetwork: unexpected error: %v", err)
+	}
+	if lines := strings.Count(strings.TrimSpace(res.Stdout), "\n"); lines != 2 || !strings.Contains(res.Stdout, "lo:") {
+		t.Errorf("NoNetwork: interfaces = %q, want only lo", res.Stdout)
+	}
+
+	dir := t.TempDir()
+	target := filepath.Join(dir, "written")
+	if _, err := sh(execsafe.Sandbox{ReadOnlyFS: true}, "touch "+target); err == nil {
+		t.Error("ReadOnlyFS: touch should fail")
+	}
+	if _, statErr := os.Stat(target); !os.IsNotExist(statErr) {
+		t.Errorf("ReadOnlyFS: %s was created", target)
+	}
+
+	private := filepath.Join("/tmp", filepath.Base(dir)+"-private")
+	if res, err := sh(execsafe.Sandbox{ReadOnlyFS: true, PrivateTmp: true}, "touch "+private); err != nil {
+		t.Fatalf("PrivateTmp: touch failed: %v (%s)", err, res.Stderr)
+	}
+	if _, statErr := os.Stat(private); !os.IsNotExist(statErr) {
+		os.Remove(private)
+		t.Errorf("PrivateTmp: %s leaked to the host /tmp", private)
+	}
+}
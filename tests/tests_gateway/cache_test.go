@@ -0,0 +1,104 @@
+package testsgateway
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	gatewayController "github.com/kubex-ecosystem/gobe/internal/app/controllers/gateway"
+	"github.com/kubex-ecosystem/gobe/internal/app/middlewares"
+	"github.com/kubex-ecosystem/gobe/internal/services/gateway/cache"
+)
+
+func TestLRU_EvictsAndExpires(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	lru := cache.NewLRU(2)
+	_ = lru.Set(ctx, "a", []byte("1"), 0)
+	_ = lru.Set(ctx, "b", []byte("2"), 0)
+	if _, ok, _ := lru.Get(ctx, "a"); !ok {
+		t.Fatal("Get(a) missed before eviction")
+	}
+	_ = lru.Set(ctx, "c", []byte("3"), 0)
+	if _, ok, _ := lru.Get(ctx, "b"); ok {
+		t.Error("least recently used entry b was not evicted")
+	}
+	if value, ok, _ := lru.Get(ctx, "a"); !ok || string(value) != "1" {
+		t.Errorf("Get(a) = %q, %v; want 1, true", value, ok)
+	}
+
+	_ = lru.Set(ctx, "short", []byte("x"), time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if _, ok, _ := lru.Get(ctx, "short"); ok {
+		t.Error("expired entry was served")
+	}
+}
+
+func TestCache_ChatSSEServesRepeatsFromCache(t *testing.T) {
+	t.Parallel()
+
+	upstream, hits := countingUpstream(t, http.StatusOK)
+	service := newRoutingService(t, map[string]string{"openai": upstream.URL})
+	responses := cache.New(cache.NewLRU(10), time.Minute)
+	service.SetCache(responses)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middlewares.CacheMiddleware())
+	router.POST("/chat", gatewayController.NewChatController(service).ChatSSE)
+
+	post := func(body, cacheControl string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/chat", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if cacheControl != "" {
+			req.Header.Set("Cache-Control", cacheControl)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	deterministic := `{"provider":"openai","temperature":0,"messages":[{"role":"user","content":"hi"}]}`
+	tests := []struct {
+		name         string
+		body         string
+		cacheControl string
+		wantStatus   string
+		wantHits     int32
+	}{
+		{"first call misses", deterministic, "", cache.StatusMiss, 1},
+		{"repeat hits", deterministic, "", cache.StatusHit, 1},
+		{"no-cache refreshes", deterministic, "no-cache", cache.StatusMiss, 2},
+		{"default temperature bypasses", `{"provider":"openai","messages":[{"role":"user","content":"hi"}]}`, "", cache.StatusBypass, 3},
+		{"max-age opts in", `{"provider":"openai","temperature":0.9,"messages":[{"role":"user","content":"hi"}]}`, "max-age=60", cache.StatusMiss, 4},
+		{"opted-in repeat hits", `{"provider":"openai","temperature":0.9,"messages":[{"role":"user","content":"hi"}]}`, "max-age=60", cache.StatusHit, 4},
+	}
+	for _, tt := range tests {
+		rec := post(tt.body, tt.cacheControl)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, body %s", tt.name, rec.Code, rec.Body.String())
+		}
+		if got := rec.Header().Get("X-Cache"); got != tt.wantStatus {
+			t.Errorf("%s: X-Cache = %q, want %q", tt.name, got, tt.wantStatus)
+		}
+		if got := hits.Load(); got != tt.wantHits {
+			t.Errorf("%s: upstream hits = %d, want %d", tt.name, got, tt.wantHits)
+		}
+		if !strings.Contains(rec.Body.String(), "ok") {
+			t.Errorf("%s: body %q lacks the answer", tt.name, rec.Body.String())
+		}
+		if tt.wantStatus == cache.StatusHit && rec.Header().Get("Age") == "" {
+			t.Errorf("%s: Age header missing on hit", tt.name)
+		}
+	}
+
+	stats := responses.Stats()
+	if stats.Hits != 2 || stats.Misses != 3 || stats.Bypassed != 1 || stats.Stores != 3 {
+		t.Errorf("Stats() = %+v, want 2 hits, 3 misses, 1 bypass, 3 stores", stats)
+	}
+}
@@ -0,0 +1,188 @@
+package testsgateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	models "github.com/kubex-ecosystem/gdbase/factory/models/mcp"
+	gatewayController "github.com/kubex-ecosystem/gobe/internal/app/controllers/gateway"
+	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
+	"github.com/kubex-ecosystem/gobe/internal/services/gateway"
+	"github.com/kubex-ecosystem/gobe/internal/services/gateway/providers"
+	gatewaysvc "github.com/kubex-ecosystem/gobe/internal/services/gateway/registry"
+)
+
+// localServer fakes a keyless local backend. It serves its model list on
+// modelsPath and records the headers and model of each chat request.
+type localServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	headers []http.Header
+	models  []string
+}
+
+func newLocalServer(t *testing.T, modelsPath, listing string) *localServer {
+	t.Helper()
+	ls := &localServer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc(modelsPath, func(w http.ResponseWriter, r *http.Request) {
+		ls.mu.Lock()
+		ls.headers = append(ls.headers, r.Header.Clone())
+		ls.mu.Unlock()
+		fmt.Fprint(w, listing)
+	})
+	mux.HandleFunc("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		raw, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(raw, &body)
+		ls.mu.Lock()
+		ls.headers = append(ls.headers, r.Header.Clone())
+		ls.models = append(ls.models, body.Model)
+		ls.mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":\"local %s\"}}]}\n\n", body.Model)
+		fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":2,\"completion_tokens\":3}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+	ls.Server = httptest.NewServer(mux)
+	t.Cleanup(ls.Close)
+	return ls
+}
+
+func chatText(t *testing.T, provider gateway.Provider, model string) (string, *gateway.Usage) {
+	t.Helper()
+	stream, err := provider.Chat(context.Background(), gateway.ChatRequest{
+		Model:    model,
+		Messages: []gateway.Message{{Role: "user", Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatalf("Chat() unexpected error = %v", err)
+	}
+	var text strings.Builder
+	var usage *gateway.Usage
+	for chunk := range stream {
+		if chunk.Error != "" {
+			t.Fatalf("stream error %s", chunk.Error)
+		}
+		text.WriteString(chunk.Content)
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+	}
+	return text.String(), usage
+}
+
+func TestOllamaProvider_DiscoversModelsWithoutKey(t *testing.T) {
+	t.Parallel()
+
+	server := newLocalServer(t, "/api/tags", `{"models":[{"name":"llama3.2:latest"},{"name":"qwen2.5:7b"}]}`)
+	provider, err := providers.New(providers.Config{Name: "local", Type: "ollama", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("New() unexpected error = %v", err)
+	}
+	if err := provider.Available(); err != nil {
+		t.Fatalf("Available() unexpected error = %v", err)
+	}
+
+	lister, ok := provider.(gateway.ModelLister)
+	if !ok {
+		t.Fatal("ollama provider should list models")
+	}
+	discovered, err := lister.Models(context.Background())
+	if err != nil || strings.Join(discovered, ",") != "llama3.2:latest,qwen2.5:7b" {
+		t.Fatalf("Models() = %v, %v", discovered, err)
+	}
+
+	// Without a default model the first discovered one is used
+	text, usage := chatText(t, provider, "")
+	if text != "local llama3.2:latest" {
+		t.Errorf("content = %q", text)
+	}
+	if usage == nil || usage.TotalTokens != 5 || usage.CostUSD != 0 || usage.Model != "llama3.2:latest" {
+		t.Errorf("unexpected usage %+v", usage)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	for _, header := range server.headers {
+		if header.Get("Authorization") != "" {
+			t.Errorf("keyless provider sent Authorization %q", header.Get("Authorization"))
+		}
+	}
+}
+
+func TestOpenAICompatibleProvider_AuthHeader(t *testing.T) {
+	t.Parallel()
+
+	server := newLocalServer(t, "/v1/models", `{"data":[{"id":"mistral-7b"}]}`)
+	provider, err := providers.New(providers.Config{
+		Name:         "vllm",
+		Type:         "openai-compatible",
+		BaseURL:      server.URL + "/v1/",
+		APIKey:       "secret",
+		DefaultModel: "mistral-7b",
+		Metadata:     map[string]interface{}{"auth_header": "X-Api-Key", "auth_scheme": ""},
+	})
+	if err != nil {
+		t.Fatalf("New() unexpected error = %v", err)
+	}
+
+	if text, _ := chatText(t, provider, ""); text != "local mistral-7b" {
+		t.Errorf("content = %q", text)
+	}
+	server.mu.Lock()
+	header := server.headers[0]
+	server.mu.Unlock()
+	if header.Get("X-Api-Key") != "secret" || header.Get("Authorization") != "" {
+		t.Errorf("unexpected auth headers %v", header)
+	}
+
+	if _, err := providers.New(providers.Config{Name: "nowhere", Type: "openai-compatible"}); err == nil {
+		t.Error("openai-compatible without base url should fail")
+	}
+}
+
+func TestOpenAIFacade_ListsDiscoveredModels(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	server := newLocalServer(t, "/api/tags", `{"models":[{"name":"phi3:mini"}]}`)
+	service, err := gatewaysvc.NewService(&fakeProviders{records: []svc.ProvidersModel{
+		models.NewProvidersModel("ollama", "", map[string]interface{}{"type": "ollama", "base_url": server.URL}),
+		models.NewProvidersModel("other", "", map[string]interface{}{
+			"type": "openai", "base_url": server.URL, "api_key": "k", "default_model": "gpt-x",
+		}),
+	}})
+	if err != nil {
+		t.Fatalf("NewService() unexpected error = %v", err)
+	}
+	controller := gatewayController.NewOpenAIController(service, nil)
+	router := gin.New()
+	router.POST("/v1/chat/completions", controller.ChatCompletions)
+	router.GET("/v1/models", controller.ListModels)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/models", nil))
+	if !strings.Contains(rec.Body.String(), `"ollama/phi3:mini"`) {
+		t.Errorf("discovered model not listed: %s", rec.Body.String())
+	}
+
+	// A bare discovered model routes to the server that serves it
+	rec = postCompletion(router, `{"model":"phi3:mini","messages":[{"role":"user","content":"hi"}]}`)
+	var resp gatewayController.OpenAIChatResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	if rec.Code != http.StatusOK || resp.Model != "ollama/phi3:mini" {
+		t.Errorf("status %d, body %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,129 @@
+package testsgateway
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	gatewayController "github.com/kubex-ecosystem/gobe/internal/app/controllers/gateway"
+	"github.com/kubex-ecosystem/gobe/internal/config"
+	"github.com/kubex-ecosystem/gobe/internal/services/gateway"
+	"github.com/kubex-ecosystem/gobe/internal/services/gateway/ledger"
+)
+
+func TestPriceTable_Cost(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		model string
+		want  float64
+		ok    bool
+	}{
+		{"gpt-4o", 2.50 + 10.00, true},
+		{"gpt-4o-2024-08-06", 2.50 + 10.00, true},
+		{"gpt-4o-mini", 0.15 + 0.60, true},
+		{"claude-3-5-sonnet-20241022", 3.00 + 15.00, true},
+		{"llama3.2:latest", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := ledger.DefaultPrices.Cost(tt.model, 1_000_000, 1_000_000)
+		if ok != tt.ok || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Cost(%q) = %v, %v; want %v, %v", tt.model, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestLedger_RecordsServiceUsage(t *testing.T) {
+	t.Parallel()
+
+	upstream := newUpstream(t)
+	defer upstream.Close()
+	service := newRoutingService(t, map[string]string{"openai": upstream.URL})
+
+	book := ledger.New(ledger.NewMemoryStore(), ledger.DefaultPrices)
+	book.ApplyConfig(config.GatewayConfig{
+		Prices: map[string]config.GatewayModelPrice{"house-model": {InputPerMillion: 1_000_000, OutputPerMillion: 2_000_000}},
+	})
+	service.SetUsageRecorder(book)
+
+	for _, call := range []struct{ tenant, user, model, kind string }{
+		{"acme", "ana", "house-model", ""},
+		{"acme", "bob", "house-model", "advise"},
+		{"", "", "unpriced", ""},
+	} {
+		stream, _, err := service.Chat(context.Background(), gateway.ChatRequest{
+			Provider: "openai",
+			Model:    call.model,
+			Messages: []gateway.Message{{Role: "user", Content: "hi"}},
+			Meta:     map[string]interface{}{"tenant_id": call.tenant, "user_id": call.user, "kind": call.kind},
+		})
+		if err != nil {
+			t.Fatalf("Chat() unexpected error = %v", err)
+		}
+		for chunk := range stream {
+			// The client sees the price from the table: 3 prompt and 4 completion tokens
+			if chunk.Usage != nil && call.model == "house-model" && chunk.Usage.CostUSD != 11 {
+				t.Errorf("streamed cost = %v, want 11", chunk.Usage.CostUSD)
+			}
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	controller := gatewayController.NewUsageController(book)
+	router := gin.New()
+	router.GET("/v1/usage", controller.Summary)
+	router.GET("/v1/usage/records", controller.Records)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := get("/v1/usage?tenant=acme&group_by=user&from=2000-01-01")
+	var summary gatewayController.UsageResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &summary); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("status %d, body %s", rec.Code, rec.Body.String())
+	}
+	if summary.Totals.Requests != 2 || summary.Totals.CostUSD != 22 || summary.Totals.TotalTokens != 14 {
+		t.Errorf("unexpected totals %+v", summary.Totals)
+	}
+	if len(summary.Groups) != 2 || summary.From == nil {
+		t.Errorf("unexpected groups %+v", summary.Groups)
+	}
+
+	rec = get("/v1/usage/records?tenant=" + ledger.AnonymousTenant)
+	var records gatewayController.UsageRecordsResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &records)
+	if len(records.Records) != 1 || records.Records[0].Model != "unpriced" || records.Records[0].Kind != "chat" {
+		t.Errorf("unexpected anonymous records %+v", records.Records)
+	}
+
+	rec = get("/v1/usage?group_by=kind")
+	_ = json.Unmarshal(rec.Body.Bytes(), &summary)
+	kinds := map[string]int64{}
+	for _, group := range summary.Groups {
+		kinds[group.Key] = group.Requests
+	}
+	if kinds["chat"] != 2 || kinds["advise"] != 1 {
+		t.Errorf("unexpected kinds %v", kinds)
+	}
+
+	for _, path := range []string{"/v1/usage?group_by=planet", "/v1/usage?from=yesterday", "/v1/usage/records?limit=0"} {
+		if rec := get(path); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", path, rec.Code)
+		}
+	}
+
+	unavailable := gin.New()
+	unavailable.GET("/v1/usage", gatewayController.NewUsageController(nil).Summary)
+	rec = httptest.NewRecorder()
+	unavailable.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/usage", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("nil ledger: status = %d", rec.Code)
+	}
+}
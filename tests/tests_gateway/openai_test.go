@@ -0,0 +1,216 @@
+package testsgateway
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	models "github.com/kubex-ecosystem/gdbase/factory/models/mcp"
+	gatewayController "github.com/kubex-ecosystem/gobe/internal/app/controllers/gateway"
+	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
+	gatewaysvc "github.com/kubex-ecosystem/gobe/internal/services/gateway/registry"
+)
+
+// fakeProviders serves provider records from memory; only ListProviders is
+// used by the gateway registry.
+type fakeProviders struct {
+	svc.ProvidersService
+	records []svc.ProvidersModel
+}
+
+func (f *fakeProviders) ListProviders() ([]svc.ProvidersModel, error) {
+	return f.records, nil
+}
+
+type fakeCatalog struct {
+	records []svc.LLMModel
+}
+
+func (f *fakeCatalog) GetEnabledLLMModels() ([]svc.LLMModel, error) {
+	return f.records, nil
+}
+
+// newUpstream fakes an OpenAI API that echoes the requested model.
+func newUpstream(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		raw, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(raw, &body)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, part := range []string{"hello ", "from " + body.Model} {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", part)
+		}
+		fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":3,\"completion_tokens\":4,\"total_tokens\":7}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+}
+
+func newOpenAIRouter(t *testing.T, upstream string) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	providers := &fakeProviders{records: []svc.ProvidersModel{
+		models.NewProvidersModel("anthropic", "", map[string]interface{}{
+			"type": "openai", "base_url": upstream, "api_key": "k", "default_model": "claude-x",
+		}),
+		models.NewProvidersModel("local", "", map[string]interface{}{
+			"type": "openai", "base_url": upstream, "api_key": "k", "default_model": "gpt-local",
+		}),
+	}}
+	service, err := gatewaysvc.NewService(providers)
+	if err != nil {
+		t.Fatalf("NewService() unexpected error = %v", err)
+	}
+
+	catalog := &fakeCatalog{records: []svc.LLMModel{
+		models.NewLLMModel(true, "local", "gpt-extra", 0.7, 1024, 1, 0, 0, nil),
+	}}
+	controller := gatewayController.NewOpenAIController(service, catalog)
+
+	router := gin.New()
+	router.POST("/v1/chat/completions", controller.ChatCompletions)
+	router.GET("/v1/models", controller.ListModels)
+	return router
+}
+
+func postCompletion(router *gin.Engine, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestOpenAIFacade_ChatCompletion(t *testing.T) {
+	t.Parallel()
+
+	upstream := newUpstream(t)
+	defer upstream.Close()
+	router := newOpenAIRouter(t, upstream.URL)
+
+	rec := postCompletion(router, `{"model":"anthropic/claude-y","messages":[{"role":"user","content":[{"type":"text","text":"hi"}]}]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	var resp gatewayController.OpenAIChatResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if resp.Object != "chat.completion" || resp.Model != "anthropic/claude-y" || !strings.HasPrefix(resp.ID, "chatcmpl-") {
+		t.Errorf("unexpected envelope %+v", resp)
+	}
+	if len(resp.Choices) != 1 || string(resp.Choices[0].Message.Content) != "hello from claude-y" || *resp.Choices[0].FinishReason != "stop" {
+		t.Errorf("unexpected choices %+v", resp.Choices)
+	}
+	if resp.Usage == nil || resp.Usage.TotalTokens != 7 || resp.Usage.PromptTokens != 3 {
+		t.Errorf("unexpected usage %+v", resp.Usage)
+	}
+
+	// Bare models route through default models and LLMModel records
+	for model, want := range map[string]string{"gpt-local": "local/gpt-local", "gpt-extra": "local/gpt-extra", "local": "local/gpt-local"} {
+		rec := postCompletion(router, `{"model":"`+model+`","messages":[{"role":"user","content":"hi"}]}`)
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		if rec.Code != http.StatusOK || resp.Model != want {
+			t.Errorf("model %q routed to %q (status %d), want %q", model, resp.Model, rec.Code, want)
+		}
+	}
+
+	rec = postCompletion(router, `{"model":"unknown-model","messages":[{"role":"user","content":"hi"}]}`)
+	var errResp gatewayController.OpenAIErrorResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &errResp)
+	if rec.Code != http.StatusNotFound || errResp.Error.Type != "invalid_request_error" {
+		t.Errorf("unknown model: status %d, body %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestOpenAIFacade_Streaming(t *testing.T) {
+	t.Parallel()
+
+	upstream := newUpstream(t)
+	defer upstream.Close()
+	router := newOpenAIRouter(t, upstream.URL)
+
+	rec := postCompletion(router, `{"model":"local/gpt-local","stream":true,"stream_options":{"include_usage":true},"messages":[{"role":"user","content":"hi"}]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	var chunks []gatewayController.OpenAIChatResponse
+	sawDone := false
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		payload, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		if payload == "[DONE]" {
+			sawDone = true
+			continue
+		}
+		var chunk gatewayController.OpenAIChatResponse
+		if err := json.Unmarshal([]byte(payload), &chunk); err != nil {
+			t.Fatalf("invalid chunk %s: %v", payload, err)
+		}
+		chunks = append(chunks, chunk)
+	}
+	if !sawDone || len(chunks) < 4 {
+		t.Fatalf("done = %v, chunks = %d", sawDone, len(chunks))
+	}
+
+	if chunks[0].Choices[0].Delta.Role != "assistant" {
+		t.Errorf("first chunk should announce the role, got %+v", chunks[0].Choices[0].Delta)
+	}
+	var content string
+	for _, chunk := range chunks {
+		if chunk.Object != "chat.completion.chunk" {
+			t.Errorf("unexpected object %q", chunk.Object)
+		}
+		for _, choice := range chunk.Choices {
+			content += choice.Delta.Content
+		}
+	}
+	if content != "hello from gpt-local" {
+		t.Errorf("content = %q", content)
+	}
+
+	finish := chunks[len(chunks)-2]
+	if finish.Choices[0].FinishReason == nil || *finish.Choices[0].FinishReason != "stop" {
+		t.Errorf("unexpected finish chunk %+v", finish)
+	}
+	usage := chunks[len(chunks)-1]
+	if len(usage.Choices) != 0 || usage.Usage == nil || usage.Usage.TotalTokens != 7 {
+		t.Errorf("unexpected usage chunk %+v", usage)
+	}
+}
+
+func TestOpenAIFacade_ListModels(t *testing.T) {
+	t.Parallel()
+
+	router := newOpenAIRouter(t, "http://127.0.0.1:0")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/models", nil))
+
+	var list gatewayController.OpenAIModelList
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	var ids []string
+	for _, model := range list.Data {
+		ids = append(ids, model.ID)
+	}
+	want := "anthropic/claude-x,local/gpt-extra,local/gpt-local"
+	if list.Object != "list" || strings.Join(ids, ",") != want {
+		t.Errorf("models = %v, want %s", ids, want)
+	}
+}
@@ -0,0 +1,204 @@
+package testsgateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	models "github.com/kubex-ecosystem/gdbase/factory/models/mcp"
+	"github.com/kubex-ecosystem/gobe/internal/config"
+	"github.com/kubex-ecosystem/gobe/internal/services/gateway"
+	gatewaysvc "github.com/kubex-ecosystem/gobe/internal/services/gateway/registry"
+)
+
+// countingUpstream answers with status, or streams "ok" when status is 200.
+func countingUpstream(t *testing.T, status int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	hits := &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if status != http.StatusOK {
+			http.Error(w, "upstream says no", status)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"ok\"}}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+	return server, hits
+}
+
+func newRoutingService(t *testing.T, upstreams map[string]string) *gatewaysvc.Service {
+	t.Helper()
+	providers := &fakeProviders{}
+	for name, url := range upstreams {
+		providers.records = append(providers.records, models.NewProvidersModel(name, "", map[string]interface{}{
+			"type": "openai", "base_url": url, "api_key": "k", "default_model": name + "-model",
+		}))
+	}
+	service, err := gatewaysvc.NewService(providers)
+	if err != nil {
+		t.Fatalf("NewService() unexpected error = %v", err)
+	}
+	return service
+}
+
+func chatOnce(t *testing.T, service *gatewaysvc.Service, provider string) (gateway.ProviderConfig, *gateway.Usage, error) {
+	t.Helper()
+	stream, cfg, err := service.Chat(context.Background(), gateway.ChatRequest{
+		Provider: provider,
+		Messages: []gateway.Message{{Role: "user", Content: "hi"}},
+	})
+	if err != nil {
+		return cfg, nil, err
+	}
+	var usage *gateway.Usage
+	for chunk := range stream {
+		if chunk.Error != "" {
+			t.Fatalf("stream error %s", chunk.Error)
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+	}
+	return cfg, usage, nil
+}
+
+func TestRouting_FallbackRetriesAndCooldown(t *testing.T) {
+	t.Parallel()
+
+	down, downHits := countingUpstream(t, http.StatusServiceUnavailable)
+	up, upHits := countingUpstream(t, http.StatusOK)
+	service := newRoutingService(t, map[string]string{"down": down.URL, "up": up.URL})
+
+	err := service.ApplyConfig(config.GatewayConfig{
+		Routes: []config.GatewayRouteConfig{{
+			Name:    "smart",
+			Targets: []config.GatewayRouteTarget{{Provider: "down"}, {Provider: "up", Model: "up-large"}},
+		}},
+		Retry:           config.GatewayRetryConfig{MaxAttempts: 2, BackoffMS: 1},
+		CooldownSeconds: 60,
+	})
+	if err != nil {
+		t.Fatalf("ApplyConfig() unexpected error = %v", err)
+	}
+
+	cfg, usage, err := chatOnce(t, service, "smart")
+	if err != nil {
+		t.Fatalf("Chat() unexpected error = %v", err)
+	}
+	if cfg.Name != "up" || downHits.Load() != 2 || upHits.Load() != 1 {
+		t.Fatalf("chosen %q, down hits %d, up hits %d", cfg.Name, downHits.Load(), upHits.Load())
+	}
+	if usage == nil || len(usage.Attempts) != 3 {
+		t.Fatalf("unexpected usage %+v", usage)
+	}
+	if a := usage.Attempts[0]; a.Provider != "down" || a.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("unexpected first attempt %+v", a)
+	}
+	if a := usage.Attempts[2]; a.Provider != "up" || a.Model != "up-large" || a.Error != "" {
+		t.Errorf("unexpected last attempt %+v", a)
+	}
+
+	// The failing entry is benched and skipped on the next request
+	benched := false
+	for _, summary := range service.ProviderSummaries() {
+		if summary.Name == "down" && summary.CooldownUntil != nil && !summary.Available {
+			benched = true
+		}
+	}
+	if !benched {
+		t.Error("down provider should be cooling down")
+	}
+	_, usage, err = chatOnce(t, service, "smart")
+	if err != nil {
+		t.Fatalf("second Chat() unexpected error = %v", err)
+	}
+	if downHits.Load() != 2 || len(usage.Attempts) != 1 {
+		t.Errorf("benched provider was retried: down hits %d, attempts %+v", downHits.Load(), usage.Attempts)
+	}
+}
+
+func TestRouting_ClientErrorsAreNotRetried(t *testing.T) {
+	t.Parallel()
+
+	bad, badHits := countingUpstream(t, http.StatusUnauthorized)
+	service := newRoutingService(t, map[string]string{"bad": bad.URL})
+	service.SetRetryPolicy(gateway.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond})
+
+	_, _, err := chatOnce(t, service, "bad")
+	var routeErr *gateway.RouteError
+	if !errors.As(err, &routeErr) {
+		t.Fatalf("Chat() error = %v, want *gateway.RouteError", err)
+	}
+	if badHits.Load() != 1 || len(routeErr.Attempts) != 1 || routeErr.Attempts[0].StatusCode != http.StatusUnauthorized {
+		t.Errorf("hits %d, attempts %+v", badHits.Load(), routeErr.Attempts)
+	}
+
+	// A 4xx other than 429 says nothing about the provider's health
+	for _, summary := range service.ProviderSummaries() {
+		if summary.CooldownUntil != nil {
+			t.Errorf("provider %s should not be benched", summary.Name)
+		}
+	}
+}
+
+func TestRouting_WeightedSpreadsLoad(t *testing.T) {
+	t.Parallel()
+
+	a, aHits := countingUpstream(t, http.StatusOK)
+	b, bHits := countingUpstream(t, http.StatusOK)
+	service := newRoutingService(t, map[string]string{"a": a.URL, "b": b.URL})
+	err := service.SetRoutes([]gateway.RoutePolicy{{
+		Name:     "balanced",
+		Strategy: gateway.RouteWeighted,
+		Targets:  []gateway.RouteTarget{{Provider: "a", Weight: 3}, {Provider: "b", Weight: 1}},
+	}})
+	if err != nil {
+		t.Fatalf("SetRoutes() unexpected error = %v", err)
+	}
+
+	const requests = 200
+	for i := 0; i < requests; i++ {
+		if _, _, err := chatOnce(t, service, "balanced"); err != nil {
+			t.Fatalf("Chat() unexpected error = %v", err)
+		}
+	}
+	if aHits.Load()+bHits.Load() != requests || aHits.Load() < 110 || aHits.Load() > 185 {
+		t.Errorf("a hits %d, b hits %d; want roughly 3:1", aHits.Load(), bHits.Load())
+	}
+}
+
+func TestRouting_InvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	service := newRoutingService(t, nil)
+	cases := []config.GatewayConfig{
+		{Routes: []config.GatewayRouteConfig{{Name: "r"}}},
+		{Routes: []config.GatewayRouteConfig{{Name: "r", Strategy: "random", Targets: []config.GatewayRouteTarget{{Provider: "a"}}}}},
+		{Routes: []config.GatewayRouteConfig{{Targets: []config.GatewayRouteTarget{{Provider: "a"}}}}},
+	}
+	for i, cfg := range cases {
+		if err := service.ApplyConfig(cfg); err == nil {
+			t.Errorf("case %d: ApplyConfig() expected error", i)
+		}
+	}
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	t.Parallel()
+
+	policy := gateway.RetryPolicy{MaxAttempts: 5, Backoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond}
+	for i, expected := range want {
+		if got := policy.Delay(i + 1); got != expected {
+			t.Errorf("Delay(%d) = %v, want %v", i+1, got, expected)
+		}
+	}
+}
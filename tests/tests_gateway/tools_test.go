@@ -0,0 +1,278 @@
+package testsgateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kubex-ecosystem/gobe/internal/services/gateway"
+	"github.com/kubex-ecosystem/gobe/internal/services/gateway/providers"
+	"github.com/kubex-ecosystem/gobe/internal/services/mcp"
+)
+
+func newToolRegistry(t *testing.T) mcp.Registry {
+	t.Helper()
+
+	registry := mcp.NewRegistry()
+	specs := []mcp.ToolSpec{
+		{
+			Name:        "test.echo",
+			Description: "Echo a message",
+			InputSchema: json.RawMessage(`{"type":"object","properties":{"msg":{"type":"string"}},"required":["msg"]}`),
+			Handler: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+				return map[string]interface{}{"echo": args["msg"]}, nil
+			},
+		},
+		{
+			Name: "test.secret",
+			Auth: mcp.AuthAdmin,
+			Handler: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+				return "secret", nil
+			},
+		},
+	}
+	for _, spec := range specs {
+		if err := registry.Register(spec); err != nil {
+			t.Fatalf("Register(%s) unexpected error = %v", spec.Name, err)
+		}
+	}
+	return registry
+}
+
+// scriptedProvider answers each Chat call with the next scripted step and
+// records the requests it received.
+type scriptedProvider struct {
+	mu       sync.Mutex
+	steps    [][]gateway.ChatChunk
+	requests []gateway.ChatRequest
+}
+
+func (p *scriptedProvider) Name() string                                                  { return "scripted" }
+func (p *scriptedProvider) Available() error                                              { return nil }
+func (p *scriptedProvider) Notify(ctx context.Context, _ gateway.NotificationEvent) error { return nil }
+
+func (p *scriptedProvider) Chat(ctx context.Context, req gateway.ChatRequest) (<-chan gateway.ChatChunk, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	idx := len(p.requests)
+	p.requests = append(p.requests, req)
+	step := p.steps[len(p.steps)-1]
+	if idx < len(p.steps) {
+		step = p.steps[idx]
+	}
+
+	ch := make(chan gateway.ChatChunk, len(step))
+	for _, chunk := range step {
+		ch <- chunk
+	}
+	close(ch)
+	return ch, nil
+}
+
+func collect(t *testing.T, stream <-chan gateway.ChatChunk) []gateway.ChatChunk {
+	t.Helper()
+	var chunks []gateway.ChatChunk
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case chunk, ok := <-stream:
+			if !ok {
+				return chunks
+			}
+			chunks = append(chunks, chunk)
+		case <-timeout:
+			t.Fatal("tool loop did not finish")
+		}
+	}
+}
+
+func TestGatewayTools_FiltersAndSanitizesNames(t *testing.T) {
+	t.Parallel()
+
+	registry := newToolRegistry(t)
+
+	// Admin-only tools are hidden from anonymous callers
+	tools := mcp.GatewayTools(context.Background(), registry, []string{"test.*"}).Tools()
+	if len(tools) != 1 || tools[0].Name != "test_echo" || tools[0].Description != "Echo a message" {
+		t.Fatalf("unexpected tools %+v", tools)
+	}
+
+	admin := mcp.WithPrincipal(context.Background(), &mcp.Principal{ID: "root", Roles: []string{mcp.AuthAdmin}})
+	executor := mcp.GatewayTools(admin, registry, []string{"test.*"})
+	if got := len(executor.Tools()); got != 2 {
+		t.Fatalf("admin should see 2 tools, got %d", got)
+	}
+
+	result, err := executor.Exec(admin, "test_echo", map[string]interface{}{"msg": "hi"})
+	if err != nil {
+		t.Fatalf("Exec() unexpected error = %v", err)
+	}
+	if result.(map[string]interface{})["echo"] != "hi" {
+		t.Errorf("unexpected result %v", result)
+	}
+
+	// Registry validation still applies to model-generated arguments
+	if _, err := executor.Exec(admin, "test_echo", map[string]interface{}{}); err == nil {
+		t.Error("Exec() expected schema validation error")
+	}
+	if _, err := executor.Exec(admin, "system_status", nil); err == nil {
+		t.Error("Exec() of a tool not offered to the model expected error")
+	}
+}
+
+func TestRunToolLoop_FeedsToolResultsBack(t *testing.T) {
+	t.Parallel()
+
+	provider := &scriptedProvider{steps: [][]gateway.ChatChunk{
+		{
+			{ToolCall: &gateway.ToolCall{ID: "call_1", Name: "test_echo", Args: map[string]interface{}{"msg": "ping"}}},
+			{Done: true, Usage: &gateway.Usage{PromptTokens: 10, CompletionTokens: 2, TotalTokens: 12}},
+		},
+		{
+			{Content: "the tool said ping"},
+			{Done: true, Usage: &gateway.Usage{PromptTokens: 20, CompletionTokens: 5, TotalTokens: 25}},
+		},
+	}}
+	executor := mcp.GatewayTools(context.Background(), newToolRegistry(t), nil)
+
+	req := gateway.ChatRequest{Model: "m", Messages: []gateway.Message{{Role: "user", Content: "echo ping"}}}
+	stream, err := gateway.RunToolLoop(context.Background(), provider, req, executor, 0)
+	if err != nil {
+		t.Fatalf("RunToolLoop() unexpected error = %v", err)
+	}
+	chunks := collect(t, stream)
+
+	var result *gateway.ToolResult
+	var content string
+	for _, chunk := range chunks {
+		if chunk.ToolResult != nil {
+			result = chunk.ToolResult
+		}
+		content += chunk.Content
+	}
+	if result == nil || result.ID != "call_1" || result.Error != "" {
+		t.Fatalf("unexpected tool result %+v", result)
+	}
+	if content != "the tool said ping" {
+		t.Errorf("content = %q", content)
+	}
+
+	last := chunks[len(chunks)-1]
+	if !last.Done || last.Error != "" || last.Step != 2 || last.Usage.TotalTokens != 37 {
+		t.Errorf("unexpected final chunk %+v (usage %+v)", last, last.Usage)
+	}
+
+	if len(provider.requests) != 2 {
+		t.Fatalf("provider called %d times, want 2", len(provider.requests))
+	}
+	if len(provider.requests[0].Tools) == 0 {
+		t.Error("tools were not offered to the provider")
+	}
+	msgs := provider.requests[1].Messages
+	if len(msgs) != 3 || msgs[1].Role != "assistant" || len(msgs[1].ToolCalls) != 1 {
+		t.Fatalf("unexpected follow-up messages %+v", msgs)
+	}
+	if msgs[2].Role != "tool" || msgs[2].ToolCallID != "call_1" || !strings.Contains(msgs[2].Content, `"echo":"ping"`) {
+		t.Errorf("unexpected tool message %+v", msgs[2])
+	}
+}
+
+func TestRunToolLoop_StopsAfterMaxSteps(t *testing.T) {
+	t.Parallel()
+
+	// The model keeps calling tools; failures are fed back rather than aborting
+	provider := &scriptedProvider{steps: [][]gateway.ChatChunk{{
+		{ToolCall: &gateway.ToolCall{ID: "call_x", Name: "test_secret"}},
+		{Done: true},
+	}}}
+	executor := mcp.GatewayTools(context.Background(), newToolRegistry(t), nil)
+
+	req := gateway.ChatRequest{Messages: []gateway.Message{{Role: "user", Content: "loop"}}}
+	stream, err := gateway.RunToolLoop(context.Background(), provider, req, executor, 3)
+	if err != nil {
+		t.Fatalf("RunToolLoop() unexpected error = %v", err)
+	}
+	chunks := collect(t, stream)
+
+	failed := 0
+	for _, chunk := range chunks {
+		if chunk.ToolResult != nil && chunk.ToolResult.Error != "" {
+			failed++
+		}
+	}
+	if failed != 2 {
+		t.Errorf("failed tool results = %d, want 2", failed)
+	}
+
+	last := chunks[len(chunks)-1]
+	if !last.Done || last.Step != 3 || !strings.Contains(last.Error, "3 steps") {
+		t.Errorf("unexpected final chunk %+v", last)
+	}
+	if len(provider.requests) != 3 {
+		t.Errorf("provider called %d times, want 3", len(provider.requests))
+	}
+}
+
+func TestOpenAIProvider_StreamsToolCalls(t *testing.T) {
+	t.Parallel()
+
+	var body map[string]interface{}
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(raw, &body)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		// Arguments arrive split across deltas keyed by index
+		for _, payload := range []string{
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_a","function":{"name":"test_echo","arguments":"{\"msg\":"}}]}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"hi\"}"}}]}}]}`,
+			`{"choices":[{"delta":{},"finish_reason":"tool_calls"}]}`,
+		} {
+			fmt.Fprintf(w, "data: %s\n\n", payload)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer api.Close()
+
+	provider, err := providers.New(providers.Config{Name: "oa", Type: "openai", BaseURL: api.URL, APIKey: "k", DefaultModel: "gpt"})
+	if err != nil {
+		t.Fatalf("providers.New() unexpected error = %v", err)
+	}
+
+	stream, err := provider.Chat(context.Background(), gateway.ChatRequest{
+		Messages: []gateway.Message{{Role: "user", Content: "hi"}},
+		Tools:    []gateway.Tool{{Name: "test_echo", Parameters: json.RawMessage(`{"type":"object"}`)}},
+	})
+	if err != nil {
+		t.Fatalf("Chat() unexpected error = %v", err)
+	}
+
+	var calls []gateway.ToolCall
+	for _, chunk := range collect(t, stream) {
+		if chunk.Error != "" {
+			t.Fatalf("stream error %s", chunk.Error)
+		}
+		if chunk.ToolCall != nil {
+			calls = append(calls, *chunk.ToolCall)
+		}
+	}
+	if len(calls) != 1 || calls[0].ID != "call_a" || calls[0].Name != "test_echo" {
+		t.Fatalf("unexpected tool calls %+v", calls)
+	}
+	if args, _ := calls[0].Args.(map[string]interface{}); args["msg"] != "hi" {
+		t.Errorf("unexpected args %+v", calls[0].Args)
+	}
+
+	tools, _ := body["tools"].([]interface{})
+	if len(tools) != 1 {
+		t.Errorf("request tools = %v", body["tools"])
+	}
+}
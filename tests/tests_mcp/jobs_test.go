@@ -0,0 +1,256 @@
+package testsmcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/kubex-ecosystem/gobe/internal/services/mcp"
+)
+
+// newJobRegistry registers test.slow, which reports progress and blocks until
+// release is closed or its context is cancelled.
+func newJobRegistry(t *testing.T, release <-chan struct{}) mcp.Registry {
+	t.Helper()
+
+	registry := mcp.NewRegistry()
+	err := registry.Register(mcp.ToolSpec{
+		Name: "test.slow",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {"steps": {"type": "integer", "minimum": 1}},
+			"required": ["steps"]
+		}`),
+		Handler: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			steps, _ := args["steps"].(float64)
+			if n, ok := args["steps"].(int); ok {
+				steps = float64(n)
+			}
+			mcp.ReportProgress(ctx, 1, steps, "step 1")
+			select {
+			case <-release:
+				mcp.ReportProgress(ctx, steps, steps, "done")
+				return map[string]interface{}{"steps": steps}, nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("Register() unexpected error = %v", err)
+	}
+	return registry
+}
+
+func waitForJob(t *testing.T, jobs *mcp.JobManager, ctx context.Context, id string, cond func(mcp.Job) bool) mcp.Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := jobs.Get(ctx, id)
+		if err == nil && cond(job) {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	job, err := jobs.Get(ctx, id)
+	t.Fatalf("job %s did not reach the expected state, last = %+v (err %v)", id, job, err)
+	return job
+}
+
+func TestJobManager_ProgressAndResult(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	jobs := mcp.NewJobManager(newJobRegistry(t, release), 50*time.Millisecond)
+	ctx := mcp.WithPrincipal(context.Background(), &mcp.Principal{ID: "alice"})
+
+	job, err := jobs.Submit(ctx, "test.slow", map[string]interface{}{"steps": 3})
+	if err != nil {
+		t.Fatalf("Submit() unexpected error = %v", err)
+	}
+	if job.ID == "" || job.Status != mcp.JobPending {
+		t.Fatalf("unexpected submitted job %+v", job)
+	}
+
+	running := waitForJob(t, jobs, ctx, job.ID, func(j mcp.Job) bool { return j.Progress == 1 })
+	if running.Status != mcp.JobRunning || running.Total != 3 || running.Message != "step 1" {
+		t.Errorf("unexpected running job %+v", running)
+	}
+
+	close(release)
+	done := waitForJob(t, jobs, ctx, job.ID, func(j mcp.Job) bool { return j.Status.Done() })
+	if done.Status != mcp.JobSucceeded || done.Result == nil || done.ExpiresAt == nil {
+		t.Errorf("unexpected finished job %+v", done)
+	}
+
+	// Results are dropped once the TTL elapses
+	time.Sleep(100 * time.Millisecond)
+	if _, err := jobs.Get(ctx, job.ID); !errors.Is(err, mcp.ErrJobNotFound) {
+		t.Errorf("Get() after TTL error = %v, want ErrJobNotFound", err)
+	}
+}
+
+func TestJobManager_Cancel(t *testing.T) {
+	t.Parallel()
+
+	jobs := mcp.NewJobManager(newJobRegistry(t, make(chan struct{})), time.Minute)
+	owner := mcp.WithPrincipal(context.Background(), &mcp.Principal{ID: "alice"})
+	other := mcp.WithPrincipal(context.Background(), &mcp.Principal{ID: "bob"})
+
+	job, err := jobs.Submit(owner, "test.slow", map[string]interface{}{"steps": 2})
+	if err != nil {
+		t.Fatalf("Submit() unexpected error = %v", err)
+	}
+	waitForJob(t, jobs, owner, job.ID, func(j mcp.Job) bool { return j.Status == mcp.JobRunning })
+
+	// Other users can neither see nor cancel the job; admins can
+	if _, err := jobs.Get(other, job.ID); !errors.Is(err, mcp.ErrJobNotFound) {
+		t.Errorf("Get() by another user error = %v, want ErrJobNotFound", err)
+	}
+	if _, err := jobs.Cancel(other, job.ID); !errors.Is(err, mcp.ErrJobNotFound) {
+		t.Errorf("Cancel() by another user error = %v, want ErrJobNotFound", err)
+	}
+	if _, err := jobs.Get(adminContext(), job.ID); err != nil {
+		t.Errorf("Get() by admin unexpected error = %v", err)
+	}
+
+	cancelled, err := jobs.Cancel(owner, job.ID)
+	if err != nil {
+		t.Fatalf("Cancel() unexpected error = %v", err)
+	}
+	if cancelled.Status != mcp.JobCancelled {
+		t.Errorf("Cancel() status = %s, want cancelled", cancelled.Status)
+	}
+	if _, err := jobs.Cancel(owner, job.ID); !errors.Is(err, mcp.ErrJobFinished) {
+		t.Errorf("second Cancel() error = %v, want ErrJobFinished", err)
+	}
+
+	// The handler's context is cancelled and its late return does not flip the status
+	time.Sleep(50 * time.Millisecond)
+	if final, _ := jobs.Get(owner, job.ID); final.Status != mcp.JobCancelled {
+		t.Errorf("final status = %s, want cancelled", final.Status)
+	}
+}
+
+func TestJobManager_OwnerlessJobsAreAdminOnly(t *testing.T) {
+	t.Parallel()
+
+	jobs := mcp.NewJobManager(newJobRegistry(t, make(chan struct{})), time.Minute)
+	job, err := jobs.Submit(context.Background(), "test.slow", map[string]interface{}{"steps": 2})
+	if err != nil {
+		t.Fatalf("Submit() unexpected error = %v", err)
+	}
+	if job.Owner != "" {
+		t.Fatalf("anonymous job owner = %q, want none", job.Owner)
+	}
+
+	for name, ctx := range map[string]context.Context{
+		"anonymous": context.Background(),
+		"user":      mcp.WithPrincipal(context.Background(), &mcp.Principal{ID: "bob"}),
+	} {
+		if _, err := jobs.Get(ctx, job.ID); !errors.Is(err, mcp.ErrJobNotFound) {
+			t.Errorf("Get() by %s error = %v, want ErrJobNotFound", name, err)
+		}
+		if _, err := jobs.Cancel(ctx, job.ID); !errors.Is(err, mcp.ErrJobNotFound) {
+			t.Errorf("Cancel() by %s error = %v, want ErrJobNotFound", name, err)
+		}
+	}
+	if _, err := jobs.Cancel(adminContext(), job.ID); err != nil {
+		t.Errorf("Cancel() by admin unexpected error = %v", err)
+	}
+}
+
+func TestJobManager_SubmitRejectsSynchronously(t *testing.T) {
+	t.Parallel()
+
+	registry := newJobRegistry(t, make(chan struct{}))
+	if err := mcp.RegisterBuiltinTools(registry); err != nil {
+		t.Fatalf("RegisterBuiltinTools() error = %v", err)
+	}
+	jobs := mcp.NewJobManager(registry, 0)
+
+	var verr *mcp.ValidationError
+	if _, err := jobs.Submit(context.Background(), "test.slow", map[string]interface{}{}); !errors.As(err, &verr) {
+		t.Errorf("Submit() with missing args error = %v, want *mcp.ValidationError", err)
+	}
+
+	var authErr *mcp.AuthorizationError
+	if _, err := jobs.Submit(context.Background(), "shell.command", map[string]interface{}{"command": "ls"}); !errors.As(err, &authErr) {
+		t.Errorf("Submit() of admin tool error = %v, want *mcp.AuthorizationError", err)
+	}
+
+	if _, err := jobs.Submit(context.Background(), "test.missing", nil); err == nil {
+		t.Error("Submit() of unknown tool expected error")
+	}
+}
+
+func TestServer_EmitsProgressNotifications(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	close(release)
+	srv, err := mcp.NewServer(nil)
+	if err != nil {
+		t.Fatalf("NewServer() unexpected error = %v", err)
+	}
+	srv.RegisterRegistryTools(newJobRegistry(t, release))
+
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.ServeStdio(ctx, inReader, outWriter)
+	}()
+
+	requests := []string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"gobe-test","version":"1.0.0"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"test.slow","arguments":{"steps":2},"_meta":{"progressToken":"tok-1"}}}`,
+	}
+	go func() {
+		for _, req := range requests {
+			_, _ = io.WriteString(inWriter, req+"\n")
+		}
+	}()
+
+	// Notifications and the response travel on separate goroutines, so read
+	// until both progress updates and the result have arrived.
+	var progress []map[string]interface{}
+	answered := false
+	scanner := bufio.NewScanner(outReader)
+	for (!answered || len(progress) < 2) && scanner.Scan() {
+		var msg struct {
+			ID     json.RawMessage        `json:"id"`
+			Method string                 `json:"method"`
+			Params map[string]interface{} `json:"params"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			t.Fatalf("invalid JSON-RPC message %s: %v", scanner.Text(), err)
+		}
+		switch {
+		case msg.Method == "notifications/progress":
+			progress = append(progress, msg.Params)
+		case string(msg.ID) == "2":
+			answered = true
+		}
+	}
+
+	if !answered || len(progress) != 2 {
+		t.Fatalf("answered = %v, progress notifications = %d, want 2", answered, len(progress))
+	}
+	last := progress[1]
+	if last["progressToken"] != "tok-1" || last["progress"] != float64(2) || last["total"] != float64(2) || last["message"] != "done" {
+		t.Errorf("unexpected progress notification %+v", last)
+	}
+
+	cancel()
+	_ = inWriter.Close()
+	<-done
+}
@@ -0,0 +1,270 @@
+package testsmcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubex-ecosystem/gobe/internal/services/gateway"
+	"github.com/kubex-ecosystem/gobe/internal/services/mcp"
+)
+
+func writeManifest(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+	return path
+}
+
+func adminContext() context.Context {
+	return mcp.WithPrincipal(context.Background(), &mcp.Principal{ID: "tester", Roles: []string{"admin"}})
+}
+
+const echoManifest = `
+name: test.echo_cmd
+description: Echo a greeting
+inputSchema:
+  type: object
+  properties:
+    name: {type: string, minLength: 1}
+    loud: {type: string}
+  required: [name]
+command:
+  binary: echo
+  args: ["hello", "{{.name}}", "{{.loud}}"]
+  argPattern: '^[A-Za-z!]+$'
+  timeout: 2s
+`
+
+func TestManifest_CommandBackend(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeManifest(t, dir, "echo.yaml", echoManifest)
+
+	registry := mcp.NewRegistry()
+	loader := mcp.NewManifestLoader(dir, registry, mcp.ManifestBackends{})
+	if _, err := loader.Load(); err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+
+	tool := mustGetTool(t, registry, "test.echo_cmd")
+	if tool.Auth != mcp.AuthAdmin {
+		t.Errorf("command tools should default to admin, got %q", tool.Auth)
+	}
+
+	if _, err := registry.Exec(context.Background(), "test.echo_cmd", map[string]interface{}{"name": "gobe"}); err == nil {
+		t.Error("Exec() should require admin for command tools")
+	}
+
+	result, err := registry.Exec(adminContext(), "test.echo_cmd", map[string]interface{}{"name": "gobe"})
+	if err != nil {
+		t.Fatalf("Exec() unexpected error = %v", err)
+	}
+	out := result.(map[string]interface{})
+	if out["status"] != "success" || strings.TrimSpace(out["stdout"].(string)) != "hello gobe" {
+		t.Errorf("unexpected command result %v", out)
+	}
+
+	// Args are validated by execsafe before the binary runs
+	result, err = registry.Exec(adminContext(), "test.echo_cmd", map[string]interface{}{"name": "a;b"})
+	if err == nil {
+		t.Errorf("Exec() expected argPattern rejection, got %v", result)
+	}
+}
+
+func TestManifest_HTTPAndPromptBackends(t *testing.T) {
+	t.Parallel()
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Token") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"q": r.URL.Query().Get("q")})
+	}))
+	defer api.Close()
+
+	dir := t.TempDir()
+	writeManifest(t, dir, "tools.json", `{
+		"tools": [
+			{
+				"name": "test.search",
+				"inputSchema": {"type": "object", "properties": {"q": {"type": "string"}}, "required": ["q"]},
+				"http": {"url": "`+api.URL+`/search?q={{.q | urlquery}}", "headers": {"X-Token": "secret"}}
+			},
+			{
+				"name": "test.summarize",
+				"inputSchema": {"type": "object", "properties": {"text": {"type": "string"}}},
+				"prompt": {"provider": "fake", "system": "be brief", "template": "Summarize: {{.text}}"}
+			},
+			{
+				"name": "test.status",
+				"builtin": "system.status"
+			}
+		]
+	}`)
+
+	chat := &fakeChat{}
+	registry := mcp.NewRegistry()
+	loader := mcp.NewManifestLoader(dir, registry, mcp.ManifestBackends{Chat: chat})
+	if _, err := loader.Load(); err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+
+	result, err := registry.Exec(context.Background(), "test.search", map[string]interface{}{"q": "a b&c"})
+	if err != nil {
+		t.Fatalf("http tool error = %v", err)
+	}
+	body := result.(map[string]interface{})["body"].(map[string]interface{})
+	if body["q"] != "a b&c" {
+		t.Errorf("query not escaped correctly, got %v", body)
+	}
+
+	result, err = registry.Exec(context.Background(), "test.summarize", map[string]interface{}{"text": "long text"})
+	if err != nil {
+		t.Fatalf("prompt tool error = %v", err)
+	}
+	if result.(map[string]interface{})["content"] != "ok" {
+		t.Errorf("unexpected prompt result %v", result)
+	}
+	if len(chat.last.Messages) != 2 || chat.last.Messages[1].Content != "Summarize: long text" {
+		t.Errorf("unexpected prompt messages %+v", chat.last.Messages)
+	}
+
+	status := mustGetTool(t, registry, "test.status")
+	if len(status.OutputSchema) == 0 || status.Auth != "none" {
+		t.Errorf("builtin manifest should inherit schema and auth, got %+v", status)
+	}
+}
+
+func TestManifestLoader_ReloadIsAtomicPerFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := writeManifest(t, dir, "echo.yaml", echoManifest)
+
+	registry := mcp.NewRegistry()
+	if err := mcp.RegisterBuiltinTools(registry); err != nil {
+		t.Fatalf("RegisterBuiltinTools() error = %v", err)
+	}
+	loader := mcp.NewManifestLoader(dir, registry, mcp.ManifestBackends{})
+	var removed []string
+	loader.OnChange(func(names []string) { removed = append(removed, names...) })
+	if _, err := loader.Load(); err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+
+	// A broken edit keeps the previous version of the file's tools
+	writeManifest(t, dir, "echo.yaml", "name: test.echo_cmd\ncommand: {}\n")
+	if _, err := loader.Load(); err == nil {
+		t.Fatal("Load() expected error for invalid manifest")
+	}
+	if _, ok := registry.GetTool("test.echo_cmd"); !ok {
+		t.Fatal("invalid edit removed the previously loaded tool")
+	}
+
+	// Renaming the tool replaces it
+	writeManifest(t, dir, "echo.yaml", strings.Replace(echoManifest, "test.echo_cmd", "test.echo_v2", 1))
+	if _, err := loader.Load(); err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if _, ok := registry.GetTool("test.echo_cmd"); ok {
+		t.Error("old tool name still registered after rename")
+	}
+	mustGetTool(t, registry, "test.echo_v2")
+	if len(removed) != 1 || removed[0] != "test.echo_cmd" {
+		t.Errorf("OnChange removed = %v, want [test.echo_cmd]", removed)
+	}
+
+	// Manifests cannot shadow tools registered in Go
+	writeManifest(t, dir, "shadow.yaml", "name: shell.command\nbuiltin: system.status\n")
+	if _, err := loader.Load(); err == nil {
+		t.Error("Load() expected conflict with built-in tool")
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = loader.Load()
+	if _, ok := registry.GetTool("test.echo_v2"); ok {
+		t.Error("tools of a deleted manifest are still registered")
+	}
+	mustGetTool(t, registry, "shell.command")
+}
+
+func TestManifestLoader_Watch(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	registry := mcp.NewRegistry()
+	loader := mcp.NewManifestLoader(dir, registry, mcp.ManifestBackends{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = loader.Watch(ctx) }()
+	time.Sleep(100 * time.Millisecond)
+
+	writeManifest(t, dir, "echo.yaml", echoManifest)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, ok := registry.GetTool("test.echo_cmd"); ok {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatal("watcher did not register the new manifest")
+}
+
+func TestValidateManifests(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeManifest(t, dir, "a.yaml", echoManifest)
+	writeManifest(t, dir, "b.yml", echoManifest)
+	writeManifest(t, dir, "c.yaml", "name: test.bad\nhttp: {url: '{{.x'}\n")
+	writeManifest(t, dir, "notes.txt", "ignored")
+
+	reports, err := mcp.ValidateManifests(dir)
+	if err != nil {
+		t.Fatalf("ValidateManifests() error = %v", err)
+	}
+	if len(reports) != 3 {
+		t.Fatalf("expected 3 reports, got %d", len(reports))
+	}
+	if reports[0].Err != nil {
+		t.Errorf("a.yaml should be valid, got %v", reports[0].Err)
+	}
+	if reports[1].Err == nil || !strings.Contains(reports[1].Err.Error(), "already declared") {
+		t.Errorf("b.yml should report a duplicate, got %v", reports[1].Err)
+	}
+	if reports[2].Err == nil {
+		t.Error("c.yaml should report an invalid template")
+	}
+}
+
+type fakeChat struct {
+	last gateway.ChatRequest
+}
+
+func (f *fakeChat) Chat(ctx context.Context, req gateway.ChatRequest) (<-chan gateway.ChatChunk, gateway.ProviderConfig, error) {
+	if req.Provider != "fake" {
+		return nil, gateway.ProviderConfig{}, errors.New("unknown provider")
+	}
+	f.last = req
+	ch := make(chan gateway.ChatChunk, 2)
+	ch <- gateway.ChatChunk{Content: "ok"}
+	ch <- gateway.ChatChunk{Done: true}
+	close(ch)
+	return ch, gateway.ProviderConfig{Name: "fake", DefaultModel: "fake-1"}, nil
+}
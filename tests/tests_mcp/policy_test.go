@@ -0,0 +1,267 @@
+package testsmcp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/kubex-ecosystem/gobe/internal/config"
+	"github.com/kubex-ecosystem/gobe/internal/contracts/types"
+	"github.com/kubex-ecosystem/gobe/internal/services/mcp"
+	"github.com/mark3labs/mcp-go/client"
+	mcptransport "github.com/mark3labs/mcp-go/client/transport"
+	mcpgo "github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestRulePolicy_AuthRequirement(t *testing.T) {
+	t.Parallel()
+
+	policy := mcp.DefaultPolicy()
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		auth      string
+		principal *mcp.Principal
+		wantErr   bool
+	}{
+		{"public tool, anonymous", mcp.AuthNone, nil, false},
+		{"empty auth, anonymous", "", nil, false},
+		{"authenticated, anonymous", mcp.AuthAuthenticated, nil, true},
+		{"authenticated, known user", mcp.AuthAuthenticated, &mcp.Principal{ID: "u1"}, false},
+		{"admin, plain user", mcp.AuthAdmin, &mcp.Principal{ID: "u1", Roles: []string{"user"}}, true},
+		{"admin, admin user", mcp.AuthAdmin, &mcp.Principal{ID: "u1", Roles: []string{"Admin"}}, false},
+		{"role list", "ops, admin", &mcp.Principal{ID: "u1", Roles: []string{"ops"}}, false},
+		{"scope requirement", "scope:tools.exec", &mcp.Principal{ID: "u1", Scopes: []string{"tools.exec"}}, false},
+		{"scope missing", "scope:tools.exec", &mcp.Principal{ID: "u1", Scopes: []string{"tools.read"}}, true},
+	}
+
+	for _, tt := range tests {
+		err := policy.Authorize(ctx, mcp.ToolSpec{Name: "test.tool", Auth: tt.auth}, tt.principal)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Authorize() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRulePolicy_RulesAndBindings(t *testing.T) {
+	t.Parallel()
+
+	policy := mcp.NewPolicy(config.MCPPolicyConfig{
+		Bindings: []config.MCPRoleBinding{
+			{Role: "admin", Users: []string{"alice"}},
+			{Role: "ops", Guilds: []string{"guild-1"}},
+		},
+		Rules: []config.MCPPolicyRule{
+			{Tools: []string{"shell.*"}, Effect: "allow", Roles: []string{"ops"}},
+			{Tools: []string{"shell.command"}, Effect: "deny", Channels: []string{"public"}},
+		},
+	})
+	ctx := context.Background()
+	shell := mcp.ToolSpec{Name: "shell.command", Auth: mcp.AuthAdmin}
+
+	if err := policy.Authorize(ctx, shell, &mcp.Principal{Username: "alice"}); err != nil {
+		t.Errorf("user binding should grant admin, got %v", err)
+	}
+	if err := policy.Authorize(ctx, shell, &mcp.Principal{ID: "bob", GuildID: "guild-1"}); err != nil {
+		t.Errorf("guild binding plus allow rule should grant access, got %v", err)
+	}
+	if err := policy.Authorize(ctx, shell, &mcp.Principal{ID: "bob", GuildID: "guild-2"}); err == nil {
+		t.Error("caller outside the bound guild should be denied")
+	}
+
+	err := policy.Authorize(ctx, shell, &mcp.Principal{Username: "alice", ChannelID: "public"})
+	var authErr *mcp.AuthorizationError
+	if !errors.As(err, &authErr) {
+		t.Fatalf("deny rule should override roles, got %v", err)
+	}
+	if authErr.Tool != "shell.command" {
+		t.Errorf("AuthorizationError.Tool = %q, want shell.command", authErr.Tool)
+	}
+}
+
+func TestPrincipalFromContext_JWTClaims(t *testing.T) {
+	t.Parallel()
+
+	if p := mcp.PrincipalFromContext(context.Background()); p != nil {
+		t.Errorf("expected anonymous principal, got %v", p)
+	}
+
+	claims := jwt.MapClaims{
+		"sub":   "user-42",
+		"roles": []interface{}{"admin"},
+		"scope": "tools.read tools.exec",
+	}
+	ctx := context.WithValue(context.Background(), types.CtxKey("user"), claims)
+
+	p := mcp.PrincipalFromContext(ctx)
+	if p == nil {
+		t.Fatal("expected principal from JWT claims")
+	}
+	if p.ID != "user-42" || len(p.Roles) != 1 || p.Roles[0] != "admin" || len(p.Scopes) != 2 {
+		t.Errorf("unexpected principal %+v", p)
+	}
+
+	explicit := &mcp.Principal{ID: "discord-user", Source: "discord"}
+	if got := mcp.PrincipalFromContext(mcp.WithPrincipal(ctx, explicit)); got != explicit {
+		t.Errorf("explicit principal should take precedence, got %+v", got)
+	}
+}
+
+func TestRegistry_ExecEnforcesPolicy(t *testing.T) {
+	t.Parallel()
+
+	registry := mcp.NewRegistry()
+	called := false
+	err := registry.Register(mcp.ToolSpec{
+		Name: "test.admin",
+		Auth: mcp.AuthAdmin,
+		Handler: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			called = true
+			return "ok", nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Register() unexpected error = %v", err)
+	}
+
+	_, err = registry.Exec(context.Background(), "test.admin", map[string]interface{}{})
+	var authErr *mcp.AuthorizationError
+	if !errors.As(err, &authErr) {
+		t.Fatalf("Exec() error = %v, want *mcp.AuthorizationError", err)
+	}
+	if called {
+		t.Error("Exec() ran the handler for an unauthorized caller")
+	}
+
+	registry.SetPolicy(mcp.NewPolicy(config.MCPPolicyConfig{
+		Rules: []config.MCPPolicyRule{{Tools: []string{"test.*"}, Effect: "allow", Users: []string{"svc"}}},
+	}))
+	ctx := mcp.WithPrincipal(context.Background(), &mcp.Principal{ID: "svc"})
+	if _, err := registry.Exec(ctx, "test.admin", map[string]interface{}{}); err != nil {
+		t.Errorf("Exec() with allow rule unexpected error = %v", err)
+	}
+	if err := registry.Authorize(ctx, "test.admin"); err != nil {
+		t.Errorf("Authorize() unexpected error = %v", err)
+	}
+}
+
+func TestServer_AdminToolsIgnoreForgedUserID(t *testing.T) {
+	t.Parallel()
+
+	srv, err := mcp.NewServer(nil)
+	if err != nil {
+		t.Fatalf("NewServer() unexpected error = %v", err)
+	}
+	srv.SetPolicy(mcp.NewPolicy(config.MCPPolicyConfig{
+		Bindings: []config.MCPRoleBinding{{Role: "admin", Users: []string{"42"}}},
+	}))
+
+	// an anonymous caller claiming the admin's user_id stays anonymous
+	anonymous := context.Background()
+	result, err := srv.HandleShellCommand(anonymous, map[string]interface{}{"command": "echo forged", "user_id": "42"})
+	if err != nil || !result.IsError || !strings.Contains(toolText(result), "ACESSO NEGADO") {
+		t.Fatalf("HandleShellCommand() with a forged user_id = %+v, %v", result, err)
+	}
+	result, err = srv.HandleSystemInfo(anonymous, map[string]interface{}{"info_type": "cpu", "user_id": "42"})
+	if err != nil || !result.IsError || !strings.Contains(toolText(result), "ACESSO NEGADO") {
+		t.Fatalf("HandleSystemInfo() with a forged user_id = %+v, %v", result, err)
+	}
+
+	// the bound user is recognised from the context
+	admin := mcp.WithPrincipal(context.Background(), &mcp.Principal{ID: "42", Source: "test"})
+	result, err = srv.HandleShellCommand(admin, map[string]interface{}{"command": "echo allowed"})
+	if err != nil || result.IsError || !strings.Contains(toolText(result), "allowed") {
+		t.Fatalf("HandleShellCommand() as the bound admin = %+v, %v", result, err)
+	}
+}
+
+func TestHTTPTransport_RunsToolsAsJWTSubject(t *testing.T) {
+	t.Parallel()
+
+	srv, err := mcp.NewServer(nil)
+	if err != nil {
+		t.Fatalf("NewServer() unexpected error = %v", err)
+	}
+	srv.SetPolicy(mcp.NewPolicy(config.MCPPolicyConfig{
+		Bindings: []config.MCPRoleBinding{{Role: "admin", Users: []string{"42"}}},
+	}))
+	transport := srv.NewHTTPTransport("")
+	// stands in for the JWT middleware in front of the /mcp routes
+	withClaims := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if sub := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "); sub != "" {
+				r = r.WithContext(context.WithValue(r.Context(), types.CtxKey("user"), jwt.MapClaims{"sub": sub}))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	mux := http.NewServeMux()
+	mux.Handle(transport.BasePath(), withClaims(transport.StreamableHandler()))
+	mux.Handle(transport.SSEPath(), withClaims(transport.SSEHandler()))
+	mux.Handle(transport.MessagePath(), withClaims(transport.MessageHandler()))
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for _, tc := range []struct {
+		name    string
+		subject string
+		allowed bool
+	}{
+		{"bound admin", "42", true},
+		{"other user", "7", false},
+		{"no token", "", false},
+	} {
+		headers := map[string]string{}
+		if tc.subject != "" {
+			headers["Authorization"] = "Bearer " + tc.subject
+		}
+		streamable, err := client.NewStreamableHttpClient(ts.URL+transport.BasePath(), mcptransport.WithHTTPHeaders(headers))
+		if err != nil {
+			t.Fatalf("NewStreamableHttpClient() error = %v", err)
+		}
+		sse, err := client.NewSSEMCPClient(ts.URL+transport.SSEPath(), client.WithHeaders(headers))
+		if err != nil {
+			t.Fatalf("NewSSEMCPClient() error = %v", err)
+		}
+		if err := sse.Start(ctx); err != nil {
+			t.Fatalf("SSE Start() error = %v", err)
+		}
+		for kind, c := range map[string]*client.Client{"streamable": streamable, "sse": sse} {
+			initReq := mcpgo.InitializeRequest{}
+			initReq.Params.ProtocolVersion = mcpgo.LATEST_PROTOCOL_VERSION
+			initReq.Params.ClientInfo = mcpgo.Implementation{Name: "gobe-test", Version: "1.0.0"}
+			if _, err := c.Initialize(ctx, initReq); err != nil {
+				t.Fatalf("%s Initialize() error = %v", kind, err)
+			}
+			callReq := mcpgo.CallToolRequest{}
+			callReq.Params.Name = "execute_shell_command"
+			callReq.Params.Arguments = map[string]interface{}{"command": "echo hello"}
+			result, err := c.CallTool(ctx, callReq)
+			if err != nil {
+				t.Fatalf("%s CallTool() error = %v", kind, err)
+			}
+			if denied := result.IsError && strings.Contains(toolText(result), "ACESSO NEGADO"); denied == tc.allowed {
+				t.Errorf("%s, %s: execute_shell_command = %q, allowed %v", tc.name, kind, toolText(result), tc.allowed)
+			}
+			c.Close()
+		}
+	}
+}
+
+func toolText(result *mcpgo.CallToolResult) string {
+	var text strings.Builder
+	for _, content := range result.Content {
+		if c, ok := content.(mcpgo.TextContent); ok {
+			text.WriteString(c.Text)
+		}
+	}
+	return text.String()
+}
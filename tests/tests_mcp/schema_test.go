@@ -0,0 +1,227 @@
+package testsmcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/kubex-ecosystem/gobe/internal/services/mcp"
+)
+
+func echoHandler(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	return args, nil
+}
+
+func TestRegistry_ExecRejectsInvalidArgs(t *testing.T) {
+	t.Parallel()
+
+	registry := mcp.NewRegistry()
+	called := false
+	err := registry.Register(mcp.ToolSpec{
+		Name: "test.schema",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"name":  {"type": "string", "minLength": 1},
+				"count": {"type": "integer", "minimum": 1}
+			},
+			"required": ["name"]
+		}`),
+		Handler: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			called = true
+			return "ok", nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Register() unexpected error = %v", err)
+	}
+
+	_, err = registry.Exec(context.Background(), "test.schema", map[string]interface{}{
+		"count": 0,
+	})
+	if err == nil {
+		t.Fatal("Exec() expected validation error, got nil")
+	}
+	if called {
+		t.Error("Exec() ran the handler despite invalid arguments")
+	}
+
+	var verr *mcp.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Exec() error type = %T, want *mcp.ValidationError", err)
+	}
+	if verr.Target != "input" {
+		t.Errorf("ValidationError.Target = %q, want input", verr.Target)
+	}
+
+	fields := map[string]string{}
+	for _, fe := range verr.Errors {
+		fields[fe.Field] = fe.Type
+	}
+	if fields["name"] != "required" {
+		t.Errorf("expected required error on field name, got %v", verr.Errors)
+	}
+	if _, ok := fields["count"]; !ok {
+		t.Errorf("expected error on field count, got %v", verr.Errors)
+	}
+}
+
+func TestRegistry_ExecAppliesDefaults(t *testing.T) {
+	t.Parallel()
+
+	registry := mcp.NewRegistry()
+	err := registry.Register(mcp.ToolSpec{
+		Name: "test.defaults",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {"verbose": {"type": "boolean", "default": true}}
+		}`),
+		Handler: echoHandler,
+	})
+	if err != nil {
+		t.Fatalf("Register() unexpected error = %v", err)
+	}
+
+	args := map[string]interface{}{}
+	result, err := registry.Exec(context.Background(), "test.defaults", args)
+	if err != nil {
+		t.Fatalf("Exec() unexpected error = %v", err)
+	}
+
+	got := result.(map[string]interface{})
+	if got["verbose"] != true {
+		t.Errorf("default not applied, got %v", got)
+	}
+	if _, mutated := args["verbose"]; mutated {
+		t.Error("Exec() mutated the caller's args map")
+	}
+}
+
+func TestRegistry_LegacyArgsBecomeSchema(t *testing.T) {
+	t.Parallel()
+
+	registry := mcp.NewRegistry()
+	err := registry.Register(mcp.ToolSpec{
+		Name: "test.legacy",
+		Args: map[string]interface{}{
+			"command": map[string]interface{}{
+				"type":     "string",
+				"required": true,
+			},
+		},
+		Handler: echoHandler,
+	})
+	if err != nil {
+		t.Fatalf("Register() unexpected error = %v", err)
+	}
+
+	tool, ok := registry.GetTool("test.legacy")
+	if !ok {
+		t.Fatal("GetTool() expected tool to exist")
+	}
+
+	var schema struct {
+		Type     string   `json:"type"`
+		Required []string `json:"required"`
+	}
+	if err := json.Unmarshal(tool.InputSchema, &schema); err != nil {
+		t.Fatalf("InputSchema is not valid JSON: %v", err)
+	}
+	if schema.Type != "object" || len(schema.Required) != 1 || schema.Required[0] != "command" {
+		t.Errorf("unexpected derived schema: %s", tool.InputSchema)
+	}
+
+	if _, err := registry.Exec(context.Background(), "test.legacy", map[string]interface{}{}); err == nil {
+		t.Error("Exec() expected error for missing required legacy arg")
+	}
+}
+
+func TestRegistry_RegisterRejectsInvalidSchema(t *testing.T) {
+	t.Parallel()
+
+	registry := mcp.NewRegistry()
+	err := registry.Register(mcp.ToolSpec{
+		Name:        "test.bad",
+		InputSchema: json.RawMessage(`{"type": 42}`),
+		Handler:     echoHandler,
+	})
+	if err == nil {
+		t.Fatal("Register() expected error for invalid schema")
+	}
+	if _, ok := registry.GetTool("test.bad"); ok {
+		t.Error("tool with invalid schema should not be registered")
+	}
+}
+
+func TestRegistry_ExecKeepsResultOffOutputSchema(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	registry := mcp.NewRegistry()
+	err := registry.Register(mcp.ToolSpec{
+		Name:         "test.output",
+		OutputSchema: json.RawMessage(`{"type": "object", "required": ["status"]}`),
+		Handler: func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			calls++
+			return map[string]interface{}{"other": 1}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Register() unexpected error = %v", err)
+	}
+
+	// the handler ran, so its result is returned even off the schema
+	result, err := registry.Exec(context.Background(), "test.output", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("Exec() error = %v, want the handler result", err)
+	}
+	if got, ok := result.(map[string]interface{}); !ok || got["other"] != 1 || calls != 1 {
+		t.Fatalf("Exec() = %v after %d calls", result, calls)
+	}
+}
+
+func TestBuiltinTools_ShellCommandSchema(t *testing.T) {
+	t.Parallel()
+
+	registry := mcp.NewRegistry()
+	if err := mcp.RegisterBuiltinTools(registry); err != nil {
+		t.Fatalf("RegisterBuiltinTools() error = %v", err)
+	}
+
+	ctx := mcp.WithPrincipal(context.Background(), &mcp.Principal{ID: "tester", Roles: []string{"admin"}})
+	_, err := registry.Exec(ctx, "shell.command", map[string]interface{}{
+		"command": "echo",
+		"args":    []interface{}{"ok", 3},
+	})
+	var verr *mcp.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Exec() error = %v, want *mcp.ValidationError", err)
+	}
+
+	tool := mcp.ToMCPTool(mustGetTool(t, registry, "shell.command"))
+	data, err := json.Marshal(tool)
+	if err != nil {
+		t.Fatalf("failed to marshal MCP tool: %v", err)
+	}
+	var advertised struct {
+		InputSchema struct {
+			Required []string `json:"required"`
+		} `json:"inputSchema"`
+	}
+	if err := json.Unmarshal(data, &advertised); err != nil {
+		t.Fatalf("failed to decode advertised tool: %v", err)
+	}
+	if len(advertised.InputSchema.Required) != 1 || advertised.InputSchema.Required[0] != "command" {
+		t.Errorf("advertised inputSchema = %s", data)
+	}
+}
+
+func mustGetTool(t *testing.T, registry mcp.Registry, name string) mcp.ToolSpec {
+	t.Helper()
+	tool, ok := registry.GetTool(name)
+	if !ok {
+		t.Fatalf("tool %s not registered", name)
+	}
+	return *tool
+}
@@ -0,0 +1,163 @@
+package testsmcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubex-ecosystem/gobe/internal/services/mcp"
+	"github.com/mark3labs/mcp-go/client"
+	mcpgo "github.com/mark3labs/mcp-go/mcp"
+)
+
+func newTransportServer(t *testing.T) *mcp.Server {
+	t.Helper()
+
+	registry := mcp.NewRegistry()
+	err := registry.Register(mcp.ToolSpec{
+		Name:        "test.echo",
+		Description: "Echo the arguments back",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {"text": {"type": "string"}},
+			"required": ["text"]
+		}`),
+		Handler: echoHandler,
+	})
+	if err != nil {
+		t.Fatalf("Register() unexpected error = %v", err)
+	}
+
+	srv, err := mcp.NewServer(nil)
+	if err != nil {
+		t.Fatalf("NewServer() unexpected error = %v", err)
+	}
+	srv.RegisterRegistryTools(registry)
+	return srv
+}
+
+func TestHTTPTransport_StreamableServesRegistryTools(t *testing.T) {
+	t.Parallel()
+
+	transport := newTransportServer(t).NewHTTPTransport("")
+	if transport.BasePath() != mcp.DefaultHTTPBasePath {
+		t.Errorf("BasePath() = %q, want %q", transport.BasePath(), mcp.DefaultHTTPBasePath)
+	}
+	if transport.SSEPath() != "/mcp/sse" || transport.MessagePath() != "/mcp/message" {
+		t.Errorf("unexpected SSE paths %q, %q", transport.SSEPath(), transport.MessagePath())
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(transport.BasePath(), transport.StreamableHandler())
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	c, err := client.NewStreamableHttpClient(ts.URL + transport.BasePath())
+	if err != nil {
+		t.Fatalf("NewStreamableHttpClient() error = %v", err)
+	}
+	defer c.Close()
+
+	initReq := mcpgo.InitializeRequest{}
+	initReq.Params.ProtocolVersion = mcpgo.LATEST_PROTOCOL_VERSION
+	initReq.Params.ClientInfo = mcpgo.Implementation{Name: "gobe-test", Version: "1.0.0"}
+	if _, err := c.Initialize(ctx, initReq); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	tools, err := c.ListTools(ctx, mcpgo.ListToolsRequest{})
+	if err != nil {
+		t.Fatalf("ListTools() error = %v", err)
+	}
+	found := false
+	for _, tool := range tools.Tools {
+		if tool.Name == "test.echo" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("registry tool test.echo not advertised, got %d tools", len(tools.Tools))
+	}
+
+	callReq := mcpgo.CallToolRequest{}
+	callReq.Params.Name = "test.echo"
+	callReq.Params.Arguments = map[string]interface{}{"text": "hello"}
+	result, err := c.CallTool(ctx, callReq)
+	if err != nil {
+		t.Fatalf("CallTool() error = %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("CallTool() returned tool error: %+v", result.Content)
+	}
+
+	resources, err := c.ListResources(ctx, mcpgo.ListResourcesRequest{})
+	if err != nil {
+		t.Fatalf("ListResources() error = %v", err)
+	}
+	if len(resources.Resources) == 0 || resources.Resources[0].URI != "discord://events" {
+		t.Errorf("expected discord://events resource, got %+v", resources.Resources)
+	}
+}
+
+func TestServer_ServeStdio(t *testing.T) {
+	t.Parallel()
+
+	srv := newTransportServer(t)
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.ServeStdio(ctx, inReader, outWriter)
+	}()
+
+	requests := []string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"gobe-test","version":"1.0.0"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"test.echo","arguments":{}}}`,
+	}
+	go func() {
+		for _, req := range requests {
+			_, _ = io.WriteString(inWriter, req+"\n")
+		}
+	}()
+
+	scanner := bufio.NewScanner(outReader)
+	var callResponse string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.Contains(line, `"id":2`) {
+			callResponse = line
+			break
+		}
+	}
+	if callResponse == "" {
+		t.Fatal("no response to tools/call over stdio")
+	}
+
+	var resp struct {
+		Result mcpgo.CallToolResult `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(callResponse), &resp); err != nil {
+		t.Fatalf("invalid JSON-RPC response %s: %v", callResponse, err)
+	}
+	if !resp.Result.IsError {
+		t.Errorf("expected schema error for missing text, got %s", callResponse)
+	}
+
+	cancel()
+	_ = inWriter.Close()
+	<-done
+}
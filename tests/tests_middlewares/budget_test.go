@@ -0,0 +1,83 @@
+package testsmiddlewares
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kubex-ecosystem/gobe/internal/app/middlewares"
+	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
+	"github.com/kubex-ecosystem/gobe/internal/services/gateway/ledger"
+)
+
+func TestBudgetMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	now := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	store := ledger.NewMemoryStore()
+	book := ledger.New(store, ledger.DefaultPrices)
+	book.SetClock(func() time.Time { return now })
+	book.SetBudgets(ledger.Budgets{
+		Default: ledger.Limit{DailyUSD: 1},
+		Tenants: map[string]ledger.Limit{"acme": {DailyUSD: 5, MonthlyUSD: 6}},
+	})
+
+	spend := func(tenant string, cost float64, at time.Time) {
+		_ = store.RecordUsage(context.Background(), &svc.UsageRecord{TenantID: tenant, CostUSD: cost, CreatedAt: at})
+	}
+	spend("small", 1.5, now.Add(-time.Hour))
+	spend("yesterday", 3, now.AddDate(0, 0, -1))
+	spend("acme", 2, now.Add(-time.Hour))
+	spend("acme", 4.5, now.AddDate(0, 0, -3))
+
+	router := gin.New()
+	router.Use(middlewares.BudgetMiddleware(book))
+	router.POST("/chat", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		tenant string
+		status int
+		period string
+	}{
+		{"small", http.StatusPaymentRequired, "daily"},
+		{"yesterday", http.StatusOK, ""},
+		{"acme", http.StatusPaymentRequired, "monthly"},
+		{"", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/chat", nil)
+		req.Header.Set("X-Tenant-ID", tt.tenant)
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != tt.status {
+			t.Errorf("tenant %q: status = %d, want %d (%s)", tt.tenant, rec.Code, tt.status, rec.Body.String())
+			continue
+		}
+		if tt.period == "" {
+			continue
+		}
+		var body struct {
+			Period   string    `json:"period"`
+			ResetsAt time.Time `json:"resets_at"`
+		}
+		_ = json.Unmarshal(rec.Body.Bytes(), &body)
+		if body.Period != tt.period || !body.ResetsAt.After(now) {
+			t.Errorf("tenant %q: unexpected body %s", tt.tenant, rec.Body.String())
+		}
+	}
+
+	// Without a checker the middleware lets everything through
+	open := gin.New()
+	open.Use(middlewares.BudgetMiddleware(nil))
+	open.POST("/chat", func(c *gin.Context) { c.Status(http.StatusOK) })
+	rec := httptest.NewRecorder()
+	open.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/chat", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("nil checker: status = %d", rec.Code)
+	}
+}
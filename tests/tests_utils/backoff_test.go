@@ -0,0 +1,23 @@
+package utils_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kubex-ecosystem/gobe/internal/utils/backoff"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, delay := range want {
+		if got := backoff.Delay(i+1, time.Second, 5*time.Second); got != delay {
+			t.Fatalf("Delay(%d) = %v, want %v", i+1, got, delay)
+		}
+	}
+	if got := backoff.Delay(3, 0, time.Second); got != 0 {
+		t.Fatalf("Delay without base = %v, want 0", got)
+	}
+	if got := backoff.Delay(200, time.Second, 0); got <= 0 {
+		t.Fatalf("uncapped Delay overflowed to %v", got)
+	}
+}
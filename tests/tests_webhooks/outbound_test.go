@@ -0,0 +1,400 @@
+package testswebhooks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
+	"github.com/kubex-ecosystem/gobe/internal/services/webhooks"
+	"github.com/kubex-ecosystem/gobe/internal/services/webhooks/outbound"
+	"github.com/kubex-ecosystem/gobe/internal/services/webhooks/verify"
+)
+
+// fastOptions retries within milliseconds so that dead letters are reached
+// quickly; the worker is woken up by Publish and Redeliver.
+var fastOptions = outbound.Options{
+	MaxAttempts:  3,
+	Backoff:      5 * time.Millisecond,
+	MaxBackoff:   20 * time.Millisecond,
+	Timeout:      2 * time.Second,
+	PollInterval: 5 * time.Millisecond,
+	BatchSize:    10,
+	Lease:        time.Second,
+	// the receivers are httptest servers on loopback
+	AllowPrivateTargets: true,
+}
+
+func newDispatcher(t *testing.T) *outbound.Dispatcher {
+	t.Helper()
+	dispatcher, err := outbound.NewDispatcher(outbound.NewMemoryStore(), fastOptions)
+	if err != nil {
+		t.Fatalf("NewDispatcher: %v", err)
+	}
+	t.Cleanup(func() { _ = dispatcher.Close() })
+	return dispatcher
+}
+
+type received struct {
+	header http.Header
+	body   []byte
+}
+
+// receiver records the requests it gets and answers with status.
+type receiver struct {
+	mu       sync.Mutex
+	requests []received
+	status   atomic.Int32
+}
+
+func newReceiver(t *testing.T, status int) (*receiver, *httptest.Server) {
+	t.Helper()
+	r := &receiver{}
+	r.status.Store(int32(status))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		r.requests = append(r.requests, received{header: req.Header.Clone(), body: body})
+		r.mu.Unlock()
+		w.WriteHeader(int(r.status.Load()))
+	}))
+	t.Cleanup(server.Close)
+	return r, server
+}
+
+func (r *receiver) all() []received {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]received(nil), r.requests...)
+}
+
+func waitForDelivery(t *testing.T, dispatcher *outbound.Dispatcher, id, status string) *outbound.Delivery {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		delivery, err := dispatcher.GetDelivery(context.Background(), id)
+		if err != nil {
+			t.Fatalf("GetDelivery: %v", err)
+		}
+		if delivery.Status == status {
+			return delivery
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("delivery %s is %s after %d attempts, want %s", id, delivery.Status, delivery.Attempts, status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func onlyDelivery(t *testing.T, dispatcher *outbound.Dispatcher, subscriptionID string) outbound.Delivery {
+	t.Helper()
+	deliveries, total, err := dispatcher.ListDeliveries(context.Background(), outbound.DeliveryQuery{SubscriptionID: subscriptionID})
+	if err != nil {
+		t.Fatalf("ListDeliveries: %v", err)
+	}
+	if total != 1 || len(deliveries) != 1 {
+		t.Fatalf("expected one delivery, got %d", total)
+	}
+	return deliveries[0]
+}
+
+func TestOutbound_SignedDeliveryVerifies(t *testing.T) {
+	dispatcher := newDispatcher(t)
+	rec, server := newReceiver(t, http.StatusOK)
+	ctx := context.Background()
+
+	sub, err := dispatcher.Subscribe(ctx, outbound.Subscription{URL: server.URL, EventTypes: []string{"cron.*"}})
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if sub.Secret == "" {
+		t.Fatal("expected a generated secret")
+	}
+
+	queued, err := dispatcher.Publish(ctx, outbound.EventCronJobExecuted, map[string]string{"status": "succeeded"})
+	if err != nil || queued != 1 {
+		t.Fatalf("Publish = %d, %v", queued, err)
+	}
+	delivery := waitForDelivery(t, dispatcher, onlyDelivery(t, dispatcher, sub.ID).ID, svc.WebhookDeliverySucceeded)
+	if delivery.ResponseStatus != http.StatusOK || delivery.Attempts != 1 {
+		t.Fatalf("unexpected delivery: %+v", delivery)
+	}
+
+	requests := rec.all()
+	if len(requests) != 1 {
+		t.Fatalf("expected one request, got %d", len(requests))
+	}
+	header := requests[0].header
+	if header.Get(outbound.HeaderEvent) != outbound.EventCronJobExecuted || header.Get(outbound.HeaderDelivery) != delivery.ID {
+		t.Fatalf("unexpected headers: %v", header)
+	}
+
+	// The receiver side of gobe checks the signature with the hmac scheme
+	verifier := verify.NewHMAC(sub.Secret)
+	verifier.SignatureHeader = outbound.HeaderSignature
+	verifier.TimestampHeader = outbound.HeaderTimestamp
+	verifier.Prefix = "sha256="
+	signed, err := verifier.Verify(header, requests[0].body, time.Now())
+	if err != nil || signed != header.Get(outbound.HeaderTimestamp)+":"+strings.TrimPrefix(header.Get(outbound.HeaderSignature), "sha256=") {
+		t.Fatalf("Verify = %q, %v", signed, err)
+	}
+	if want := outbound.Sign(sub.Secret, header.Get(outbound.HeaderTimestamp), requests[0].body); header.Get(outbound.HeaderSignature) != want {
+		t.Fatalf("signature mismatch")
+	}
+}
+
+func TestOutbound_SendSignsWithSecret(t *testing.T) {
+	ctx := context.Background()
+	rec, server := newReceiver(t, http.StatusOK)
+
+	if _, err := newDispatcher(t).Send(ctx, server.URL, outbound.EventAnalyzerNotification, nil); !errors.Is(err, outbound.ErrNoSecret) {
+		t.Fatalf("Send without secret: %v", err)
+	}
+
+	opts := fastOptions
+	opts.Secret = "one-off"
+	dispatcher, err := outbound.NewDispatcher(outbound.NewMemoryStore(), opts)
+	if err != nil {
+		t.Fatalf("NewDispatcher: %v", err)
+	}
+	t.Cleanup(func() { _ = dispatcher.Close() })
+	delivery, err := dispatcher.Send(ctx, server.URL, outbound.EventAnalyzerNotification, map[string]string{"subject": "hi"})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	waitForDelivery(t, dispatcher, delivery.ID, svc.WebhookDeliverySucceeded)
+
+	requests := rec.all()
+	if len(requests) != 1 {
+		t.Fatalf("expected one request, got %d", len(requests))
+	}
+	header := requests[0].header
+	if want := outbound.Sign("one-off", header.Get(outbound.HeaderTimestamp), requests[0].body); header.Get(outbound.HeaderSignature) != want {
+		t.Fatalf("signature = %q, want %q", header.Get(outbound.HeaderSignature), want)
+	}
+}
+
+func TestOutbound_PublishFiltersByEventType(t *testing.T) {
+	dispatcher := newDispatcher(t)
+	_, server := newReceiver(t, http.StatusOK)
+	ctx := context.Background()
+
+	for _, types := range [][]string{{"*"}, {"cron.*"}, {outbound.EventApprovalDecided}} {
+		if _, err := dispatcher.Subscribe(ctx, outbound.Subscription{URL: server.URL, EventTypes: types}); err != nil {
+			t.Fatalf("Subscribe %v: %v", types, err)
+		}
+	}
+	inactive := false
+	sub, err := dispatcher.Subscribe(ctx, outbound.Subscription{URL: server.URL, EventTypes: []string{"*"}})
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if _, err := dispatcher.UpdateSubscription(ctx, sub.ID, outbound.SubscriptionUpdate{Active: &inactive}); err != nil {
+		t.Fatalf("UpdateSubscription: %v", err)
+	}
+
+	cases := map[string]int{
+		outbound.EventCronJobExecuted: 2,
+		outbound.EventApprovalDecided: 2,
+		outbound.EventWebhookReceived: 1,
+	}
+	for eventType, want := range cases {
+		queued, err := dispatcher.Publish(ctx, eventType, nil)
+		if err != nil {
+			t.Fatalf("Publish %s: %v", eventType, err)
+		}
+		if queued != want {
+			t.Fatalf("Publish %s queued %d deliveries, want %d", eventType, queued, want)
+		}
+	}
+}
+
+func TestOutbound_ReceivedWebhooksReachSubscribers(t *testing.T) {
+	dispatcher := newDispatcher(t)
+	_, server := newReceiver(t, http.StatusOK)
+	sub, err := dispatcher.Subscribe(context.Background(), outbound.Subscription{URL: server.URL, EventTypes: []string{outbound.EventWebhookReceived}})
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	// without a dispatcher the service only stores the events
+	service := newService(t, webhooks.NewMemoryStore())
+	if _, err := service.ReceiveWebhook("github", "github.push", nil, nil); err != nil {
+		t.Fatalf("ReceiveWebhook: %v", err)
+	}
+	service.SetDispatcher(dispatcher)
+	if _, err := service.ReceiveWebhook("github", "github.push", nil, nil); err != nil {
+		t.Fatalf("ReceiveWebhook: %v", err)
+	}
+	waitForDelivery(t, dispatcher, onlyDelivery(t, dispatcher, sub.ID).ID, svc.WebhookDeliverySucceeded)
+}
+
+func TestOutbound_RetriesThenDeadLetter(t *testing.T) {
+	dispatcher := newDispatcher(t)
+	rec, server := newReceiver(t, http.StatusServiceUnavailable)
+	ctx := context.Background()
+
+	sub, err := dispatcher.Subscribe(ctx, outbound.Subscription{URL: server.URL, EventTypes: []string{"*"}})
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if _, err := dispatcher.Publish(ctx, outbound.EventApprovalDecided, nil); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	delivery := waitForDelivery(t, dispatcher, onlyDelivery(t, dispatcher, sub.ID).ID, svc.WebhookDeliveryDead)
+	if delivery.Attempts != fastOptions.MaxAttempts || len(rec.all()) != fastOptions.MaxAttempts {
+		t.Fatalf("expected %d attempts, got %d (%d requests)", fastOptions.MaxAttempts, delivery.Attempts, len(rec.all()))
+	}
+	if delivery.ResponseStatus != http.StatusServiceUnavailable || delivery.LastError == "" {
+		t.Fatalf("unexpected dead delivery: %+v", delivery)
+	}
+
+	// Once the receiver recovers, a redelivery goes through as a new delivery
+	rec.status.Store(http.StatusNoContent)
+	redelivery, err := dispatcher.Redeliver(ctx, delivery.ID)
+	if err != nil {
+		t.Fatalf("Redeliver: %v", err)
+	}
+	if redelivery.ID == delivery.ID || redelivery.EventID != delivery.EventID {
+		t.Fatalf("unexpected redelivery: %+v", redelivery)
+	}
+	waitForDelivery(t, dispatcher, redelivery.ID, svc.WebhookDeliverySucceeded)
+
+	if dead, _ := dispatcher.GetDelivery(ctx, delivery.ID); dead.Status != svc.WebhookDeliveryDead {
+		t.Fatalf("original delivery changed to %s", dead.Status)
+	}
+}
+
+func TestOutbound_SlowSubscriberDoesNotBlockOthers(t *testing.T) {
+	dispatcher := newDispatcher(t)
+	ctx := context.Background()
+
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-release
+	}))
+	t.Cleanup(slow.Close)
+	t.Cleanup(func() { close(release) })
+	_, fast := newReceiver(t, http.StatusOK)
+
+	if _, err := dispatcher.Subscribe(ctx, outbound.Subscription{URL: slow.URL, EventTypes: []string{"*"}}); err != nil {
+		t.Fatalf("Subscribe slow: %v", err)
+	}
+	sub, err := dispatcher.Subscribe(ctx, outbound.Subscription{URL: fast.URL, EventTypes: []string{"*"}})
+	if err != nil {
+		t.Fatalf("Subscribe fast: %v", err)
+	}
+	start := time.Now()
+	if _, err := dispatcher.Publish(ctx, outbound.EventApprovalDecided, nil); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	waitForDelivery(t, dispatcher, onlyDelivery(t, dispatcher, sub.ID).ID, svc.WebhookDeliverySucceeded)
+	if elapsed := time.Since(start); elapsed >= fastOptions.Timeout {
+		t.Fatalf("fast subscriber waited %v for the slow one", elapsed)
+	}
+}
+
+func TestOutbound_BackoffDoublesUpToMax(t *testing.T) {
+	opts := outbound.Options{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, delay := range want {
+		if got := opts.Delay(i + 1); got != delay {
+			t.Fatalf("Delay(%d) = %v, want %v", i+1, got, delay)
+		}
+	}
+}
+
+func TestOutbound_RejectsInvalidSubscriptions(t *testing.T) {
+	dispatcher := newDispatcher(t)
+	ctx := context.Background()
+
+	invalid := []outbound.Subscription{
+		{URL: "ftp://example.com/hook", EventTypes: []string{"*"}},
+		{URL: "not a url", EventTypes: []string{"*"}},
+		{URL: "https://example.com/hook"},
+	}
+	for _, sub := range invalid {
+		if _, err := dispatcher.Subscribe(ctx, sub); err == nil {
+			t.Fatalf("expected %+v to be rejected", sub)
+		}
+	}
+}
+
+func TestOutbound_RefusesInternalTargets(t *testing.T) {
+	opts := fastOptions
+	opts.AllowPrivateTargets = false
+	dispatcher, err := outbound.NewDispatcher(outbound.NewMemoryStore(), opts)
+	if err != nil {
+		t.Fatalf("NewDispatcher: %v", err)
+	}
+	t.Cleanup(func() { _ = dispatcher.Close() })
+	ctx := context.Background()
+
+	for _, target := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://10.0.0.1/hook",
+		"http://[::1]/hook",
+		"http://[::ffff:192.168.0.1]/hook",
+	} {
+		if _, err := dispatcher.Subscribe(ctx, outbound.Subscription{URL: target, EventTypes: []string{"*"}}); !errors.Is(err, outbound.ErrForbiddenTarget) {
+			t.Fatalf("Subscribe %s: %v", target, err)
+		}
+	}
+
+	// names are checked once resolved, when the delivery is sent
+	rec, server := newReceiver(t, http.StatusOK)
+	_, port, _ := strings.Cut(strings.TrimPrefix(server.URL, "http://"), ":")
+	sub, err := dispatcher.Subscribe(ctx, outbound.Subscription{URL: "http://localhost:" + port, EventTypes: []string{"*"}})
+	if err != nil {
+		t.Fatalf("Subscribe by name: %v", err)
+	}
+	if _, err := dispatcher.Publish(ctx, outbound.EventApprovalDecided, nil); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	delivery := waitForDelivery(t, dispatcher, onlyDelivery(t, dispatcher, sub.ID).ID, svc.WebhookDeliveryDead)
+	if !strings.Contains(delivery.LastError, outbound.ErrForbiddenTarget.Error()) || len(rec.all()) != 0 {
+		t.Fatalf("dead delivery %+v after %d requests", delivery, len(rec.all()))
+	}
+}
+
+func TestOutbound_SecretsEncryptedAtRest(t *testing.T) {
+	store := outbound.NewMemoryStore()
+	opts := fastOptions
+	opts.Key = make([]byte, outbound.KeySize)
+	dispatcher, err := outbound.NewDispatcher(store, opts)
+	if err != nil {
+		t.Fatalf("NewDispatcher: %v", err)
+	}
+	t.Cleanup(func() { _ = dispatcher.Close() })
+	ctx := context.Background()
+
+	sub, err := dispatcher.Subscribe(ctx, outbound.Subscription{URL: "https://example.com/hook", EventTypes: []string{"*"}, Secret: "plain-secret"})
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if sub.Secret != "plain-secret" {
+		t.Fatalf("returned secret = %q", sub.Secret)
+	}
+	record, err := store.GetSubscription(ctx, sub.ID)
+	if err != nil {
+		t.Fatalf("GetSubscription: %v", err)
+	}
+	if record.Secret == "" || strings.Contains(record.Secret, "plain-secret") {
+		t.Fatalf("stored secret = %q", record.Secret)
+	}
+
+	opts.Key = []byte("short")
+	if _, err := outbound.NewDispatcher(store, opts); err == nil {
+		t.Fatal("expected an error for a short key")
+	}
+}
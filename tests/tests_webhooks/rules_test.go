@@ -0,0 +1,241 @@
+package testswebhooks
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
+	"github.com/kubex-ecosystem/gobe/internal/services/webhooks"
+)
+
+// toolCall records a run of the fake MCP tool action.
+type toolCall struct {
+	tool string
+	args map[string]interface{}
+}
+
+func newRuleService(t *testing.T) (*webhooks.WebhookService, *webhooks.MemoryStore, *[]toolCall) {
+	t.Helper()
+	store := webhooks.NewMemoryStore()
+	service := newService(t, store)
+	calls := &[]toolCall{}
+	service.RegisterAction(webhooks.ActionMCPTool, webhooks.ToolAction(func(ctx context.Context, tool string, args map[string]interface{}) (interface{}, error) {
+		if tool == "broken" {
+			return nil, errors.New("tool failed")
+		}
+		*calls = append(*calls, toolCall{tool: tool, args: args})
+		return "ok", nil
+	}))
+	return service, store, calls
+}
+
+func mustCreateRule(t *testing.T, service *webhooks.WebhookService, rule webhooks.Rule) *webhooks.Rule {
+	t.Helper()
+	rule.Enabled = true
+	created, err := service.CreateRule(context.Background(), rule)
+	if err != nil {
+		t.Fatalf("CreateRule %q: %v", rule.Name, err)
+	}
+	return created
+}
+
+func processOne(t *testing.T, service *webhooks.WebhookService, store *webhooks.MemoryStore, source, eventType string, payload map[string]interface{}) *svc.WebhookEventRecord {
+	t.Helper()
+	event, err := service.ReceiveWebhook(source, eventType, payload, nil)
+	if err != nil {
+		t.Fatalf("ReceiveWebhook: %v", err)
+	}
+	if _, err := service.ProcessPending(context.Background()); err != nil {
+		t.Fatalf("ProcessPending: %v", err)
+	}
+	record, err := store.GetEvent(context.Background(), event.ID.String())
+	if err != nil {
+		t.Fatalf("GetEvent: %v", err)
+	}
+	return record
+}
+
+func TestRules_GitHubPushRunsToolWithMappedArgs(t *testing.T) {
+	service, store, calls := newRuleService(t)
+	mustCreateRule(t, service, webhooks.Rule{
+		Name:      "scan on push",
+		Source:    "github",
+		EventType: "github.*",
+		Action:    webhooks.ActionMCPTool,
+		Target:    "analyzer.scan",
+		Params:    map[string]interface{}{"depth": "full"},
+		Mapping: map[string]string{
+			"repository": "$.payload.repository.full_name",
+			"commits":    "$.payload.commits[*].id",
+			"head":       "$.payload['commits'][-1].id",
+			"missing":    "$.payload.nope",
+		},
+	})
+
+	record := processOne(t, service, store, "github", "github.push", map[string]interface{}{
+		"repository": map[string]interface{}{"full_name": "kubex/gobe"},
+		"commits":    []interface{}{map[string]interface{}{"id": "a1"}, map[string]interface{}{"id": "b2"}},
+	})
+	if record.Status != svc.WebhookEventCompleted {
+		t.Fatalf("status = %s (%s)", record.Status, record.Error)
+	}
+
+	if len(*calls) != 1 {
+		t.Fatalf("expected one tool call, got %d", len(*calls))
+	}
+	want := map[string]interface{}{
+		"depth":      "full",
+		"repository": "kubex/gobe",
+		"commits":    []interface{}{"a1", "b2"},
+		"head":       "b2",
+	}
+	if call := (*calls)[0]; call.tool != "analyzer.scan" || !reflect.DeepEqual(call.args, want) {
+		t.Fatalf("call = %s %v, want analyzer.scan %v", call.tool, call.args, want)
+	}
+
+	// Other sources do not match the rule
+	processOne(t, service, store, "gitlab", "github.push", map[string]interface{}{})
+	if len(*calls) != 1 {
+		t.Fatalf("rule matched another source")
+	}
+}
+
+func TestRules_PriorityTransformAndStop(t *testing.T) {
+	service, store, calls := newRuleService(t)
+	ctx := context.Background()
+
+	mustCreateRule(t, service, webhooks.Rule{Name: "late", Priority: 20, Action: webhooks.ActionMCPTool, Target: "never"})
+	mustCreateRule(t, service, webhooks.Rule{
+		Name: "notify", Priority: 10, StopOnMatch: true,
+		Action: webhooks.ActionMCPTool, Target: "notify",
+		Mapping: map[string]string{"who": "$.payload.user"},
+	})
+	mustCreateRule(t, service, webhooks.Rule{
+		Name: "shape", Priority: 1, EventType: "user.created",
+		Action:  webhooks.ActionTransform,
+		Mapping: map[string]string{"user": "$.payload.data.login", "source": "$.source"},
+	})
+	disabled := mustCreateRule(t, service, webhooks.Rule{Name: "off", Priority: 0, Action: webhooks.ActionMCPTool, Target: "disabled"})
+	disabled.Enabled = false
+	if _, err := service.UpdateRule(ctx, disabled.ID, *disabled); err != nil {
+		t.Fatalf("UpdateRule: %v", err)
+	}
+
+	rules, err := service.ListRules(ctx)
+	if err != nil || len(rules) != 4 || rules[0].Name != "off" || rules[1].Name != "shape" {
+		t.Fatalf("ListRules = %+v, %v", rules, err)
+	}
+
+	record := processOne(t, service, store, "app", "user.created", map[string]interface{}{
+		"data": map[string]interface{}{"login": "ana"},
+	})
+	if record.Status != svc.WebhookEventCompleted {
+		t.Fatalf("status = %s (%s)", record.Status, record.Error)
+	}
+	if len(*calls) != 1 || (*calls)[0].tool != "notify" || (*calls)[0].args["who"] != "ana" {
+		t.Fatalf("calls = %+v", *calls)
+	}
+	// The stored payload is the one received, not the transformed one
+	if !strings.Contains(record.Payload, `"login":"ana"`) {
+		t.Fatalf("payload rewritten: %s", record.Payload)
+	}
+}
+
+func TestRules_FailingActionFailsEvent(t *testing.T) {
+	service, store, _ := newRuleService(t)
+	mustCreateRule(t, service, webhooks.Rule{Name: "broken", Action: webhooks.ActionMCPTool, Target: "broken"})
+
+	record := processOne(t, service, store, "app", "anything", map[string]interface{}{})
+	if record.Status != svc.WebhookEventFailed || !strings.Contains(record.Error, "tool failed") {
+		t.Fatalf("record = %s %q", record.Status, record.Error)
+	}
+}
+
+func TestRules_ChatSender(t *testing.T) {
+	service, store, _ := newRuleService(t)
+	var sent []string
+	webhooks.RegisterChatSender("test-chat", func(ctx context.Context, channel, text string) error {
+		sent = append(sent, channel+"|"+text)
+		return nil
+	})
+	mustCreateRule(t, service, webhooks.Rule{
+		Name: "announce", EventType: "deploy.finished",
+		Action:  webhooks.ActionChat,
+		Target:  "test-chat:ops",
+		Mapping: map[string]string{"text": "$.payload.summary"},
+	})
+
+	processOne(t, service, store, "ci", "deploy.finished", map[string]interface{}{"summary": "v1.2 is live"})
+	if len(sent) != 1 || sent[0] != "ops|v1.2 is live" {
+		t.Fatalf("sent = %v", sent)
+	}
+}
+
+func TestRules_PublishOnTransport(t *testing.T) {
+	service, store, _ := newRuleService(t)
+	published := map[string]string{}
+	webhooks.RegisterPublisher("test-bus", func(ctx context.Context, topic string, body []byte) error {
+		published[topic] = string(body)
+		return nil
+	})
+	t.Cleanup(func() { webhooks.RegisterPublisher("test-bus", nil) })
+	mustCreateRule(t, service, webhooks.Rule{
+		Name: "forward", Source: "github",
+		Action:  webhooks.ActionPublish,
+		Target:  "test-bus:alerts.github",
+		Mapping: map[string]string{"repo": "$.payload.repo"},
+	})
+
+	record := processOne(t, service, store, "github", "push", map[string]interface{}{"repo": "gobe"})
+	if record.Status != svc.WebhookEventCompleted {
+		t.Fatalf("status = %s %q", record.Status, record.Error)
+	}
+	if published["alerts.github"] != `{"repo":"gobe"}` {
+		t.Fatalf("rule published %v", published)
+	}
+	if received := published[webhooks.ReceivedTopic+"github"]; !strings.Contains(received, `"event_type":"push"`) {
+		t.Fatalf("received webhook published as %q", received)
+	}
+}
+
+func TestRules_NoMatchCompletes(t *testing.T) {
+	service, store, calls := newRuleService(t)
+	mustCreateRule(t, service, webhooks.Rule{Name: "stripe", Source: "stripe", Action: webhooks.ActionMCPTool, Target: "billing"})
+
+	record := processOne(t, service, store, "github", "github.push", map[string]interface{}{})
+	if record.Status != svc.WebhookEventCompleted || len(*calls) != 0 {
+		t.Fatalf("status = %s, calls = %d", record.Status, len(*calls))
+	}
+}
+
+func TestRules_Validation(t *testing.T) {
+	service, _, _ := newRuleService(t)
+	ctx := context.Background()
+
+	invalid := []webhooks.Rule{
+		{Action: "launch_rockets"},
+		{Action: webhooks.ActionCronRun, Target: "nightly"},
+		{Action: webhooks.ActionMCPTool},
+		{Action: webhooks.ActionChat, Target: "discord"},
+		{Action: webhooks.ActionPublish, Target: "zmq"},
+		{Action: webhooks.ActionTransform},
+		{Action: webhooks.ActionMCPTool, Target: "x", Mapping: map[string]string{"a": "payload.a"}},
+		{Action: webhooks.ActionMCPTool, Target: "x", Mapping: map[string]string{"a": "$.items[one]"}},
+		{Action: webhooks.ActionMCPTool, Target: "x", Source: "[github"},
+	}
+	for _, rule := range invalid {
+		if _, err := service.CreateRule(ctx, rule); !errors.Is(err, webhooks.ErrInvalidRule) {
+			t.Fatalf("CreateRule(%+v) = %v, want ErrInvalidRule", rule, err)
+		}
+	}
+
+	if _, err := service.UpdateRule(ctx, "missing", webhooks.Rule{Action: webhooks.ActionMCPTool, Target: "x"}); !errors.Is(err, svc.ErrWebhookRuleNotFound) {
+		t.Fatalf("UpdateRule = %v", err)
+	}
+	if err := service.DeleteRule(ctx, "missing"); !errors.Is(err, svc.ErrWebhookRuleNotFound) {
+		t.Fatalf("DeleteRule = %v", err)
+	}
+}
@@ -0,0 +1,186 @@
+package testswebhooks
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
+	"github.com/kubex-ecosystem/gobe/internal/services/webhooks"
+)
+
+// quietOptions keeps the background worker out of the way of the tests,
+// which drive ProcessPending and Purge themselves.
+var quietOptions = webhooks.Options{
+	Retention:     24 * time.Hour,
+	PurgeInterval: time.Hour,
+	PollInterval:  time.Hour,
+	BatchSize:     10,
+	Lease:         time.Minute,
+}
+
+func newService(t *testing.T, store webhooks.Store) *webhooks.WebhookService {
+	t.Helper()
+	service := webhooks.NewWebhookService(nil, store, quietOptions)
+	t.Cleanup(func() { _ = service.Close() })
+	return service
+}
+
+func TestReceiveWebhook_Persists(t *testing.T) {
+	store := webhooks.NewMemoryStore()
+	service := newService(t, store)
+
+	event, err := service.ReceiveWebhook("github", "github.push", map[string]interface{}{"ref": "main"}, map[string]string{
+		"X-GitHub-Event":      "push",
+		"Authorization":       "Bearer leaked",
+		"Cookie":              "session=leaked",
+		"X-Hub-Signature-256": "sha256=leaked",
+		"X-Gitlab-Token":      "leaked",
+	})
+	if err != nil {
+		t.Fatalf("ReceiveWebhook: %v", err)
+	}
+
+	record, err := store.GetEvent(context.Background(), event.ID.String())
+	if err != nil {
+		t.Fatalf("GetEvent: %v", err)
+	}
+	if record.Source != "github" || record.Status != svc.WebhookEventReceived {
+		t.Fatalf("stored record = %+v", record)
+	}
+
+	got, err := service.GetWebhookEvent(event.ID)
+	if err != nil {
+		t.Fatalf("GetWebhookEvent: %v", err)
+	}
+	if got.Payload["ref"] != "main" || got.Headers["X-GitHub-Event"] != "push" {
+		t.Fatalf("event = %+v", got)
+	}
+	for _, name := range []string{"Authorization", "Cookie", "X-Hub-Signature-256", "X-Gitlab-Token"} {
+		if _, ok := got.Headers[name]; ok {
+			t.Fatalf("header %s was kept: %+v", name, got.Headers)
+		}
+	}
+}
+
+func TestListEvents_CursorPagination(t *testing.T) {
+	service := newService(t, webhooks.NewMemoryStore())
+	for i := 0; i < 5; i++ {
+		if _, err := service.ReceiveWebhook("github", "github.push", map[string]interface{}{"n": i}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := service.ReceiveWebhook("stripe", "stripe.payment", map[string]interface{}{}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	seen := map[string]bool{}
+	query := webhooks.EventQuery{Source: "github", Limit: 2}
+	pages := 0
+	for {
+		page, err := service.ListEvents(ctx, query)
+		if err != nil {
+			t.Fatalf("ListEvents: %v", err)
+		}
+		if page.Total != 5 {
+			t.Fatalf("Total = %d, want 5", page.Total)
+		}
+		for _, event := range page.Events {
+			if event.Source != "github" {
+				t.Fatalf("filter leaked %q", event.Source)
+			}
+			if seen[event.ID.String()] {
+				t.Fatalf("event %s listed twice", event.ID)
+			}
+			seen[event.ID.String()] = true
+		}
+		pages++
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+	if len(seen) != 5 || pages != 3 {
+		t.Fatalf("listed %d events in %d pages, want 5 in 3", len(seen), pages)
+	}
+
+	if _, err := service.ListEvents(ctx, webhooks.EventQuery{Cursor: "bogus"}); !errors.Is(err, webhooks.ErrInvalidCursor) {
+		t.Fatalf("bogus cursor error = %v", err)
+	}
+}
+
+func TestProcessPending_ReclaimsExpiredLease(t *testing.T) {
+	store := webhooks.NewMemoryStore()
+	service := newService(t, store)
+	ctx := context.Background()
+
+	fresh, err := service.ReceiveWebhook("github", "github.push", map[string]interface{}{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// left behind by a worker that crashed mid-processing
+	expired := time.Now().Add(-time.Minute)
+	stuck := &svc.WebhookEventRecord{Source: "app", EventType: "user.created", Payload: "{}", Headers: "{}", Status: svc.WebhookEventProcessing, LockedUntil: &expired}
+	if err := store.SaveEvent(ctx, stuck); err != nil {
+		t.Fatal(err)
+	}
+	// still owned by a live worker
+	leased := time.Now().Add(time.Hour)
+	busy := &svc.WebhookEventRecord{Source: "app", EventType: "user.created", Payload: "{}", Headers: "{}", Status: svc.WebhookEventProcessing, LockedUntil: &leased}
+	if err := store.SaveEvent(ctx, busy); err != nil {
+		t.Fatal(err)
+	}
+
+	processed, err := service.ProcessPending(ctx)
+	if err != nil {
+		t.Fatalf("ProcessPending: %v", err)
+	}
+	if processed != 2 {
+		t.Fatalf("processed = %d, want 2", processed)
+	}
+	for _, id := range []string{fresh.ID.String(), stuck.ID} {
+		record, _ := store.GetEvent(ctx, id)
+		if record.Status != svc.WebhookEventCompleted || record.Attempts != 1 || record.ProcessedAt == nil {
+			t.Fatalf("record %s = %+v", id, record)
+		}
+	}
+	if record, _ := store.GetEvent(ctx, busy.ID); record.Status != svc.WebhookEventProcessing {
+		t.Fatalf("leased record status = %q", record.Status)
+	}
+}
+
+func TestPurge_RespectsRetention(t *testing.T) {
+	store := webhooks.NewMemoryStore()
+	service := newService(t, store)
+	ctx := context.Background()
+
+	old := time.Now().Add(-48 * time.Hour)
+	records := []*svc.WebhookEventRecord{
+		{Source: "a", EventType: "x", Status: svc.WebhookEventCompleted, ReceivedAt: old},
+		{Source: "a", EventType: "x", Status: svc.WebhookEventFailed, ReceivedAt: old},
+		{Source: "a", EventType: "x", Status: svc.WebhookEventReceived, ReceivedAt: old},
+		{Source: "a", EventType: "x", Status: svc.WebhookEventCompleted},
+	}
+	for _, record := range records {
+		if err := store.SaveEvent(ctx, record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	purged, err := service.Purge(ctx)
+	if err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if purged != 2 {
+		t.Fatalf("purged = %d, want 2", purged)
+	}
+	for i, record := range records {
+		_, err := store.GetEvent(ctx, record.ID)
+		gone := errors.Is(err, svc.ErrWebhookEventNotFound)
+		if gone != (i < 2) {
+			t.Errorf("record %d (%s) gone = %v", i, record.Status, gone)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package testswebhooks
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	gatewayController "github.com/kubex-ecosystem/gobe/internal/app/controllers/gateway"
+	svc "github.com/kubex-ecosystem/gobe/internal/bridges/gdbasez"
+	"github.com/kubex-ecosystem/gobe/internal/config"
+	"github.com/kubex-ecosystem/gobe/internal/services/webhooks"
+	"github.com/kubex-ecosystem/gobe/internal/services/webhooks/verify"
+)
+
+func hmacHex(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifiers(t *testing.T) {
+	now := time.Now()
+	ts := strconv.FormatInt(now.Unix(), 10)
+	stale := strconv.FormatInt(now.Add(-time.Hour).Unix(), 10)
+	body := []byte(`{"id":"evt_1"}`)
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	discord, err := verify.NewDiscord(hex.EncodeToString(pub))
+	if err != nil {
+		t.Fatal(err)
+	}
+	discordSig := func(ts string) string {
+		return hex.EncodeToString(ed25519.Sign(priv, append([]byte(ts), body...)))
+	}
+
+	tests := []struct {
+		name     string
+		verifier verify.Verifier
+		header   map[string]string
+		wantID   string
+		wantErr  error
+	}{
+		{"github", verify.NewGitHub("s3cret"), map[string]string{
+			"X-Hub-Signature-256": "sha256=" + hmacHex("s3cret", string(body)),
+			"X-GitHub-Delivery":   "d-1",
+		}, hmacHex("s3cret", string(body)), nil},
+		{"github wrong secret", verify.NewGitHub("s3cret"), map[string]string{
+			"X-Hub-Signature-256": "sha256=" + hmacHex("other", string(body)),
+		}, "", verify.ErrInvalidSignature},
+		{"github unsigned", verify.NewGitHub("s3cret"), nil, "", verify.ErrMissingSignature},
+		{"stripe", verify.NewStripe("whsec"), map[string]string{
+			"Stripe-Signature": "t=" + ts + ",v1=deadbeef,v1=" + hmacHex("whsec", ts+"."+string(body)),
+		}, ts + ":" + hmacHex("whsec", ts+"."+string(body)), nil},
+		{"stripe stale", verify.NewStripe("whsec"), map[string]string{
+			"Stripe-Signature": "t=" + stale + ",v1=" + hmacHex("whsec", stale+"."+string(body)),
+		}, "", verify.ErrStaleTimestamp},
+		{"slack", verify.NewSlack("signing"), map[string]string{
+			"X-Slack-Request-Timestamp": ts,
+			"X-Slack-Signature":         "v0=" + hmacHex("signing", "v0:"+ts+":"+string(body)),
+		}, ts + ":" + hmacHex("signing", "v0:"+ts+":"+string(body)), nil},
+		{"slack tampered", verify.NewSlack("signing"), map[string]string{
+			"X-Slack-Request-Timestamp": ts,
+			"X-Slack-Signature":         "v0=" + hmacHex("signing", "v0:"+ts+":{}"),
+		}, "", verify.ErrInvalidSignature},
+		{"github uppercase digest", verify.NewGitHub("s3cret"), map[string]string{
+			"X-Hub-Signature-256": "sha256=" + strings.ToUpper(hmacHex("s3cret", string(body))),
+		}, hmacHex("s3cret", string(body)), nil},
+		{"discord", discord, map[string]string{
+			"X-Signature-Timestamp": ts,
+			"X-Signature-Ed25519":   discordSig(ts),
+		}, ts + ":" + discordSig(ts), nil},
+		{"discord wrong timestamp", discord, map[string]string{
+			"X-Signature-Timestamp": strconv.FormatInt(now.Unix()+1, 10),
+			"X-Signature-Ed25519":   discordSig(ts),
+		}, "", verify.ErrInvalidSignature},
+		{"hmac", verify.NewHMAC("k"), map[string]string{
+			"X-Webhook-Signature": hmacHex("k", string(body)),
+			"X-Webhook-Id":        "42",
+		}, hmacHex("k", string(body)), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for k, v := range tt.header {
+				header.Set(k, v)
+			}
+			id, err := tt.verifier.Verify(header, body, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && id != tt.wantID {
+				t.Fatalf("id = %q, want %q", id, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestRegistry_RefusesReplays(t *testing.T) {
+	registry := verify.NewRegistry(time.Minute)
+	registry.Register("github", verify.NewGitHub("s3cret"))
+	body := []byte(`{}`)
+	header := http.Header{}
+	header.Set("X-Hub-Signature-256", "sha256="+hmacHex("s3cret", string(body)))
+	header.Set("X-GitHub-Delivery", "d-1")
+
+	id, err := registry.Verify("github", header, body)
+	if err != nil || id == "" {
+		t.Fatalf("first delivery: id %q, err %v", id, err)
+	}
+	// the delivery header is not signed: changing it does not make a new delivery
+	header.Set("X-GitHub-Delivery", "d-2")
+	if _, err := registry.Verify("github", header, body); !errors.Is(err, verify.ErrReplayed) {
+		t.Fatalf("replay err = %v", err)
+	}
+	registry.Release("github", id)
+	if _, err := registry.Verify("github", header, body); err != nil {
+		t.Fatalf("released delivery: %v", err)
+	}
+
+	if _, err := registry.Verify("unknown", nil, body); !errors.Is(err, verify.ErrUnsignedSource) {
+		t.Fatalf("unregistered source err = %v", err)
+	}
+	registry.Require(false)
+	if _, err := registry.Verify("unknown", nil, body); err != nil {
+		t.Fatalf("unsigned source allowed: %v", err)
+	}
+}
+
+func TestFromConfig_SecretFromEnv(t *testing.T) {
+	t.Setenv("TEST_HMAC_SECRET", "k")
+	registry, err := verify.FromConfig(config.WebhooksConfig{Sources: map[string]config.WebhookSourceConfig{
+		"ci":     {Scheme: "hmac", SecretEnv: "TEST_HMAC_SECRET", Prefix: "sha256="},
+		"broken": {Scheme: "hmac", SecretEnv: "TEST_MISSING_SECRET"},
+	}})
+	if err == nil {
+		t.Fatal("expected an error for the source without secret")
+	}
+
+	body := []byte(`{}`)
+	header := http.Header{}
+	header.Set("X-Webhook-Signature", "sha256="+hmacHex("k", string(body)))
+	if _, err := registry.Verify("ci", header, body); err != nil {
+		t.Fatalf("ci: %v", err)
+	}
+	if _, err := registry.Verify("broken", header, body); err == nil {
+		t.Fatal("source without secret accepted a delivery")
+	}
+}
+
+func TestWebhookController_RecordsRejections(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := webhooks.NewMemoryStore()
+	service := newService(t, store)
+	registry := verify.NewRegistry(0)
+	registry.Register("github", verify.NewGitHub("s3cret"))
+
+	router := gin.New()
+	router.POST("/v1/webhooks", gatewayController.NewWebhookController(service, registry).Handle)
+
+	post := func(signature string) int {
+		body := []byte(`{"ref":"main"}`)
+		req := httptest.NewRequest(http.MethodPost, "/v1/webhooks?source=github", bytes.NewReader(body))
+		req.Header.Set("X-Event-Type", "github.push")
+		req.Header.Set("X-GitHub-Delivery", "d-7")
+		req.Header.Set("X-Hub-Signature-256", signature)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	valid := "sha256=" + hmacHex("s3cret", `{"ref":"main"}`)
+	if code := post(valid); code != http.StatusAccepted {
+		t.Fatalf("signed delivery: status %d", code)
+	}
+	if code := post(valid); code != http.StatusUnauthorized {
+		t.Fatalf("replayed delivery: status %d", code)
+	}
+	if code := post("sha256=00"); code != http.StatusUnauthorized {
+		t.Fatalf("bad signature: status %d", code)
+	}
+
+	page, err := service.ListEvents(t.Context(), webhooks.EventQuery{Status: svc.WebhookEventRejected})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reasons := map[string]bool{}
+	for _, event := range page.Events {
+		reasons[event.Error] = true
+	}
+	if len(page.Events) != 2 || !reasons[verify.ErrReplayed.Error()] || !reasons[verify.ErrInvalidSignature.Error()] {
+		raw, _ := json.Marshal(page.Events)
+		t.Fatalf("rejected events = %s", raw)
+	}
+}
@@ -0,0 +1,312 @@
+package testszmq
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubex-ecosystem/gobe/internal/config"
+	"github.com/kubex-ecosystem/gobe/internal/observers/events"
+	"github.com/kubex-ecosystem/gobe/internal/sockets/zmq"
+)
+
+const loopback = "tcp://127.0.0.1:0"
+
+func newPublisher(t *testing.T) *zmq.Publisher {
+	t.Helper()
+	pub, err := zmq.NewPublisher(loopback, 0)
+	if err != nil {
+		t.Fatalf("NewPublisher: %v", err)
+	}
+	t.Cleanup(func() { pub.Close() })
+	return pub
+}
+
+// newSubscriber connects a subscriber to endpoint, subscribed to prefixes,
+// and returns the channel of its messages.
+func newSubscriber(t *testing.T, endpoint string, prefixes ...string) chan zmq.Message {
+	t.Helper()
+	messages := make(chan zmq.Message, 64)
+	sub := zmq.NewSubscriber(func(msg zmq.Message) { messages <- msg }, 10*time.Millisecond)
+	for _, prefix := range prefixes {
+		sub.Subscribe(prefix)
+	}
+	if err := sub.Connect(endpoint); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() { sub.Close() })
+	return messages
+}
+
+// awaitTopic publishes until a message with topic arrives, since a
+// subscription only applies once the subscriber has joined.
+func awaitTopic(t *testing.T, messages chan zmq.Message, topic string, publish func()) zmq.Message {
+	t.Helper()
+	deadline := time.After(3 * time.Second)
+	tick := time.NewTicker(20 * time.Millisecond)
+	defer tick.Stop()
+	publish()
+	for {
+		select {
+		case msg := <-messages:
+			if msg.Topic == topic {
+				return msg
+			}
+		case <-tick.C:
+			publish()
+		case <-deadline:
+			t.Fatalf("no message on %s", topic)
+		}
+	}
+}
+
+func TestPubSub_FiltersByPrefix(t *testing.T) {
+	pub := newPublisher(t)
+	messages := newSubscriber(t, pub.Endpoint(), "gobe.events.")
+
+	msg := awaitTopic(t, messages, "gobe.events.ready", func() {
+		pub.Publish("gobe.events.ready", []byte(`{"ok":true}`))
+	})
+	if string(msg.Body) != `{"ok":true}` || len(msg.Frames) != 2 {
+		t.Fatalf("message = %+v", msg)
+	}
+
+	pub.Publish("gobe.jobs.finished", []byte("skipped"))
+	pub.Publish("gobe.events.last", []byte("large "+strings.Repeat("x", 1000)))
+	select {
+	case msg := <-messages:
+		if msg.Topic != "gobe.events.last" || len(msg.Body) != 1006 {
+			t.Fatalf("next message = %s (%d bytes)", msg.Topic, len(msg.Body))
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("long message not received")
+	}
+	if stats := pub.Stats(); !slices.Equal(stats.Topics, []string{"gobe.events."}) || stats.Published < 3 {
+		t.Fatalf("stats = %+v", stats)
+	}
+}
+
+func TestPubSub_Reconnects(t *testing.T) {
+	pub := newPublisher(t)
+	endpoint := pub.Endpoint()
+	messages := newSubscriber(t, endpoint, "")
+	awaitTopic(t, messages, "first", func() { pub.Publish("first") })
+
+	pub.Close()
+	restarted, err := zmq.NewPublisher(endpoint, 0)
+	if err != nil {
+		t.Fatalf("rebind %s: %v", endpoint, err)
+	}
+	defer restarted.Close()
+	awaitTopic(t, messages, "second", func() { restarted.Publish("second") })
+}
+
+// rawFrame builds a ZMTP frame as libzmq writes it.
+func rawFrame(flags byte, body []byte) []byte {
+	if len(body) > 255 {
+		header := make([]byte, 9)
+		header[0] = flags | 0x02
+		binary.BigEndian.PutUint64(header[1:], uint64(len(body)))
+		return append(header, body...)
+	}
+	return append([]byte{flags, byte(len(body))}, body...)
+}
+
+func rawCommand(name string, data []byte) []byte {
+	body := append([]byte{byte(len(name))}, name...)
+	return rawFrame(0x04, append(body, data...))
+}
+
+func readRawFrame(t *testing.T, r *bufio.Reader) (byte, []byte) {
+	t.Helper()
+	flags, err := r.ReadByte()
+	if err != nil {
+		t.Fatalf("read flags: %v", err)
+	}
+	size := uint64(0)
+	if flags&0x02 != 0 {
+		var long [8]byte
+		io.ReadFull(r, long[:])
+		size = binary.BigEndian.Uint64(long[:])
+	} else {
+		short, _ := r.ReadByte()
+		size = uint64(short)
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	return flags, body
+}
+
+// TestPublisher_SpeaksZMTP drives the publisher byte by byte the way a
+// libzmq 4.3 SUB socket does: ZMTP 3.1 greeting, READY with metadata and,
+// since the publisher answers with ZMTP 3.0, a subscription message.
+func TestPublisher_SpeaksZMTP(t *testing.T) {
+	pub := newPublisher(t)
+	nc, err := net.Dial("tcp", strings.TrimPrefix(pub.Endpoint(), "tcp://"))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer nc.Close()
+	nc.SetDeadline(time.Now().Add(3 * time.Second))
+	r := bufio.NewReader(nc)
+
+	greeting := make([]byte, 64)
+	greeting[0], greeting[8], greeting[9], greeting[10], greeting[11] = 0xFF, 0x01, 0x7F, 3, 1
+	copy(greeting[12:], "NULL")
+	nc.Write(greeting)
+	nc.Write(rawCommand("READY", []byte("\x0bSocket-Type\x00\x00\x00\x03SUB\x08Identity\x00\x00\x00\x00")))
+
+	peer := make([]byte, 64)
+	if _, err := io.ReadFull(r, peer); err != nil {
+		t.Fatalf("greeting: %v", err)
+	}
+	if peer[0] != 0xFF || peer[9] != 0x7F || peer[10] != 3 || !strings.HasPrefix(string(peer[12:32]), "NULL\x00") {
+		t.Fatalf("greeting = %x", peer)
+	}
+	flags, ready := readRawFrame(t, r)
+	if flags != 0x04 || !strings.HasPrefix(string(ready), "\x05READY") || !strings.Contains(string(ready), "Socket-Type\x00\x00\x00\x03PUB") {
+		t.Fatalf("READY = %x %q", flags, ready)
+	}
+
+	nc.Write(rawFrame(0x00, []byte("\x01alerts.")))
+	deadline := time.Now().Add(2 * time.Second)
+	for !slices.Contains(pub.Stats().Topics, "alerts.") {
+		if time.Now().After(deadline) {
+			t.Fatal("subscription not applied")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	pub.Publish("other", []byte("ignored"))
+	pub.Publish("alerts.disk", []byte(strings.Repeat("y", 300)))
+
+	flags, topic := readRawFrame(t, r)
+	if flags != 0x01 || string(topic) != "alerts.disk" {
+		t.Fatalf("topic frame = %x %q", flags, topic)
+	}
+	flags, payload := readRawFrame(t, r)
+	if flags != 0x02 || len(payload) != 300 {
+		t.Fatalf("body frame = %x (%d bytes)", flags, len(payload))
+	}
+}
+
+func TestOptionsFromConfig(t *testing.T) {
+	cases := []struct {
+		cfg  config.ZMQConfig
+		want string
+	}{
+		{config.ZMQConfig{}, "tcp://*:5555"},
+		{config.ZMQConfig{Address: "127.0.0.1", Port: 6000}, "tcp://127.0.0.1:6000"},
+		{config.ZMQConfig{Address: "tcp://0.0.0.0", Port: 6001}, "tcp://0.0.0.0:6001"},
+		{config.ZMQConfig{Address: "tcp://*:7000", Port: 6002}, "tcp://*:7000"},
+	}
+	for _, c := range cases {
+		if got := zmq.OptionsFromConfig(c.cfg).Endpoint; got != c.want {
+			t.Fatalf("endpoint of %+v = %q, want %q", c.cfg, got, c.want)
+		}
+	}
+	opts := zmq.OptionsFromConfig(config.ZMQConfig{TopicPrefix: "ops.", ReconnectIntervalSeconds: 3})
+	if opts.TopicPrefix != "ops." || opts.ReconnectInterval != 3*time.Second || opts.QueueSize != zmq.DefaultQueueSize {
+		t.Fatalf("options = %+v", opts)
+	}
+}
+
+func newTransport(t *testing.T, connect ...string) *zmq.Transport {
+	t.Helper()
+	opts := zmq.DefaultOptions
+	opts.Endpoint = loopback
+	opts.Connect = connect
+	opts.ReconnectInterval = 10 * time.Millisecond
+	transport, err := zmq.NewTransport(opts)
+	if err != nil {
+		t.Fatalf("NewTransport: %v", err)
+	}
+	t.Cleanup(func() { transport.Close() })
+	return transport
+}
+
+func TestTransport_ForwardsStreamEvents(t *testing.T) {
+	stream := events.NewStream()
+	go stream.Run()
+	t.Cleanup(stream.Close)
+
+	transport := newTransport(t)
+	if err := transport.ForwardStream(stream); err != nil {
+		t.Fatalf("ForwardStream: %v", err)
+	}
+	messages := newSubscriber(t, transport.Endpoint(), "gobe.")
+
+	msg := awaitTopic(t, messages, "gobe.events.task_created", func() {
+		stream.Broadcast(events.Event{Type: "task_created", Data: map[string]string{"title": "deploy"}})
+	})
+	var event events.Event
+	if err := json.Unmarshal(msg.Body, &event); err != nil || event.ID == 0 || event.Type != "task_created" {
+		t.Fatalf("event = %+v (%v)", event, err)
+	}
+
+	awaitTopic(t, messages, "gobe.jobs.finished", func() {
+		stream.Broadcast(events.Event{Type: "mcp_job_finished", Data: map[string]string{"job_id": "1"}})
+	})
+}
+
+func TestTransport_RunsCommands(t *testing.T) {
+	commandSource := newPublisher(t)
+	transport := newTransport(t, commandSource.Endpoint())
+	replies := newSubscriber(t, transport.Endpoint(), "gobe.replies.")
+
+	received := make(chan zmq.Command, 64)
+	transport.HandleCommands(func(ctx context.Context, cmd zmq.Command) (interface{}, error) {
+		if cmd.Tool == "broken" {
+			return nil, errors.New("tool refused")
+		}
+		received <- cmd
+		return map[string]string{"job": "42"}, nil
+	})
+
+	msg := awaitTopic(t, replies, "gobe.replies.accepted", func() {
+		commandSource.Publish("gobe.commands.run", []byte(`{"id":"c1","tool":"system_info","args":{"verbose":true}}`))
+	})
+	var reply zmq.CommandReply
+	json.Unmarshal(msg.Body, &reply)
+	if reply.ID != "c1" || reply.Error != "" {
+		t.Fatalf("reply = %+v", reply)
+	}
+	cmd := <-received
+	if cmd.Tool != "system_info" || cmd.Args["verbose"] != true || cmd.Topic != "gobe.commands.run" {
+		t.Fatalf("command = %+v", cmd)
+	}
+
+	// single-frame "<topic> <json>" commands are understood too
+	awaitTopic(t, replies, "gobe.replies.failed", func() {
+		commandSource.Publish(`gobe.commands.run {"id":"c2","tool":"broken"}`)
+	})
+	awaitTopic(t, replies, "gobe.replies.failed", func() {
+		commandSource.Publish("gobe.commands.run", []byte(`not json`))
+	})
+	if stats := transport.Stats(); stats.CommandsAccepted == 0 || stats.CommandsFailed == 0 {
+		t.Fatalf("stats = %+v", stats)
+	}
+
+	// commands outside the commands topic are not received
+	commandSource.Publish("gobe.events.run", []byte(`{"id":"c3","tool":"system_info"}`))
+	timeout := time.After(100 * time.Millisecond)
+	for {
+		select {
+		case cmd := <-received:
+			if cmd.ID == "c3" {
+				t.Fatalf("received %+v", cmd)
+			}
+		case <-timeout:
+			return
+		}
+	}
+}